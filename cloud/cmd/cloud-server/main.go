@@ -127,6 +127,13 @@ func main() {
 		edgeMQTTSubscriber.SetABACLogHandler(abacLogHandler)
 		utils.Info("ABAC log handler configured for policy ACK processing")
 
+		// 设置策略对账服务（根据Edge策略摘要心跳自动补发漂移策略）
+		policyPublisher := mqtt.NewPolicyPublisher(edgeMQTTClient.GetClient(), policyRepo)
+		policyPublisher.SetCombiningAlgorithm(cfg.ABAC.CombiningAlgorithm)
+		policyReconciler := services.NewPolicyReconciler(policyRepo, policyPublisher)
+		policyReconciler.SetCombiningAlgorithm(cfg.ABAC.CombiningAlgorithm)
+		edgeMQTTSubscriber.SetPolicyReconciler(policyReconciler)
+
 		// 启动订阅
 		if err := edgeMQTTSubscriber.Start(); err != nil {
 			utils.Warn("Failed to start Edge MQTT subscriber",
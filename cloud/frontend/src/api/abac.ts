@@ -9,7 +9,10 @@ import type {
   AccessLogListResponse,
   AccessStats,
   EvaluationRequest,
-  EvaluationResult
+  EvaluationResult,
+  ShadowReport,
+  DeviceTrust,
+  TrustEvent
 } from '@/types/abac'
 import { request } from '@/utils/request'
 
@@ -57,6 +60,20 @@ export function togglePolicy(id: string) {
   return request.post<{ message: string }>(`/abac/policies/${id}/toggle`)
 }
 
+/**
+ * 获取试运行策略影响报告
+ */
+export function getShadowReport(id: string, params?: { since?: string }) {
+  return request.get<{ policy: AccessPolicy; report: ShadowReport }>(`/abac/policies/${id}/shadow-report`, { params })
+}
+
+/**
+ * 试运行策略转为生效
+ */
+export function promotePolicy(id: string) {
+  return request.post<{ message: string }>(`/abac/policies/${id}/promote`)
+}
+
 // ==================== 访问日志API ====================
 
 /**
@@ -73,6 +90,24 @@ export function getAccessStats(params?: { start_time?: string; end_time?: string
   return request.get<{ stats: AccessStats }>('/abac/access-stats', { params })
 }
 
+// ==================== 设备信任度API ====================
+
+/**
+ * 获取设备行为信任度（按分数升序）
+ */
+export function listDeviceTrust(params?: { cabinet_id?: string; max_score?: number }) {
+  return request.get<{ devices: DeviceTrust[]; total: number }>('/abac/device-trust', { params })
+}
+
+/**
+ * 获取设备信任度变化记录
+ */
+export function getDeviceTrustHistory(cabinetId: string, deviceId: string) {
+  return request.get<{ cabinet_id: string; device_id: string; history: TrustEvent[] }>(
+    `/abac/cabinets/${cabinetId}/devices/${deviceId}/trust`
+  )
+}
+
 // ==================== 策略评估API ====================
 
 /**
@@ -35,6 +35,16 @@ import type {
   CreateUserRequest,
   UpdateUserRequest,
   ResetPasswordRequest,
+  FirmwareAdvisory,
+  FirmwareAdvisoryRequest,
+  CabinetCertificate,
+  CabinetRemediation,
+  VulnerabilityReport,
+  FleetQuery,
+  FleetCabinetRanking,
+  FleetVulnerabilityType,
+  FleetRemediationTime,
+  FleetHeatmap,
 } from '@/types/api';
 
 // ========== 配置相关 ==========
@@ -289,6 +299,16 @@ export const commandApi = {
   getStatus(commandId: string): Promise<SuccessResponse<Command>> {
     return request.get(`/commands/${commandId}`);
   },
+
+  // 远程批准配置基线（确认配置变更为授权操作）
+  approveConfigBaseline(cabinetId: string, reason?: string): Promise<SuccessResponse<Command>> {
+    return request.post(`/cabinets/${cabinetId}/config-baseline/approve`, { reason });
+  },
+
+  // 确认或拒绝Edge端等待确认的漏洞处置
+  confirmRemediation(cabinetId: string, remediationId: number, approve = true, reason?: string): Promise<SuccessResponse<Command>> {
+    return request.post(`/cabinets/${cabinetId}/remediations/${remediationId}/confirm`, { approve, reason });
+  },
 };
 
 // ========== 告警相关 ==========
@@ -389,6 +409,83 @@ export const vulnerabilityApi = {
   }>> {
     return request.get(`/cabinets/${cabinetId}/vulnerability/stats`, { params });
   },
+
+  // 获取固件漏洞目录
+  listFirmwareAdvisories(): Promise<SuccessResponse<FirmwareAdvisory[]>> {
+    return request.get('/vulnerability/firmware-advisories');
+  },
+
+  // 新增固件漏洞公告（自动下发到所有储能柜）
+  createFirmwareAdvisory(data: FirmwareAdvisoryRequest): Promise<SuccessResponse<FirmwareAdvisory>> {
+    return request.post('/vulnerability/firmware-advisories', data);
+  },
+
+  // 更新固件漏洞公告
+  updateFirmwareAdvisory(advisoryId: string, data: FirmwareAdvisoryRequest): Promise<SuccessResponse<FirmwareAdvisory>> {
+    return request.put(`/vulnerability/firmware-advisories/${advisoryId}`, data);
+  },
+
+  // 删除固件漏洞公告
+  deleteFirmwareAdvisory(advisoryId: string): Promise<SuccessResponse<null>> {
+    return request.delete(`/vulnerability/firmware-advisories/${advisoryId}`);
+  },
+
+  // 下发固件漏洞目录（不指定储能柜时下发到所有储能柜）
+  distributeFirmwareCatalog(cabinetId?: string): Promise<SuccessResponse<null>> {
+    return request.post('/vulnerability/firmware-advisories/distribute', null, {
+      params: cabinetId ? { cabinet_id: cabinetId } : undefined,
+    });
+  },
+
+  // 获取全网即将到期及已过期的TLS证书（默认30天内）
+  listExpiringCertificates(params?: { days?: number; cabinet_id?: string }): Promise<SuccessResponse<CabinetCertificate[]>> {
+    return request.get('/vulnerability/certificates/expiring', { params });
+  },
+
+  // 获取储能柜漏洞处置记录
+  listRemediations(cabinetId: string, params?: { status?: string; limit?: number }): Promise<SuccessResponse<CabinetRemediation[]>> {
+    return request.get(`/cabinets/${cabinetId}/vulnerability/remediations`, { params });
+  },
+
+  // 生成脆弱性评估报告（不指定储能柜时生成全网报告）
+  generateReport(data: { cabinet_id?: string; format: 'html' | 'markdown'; start_time: string; end_time: string }): Promise<SuccessResponse<VulnerabilityReport>> {
+    return request.post('/vulnerability/reports', data);
+  },
+
+  // 查询归档的评估报告
+  listReports(params?: { cabinet_id?: string; limit?: number }): Promise<SuccessResponse<VulnerabilityReport[]>> {
+    return request.get('/vulnerability/reports', { params });
+  },
+
+  // 下载归档的评估报告
+  downloadReport(reportId: number): Promise<Blob> {
+    return request.get(`/vulnerability/reports/${reportId}/download`, { responseType: 'blob' });
+  },
+
+  // 删除归档的评估报告
+  deleteReport(reportId: number): Promise<SuccessResponse<null>> {
+    return request.delete(`/vulnerability/reports/${reportId}`);
+  },
+
+  // 全网储能柜评分排名（默认按最新评分升序，风险最高在前）
+  getFleetRanking(params?: FleetQuery): Promise<SuccessResponse<FleetCabinetRanking[]>> {
+    return request.get('/vulnerability/fleet/ranking', { params });
+  },
+
+  // 全网最常见的漏洞类型
+  getFleetTopTypes(params?: Pick<FleetQuery, 'days' | 'limit'>): Promise<SuccessResponse<FleetVulnerabilityType[]>> {
+    return request.get('/vulnerability/fleet/top-types', { params });
+  },
+
+  // 按漏洞类型统计平均修复时长
+  getFleetRemediationTimes(params?: Pick<FleetQuery, 'days'>): Promise<SuccessResponse<FleetRemediationTime[]>> {
+    return request.get('/vulnerability/fleet/mttr', { params });
+  },
+
+  // 全网区域及风险热力数据
+  getFleetHeatmap(params?: Pick<FleetQuery, 'days'>): Promise<SuccessResponse<FleetHeatmap>> {
+    return request.get('/vulnerability/fleet/heatmap', { params });
+  },
 };
 
 // ========== 流量检测相关 ==========
@@ -1,16 +1,30 @@
 // ABAC访问控制相关类型定义
 
 // 主体类型
-export type SubjectType = 'user' | 'cabinet' | 'device'
+export type SubjectType = 'user' | 'cabinet' | 'device' | 'group'
+
+// 策略效果
+export type PolicyEffect = 'allow' | 'deny'
+
+// 策略模式：shadow为试运行，只记录假设结果不影响授权
+export type PolicyMode = 'enforce' | 'shadow'
+
+// 策略组合算法
+export type CombiningAlgorithm = 'deny-overrides' | 'permit-overrides' | 'first-applicable'
 
 // 策略条件操作符
-export type ConditionOperator = 'eq' | 'ne' | 'gt' | 'lt' | 'gte' | 'lte' | 'in' | 'contains'
+export type ConditionOperator =
+  | 'eq' | 'ne' | 'gt' | 'lt' | 'gte' | 'lte' | 'in' | 'contains'
+  | 'regex' | 'cidr' | 'prefix' | 'between' | 'exists'
 
-// 策略条件
+// 策略条件：叶子条件使用 attribute/operator/value，条件组使用 all/any/not
 export interface PolicyCondition {
-  attribute: string
-  operator: ConditionOperator
-  value: any
+  attribute?: string
+  operator?: ConditionOperator
+  value?: any
+  all?: PolicyCondition[]
+  any?: PolicyCondition[]
+  not?: PolicyCondition
 }
 
 // 访问策略
@@ -19,20 +33,54 @@ export interface AccessPolicy {
   name: string
   description?: string
   subject_type: SubjectType
+  effect: PolicyEffect
+  mode: PolicyMode
   conditions: PolicyCondition[]
   permissions: string[]
   priority: number
   enabled: boolean
+  version: number
+  hash?: string
   created_at: string
   updated_at: string
 }
 
+// 内容不一致的策略
+export interface StalePolicy {
+  id: string
+  expected_version: number
+  reported_version: number
+}
+
+// 储能柜策略集与Cloud期望策略集的差异
+export interface PolicyDrift {
+  missing: string[]
+  stale: StalePolicy[]
+  extra: string[]
+}
+
+// 储能柜策略同步状态（心跳对账结果）
+export interface PolicySyncStatus {
+  cabinet_id: string
+  in_sync: boolean
+  expected_digest: string
+  reported_digest: string
+  expected_count: number
+  reported_count: number
+  drift?: PolicyDrift
+  last_action: 'none' | 'delta' | 'full_sync' | 'cooldown'
+  last_heartbeat_at: string
+  last_reconciled_at?: string
+}
+
 // 创建策略请求
 export interface CreatePolicyRequest {
   id: string
   name: string
   description?: string
   subject_type: SubjectType
+  effect?: PolicyEffect
+  mode?: PolicyMode
   conditions: PolicyCondition[]
   permissions: string[]
   priority: number
@@ -42,6 +90,8 @@ export interface CreatePolicyRequest {
 export interface UpdatePolicyRequest {
   name?: string
   description?: string
+  effect?: PolicyEffect
+  mode?: PolicyMode
   conditions?: PolicyCondition[]
   permissions?: string[]
   priority?: number
@@ -79,6 +129,33 @@ export interface AccessLog {
   ip_address?: string
   timestamp: string
   attributes?: Record<string, any>
+  shadow?: ShadowDecision[]
+}
+
+// 试运行策略的假设结果
+export interface ShadowDecision {
+  policy_id: string
+  allowed: boolean
+  changed: boolean
+}
+
+// 按主体统计的结果变化
+export interface ShadowSubjectStat {
+  subject_type: string
+  subject_id: string
+  changed: number
+}
+
+// 试运行策略影响报告
+export interface ShadowReport {
+  policy_id: string
+  since?: string
+  evaluated: number
+  changed: number
+  would_deny: number
+  would_allow: number
+  changed_rate: number
+  top_subjects: ShadowSubjectStat[]
 }
 
 // 访问日志筛选
@@ -134,12 +211,41 @@ export interface AccessStats {
   deny_reasons: DenyReasonStat[]
 }
 
+// 上下文属性（条件中以 context. 前缀引用）
+export interface ContextAttributes {
+  time_of_day?: string
+  hour?: number
+  day_of_week?: string
+  source_ip?: string
+  cabinet_id?: string
+  risk_level?: string
+  vulnerability_score?: number
+  active_alerts?: number
+  critical_alerts?: number
+  license_status?: string
+  state_unavailable?: boolean
+}
+
 // 策略评估请求
 export interface EvaluationRequest {
   subject_type: SubjectType
   attributes: Record<string, any>
   resource: string
   action: string
+  algorithm?: CombiningAlgorithm
+  context?: ContextAttributes
+}
+
+// 单条策略的评估说明
+export interface PolicyDecision {
+  policy_id: string
+  policy_name: string
+  effect: PolicyEffect
+  priority: number
+  matched: boolean
+  applicable: boolean
+  decisive: boolean
+  reason: string
 }
 
 // 策略评估结果
@@ -149,4 +255,35 @@ export interface EvaluationResult {
   trust_score: number
   permissions: string[]
   reason: string
+  algorithm: CombiningAlgorithm
+  decisions: PolicyDecision[]
+  shadow: ShadowDecision[]
+}
+
+// 设备行为信任度信号
+export type TrustSignal = 'auth_failure' | 'access_denied' | 'quality_anomaly' | 'heartbeat_missed' | 'firmware'
+
+// Edge上报的设备行为信任度
+export interface DeviceTrust {
+  cabinet_id: string
+  device_id: string
+  score: number
+  penalty: number
+  firmware_penalty: number
+  firmware_version?: string
+  signals: Partial<Record<TrustSignal, number>>
+  last_signal?: TrustSignal
+  last_signal_at?: string
+  updated_at: string
+}
+
+// 设备信任度变化记录
+export interface TrustEvent {
+  id: number
+  cabinet_id: string
+  device_id: string
+  signal: TrustSignal
+  delta: number
+  score: number
+  timestamp: string
 }
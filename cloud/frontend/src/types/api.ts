@@ -249,6 +249,156 @@ export interface HealthScore {
   details?: Record<string, any>;
 }
 
+// 固件漏洞公告类型
+export interface FirmwareAdvisory {
+  advisory_id: string;
+  manufacturer: string; // 为空时匹配所有制造商
+  model: string; // 为空时匹配所有型号
+  min_version: string; // 受影响的最低版本（含）
+  max_version: string; // 受影响的最高版本（含）
+  fixed_version: string;
+  severity: 'low' | 'medium' | 'high' | 'critical';
+  title: string;
+  description: string;
+  remediation: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// 创建/更新固件漏洞公告请求
+export type FirmwareAdvisoryRequest = Omit<FirmwareAdvisory, 'created_at' | 'updated_at'>;
+
+// 储能柜TLS证书状态
+export interface CabinetCertificate {
+  cabinet_id: string;
+  target: string; // edge_https/edge_mqtt_tls/mqtt_broker/cloud_endpoint等
+  endpoint: string;
+  subject: string;
+  issuer: string;
+  serial_number: string;
+  not_after?: string;
+  days_to_expiry: number; // 负数表示已过期
+  key_algorithm: string;
+  key_size: number;
+  signature_algorithm: string;
+  chain_valid: boolean;
+  chain_error?: string;
+  tls_version?: string;
+  cipher_suite?: string;
+  legacy_protocols?: string[];
+  error?: string;
+  checked_at: string;
+  updated_at: string;
+}
+
+// 储能柜漏洞处置记录（Edge处置剧本上报）
+export interface CabinetRemediation {
+  cabinet_id: string;
+  remediation_id: number; // Edge本地记录ID
+  event_type: string;
+  severity: string;
+  device_id?: string;
+  action: string; // block_ip/disable_device/cleanup_storage/rotate_logs/refresh_license
+  mode: 'auto' | 'confirm';
+  status: 'pending' | 'succeeded' | 'failed' | 'rejected';
+  result?: string;
+  confirmed_by?: string;
+  created_at: string;
+  executed_at?: string;
+  updated_at: string;
+}
+
+// 归档的脆弱性评估报告
+export interface VulnerabilityReport {
+  id: number;
+  cabinet_id: string; // 为空表示全网报告
+  format: 'html' | 'markdown';
+  start_time: string;
+  end_time: string;
+  title: string;
+  file_name: string;
+  size_bytes: number;
+  sha256: string;
+  generated_by: string;
+  created_at: string;
+}
+
+// 全网脆弱性看板查询参数
+export interface FleetQuery {
+  days?: number; // 默认30，最大365
+  limit?: number;
+  sort?: 'score' | 'trend' | 'delta';
+  order?: 'asc' | 'desc';
+}
+
+export interface FleetCabinetRanking {
+  cabinet_id: string;
+  name: string;
+  location?: string;
+  latitude?: number;
+  longitude?: number;
+  latest_score: number;
+  risk_level: string;
+  last_assessed: string;
+  start_score: number;
+  score_delta: number;
+  trend_per_day: number; // 分/天，负值表示恶化
+  avg_score: number;
+  assessments: number;
+}
+
+export interface FleetVulnerabilityType {
+  type: string;
+  category: string;
+  max_severity: string;
+  affected_cabinets: number;
+  affected_ratio: number;
+  open_cabinets: number;
+  occurrences: number;
+}
+
+export interface FleetRemediationTime {
+  type: string;
+  exposures: number;
+  resolved: number;
+  open: number;
+  mean_seconds?: number;
+  median_seconds?: number;
+  max_seconds?: number;
+  actions: number;
+  actions_succeeded: number;
+  mean_action_delay_seconds?: number;
+}
+
+export interface FleetHeatmapPoint {
+  cabinet_id: string;
+  name: string;
+  location?: string;
+  latitude: number;
+  longitude: number;
+  score: number;
+  risk_level: string;
+  vulnerabilities: number;
+  weight: number; // 100 - 评分
+  assessed_at: string;
+}
+
+export interface FleetHeatmapRegion {
+  region: string;
+  cabinets: number;
+  avg_score: number;
+  min_score: number;
+  risk_counts: Record<string, number>;
+}
+
+export interface FleetHeatmap {
+  points: FleetHeatmapPoint[];
+  regions: FleetHeatmapRegion[];
+  unlocated: number;
+  risk_counts: Record<string, number>;
+  generated_at: string;
+}
+
 // 前端配置类型
 export interface FrontendConfig {
   api_base_url: string;
@@ -47,6 +47,7 @@ const (
 	SubjectTypeUser    SubjectType = "user"
 	SubjectTypeCabinet SubjectType = "cabinet"
 	SubjectTypeDevice  SubjectType = "device"
+	SubjectTypeGroup   SubjectType = "group" // Edge端群组成员认证主体（如共享诊断工具）
 )
 
 // Attributes 通用属性接口
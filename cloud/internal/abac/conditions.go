@@ -0,0 +1,162 @@
+package abac
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sync"
+)
+
+// 条件组最大嵌套深度
+const maxConditionDepth = 8
+
+// 支持的叶子条件操作符
+var conditionOperators = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "lt": true, "gte": true, "lte": true,
+	"in": true, "contains": true, "regex": true, "cidr": true, "prefix": true,
+	"between": true, "exists": true,
+}
+
+// regexCache 已编译的正则表达式缓存（pattern -> *regexp.Regexp）
+var regexCache sync.Map
+
+// IsGroup 是否为条件组（all/any/not）
+func (c *PolicyCondition) IsGroup() bool {
+	return c.All != nil || c.Any != nil || c.Not != nil
+}
+
+// ValidateConditions 校验策略条件：操作符、正则、CIDR、between区间及嵌套深度
+func ValidateConditions(conditions []PolicyCondition) error {
+	for i := range conditions {
+		if err := validateCondition(&conditions[i], 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateCondition(cond *PolicyCondition, depth int) error {
+	if depth > maxConditionDepth {
+		return fmt.Errorf("条件嵌套超过%d层", maxConditionDepth)
+	}
+
+	if cond.IsGroup() {
+		if cond.Attribute != "" || cond.Operator != "" {
+			return fmt.Errorf("条件组不能同时包含attribute/operator")
+		}
+		for i := range cond.All {
+			if err := validateCondition(&cond.All[i], depth+1); err != nil {
+				return err
+			}
+		}
+		for i := range cond.Any {
+			if err := validateCondition(&cond.Any[i], depth+1); err != nil {
+				return err
+			}
+		}
+		if cond.Not != nil {
+			return validateCondition(cond.Not, depth+1)
+		}
+		return nil
+	}
+
+	if cond.Attribute == "" {
+		return fmt.Errorf("条件缺少attribute")
+	}
+	if !conditionOperators[cond.Operator] {
+		return fmt.Errorf("不支持的操作符: %s", cond.Operator)
+	}
+
+	switch cond.Operator {
+	case "regex":
+		pattern, ok := cond.Value.(string)
+		if !ok {
+			return fmt.Errorf("regex条件的值必须是字符串")
+		}
+		if _, err := compileRegex(pattern); err != nil {
+			return fmt.Errorf("无效的正则表达式 %q: %w", pattern, err)
+		}
+	case "cidr":
+		nets, ok := parseCIDRs(cond.Value)
+		if !ok || len(nets) == 0 {
+			return fmt.Errorf("cidr条件的值必须是CIDR字符串或CIDR列表")
+		}
+	case "between":
+		if _, _, ok := new(Evaluator).betweenBounds(cond.Value); !ok {
+			return fmt.Errorf("between条件的值必须是[最小值, 最大值]")
+		}
+	}
+	return nil
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, re)
+	return re, nil
+}
+
+// parseCIDRs 解析单个CIDR字符串或CIDR列表
+func parseCIDRs(v interface{}) ([]*net.IPNet, bool) {
+	var values []interface{}
+	switch val := v.(type) {
+	case string:
+		values = []interface{}{val}
+	case []interface{}:
+		values = val
+	case []string:
+		for _, s := range val {
+			values = append(values, s)
+		}
+	default:
+		return nil, false
+	}
+
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, item := range values {
+		s, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, false
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, true
+}
+
+// betweenBounds 解析 [min, max] 区间
+func (e *Evaluator) betweenBounds(v interface{}) (float64, float64, bool) {
+	var bounds []interface{}
+	switch val := v.(type) {
+	case []interface{}:
+		bounds = val
+	case []float64:
+		for _, f := range val {
+			bounds = append(bounds, f)
+		}
+	case []int:
+		for _, i := range val {
+			bounds = append(bounds, i)
+		}
+	default:
+		return 0, 0, false
+	}
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+
+	min, minOK := e.toFloat64(bounds[0])
+	max, maxOK := e.toFloat64(bounds[1])
+	if !minOK || !maxOK || min > max {
+		return 0, 0, false
+	}
+	return min, max, true
+}
@@ -0,0 +1,178 @@
+package abac
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/repository"
+)
+
+// ContextAttributePrefix 条件中引用上下文属性的前缀，如 context.critical_alerts
+const ContextAttributePrefix = "context."
+
+// ContextAttributes 环境/上下文属性
+// 与主体无关，描述请求发生时的时间、来源及目标储能柜当前状态
+type ContextAttributes struct {
+	TimeOfDay          string  `json:"time_of_day"`         // HH:MM（本地时间）
+	Hour               int     `json:"hour"`                // 0-23
+	DayOfWeek          string  `json:"day_of_week"`         // monday ... sunday
+	SourceIP           string  `json:"source_ip"`           // 请求来源IP
+	CabinetID          string  `json:"cabinet_id"`          // 目标储能柜（储能柜主体或路由参数cabinet_id）
+	RiskLevel          string  `json:"risk_level"`          // 最新脆弱性评估风险等级
+	VulnerabilityScore float64 `json:"vulnerability_score"` // 最新脆弱性评估综合评分
+	ActiveAlerts       int     `json:"active_alerts"`       // 未解决告警数
+	CriticalAlerts     int     `json:"critical_alerts"`     // 未解决的critical告警数
+	LicenseStatus      string  `json:"license_status"`      // valid, expired, revoked, unlicensed
+	StateUnavailable   bool    `json:"state_unavailable"`   // 储能柜状态获取失败，以上状态属性未知
+}
+
+// cabinetStateAttributes 由储能柜状态填充的上下文属性
+var cabinetStateAttributes = map[string]bool{
+	"risk_level":          true,
+	"vulnerability_score": true,
+	"active_alerts":       true,
+	"critical_alerts":     true,
+	"license_status":      true,
+}
+
+// CabinetState 储能柜当前状态，由ContextProvider提供
+type CabinetState struct {
+	RiskLevel          string
+	VulnerabilityScore float64
+	ActiveAlerts       int
+	CriticalAlerts     int
+	LicenseStatus      string
+}
+
+// ContextProvider 储能柜状态提供者
+type ContextProvider interface {
+	CabinetState(ctx context.Context, cabinetID string) (*CabinetState, error)
+}
+
+// NewContextAttributes 根据当前时间和来源IP构建上下文属性
+func NewContextAttributes(now time.Time, sourceIP string) *ContextAttributes {
+	return &ContextAttributes{
+		TimeOfDay: now.Format("15:04"),
+		Hour:      now.Hour(),
+		DayOfWeek: strings.ToLower(now.Weekday().String()),
+		SourceIP:  sourceIP,
+	}
+}
+
+// ApplyCabinetState 填充储能柜状态
+func (c *ContextAttributes) ApplyCabinetState(state *CabinetState) {
+	if state == nil {
+		return
+	}
+	c.RiskLevel = state.RiskLevel
+	c.VulnerabilityScore = state.VulnerabilityScore
+	c.ActiveAlerts = state.ActiveAlerts
+	c.CriticalAlerts = state.CriticalAlerts
+	c.LicenseStatus = state.LicenseStatus
+}
+
+// referencesCabinetState 判断条件（含嵌套组合）是否引用储能柜状态属性
+func referencesCabinetState(conditions []PolicyCondition) bool {
+	for i := range conditions {
+		cond := &conditions[i]
+		if name, found := strings.CutPrefix(cond.Attribute, ContextAttributePrefix); found && cabinetStateAttributes[name] {
+			return true
+		}
+		if referencesCabinetState(cond.All) || referencesCabinetState(cond.Any) {
+			return true
+		}
+		if cond.Not != nil && referencesCabinetState([]PolicyCondition{*cond.Not}) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextualAttributes 将上下文属性附加到主体属性上，供条件匹配使用
+type contextualAttributes struct {
+	Attributes
+	env *ContextAttributes
+}
+
+// 储能柜状态缓存有效期，避免每个请求都查询告警、评估和许可证
+const cabinetStateCacheTTL = 10 * time.Second
+
+type cachedCabinetState struct {
+	state    *CabinetState
+	loadedAt time.Time
+}
+
+// RepositoryContextProvider 基于数据库的储能柜状态提供者
+type RepositoryContextProvider struct {
+	vulnRepo    repository.VulnerabilityRepository
+	alertRepo   repository.AlertRepository
+	licenseRepo repository.LicenseRepository
+
+	mu    sync.Mutex
+	cache map[string]cachedCabinetState
+}
+
+// NewRepositoryContextProvider 创建储能柜状态提供者，各repository均可为nil
+func NewRepositoryContextProvider(vulnRepo repository.VulnerabilityRepository, alertRepo repository.AlertRepository, licenseRepo repository.LicenseRepository) *RepositoryContextProvider {
+	return &RepositoryContextProvider{
+		vulnRepo:    vulnRepo,
+		alertRepo:   alertRepo,
+		licenseRepo: licenseRepo,
+		cache:       make(map[string]cachedCabinetState),
+	}
+}
+
+// CabinetState 获取储能柜最新风险等级、未解决告警数和许可证状态
+func (p *RepositoryContextProvider) CabinetState(ctx context.Context, cabinetID string) (*CabinetState, error) {
+	p.mu.Lock()
+	if cached, ok := p.cache[cabinetID]; ok && time.Since(cached.loadedAt) < cabinetStateCacheTTL {
+		p.mu.Unlock()
+		return cached.state, nil
+	}
+	p.mu.Unlock()
+
+	state := &CabinetState{}
+	if p.vulnRepo != nil {
+		if assessment, err := p.vulnRepo.GetLatestByCabinetID(ctx, cabinetID); err == nil && assessment != nil {
+			state.RiskLevel = assessment.RiskLevel
+			state.VulnerabilityScore = assessment.OverallScore
+		}
+	}
+	if p.alertRepo != nil {
+		alerts, err := p.alertRepo.GetActiveByCabinet(ctx, cabinetID)
+		if err != nil {
+			return nil, err
+		}
+		state.ActiveAlerts = len(alerts)
+		for _, alert := range alerts {
+			if alert.Severity == "critical" {
+				state.CriticalAlerts++
+			}
+		}
+	}
+	if p.licenseRepo != nil {
+		license, err := p.licenseRepo.GetByCabinetID(ctx, cabinetID)
+		state.LicenseStatus = licenseStatus(license, err)
+	}
+
+	p.mu.Lock()
+	p.cache[cabinetID] = cachedCabinetState{state: state, loadedAt: time.Now()}
+	p.mu.Unlock()
+	return state, nil
+}
+
+func licenseStatus(license *models.License, err error) string {
+	if err != nil || license == nil {
+		return "unlicensed"
+	}
+	if license.Status == "revoked" {
+		return "revoked"
+	}
+	if license.Status == "expired" || time.Now().After(license.ExpiresAt) {
+		return "expired"
+	}
+	return "valid"
+}
@@ -0,0 +1,43 @@
+package abac
+
+import "time"
+
+// DeviceTrust Edge上报的设备行为信任度
+// 由认证失败、访问拒绝、数据质量异常、心跳缺失及固件版本驱动，扣分随时间衰减
+type DeviceTrust struct {
+	CabinetID       string           `json:"cabinet_id" db:"cabinet_id"`
+	DeviceID        string           `json:"device_id" db:"device_id"`
+	Score           float64          `json:"score" db:"score"`                       // 行为信任度 (0-100)
+	Penalty         float64          `json:"penalty" db:"penalty"`                   // 行为扣分（随时间衰减）
+	FirmwarePenalty float64          `json:"firmware_penalty" db:"firmware_penalty"` // 固件扣分
+	FirmwareVersion string           `json:"firmware_version,omitempty" db:"firmware_version"`
+	Signals         map[string]int64 `json:"signals" db:"signals"` // JSONB, 各信号累计次数
+	LastSignal      string           `json:"last_signal,omitempty" db:"last_signal"`
+	LastSignalAt    *time.Time       `json:"last_signal_at,omitempty" db:"last_signal_at"`
+	UpdatedAt       time.Time        `json:"updated_at" db:"updated_at"`
+}
+
+// TrustEvent 设备信任度变化记录
+type TrustEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	CabinetID string    `json:"cabinet_id" db:"cabinet_id"`
+	DeviceID  string    `json:"device_id" db:"device_id"`
+	Signal    string    `json:"signal" db:"signal"` // auth_failure, access_denied, quality_anomaly, heartbeat_missed, firmware
+	Delta     float64   `json:"delta" db:"delta"`   // 本次分数变化（负数为扣分）
+	Score     float64   `json:"score" db:"score"`   // 变化后的行为信任度
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+}
+
+// TrustReport Edge定期上报的设备信任度
+type TrustReport struct {
+	CabinetID string         `json:"cabinet_id"`
+	Devices   []*DeviceTrust `json:"devices"`
+	Events    []*TrustEvent  `json:"events"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// DeviceTrustFilter 设备信任度查询过滤器
+type DeviceTrustFilter struct {
+	CabinetID string   `form:"cabinet_id"`
+	MaxScore  *float64 `form:"max_score"` // 仅返回分数不高于该值的设备
+}
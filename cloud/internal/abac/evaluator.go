@@ -2,7 +2,9 @@ package abac
 
 import (
 	"fmt"
+	"net"
 	"reflect"
+	"sort"
 	"strings"
 )
 
@@ -18,79 +20,216 @@ func NewEvaluator() *Evaluator {
 	}
 }
 
+// 组合算法，决定多条策略同时适用时的最终结果
+const (
+	AlgorithmDenyOverrides   = "deny-overrides"   // 任一拒绝策略适用即拒绝
+	AlgorithmPermitOverrides = "permit-overrides" // 任一允许策略适用即允许
+	AlgorithmFirstApplicable = "first-applicable" // 按优先级取第一条适用策略
+
+	DefaultCombiningAlgorithm = AlgorithmDenyOverrides
+)
+
+// ValidCombiningAlgorithm 判断组合算法是否受支持
+func ValidCombiningAlgorithm(algorithm string) bool {
+	switch algorithm {
+	case AlgorithmDenyOverrides, AlgorithmPermitOverrides, AlgorithmFirstApplicable:
+		return true
+	}
+	return false
+}
+
 // EvaluateRequest 评估访问请求
 type EvaluateRequest struct {
-	SubjectAttrs Attributes // 主体属性
-	Resource     string     // 请求的资源
-	Action       string     // 请求的动作 (GET, POST, PUT, DELETE)
+	SubjectAttrs Attributes      // 主体属性
+	Resource     string          // 请求的资源
+	Action       string          // 请求的动作 (GET, POST, PUT, DELETE)
 	Policies     []*AccessPolicy // 策略列表
+	Algorithm    string          // 组合算法，为空时使用 deny-overrides
+	Context      *ContextAttributes // 上下文属性（可选）
 }
 
 // EvaluateResponse 评估响应
 type EvaluateResponse struct {
-	Allowed       bool          // 是否允许访问
-	MatchedPolicy *AccessPolicy // 匹配的策略
-	TrustScore    float64       // 信任度分数
-	Permissions   []string      // 授予的权限
-	Reason        string        // 拒绝原因
+	Allowed       bool             // 是否允许访问
+	MatchedPolicy *AccessPolicy    // 决定最终结果的策略
+	TrustScore    float64          // 信任度分数
+	Permissions   []string         // 授予的权限
+	Reason        string           // 拒绝原因
+	Algorithm     string           // 实际使用的组合算法
+	Decisions     []PolicyDecision // 每条参与评估的策略的判定说明
+	Shadow        []ShadowDecision // 试运行策略的假设结果，不影响Allowed
+}
+
+// ShadowDecision 试运行策略启用后的假设结果
+type ShadowDecision struct {
+	PolicyID string `json:"policy_id"`
+	Allowed  bool   `json:"allowed"` // 该策略转为enforce后的结果
+	Changed  bool   `json:"changed"` // 是否与实际结果不同
+}
+
+// PolicyDecision 单条策略的评估说明
+type PolicyDecision struct {
+	PolicyID   string `json:"policy_id"`
+	PolicyName string `json:"policy_name"`
+	Effect     string `json:"effect"`
+	Priority   int    `json:"priority"`
+	Matched    bool   `json:"matched"`    // 条件是否匹配
+	Applicable bool   `json:"applicable"` // 条件匹配且覆盖所需权限
+	Decisive   bool   `json:"decisive"`   // 是否为最终决定依据
+	Reason     string `json:"reason"`
 }
 
 // Evaluate 执行策略评估
+// 试运行（shadow）策略不参与实际决策，而是逐条与生效策略一起重新评估，记录假设结果
 func (e *Evaluator) Evaluate(req *EvaluateRequest) *EvaluateResponse {
+	enforced := make([]*AccessPolicy, 0, len(req.Policies))
+	var shadows []*AccessPolicy
+	for _, policy := range req.Policies {
+		if policy.IsShadow() {
+			if policy.Enabled && string(req.SubjectAttrs.GetType()) == policy.SubjectType {
+				shadows = append(shadows, policy)
+			}
+			continue
+		}
+		enforced = append(enforced, policy)
+	}
+
+	resp := e.evaluate(req, enforced)
+	for _, shadow := range shadows {
+		candidates := append(append(make([]*AccessPolicy, 0, len(enforced)+1), enforced...), shadow)
+		would := e.evaluate(req, candidates)
+		resp.Shadow = append(resp.Shadow, ShadowDecision{
+			PolicyID: shadow.ID,
+			Allowed:  would.Allowed,
+			Changed:  would.Allowed != resp.Allowed,
+		})
+	}
+	return resp
+}
+
+// evaluate 使用给定策略集执行一次评估
+func (e *Evaluator) evaluate(req *EvaluateRequest, candidates []*AccessPolicy) *EvaluateResponse {
 	resp := &EvaluateResponse{
 		Allowed:     false,
 		Permissions: []string{},
+		Algorithm:   req.Algorithm,
+		Decisions:   []PolicyDecision{},
+	}
+	if !ValidCombiningAlgorithm(resp.Algorithm) {
+		resp.Algorithm = DefaultCombiningAlgorithm
 	}
 
 	// 1. 计算信任度
 	resp.TrustScore = e.scorer.CalculateTrustScore(req.SubjectAttrs)
 
-	// 2. 按优先级筛选并评估策略
-	var matchedPolicy *AccessPolicy
-	for _, policy := range req.Policies {
-		// 只评估启用的策略且主体类型匹配的策略
-		if !policy.Enabled || string(req.SubjectAttrs.GetType()) != policy.SubjectType {
-			continue
-		}
-
-		// 检查策略条件
-		if e.matchConditions(req.SubjectAttrs, policy.Conditions) {
-			matchedPolicy = policy
-			break // 找到第一个匹配的策略就停止 (策略已按优先级排序)
+	// 2. 按优先级逐条评估同类主体的启用策略
+	policies := make([]*AccessPolicy, 0, len(candidates))
+	for _, policy := range candidates {
+		if policy.Enabled && string(req.SubjectAttrs.GetType()) == policy.SubjectType {
+			policies = append(policies, policy)
 		}
 	}
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policies[i].Priority > policies[j].Priority
+	})
 
-	// 3. 没有匹配的策略
-	if matchedPolicy == nil {
-		resp.Reason = "无匹配的访问策略"
-		return resp
+	var subject Attributes = req.SubjectAttrs
+	if req.Context != nil {
+		subject = &contextualAttributes{Attributes: req.SubjectAttrs, env: req.Context}
 	}
 
-	// 4. 检查权限
-	resp.MatchedPolicy = matchedPolicy
-	resp.Permissions = matchedPolicy.Permissions
+	requiredPerm := e.makePermission(req.Resource, req.Action)
+	firstAllow, firstDeny, firstApplicable := -1, -1, -1
+	anyMatched := false
+	for _, policy := range policies {
+		d := PolicyDecision{
+			PolicyID:   policy.ID,
+			PolicyName: policy.Name,
+			Effect:     policy.GetEffect(),
+			Priority:   policy.Priority,
+		}
+		matched := e.matchConditions(subject, policy.Conditions)
+		if req.Context != nil && req.Context.StateUnavailable && referencesCabinetState(policy.Conditions) {
+			// 储能柜状态未知时依赖状态的条件无法判定：拒绝策略按匹配处理，允许策略按不匹配处理
+			matched = d.Effect == EffectDeny
+		}
+		switch {
+		case !matched:
+			d.Reason = "条件不匹配"
+		case !e.coversPermission(policy.Permissions, requiredPerm):
+			d.Matched = true
+			d.Reason = fmt.Sprintf("条件匹配，但未覆盖权限: %s", requiredPerm)
+		default:
+			d.Matched = true
+			d.Applicable = true
+			d.Reason = "策略适用"
+		}
+		anyMatched = anyMatched || d.Matched
+
+		idx := len(resp.Decisions)
+		if d.Applicable {
+			if firstApplicable < 0 {
+				firstApplicable = idx
+			}
+			if d.Effect == EffectDeny && firstDeny < 0 {
+				firstDeny = idx
+			}
+			if d.Effect == EffectAllow && firstAllow < 0 {
+				firstAllow = idx
+			}
+		}
+		resp.Decisions = append(resp.Decisions, d)
+	}
 
-	// 检查是否有通配符权限
-	for _, perm := range matchedPolicy.Permissions {
-		if perm == "*" {
-			resp.Allowed = true
-			return resp
+	// 3. 按组合算法选出决定性策略
+	decisive := -1
+	switch resp.Algorithm {
+	case AlgorithmPermitOverrides:
+		decisive = firstAllow
+		if decisive < 0 {
+			decisive = firstDeny
+		}
+	case AlgorithmFirstApplicable:
+		decisive = firstApplicable
+	default:
+		decisive = firstDeny
+		if decisive < 0 {
+			decisive = firstAllow
 		}
 	}
 
-	// 检查资源和动作是否在权限列表中
-	requiredPerm := e.makePermission(req.Resource, req.Action)
-	for _, perm := range matchedPolicy.Permissions {
-		if e.matchPermission(perm, requiredPerm) {
-			resp.Allowed = true
-			return resp
+	// 4. 没有适用的策略
+	if decisive < 0 {
+		if anyMatched {
+			resp.Reason = fmt.Sprintf("权限不足，需要权限: %s", requiredPerm)
+		} else {
+			resp.Reason = "无匹配的访问策略"
 		}
+		return resp
+	}
+
+	resp.Decisions[decisive].Decisive = true
+	resp.MatchedPolicy = policies[decisive]
+	if resp.MatchedPolicy.GetEffect() == EffectDeny {
+		resp.Reason = fmt.Sprintf("被拒绝策略 %s 禁止: %s", resp.MatchedPolicy.Name, requiredPerm)
+		return resp
 	}
 
-	resp.Reason = fmt.Sprintf("权限不足，需要权限: %s", requiredPerm)
+	resp.Allowed = true
+	resp.Permissions = resp.MatchedPolicy.Permissions
 	return resp
 }
 
+// coversPermission 判断权限列表是否覆盖所需权限
+func (e *Evaluator) coversPermission(permissions []string, required string) bool {
+	for _, perm := range permissions {
+		if e.matchPermission(perm, required) {
+			return true
+		}
+	}
+	return false
+}
+
 // matchConditions 匹配策略条件
 func (e *Evaluator) matchConditions(attrs Attributes, conditions []PolicyCondition) bool {
 	// 所有条件都必须满足 (AND逻辑)
@@ -102,10 +241,17 @@ func (e *Evaluator) matchConditions(attrs Attributes, conditions []PolicyConditi
 	return true
 }
 
-// matchCondition 匹配单个条件
+// matchCondition 匹配单个条件或条件组
 func (e *Evaluator) matchCondition(attrs Attributes, cond PolicyCondition) bool {
+	if cond.IsGroup() {
+		return e.matchGroup(attrs, cond)
+	}
+
 	// 获取属性值
 	attrValue := e.getAttributeValue(attrs, cond.Attribute)
+	if cond.Operator == "exists" {
+		return e.exists(attrValue, cond.Value)
+	}
 	if attrValue == nil {
 		return false
 	}
@@ -128,27 +274,80 @@ func (e *Evaluator) matchCondition(attrs Attributes, cond PolicyCondition) bool
 		return e.in(attrValue, cond.Value)
 	case "contains":
 		return e.contains(attrValue, cond.Value)
+	case "regex":
+		return e.regex(attrValue, cond.Value)
+	case "cidr":
+		return e.cidr(attrValue, cond.Value)
+	case "prefix":
+		return e.prefix(attrValue, cond.Value)
+	case "between":
+		return e.between(attrValue, cond.Value)
 	default:
 		return false
 	}
 }
 
-// getAttributeValue 获取属性值 (支持嵌套属性)
+// matchGroup 匹配条件组，同时设置多个时取AND
+func (e *Evaluator) matchGroup(attrs Attributes, cond PolicyCondition) bool {
+	if cond.All != nil && !e.matchConditions(attrs, cond.All) {
+		return false
+	}
+	if cond.Any != nil {
+		matched := false
+		for _, c := range cond.Any {
+			if e.matchCondition(attrs, c) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if cond.Not != nil && e.matchCondition(attrs, *cond.Not) {
+		return false
+	}
+	return true
+}
+
+// getAttributeValue 获取属性值 (context. 前缀访问上下文属性)
 func (e *Evaluator) getAttributeValue(attrs Attributes, attrName string) interface{} {
+	if ca, ok := attrs.(*contextualAttributes); ok {
+		attrs = ca.Attributes
+		if name, found := strings.CutPrefix(attrName, ContextAttributePrefix); found {
+			if ca.env.StateUnavailable && cabinetStateAttributes[name] {
+				return nil
+			}
+			return e.fieldValue(ca.env, name)
+		}
+	}
+	if strings.HasPrefix(attrName, ContextAttributePrefix) {
+		return nil
+	}
+
 	// 特殊属性：trust_score
 	if attrName == "trust_score" {
 		return attrs.GetTrustScore()
 	}
 
-	// 使用反射获取结构体字段值
-	val := reflect.ValueOf(attrs)
+	return e.fieldValue(attrs, attrName)
+}
+
+// fieldValue 使用反射获取结构体字段值
+func (e *Evaluator) fieldValue(v interface{}, attrName string) interface{} {
+	val := reflect.ValueOf(v)
 	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
 		val = val.Elem()
 	}
 
-	// 将snake_case转换为PascalCase
-	fieldName := e.snakeToPascal(attrName)
-	field := val.FieldByName(fieldName)
+	// 优先按json标签匹配（device_id -> DeviceID），否则将snake_case转换为PascalCase
+	field := e.fieldByJSONTag(val, attrName)
+	if !field.IsValid() {
+		field = val.FieldByName(e.snakeToPascal(attrName))
+	}
 	if !field.IsValid() {
 		return nil
 	}
@@ -213,6 +412,74 @@ func (e *Evaluator) contains(a, b interface{}) bool {
 	return strings.Contains(aStr, bStr)
 }
 
+// exists 属性存在且非零值；value为false时取反
+func (e *Evaluator) exists(a, b interface{}) bool {
+	present := a != nil && !reflect.ValueOf(a).IsZero()
+	if want, ok := b.(bool); ok && !want {
+		return !present
+	}
+	return present
+}
+
+func (e *Evaluator) regex(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := b.(string)
+	if !ok {
+		return false
+	}
+	re, err := compileRegex(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(aStr)
+}
+
+func (e *Evaluator) cidr(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(aStr)
+	if ip == nil {
+		return false
+	}
+	nets, ok := parseCIDRs(b)
+	if !ok {
+		return false
+	}
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Evaluator) prefix(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bStr, ok := b.(string)
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(aStr, bStr)
+}
+
+// between 闭区间 [min, max]
+func (e *Evaluator) between(a, b interface{}) bool {
+	av, ok := e.toFloat64(a)
+	if !ok {
+		return false
+	}
+	min, max, ok := e.betweenBounds(b)
+	return ok && av >= min && av <= max
+}
+
 // toFloat64 尝试将值转换为float64
 func (e *Evaluator) toFloat64(v interface{}) (float64, bool) {
 	switch val := v.(type) {
@@ -226,11 +493,33 @@ func (e *Evaluator) toFloat64(v interface{}) (float64, bool) {
 		return float64(val), true
 	case int64:
 		return float64(val), true
+	case string:
+		// 尝试解析字符串数字（与Edge端评估器一致）
+		var f float64
+		if _, err := fmt.Sscanf(val, "%f", &f); err == nil {
+			return f, true
+		}
+		return 0, false
 	default:
 		return 0, false
 	}
 }
 
+// fieldByJSONTag 按json标签查找结构体字段
+func (e *Evaluator) fieldByJSONTag(val reflect.Value, name string) reflect.Value {
+	if val.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return val.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
 // snakeToPascal 将snake_case转换为PascalCase
 func (e *Evaluator) snakeToPascal(s string) string {
 	parts := strings.Split(s, "_")
@@ -0,0 +1,61 @@
+package abac
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConditionParityVectors 与Edge端评估器共用同一组向量，保证两端条件匹配结果一致
+func TestConditionParityVectors(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "abac", "condition_vectors.json"))
+	if err != nil {
+		t.Skipf("shared ABAC vectors not available: %v", err)
+	}
+	var vectors struct {
+		Device DeviceAttributes `json:"device"`
+		Cases  []struct {
+			Name       string            `json:"name"`
+			Conditions []PolicyCondition `json:"conditions"`
+			Want       bool              `json:"want"`
+		} `json:"cases"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &vectors))
+
+	e := NewEvaluator()
+	for _, tc := range vectors.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.NoError(t, ValidateConditions(tc.Conditions))
+			assert.Equal(t, tc.Want, e.matchConditions(&vectors.Device, tc.Conditions))
+		})
+	}
+}
+
+// TestToFloat64NumericStrings 数值字符串按数值比较
+func TestToFloat64NumericStrings(t *testing.T) {
+	e := NewEvaluator()
+	tests := []struct {
+		in   interface{}
+		want float64
+		ok   bool
+	}{
+		{in: "80", want: 80, ok: true},
+		{in: "75.5", want: 75.5, ok: true},
+		{in: "-3", want: -3, ok: true},
+		{in: "high", ok: false},
+		{in: "", ok: false},
+		{in: 42, want: 42, ok: true},
+		{in: true, ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := e.toFloat64(tt.in)
+		assert.Equal(t, tt.ok, ok, "toFloat64(%#v)", tt.in)
+		if tt.ok {
+			assert.Equal(t, tt.want, got, "toFloat64(%#v)", tt.in)
+		}
+	}
+}
@@ -13,7 +13,8 @@ import (
 )
 
 // ABACMiddleware ABAC访问控制中间件
-func ABACMiddleware(policyRepo PolicyRepository, cabinetRepo repository.CabinetRepository, vulnRepo repository.VulnerabilityRepository) gin.HandlerFunc {
+// ctxProvider 提供上下文属性中的储能柜状态，可为nil
+func ABACMiddleware(policyRepo PolicyRepository, cabinetRepo repository.CabinetRepository, vulnRepo repository.VulnerabilityRepository, algorithm string, ctxProvider ContextProvider) gin.HandlerFunc {
 	evaluator := NewEvaluator()
 	scorer := NewTrustScorer()
 
@@ -102,20 +103,38 @@ func ABACMiddleware(policyRepo PolicyRepository, cabinetRepo repository.CabinetR
 			return
 		}
 
-		// 3. 执行策略评估
+		// 3. 构建上下文属性（目标储能柜：储能柜主体自身或路由参数cabinet_id）
+		env := NewContextAttributes(time.Now(), c.ClientIP())
+		env.CabinetID = c.Param("cabinet_id")
+		if subjectType == SubjectTypeCabinet {
+			env.CabinetID = subjectID
+		}
+		if env.CabinetID != "" && ctxProvider != nil {
+			if state, err := ctxProvider.CabinetState(c.Request.Context(), env.CabinetID); err == nil {
+				env.ApplyCabinetState(state)
+			} else {
+				// 状态未知时依赖状态的策略失败关闭，不能把零值当作无告警
+				env.StateUnavailable = true
+				utils.Warn("获取储能柜状态失败", zap.String("cabinet_id", env.CabinetID), zap.Error(err))
+			}
+		}
+
+		// 4. 执行策略评估
 		evalReq := &EvaluateRequest{
 			SubjectAttrs: attrs,
 			Resource:     c.Request.URL.Path,
 			Action:       c.Request.Method,
 			Policies:     policies,
+			Algorithm:    algorithm,
+			Context:      env,
 		}
 
 		evalResp := evaluator.Evaluate(evalReq)
 
-		// 4. 记录访问日志
+		// 5. 记录访问日志
 		logAccess(c, policyRepo, string(subjectType), subjectID, evalResp)
 
-		// 5. 授权决策
+		// 6. 授权决策
 		if !evalResp.Allowed {
 			utils.Forbidden(c, evalResp.Reason)
 			c.Abort()
@@ -152,6 +171,7 @@ func logAccess(c *gin.Context, policyRepo PolicyRepository, subjectType, subject
 		TrustScore:  &evalResp.TrustScore,
 		Timestamp:   time.Now(),
 		Attributes:  attrsJSON,
+		Shadow:      evalResp.Shadow,
 	}
 
 	if evalResp.MatchedPolicy != nil {
@@ -10,35 +10,70 @@ type AccessPolicy struct {
 	ID          string            `json:"id" db:"id"`
 	Name        string            `json:"name" db:"name"`
 	Description string            `json:"description" db:"description"`
-	SubjectType string            `json:"subject_type" db:"subject_type"` // user, cabinet, device
+	SubjectType string            `json:"subject_type" db:"subject_type"` // user, cabinet, device, group
+	Effect      string            `json:"effect" db:"effect"`             // allow, deny
+	Mode        string            `json:"mode" db:"mode"`                 // enforce, shadow
 	Conditions  []PolicyCondition `json:"conditions" db:"conditions"`      // 条件列表
 	Permissions []string          `json:"permissions" db:"permissions"`    // 权限列表
 	Priority    int               `json:"priority" db:"priority"`          // 优先级
 	Enabled     bool              `json:"enabled" db:"enabled"`
+	Version     int               `json:"version" db:"version"`            // 版本，每次修改递增
+	Hash        string            `json:"hash,omitempty" db:"-"`           // 内容哈希，读取时计算
 	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
 }
 
+// 策略效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// GetEffect 返回策略效果，未设置时视为allow
+func (p *AccessPolicy) GetEffect() string {
+	if p.Effect == EffectDeny {
+		return EffectDeny
+	}
+	return EffectAllow
+}
+
+// 策略模式
+const (
+	ModeEnforce = "enforce" // 参与实际授权
+	ModeShadow  = "shadow"  // 仅试运行：评估并记录假设结果，不影响授权
+)
+
+// IsShadow 是否为试运行策略
+func (p *AccessPolicy) IsShadow() bool {
+	return p.Mode == ModeShadow
+}
+
 // PolicyCondition 策略条件
+// 叶子条件使用 Attribute/Operator/Value；条件组使用 All/Any/Not，可任意嵌套
 type PolicyCondition struct {
-	Attribute string      `json:"attribute"` // 属性名
-	Operator  string      `json:"operator"`  // 操作符: eq, ne, gt, lt, gte, lte, in, contains
-	Value     interface{} `json:"value"`     // 比较值
+	Attribute string      `json:"attribute,omitempty"` // 属性名
+	Operator  string      `json:"operator,omitempty"`  // 操作符: eq, ne, gt, lt, gte, lte, in, contains, regex, cidr, prefix, between, exists
+	Value     interface{} `json:"value"`               // 比较值
+
+	All []PolicyCondition `json:"all,omitempty"` // 全部满足
+	Any []PolicyCondition `json:"any,omitempty"` // 任一满足
+	Not *PolicyCondition  `json:"not,omitempty"` // 取反
 }
 
 // AccessLog 访问日志
 type AccessLog struct {
-	ID          int64           `json:"id" db:"id"`
-	SubjectType string          `json:"subject_type" db:"subject_type"`
-	SubjectID   string          `json:"subject_id" db:"subject_id"`
-	Resource    string          `json:"resource" db:"resource"`
-	Action      string          `json:"action" db:"action"`
-	Allowed     bool            `json:"allowed" db:"allowed"`
-	PolicyID    *string         `json:"policy_id,omitempty" db:"policy_id"`
-	TrustScore  *float64        `json:"trust_score,omitempty" db:"trust_score"`
-	IPAddress   *string         `json:"ip_address,omitempty" db:"ip_address"`
-	Timestamp   time.Time       `json:"timestamp" db:"timestamp"`
-	Attributes  json.RawMessage `json:"attributes,omitempty" db:"attributes"` // JSONB
+	ID          int64            `json:"id" db:"id"`
+	SubjectType string           `json:"subject_type" db:"subject_type"`
+	SubjectID   string           `json:"subject_id" db:"subject_id"`
+	Resource    string           `json:"resource" db:"resource"`
+	Action      string           `json:"action" db:"action"`
+	Allowed     bool             `json:"allowed" db:"allowed"`
+	PolicyID    *string          `json:"policy_id,omitempty" db:"policy_id"`
+	TrustScore  *float64         `json:"trust_score,omitempty" db:"trust_score"`
+	IPAddress   *string          `json:"ip_address,omitempty" db:"ip_address"`
+	Timestamp   time.Time        `json:"timestamp" db:"timestamp"`
+	Attributes  json.RawMessage  `json:"attributes,omitempty" db:"attributes"` // JSONB
+	Shadow      []ShadowDecision `json:"shadow,omitempty" db:"shadow"`         // JSONB，试运行策略的假设结果
 }
 
 // CreatePolicyRequest 创建策略请求
@@ -46,7 +81,9 @@ type CreatePolicyRequest struct {
 	ID          string            `json:"id" binding:"required"`
 	Name        string            `json:"name" binding:"required"`
 	Description string            `json:"description"`
-	SubjectType string            `json:"subject_type" binding:"required,oneof=user cabinet device"`
+	SubjectType string            `json:"subject_type" binding:"required,oneof=user cabinet device group"`
+	Effect      string            `json:"effect" binding:"omitempty,oneof=allow deny"`
+	Mode        string            `json:"mode" binding:"omitempty,oneof=enforce shadow"`
 	Conditions  []PolicyCondition `json:"conditions" binding:"required"`
 	Permissions []string          `json:"permissions" binding:"required"`
 	Priority    int               `json:"priority" binding:"required,min=0,max=1000"`
@@ -56,6 +93,8 @@ type CreatePolicyRequest struct {
 type UpdatePolicyRequest struct {
 	Name        *string            `json:"name"`
 	Description *string            `json:"description"`
+	Effect      *string            `json:"effect" binding:"omitempty,oneof=allow deny"`
+	Mode        *string            `json:"mode" binding:"omitempty,oneof=enforce shadow"`
 	Conditions  *[]PolicyCondition `json:"conditions"`
 	Permissions *[]string          `json:"permissions"`
 	Priority    *int               `json:"priority" binding:"omitempty,min=0,max=1000"`
@@ -64,7 +103,7 @@ type UpdatePolicyRequest struct {
 
 // PolicyListFilter 策略列表筛选
 type PolicyListFilter struct {
-	SubjectType *string `form:"subject_type" binding:"omitempty,oneof=user cabinet device"`
+	SubjectType *string `form:"subject_type" binding:"omitempty,oneof=user cabinet device group"`
 	Enabled     *bool   `form:"enabled"`
 	Search      string  `form:"search"`
 	Page        int     `form:"page" binding:"omitempty,min=1"`
@@ -73,7 +112,7 @@ type PolicyListFilter struct {
 
 // AccessLogFilter 访问日志筛选
 type AccessLogFilter struct {
-	SubjectType *string    `form:"subject_type" binding:"omitempty,oneof=user cabinet device"`
+	SubjectType *string    `form:"subject_type" binding:"omitempty,oneof=user cabinet device group"`
 	SubjectID   *string    `form:"subject_id"`
 	Resource    *string    `form:"resource"`
 	Allowed     *bool      `form:"allowed"`
@@ -117,19 +156,43 @@ type DenyReasonStat struct {
 
 // EvaluationRequest 策略评估测试请求
 type EvaluationRequest struct {
-	SubjectType string                 `json:"subject_type" binding:"required,oneof=user cabinet device"`
+	SubjectType string                 `json:"subject_type" binding:"required,oneof=user cabinet device group"`
 	Attributes  map[string]interface{} `json:"attributes" binding:"required"`
 	Resource    string                 `json:"resource" binding:"required"`
 	Action      string                 `json:"action" binding:"required"`
+	Algorithm   string                 `json:"algorithm" binding:"omitempty,oneof=deny-overrides permit-overrides first-applicable"` // 为空时使用系统配置
+	Context     *ContextAttributes     `json:"context"`                                                                             // 上下文属性（可选）
 }
 
 // EvaluationResult 策略评估结果
 type EvaluationResult struct {
-	Allowed       bool              `json:"allowed"`
-	MatchedPolicy *AccessPolicy     `json:"matched_policy,omitempty"`
-	TrustScore    float64           `json:"trust_score"`
-	Permissions   []string          `json:"permissions"`
-	Reason        string            `json:"reason"`
+	Allowed       bool             `json:"allowed"`
+	MatchedPolicy *AccessPolicy    `json:"matched_policy,omitempty"`
+	TrustScore    float64          `json:"trust_score"`
+	Permissions   []string         `json:"permissions"`
+	Reason        string           `json:"reason"`
+	Algorithm     string           `json:"algorithm"`
+	Decisions     []PolicyDecision `json:"decisions"` // 每条参与评估的策略的判定说明
+	Shadow        []ShadowDecision `json:"shadow"`    // 试运行策略的假设结果
+}
+
+// ShadowReport 试运行策略影响报告
+type ShadowReport struct {
+	PolicyID    string              `json:"policy_id"`
+	Since       *time.Time          `json:"since,omitempty"`
+	Evaluated   int64               `json:"evaluated"`    // 评估过该试运行策略的请求数
+	Changed     int64               `json:"changed"`      // 结果会改变的请求数
+	WouldDeny   int64               `json:"would_deny"`   // 原本允许、启用后会拒绝
+	WouldAllow  int64               `json:"would_allow"`  // 原本拒绝、启用后会允许
+	ChangedRate float64             `json:"changed_rate"` // changed / evaluated
+	TopSubjects []ShadowSubjectStat `json:"top_subjects"` // 结果改变最多的主体
+}
+
+// ShadowSubjectStat 按主体统计的结果变化
+type ShadowSubjectStat struct {
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+	Changed     int64  `json:"changed"`
 }
 
 // DistributionLog 策略分发日志
@@ -137,7 +200,7 @@ type DistributionLog struct {
 	ID              int64      `json:"id" db:"id"`
 	PolicyID        string     `json:"policy_id" db:"policy_id"`
 	CabinetID       string     `json:"cabinet_id" db:"cabinet_id"`
-	OperationType   string     `json:"operation_type" db:"operation_type"` // distribute, broadcast, sync
+	OperationType   string     `json:"operation_type" db:"operation_type"` // distribute, broadcast, sync, reconcile
 	Status          string     `json:"status" db:"status"`                 // pending, success, failed
 	OperatorID      *int       `json:"operator_id,omitempty" db:"operator_id"`
 	OperatorName    *string    `json:"operator_name,omitempty" db:"operator_name"`
@@ -2,6 +2,7 @@ package abac
 
 import (
 	"context"
+	"time"
 )
 
 // PolicyRepository 策略数据访问接口
@@ -22,9 +23,21 @@ type PolicyRepository interface {
 	LogAccess(ctx context.Context, log *AccessLog) error
 	GetAccessLogs(ctx context.Context, filter *AccessLogFilter) ([]*AccessLog, int64, error)
 	GetAccessStats(ctx context.Context, startTime, endTime *string) (*AccessStats, error)
+	GetShadowReport(ctx context.Context, policyID string, since *time.Time) (*ShadowReport, error)
 
 	// 策略分发日志
 	LogDistribution(ctx context.Context, log *DistributionLog) error
 	GetDistributionLogs(ctx context.Context, filter *DistributionLogFilter) ([]*DistributionLog, int64, error)
 	UpdateDistributionAck(ctx context.Context, policyID, cabinetID string) error
+
+	// 策略同步状态（心跳对账）
+	SavePolicySyncStatus(ctx context.Context, status *PolicySyncStatus) error
+	GetPolicySyncStatus(ctx context.Context, cabinetID string) (*PolicySyncStatus, error)
+	ListPolicySyncStatus(ctx context.Context) ([]*PolicySyncStatus, error)
+
+	// 设备行为信任度（Edge上报）
+	SaveDeviceTrust(ctx context.Context, trust *DeviceTrust) error
+	ListDeviceTrust(ctx context.Context, filter *DeviceTrustFilter) ([]*DeviceTrust, error)
+	SaveTrustEvents(ctx context.Context, events []*TrustEvent) error
+	GetTrustHistory(ctx context.Context, cabinetID, deviceID string, limit int) ([]*TrustEvent, error)
 }
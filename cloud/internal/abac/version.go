@@ -0,0 +1,180 @@
+package abac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// PolicyVersion 单条策略的版本和内容哈希
+type PolicyVersion struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+// PolicyHeartbeat Edge定期上报的策略集摘要
+type PolicyHeartbeat struct {
+	CabinetID          string          `json:"cabinet_id"`
+	Digest             string          `json:"digest"`
+	PolicyCount        int             `json:"policy_count"`
+	Policies           []PolicyVersion `json:"policies"`
+	CombiningAlgorithm string          `json:"combining_algorithm"`
+	Timestamp          time.Time       `json:"timestamp"`
+}
+
+// ContentHash 计算策略内容哈希（不含版本和时间戳），Cloud与Edge使用相同算法
+func (p *AccessPolicy) ContentHash() string {
+	conditions := p.Conditions
+	if conditions == nil {
+		conditions = []PolicyCondition{}
+	}
+	permissions := p.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	data, _ := json.Marshal(struct {
+		ID          string            `json:"id"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		SubjectType string            `json:"subject_type"`
+		Effect      string            `json:"effect"`
+		Mode        string            `json:"mode,omitempty"` // enforce不计入，保持旧策略哈希不变
+		Conditions  []PolicyCondition `json:"conditions"`
+		Permissions []string          `json:"permissions"`
+		Priority    int               `json:"priority"`
+		Enabled     bool              `json:"enabled"`
+	}{p.ID, p.Name, p.Description, p.SubjectType, p.GetEffect(), shadowMode(p), conditions, permissions, p.Priority, p.Enabled})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func shadowMode(p *AccessPolicy) string {
+	if p.IsShadow() {
+		return ModeShadow
+	}
+	return ""
+}
+
+// PolicyVersions 返回策略集的版本列表（按ID排序）
+func PolicyVersions(policies []*AccessPolicy) []PolicyVersion {
+	versions := make([]PolicyVersion, 0, len(policies))
+	for _, p := range policies {
+		versions = append(versions, PolicyVersion{ID: p.ID, Version: p.Version, Hash: p.ContentHash()})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID < versions[j].ID })
+	return versions
+}
+
+// PolicySetDigest 计算策略集摘要，只取决于各策略ID和内容哈希
+func PolicySetDigest(versions []PolicyVersion) string {
+	sorted := make([]PolicyVersion, len(versions))
+	copy(sorted, versions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	h := sha256.New()
+	for _, v := range sorted {
+		h.Write([]byte(v.ID + ":" + v.Hash + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// 策略漂移类型
+const (
+	DriftMissing = "missing" // Edge缺少该策略
+	DriftStale   = "stale"   // Edge持有的策略内容与Cloud不一致
+	DriftExtra   = "extra"   // Edge持有Cloud已删除的策略
+)
+
+// StalePolicy 内容不一致的策略
+type StalePolicy struct {
+	ID              string `json:"id"`
+	ExpectedVersion int    `json:"expected_version"`
+	ReportedVersion int    `json:"reported_version"`
+}
+
+// PolicyDrift 储能柜策略集与Cloud期望策略集的差异
+type PolicyDrift struct {
+	Missing []string      `json:"missing"`
+	Stale   []StalePolicy `json:"stale"`
+	Extra   []string      `json:"extra"`
+}
+
+// DiffPolicySets 比较Cloud期望的策略集与Edge上报的策略集
+func DiffPolicySets(expected []*AccessPolicy, reported []PolicyVersion) *PolicyDrift {
+	drift := &PolicyDrift{Missing: []string{}, Stale: []StalePolicy{}, Extra: []string{}}
+
+	held := make(map[string]PolicyVersion, len(reported))
+	for _, v := range reported {
+		held[v.ID] = v
+	}
+
+	for _, v := range PolicyVersions(expected) {
+		got, ok := held[v.ID]
+		delete(held, v.ID)
+		switch {
+		case !ok:
+			drift.Missing = append(drift.Missing, v.ID)
+		case got.Hash != v.Hash:
+			drift.Stale = append(drift.Stale, StalePolicy{ID: v.ID, ExpectedVersion: v.Version, ReportedVersion: got.Version})
+		}
+	}
+
+	for id := range held {
+		drift.Extra = append(drift.Extra, id)
+	}
+	sort.Strings(drift.Extra)
+	return drift
+}
+
+// Count 差异策略数量
+func (d *PolicyDrift) Count() int {
+	return len(d.Missing) + len(d.Stale) + len(d.Extra)
+}
+
+// Outdated 需要重新下发的策略ID（缺失和内容不一致）
+func (d *PolicyDrift) Outdated() []string {
+	ids := append([]string{}, d.Missing...)
+	for _, s := range d.Stale {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
+// StateOf 返回指定策略的漂移类型，无差异时返回空字符串
+func (d *PolicyDrift) StateOf(policyID string) string {
+	for _, id := range d.Missing {
+		if id == policyID {
+			return DriftMissing
+		}
+	}
+	for _, s := range d.Stale {
+		if s.ID == policyID {
+			return DriftStale
+		}
+	}
+	for _, id := range d.Extra {
+		if id == policyID {
+			return DriftExtra
+		}
+	}
+	return ""
+}
+
+// PolicySyncStatus 储能柜策略同步状态（最近一次心跳的对账结果）
+type PolicySyncStatus struct {
+	CabinetID        string       `json:"cabinet_id" db:"cabinet_id"`
+	InSync           bool         `json:"in_sync" db:"in_sync"`
+	ExpectedDigest   string       `json:"expected_digest" db:"expected_digest"`
+	ReportedDigest   string       `json:"reported_digest" db:"reported_digest"`
+	ExpectedCount    int          `json:"expected_count" db:"expected_count"`
+	ReportedCount    int          `json:"reported_count" db:"reported_count"`
+	Drift            *PolicyDrift `json:"drift,omitempty" db:"drift"`   // JSONB
+	LastAction       string       `json:"last_action" db:"last_action"` // none, delta, full_sync, cooldown
+	LastHeartbeatAt  time.Time    `json:"last_heartbeat_at" db:"last_heartbeat_at"`
+	LastReconciledAt *time.Time   `json:"last_reconciled_at,omitempty" db:"last_reconciled_at"`
+}
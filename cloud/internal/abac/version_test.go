@@ -0,0 +1,126 @@
+package abac
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadDigestVectors 读取与Edge端共用的策略摘要向量
+func loadDigestVectors(t *testing.T) (policies []*AccessPolicy, hashes map[string]string, digest, emptyDigest string) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "abac", "policy_digest_vectors.json"))
+	if err != nil {
+		t.Skipf("shared ABAC vectors not available: %v", err)
+	}
+	var vectors struct {
+		Policies    []*AccessPolicy   `json:"policies"`
+		Hashes      map[string]string `json:"hashes"`
+		Digest      string            `json:"digest"`
+		EmptyDigest string            `json:"empty_digest"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &vectors))
+	return vectors.Policies, vectors.Hashes, vectors.Digest, vectors.EmptyDigest
+}
+
+// TestPolicyDigestVectors 与Edge端共用同一组向量，保证两端内容哈希和策略集摘要一致
+func TestPolicyDigestVectors(t *testing.T) {
+	policies, hashes, digest, emptyDigest := loadDigestVectors(t)
+
+	for _, p := range policies {
+		assert.Equal(t, hashes[p.ID], p.ContentHash(), "content hash of %s", p.ID)
+		// 版本号不计入内容哈希
+		bumped := *p
+		bumped.Version++
+		assert.Equal(t, hashes[p.ID], bumped.ContentHash(), "version must not affect hash of %s", p.ID)
+	}
+
+	versions := PolicyVersions(policies)
+	assert.Equal(t, digest, PolicySetDigest(versions))
+	reversed := make([]PolicyVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		reversed = append(reversed, versions[i])
+	}
+	assert.Equal(t, digest, PolicySetDigest(reversed), "digest must not depend on order")
+	assert.Equal(t, emptyDigest, PolicySetDigest(nil))
+}
+
+func TestDiffPolicySets(t *testing.T) {
+	policies, _, _, _ := loadDigestVectors(t)
+	byID := map[string]*AccessPolicy{}
+	for _, p := range policies {
+		byID[p.ID] = p
+	}
+	current := PolicyVersions(policies)
+
+	// 内容变化但版本号未变（例如Edge端数据损坏）也应识别为不一致
+	changed := *byID["deny-untrusted"]
+	changed.Priority = 1
+
+	tests := []struct {
+		name     string
+		expected []*AccessPolicy
+		reported []PolicyVersion
+		want     *PolicyDrift
+	}{
+		{
+			name:     "in sync",
+			expected: policies,
+			reported: current,
+			want:     &PolicyDrift{Missing: []string{}, Stale: []StalePolicy{}, Extra: []string{}},
+		},
+		{
+			name:     "empty edge",
+			expected: policies,
+			want: &PolicyDrift{
+				Missing: []string{"deny-untrusted", "legacy-allow", "shadow-diagnostics"},
+				Stale:   []StalePolicy{},
+				Extra:   []string{},
+			},
+		},
+		{
+			name:     "stale content",
+			expected: []*AccessPolicy{&changed, byID["legacy-allow"]},
+			reported: current,
+			want: &PolicyDrift{
+				Missing: []string{},
+				Stale:   []StalePolicy{{ID: "deny-untrusted", ExpectedVersion: 7, ReportedVersion: 7}},
+				Extra:   []string{"shadow-diagnostics"},
+			},
+		},
+		{
+			name:     "version bump without content change",
+			expected: policies,
+			reported: []PolicyVersion{
+				{ID: "deny-untrusted", Version: 6, Hash: byID["deny-untrusted"].ContentHash()},
+				{ID: "legacy-allow", Version: 1, Hash: byID["legacy-allow"].ContentHash()},
+				{ID: "zz-removed", Version: 2, Hash: "x"},
+				{ID: "aa-removed", Version: 1, Hash: "y"},
+			},
+			want: &PolicyDrift{
+				Missing: []string{"shadow-diagnostics"},
+				Stale:   []StalePolicy{},
+				Extra:   []string{"aa-removed", "zz-removed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift := DiffPolicySets(tt.expected, tt.reported)
+			assert.Equal(t, tt.want, drift)
+			assert.Equal(t, len(tt.want.Missing)+len(tt.want.Stale)+len(tt.want.Extra), drift.Count())
+		})
+	}
+
+	drift := DiffPolicySets([]*AccessPolicy{&changed, byID["legacy-allow"]}, []PolicyVersion{current[0], current[2]})
+	assert.Equal(t, []string{"legacy-allow", "deny-untrusted"}, drift.Outdated())
+	assert.Equal(t, DriftMissing, drift.StateOf("legacy-allow"))
+	assert.Equal(t, DriftStale, drift.StateOf("deny-untrusted"))
+	assert.Equal(t, DriftExtra, drift.StateOf("shadow-diagnostics"))
+	assert.Equal(t, "", drift.StateOf("unknown"))
+}
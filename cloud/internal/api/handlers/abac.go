@@ -14,6 +14,7 @@ import (
 type ABACHandler struct {
 	policyRepo      abac.PolicyRepository
 	policyPublisher *mqtt.PolicyPublisher
+	algorithm       string // 策略组合算法
 }
 
 func NewABACHandler(policyRepo abac.PolicyRepository) *ABACHandler {
@@ -22,6 +23,11 @@ func NewABACHandler(policyRepo abac.PolicyRepository) *ABACHandler {
 	}
 }
 
+// SetCombiningAlgorithm 设置策略组合算法
+func (h *ABACHandler) SetCombiningAlgorithm(algorithm string) {
+	h.algorithm = algorithm
+}
+
 // SetPolicyPublisher 设置策略发布器
 func (h *ABACHandler) SetPolicyPublisher(publisher *mqtt.PolicyPublisher) {
 	h.policyPublisher = publisher
@@ -74,12 +80,18 @@ func (h *ABACHandler) CreatePolicy(c *gin.Context) {
 		utils.BadRequest(c, "无效的请求参数")
 		return
 	}
+	if err := abac.ValidateConditions(req.Conditions); err != nil {
+		utils.BadRequest(c, "策略条件无效: "+err.Error())
+		return
+	}
 
 	policy := &abac.AccessPolicy{
 		ID:          req.ID,
 		Name:        req.Name,
 		Description: req.Description,
 		SubjectType: req.SubjectType,
+		Effect:      req.Effect,
+		Mode:        req.Mode,
 		Conditions:  req.Conditions,
 		Permissions: req.Permissions,
 		Priority:    req.Priority,
@@ -107,6 +119,12 @@ func (h *ABACHandler) UpdatePolicy(c *gin.Context) {
 		utils.BadRequest(c, "无效的请求参数")
 		return
 	}
+	if req.Conditions != nil {
+		if err := abac.ValidateConditions(*req.Conditions); err != nil {
+			utils.BadRequest(c, "策略条件无效: "+err.Error())
+			return
+		}
+	}
 
 	if err := h.policyRepo.Update(c.Request.Context(), id, &req); err != nil {
 		utils.Error("更新策略失败", zap.Error(err), zap.String("policy_id", id))
@@ -170,6 +188,61 @@ func (h *ABACHandler) TogglePolicy(c *gin.Context) {
 	utils.Success(c, gin.H{"message": "操作成功"})
 }
 
+// GetShadowReport 获取试运行策略影响报告（启用后会改变结果的请求数）
+func (h *ABACHandler) GetShadowReport(c *gin.Context) {
+	id := c.Param("id")
+
+	policy, err := h.policyRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		utils.NotFound(c, "策略不存在")
+		return
+	}
+
+	var since *time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.BadRequest(c, "since格式应为RFC3339")
+			return
+		}
+		since = &t
+	}
+
+	report, err := h.policyRepo.GetShadowReport(c.Request.Context(), id, since)
+	if err != nil {
+		utils.Error("查询试运行报告失败", zap.Error(err), zap.String("policy_id", id))
+		utils.InternalServerError(c, "查询失败")
+		return
+	}
+
+	utils.Success(c, gin.H{"policy": policy, "report": report})
+}
+
+// PromotePolicy 将试运行策略转为生效策略
+func (h *ABACHandler) PromotePolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	policy, err := h.policyRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		utils.NotFound(c, "策略不存在")
+		return
+	}
+	if !policy.IsShadow() {
+		utils.BadRequest(c, "策略不处于试运行模式")
+		return
+	}
+
+	mode := abac.ModeEnforce
+	if err := h.policyRepo.Update(c.Request.Context(), id, &abac.UpdatePolicyRequest{Mode: &mode}); err != nil {
+		utils.Error("启用试运行策略失败", zap.Error(err), zap.String("policy_id", id))
+		utils.InternalServerError(c, "操作失败")
+		return
+	}
+
+	utils.Info("试运行策略已转为生效", zap.String("policy_id", id))
+	utils.Success(c, gin.H{"message": "策略已生效"})
+}
+
 // ListAccessLogs 列出访问日志
 func (h *ABACHandler) ListAccessLogs(c *gin.Context) {
 	var filter abac.AccessLogFilter
@@ -292,6 +365,11 @@ func (h *ABACHandler) EvaluatePolicy(c *gin.Context) {
 		Resource:     req.Resource,
 		Action:       req.Action,
 		Policies:     policies,
+		Algorithm:    h.algorithm,
+		Context:      req.Context,
+	}
+	if req.Algorithm != "" {
+		evalReq.Algorithm = req.Algorithm
 	}
 
 	evalResp := evaluator.Evaluate(evalReq)
@@ -302,6 +380,9 @@ func (h *ABACHandler) EvaluatePolicy(c *gin.Context) {
 		TrustScore:    evalResp.TrustScore,
 		Permissions:   evalResp.Permissions,
 		Reason:        evalResp.Reason,
+		Algorithm:     evalResp.Algorithm,
+		Decisions:     evalResp.Decisions,
+		Shadow:        evalResp.Shadow,
 	}
 
 	utils.Success(c, gin.H{"result": result})
@@ -526,10 +607,99 @@ func (h *ABACHandler) GetPolicyDistributionStatus(c *gin.Context) {
 		stats[log.Status]++
 	}
 
+	// 最近一次心跳对账中该策略存在漂移的储能柜
+	statuses, err := h.policyRepo.ListPolicySyncStatus(c.Request.Context())
+	if err != nil {
+		utils.Error("查询策略同步状态失败", zap.Error(err))
+		utils.InternalServerError(c, "查询失败")
+		return
+	}
+	drift := []gin.H{}
+	for _, status := range statuses {
+		if status.Drift == nil {
+			continue
+		}
+		if state := status.Drift.StateOf(policyID); state != "" {
+			drift = append(drift, gin.H{
+				"cabinet_id":        status.CabinetID,
+				"state":             state,
+				"last_action":       status.LastAction,
+				"last_heartbeat_at": status.LastHeartbeatAt,
+			})
+		}
+	}
+
 	utils.Success(c, gin.H{
 		"policy_id": policyID,
 		"logs":      logs,
 		"total":     total,
 		"stats":     stats,
+		"drift":     drift,
+	})
+}
+
+// ListPolicySyncStatus 获取各储能柜策略同步状态（心跳对账结果）
+func (h *ABACHandler) ListPolicySyncStatus(c *gin.Context) {
+	statuses, err := h.policyRepo.ListPolicySyncStatus(c.Request.Context())
+	if err != nil {
+		utils.Error("查询策略同步状态失败", zap.Error(err))
+		utils.InternalServerError(c, "查询失败")
+		return
+	}
+
+	drifted := 0
+	for _, status := range statuses {
+		if !status.InSync {
+			drifted++
+		}
+	}
+
+	utils.Success(c, gin.H{
+		"cabinets": statuses,
+		"total":    len(statuses),
+		"in_sync":  len(statuses) - drifted,
+		"drifted":  drifted,
+	})
+}
+
+// ListDeviceTrust 获取Edge上报的设备行为信任度（按分数升序）
+func (h *ABACHandler) ListDeviceTrust(c *gin.Context) {
+	var filter abac.DeviceTrustFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		utils.BadRequest(c, "无效的查询参数")
+		return
+	}
+
+	devices, err := h.policyRepo.ListDeviceTrust(c.Request.Context(), &filter)
+	if err != nil {
+		utils.Error("查询设备信任度失败", zap.Error(err))
+		utils.InternalServerError(c, "查询失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"devices": devices,
+		"total":   len(devices),
+	})
+}
+
+// GetDeviceTrustHistory 获取设备信任度变化记录
+func (h *ABACHandler) GetDeviceTrustHistory(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+	deviceID := c.Param("device_id")
+
+	history, err := h.policyRepo.GetTrustHistory(c.Request.Context(), cabinetID, deviceID, 100)
+	if err != nil {
+		utils.Error("查询设备信任度历史失败", zap.Error(err),
+			zap.String("cabinet_id", cabinetID),
+			zap.String("device_id", deviceID))
+		utils.InternalServerError(c, "查询失败")
+		return
+	}
+
+	utils.Success(c, gin.H{
+		"cabinet_id": cabinetID,
+		"device_id":  deviceID,
+		"history":    history,
 	})
 }
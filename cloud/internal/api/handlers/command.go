@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"cloud-system/internal/models"
 	"cloud-system/internal/services"
@@ -43,6 +44,161 @@ func (h *CommandHandler) SendCommand(c *gin.Context) {
 		return
 	}
 
+	h.sendCommand(c, cabinetID, &request, "命令已发送")
+}
+
+// ForceCredentialRotation 强制储能柜下的设备轮换凭证
+// @Summary 强制凭证轮换
+// @Tags Command
+// @Accept json
+// @Produce json
+// @Param cabinet_id path string true "储能柜ID"
+// @Param request body models.ForceCredentialRotationRequest false "轮换请求（device_id为空表示全部设备）"
+// @Success 200 {object} utils.SuccessResponse{data=models.Command}
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /api/v1/cabinets/{cabinet_id}/credentials/rotate [post]
+func (h *CommandHandler) ForceCredentialRotation(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+
+	var request models.ForceCredentialRotationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ValidationError(c, "请求参数格式错误")
+			return
+		}
+	}
+
+	commandRequest := &models.SendCommandRequest{
+		CommandType: models.CommandTypeCredentialRotate,
+		Payload: map[string]interface{}{
+			"device_id": request.DeviceID,
+			"reason":    request.Reason,
+		},
+	}
+
+	h.sendCommand(c, cabinetID, commandRequest, "凭证轮换命令已发送")
+}
+
+// RevokeSessions 撤销储能柜下设备的会话
+// @Summary 撤销设备会话
+// @Tags Command
+// @Accept json
+// @Produce json
+// @Param cabinet_id path string true "储能柜ID"
+// @Param request body models.RevokeSessionsRequest false "撤销请求（device_id为空表示全部设备）"
+// @Success 200 {object} utils.SuccessResponse{data=models.Command}
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /api/v1/cabinets/{cabinet_id}/sessions/revoke [post]
+func (h *CommandHandler) RevokeSessions(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+
+	var request models.RevokeSessionsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ValidationError(c, "请求参数格式错误")
+			return
+		}
+	}
+
+	commandRequest := &models.SendCommandRequest{
+		CommandType: models.CommandTypeSessionRevoke,
+		Payload: map[string]interface{}{
+			"device_id": request.DeviceID,
+		},
+	}
+
+	h.sendCommand(c, cabinetID, commandRequest, "会话撤销命令已发送")
+}
+
+// ApproveConfigBaseline 远程批准储能柜的配置基线（确认配置变更为授权操作）
+// @Summary 批准配置基线
+// @Tags Command
+// @Accept json
+// @Produce json
+// @Param cabinet_id path string true "储能柜ID"
+// @Param request body models.ApproveConfigBaselineRequest false "批准请求"
+// @Success 200 {object} utils.SuccessResponse{data=models.Command}
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /api/v1/cabinets/{cabinet_id}/config-baseline/approve [post]
+func (h *CommandHandler) ApproveConfigBaseline(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+
+	var request models.ApproveConfigBaselineRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ValidationError(c, "请求参数格式错误")
+			return
+		}
+	}
+
+	approvedBy := "cloud"
+	if username, exists := c.Get("username"); exists {
+		approvedBy = fmt.Sprintf("cloud:%v", username)
+	}
+
+	commandRequest := &models.SendCommandRequest{
+		CommandType: models.CommandTypeConfigBaselineApprove,
+		Payload: map[string]interface{}{
+			"reason":      request.Reason,
+			"approved_by": approvedBy,
+		},
+	}
+
+	h.sendCommand(c, cabinetID, commandRequest, "配置基线批准命令已发送")
+}
+
+// ConfirmRemediation 确认或拒绝Edge端等待确认的漏洞处置
+// @Summary 确认漏洞处置
+// @Tags Command
+// @Accept json
+// @Produce json
+// @Param cabinet_id path string true "储能柜ID"
+// @Param remediation_id path int true "Edge处置记录ID"
+// @Param request body models.ConfirmRemediationRequest false "确认请求"
+// @Success 200 {object} utils.SuccessResponse{data=models.Command}
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /api/v1/cabinets/{cabinet_id}/remediations/{remediation_id}/confirm [post]
+func (h *CommandHandler) ConfirmRemediation(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+	remediationID, err := strconv.ParseInt(c.Param("remediation_id"), 10, 64)
+	if err != nil || remediationID <= 0 {
+		utils.ValidationError(c, "无效的处置记录ID")
+		return
+	}
+
+	var request models.ConfirmRemediationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			utils.ValidationError(c, "请求参数格式错误")
+			return
+		}
+	}
+	approve := request.Approve == nil || *request.Approve
+
+	operator := "cloud"
+	if username, exists := c.Get("username"); exists {
+		operator = fmt.Sprintf("cloud:%v", username)
+	}
+
+	commandRequest := &models.SendCommandRequest{
+		CommandType: models.CommandTypeRemediationConfirm,
+		Payload: map[string]interface{}{
+			"remediation_id": remediationID,
+			"approve":        approve,
+			"operator":       operator,
+			"reason":         request.Reason,
+		},
+	}
+
+	message := "处置确认命令已发送"
+	if !approve {
+		message = "处置拒绝命令已发送"
+	}
+	h.sendCommand(c, cabinetID, commandRequest, message)
+}
+
+// sendCommand 下发命令并写入响应
+func (h *CommandHandler) sendCommand(c *gin.Context, cabinetID string, request *models.SendCommandRequest, message string) {
 	// 从上下文获取用户信息（通过JWT中间件设置）
 	createdBy := "admin"
 	if user, exists := c.Get("user_id"); exists {
@@ -59,7 +215,7 @@ func (h *CommandHandler) SendCommand(c *gin.Context) {
 		}
 	}
 
-	command, err := h.commandService.SendCommand(c.Request.Context(), cabinetID, &request, createdBy)
+	command, err := h.commandService.SendCommand(c.Request.Context(), cabinetID, request, createdBy)
 	if err != nil {
 		appErr := err.(*errors.AppError)
 		statusCode := http.StatusBadRequest
@@ -70,7 +226,7 @@ func (h *CommandHandler) SendCommand(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessWithMessage(c, command, "命令已发送")
+	utils.SuccessWithMessage(c, command, message)
 }
 
 // GetCommand 获取命令详情
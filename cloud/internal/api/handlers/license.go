@@ -226,6 +226,29 @@ func (h *LicenseHandler) PushLicense(c *gin.Context) {
 	utils.SuccessWithMessage(c, command, "许可证下发命令已发送")
 }
 
+// RefreshLicense Edge端请求重新下发许可证（漏洞处置剧本的refresh_license动作）
+// 只允许已通过API Key认证的储能柜为自身请求
+func (h *LicenseHandler) RefreshLicense(c *gin.Context) {
+	cabinetID := c.Param("cabinet_id")
+	authenticated, exists := c.Get("cabinet_id")
+	if !exists {
+		utils.Unauthorized(c, "需要储能柜API Key认证")
+		return
+	}
+	if authenticated.(string) != cabinetID {
+		utils.Forbidden(c, "只能为本储能柜请求许可证")
+		return
+	}
+
+	command, err := h.pushLicenseToEdge(c.Request.Context(), cabinetID, "edge:"+cabinetID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err.(*errors.AppError))
+		return
+	}
+
+	utils.SuccessWithMessage(c, command, "许可证下发命令已发送")
+}
+
 func (h *LicenseHandler) sendLicenseCommand(ctx context.Context, cabinetID, commandType, createdBy string, payload map[string]interface{}) (*models.Command, error) {
 	if h.commandService == nil {
 		return nil, errors.New(errors.ErrInternalServer, "命令服务不可用")
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -261,3 +262,397 @@ func (h *VulnerabilityHandler) GetStats(c *gin.Context) {
 
 	utils.Success(c, stats)
 }
+
+// ListRemediations 获取储能柜的漏洞处置记录
+// @Summary 获取漏洞处置记录
+// @Tags Vulnerability
+// @Produce json
+// @Param cabinet_id path string true "储能柜ID"
+// @Param status query string false "处置状态(pending/succeeded/failed/rejected)"
+// @Param limit query int false "返回条数，默认100"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CabinetRemediation}
+// @Router /api/v1/cabinets/{cabinet_id}/vulnerability/remediations [get]
+func (h *VulnerabilityHandler) ListRemediations(c *gin.Context) {
+	var query models.RemediationListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	remediations, err := h.vulnService.ListRemediations(c.Request.Context(), c.Param("cabinet_id"), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, remediations)
+}
+
+// ListExpiringCertificates 获取全网即将到期及已过期的TLS证书
+// @Summary 获取即将到期证书
+// @Tags Vulnerability
+// @Produce json
+// @Param days query int false "到期天数阈值，默认30"
+// @Param cabinet_id query string false "储能柜ID"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.CabinetCertificate}
+// @Router /api/v1/vulnerability/certificates/expiring [get]
+func (h *VulnerabilityHandler) ListExpiringCertificates(c *gin.Context) {
+	var query models.ExpiringCertificateQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+	if query.Days > 365 {
+		query.Days = 365 // 限制最大365天
+	}
+
+	certificates, err := h.vulnService.ListExpiringCertificates(c.Request.Context(), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, certificates)
+}
+
+// GetFleetRanking 全网储能柜评分排名
+// @Summary 获取全网储能柜评分排名
+// @Tags Vulnerability
+// @Produce json
+// @Param days query int false "统计天数，默认30，最大365"
+// @Param limit query int false "返回条数，默认20"
+// @Param sort query string false "排序字段(score/trend/delta)，默认score"
+// @Param order query string false "排序方向(asc/desc)，默认asc即风险最高在前"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.FleetCabinetRanking}
+// @Router /api/v1/vulnerability/fleet/ranking [get]
+func (h *VulnerabilityHandler) GetFleetRanking(c *gin.Context) {
+	var query models.FleetQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	rankings, err := h.vulnService.RankCabinets(c.Request.Context(), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, rankings)
+}
+
+// GetFleetTopTypes 全网最常见的漏洞类型
+// @Summary 获取全网常见漏洞类型
+// @Tags Vulnerability
+// @Produce json
+// @Param days query int false "统计天数，默认30，最大365"
+// @Param limit query int false "返回条数，默认20"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.FleetVulnerabilityType}
+// @Router /api/v1/vulnerability/fleet/top-types [get]
+func (h *VulnerabilityHandler) GetFleetTopTypes(c *gin.Context) {
+	var query models.FleetQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	types, err := h.vulnService.TopVulnerabilityTypes(c.Request.Context(), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, types)
+}
+
+// GetFleetRemediationTimes 按漏洞类型统计平均修复时长
+// @Summary 获取漏洞平均修复时长
+// @Tags Vulnerability
+// @Produce json
+// @Param days query int false "统计天数，默认30，最大365"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.FleetRemediationTime}
+// @Router /api/v1/vulnerability/fleet/mttr [get]
+func (h *VulnerabilityHandler) GetFleetRemediationTimes(c *gin.Context) {
+	var query models.FleetQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	items, err := h.vulnService.GetRemediationTimes(c.Request.Context(), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, items)
+}
+
+// GetFleetHeatmap 全网区域及风险热力数据
+// @Summary 获取全网风险热力数据
+// @Tags Vulnerability
+// @Produce json
+// @Param days query int false "只统计该天数内有评估的储能柜，默认30，最大365"
+// @Success 200 {object} utils.SuccessResponse{data=models.FleetHeatmap}
+// @Router /api/v1/vulnerability/fleet/heatmap [get]
+func (h *VulnerabilityHandler) GetFleetHeatmap(c *gin.Context) {
+	var query models.FleetQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	heatmap, err := h.vulnService.GetHeatmap(c.Request.Context(), &query)
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, heatmap)
+}
+
+// ListFirmwareAdvisories 获取固件漏洞目录
+// @Summary 获取固件漏洞目录
+// @Tags Vulnerability
+// @Produce json
+// @Success 200 {object} utils.SuccessResponse{data=[]models.FirmwareAdvisory}
+// @Router /api/v1/vulnerability/firmware-advisories [get]
+func (h *VulnerabilityHandler) ListFirmwareAdvisories(c *gin.Context) {
+	advisories, err := h.vulnService.ListFirmwareAdvisories(c.Request.Context())
+	if err != nil {
+		appErr := err.(*errors.AppError)
+		utils.ErrorResponse(c, http.StatusInternalServerError, appErr)
+		return
+	}
+
+	utils.Success(c, advisories)
+}
+
+// CreateFirmwareAdvisory 新增固件漏洞公告
+// @Summary 新增固件漏洞公告
+// @Tags Vulnerability
+// @Accept json
+// @Produce json
+// @Param request body models.FirmwareAdvisoryRequest true "公告内容"
+// @Success 200 {object} utils.SuccessResponse{data=models.FirmwareAdvisory}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/firmware-advisories [post]
+func (h *VulnerabilityHandler) CreateFirmwareAdvisory(c *gin.Context) {
+	var request models.FirmwareAdvisoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "请求参数格式错误")
+		return
+	}
+
+	advisory, err := h.vulnService.CreateFirmwareAdvisory(c.Request.Context(), &request)
+	if err != nil {
+		respondFirmwareError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, advisory, "固件漏洞公告已创建")
+}
+
+// UpdateFirmwareAdvisory 更新固件漏洞公告
+// @Summary 更新固件漏洞公告
+// @Tags Vulnerability
+// @Accept json
+// @Produce json
+// @Param advisory_id path string true "公告ID"
+// @Param request body models.FirmwareAdvisoryRequest true "公告内容"
+// @Success 200 {object} utils.SuccessResponse{data=models.FirmwareAdvisory}
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/firmware-advisories/{advisory_id} [put]
+func (h *VulnerabilityHandler) UpdateFirmwareAdvisory(c *gin.Context) {
+	var request models.FirmwareAdvisoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "请求参数格式错误")
+		return
+	}
+
+	advisory, err := h.vulnService.UpdateFirmwareAdvisory(c.Request.Context(), c.Param("advisory_id"), &request)
+	if err != nil {
+		respondFirmwareError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, advisory, "固件漏洞公告已更新")
+}
+
+// DeleteFirmwareAdvisory 删除固件漏洞公告
+// @Summary 删除固件漏洞公告
+// @Tags Vulnerability
+// @Produce json
+// @Param advisory_id path string true "公告ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/firmware-advisories/{advisory_id} [delete]
+func (h *VulnerabilityHandler) DeleteFirmwareAdvisory(c *gin.Context) {
+	if err := h.vulnService.DeleteFirmwareAdvisory(c.Request.Context(), c.Param("advisory_id")); err != nil {
+		respondFirmwareError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, nil, "固件漏洞公告已删除")
+}
+
+// DistributeFirmwareCatalog 下发固件漏洞目录
+// @Summary 下发固件漏洞目录
+// @Tags Vulnerability
+// @Produce json
+// @Param cabinet_id query string false "储能柜ID（为空时下发到所有储能柜）"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 503 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/firmware-advisories/distribute [post]
+func (h *VulnerabilityHandler) DistributeFirmwareCatalog(c *gin.Context) {
+	if err := h.vulnService.DistributeFirmwareCatalog(c.Request.Context(), c.Query("cabinet_id")); err != nil {
+		respondFirmwareError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, nil, "固件漏洞目录已下发")
+}
+
+// respondFirmwareError 固件漏洞目录错误响应
+func respondFirmwareError(c *gin.Context, err error) {
+	appErr := err.(*errors.AppError)
+	statusCode := http.StatusInternalServerError
+	switch appErr.Code {
+	case errors.ErrBadRequest:
+		statusCode = http.StatusBadRequest
+	case errors.ErrNotFound:
+		statusCode = http.StatusNotFound
+	case errors.ErrConflict:
+		statusCode = http.StatusConflict
+	case errors.ErrExternalService:
+		statusCode = http.StatusServiceUnavailable
+	}
+	utils.ErrorResponse(c, statusCode, appErr)
+}
+
+// GenerateReport 生成脆弱性评估报告并归档
+// @Summary 生成脆弱性评估报告
+// @Tags Vulnerability
+// @Accept json
+// @Produce json
+// @Param request body models.GenerateReportRequest true "报告范围及格式（cabinet_id为空时生成全网报告）"
+// @Success 200 {object} utils.SuccessResponse{data=models.VulnerabilityReport}
+// @Failure 400 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/reports [post]
+func (h *VulnerabilityHandler) GenerateReport(c *gin.Context) {
+	var request models.GenerateReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.ValidationError(c, "请求参数格式错误")
+		return
+	}
+
+	generatedBy := "system"
+	if username, exists := c.Get("username"); exists {
+		generatedBy = fmt.Sprintf("%v", username)
+	}
+
+	report, err := h.vulnService.GenerateReport(c.Request.Context(), &request, generatedBy)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, report, "评估报告已生成")
+}
+
+// ListReports 查询归档的评估报告
+// @Summary 查询评估报告归档
+// @Tags Vulnerability
+// @Produce json
+// @Param cabinet_id query string false "储能柜ID"
+// @Param limit query int false "返回条数，默认50"
+// @Success 200 {object} utils.SuccessResponse{data=[]models.VulnerabilityReport}
+// @Router /api/v1/vulnerability/reports [get]
+func (h *VulnerabilityHandler) ListReports(c *gin.Context) {
+	var query models.ReportListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ValidationError(c, "查询参数格式错误")
+		return
+	}
+
+	reports, err := h.vulnService.ListReports(c.Request.Context(), &query)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	utils.Success(c, reports)
+}
+
+// DownloadReport 下载归档的评估报告
+// @Summary 下载评估报告
+// @Tags Vulnerability
+// @Produce text/html,text/markdown
+// @Param id path int true "报告ID"
+// @Success 200 {file} file
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/reports/{id}/download [get]
+func (h *VulnerabilityHandler) DownloadReport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationError(c, "无效的报告ID")
+		return
+	}
+
+	report, err := h.vulnService.GetReport(c.Request.Context(), id)
+	if err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	contentType := "text/markdown; charset=utf-8"
+	if report.Format == models.ReportFormatHTML {
+		contentType = "text/html; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, report.FileName))
+	c.Header("X-Content-SHA256", report.SHA256)
+	c.Data(http.StatusOK, contentType, []byte(report.Content))
+}
+
+// DeleteReport 删除归档的评估报告
+// @Summary 删除评估报告
+// @Tags Vulnerability
+// @Produce json
+// @Param id path int true "报告ID"
+// @Success 200 {object} utils.SuccessResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/vulnerability/reports/{id} [delete]
+func (h *VulnerabilityHandler) DeleteReport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.ValidationError(c, "无效的报告ID")
+		return
+	}
+
+	if err := h.vulnService.DeleteReport(c.Request.Context(), id); err != nil {
+		respondReportError(c, err)
+		return
+	}
+
+	utils.SuccessWithMessage(c, nil, "评估报告已删除")
+}
+
+// respondReportError 评估报告错误响应
+func respondReportError(c *gin.Context, err error) {
+	appErr := err.(*errors.AppError)
+	statusCode := http.StatusInternalServerError
+	switch appErr.Code {
+	case errors.ErrBadRequest:
+		statusCode = http.StatusBadRequest
+	case errors.ErrNotFound, errors.ErrCabinetNotFound:
+		statusCode = http.StatusNotFound
+	}
+	utils.ErrorResponse(c, statusCode, appErr)
+}
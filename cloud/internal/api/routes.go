@@ -33,6 +33,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 	vulnRepo := postgres.NewVulnerabilityRepository(pgClient.GetPool(), utils.GetLogger())
 	trafficRepo := postgres.NewTrafficRepository(pgClient.GetPool(), utils.GetLogger())
 	policyRepo := postgres.NewPolicyRepo(pgClient.GetPool())
+	abacContext := abac.NewRepositoryContextProvider(vulnRepo, alertRepo, licenseRepo)
 
 	// 初始化Service
 	authService := services.NewAuthService(userRepo, cfg)
@@ -76,14 +77,25 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 	trafficHandler := handlers.NewTrafficHandler(trafficService, cabinetService, trafficRepo)
 	mapHandler := handlers.NewMapHandler(mapService)
 	abacHandler := handlers.NewABACHandler(policyRepo)
+	abacHandler.SetCombiningAlgorithm(cfg.ABAC.CombiningAlgorithm)
 
 	// 【ABAC策略分发】初始化PolicyPublisher
 	if edgeMQTTClient != nil {
 		policyPublisher := mqtt.NewPolicyPublisher(edgeMQTTClient.GetClient(), policyRepo)
+		policyPublisher.SetCombiningAlgorithm(cfg.ABAC.CombiningAlgorithm)
 		abacHandler.SetPolicyPublisher(policyPublisher)
 		utils.Info("ABAC PolicyPublisher已初始化")
 	}
 
+	// 【固件漏洞目录下发】
+	if edgeMQTTClient != nil {
+		if svc, ok := vulnService.(interface {
+			SetFirmwareCatalogPublisher(services.FirmwareCatalogPublisher)
+		}); ok {
+			svc.SetFirmwareCatalogPublisher(mqtt.NewFirmwareCatalogPublisher(edgeMQTTClient.GetClient()))
+		}
+	}
+
 	// 全局中间件
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(middleware.RecoveryMiddleware())
@@ -125,11 +137,14 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 		// Edge端同步端点组（使用可选的API Key认证 + ABAC访问控制）
 		edgeSync := v1.Group("")
 		edgeSync.Use(middleware.EdgeAPIKeyMiddleware(cabinetRepo))
-		edgeSync.Use(abac.ABACMiddleware(policyRepo, cabinetRepo, vulnRepo))
+		edgeSync.Use(abac.ABACMiddleware(policyRepo, cabinetRepo, vulnRepo, cfg.ABAC.CombiningAlgorithm, abacContext))
 		{
 			// 许可证验证端点
 			edgeSync.POST("/license/validate", licenseHandler.ValidateLicense)
 
+			// 许可证重新下发请求（Edge漏洞处置）
+			edgeSync.POST("/cabinets/:cabinet_id/license/refresh", licenseHandler.RefreshLicense)
+
 			// 传感器数据同步端点
 			edgeSync.POST("/cabinets/:cabinet_id/sync", sensorHandler.SyncSensorData)
 
@@ -155,7 +170,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 		// 需要JWT认证的端点（+ ABAC访问控制）
 		authorized := v1.Group("")
 		authorized.Use(middleware.AuthMiddleware(cfg))
-		authorized.Use(abac.ABACMiddleware(policyRepo, nil, nil))
+		authorized.Use(abac.ABACMiddleware(policyRepo, nil, nil, cfg.ABAC.CombiningAlgorithm, abacContext))
 		{
 			// 储能柜管理
 			cabinets := authorized.Group("/cabinets")
@@ -186,9 +201,14 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 				cabinets.GET("/:cabinet_id/vulnerability/latest", vulnHandler.GetLatestAssessment)
 				cabinets.GET("/:cabinet_id/vulnerability/history", vulnHandler.GetHistory)
 				cabinets.GET("/:cabinet_id/vulnerability/stats", vulnHandler.GetStats)
+				cabinets.GET("/:cabinet_id/vulnerability/remediations", vulnHandler.ListRemediations)
 
 				// 储能柜命令下发
 				cabinets.POST("/:cabinet_id/commands", commandHandler.SendCommand)
+				cabinets.POST("/:cabinet_id/credentials/rotate", commandHandler.ForceCredentialRotation)
+				cabinets.POST("/:cabinet_id/sessions/revoke", commandHandler.RevokeSessions)
+				cabinets.POST("/:cabinet_id/config-baseline/approve", commandHandler.ApproveConfigBaseline)
+				cabinets.POST("/:cabinet_id/remediations/:remediation_id/confirm", commandHandler.ConfirmRemediation)
 			}
 
 			// 传感器设备管理
@@ -234,6 +254,24 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 			{
 				vulnerability.GET("/assessments", vulnHandler.ListAssessments)
 				vulnerability.GET("/assessments/:id", vulnHandler.GetAssessmentDetail)
+
+				// 全网看板（排名、常见漏洞类型、修复时长、风险热力）
+				vulnerability.GET("/fleet/ranking", vulnHandler.GetFleetRanking)
+				vulnerability.GET("/fleet/top-types", vulnHandler.GetFleetTopTypes)
+				vulnerability.GET("/fleet/mttr", vulnHandler.GetFleetRemediationTimes)
+				vulnerability.GET("/fleet/heatmap", vulnHandler.GetFleetHeatmap)
+
+				// 固件漏洞目录（变更后自动下发到储能柜）
+				vulnerability.GET("/certificates/expiring", vulnHandler.ListExpiringCertificates)
+				vulnerability.GET("/reports", vulnHandler.ListReports)
+				vulnerability.POST("/reports", vulnHandler.GenerateReport)
+				vulnerability.GET("/reports/:id/download", vulnHandler.DownloadReport)
+				vulnerability.DELETE("/reports/:id", vulnHandler.DeleteReport)
+				vulnerability.GET("/firmware-advisories", vulnHandler.ListFirmwareAdvisories)
+				vulnerability.POST("/firmware-advisories", vulnHandler.CreateFirmwareAdvisory)
+				vulnerability.POST("/firmware-advisories/distribute", vulnHandler.DistributeFirmwareCatalog)
+				vulnerability.PUT("/firmware-advisories/:advisory_id", vulnHandler.UpdateFirmwareAdvisory)
+				vulnerability.DELETE("/firmware-advisories/:advisory_id", vulnHandler.DeleteFirmwareAdvisory)
 			}
 
 			traffic := authorized.Group("/traffic")
@@ -271,21 +309,26 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, pgClient *postgres.Clie
 			abac := authorized.Group("/abac")
 			abac.Use(middleware.AdminMiddleware())
 			{
-				abac.GET("/policies", abacHandler.ListPolicies)                                        // 列出所有策略
-				abac.POST("/policies", abacHandler.CreatePolicy)                                       // 创建策略
-				abac.GET("/policies/:id", abacHandler.GetPolicy)                                       // 获取策略详情
-				abac.PUT("/policies/:id", abacHandler.UpdatePolicy)                                    // 更新策略
-				abac.DELETE("/policies/:id", abacHandler.DeletePolicy)                                 // 删除策略
-				abac.POST("/policies/:id/toggle", abacHandler.TogglePolicy)                            // 切换策略启用状态
-				abac.POST("/policies/:id/distribute", abacHandler.DistributePolicy)                    // 分发策略到储能柜
-				abac.GET("/policies/:id/distribution-status", abacHandler.GetPolicyDistributionStatus) // 获取策略分发状态
-				abac.POST("/policies/:id/broadcast", abacHandler.BroadcastPolicy)                      // 广播策略到所有储能柜
-				abac.POST("/cabinets/:cabinet_id/policies/sync", abacHandler.FullSyncPolicies)         // 全量同步策略
-				abac.POST("/cabinets/:cabinet_id/logs/sync", abacHandler.SyncDeviceAccessLogs)         // 接收设备访问日志
-				abac.GET("/access-logs", abacHandler.ListAccessLogs)                                   // 访问日志
-				abac.GET("/access-stats", abacHandler.GetAccessStats)                                  // 访问统计
-				abac.GET("/distribution-logs", abacHandler.ListDistributionLogs)                       // 策略分发历史
-				abac.POST("/evaluate", abacHandler.EvaluatePolicy)                                     // 测试策略评估
+				abac.GET("/policies", abacHandler.ListPolicies)                                               // 列出所有策略
+				abac.POST("/policies", abacHandler.CreatePolicy)                                              // 创建策略
+				abac.GET("/policies/:id", abacHandler.GetPolicy)                                              // 获取策略详情
+				abac.PUT("/policies/:id", abacHandler.UpdatePolicy)                                           // 更新策略
+				abac.DELETE("/policies/:id", abacHandler.DeletePolicy)                                        // 删除策略
+				abac.POST("/policies/:id/toggle", abacHandler.TogglePolicy)                                   // 切换策略启用状态
+				abac.POST("/policies/:id/distribute", abacHandler.DistributePolicy)                           // 分发策略到储能柜
+				abac.GET("/policies/:id/distribution-status", abacHandler.GetPolicyDistributionStatus)        // 获取策略分发状态
+				abac.POST("/policies/:id/broadcast", abacHandler.BroadcastPolicy)                             // 广播策略到所有储能柜
+				abac.GET("/policies/:id/shadow-report", abacHandler.GetShadowReport)                          // 试运行策略影响报告
+				abac.POST("/policies/:id/promote", abacHandler.PromotePolicy)                                 // 试运行策略转为生效
+				abac.POST("/cabinets/:cabinet_id/policies/sync", abacHandler.FullSyncPolicies)                // 全量同步策略
+				abac.POST("/cabinets/:cabinet_id/logs/sync", abacHandler.SyncDeviceAccessLogs)                // 接收设备访问日志
+				abac.GET("/access-logs", abacHandler.ListAccessLogs)                                          // 访问日志
+				abac.GET("/access-stats", abacHandler.GetAccessStats)                                         // 访问统计
+				abac.GET("/distribution-logs", abacHandler.ListDistributionLogs)                              // 策略分发历史
+				abac.GET("/sync-status", abacHandler.ListPolicySyncStatus)                                    // 各储能柜策略同步状态
+				abac.GET("/device-trust", abacHandler.ListDeviceTrust)                                        // 设备行为信任度
+				abac.GET("/cabinets/:cabinet_id/devices/:device_id/trust", abacHandler.GetDeviceTrustHistory) // 设备信任度变化记录
+				abac.POST("/evaluate", abacHandler.EvaluatePolicy)                                            // 测试策略评估
 			}
 
 		}
@@ -24,6 +24,7 @@ type Config struct {
 	Business   BusinessConfig   `mapstructure:"business"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
 	CORS       CORSConfig       `mapstructure:"cors"`
+	ABAC       ABACConfig       `mapstructure:"abac"`
 }
 
 // ServerConfig 服务器配置
@@ -228,6 +229,11 @@ type CORSConfig struct {
 	MaxAge       int      `mapstructure:"max_age"`
 }
 
+// ABACConfig ABAC访问控制配置
+type ABACConfig struct {
+	CombiningAlgorithm string `mapstructure:"combining_algorithm"` // deny-overrides, permit-overrides, first-applicable
+}
+
 var (
 	instance *Config
 	once     sync.Once
@@ -394,6 +400,14 @@ func (c *Config) Validate() error {
 		c.EdgeAPI.BaseURL = fmt.Sprintf("%s://localhost:%d/api/v1", c.EdgeAPI.Scheme, c.EdgeAPI.Port)
 	}
 
+	switch c.ABAC.CombiningAlgorithm {
+	case "":
+		c.ABAC.CombiningAlgorithm = "deny-overrides"
+	case "deny-overrides", "permit-overrides", "first-applicable":
+	default:
+		return fmt.Errorf("invalid abac combining_algorithm: %s", c.ABAC.CombiningAlgorithm)
+	}
+
 	return nil
 }
 
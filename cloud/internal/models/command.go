@@ -25,6 +25,40 @@ type SendCommandRequest struct {
 	Payload     map[string]interface{} `json:"payload" binding:"required"`
 }
 
+// CommandTypeCredentialRotate 强制设备凭证轮换命令
+const CommandTypeCredentialRotate = "credential_rotate"
+
+// CommandTypeSessionRevoke 撤销设备会话命令
+const CommandTypeSessionRevoke = "session_revoke"
+
+// CommandTypeConfigBaselineApprove 批准Edge端配置基线命令
+const CommandTypeConfigBaselineApprove = "config_baseline_approve"
+
+// CommandTypeRemediationConfirm 确认或拒绝Edge端待处置记录命令
+const CommandTypeRemediationConfirm = "remediation_confirm"
+
+// CommandTypeCertificateRequestReview 批准或拒绝Edge端设备证书申请命令
+const CommandTypeCertificateRequestReview = "certificate_request_review"
+
+// CommandTypeAuthGroupSync 同步Edge端授权群组及成员命令
+const CommandTypeAuthGroupSync = "auth_group_sync"
+
+// RevokeSessionsRequest 撤销会话请求
+type RevokeSessionsRequest struct {
+	DeviceID string `json:"device_id,omitempty"` // 为空表示储能柜下所有设备
+}
+
+// ForceCredentialRotationRequest 强制凭证轮换请求
+type ForceCredentialRotationRequest struct {
+	DeviceID string `json:"device_id,omitempty"` // 为空表示储能柜下所有设备
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ApproveConfigBaselineRequest 批准配置基线请求
+type ApproveConfigBaselineRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
 // CommandAckRequest Edge端命令回执
 type CommandAckRequest struct {
 	Status  string `json:"status" binding:"required,oneof=success failed"`
@@ -46,6 +80,7 @@ var ValidCommandTypes = []string{
 	// 配置类命令 (config)
 	"config_update",       // 配置更新
 	"config_push",         // 配置推送
+	"config_baseline_approve", // 批准配置基线（以当前配置为新基线）
 	"config",              // 通用配置命令
 	
 	// 许可证类命令 (license)
@@ -64,6 +99,11 @@ var ValidCommandTypes = []string{
 	"mode_switch",         // 切换运行模式
 	"cache_clear",         // 清理缓存
 	"resolve_alert",       // 解决告警
+	"credential_rotate",   // 强制设备凭证轮换
+	"session_revoke",      // 撤销设备会话
+	"remediation_confirm", // 确认或拒绝漏洞处置
+	"certificate_request_review", // 批准或拒绝设备证书申请
+	"auth_group_sync",     // 同步授权群组及成员
 	"control",             // 通用控制命令
 }
 
@@ -26,6 +26,7 @@ type VulnerabilityEvent struct {
 	ID           int64     `json:"id" db:"id"`
 	AssessmentID int64     `json:"assessment_id" db:"assessment_id"`
 	CabinetID    string    `json:"cabinet_id" db:"cabinet_id"`
+	DeviceID     *string   `json:"device_id,omitempty" db:"device_id"` // 设备级漏洞（如固件漏洞）关联的设备
 	EventType    string    `json:"event_type" db:"event_type"`
 	Category     string    `json:"category" db:"category"`
 	Title        string    `json:"title" db:"title"`
@@ -38,18 +39,21 @@ type VulnerabilityEvent struct {
 
 // VulnerabilitySyncRequest Edge端同步请求
 type VulnerabilitySyncRequest struct {
-	CabinetID               string                 `json:"cabinet_id" binding:"required"`
-	Timestamp               time.Time              `json:"timestamp"`
-	LicenseComplianceScore  float64                `json:"license_compliance_score"`
-	CommunicationScore      float64                `json:"communication_score" binding:"required"`
-	ConfigSecurityScore     float64                `json:"config_security_score" binding:"required"`
-	DataAnomalyScore        float64                `json:"data_anomaly_score" binding:"required"`
-	OverallScore            float64                `json:"overall_score" binding:"required"`
-	RiskLevel               string                 `json:"risk_level" binding:"required"`
-	TransmissionMetrics     map[string]interface{} `json:"transmission_metrics,omitempty"`
-	TrafficFeatures         []float64              `json:"traffic_features,omitempty"`
-	ConfigChecks            map[string]bool        `json:"config_checks,omitempty"`
-	DetectedVulnerabilities []VulnerabilityEventDTO `json:"detected_vulnerabilities,omitempty"`
+	CabinetID                string                      `json:"cabinet_id" binding:"required"`
+	Timestamp                time.Time                   `json:"timestamp"`
+	LicenseComplianceScore   float64                     `json:"license_compliance_score"`
+	CommunicationScore       float64                     `json:"communication_score" binding:"required"`
+	ConfigSecurityScore      float64                     `json:"config_security_score" binding:"required"`
+	DataAnomalyScore         float64                     `json:"data_anomaly_score" binding:"required"`
+	OverallScore             float64                     `json:"overall_score" binding:"required"`
+	RiskLevel                string                      `json:"risk_level" binding:"required"`
+	TransmissionMetrics      map[string]interface{}      `json:"transmission_metrics,omitempty"`
+	TrafficFeatures          []float64                   `json:"traffic_features,omitempty"`
+	ConfigChecks             map[string]bool             `json:"config_checks,omitempty"`
+	DetectedVulnerabilities  []VulnerabilityEventDTO     `json:"detected_vulnerabilities,omitempty"`
+	Certificates             []CabinetCertificate        `json:"certificates,omitempty"`
+	Remediations             []RemediationDTO            `json:"remediations,omitempty"`
+	DismissedVulnerabilities []DismissedVulnerabilityDTO `json:"dismissed_vulnerabilities,omitempty"`
 }
 
 // VulnerabilityEventDTO 漏洞事件DTO (从Edge端传输)
@@ -60,6 +64,7 @@ type VulnerabilityEventDTO struct {
 	Severity    string    `json:"severity"`
 	Description string    `json:"description"`
 	Solution    string    `json:"solution"`
+	DeviceID    string    `json:"device_id,omitempty"`
 	DetectedAt  time.Time `json:"detected_at"`
 }
 
@@ -87,3 +92,290 @@ type VulnerabilityDetailResponse struct {
 	Assessment *VulnerabilityAssessment `json:"assessment"`
 	Events     []*VulnerabilityEvent    `json:"events"`
 }
+
+// FirmwareAdvisory 固件漏洞公告，描述受影响的设备及固件版本范围
+type FirmwareAdvisory struct {
+	AdvisoryID   string    `json:"advisory_id" db:"advisory_id"`
+	Manufacturer string    `json:"manufacturer" db:"manufacturer"`   // 为空时匹配所有制造商
+	Model        string    `json:"model" db:"model"`                 // 为空时匹配所有型号
+	MinVersion   string    `json:"min_version" db:"min_version"`     // 受影响的最低版本（含），为空表示不限
+	MaxVersion   string    `json:"max_version" db:"max_version"`     // 受影响的最高版本（含），为空表示不限
+	FixedVersion string    `json:"fixed_version" db:"fixed_version"` // 修复版本
+	Severity     string    `json:"severity" db:"severity"`           // low/medium/high/critical
+	Title        string    `json:"title" db:"title"`
+	Description  string    `json:"description" db:"description"`
+	Remediation  string    `json:"remediation" db:"remediation"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FirmwareAdvisoryRequest 创建/更新固件漏洞公告请求
+type FirmwareAdvisoryRequest struct {
+	AdvisoryID   string `json:"advisory_id"`
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	MinVersion   string `json:"min_version"`
+	MaxVersion   string `json:"max_version"`
+	FixedVersion string `json:"fixed_version"`
+	Severity     string `json:"severity" binding:"required,oneof=low medium high critical"`
+	Title        string `json:"title" binding:"required"`
+	Description  string `json:"description"`
+	Remediation  string `json:"remediation"`
+}
+
+// CabinetCertificate 储能柜使用中的TLS证书状态（由Edge上报）
+type CabinetCertificate struct {
+	CabinetID          string     `json:"cabinet_id" db:"cabinet_id"`
+	Target             string     `json:"target" db:"target"`     // edge_https/edge_mqtt_tls/mqtt_broker/cloud_endpoint等
+	Endpoint           string     `json:"endpoint" db:"endpoint"` // 握手地址或证书文件路径
+	Subject            string     `json:"subject" db:"subject"`
+	Issuer             string     `json:"issuer" db:"issuer"`
+	SerialNumber       string     `json:"serial_number" db:"serial_number"`
+	NotAfter           *time.Time `json:"not_after,omitempty" db:"not_after"`
+	DaysToExpiry       int        `json:"days_to_expiry" db:"-"` // 按查询时间计算
+	KeyAlgorithm       string     `json:"key_algorithm" db:"key_algorithm"`
+	KeySize            int        `json:"key_size" db:"key_size"`
+	SignatureAlgorithm string     `json:"signature_algorithm" db:"signature_algorithm"`
+	ChainValid         bool       `json:"chain_valid" db:"chain_valid"`
+	ChainError         string     `json:"chain_error,omitempty" db:"chain_error"`
+	TLSVersion         string     `json:"tls_version,omitempty" db:"tls_version"`
+	CipherSuite        string     `json:"cipher_suite,omitempty" db:"cipher_suite"`
+	LegacyProtocols    []string   `json:"legacy_protocols,omitempty" db:"legacy_protocols"`
+	Error              string     `json:"error,omitempty" db:"error"`
+	CheckedAt          time.Time  `json:"checked_at" db:"checked_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ExpiringCertificateQuery 即将到期证书查询
+type ExpiringCertificateQuery struct {
+	CabinetID string `form:"cabinet_id"`
+	Days      int    `form:"days"` // 到期天数阈值，默认30天，含已过期证书
+}
+
+// RemediationDTO Edge端处置记录（随评估结果上报）
+type RemediationDTO struct {
+	ID          int64      `json:"id"`
+	EventType   string     `json:"event_type"`
+	Severity    string     `json:"severity"`
+	DeviceID    string     `json:"device_id,omitempty"`
+	Action      string     `json:"action"`
+	Mode        string     `json:"mode"`
+	Status      string     `json:"status"`
+	Result      string     `json:"result,omitempty"`
+	ConfirmedBy string     `json:"confirmed_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExecutedAt  *time.Time `json:"executed_at,omitempty"`
+}
+
+// CabinetRemediation 储能柜漏洞处置记录
+type CabinetRemediation struct {
+	CabinetID     string     `json:"cabinet_id" db:"cabinet_id"`
+	RemediationID int64      `json:"remediation_id" db:"remediation_id"` // Edge本地记录ID
+	EventType     string     `json:"event_type" db:"event_type"`
+	Severity      string     `json:"severity" db:"severity"`
+	DeviceID      string     `json:"device_id,omitempty" db:"device_id"`
+	Action        string     `json:"action" db:"action"` // block_ip/disable_device/cleanup_storage/rotate_logs/refresh_license
+	Mode          string     `json:"mode" db:"mode"`     // auto/confirm
+	Status        string     `json:"status" db:"status"` // pending/succeeded/failed/rejected
+	Result        string     `json:"result,omitempty" db:"result"`
+	ConfirmedBy   string     `json:"confirmed_by,omitempty" db:"confirmed_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ExecutedAt    *time.Time `json:"executed_at,omitempty" db:"executed_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// RemediationListQuery 处置记录查询
+type RemediationListQuery struct {
+	Status string `form:"status"`
+	Limit  int    `form:"limit"`
+}
+
+// ConfirmRemediationRequest 确认或拒绝Edge待处置记录请求
+type ConfirmRemediationRequest struct {
+	Approve *bool  `json:"approve"` // 默认true，false表示拒绝
+	Reason  string `json:"reason"`  // 拒绝原因
+}
+
+// DismissedVulnerabilityDTO Edge端漏洞消除记录（随评估结果上报）
+type DismissedVulnerabilityDTO struct {
+	ID          int64      `json:"id"`
+	Type        string     `json:"type"`
+	Reason      string     `json:"reason"`
+	DismissedBy string     `json:"dismissed_by"`
+	DismissedAt time.Time  `json:"dismissed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// CabinetDismissal 储能柜漏洞消除记录
+type CabinetDismissal struct {
+	CabinetID         string     `json:"cabinet_id" db:"cabinet_id"`
+	DismissalID       int64      `json:"dismissal_id" db:"dismissal_id"` // Edge本地记录ID
+	VulnerabilityType string     `json:"vulnerability_type" db:"vulnerability_type"`
+	Reason            string     `json:"reason" db:"reason"`
+	DismissedBy       string     `json:"dismissed_by" db:"dismissed_by"`
+	DismissedAt       time.Time  `json:"dismissed_at" db:"dismissed_at"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// 报告格式
+const (
+	ReportFormatHTML     = "html"
+	ReportFormatMarkdown = "markdown"
+)
+
+// VulnerabilityReport 脆弱性评估报告归档
+type VulnerabilityReport struct {
+	ID          int64     `json:"id" db:"id"`
+	CabinetID   string    `json:"cabinet_id" db:"cabinet_id"` // 为空表示全网报告
+	Format      string    `json:"format" db:"format"`         // html/markdown
+	StartTime   time.Time `json:"start_time" db:"start_time"`
+	EndTime     time.Time `json:"end_time" db:"end_time"`
+	Title       string    `json:"title" db:"title"`
+	FileName    string    `json:"file_name" db:"file_name"`
+	Content     string    `json:"-" db:"content"` // 报告内容，仅通过下载接口返回
+	SizeBytes   int       `json:"size_bytes" db:"size_bytes"`
+	SHA256      string    `json:"sha256" db:"sha256"`
+	GeneratedBy string    `json:"generated_by" db:"generated_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// GenerateReportRequest 生成脆弱性评估报告请求
+type GenerateReportRequest struct {
+	CabinetID string    `json:"cabinet_id"` // 为空时生成全网报告
+	Format    string    `json:"format" binding:"required,oneof=html markdown"`
+	StartTime time.Time `json:"start_time" binding:"required"`
+	EndTime   time.Time `json:"end_time" binding:"required"`
+}
+
+// ReportListQuery 报告归档查询
+type ReportListQuery struct {
+	CabinetID string `form:"cabinet_id"`
+	Limit     int    `form:"limit"`
+}
+
+// ScoreTrendPoint 按天汇总的评分趋势
+type ScoreTrendPoint struct {
+	CabinetID string    `json:"cabinet_id"`
+	Day       time.Time `json:"day"`
+	AvgScore  float64   `json:"avg_score"`
+	MinScore  float64   `json:"min_score"`
+	MaxScore  float64   `json:"max_score"`
+	Samples   int       `json:"samples"`
+}
+
+// RiskLevelPoint 风险等级变化点：区间内首次评估、等级变化的评估及最后一次评估
+type RiskLevelPoint struct {
+	CabinetID    string    `json:"cabinet_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	FromLevel    string    `json:"from_level"` // 区间内首次评估为空
+	RiskLevel    string    `json:"risk_level"`
+	OverallScore float64   `json:"overall_score"`
+	Last         bool      `json:"last"` // 区间内最后一次评估
+}
+
+// VulnerabilityEventSummary 区间内漏洞事件按储能柜及类型汇总
+type VulnerabilityEventSummary struct {
+	CabinetID   string    `json:"cabinet_id"`
+	EventType   string    `json:"event_type"`
+	Category    string    `json:"category"`
+	Title       string    `json:"title"`
+	Severity    string    `json:"severity"` // 最近一次检测的严重程度
+	DeviceID    string    `json:"device_id,omitempty"`
+	Solution    string    `json:"solution,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+	Occurrences int       `json:"occurrences"`
+	Open        bool      `json:"open"` // 区间内最后一次评估仍检测到
+}
+
+// 全网排名排序字段
+const (
+	FleetSortScore = "score" // 最新评分
+	FleetSortTrend = "trend" // 评分日均变化率
+	FleetSortDelta = "delta" // 区间内评分变化量
+)
+
+// FleetQuery 全网脆弱性看板查询
+type FleetQuery struct {
+	Days  int    `form:"days"`                                             // 统计天数，默认30，最大365
+	Limit int    `form:"limit"`                                            // 返回条数
+	Sort  string `form:"sort" binding:"omitempty,oneof=score trend delta"` // 仅排名使用，默认score
+	Order string `form:"order" binding:"omitempty,oneof=asc desc"`         // 仅排名使用，默认asc（最差在前）
+}
+
+// FleetCabinetRanking 全网储能柜评分排名
+type FleetCabinetRanking struct {
+	CabinetID    string    `json:"cabinet_id"`
+	Name         string    `json:"name"`
+	Location     string    `json:"location,omitempty"`
+	Latitude     *float64  `json:"latitude,omitempty"`
+	Longitude    *float64  `json:"longitude,omitempty"`
+	LatestScore  float64   `json:"latest_score"`
+	RiskLevel    string    `json:"risk_level"`
+	LastAssessed time.Time `json:"last_assessed"`
+	StartScore   float64   `json:"start_score"`   // 区间内首次评估评分
+	ScoreDelta   float64   `json:"score_delta"`   // 最新评分 - 首次评分
+	TrendPerDay  float64   `json:"trend_per_day"` // 评分对时间的线性回归斜率（分/天），负值表示恶化
+	AvgScore     float64   `json:"avg_score"`
+	Assessments  int       `json:"assessments"`
+}
+
+// FleetVulnerabilityType 全网常见漏洞类型（按类型前缀汇总，如firmware:xxx归为firmware）
+type FleetVulnerabilityType struct {
+	Type             string  `json:"type"`
+	Category         string  `json:"category"`
+	MaxSeverity      string  `json:"max_severity"`
+	AffectedCabinets int     `json:"affected_cabinets"` // 区间内检测到的储能柜数
+	AffectedRatio    float64 `json:"affected_ratio"`    // 占区间内有评估的储能柜比例
+	OpenCabinets     int     `json:"open_cabinets"`     // 最新评估仍检测到的储能柜数
+	Occurrences      int     `json:"occurrences"`
+}
+
+// FleetRemediationTime 按漏洞类型统计的平均修复时长
+// 一次暴露从首次检测到的评估开始，到之后第一次未检测到的评估结束（含人工消除）；
+// 区间开始前已存在的暴露按区间内首次检测计
+type FleetRemediationTime struct {
+	Type                   string   `json:"type"`
+	Exposures              int      `json:"exposures"`
+	Resolved               int      `json:"resolved"`
+	Open                   int      `json:"open"`
+	MeanSeconds            *float64 `json:"mean_seconds,omitempty"` // 已修复暴露的平均时长
+	MedianSeconds          *float64 `json:"median_seconds,omitempty"`
+	MaxSeconds             *float64 `json:"max_seconds,omitempty"`
+	Actions                int      `json:"actions"` // 处置剧本触发的动作数
+	ActionsSucceeded       int      `json:"actions_succeeded"`
+	MeanActionDelaySeconds *float64 `json:"mean_action_delay_seconds,omitempty"` // 动作创建到执行的平均时长（含等待确认）
+}
+
+// FleetHeatmapPoint 热力图点位（储能柜最新评估，points中只包含有坐标的储能柜）
+type FleetHeatmapPoint struct {
+	CabinetID       string    `json:"cabinet_id"`
+	Name            string    `json:"name"`
+	Location        string    `json:"location,omitempty"`
+	Latitude        *float64  `json:"latitude"`
+	Longitude       *float64  `json:"longitude"`
+	Score           float64   `json:"score"`
+	RiskLevel       string    `json:"risk_level"`
+	Vulnerabilities int       `json:"vulnerabilities"` // 最新评估的漏洞数
+	Weight          float64   `json:"weight"`          // 热力权重 = 100 - 评分
+	AssessedAt      time.Time `json:"assessed_at"`
+}
+
+// FleetHeatmapRegion 按位置汇总的区域风险
+type FleetHeatmapRegion struct {
+	Region     string         `json:"region"`
+	Cabinets   int            `json:"cabinets"`
+	AvgScore   float64        `json:"avg_score"`
+	MinScore   float64        `json:"min_score"`
+	RiskCounts map[string]int `json:"risk_counts"`
+}
+
+// FleetHeatmap 全网区域及风险热力数据（基于各储能柜区间内最新评估）
+type FleetHeatmap struct {
+	Points      []*FleetHeatmapPoint  `json:"points"`
+	Regions     []*FleetHeatmapRegion `json:"regions"`
+	Unlocated   int                   `json:"unlocated"` // 无坐标的储能柜数（仅计入区域汇总）
+	RiskCounts  map[string]int        `json:"risk_counts"`
+	GeneratedAt time.Time             `json:"generated_at"`
+}
@@ -189,13 +189,13 @@ const (
 // 根据 senddata.md 规范，命令 Topic 格式为: cloud/cabinets/{cabinet_id}/commands/{category}
 func GetCommandTopic(cabinetID, commandType string) string {
 	switch commandType {
-	case "config", "config_update", "config_push":
+	case "config", "config_update", "config_push", "config_baseline_approve":
 		return fmt.Sprintf(TopicCommandConfig, cabinetID)
 	case "license", "license_update", "license_push", "license_revoke":
 		return fmt.Sprintf(TopicCommandLicense, cabinetID)
 	case "query", "query_status", "query_logs":
 		return fmt.Sprintf(TopicCommandQuery, cabinetID)
-	case "control", "restart", "mode_switch", "cache_clear", "resolve_alert":
+	case "control", "restart", "mode_switch", "cache_clear", "resolve_alert", "credential_rotate", "session_revoke", "remediation_confirm", "certificate_request_review", "auth_group_sync":
 		return fmt.Sprintf(TopicCommandControl, cabinetID)
 	default:
 		// 默认使用 control 类别
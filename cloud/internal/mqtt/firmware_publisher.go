@@ -0,0 +1,77 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/utils"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+)
+
+// FirmwareCatalogMessage 固件漏洞目录下发消息（全量）
+type FirmwareCatalogMessage struct {
+	Version    string                     `json:"version"` // 目录内容摘要，Edge据此判断是否需要更新
+	Advisories []*models.FirmwareAdvisory `json:"advisories"`
+	Timestamp  time.Time                  `json:"timestamp"`
+}
+
+// FirmwareCatalogPublisher MQTT固件漏洞目录发布器
+// 目录以保留消息发布，储能柜重连后即可获取最新目录
+type FirmwareCatalogPublisher struct {
+	client mqtt.Client
+}
+
+// NewFirmwareCatalogPublisher 创建固件漏洞目录发布器
+func NewFirmwareCatalogPublisher(client mqtt.Client) *FirmwareCatalogPublisher {
+	return &FirmwareCatalogPublisher{client: client}
+}
+
+// PublishCatalog 下发完整固件漏洞目录到指定储能柜
+func (p *FirmwareCatalogPublisher) PublishCatalog(ctx context.Context, cabinetID string, advisories []*models.FirmwareAdvisory) error {
+	msg := FirmwareCatalogMessage{
+		Version:    FirmwareCatalogVersion(advisories),
+		Advisories: advisories,
+		Timestamp:  time.Now(),
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	topic := fmt.Sprintf("cloud/cabinet/%s/firmware/catalog", cabinetID)
+	token := p.client.Publish(topic, 1, true, data)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("发布MQTT消息失败: %w", token.Error())
+	}
+
+	utils.Info("固件漏洞目录已下发",
+		zap.String("topic", topic),
+		zap.String("version", msg.Version),
+		zap.Int("advisory_count", len(advisories)),
+	)
+	return nil
+}
+
+// FirmwareCatalogVersion 计算固件漏洞目录摘要（按公告ID排序，与更新时间无关）
+func FirmwareCatalogVersion(advisories []*models.FirmwareAdvisory) string {
+	sorted := make([]models.FirmwareAdvisory, 0, len(advisories))
+	for _, advisory := range advisories {
+		a := *advisory
+		a.CreatedAt, a.UpdatedAt = time.Time{}, time.Time{}
+		sorted = append(sorted, a)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].AdvisoryID < sorted[j].AdvisoryID })
+
+	data, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
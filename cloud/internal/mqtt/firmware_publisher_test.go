@@ -0,0 +1,32 @@
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"cloud-system/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirmwareCatalogVersion(t *testing.T) {
+	a := &models.FirmwareAdvisory{AdvisoryID: "FW-1", Model: "SMK-100", MaxVersion: "1.2.9", Severity: "high", Title: "a"}
+	b := &models.FirmwareAdvisory{AdvisoryID: "FW-2", Manufacturer: "acme", Severity: "low", Title: "b"}
+	version := FirmwareCatalogVersion([]*models.FirmwareAdvisory{a, b})
+	assert.Len(t, version, 64)
+
+	// 与顺序及时间戳无关
+	touched := *a
+	touched.CreatedAt, touched.UpdatedAt = time.Now(), time.Now()
+	assert.Equal(t, version, FirmwareCatalogVersion([]*models.FirmwareAdvisory{b, &touched}))
+
+	// 不修改调用方的公告
+	assert.True(t, touched.UpdatedAt.After(time.Time{}))
+
+	// 内容变化时摘要变化
+	changed := *a
+	changed.MaxVersion = "1.3.0"
+	assert.NotEqual(t, version, FirmwareCatalogVersion([]*models.FirmwareAdvisory{&changed, b}))
+	assert.NotEqual(t, version, FirmwareCatalogVersion([]*models.FirmwareAdvisory{a}))
+	assert.Equal(t, FirmwareCatalogVersion(nil), FirmwareCatalogVersion([]*models.FirmwareAdvisory{}))
+}
@@ -17,6 +17,7 @@ import (
 type PolicyPublisher struct {
 	client     mqtt.Client
 	policyRepo abac.PolicyRepository
+	algorithm  string // 随每条同步消息下发的组合算法
 }
 
 // NewPolicyPublisher 创建策略发布器
@@ -27,12 +28,18 @@ func NewPolicyPublisher(client mqtt.Client, policyRepo abac.PolicyRepository) *P
 	}
 }
 
+// SetCombiningAlgorithm 设置下发到储能柜的组合算法
+func (p *PolicyPublisher) SetCombiningAlgorithm(algorithm string) {
+	p.algorithm = algorithm
+}
+
 // PolicySyncMessage 策略同步消息格式
 type PolicySyncMessage struct {
-	Action    string               `json:"action"` // sync, delete, full_sync
-	Policies  []*abac.AccessPolicy `json:"policies,omitempty"`
-	PolicyIDs []string             `json:"policy_ids,omitempty"`
-	Timestamp time.Time            `json:"timestamp"`
+	Action             string               `json:"action"` // sync, delete, full_sync
+	Policies           []*abac.AccessPolicy `json:"policies,omitempty"`
+	PolicyIDs          []string             `json:"policy_ids,omitempty"`
+	CombiningAlgorithm string               `json:"combining_algorithm,omitempty"`
+	Timestamp          time.Time            `json:"timestamp"`
 }
 
 // DistributePolicyToCabinet 分发策略到指定储能柜
@@ -42,9 +49,9 @@ func (p *PolicyPublisher) DistributePolicyToCabinet(ctx context.Context, cabinet
 		return fmt.Errorf("获取策略失败: %w", err)
 	}
 
-	// 只分发device类型策略
-	if policy.SubjectType != "device" {
-		return fmt.Errorf("只能分发device类型策略到储能柜")
+	// 只分发储能柜本地评估的策略（device、group）
+	if !isCabinetPolicy(policy) {
+		return fmt.Errorf("只能分发device或group类型策略到储能柜")
 	}
 
 	msg := PolicySyncMessage{
@@ -66,13 +73,13 @@ func (p *PolicyPublisher) DistributePoliciesToCabinet(ctx context.Context, cabin
 			utils.Warn("获取策略失败", zap.String("policy_id", id), zap.Error(err))
 			continue
 		}
-		if policy.SubjectType == "device" {
+		if isCabinetPolicy(policy) {
 			policies = append(policies, policy)
 		}
 	}
 
 	if len(policies) == 0 {
-		return fmt.Errorf("没有可分发的device类型策略")
+		return fmt.Errorf("没有可分发的device或group类型策略")
 	}
 
 	msg := PolicySyncMessage{
@@ -84,12 +91,17 @@ func (p *PolicyPublisher) DistributePoliciesToCabinet(ctx context.Context, cabin
 	return p.publish(cabinetID, msg)
 }
 
-// FullSyncToCabinet 全量同步所有device策略到指定储能柜
+// FullSyncToCabinet 全量同步所有device、group策略到指定储能柜
 func (p *PolicyPublisher) FullSyncToCabinet(ctx context.Context, cabinetID string) error {
 	policies, err := p.policyRepo.GetBySubjectType(ctx, "device", false)
 	if err != nil {
 		return fmt.Errorf("获取device策略失败: %w", err)
 	}
+	groupPolicies, err := p.policyRepo.GetBySubjectType(ctx, "group", false)
+	if err != nil {
+		return fmt.Errorf("获取group策略失败: %w", err)
+	}
+	policies = append(policies, groupPolicies...)
 
 	msg := PolicySyncMessage{
 		Action:    "full_sync",
@@ -118,8 +130,8 @@ func (p *PolicyPublisher) BroadcastPolicyToAllCabinets(ctx context.Context, poli
 		return fmt.Errorf("获取策略失败: %w", err)
 	}
 
-	if policy.SubjectType != "device" {
-		return fmt.Errorf("只能广播device类型策略")
+	if !isCabinetPolicy(policy) {
+		return fmt.Errorf("只能广播device或group类型策略")
 	}
 
 	msg := PolicySyncMessage{
@@ -133,12 +145,18 @@ func (p *PolicyPublisher) BroadcastPolicyToAllCabinets(ctx context.Context, poli
 	return p.publishToTopic(topic, msg)
 }
 
+// isCabinetPolicy 判断策略是否在储能柜本地评估
+func isCabinetPolicy(policy *abac.AccessPolicy) bool {
+	return policy.SubjectType == string(abac.SubjectTypeDevice) || policy.SubjectType == string(abac.SubjectTypeGroup)
+}
+
 func (p *PolicyPublisher) publish(cabinetID string, msg PolicySyncMessage) error {
 	topic := fmt.Sprintf("cloud/cabinet/%s/policy/sync", cabinetID)
 	return p.publishToTopic(topic, msg)
 }
 
 func (p *PolicyPublisher) publishToTopic(topic string, msg PolicySyncMessage) error {
+	msg.CombiningAlgorithm = p.algorithm
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("序列化消息失败: %w", err)
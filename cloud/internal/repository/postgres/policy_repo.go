@@ -34,8 +34,8 @@ func (r *policyRepo) Create(ctx context.Context, policy *abac.AccessPolicy) erro
 	}
 
 	query := `
-		INSERT INTO access_policies (id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO access_policies (id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 1, $11, $12)
 	`
 
 	now := time.Now()
@@ -44,6 +44,8 @@ func (r *policyRepo) Create(ctx context.Context, policy *abac.AccessPolicy) erro
 		policy.Name,
 		policy.Description,
 		policy.SubjectType,
+		policy.GetEffect(),
+		policyMode(policy.Mode),
 		conditionsJSON,
 		permissionsJSON,
 		policy.Priority,
@@ -58,7 +60,7 @@ func (r *policyRepo) Create(ctx context.Context, policy *abac.AccessPolicy) erro
 // GetByID 根据ID获取策略
 func (r *policyRepo) GetByID(ctx context.Context, id string) (*abac.AccessPolicy, error) {
 	query := `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM access_policies
 		WHERE id = $1
 	`
@@ -71,10 +73,13 @@ func (r *policyRepo) GetByID(ctx context.Context, id string) (*abac.AccessPolicy
 		&policy.Name,
 		&policy.Description,
 		&policy.SubjectType,
+		&policy.Effect,
+		&policy.Mode,
 		&conditionsJSON,
 		&permissionsJSON,
 		&policy.Priority,
 		&policy.Enabled,
+		&policy.Version,
 		&policy.CreatedAt,
 		&policy.UpdatedAt,
 	)
@@ -90,6 +95,7 @@ func (r *policyRepo) GetByID(ctx context.Context, id string) (*abac.AccessPolicy
 	if err := json.Unmarshal(permissionsJSON, &policy.Permissions); err != nil {
 		return nil, fmt.Errorf("unmarshal permissions: %w", err)
 	}
+	policy.Hash = policy.ContentHash()
 
 	return &policy, nil
 }
@@ -135,7 +141,7 @@ func (r *policyRepo) List(ctx context.Context, filter *abac.PolicyListFilter) ([
 	// 数据查询
 	offset := (filter.Page - 1) * filter.PageSize
 	dataQuery := fmt.Sprintf(`
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM access_policies %s
 		ORDER BY priority DESC, created_at DESC
 		LIMIT $%d OFFSET $%d
@@ -159,10 +165,13 @@ func (r *policyRepo) List(ctx context.Context, filter *abac.PolicyListFilter) ([
 			&policy.Name,
 			&policy.Description,
 			&policy.SubjectType,
+			&policy.Effect,
+			&policy.Mode,
 			&conditionsJSON,
 			&permissionsJSON,
 			&policy.Priority,
 			&policy.Enabled,
+			&policy.Version,
 			&policy.CreatedAt,
 			&policy.UpdatedAt,
 		)
@@ -177,6 +186,7 @@ func (r *policyRepo) List(ctx context.Context, filter *abac.PolicyListFilter) ([
 		if err := json.Unmarshal(permissionsJSON, &policy.Permissions); err != nil {
 			return nil, 0, fmt.Errorf("unmarshal permissions: %w", err)
 		}
+		policy.Hash = policy.ContentHash()
 
 		policies = append(policies, &policy)
 	}
@@ -202,6 +212,18 @@ func (r *policyRepo) Update(ctx context.Context, id string, req *abac.UpdatePoli
 		argPos++
 	}
 
+	if req.Effect != nil {
+		updates = append(updates, fmt.Sprintf("effect = $%d", argPos))
+		args = append(args, *req.Effect)
+		argPos++
+	}
+
+	if req.Mode != nil {
+		updates = append(updates, fmt.Sprintf("mode = $%d", argPos))
+		args = append(args, policyMode(*req.Mode))
+		argPos++
+	}
+
 	if req.Conditions != nil {
 		conditionsJSON, err := json.Marshal(*req.Conditions)
 		if err != nil {
@@ -238,6 +260,7 @@ func (r *policyRepo) Update(ctx context.Context, id string, req *abac.UpdatePoli
 		return nil // 没有更新
 	}
 
+	updates = append(updates, "version = version + 1")
 	updates = append(updates, fmt.Sprintf("updated_at = $%d", argPos))
 	args = append(args, time.Now())
 	argPos++
@@ -265,7 +288,7 @@ func (r *policyRepo) Delete(ctx context.Context, id string) error {
 func (r *policyRepo) ToggleEnabled(ctx context.Context, id string) error {
 	query := `
 		UPDATE access_policies
-		SET enabled = NOT enabled, updated_at = $1
+		SET enabled = NOT enabled, version = version + 1, updated_at = $1
 		WHERE id = $2
 	`
 	_, err := r.pool.Exec(ctx, query, time.Now(), id)
@@ -275,7 +298,7 @@ func (r *policyRepo) ToggleEnabled(ctx context.Context, id string) error {
 // GetBySubjectType 根据主体类型获取策略
 func (r *policyRepo) GetBySubjectType(ctx context.Context, subjectType string, enabledOnly bool) ([]*abac.AccessPolicy, error) {
 	query := `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM access_policies
 		WHERE subject_type = $1
 	`
@@ -298,7 +321,7 @@ func (r *policyRepo) GetBySubjectType(ctx context.Context, subjectType string, e
 // GetAllEnabled 获取所有启用的策略
 func (r *policyRepo) GetAllEnabled(ctx context.Context) ([]*abac.AccessPolicy, error) {
 	query := `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM access_policies
 		WHERE enabled = true
 		ORDER BY priority DESC
@@ -316,10 +339,19 @@ func (r *policyRepo) GetAllEnabled(ctx context.Context) ([]*abac.AccessPolicy, e
 // LogAccess 记录访问日志
 func (r *policyRepo) LogAccess(ctx context.Context, log *abac.AccessLog) error {
 	query := `
-		INSERT INTO access_logs (subject_type, subject_id, resource, action, allowed, policy_id, trust_score, ip_address, timestamp, attributes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO access_logs (subject_type, subject_id, resource, action, allowed, policy_id, trust_score, ip_address, timestamp, attributes, shadow)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
+	var shadowJSON []byte
+	if len(log.Shadow) > 0 {
+		data, err := json.Marshal(log.Shadow)
+		if err != nil {
+			return fmt.Errorf("marshal shadow decisions: %w", err)
+		}
+		shadowJSON = data
+	}
+
 	_, err := r.pool.Exec(ctx, query,
 		log.SubjectType,
 		log.SubjectID,
@@ -331,6 +363,7 @@ func (r *policyRepo) LogAccess(ctx context.Context, log *abac.AccessLog) error {
 		log.IPAddress,
 		log.Timestamp,
 		log.Attributes,
+		shadowJSON,
 	)
 
 	return err
@@ -395,7 +428,7 @@ func (r *policyRepo) GetAccessLogs(ctx context.Context, filter *abac.AccessLogFi
 	// 数据查询
 	offset := (filter.Page - 1) * filter.PageSize
 	dataQuery := fmt.Sprintf(`
-		SELECT id, subject_type, subject_id, resource, action, allowed, policy_id, trust_score, ip_address, timestamp, attributes
+		SELECT id, subject_type, subject_id, resource, action, allowed, policy_id, trust_score, ip_address, timestamp, attributes, shadow
 		FROM access_logs %s
 		ORDER BY timestamp DESC
 		LIMIT $%d OFFSET $%d
@@ -412,6 +445,7 @@ func (r *policyRepo) GetAccessLogs(ctx context.Context, filter *abac.AccessLogFi
 	logs := []*abac.AccessLog{}
 	for rows.Next() {
 		var log abac.AccessLog
+		var shadowJSON []byte
 
 		err := rows.Scan(
 			&log.ID,
@@ -425,10 +459,16 @@ func (r *policyRepo) GetAccessLogs(ctx context.Context, filter *abac.AccessLogFi
 			&log.IPAddress,
 			&log.Timestamp,
 			&log.Attributes,
+			&shadowJSON,
 		)
 		if err != nil {
 			return nil, 0, err
 		}
+		if len(shadowJSON) > 0 {
+			if err := json.Unmarshal(shadowJSON, &log.Shadow); err != nil {
+				return nil, 0, fmt.Errorf("unmarshal shadow decisions: %w", err)
+			}
+		}
 
 		logs = append(logs, &log)
 	}
@@ -561,6 +601,64 @@ func (r *policyRepo) GetAccessStats(ctx context.Context, startTime, endTime *str
 	return stats, nil
 }
 
+// GetShadowReport 统计试运行策略的假设结果：评估次数及会改变结果的请求数
+func (r *policyRepo) GetShadowReport(ctx context.Context, policyID string, since *time.Time) (*abac.ShadowReport, error) {
+	report := &abac.ShadowReport{PolicyID: policyID, Since: since, TopSubjects: []abac.ShadowSubjectStat{}}
+
+	where := "l.shadow IS NOT NULL AND d->>'policy_id' = $1"
+	args := []interface{}{policyID}
+	if since != nil {
+		where += " AND l.timestamp >= $2"
+		args = append(args, *since)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE (d->>'changed')::boolean),
+			COUNT(*) FILTER (WHERE (d->>'changed')::boolean AND l.allowed),
+			COUNT(*) FILTER (WHERE (d->>'changed')::boolean AND NOT l.allowed)
+		FROM access_logs l, jsonb_array_elements(l.shadow) d
+		WHERE %s
+	`, where)
+	err := r.pool.QueryRow(ctx, query, args...).Scan(
+		&report.Evaluated,
+		&report.Changed,
+		&report.WouldDeny,
+		&report.WouldAllow,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if report.Evaluated > 0 {
+		report.ChangedRate = float64(report.Changed) / float64(report.Evaluated)
+	}
+
+	subjectQuery := fmt.Sprintf(`
+		SELECT l.subject_type, l.subject_id, COUNT(*) AS changed
+		FROM access_logs l, jsonb_array_elements(l.shadow) d
+		WHERE %s AND (d->>'changed')::boolean
+		GROUP BY l.subject_type, l.subject_id
+		ORDER BY changed DESC
+		LIMIT 10
+	`, where)
+	rows, err := r.pool.Query(ctx, subjectQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat abac.ShadowSubjectStat
+		if err := rows.Scan(&stat.SubjectType, &stat.SubjectID, &stat.Changed); err != nil {
+			return nil, err
+		}
+		report.TopSubjects = append(report.TopSubjects, stat)
+	}
+
+	return report, rows.Err()
+}
+
 // LogDistribution 记录策略分发日志
 func (r *policyRepo) LogDistribution(ctx context.Context, log *abac.DistributionLog) error {
 	query := `
@@ -704,10 +802,13 @@ func (r *policyRepo) scanPolicies(rows interface {
 			&policy.Name,
 			&policy.Description,
 			&policy.SubjectType,
+			&policy.Effect,
+			&policy.Mode,
 			&conditionsJSON,
 			&permissionsJSON,
 			&policy.Priority,
 			&policy.Enabled,
+			&policy.Version,
 			&policy.CreatedAt,
 			&policy.UpdatedAt,
 		)
@@ -722,9 +823,295 @@ func (r *policyRepo) scanPolicies(rows interface {
 		if err := json.Unmarshal(permissionsJSON, &policy.Permissions); err != nil {
 			return nil, fmt.Errorf("unmarshal permissions: %w", err)
 		}
+		policy.Hash = policy.ContentHash()
 
 		policies = append(policies, &policy)
 	}
 
 	return policies, nil
 }
+
+// SavePolicySyncStatus 保存储能柜策略同步状态
+func (r *policyRepo) SavePolicySyncStatus(ctx context.Context, status *abac.PolicySyncStatus) error {
+	driftJSON, err := json.Marshal(status.Drift)
+	if err != nil {
+		return fmt.Errorf("marshal drift: %w", err)
+	}
+
+	query := `
+		INSERT INTO policy_sync_status (cabinet_id, in_sync, expected_digest, reported_digest, expected_count, reported_count, drift, last_action, last_heartbeat_at, last_reconciled_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (cabinet_id) DO UPDATE SET
+			in_sync = EXCLUDED.in_sync,
+			expected_digest = EXCLUDED.expected_digest,
+			reported_digest = EXCLUDED.reported_digest,
+			expected_count = EXCLUDED.expected_count,
+			reported_count = EXCLUDED.reported_count,
+			drift = EXCLUDED.drift,
+			last_action = EXCLUDED.last_action,
+			last_heartbeat_at = EXCLUDED.last_heartbeat_at,
+			last_reconciled_at = COALESCE(EXCLUDED.last_reconciled_at, policy_sync_status.last_reconciled_at)
+	`
+
+	_, err = r.pool.Exec(ctx, query,
+		status.CabinetID,
+		status.InSync,
+		status.ExpectedDigest,
+		status.ReportedDigest,
+		status.ExpectedCount,
+		status.ReportedCount,
+		driftJSON,
+		status.LastAction,
+		status.LastHeartbeatAt,
+		status.LastReconciledAt,
+	)
+	return err
+}
+
+// GetPolicySyncStatus 获取储能柜策略同步状态
+func (r *policyRepo) GetPolicySyncStatus(ctx context.Context, cabinetID string) (*abac.PolicySyncStatus, error) {
+	query := `
+		SELECT cabinet_id, in_sync, expected_digest, reported_digest, expected_count, reported_count, drift, last_action, last_heartbeat_at, last_reconciled_at
+		FROM policy_sync_status
+		WHERE cabinet_id = $1
+	`
+
+	rows, err := r.pool.Query(ctx, query, cabinetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses, err := r.scanPolicySyncStatus(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	return statuses[0], nil
+}
+
+// ListPolicySyncStatus 列出所有储能柜的策略同步状态
+func (r *policyRepo) ListPolicySyncStatus(ctx context.Context) ([]*abac.PolicySyncStatus, error) {
+	query := `
+		SELECT cabinet_id, in_sync, expected_digest, reported_digest, expected_count, reported_count, drift, last_action, last_heartbeat_at, last_reconciled_at
+		FROM policy_sync_status
+		ORDER BY in_sync ASC, last_heartbeat_at DESC
+	`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanPolicySyncStatus(rows)
+}
+
+// scanPolicySyncStatus 扫描策略同步状态行
+func (r *policyRepo) scanPolicySyncStatus(rows interface {
+	Next() bool
+	Scan(...interface{}) error
+}) ([]*abac.PolicySyncStatus, error) {
+	statuses := []*abac.PolicySyncStatus{}
+
+	for rows.Next() {
+		var status abac.PolicySyncStatus
+		var driftJSON []byte
+
+		err := rows.Scan(
+			&status.CabinetID,
+			&status.InSync,
+			&status.ExpectedDigest,
+			&status.ReportedDigest,
+			&status.ExpectedCount,
+			&status.ReportedCount,
+			&driftJSON,
+			&status.LastAction,
+			&status.LastHeartbeatAt,
+			&status.LastReconciledAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(driftJSON) > 0 {
+			if err := json.Unmarshal(driftJSON, &status.Drift); err != nil {
+				return nil, fmt.Errorf("unmarshal drift: %w", err)
+			}
+		}
+
+		statuses = append(statuses, &status)
+	}
+
+	return statuses, nil
+}
+
+// policyMode 规范化策略模式，未指定时为enforce
+func policyMode(mode string) string {
+	if mode == abac.ModeShadow {
+		return abac.ModeShadow
+	}
+	return abac.ModeEnforce
+}
+
+// SaveDeviceTrust 保存Edge上报的设备行为信任度
+func (r *policyRepo) SaveDeviceTrust(ctx context.Context, trust *abac.DeviceTrust) error {
+	signalsJSON, err := json.Marshal(trust.Signals)
+	if err != nil {
+		return fmt.Errorf("marshal signals: %w", err)
+	}
+
+	query := `
+		INSERT INTO device_trust_scores (cabinet_id, device_id, score, penalty, firmware_penalty, firmware_version, signals, last_signal, last_signal_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (cabinet_id, device_id) DO UPDATE SET
+			score = EXCLUDED.score,
+			penalty = EXCLUDED.penalty,
+			firmware_penalty = EXCLUDED.firmware_penalty,
+			firmware_version = EXCLUDED.firmware_version,
+			signals = EXCLUDED.signals,
+			last_signal = EXCLUDED.last_signal,
+			last_signal_at = EXCLUDED.last_signal_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE device_trust_scores.updated_at IS NULL OR device_trust_scores.updated_at <= EXCLUDED.updated_at
+	`
+
+	_, err = r.pool.Exec(ctx, query,
+		trust.CabinetID,
+		trust.DeviceID,
+		trust.Score,
+		trust.Penalty,
+		trust.FirmwarePenalty,
+		trust.FirmwareVersion,
+		signalsJSON,
+		trust.LastSignal,
+		trust.LastSignalAt,
+		trust.UpdatedAt,
+	)
+	return err
+}
+
+// ListDeviceTrust 查询设备行为信任度（按分数升序）
+func (r *policyRepo) ListDeviceTrust(ctx context.Context, filter *abac.DeviceTrustFilter) ([]*abac.DeviceTrust, error) {
+	where := []string{"1=1"}
+	args := []interface{}{}
+	argIdx := 1
+
+	if filter != nil && filter.CabinetID != "" {
+		where = append(where, fmt.Sprintf("cabinet_id = $%d", argIdx))
+		args = append(args, filter.CabinetID)
+		argIdx++
+	}
+	if filter != nil && filter.MaxScore != nil {
+		where = append(where, fmt.Sprintf("score <= $%d", argIdx))
+		args = append(args, *filter.MaxScore)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT cabinet_id, device_id, score, penalty, firmware_penalty, COALESCE(firmware_version, ''), signals, COALESCE(last_signal, ''), last_signal_at, updated_at
+		FROM device_trust_scores
+		WHERE %s
+		ORDER BY score ASC, cabinet_id, device_id
+	`, strings.Join(where, " AND "))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trusts := []*abac.DeviceTrust{}
+	for rows.Next() {
+		var trust abac.DeviceTrust
+		var signalsJSON []byte
+
+		err := rows.Scan(
+			&trust.CabinetID,
+			&trust.DeviceID,
+			&trust.Score,
+			&trust.Penalty,
+			&trust.FirmwarePenalty,
+			&trust.FirmwareVersion,
+			&signalsJSON,
+			&trust.LastSignal,
+			&trust.LastSignalAt,
+			&trust.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		trust.Signals = map[string]int64{}
+		if len(signalsJSON) > 0 {
+			if err := json.Unmarshal(signalsJSON, &trust.Signals); err != nil {
+				return nil, fmt.Errorf("unmarshal signals: %w", err)
+			}
+		}
+
+		trusts = append(trusts, &trust)
+	}
+
+	return trusts, rows.Err()
+}
+
+// SaveTrustEvents 批量保存设备信任度变化记录
+func (r *policyRepo) SaveTrustEvents(ctx context.Context, events []*abac.TrustEvent) error {
+	query := `
+		INSERT INTO device_trust_events (cabinet_id, device_id, signal, delta, score, timestamp)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	for _, event := range events {
+		_, err := r.pool.Exec(ctx, query,
+			event.CabinetID,
+			event.DeviceID,
+			event.Signal,
+			event.Delta,
+			event.Score,
+			event.Timestamp,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTrustHistory 获取设备信任度变化记录（最新在前）
+func (r *policyRepo) GetTrustHistory(ctx context.Context, cabinetID, deviceID string, limit int) ([]*abac.TrustEvent, error) {
+	query := `
+		SELECT id, cabinet_id, device_id, signal, delta, score, timestamp
+		FROM device_trust_events
+		WHERE cabinet_id = $1 AND device_id = $2
+		ORDER BY timestamp DESC, id DESC
+		LIMIT $3
+	`
+
+	rows, err := r.pool.Query(ctx, query, cabinetID, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*abac.TrustEvent{}
+	for rows.Next() {
+		var event abac.TrustEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.CabinetID,
+			&event.DeviceID,
+			&event.Signal,
+			&event.Delta,
+			&event.Score,
+			&event.Timestamp,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
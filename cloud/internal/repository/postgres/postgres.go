@@ -85,7 +85,7 @@ func (c *Client) RunMigrations(ctx context.Context, migrationsPath string) error
 	logger := utils.GetLogger()
 	logger.Info("Running database migrations", zap.String("path", migrationsPath))
 
-	// 步骤1: 初始化核心Schema（14张表+索引+触发器+Hypertables+初始数据）
+	// 步骤1: 初始化核心Schema（22张表+索引+触发器+Hypertables+初始数据）
 	// 使用InitSchema创建完整数据库结构（如果表已存在则跳过）
 	if err := InitSchema(ctx, c.pool); err != nil {
 		// Schema初始化失败记录警告但不中断（允许使用现有数据库）
@@ -170,6 +170,36 @@ func (c *Client) migrateActivationFields(ctx context.Context) error {
 				ALTER TABLE cabinets ADD COLUMN notes TEXT;
 			END IF;
 		END $$;`,
+		`DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'access_policies' AND column_name = 'effect') THEN
+				ALTER TABLE access_policies ADD COLUMN effect TEXT NOT NULL DEFAULT 'allow';
+			END IF;
+		END $$;`,
+		`DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'access_policies' AND column_name = 'version') THEN
+				ALTER TABLE access_policies ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+			END IF;
+		END $$;`,
+		`DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'access_policies' AND column_name = 'mode') THEN
+				ALTER TABLE access_policies ADD COLUMN mode TEXT NOT NULL DEFAULT 'enforce';
+			END IF;
+		END $$;`,
+		`DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'access_logs' AND column_name = 'shadow') THEN
+				ALTER TABLE access_logs ADD COLUMN shadow JSONB;
+			END IF;
+		END $$;`,
+		`DO $$ 
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'vulnerability_events' AND column_name = 'device_id') THEN
+				ALTER TABLE vulnerability_events ADD COLUMN device_id VARCHAR(64);
+			END IF;
+		END $$;`,
 	}
 
 	for _, migration := range migrations {
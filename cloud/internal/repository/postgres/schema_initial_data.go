@@ -139,6 +139,18 @@ func insertABACPolicies(ctx context.Context, conn *pgxpool.Conn) error {
 			permissions: `["write:sensor_data", "trigger:alert"]`,
 			priority:    70,
 		},
+		// 策略6: 设备MQTT上行及命令订阅
+		{
+			id:          "policy_device_mqtt_topics",
+			name:        "设备MQTT上行及命令订阅",
+			description: "未禁用的设备可以发布传感器数据、状态、心跳和告警，并订阅下行命令",
+			subjectType: "device",
+			conditions: `[
+                {"attribute": "status", "operator": "ne", "value": "disabled"}
+            ]`,
+			permissions: `["write:sensors", "write:status", "write:heartbeat", "write:alerts", "read:commands"]`,
+			priority:    40,
+		},
 	}
 
 	query := `
@@ -12,7 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// TestInitSchema_AllTablesCreated 测试所有14张表都被创建
+// TestInitSchema_AllTablesCreated 测试所有22张表都被创建
 func TestInitSchema_AllTablesCreated(t *testing.T) {
 	ctx := context.Background()
 
@@ -30,7 +30,7 @@ func TestInitSchema_AllTablesCreated(t *testing.T) {
 	err = InitSchema(ctx, pool)
 	require.NoError(t, err, "InitSchema should succeed")
 
-	// 验证14张表都存在
+	// 验证22张表都存在
 	expectedTables := []string{
 		"cabinets",
 		"users",
@@ -46,6 +46,14 @@ func TestInitSchema_AllTablesCreated(t *testing.T) {
 		"access_policies",
 		"access_logs",
 		"policy_distribution_logs",
+		"policy_sync_status",
+		"device_trust_scores",
+		"device_trust_events",
+		"firmware_advisories",
+		"cabinet_certificates",
+		"cabinet_remediations",
+		"cabinet_dismissals",
+		"vulnerability_reports",
 	}
 
 	for _, tableName := range expectedTables {
@@ -234,13 +242,13 @@ func TestInitSchema_DefaultDataInserted(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, userCount, "Should have exactly one admin user")
 
-	// 验证5条ABAC策略存在
+	// 验证6条ABAC策略存在
 	var policyCount int
 	err = pool.QueryRow(ctx, `
 		SELECT COUNT(*) FROM access_policies
 	`).Scan(&policyCount)
 	require.NoError(t, err)
-	assert.Equal(t, 5, policyCount, "Should have exactly 5 ABAC policies")
+	assert.Equal(t, 6, policyCount, "Should have exactly 6 ABAC policies")
 
 	// 验证具体策略存在
 	expectedPolicies := []string{
@@ -249,6 +257,7 @@ func TestInitSchema_DefaultDataInserted(t *testing.T) {
 		"policy_cabinet_sync",
 		"policy_cabinet_limited",
 		"policy_device_high_quality",
+		"policy_device_mqtt_topics",
 	}
 
 	for _, policyID := range expectedPolicies {
@@ -294,7 +303,7 @@ func TestInitSchema_Idempotency(t *testing.T) {
 	var policyCount int
 	err = pool.QueryRow(ctx, `SELECT COUNT(*) FROM access_policies`).Scan(&policyCount)
 	require.NoError(t, err)
-	assert.Equal(t, 5, policyCount, "Policies should not be duplicated")
+	assert.Equal(t, 6, policyCount, "Policies should not be duplicated")
 }
 
 // TestInitSchema_WithoutTimescaleDB 测试在没有TimescaleDB的情况下的降级行为
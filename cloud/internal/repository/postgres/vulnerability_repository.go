@@ -90,9 +90,9 @@ func (r *VulnerabilityRepository) CreateEvents(ctx context.Context, events []*mo
 
 	query := `
 		INSERT INTO vulnerability_events (
-			assessment_id, cabinet_id, event_type, category,
+			assessment_id, cabinet_id, device_id, event_type, category,
 			title, severity, description, solution, detected_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
 
 	for _, event := range events {
@@ -100,6 +100,7 @@ func (r *VulnerabilityRepository) CreateEvents(ctx context.Context, events []*mo
 			ctx, query,
 			event.AssessmentID,
 			event.CabinetID,
+			event.DeviceID,
 			event.EventType,
 			event.Category,
 			event.Title,
@@ -214,7 +215,7 @@ func (r *VulnerabilityRepository) GetAssessmentByID(ctx context.Context, id int6
 // GetEventsByAssessmentID 获取评估的所有漏洞事件
 func (r *VulnerabilityRepository) GetEventsByAssessmentID(ctx context.Context, assessmentID int64) ([]*models.VulnerabilityEvent, error) {
 	query := `
-		SELECT id, assessment_id, cabinet_id, event_type, category,
+		SELECT id, assessment_id, cabinet_id, device_id, event_type, category,
 			title, severity, description, solution, detected_at, created_at
 		FROM vulnerability_events
 		WHERE assessment_id = $1
@@ -235,6 +236,7 @@ func (r *VulnerabilityRepository) GetEventsByAssessmentID(ctx context.Context, a
 			&event.ID,
 			&event.AssessmentID,
 			&event.CabinetID,
+			&event.DeviceID,
 			&event.EventType,
 			&event.Category,
 			&event.Title,
@@ -514,6 +516,899 @@ func (r *VulnerabilityRepository) GetStatsByCabinetID(ctx context.Context, cabin
 	return result, nil
 }
 
+// SaveFirmwareAdvisory 创建或更新固件漏洞公告
+func (r *VulnerabilityRepository) SaveFirmwareAdvisory(ctx context.Context, advisory *models.FirmwareAdvisory) error {
+	query := `
+		INSERT INTO firmware_advisories (
+			advisory_id, manufacturer, model, min_version, max_version,
+			fixed_version, severity, title, description, remediation,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (advisory_id) DO UPDATE SET
+			manufacturer = EXCLUDED.manufacturer,
+			model = EXCLUDED.model,
+			min_version = EXCLUDED.min_version,
+			max_version = EXCLUDED.max_version,
+			fixed_version = EXCLUDED.fixed_version,
+			severity = EXCLUDED.severity,
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			remediation = EXCLUDED.remediation,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.pool.Exec(ctx, query,
+		advisory.AdvisoryID,
+		advisory.Manufacturer,
+		advisory.Model,
+		advisory.MinVersion,
+		advisory.MaxVersion,
+		advisory.FixedVersion,
+		advisory.Severity,
+		advisory.Title,
+		advisory.Description,
+		advisory.Remediation,
+		advisory.CreatedAt,
+		advisory.UpdatedAt,
+	)
+	if err != nil {
+		r.logger.Error("保存固件漏洞公告失败", zap.String("advisory_id", advisory.AdvisoryID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+const firmwareAdvisoryColumns = `advisory_id, manufacturer, model, min_version, max_version,
+			fixed_version, severity, title, description, remediation,
+			created_at, updated_at`
+
+func scanFirmwareAdvisory(row pgx.Row) (*models.FirmwareAdvisory, error) {
+	advisory := &models.FirmwareAdvisory{}
+	err := row.Scan(
+		&advisory.AdvisoryID,
+		&advisory.Manufacturer,
+		&advisory.Model,
+		&advisory.MinVersion,
+		&advisory.MaxVersion,
+		&advisory.FixedVersion,
+		&advisory.Severity,
+		&advisory.Title,
+		&advisory.Description,
+		&advisory.Remediation,
+		&advisory.CreatedAt,
+		&advisory.UpdatedAt,
+	)
+	return advisory, err
+}
+
+// GetFirmwareAdvisory 获取固件漏洞公告
+func (r *VulnerabilityRepository) GetFirmwareAdvisory(ctx context.Context, advisoryID string) (*models.FirmwareAdvisory, error) {
+	query := `SELECT ` + firmwareAdvisoryColumns + ` FROM firmware_advisories WHERE advisory_id = $1`
+
+	advisory, err := scanFirmwareAdvisory(r.pool.QueryRow(ctx, query, advisoryID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("获取固件漏洞公告失败", zap.Error(err))
+		return nil, err
+	}
+	return advisory, nil
+}
+
+// ListFirmwareAdvisories 获取全部固件漏洞公告
+func (r *VulnerabilityRepository) ListFirmwareAdvisories(ctx context.Context) ([]*models.FirmwareAdvisory, error) {
+	query := `SELECT ` + firmwareAdvisoryColumns + ` FROM firmware_advisories ORDER BY advisory_id`
+
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		r.logger.Error("查询固件漏洞公告失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	advisories := []*models.FirmwareAdvisory{}
+	for rows.Next() {
+		advisory, err := scanFirmwareAdvisory(rows)
+		if err != nil {
+			r.logger.Error("扫描固件漏洞公告失败", zap.Error(err))
+			return nil, err
+		}
+		advisories = append(advisories, advisory)
+	}
+	return advisories, rows.Err()
+}
+
+// DeleteFirmwareAdvisory 删除固件漏洞公告
+func (r *VulnerabilityRepository) DeleteFirmwareAdvisory(ctx context.Context, advisoryID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM firmware_advisories WHERE advisory_id = $1`, advisoryID)
+	if err != nil {
+		r.logger.Error("删除固件漏洞公告失败", zap.String("advisory_id", advisoryID), zap.Error(err))
+	}
+	return err
+}
+
+// ReplaceCabinetCertificates 以Edge最新上报的证书状态替换储能柜的证书记录
+func (r *VulnerabilityRepository) ReplaceCabinetCertificates(ctx context.Context, cabinetID string, certificates []models.CabinetCertificate) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM cabinet_certificates WHERE cabinet_id = $1`, cabinetID); err != nil {
+		r.logger.Error("清理储能柜证书记录失败", zap.String("cabinet_id", cabinetID), zap.Error(err))
+		return err
+	}
+
+	query := `
+		INSERT INTO cabinet_certificates (
+			cabinet_id, target, endpoint, subject, issuer, serial_number,
+			not_after, key_algorithm, key_size, signature_algorithm,
+			chain_valid, chain_error, tls_version, cipher_suite,
+			legacy_protocols, error, checked_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, NOW())
+		ON CONFLICT (cabinet_id, target) DO NOTHING
+	`
+	for _, cert := range certificates {
+		legacy := cert.LegacyProtocols
+		if legacy == nil {
+			legacy = []string{}
+		}
+		if _, err := tx.Exec(ctx, query,
+			cabinetID,
+			cert.Target,
+			cert.Endpoint,
+			cert.Subject,
+			cert.Issuer,
+			cert.SerialNumber,
+			cert.NotAfter,
+			cert.KeyAlgorithm,
+			cert.KeySize,
+			cert.SignatureAlgorithm,
+			cert.ChainValid,
+			cert.ChainError,
+			cert.TLSVersion,
+			cert.CipherSuite,
+			legacy,
+			cert.Error,
+			cert.CheckedAt,
+		); err != nil {
+			r.logger.Error("保存储能柜证书记录失败", zap.String("cabinet_id", cabinetID), zap.String("target", cert.Target), zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListExpiringCertificates 查询到期时间早于before的证书，按到期时间排序
+func (r *VulnerabilityRepository) ListExpiringCertificates(ctx context.Context, cabinetID string, before time.Time) ([]*models.CabinetCertificate, error) {
+	query := `
+		SELECT cabinet_id, target, endpoint, subject, issuer, serial_number,
+			not_after, key_algorithm, key_size, signature_algorithm,
+			chain_valid, chain_error, tls_version, cipher_suite,
+			legacy_protocols, error, checked_at, updated_at
+		FROM cabinet_certificates
+		WHERE not_after IS NOT NULL AND not_after < $1 AND ($2 = '' OR cabinet_id = $2)
+		ORDER BY not_after, cabinet_id, target
+	`
+
+	rows, err := r.pool.Query(ctx, query, before, cabinetID)
+	if err != nil {
+		r.logger.Error("查询即将到期证书失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	certificates := []*models.CabinetCertificate{}
+	for rows.Next() {
+		cert := &models.CabinetCertificate{}
+		if err := rows.Scan(
+			&cert.CabinetID,
+			&cert.Target,
+			&cert.Endpoint,
+			&cert.Subject,
+			&cert.Issuer,
+			&cert.SerialNumber,
+			&cert.NotAfter,
+			&cert.KeyAlgorithm,
+			&cert.KeySize,
+			&cert.SignatureAlgorithm,
+			&cert.ChainValid,
+			&cert.ChainError,
+			&cert.TLSVersion,
+			&cert.CipherSuite,
+			&cert.LegacyProtocols,
+			&cert.Error,
+			&cert.CheckedAt,
+			&cert.UpdatedAt,
+		); err != nil {
+			r.logger.Error("扫描证书记录失败", zap.Error(err))
+			return nil, err
+		}
+		certificates = append(certificates, cert)
+	}
+	return certificates, rows.Err()
+}
+
+// UpsertRemediations 按储能柜及Edge记录ID保存处置记录，状态以Edge最新上报为准
+func (r *VulnerabilityRepository) UpsertRemediations(ctx context.Context, remediations []*models.CabinetRemediation) error {
+	query := `
+		INSERT INTO cabinet_remediations (
+			cabinet_id, remediation_id, event_type, severity, device_id, action, mode,
+			status, result, confirmed_by, created_at, executed_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		ON CONFLICT (cabinet_id, remediation_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			result = EXCLUDED.result,
+			confirmed_by = EXCLUDED.confirmed_by,
+			executed_at = EXCLUDED.executed_at,
+			updated_at = NOW()
+	`
+	for _, item := range remediations {
+		if _, err := r.pool.Exec(ctx, query,
+			item.CabinetID,
+			item.RemediationID,
+			item.EventType,
+			item.Severity,
+			item.DeviceID,
+			item.Action,
+			item.Mode,
+			item.Status,
+			item.Result,
+			item.ConfirmedBy,
+			item.CreatedAt,
+			item.ExecutedAt,
+		); err != nil {
+			r.logger.Error("保存处置记录失败",
+				zap.String("cabinet_id", item.CabinetID),
+				zap.Int64("remediation_id", item.RemediationID),
+				zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRemediations 查询储能柜处置记录，按创建时间倒序
+func (r *VulnerabilityRepository) ListRemediations(ctx context.Context, cabinetID, status string, limit int) ([]*models.CabinetRemediation, error) {
+	query := `
+		SELECT cabinet_id, remediation_id, event_type, severity, device_id, action, mode,
+			status, result, confirmed_by, created_at, executed_at, updated_at
+		FROM cabinet_remediations
+		WHERE cabinet_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, remediation_id DESC
+		LIMIT $3
+	`
+
+	return r.queryRemediations(ctx, query, cabinetID, status, limit)
+}
+
+// queryRemediations 执行处置记录查询
+func (r *VulnerabilityRepository) queryRemediations(ctx context.Context, query string, args ...interface{}) ([]*models.CabinetRemediation, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("查询处置记录失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	remediations := []*models.CabinetRemediation{}
+	for rows.Next() {
+		item := &models.CabinetRemediation{}
+		if err := rows.Scan(
+			&item.CabinetID,
+			&item.RemediationID,
+			&item.EventType,
+			&item.Severity,
+			&item.DeviceID,
+			&item.Action,
+			&item.Mode,
+			&item.Status,
+			&item.Result,
+			&item.ConfirmedBy,
+			&item.CreatedAt,
+			&item.ExecutedAt,
+			&item.UpdatedAt,
+		); err != nil {
+			r.logger.Error("扫描处置记录失败", zap.Error(err))
+			return nil, err
+		}
+		remediations = append(remediations, item)
+	}
+	return remediations, rows.Err()
+}
+
+// UpsertDismissals 按储能柜及Edge记录ID保存漏洞消除记录
+func (r *VulnerabilityRepository) UpsertDismissals(ctx context.Context, dismissals []*models.CabinetDismissal) error {
+	query := `
+		INSERT INTO cabinet_dismissals (
+			cabinet_id, dismissal_id, vulnerability_type, reason, dismissed_by,
+			dismissed_at, expires_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		ON CONFLICT (cabinet_id, dismissal_id) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			expires_at = EXCLUDED.expires_at,
+			updated_at = NOW()
+	`
+	for _, item := range dismissals {
+		if _, err := r.pool.Exec(ctx, query,
+			item.CabinetID,
+			item.DismissalID,
+			item.VulnerabilityType,
+			item.Reason,
+			item.DismissedBy,
+			item.DismissedAt,
+			item.ExpiresAt,
+		); err != nil {
+			r.logger.Error("保存漏洞消除记录失败",
+				zap.String("cabinet_id", item.CabinetID),
+				zap.Int64("dismissal_id", item.DismissalID),
+				zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// reportRowLimit 报告明细查询的最大行数
+const reportRowLimit = 5000
+
+// GetScoreTrend 按储能柜及天汇总区间内的综合评分
+func (r *VulnerabilityRepository) GetScoreTrend(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.ScoreTrendPoint, error) {
+	query := `
+		SELECT cabinet_id, date_trunc('day', timestamp) AS day,
+			AVG(overall_score), MIN(overall_score), MAX(overall_score), COUNT(*)
+		FROM vulnerability_assessments
+		WHERE timestamp >= $1 AND timestamp <= $2 AND ($3 = '' OR cabinet_id = $3)
+		GROUP BY cabinet_id, day
+		ORDER BY cabinet_id, day
+	`
+
+	rows, err := r.pool.Query(ctx, query, startTime, endTime, cabinetID)
+	if err != nil {
+		r.logger.Error("查询评分趋势失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*models.ScoreTrendPoint{}
+	for rows.Next() {
+		point := &models.ScoreTrendPoint{}
+		if err := rows.Scan(&point.CabinetID, &point.Day, &point.AvgScore, &point.MinScore, &point.MaxScore, &point.Samples); err != nil {
+			r.logger.Error("扫描评分趋势失败", zap.Error(err))
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// GetRiskLevelPoints 获取区间内每个储能柜的首次评估、风险等级变化及最后一次评估
+func (r *VulnerabilityRepository) GetRiskLevelPoints(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.RiskLevelPoint, error) {
+	query := `
+		SELECT cabinet_id, timestamp, COALESCE(prev_level, ''), risk_level, overall_score, is_last
+		FROM (
+			SELECT cabinet_id, timestamp, risk_level, overall_score,
+				LAG(risk_level) OVER w AS prev_level,
+				LEAD(timestamp) OVER w IS NULL AS is_last
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1 AND timestamp <= $2 AND ($3 = '' OR cabinet_id = $3)
+			WINDOW w AS (PARTITION BY cabinet_id ORDER BY timestamp)
+		) t
+		WHERE prev_level IS NULL OR prev_level <> risk_level OR is_last
+		ORDER BY cabinet_id, timestamp
+		LIMIT $4
+	`
+
+	rows, err := r.pool.Query(ctx, query, startTime, endTime, cabinetID, reportRowLimit)
+	if err != nil {
+		r.logger.Error("查询风险等级变化失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*models.RiskLevelPoint{}
+	for rows.Next() {
+		point := &models.RiskLevelPoint{}
+		if err := rows.Scan(&point.CabinetID, &point.Timestamp, &point.FromLevel, &point.RiskLevel, &point.OverallScore, &point.Last); err != nil {
+			r.logger.Error("扫描风险等级变化失败", zap.Error(err))
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// SummarizeEvents 按储能柜、事件类型及设备汇总区间内的漏洞事件，区间内最后一次评估仍检测到的为未关闭
+func (r *VulnerabilityRepository) SummarizeEvents(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.VulnerabilityEventSummary, error) {
+	query := `
+		WITH assessments AS (
+			SELECT id, cabinet_id, timestamp
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1 AND timestamp <= $2 AND ($3 = '' OR cabinet_id = $3)
+		), latest AS (
+			SELECT DISTINCT ON (cabinet_id) cabinet_id, id
+			FROM assessments
+			ORDER BY cabinet_id, timestamp DESC
+		)
+		SELECT e.cabinet_id, e.event_type, COALESCE(e.device_id, ''),
+			(ARRAY_AGG(e.category ORDER BY e.detected_at DESC))[1],
+			(ARRAY_AGG(e.title ORDER BY e.detected_at DESC))[1],
+			(ARRAY_AGG(e.severity ORDER BY e.detected_at DESC))[1],
+			(ARRAY_AGG(COALESCE(e.solution, '') ORDER BY e.detected_at DESC))[1],
+			MIN(e.detected_at), MAX(e.detected_at), COUNT(*),
+			BOOL_OR(e.assessment_id = l.id)
+		FROM vulnerability_events e
+		JOIN assessments a ON a.id = e.assessment_id
+		JOIN latest l ON l.cabinet_id = a.cabinet_id
+		GROUP BY e.cabinet_id, e.event_type, COALESCE(e.device_id, '')
+		ORDER BY BOOL_OR(e.assessment_id = l.id) DESC, e.cabinet_id, MAX(e.detected_at) DESC
+		LIMIT $4
+	`
+
+	rows, err := r.pool.Query(ctx, query, startTime, endTime, cabinetID, reportRowLimit)
+	if err != nil {
+		r.logger.Error("汇总漏洞事件失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []*models.VulnerabilityEventSummary{}
+	for rows.Next() {
+		item := &models.VulnerabilityEventSummary{}
+		if err := rows.Scan(
+			&item.CabinetID,
+			&item.EventType,
+			&item.DeviceID,
+			&item.Category,
+			&item.Title,
+			&item.Severity,
+			&item.Solution,
+			&item.FirstSeen,
+			&item.LastSeen,
+			&item.Occurrences,
+			&item.Open,
+		); err != nil {
+			r.logger.Error("扫描漏洞事件汇总失败", zap.Error(err))
+			return nil, err
+		}
+		summaries = append(summaries, item)
+	}
+	return summaries, rows.Err()
+}
+
+// ListDismissalsBetween 获取区间内有效的漏洞消除记录，按消除时间排序
+func (r *VulnerabilityRepository) ListDismissalsBetween(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.CabinetDismissal, error) {
+	query := `
+		SELECT cabinet_id, dismissal_id, vulnerability_type, reason, dismissed_by,
+			dismissed_at, expires_at, updated_at
+		FROM cabinet_dismissals
+		WHERE dismissed_at <= $2 AND (expires_at IS NULL OR expires_at >= $1)
+			AND ($3 = '' OR cabinet_id = $3)
+		ORDER BY dismissed_at, cabinet_id
+		LIMIT $4
+	`
+
+	rows, err := r.pool.Query(ctx, query, startTime, endTime, cabinetID, reportRowLimit)
+	if err != nil {
+		r.logger.Error("查询漏洞消除记录失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	dismissals := []*models.CabinetDismissal{}
+	for rows.Next() {
+		item := &models.CabinetDismissal{}
+		if err := rows.Scan(
+			&item.CabinetID,
+			&item.DismissalID,
+			&item.VulnerabilityType,
+			&item.Reason,
+			&item.DismissedBy,
+			&item.DismissedAt,
+			&item.ExpiresAt,
+			&item.UpdatedAt,
+		); err != nil {
+			r.logger.Error("扫描漏洞消除记录失败", zap.Error(err))
+			return nil, err
+		}
+		dismissals = append(dismissals, item)
+	}
+	return dismissals, rows.Err()
+}
+
+// ListRemediationsBetween 获取区间内创建的处置记录，按创建时间排序
+func (r *VulnerabilityRepository) ListRemediationsBetween(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.CabinetRemediation, error) {
+	query := `
+		SELECT cabinet_id, remediation_id, event_type, severity, device_id, action, mode,
+			status, result, confirmed_by, created_at, executed_at, updated_at
+		FROM cabinet_remediations
+		WHERE created_at >= $1 AND created_at <= $2 AND ($3 = '' OR cabinet_id = $3)
+		ORDER BY created_at, cabinet_id, remediation_id
+		LIMIT $4
+	`
+	return r.queryRemediations(ctx, query, startTime, endTime, cabinetID, reportRowLimit)
+}
+
+// CreateReport 归档评估报告
+func (r *VulnerabilityRepository) CreateReport(ctx context.Context, report *models.VulnerabilityReport) (int64, error) {
+	query := `
+		INSERT INTO vulnerability_reports (
+			cabinet_id, format, start_time, end_time, title, file_name,
+			content, size_bytes, sha256, generated_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.pool.QueryRow(ctx, query,
+		report.CabinetID,
+		report.Format,
+		report.StartTime,
+		report.EndTime,
+		report.Title,
+		report.FileName,
+		report.Content,
+		report.SizeBytes,
+		report.SHA256,
+		report.GeneratedBy,
+		report.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error("归档评估报告失败", zap.Error(err))
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetReport 获取归档报告（含内容），不存在时返回nil
+func (r *VulnerabilityRepository) GetReport(ctx context.Context, id int64) (*models.VulnerabilityReport, error) {
+	query := `
+		SELECT id, cabinet_id, format, start_time, end_time, title, file_name,
+			content, size_bytes, sha256, generated_by, created_at
+		FROM vulnerability_reports
+		WHERE id = $1
+	`
+
+	report := &models.VulnerabilityReport{}
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&report.ID,
+		&report.CabinetID,
+		&report.Format,
+		&report.StartTime,
+		&report.EndTime,
+		&report.Title,
+		&report.FileName,
+		&report.Content,
+		&report.SizeBytes,
+		&report.SHA256,
+		&report.GeneratedBy,
+		&report.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.logger.Error("获取归档报告失败", zap.Int64("id", id), zap.Error(err))
+		return nil, err
+	}
+	return report, nil
+}
+
+// ListReports 查询归档报告（不含内容），按生成时间倒序
+func (r *VulnerabilityRepository) ListReports(ctx context.Context, cabinetID string, limit int) ([]*models.VulnerabilityReport, error) {
+	query := `
+		SELECT id, cabinet_id, format, start_time, end_time, title, file_name,
+			size_bytes, sha256, generated_by, created_at
+		FROM vulnerability_reports
+		WHERE ($1 = '' OR cabinet_id = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, cabinetID, limit)
+	if err != nil {
+		r.logger.Error("查询归档报告失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []*models.VulnerabilityReport{}
+	for rows.Next() {
+		report := &models.VulnerabilityReport{}
+		if err := rows.Scan(
+			&report.ID,
+			&report.CabinetID,
+			&report.Format,
+			&report.StartTime,
+			&report.EndTime,
+			&report.Title,
+			&report.FileName,
+			&report.SizeBytes,
+			&report.SHA256,
+			&report.GeneratedBy,
+			&report.CreatedAt,
+		); err != nil {
+			r.logger.Error("扫描归档报告失败", zap.Error(err))
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// DeleteReport 删除归档报告
+func (r *VulnerabilityRepository) DeleteReport(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM vulnerability_reports WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("删除归档报告失败", zap.Int64("id", id), zap.Error(err))
+	}
+	return err
+}
+
+// fleetRankingOrder 排名排序字段对应的SQL表达式
+var fleetRankingOrder = map[string]string{
+	models.FleetSortScore: "latest_score",
+	models.FleetSortTrend: "trend_per_day",
+	models.FleetSortDelta: "latest_score - start_score",
+}
+
+// severityRankSQL 严重程度排序表达式
+const severityRankSQL = `CASE e.severity WHEN 'critical' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 WHEN 'low' THEN 1 ELSE 0 END`
+
+// RankCabinets 按最新评分、评分趋势（线性回归斜率，分/天）或区间内变化量对储能柜排名
+func (r *VulnerabilityRepository) RankCabinets(ctx context.Context, since time.Time, sort string, ascending bool, limit int) ([]*models.FleetCabinetRanking, error) {
+	orderBy, ok := fleetRankingOrder[sort]
+	if !ok {
+		orderBy = fleetRankingOrder[models.FleetSortScore]
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		WITH stats AS (
+			SELECT cabinet_id,
+				(ARRAY_AGG(overall_score ORDER BY timestamp DESC))[1] AS latest_score,
+				(ARRAY_AGG(risk_level ORDER BY timestamp DESC))[1] AS risk_level,
+				MAX(timestamp) AS last_assessed,
+				(ARRAY_AGG(overall_score ORDER BY timestamp))[1] AS start_score,
+				COALESCE(regr_slope(overall_score, EXTRACT(EPOCH FROM timestamp)::float8 / 86400), 0) AS trend_per_day,
+				AVG(overall_score) AS avg_score,
+				COUNT(*) AS assessments
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1
+			GROUP BY cabinet_id
+		)
+		SELECT s.cabinet_id, c.name, COALESCE(c.location, ''), c.latitude, c.longitude,
+			s.latest_score, s.risk_level, s.last_assessed, s.start_score, s.trend_per_day,
+			s.avg_score, s.assessments
+		FROM stats s
+		JOIN cabinets c ON c.cabinet_id = s.cabinet_id
+		ORDER BY %s %s, s.cabinet_id
+		LIMIT $2
+	`, orderBy, direction)
+
+	rows, err := r.pool.Query(ctx, query, since, limit)
+	if err != nil {
+		r.logger.Error("查询储能柜评分排名失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	rankings := []*models.FleetCabinetRanking{}
+	for rows.Next() {
+		item := &models.FleetCabinetRanking{}
+		if err := rows.Scan(
+			&item.CabinetID,
+			&item.Name,
+			&item.Location,
+			&item.Latitude,
+			&item.Longitude,
+			&item.LatestScore,
+			&item.RiskLevel,
+			&item.LastAssessed,
+			&item.StartScore,
+			&item.TrendPerDay,
+			&item.AvgScore,
+			&item.Assessments,
+		); err != nil {
+			r.logger.Error("扫描储能柜评分排名失败", zap.Error(err))
+			return nil, err
+		}
+		item.ScoreDelta = item.LatestScore - item.StartScore
+		rankings = append(rankings, item)
+	}
+	return rankings, rows.Err()
+}
+
+// TopVulnerabilityTypes 按类型前缀汇总区间内的漏洞事件，按影响储能柜数排序
+func (r *VulnerabilityRepository) TopVulnerabilityTypes(ctx context.Context, since time.Time, limit int) ([]*models.FleetVulnerabilityType, error) {
+	query := `
+		WITH assessments AS (
+			SELECT id, cabinet_id, timestamp
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1
+		), latest AS (
+			SELECT DISTINCT ON (cabinet_id) cabinet_id, id
+			FROM assessments
+			ORDER BY cabinet_id, timestamp DESC
+		)
+		SELECT split_part(e.event_type, ':', 1) AS vuln_type,
+			(ARRAY_AGG(e.category ORDER BY e.detected_at DESC))[1],
+			(ARRAY_AGG(e.severity ORDER BY ` + severityRankSQL + ` DESC))[1],
+			COUNT(DISTINCT e.cabinet_id) AS affected,
+			(SELECT COUNT(*) FROM latest),
+			COUNT(DISTINCT e.cabinet_id) FILTER (WHERE e.assessment_id = l.id),
+			COUNT(*) AS occurrences
+		FROM vulnerability_events e
+		JOIN assessments a ON a.id = e.assessment_id
+		JOIN latest l ON l.cabinet_id = a.cabinet_id
+		WHERE e.detected_at >= $1
+		GROUP BY vuln_type
+		ORDER BY affected DESC, occurrences DESC, vuln_type
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, since, limit)
+	if err != nil {
+		r.logger.Error("查询常见漏洞类型失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []*models.FleetVulnerabilityType{}
+	for rows.Next() {
+		item := &models.FleetVulnerabilityType{}
+		var assessed int
+		if err := rows.Scan(
+			&item.Type,
+			&item.Category,
+			&item.MaxSeverity,
+			&item.AffectedCabinets,
+			&assessed,
+			&item.OpenCabinets,
+			&item.Occurrences,
+		); err != nil {
+			r.logger.Error("扫描常见漏洞类型失败", zap.Error(err))
+			return nil, err
+		}
+		if assessed > 0 {
+			item.AffectedRatio = float64(item.AffectedCabinets) / float64(assessed)
+		}
+		types = append(types, item)
+	}
+	return types, rows.Err()
+}
+
+// GetRemediationTimes 按类型前缀统计漏洞暴露的修复时长及处置动作执行情况
+// 同一储能柜同一漏洞（类型+设备）在连续评估中被检测到视为一次暴露，
+// 之后第一次未检测到该漏洞的评估时间即为修复时间，最新评估仍检测到的为未修复
+func (r *VulnerabilityRepository) GetRemediationTimes(ctx context.Context, since time.Time) ([]*models.FleetRemediationTime, error) {
+	query := `
+		WITH assessments AS (
+			SELECT id, cabinet_id, timestamp,
+				ROW_NUMBER() OVER w AS seq,
+				LEAD(timestamp) OVER w AS next_time
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1
+			WINDOW w AS (PARTITION BY cabinet_id ORDER BY timestamp)
+		), detections AS (
+			SELECT DISTINCT a.cabinet_id, e.event_type, COALESCE(e.device_id, '') AS device_id,
+				a.seq, a.timestamp, a.next_time
+			FROM vulnerability_events e
+			JOIN assessments a ON a.id = e.assessment_id
+		), islands AS (
+			SELECT *, seq - ROW_NUMBER() OVER (PARTITION BY cabinet_id, event_type, device_id ORDER BY seq) AS grp
+			FROM detections
+		), exposures AS (
+			SELECT split_part(event_type, ':', 1) AS vuln_type,
+				EXTRACT(EPOCH FROM (ARRAY_AGG(next_time ORDER BY seq DESC))[1] - MIN(timestamp))::float8 AS seconds
+			FROM islands
+			GROUP BY cabinet_id, event_type, device_id, grp
+		), exposure_stats AS (
+			SELECT vuln_type, COUNT(*) AS exposures, COUNT(seconds) AS resolved,
+				AVG(seconds) AS mean_seconds,
+				PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY seconds) AS median_seconds,
+				MAX(seconds) AS max_seconds
+			FROM exposures
+			GROUP BY vuln_type
+		), action_stats AS (
+			SELECT split_part(event_type, ':', 1) AS vuln_type, COUNT(*) AS actions,
+				COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+				AVG(EXTRACT(EPOCH FROM executed_at - created_at)::float8) FILTER (WHERE executed_at IS NOT NULL) AS mean_delay
+			FROM cabinet_remediations
+			WHERE created_at >= $1
+			GROUP BY 1
+		)
+		SELECT COALESCE(x.vuln_type, a.vuln_type), COALESCE(x.exposures, 0), COALESCE(x.resolved, 0),
+			x.mean_seconds, x.median_seconds, x.max_seconds,
+			COALESCE(a.actions, 0), COALESCE(a.succeeded, 0), a.mean_delay
+		FROM exposure_stats x
+		FULL OUTER JOIN action_stats a ON a.vuln_type = x.vuln_type
+		ORDER BY COALESCE(x.exposures, 0) DESC, COALESCE(a.actions, 0) DESC, 1
+	`
+
+	rows, err := r.pool.Query(ctx, query, since)
+	if err != nil {
+		r.logger.Error("查询漏洞修复时长失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*models.FleetRemediationTime{}
+	for rows.Next() {
+		item := &models.FleetRemediationTime{}
+		if err := rows.Scan(
+			&item.Type,
+			&item.Exposures,
+			&item.Resolved,
+			&item.MeanSeconds,
+			&item.MedianSeconds,
+			&item.MaxSeconds,
+			&item.Actions,
+			&item.ActionsSucceeded,
+			&item.MeanActionDelaySeconds,
+		); err != nil {
+			r.logger.Error("扫描漏洞修复时长失败", zap.Error(err))
+			return nil, err
+		}
+		item.Open = item.Exposures - item.Resolved
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ListLatestCabinetScores 各储能柜区间内最新评估、漏洞数及位置信息，按评分升序
+func (r *VulnerabilityRepository) ListLatestCabinetScores(ctx context.Context, since time.Time) ([]*models.FleetHeatmapPoint, error) {
+	query := `
+		WITH latest AS (
+			SELECT DISTINCT ON (cabinet_id) id, cabinet_id, timestamp, overall_score, risk_level
+			FROM vulnerability_assessments
+			WHERE timestamp >= $1
+			ORDER BY cabinet_id, timestamp DESC
+		)
+		SELECT l.cabinet_id, c.name, COALESCE(c.location, ''), c.latitude, c.longitude,
+			l.overall_score, l.risk_level, l.timestamp,
+			(SELECT COUNT(*) FROM vulnerability_events e WHERE e.assessment_id = l.id)
+		FROM latest l
+		JOIN cabinets c ON c.cabinet_id = l.cabinet_id
+		ORDER BY l.overall_score, l.cabinet_id
+	`
+
+	rows, err := r.pool.Query(ctx, query, since)
+	if err != nil {
+		r.logger.Error("查询储能柜最新评分失败", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []*models.FleetHeatmapPoint{}
+	for rows.Next() {
+		point := &models.FleetHeatmapPoint{}
+		if err := rows.Scan(
+			&point.CabinetID,
+			&point.Name,
+			&point.Location,
+			&point.Latitude,
+			&point.Longitude,
+			&point.Score,
+			&point.RiskLevel,
+			&point.AssessedAt,
+			&point.Vulnerabilities,
+		); err != nil {
+			r.logger.Error("扫描储能柜最新评分失败", zap.Error(err))
+			return nil, err
+		}
+		point.Weight = 100 - point.Score
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
 // Helper: 将map转为JSON字符串
 func toJSONString(data interface{}) *string {
 	if data == nil {
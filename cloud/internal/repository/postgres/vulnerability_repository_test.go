@@ -0,0 +1,446 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/repository/postgres/testutils"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestVulnerabilityRepository 启动测试容器并初始化Schema
+func newTestVulnerabilityRepository(t *testing.T) (*VulnerabilityRepository, *pgxpool.Pool) {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := testutils.NewPostgresTestContainer(ctx, true)
+	require.NoError(t, err, "Failed to start test container")
+	t.Cleanup(func() { container.Close(ctx) })
+
+	pool, err := pgxpool.New(ctx, container.GetConnectionString())
+	require.NoError(t, err, "Failed to create connection pool")
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, InitSchema(ctx, pool), "InitSchema should succeed")
+	return NewVulnerabilityRepository(pool, zap.NewNop()), pool
+}
+
+// TestVulnerabilityRepository_FirmwareAdvisories 测试固件漏洞目录的增删改查
+func TestVulnerabilityRepository_FirmwareAdvisories(t *testing.T) {
+	ctx := context.Background()
+	repo, _ := newTestVulnerabilityRepository(t)
+
+	created := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for _, id := range []string{"FW-2", "FW-1"} {
+		require.NoError(t, repo.SaveFirmwareAdvisory(ctx, &models.FirmwareAdvisory{
+			AdvisoryID: id, Model: "SMK-100", MaxVersion: "1.2.9", FixedVersion: "1.3.0",
+			Severity: "high", Title: id, CreatedAt: created, UpdatedAt: created,
+		}))
+	}
+
+	missing, err := repo.GetFirmwareAdvisory(ctx, "FW-404")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	// 再次保存为更新，保留创建时间
+	updated := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, repo.SaveFirmwareAdvisory(ctx, &models.FirmwareAdvisory{
+		AdvisoryID: "FW-1", Manufacturer: "acme", Severity: "critical", Title: "updated",
+		CreatedAt: updated, UpdatedAt: updated,
+	}))
+	advisory, err := repo.GetFirmwareAdvisory(ctx, "FW-1")
+	require.NoError(t, err)
+	require.NotNil(t, advisory)
+	assert.Equal(t, "acme", advisory.Manufacturer)
+	assert.Equal(t, "", advisory.Model)
+	assert.Equal(t, "critical", advisory.Severity)
+	assert.True(t, advisory.CreatedAt.Equal(created))
+	assert.True(t, advisory.UpdatedAt.Equal(updated))
+
+	advisories, err := repo.ListFirmwareAdvisories(ctx)
+	require.NoError(t, err)
+	require.Len(t, advisories, 2)
+	assert.Equal(t, "FW-1", advisories[0].AdvisoryID)
+	assert.Equal(t, "FW-2", advisories[1].AdvisoryID)
+
+	require.NoError(t, repo.DeleteFirmwareAdvisory(ctx, "FW-2"))
+	advisories, err = repo.ListFirmwareAdvisories(ctx)
+	require.NoError(t, err)
+	require.Len(t, advisories, 1)
+	assert.Equal(t, "FW-1", advisories[0].AdvisoryID)
+}
+
+// insertTestCabinets 插入储能柜（评估记录外键依赖）
+func insertTestCabinets(t *testing.T, pool *pgxpool.Pool, cabinetIDs ...string) {
+	t.Helper()
+	for i, id := range cabinetIDs {
+		_, err := pool.Exec(context.Background(), `
+			INSERT INTO cabinets (cabinet_id, name, mac_address, status)
+			VALUES ($1, $2, $3, 'active')
+		`, id, "Cabinet "+id, fmt.Sprintf("02:00:00:00:00:%02X", i))
+		require.NoError(t, err)
+	}
+}
+
+// createTestAssessment 创建评估及其漏洞事件，事件格式为"类型"或"类型@设备ID"
+func createTestAssessment(t *testing.T, repo *VulnerabilityRepository, cabinetID string, timestamp time.Time, score float64, riskLevel string, events ...string) int64 {
+	t.Helper()
+	ctx := context.Background()
+	id, err := repo.CreateAssessment(ctx, &models.VulnerabilityAssessment{
+		CabinetID:              cabinetID,
+		Timestamp:              timestamp,
+		LicenseComplianceScore: score,
+		CommunicationScore:     score,
+		ConfigSecurityScore:    score,
+		DataAnomalyScore:       score,
+		OverallScore:           score,
+		RiskLevel:              riskLevel,
+		SyncedFromEdge:         true,
+		ReceivedAt:             timestamp,
+	})
+	require.NoError(t, err)
+
+	records := make([]*models.VulnerabilityEvent, 0, len(events))
+	for _, event := range events {
+		eventType, deviceID, _ := strings.Cut(event, "@")
+		record := &models.VulnerabilityEvent{
+			AssessmentID: id,
+			CabinetID:    cabinetID,
+			EventType:    eventType,
+			Category:     "network",
+			Title:        eventType,
+			Severity:     "high",
+			Description:  eventType,
+			DetectedAt:   timestamp,
+		}
+		if deviceID != "" {
+			record.DeviceID = &deviceID
+		}
+		records = append(records, record)
+	}
+	require.NoError(t, repo.CreateEvents(ctx, records))
+	return id
+}
+
+// TestVulnerabilityRepository_ReportQueries 测试报告查询只包含区间内的数据
+func TestVulnerabilityRepository_ReportQueries(t *testing.T) {
+	ctx := context.Background()
+	repo, pool := newTestVulnerabilityRepository(t)
+	insertTestCabinets(t, pool, "CAB-1", "CAB-2")
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 3)
+	at := func(day, hour int) time.Time { return start.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour) }
+
+	// 区间前后的评估不影响区间内的结果
+	createTestAssessment(t, repo, "CAB-1", at(-1, 12), 20, "critical", "weak_tls")
+	createTestAssessment(t, repo, "CAB-1", at(0, 10), 80, "low", "weak_tls", "open_port")
+	createTestAssessment(t, repo, "CAB-1", at(0, 14), 70, "low", "weak_tls")
+	createTestAssessment(t, repo, "CAB-1", at(1, 10), 55, "medium", "weak_tls", "firmware:FW-1@sensor-7")
+	createTestAssessment(t, repo, "CAB-1", at(4, 10), 10, "critical", "default_password")
+	createTestAssessment(t, repo, "CAB-2", at(1, 9), 40, "high", "open_port")
+
+	t.Run("score trend", func(t *testing.T) {
+		trend, err := repo.GetScoreTrend(ctx, "", start, end)
+		require.NoError(t, err)
+		require.Len(t, trend, 3)
+		assert.Equal(t, "CAB-1", trend[0].CabinetID)
+		assert.True(t, trend[0].Day.Equal(start))
+		assert.InDelta(t, 75, trend[0].AvgScore, 1e-9)
+		assert.Equal(t, 70.0, trend[0].MinScore)
+		assert.Equal(t, 80.0, trend[0].MaxScore)
+		assert.Equal(t, 2, trend[0].Samples)
+		assert.True(t, trend[1].Day.Equal(at(1, 0)))
+		assert.Equal(t, 1, trend[1].Samples)
+		assert.Equal(t, "CAB-2", trend[2].CabinetID)
+
+		single, err := repo.GetScoreTrend(ctx, "CAB-2", start, end)
+		require.NoError(t, err)
+		require.Len(t, single, 1)
+		assert.Equal(t, 40.0, single[0].AvgScore)
+	})
+
+	t.Run("risk level points", func(t *testing.T) {
+		points, err := repo.GetRiskLevelPoints(ctx, "", start, end)
+		require.NoError(t, err)
+		require.Len(t, points, 3)
+
+		// 区间内首次评估没有前一等级，区间前的critical不计入
+		assert.Equal(t, models.RiskLevelPoint{CabinetID: "CAB-1", FromLevel: "", RiskLevel: "low", OverallScore: 80}, withoutTimestamp(points[0]))
+		assert.True(t, points[0].Timestamp.Equal(at(0, 10)))
+		// 等级未变化的中间评估被省略
+		assert.Equal(t, models.RiskLevelPoint{CabinetID: "CAB-1", FromLevel: "low", RiskLevel: "medium", OverallScore: 55, Last: true}, withoutTimestamp(points[1]))
+		assert.Equal(t, models.RiskLevelPoint{CabinetID: "CAB-2", RiskLevel: "high", OverallScore: 40, Last: true}, withoutTimestamp(points[2]))
+	})
+
+	t.Run("event summaries", func(t *testing.T) {
+		summaries, err := repo.SummarizeEvents(ctx, "", start, end)
+		require.NoError(t, err)
+		require.Len(t, summaries, 4)
+
+		byKey := map[string]*models.VulnerabilityEventSummary{}
+		for i, s := range summaries {
+			byKey[s.CabinetID+"/"+s.EventType+"@"+s.DeviceID] = s
+			// 未关闭的排在前面
+			assert.Equal(t, i < 3, s.Open, "summary %d", i)
+		}
+		weakTLS := byKey["CAB-1/weak_tls@"]
+		require.NotNil(t, weakTLS)
+		assert.Equal(t, 3, weakTLS.Occurrences)
+		assert.True(t, weakTLS.FirstSeen.Equal(at(0, 10)))
+		assert.True(t, weakTLS.LastSeen.Equal(at(1, 10)))
+		assert.True(t, weakTLS.Open)
+
+		require.Contains(t, byKey, "CAB-1/firmware:FW-1@sensor-7")
+		assert.True(t, byKey["CAB-1/firmware:FW-1@sensor-7"].Open)
+		require.Contains(t, byKey, "CAB-1/open_port@")
+		assert.False(t, byKey["CAB-1/open_port@"].Open)
+		require.Contains(t, byKey, "CAB-2/open_port@")
+		assert.True(t, byKey["CAB-2/open_port@"].Open)
+		assert.NotContains(t, byKey, "CAB-1/default_password@")
+
+		single, err := repo.SummarizeEvents(ctx, "CAB-2", start, end)
+		require.NoError(t, err)
+		require.Len(t, single, 1)
+	})
+
+	t.Run("dismissals", func(t *testing.T) {
+		expired, later := at(-2, 0), at(9, 0)
+		require.NoError(t, repo.UpsertDismissals(ctx, []*models.CabinetDismissal{
+			{CabinetID: "CAB-1", DismissalID: 1, VulnerabilityType: "expired_before", DismissedAt: at(-9, 0), ExpiresAt: &expired},
+			{CabinetID: "CAB-1", DismissalID: 2, VulnerabilityType: "permanent", DismissedAt: at(-9, 0)},
+			{CabinetID: "CAB-1", DismissalID: 3, VulnerabilityType: "inside", DismissedAt: at(1, 0), ExpiresAt: &later},
+			{CabinetID: "CAB-1", DismissalID: 4, VulnerabilityType: "after_end", DismissedAt: at(4, 0)},
+			{CabinetID: "CAB-2", DismissalID: 1, VulnerabilityType: "other_cabinet", DismissedAt: at(0, 12)},
+		}))
+
+		dismissals, err := repo.ListDismissalsBetween(ctx, "", start, end)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"permanent", "other_cabinet", "inside"}, dismissalTypes(dismissals))
+
+		dismissals, err = repo.ListDismissalsBetween(ctx, "CAB-1", start, end)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"permanent", "inside"}, dismissalTypes(dismissals))
+	})
+
+	t.Run("remediations", func(t *testing.T) {
+		remediation := func(cabinetID string, id int64, createdAt time.Time) *models.CabinetRemediation {
+			return &models.CabinetRemediation{
+				CabinetID: cabinetID, RemediationID: id, EventType: "brute_force", Action: "block_ip",
+				Mode: "auto", Status: "succeeded", CreatedAt: createdAt,
+			}
+		}
+		require.NoError(t, repo.UpsertRemediations(ctx, []*models.CabinetRemediation{
+			remediation("CAB-1", 1, at(-1, 0)),
+			remediation("CAB-1", 2, at(0, 10)),
+			remediation("CAB-2", 3, at(2, 0)),
+			remediation("CAB-1", 4, end), // 区间终点包含在内
+			remediation("CAB-1", 5, at(4, 0)),
+		}))
+
+		remediations, err := repo.ListRemediationsBetween(ctx, "", start, end)
+		require.NoError(t, err)
+		ids := []int64{}
+		for _, r := range remediations {
+			ids = append(ids, r.RemediationID)
+		}
+		assert.Equal(t, []int64{2, 3, 4}, ids)
+	})
+}
+
+func withoutTimestamp(point *models.RiskLevelPoint) models.RiskLevelPoint {
+	p := *point
+	p.Timestamp = time.Time{}
+	return p
+}
+
+func dismissalTypes(dismissals []*models.CabinetDismissal) []string {
+	types := []string{}
+	for _, d := range dismissals {
+		types = append(types, d.VulnerabilityType)
+	}
+	return types
+}
+
+// TestVulnerabilityRepository_FleetQueries 测试全网看板的排名、常见漏洞、修复时长及最新评分查询
+func TestVulnerabilityRepository_FleetQueries(t *testing.T) {
+	ctx := context.Background()
+	repo, pool := newTestVulnerabilityRepository(t)
+	insertTestCabinets(t, pool, "CAB-1", "CAB-2", "CAB-3", "CAB-OLD")
+	_, err := pool.Exec(ctx, `UPDATE cabinets SET location = '深圳', latitude = 22.54, longitude = 114.05 WHERE cabinet_id = 'CAB-1'`)
+	require.NoError(t, err)
+	_, err = pool.Exec(ctx, `UPDATE cabinets SET location = '深圳' WHERE cabinet_id = 'CAB-2'`)
+	require.NoError(t, err)
+
+	base := time.Now().UTC().Truncate(time.Hour).AddDate(0, 0, -10)
+	since := base.AddDate(0, 0, -20)
+	day := func(n int) time.Time { return base.AddDate(0, 0, n) }
+
+	// CAB-1: 评分每天下降10分；weak_tls在第2天修复，FW-1第1天修复后第2天再次出现
+	createTestAssessment(t, repo, "CAB-1", day(0), 90, "low", "weak_tls", "firmware:FW-1@sensor-7")
+	createTestAssessment(t, repo, "CAB-1", day(1), 80, "low", "weak_tls")
+	createTestAssessment(t, repo, "CAB-1", day(2), 70, "medium", "firmware:FW-1@sensor-7")
+	// CAB-2: 评分每天上升10分；weak_tls未修复，FW-2在第2天修复
+	createTestAssessment(t, repo, "CAB-2", day(0), 40, "high", "weak_tls", "firmware:FW-2@sensor-1")
+	createTestAssessment(t, repo, "CAB-2", day(2), 60, "medium", "weak_tls")
+	// CAB-3: 单次评估，趋势为0
+	createTestAssessment(t, repo, "CAB-3", day(1), 50, "high")
+	// 统计区间之前的评估及处置不计入
+	createTestAssessment(t, repo, "CAB-OLD", since.AddDate(0, 0, -1), 5, "critical", "ancient")
+
+	executed := day(0).Add(time.Hour)
+	require.NoError(t, repo.UpsertRemediations(ctx, []*models.CabinetRemediation{
+		{CabinetID: "CAB-1", RemediationID: 1, EventType: "firmware:FW-1", DeviceID: "sensor-7", Action: "disable_device",
+			Mode: "auto", Status: "succeeded", CreatedAt: day(0), ExecutedAt: &executed},
+		{CabinetID: "CAB-1", RemediationID: 2, EventType: "brute_force", Action: "block_ip",
+			Mode: "confirm", Status: "pending", CreatedAt: day(1)},
+		{CabinetID: "CAB-OLD", RemediationID: 1, EventType: "ancient", Action: "rotate_logs",
+			Mode: "auto", Status: "succeeded", CreatedAt: since.AddDate(0, 0, -1)},
+	}))
+
+	t.Run("rank cabinets", func(t *testing.T) {
+		tests := []struct {
+			sort      string
+			ascending bool
+			limit     int
+			want      []string
+		}{
+			{sort: models.FleetSortScore, ascending: true, limit: 10, want: []string{"CAB-3", "CAB-2", "CAB-1"}},
+			{sort: models.FleetSortTrend, ascending: true, limit: 10, want: []string{"CAB-1", "CAB-3", "CAB-2"}},
+			{sort: models.FleetSortDelta, ascending: false, limit: 2, want: []string{"CAB-2", "CAB-3"}},
+			{sort: "unknown", ascending: false, limit: 10, want: []string{"CAB-1", "CAB-2", "CAB-3"}},
+		}
+		for _, tt := range tests {
+			rankings, err := repo.RankCabinets(ctx, since, tt.sort, tt.ascending, tt.limit)
+			require.NoError(t, err)
+			ids := []string{}
+			for _, r := range rankings {
+				ids = append(ids, r.CabinetID)
+			}
+			assert.Equal(t, tt.want, ids, "sort=%s ascending=%v", tt.sort, tt.ascending)
+		}
+
+		rankings, err := repo.RankCabinets(ctx, since, models.FleetSortScore, true, 10)
+		require.NoError(t, err)
+		require.Len(t, rankings, 3)
+		cab3, cab2, cab1 := rankings[0], rankings[1], rankings[2]
+
+		// regr_slope按天计算评分斜率
+		assert.InDelta(t, -10, cab1.TrendPerDay, 1e-6)
+		assert.Equal(t, 90.0, cab1.StartScore)
+		assert.Equal(t, 70.0, cab1.LatestScore)
+		assert.Equal(t, -20.0, cab1.ScoreDelta)
+		assert.InDelta(t, 80, cab1.AvgScore, 1e-9)
+		assert.Equal(t, 3, cab1.Assessments)
+		assert.Equal(t, "medium", cab1.RiskLevel)
+		assert.True(t, cab1.LastAssessed.Equal(day(2)))
+		assert.Equal(t, "深圳", cab1.Location)
+		require.NotNil(t, cab1.Latitude)
+		assert.InDelta(t, 22.54, *cab1.Latitude, 1e-6)
+
+		assert.InDelta(t, 10, cab2.TrendPerDay, 1e-6)
+		assert.Equal(t, 20.0, cab2.ScoreDelta)
+		assert.Nil(t, cab2.Latitude)
+
+		// 单次评估没有回归斜率
+		assert.Equal(t, 0.0, cab3.TrendPerDay)
+		assert.Equal(t, 0.0, cab3.ScoreDelta)
+		assert.Equal(t, "", cab3.Location)
+	})
+
+	t.Run("top vulnerability types", func(t *testing.T) {
+		types, err := repo.TopVulnerabilityTypes(ctx, since, 10)
+		require.NoError(t, err)
+		require.Len(t, types, 2)
+
+		weakTLS, firmware := types[0], types[1]
+		assert.Equal(t, "weak_tls", weakTLS.Type)
+		assert.Equal(t, 2, weakTLS.AffectedCabinets)
+		assert.InDelta(t, 2.0/3, weakTLS.AffectedRatio, 1e-9)
+		assert.Equal(t, 1, weakTLS.OpenCabinets)
+		assert.Equal(t, 4, weakTLS.Occurrences)
+		assert.Equal(t, "high", weakTLS.MaxSeverity)
+
+		// firmware:xxx按前缀汇总
+		assert.Equal(t, "firmware", firmware.Type)
+		assert.Equal(t, 2, firmware.AffectedCabinets)
+		assert.Equal(t, 1, firmware.OpenCabinets)
+		assert.Equal(t, 3, firmware.Occurrences)
+
+		limited, err := repo.TopVulnerabilityTypes(ctx, since, 1)
+		require.NoError(t, err)
+		require.Len(t, limited, 1)
+		assert.Equal(t, "weak_tls", limited[0].Type)
+	})
+
+	t.Run("remediation times", func(t *testing.T) {
+		items, err := repo.GetRemediationTimes(ctx, since)
+		require.NoError(t, err)
+		require.Len(t, items, 3)
+		firmware, weakTLS, bruteForce := items[0], items[1], items[2]
+
+		// 同一漏洞中断后再次出现计为两次暴露
+		assert.Equal(t, "firmware", firmware.Type)
+		assert.Equal(t, 3, firmware.Exposures)
+		assert.Equal(t, 2, firmware.Resolved)
+		assert.Equal(t, 1, firmware.Open)
+		require.NotNil(t, firmware.MeanSeconds)
+		assert.InDelta(t, 1.5*86400, *firmware.MeanSeconds, 1e-6)
+		require.NotNil(t, firmware.MedianSeconds)
+		assert.InDelta(t, 1.5*86400, *firmware.MedianSeconds, 1e-6)
+		require.NotNil(t, firmware.MaxSeconds)
+		assert.InDelta(t, 2*86400, *firmware.MaxSeconds, 1e-6)
+		assert.Equal(t, 1, firmware.Actions)
+		assert.Equal(t, 1, firmware.ActionsSucceeded)
+		require.NotNil(t, firmware.MeanActionDelaySeconds)
+		assert.InDelta(t, 3600, *firmware.MeanActionDelaySeconds, 1e-6)
+
+		// 修复时间为之后第一次未检测到该漏洞的评估
+		assert.Equal(t, "weak_tls", weakTLS.Type)
+		assert.Equal(t, 2, weakTLS.Exposures)
+		assert.Equal(t, 1, weakTLS.Resolved)
+		require.NotNil(t, weakTLS.MeanSeconds)
+		assert.InDelta(t, 2*86400, *weakTLS.MeanSeconds, 1e-6)
+		assert.Equal(t, 0, weakTLS.Actions)
+		assert.Nil(t, weakTLS.MeanActionDelaySeconds)
+
+		// 只有处置记录、没有检测记录的类型由FULL OUTER JOIN保留
+		assert.Equal(t, "brute_force", bruteForce.Type)
+		assert.Equal(t, 0, bruteForce.Exposures)
+		assert.Equal(t, 0, bruteForce.Open)
+		assert.Nil(t, bruteForce.MeanSeconds)
+		assert.Equal(t, 1, bruteForce.Actions)
+		assert.Equal(t, 0, bruteForce.ActionsSucceeded)
+		assert.Nil(t, bruteForce.MeanActionDelaySeconds)
+	})
+
+	t.Run("latest cabinet scores", func(t *testing.T) {
+		points, err := repo.ListLatestCabinetScores(ctx, since)
+		require.NoError(t, err)
+		require.Len(t, points, 3)
+
+		assert.Equal(t, "CAB-3", points[0].CabinetID)
+		assert.Equal(t, 0, points[0].Vulnerabilities)
+		assert.Equal(t, 50.0, points[0].Weight)
+
+		assert.Equal(t, "CAB-2", points[1].CabinetID)
+		assert.Equal(t, 60.0, points[1].Score)
+		assert.Equal(t, 1, points[1].Vulnerabilities)
+		assert.Equal(t, "深圳", points[1].Location)
+		assert.Nil(t, points[1].Latitude)
+
+		assert.Equal(t, "CAB-1", points[2].CabinetID)
+		assert.Equal(t, "medium", points[2].RiskLevel)
+		assert.True(t, points[2].AssessedAt.Equal(day(2)))
+		require.NotNil(t, points[2].Longitude)
+		assert.InDelta(t, 114.05, *points[2].Longitude, 1e-6)
+	})
+}
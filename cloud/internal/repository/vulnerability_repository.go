@@ -35,4 +35,74 @@ type VulnerabilityRepository interface {
 
 	// GetStatsByCabinetID 获取储能柜评估统计
 	GetStatsByCabinetID(ctx context.Context, cabinetID string, days int) (map[string]interface{}, error)
+
+	// SaveFirmwareAdvisory 创建或更新固件漏洞公告
+	SaveFirmwareAdvisory(ctx context.Context, advisory *models.FirmwareAdvisory) error
+
+	// GetFirmwareAdvisory 获取固件漏洞公告，不存在时返回nil
+	GetFirmwareAdvisory(ctx context.Context, advisoryID string) (*models.FirmwareAdvisory, error)
+
+	// ListFirmwareAdvisories 获取全部固件漏洞公告
+	ListFirmwareAdvisories(ctx context.Context) ([]*models.FirmwareAdvisory, error)
+
+	// DeleteFirmwareAdvisory 删除固件漏洞公告
+	DeleteFirmwareAdvisory(ctx context.Context, advisoryID string) error
+
+	// ReplaceCabinetCertificates 以Edge最新上报的证书状态替换储能柜的证书记录
+	ReplaceCabinetCertificates(ctx context.Context, cabinetID string, certificates []models.CabinetCertificate) error
+
+	// ListExpiringCertificates 查询到期时间早于before的证书，cabinetID为空时查询全部储能柜
+	ListExpiringCertificates(ctx context.Context, cabinetID string, before time.Time) ([]*models.CabinetCertificate, error)
+
+	// UpsertRemediations 按储能柜及Edge记录ID保存处置记录
+	UpsertRemediations(ctx context.Context, remediations []*models.CabinetRemediation) error
+
+	// ListRemediations 查询储能柜处置记录，status为空时返回全部状态
+	ListRemediations(ctx context.Context, cabinetID, status string, limit int) ([]*models.CabinetRemediation, error)
+
+	// UpsertDismissals 按储能柜及Edge记录ID保存漏洞消除记录
+	UpsertDismissals(ctx context.Context, dismissals []*models.CabinetDismissal) error
+
+	// 以下为评估报告查询，cabinetID为空时查询全部储能柜
+
+	// GetScoreTrend 按天汇总区间内的综合评分
+	GetScoreTrend(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.ScoreTrendPoint, error)
+
+	// GetRiskLevelPoints 获取区间内的风险等级变化点
+	GetRiskLevelPoints(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.RiskLevelPoint, error)
+
+	// SummarizeEvents 按储能柜及类型汇总区间内的漏洞事件
+	SummarizeEvents(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.VulnerabilityEventSummary, error)
+
+	// ListDismissalsBetween 获取区间内有效的漏洞消除记录
+	ListDismissalsBetween(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.CabinetDismissal, error)
+
+	// ListRemediationsBetween 获取区间内创建的处置记录
+	ListRemediationsBetween(ctx context.Context, cabinetID string, startTime, endTime time.Time) ([]*models.CabinetRemediation, error)
+
+	// CreateReport 归档评估报告
+	CreateReport(ctx context.Context, report *models.VulnerabilityReport) (int64, error)
+
+	// GetReport 获取归档报告（含内容），不存在时返回nil
+	GetReport(ctx context.Context, id int64) (*models.VulnerabilityReport, error)
+
+	// ListReports 查询归档报告（不含内容）
+	ListReports(ctx context.Context, cabinetID string, limit int) ([]*models.VulnerabilityReport, error)
+
+	// DeleteReport 删除归档报告
+	DeleteReport(ctx context.Context, id int64) error
+
+	// 以下为全网看板查询，since为统计区间起点
+
+	// RankCabinets 按最新评分、评分趋势或变化量对储能柜排名
+	RankCabinets(ctx context.Context, since time.Time, sort string, ascending bool, limit int) ([]*models.FleetCabinetRanking, error)
+
+	// TopVulnerabilityTypes 全网最常见的漏洞类型
+	TopVulnerabilityTypes(ctx context.Context, since time.Time, limit int) ([]*models.FleetVulnerabilityType, error)
+
+	// GetRemediationTimes 按漏洞类型统计修复时长及处置动作
+	GetRemediationTimes(ctx context.Context, since time.Time) ([]*models.FleetRemediationTime, error)
+
+	// ListLatestCabinetScores 各储能柜区间内最新评估及位置信息
+	ListLatestCabinetScores(ctx context.Context, since time.Time) ([]*models.FleetHeatmapPoint, error)
 }
@@ -106,6 +106,13 @@ func (h *ABACLogHandlerImpl) parseAccessLog(cabinetID string, data map[string]in
 		}
 	}
 
+	// 试运行策略的假设结果
+	if shadow, ok := data["shadow"]; ok {
+		if shadowJSON, err := json.Marshal(shadow); err == nil {
+			_ = json.Unmarshal(shadowJSON, &log.Shadow)
+		}
+	}
+
 	return log, nil
 }
 
@@ -129,6 +136,49 @@ func (h *ABACLogHandlerImpl) HandlePolicyAck(ctx context.Context, cabinetID, pol
 	return nil
 }
 
+// HandleTrustReport 处理Edge上报的设备行为信任度及其变化记录
+func (h *ABACLogHandlerImpl) HandleTrustReport(ctx context.Context, cabinetID string, report *abac.TrustReport) error {
+	saved := 0
+	for _, trust := range report.Devices {
+		if trust == nil || trust.DeviceID == "" {
+			continue
+		}
+		trust.CabinetID = cabinetID
+		if trust.UpdatedAt.IsZero() {
+			trust.UpdatedAt = report.Timestamp
+		}
+		if err := h.policyRepo.SaveDeviceTrust(ctx, trust); err != nil {
+			utils.Warn("保存设备信任度失败",
+				zap.String("cabinet_id", cabinetID),
+				zap.String("device_id", trust.DeviceID),
+				zap.Error(err),
+			)
+			continue
+		}
+		saved++
+	}
+
+	events := make([]*abac.TrustEvent, 0, len(report.Events))
+	for _, event := range report.Events {
+		if event == nil || event.DeviceID == "" {
+			continue
+		}
+		event.CabinetID = cabinetID
+		events = append(events, event)
+	}
+	if err := h.policyRepo.SaveTrustEvents(ctx, events); err != nil {
+		return err
+	}
+
+	utils.Info("设备信任度上报处理完成",
+		zap.String("cabinet_id", cabinetID),
+		zap.Int("devices", saved),
+		zap.Int("events", len(events)),
+	)
+
+	return nil
+}
+
 // shouldSkipLog 判断是否应该跳过访问日志记录
 // 跳过admin用户的日志，避免日志量过大
 func (h *ABACLogHandlerImpl) shouldSkipLog(log *abac.AccessLog) bool {
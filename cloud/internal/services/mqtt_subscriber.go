@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"cloud-system/internal/abac"
 	"cloud-system/internal/config"
 	"cloud-system/internal/models"
 	"cloud-system/internal/repository"
@@ -30,14 +31,21 @@ type MQTTSubscriberService struct {
 	cfg              *config.Config
 	ctx              context.Context
 	cancel           context.CancelFunc
-	wsHub            WebSocketHub   // WebSocket Hub用于实时推送
-	abacLogHandler   ABACLogHandler // ABAC日志处理器（可选）
+	wsHub            WebSocketHub     // WebSocket Hub用于实时推送
+	abacLogHandler   ABACLogHandler   // ABAC日志处理器（可选）
+	policyReconciler PolicyReconciler // 策略对账服务（可选）
 }
 
 // ABACLogHandler ABAC日志处理接口
 type ABACLogHandler interface {
 	HandleAccessLogs(ctx context.Context, cabinetID string, logs []map[string]interface{}) error
 	HandlePolicyAck(ctx context.Context, cabinetID, policyID string) error
+	HandleTrustReport(ctx context.Context, cabinetID string, report *abac.TrustReport) error
+}
+
+// PolicyReconciler 策略对账接口
+type PolicyReconciler interface {
+	HandleHeartbeat(ctx context.Context, cabinetID string, heartbeat *abac.PolicyHeartbeat) error
 }
 
 // WebSocketHub WebSocket Hub接口
@@ -84,6 +92,11 @@ func (s *MQTTSubscriberService) SetABACLogHandler(handler ABACLogHandler) {
 	s.abacLogHandler = handler
 }
 
+// SetPolicyReconciler 设置策略对账服务
+func (s *MQTTSubscriberService) SetPolicyReconciler(reconciler PolicyReconciler) {
+	s.policyReconciler = reconciler
+}
+
 // SetAlertService 设置告警服务（用于处理MQTT告警）
 func (s *MQTTSubscriberService) SetAlertService(alertService AlertService) {
 	s.alertService = alertService
@@ -94,11 +107,13 @@ func (s *MQTTSubscriberService) Start() error {
 	utils.Info("Starting MQTT subscriber service...")
 
 	topics := map[string]mqtt.MessageHandler{
-		"sensors/#":                 s.handleSensorMessage,
-		"traffic/#":                 s.handleTrafficMessage,
-		"edge/cabinet/+/abac/logs":  s.handleABACLogMessage,   // ABAC设备访问日志
-		"edge/cabinet/+/policy/ack": s.handlePolicyAckMessage, // 策略分发ACK确认
-		"edge/cabinet/+/alerts":     s.handleAlertMessage,     // Edge端实时告警推送
+		"sensors/#":                       s.handleSensorMessage,
+		"traffic/#":                       s.handleTrafficMessage,
+		"edge/cabinet/+/abac/logs":        s.handleABACLogMessage,         // ABAC设备访问日志
+		"edge/cabinet/+/abac/trust":       s.handleTrustReportMessage,     // 设备行为信任度
+		"edge/cabinet/+/policy/ack":       s.handlePolicyAckMessage,       // 策略分发ACK确认
+		"edge/cabinet/+/policy/heartbeat": s.handlePolicyHeartbeatMessage, // 策略摘要心跳（对账）
+		"edge/cabinet/+/alerts":           s.handleAlertMessage,           // Edge端实时告警推送
 	}
 
 	for topic, handler := range topics {
@@ -126,7 +141,7 @@ func (s *MQTTSubscriberService) Stop() error {
 	s.cancel()
 
 	// 取消订阅
-	s.mqttClient.Unsubscribe("sensors/#", "traffic/#", "edge/cabinet/+/abac/logs", "edge/cabinet/+/alerts")
+	s.mqttClient.Unsubscribe("sensors/#", "traffic/#", "edge/cabinet/+/abac/logs", "edge/cabinet/+/abac/trust", "edge/cabinet/+/policy/heartbeat", "edge/cabinet/+/alerts")
 
 	utils.Info("MQTT subscriber service stopped")
 	return nil
@@ -344,6 +359,37 @@ func (s *MQTTSubscriberService) handleABACLogMessage(client mqtt.Client, msg mqt
 	)
 }
 
+// handleTrustReportMessage 处理Edge上报的设备行为信任度
+func (s *MQTTSubscriberService) handleTrustReportMessage(client mqtt.Client, msg mqtt.Message) {
+	if s.abacLogHandler == nil {
+		return // ABAC功能未启用
+	}
+
+	// Topic格式: edge/cabinet/{cabinet_id}/abac/trust
+	parts := strings.Split(msg.Topic(), "/")
+	if len(parts) != 5 {
+		utils.Warn("Invalid trust report topic format", zap.String("topic", msg.Topic()))
+		return
+	}
+	cabinetID := parts[2]
+
+	var report abac.TrustReport
+	if err := json.Unmarshal(msg.Payload(), &report); err != nil {
+		utils.Error("Failed to parse trust report message",
+			zap.String("cabinet_id", cabinetID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := s.abacLogHandler.HandleTrustReport(s.ctx, cabinetID, &report); err != nil {
+		utils.Error("Failed to handle trust report",
+			zap.String("cabinet_id", cabinetID),
+			zap.Error(err),
+		)
+	}
+}
+
 // handlePolicyAckMessage 处理策略分发ACK消息
 func (s *MQTTSubscriberService) handlePolicyAckMessage(client mqtt.Client, msg mqtt.Message) {
 	if s.abacLogHandler == nil {
@@ -397,6 +443,44 @@ func (s *MQTTSubscriberService) handlePolicyAckMessage(client mqtt.Client, msg m
 	)
 }
 
+// handlePolicyHeartbeatMessage 处理Edge上报的策略摘要心跳
+func (s *MQTTSubscriberService) handlePolicyHeartbeatMessage(client mqtt.Client, msg mqtt.Message) {
+	if s.policyReconciler == nil {
+		return // 策略对账未启用
+	}
+
+	// Topic格式: edge/cabinet/{cabinet_id}/policy/heartbeat
+	parts := strings.Split(msg.Topic(), "/")
+	if len(parts) != 5 {
+		utils.Warn("Invalid policy heartbeat topic format", zap.String("topic", msg.Topic()))
+		return
+	}
+	cabinetID := parts[2]
+
+	var heartbeat abac.PolicyHeartbeat
+	if err := json.Unmarshal(msg.Payload(), &heartbeat); err != nil {
+		utils.Error("Failed to parse policy heartbeat message",
+			zap.String("cabinet_id", cabinetID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	if err := s.policyReconciler.HandleHeartbeat(s.ctx, cabinetID, &heartbeat); err != nil {
+		utils.Error("Failed to reconcile cabinet policies",
+			zap.String("cabinet_id", cabinetID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	utils.Debug("Policy heartbeat processed",
+		zap.String("cabinet_id", cabinetID),
+		zap.String("digest", heartbeat.Digest),
+		zap.Int("policy_count", heartbeat.PolicyCount),
+	)
+}
+
 // handleAlertMessage 处理Edge端实时推送的告警消息
 func (s *MQTTSubscriberService) handleAlertMessage(client mqtt.Client, msg mqtt.Message) {
 	topic := msg.Topic()
@@ -0,0 +1,182 @@
+/*
+ * 策略对账服务
+ * 根据Edge心跳上报的策略摘要检测策略漂移，并自动补发增量或全量同步
+ */
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud-system/internal/abac"
+	"cloud-system/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+// PolicyDistributor 策略下发接口（由mqtt.PolicyPublisher实现）
+type PolicyDistributor interface {
+	DistributePoliciesToCabinet(ctx context.Context, cabinetID string, policyIDs []string) error
+	DeletePolicyFromCabinet(ctx context.Context, cabinetID string, policyIDs []string) error
+	FullSyncToCabinet(ctx context.Context, cabinetID string) error
+}
+
+// 同一储能柜两次自动补发的最小间隔，避免Edge尚未应用时重复下发
+const defaultReconcileCooldown = 2 * time.Minute
+
+// PolicyReconcilerImpl 策略对账服务实现
+type PolicyReconcilerImpl struct {
+	policyRepo  abac.PolicyRepository
+	distributor PolicyDistributor
+	algorithm   string // Cloud配置的组合算法，为空时不比较
+	cooldown    time.Duration
+
+	mu         sync.Mutex
+	lastPushed map[string]time.Time // cabinet_id -> 上次自动补发时间
+}
+
+// NewPolicyReconciler 创建策略对账服务，distributor为nil时只记录漂移不补发
+func NewPolicyReconciler(policyRepo abac.PolicyRepository, distributor PolicyDistributor) *PolicyReconcilerImpl {
+	return &PolicyReconcilerImpl{
+		policyRepo:  policyRepo,
+		distributor: distributor,
+		cooldown:    defaultReconcileCooldown,
+		lastPushed:  make(map[string]time.Time),
+	}
+}
+
+// SetCombiningAlgorithm 设置期望的组合算法
+func (r *PolicyReconcilerImpl) SetCombiningAlgorithm(algorithm string) {
+	r.algorithm = algorithm
+}
+
+// HandleHeartbeat 处理Edge策略摘要心跳：对账、记录状态并按需补发
+func (r *PolicyReconcilerImpl) HandleHeartbeat(ctx context.Context, cabinetID string, heartbeat *abac.PolicyHeartbeat) error {
+	expected, err := r.expectedPolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	expectedDigest := abac.PolicySetDigest(abac.PolicyVersions(expected))
+	drift := abac.DiffPolicySets(expected, heartbeat.Policies)
+	algorithmDrift := r.algorithm != "" && heartbeat.CombiningAlgorithm != r.algorithm
+
+	status := &abac.PolicySyncStatus{
+		CabinetID:       cabinetID,
+		InSync:          drift.Count() == 0 && !algorithmDrift,
+		ExpectedDigest:  expectedDigest,
+		ReportedDigest:  heartbeat.Digest,
+		ExpectedCount:   len(expected),
+		ReportedCount:   len(heartbeat.Policies),
+		Drift:           drift,
+		LastAction:      "none",
+		LastHeartbeatAt: time.Now(),
+	}
+
+	if !status.InSync {
+		utils.Warn("检测到储能柜策略漂移",
+			zap.String("cabinet_id", cabinetID),
+			zap.Strings("missing", drift.Missing),
+			zap.Int("stale", len(drift.Stale)),
+			zap.Strings("extra", drift.Extra),
+			zap.Bool("algorithm_drift", algorithmDrift),
+		)
+		status.LastAction = r.reconcile(ctx, cabinetID, expected, drift, algorithmDrift)
+		if status.LastAction != "cooldown" {
+			now := time.Now()
+			status.LastReconciledAt = &now
+		}
+	}
+
+	return r.policyRepo.SavePolicySyncStatus(ctx, status)
+}
+
+// expectedPolicies 储能柜应持有的策略（与全量同步一致：全部device、group策略）
+func (r *PolicyReconcilerImpl) expectedPolicies(ctx context.Context) ([]*abac.AccessPolicy, error) {
+	policies, err := r.policyRepo.GetBySubjectType(ctx, string(abac.SubjectTypeDevice), false)
+	if err != nil {
+		return nil, fmt.Errorf("获取device策略失败: %w", err)
+	}
+	groupPolicies, err := r.policyRepo.GetBySubjectType(ctx, string(abac.SubjectTypeGroup), false)
+	if err != nil {
+		return nil, fmt.Errorf("获取group策略失败: %w", err)
+	}
+	return append(policies, groupPolicies...), nil
+}
+
+// reconcile 补发差异策略，返回执行的动作
+// 差异超过期望策略数一半、Edge缺少全部策略或仅组合算法不一致时使用全量同步，否则补发增量
+func (r *PolicyReconcilerImpl) reconcile(ctx context.Context, cabinetID string, expected []*abac.AccessPolicy, drift *abac.PolicyDrift, algorithmDrift bool) string {
+	if r.distributor == nil {
+		return "none"
+	}
+
+	r.mu.Lock()
+	if last, ok := r.lastPushed[cabinetID]; ok && time.Since(last) < r.cooldown {
+		r.mu.Unlock()
+		return "cooldown"
+	}
+	r.lastPushed[cabinetID] = time.Now()
+	r.mu.Unlock()
+
+	outdated := drift.Outdated()
+	fullSync := (drift.Count() == 0 && algorithmDrift) ||
+		(len(expected) > 0 && len(drift.Missing) == len(expected)) ||
+		drift.Count()*2 > len(expected)
+
+	if fullSync {
+		if err := r.distributor.FullSyncToCabinet(ctx, cabinetID); err != nil {
+			utils.Error("策略对账全量同步失败", zap.String("cabinet_id", cabinetID), zap.Error(err))
+			r.logDistribution(ctx, cabinetID, outdated, err)
+			return "full_sync"
+		}
+		r.logDistribution(ctx, cabinetID, outdated, nil)
+		utils.Info("策略对账：已触发全量同步", zap.String("cabinet_id", cabinetID), zap.Int("drift", drift.Count()))
+		return "full_sync"
+	}
+
+	if len(outdated) > 0 {
+		err := r.distributor.DistributePoliciesToCabinet(ctx, cabinetID, outdated)
+		if err != nil {
+			utils.Error("策略对账增量下发失败", zap.String("cabinet_id", cabinetID), zap.Error(err))
+		}
+		r.logDistribution(ctx, cabinetID, outdated, err)
+	}
+	if len(drift.Extra) > 0 {
+		if err := r.distributor.DeletePolicyFromCabinet(ctx, cabinetID, drift.Extra); err != nil {
+			utils.Error("策略对账删除多余策略失败", zap.String("cabinet_id", cabinetID), zap.Error(err))
+		}
+	}
+
+	utils.Info("策略对账：已补发增量",
+		zap.String("cabinet_id", cabinetID),
+		zap.Strings("policies", outdated),
+		zap.Strings("deleted", drift.Extra),
+	)
+	return "delta"
+}
+
+// logDistribution 记录对账补发日志，成功时为pending等待Edge确认
+func (r *PolicyReconcilerImpl) logDistribution(ctx context.Context, cabinetID string, policyIDs []string, pushErr error) {
+	operator := "reconciler"
+	for _, policyID := range policyIDs {
+		log := &abac.DistributionLog{
+			PolicyID:      policyID,
+			CabinetID:     cabinetID,
+			OperationType: "reconcile",
+			Status:        "pending",
+			OperatorName:  &operator,
+			DistributedAt: time.Now(),
+		}
+		if pushErr != nil {
+			errMsg := pushErr.Error()
+			log.Status = "failed"
+			log.ErrorMessage = &errMsg
+		}
+		if err := r.policyRepo.LogDistribution(ctx, log); err != nil {
+			utils.Warn("记录对账分发日志失败", zap.String("policy_id", policyID), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cloud-system/internal/abac"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePolicyRepo 只实现对账用到的方法，其余方法调用时panic
+type fakePolicyRepo struct {
+	abac.PolicyRepository
+	policies []*abac.AccessPolicy
+	statuses []*abac.PolicySyncStatus
+	logs     []*abac.DistributionLog
+}
+
+func (f *fakePolicyRepo) GetBySubjectType(ctx context.Context, subjectType string, enabledOnly bool) ([]*abac.AccessPolicy, error) {
+	var result []*abac.AccessPolicy
+	for _, p := range f.policies {
+		if p.SubjectType == subjectType {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePolicyRepo) SavePolicySyncStatus(ctx context.Context, status *abac.PolicySyncStatus) error {
+	f.statuses = append(f.statuses, status)
+	return nil
+}
+
+func (f *fakePolicyRepo) LogDistribution(ctx context.Context, log *abac.DistributionLog) error {
+	f.logs = append(f.logs, log)
+	return nil
+}
+
+// fakeDistributor 记录下发调用
+type fakeDistributor struct {
+	distributed [][]string
+	deleted     [][]string
+	fullSyncs   int
+	err         error
+}
+
+func (f *fakeDistributor) DistributePoliciesToCabinet(ctx context.Context, cabinetID string, policyIDs []string) error {
+	f.distributed = append(f.distributed, policyIDs)
+	return f.err
+}
+
+func (f *fakeDistributor) DeletePolicyFromCabinet(ctx context.Context, cabinetID string, policyIDs []string) error {
+	f.deleted = append(f.deleted, policyIDs)
+	return f.err
+}
+
+func (f *fakeDistributor) FullSyncToCabinet(ctx context.Context, cabinetID string) error {
+	f.fullSyncs++
+	return f.err
+}
+
+func reconcilerPolicies() []*abac.AccessPolicy {
+	policies := make([]*abac.AccessPolicy, 0, 5)
+	for i := 1; i <= 4; i++ {
+		policies = append(policies, &abac.AccessPolicy{
+			ID: fmt.Sprintf("device-%d", i), Name: fmt.Sprintf("device %d", i), SubjectType: "device",
+			Permissions: []string{"read:sensors"}, Priority: i, Enabled: true, Version: 1,
+		})
+	}
+	return append(policies, &abac.AccessPolicy{
+		ID: "group-1", Name: "group 1", SubjectType: "group", Permissions: []string{"read:diagnostics"}, Enabled: true, Version: 1,
+	})
+}
+
+// heartbeatOf 构造持有指定策略的心跳
+func heartbeatOf(policies []*abac.AccessPolicy, algorithm string) *abac.PolicyHeartbeat {
+	versions := abac.PolicyVersions(policies)
+	return &abac.PolicyHeartbeat{
+		CabinetID:          "CAB-1",
+		Digest:             abac.PolicySetDigest(versions),
+		PolicyCount:        len(versions),
+		Policies:           versions,
+		CombiningAlgorithm: algorithm,
+		Timestamp:          time.Now(),
+	}
+}
+
+func TestPolicyReconcilerHeartbeat(t *testing.T) {
+	policies := reconcilerPolicies()
+	stale := *policies[0]
+	stale.Priority = 99
+
+	tests := []struct {
+		name         string
+		held         []*abac.AccessPolicy
+		algorithm    string
+		extra        []abac.PolicyVersion
+		pushErr      error
+		wantInSync   bool
+		wantAction   string
+		distributed  [][]string
+		deleted      [][]string
+		fullSyncs    int
+		wantLogState string
+	}{
+		{
+			name:       "in sync",
+			held:       policies,
+			algorithm:  abac.AlgorithmDenyOverrides,
+			wantInSync: true,
+			wantAction: "none",
+		},
+		{
+			name:         "single stale policy sends delta",
+			held:         append([]*abac.AccessPolicy{&stale}, policies[1:]...),
+			algorithm:    abac.AlgorithmDenyOverrides,
+			wantAction:   "delta",
+			distributed:  [][]string{{"device-1"}},
+			wantLogState: "pending",
+		},
+		{
+			name:       "extra policy deleted",
+			held:       policies,
+			algorithm:  abac.AlgorithmDenyOverrides,
+			extra:      []abac.PolicyVersion{{ID: "removed", Version: 3, Hash: "x"}},
+			wantAction: "delta",
+			deleted:    [][]string{{"removed"}},
+		},
+		{
+			name:         "empty edge gets full sync",
+			algorithm:    abac.AlgorithmDenyOverrides,
+			wantAction:   "full_sync",
+			fullSyncs:    1,
+			wantLogState: "pending",
+		},
+		{
+			name:         "drift over half gets full sync",
+			held:         policies[:2],
+			algorithm:    abac.AlgorithmDenyOverrides,
+			wantAction:   "full_sync",
+			fullSyncs:    1,
+			wantLogState: "pending",
+		},
+		{
+			name:       "algorithm drift only gets full sync",
+			held:       policies,
+			algorithm:  abac.AlgorithmFirstApplicable,
+			wantAction: "full_sync",
+			fullSyncs:  1,
+		},
+		{
+			name:         "failed push logged",
+			held:         policies[1:],
+			algorithm:    abac.AlgorithmDenyOverrides,
+			pushErr:      errors.New("mqtt offline"),
+			wantAction:   "delta",
+			distributed:  [][]string{{"device-1"}},
+			wantLogState: "failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePolicyRepo{policies: policies}
+			distributor := &fakeDistributor{err: tt.pushErr}
+			r := NewPolicyReconciler(repo, distributor)
+			r.SetCombiningAlgorithm(abac.AlgorithmDenyOverrides)
+
+			heartbeat := heartbeatOf(tt.held, tt.algorithm)
+			heartbeat.Policies = append(heartbeat.Policies, tt.extra...)
+			require.NoError(t, r.HandleHeartbeat(context.Background(), "CAB-1", heartbeat))
+
+			require.Len(t, repo.statuses, 1)
+			status := repo.statuses[0]
+			assert.Equal(t, tt.wantInSync, status.InSync)
+			assert.Equal(t, tt.wantAction, status.LastAction)
+			assert.Equal(t, len(policies), status.ExpectedCount)
+			assert.Equal(t, abac.PolicySetDigest(abac.PolicyVersions(policies)), status.ExpectedDigest)
+			assert.Equal(t, tt.wantInSync, status.LastReconciledAt == nil)
+
+			assert.Equal(t, tt.distributed, distributor.distributed)
+			assert.Equal(t, tt.deleted, distributor.deleted)
+			assert.Equal(t, tt.fullSyncs, distributor.fullSyncs)
+			if tt.wantLogState == "" {
+				assert.Empty(t, repo.logs)
+			} else {
+				require.NotEmpty(t, repo.logs)
+				for _, log := range repo.logs {
+					assert.Equal(t, tt.wantLogState, log.Status)
+					assert.Equal(t, "reconcile", log.OperationType)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyReconcilerCooldown(t *testing.T) {
+	policies := reconcilerPolicies()
+	repo := &fakePolicyRepo{policies: policies}
+	distributor := &fakeDistributor{}
+	r := NewPolicyReconciler(repo, distributor)
+	ctx := context.Background()
+
+	drifted := heartbeatOf(policies[1:], "")
+	require.NoError(t, r.HandleHeartbeat(ctx, "CAB-1", drifted))
+	require.NoError(t, r.HandleHeartbeat(ctx, "CAB-1", drifted))
+	// 冷却期按储能柜计算
+	require.NoError(t, r.HandleHeartbeat(ctx, "CAB-2", drifted))
+
+	require.Len(t, repo.statuses, 3)
+	assert.Equal(t, "delta", repo.statuses[0].LastAction)
+	assert.Equal(t, "cooldown", repo.statuses[1].LastAction)
+	assert.Nil(t, repo.statuses[1].LastReconciledAt)
+	assert.False(t, repo.statuses[1].InSync)
+	assert.Equal(t, "delta", repo.statuses[2].LastAction)
+	assert.Len(t, distributor.distributed, 2)
+
+	// 冷却期结束后再次补发
+	r.mu.Lock()
+	r.lastPushed["CAB-1"] = time.Now().Add(-defaultReconcileCooldown - time.Second)
+	r.mu.Unlock()
+	require.NoError(t, r.HandleHeartbeat(ctx, "CAB-1", drifted))
+	assert.Equal(t, "delta", repo.statuses[3].LastAction)
+	assert.Len(t, distributor.distributed, 3)
+
+	// 未配置下发器时只记录漂移
+	passive := NewPolicyReconciler(repo, nil)
+	require.NoError(t, passive.HandleHeartbeat(ctx, "CAB-3", drifted))
+	last := repo.statuses[len(repo.statuses)-1]
+	assert.False(t, last.InSync)
+	assert.Equal(t, "none", last.LastAction)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/pkg/errors"
+)
+
+// 全网看板默认参数
+const (
+	defaultFleetDays    = 30
+	maxFleetDays        = 365
+	defaultFleetLimit   = 20
+	maxFleetLimit       = 500
+	unlocatedRegionName = "未设置位置"
+)
+
+// fleetWindow 统计区间起点及返回条数，超出范围时使用默认值
+func fleetWindow(query *models.FleetQuery) (time.Time, int) {
+	days := query.Days
+	if days <= 0 || days > maxFleetDays {
+		days = defaultFleetDays
+	}
+	limit := query.Limit
+	if limit <= 0 || limit > maxFleetLimit {
+		limit = defaultFleetLimit
+	}
+	return time.Now().AddDate(0, 0, -days), limit
+}
+
+// RankCabinets 全网储能柜评分排名，默认按最新评分升序（风险最高在前）
+func (s *vulnerabilityService) RankCabinets(ctx context.Context, query *models.FleetQuery) ([]*models.FleetCabinetRanking, error) {
+	since, limit := fleetWindow(query)
+	sortBy := query.Sort
+	if sortBy == "" {
+		sortBy = models.FleetSortScore
+	}
+
+	rankings, err := s.repo.RankCabinets(ctx, since, sortBy, query.Order != "desc", limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询储能柜排名失败")
+	}
+	return rankings, nil
+}
+
+// TopVulnerabilityTypes 全网最常见的漏洞类型
+func (s *vulnerabilityService) TopVulnerabilityTypes(ctx context.Context, query *models.FleetQuery) ([]*models.FleetVulnerabilityType, error) {
+	since, limit := fleetWindow(query)
+	types, err := s.repo.TopVulnerabilityTypes(ctx, since, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询常见漏洞类型失败")
+	}
+	return types, nil
+}
+
+// GetRemediationTimes 按漏洞类型统计平均修复时长
+func (s *vulnerabilityService) GetRemediationTimes(ctx context.Context, query *models.FleetQuery) ([]*models.FleetRemediationTime, error) {
+	since, _ := fleetWindow(query)
+	items, err := s.repo.GetRemediationTimes(ctx, since)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询漏洞修复时长失败")
+	}
+	return items, nil
+}
+
+// GetHeatmap 全网区域及风险热力数据
+func (s *vulnerabilityService) GetHeatmap(ctx context.Context, query *models.FleetQuery) (*models.FleetHeatmap, error) {
+	since, _ := fleetWindow(query)
+	latest, err := s.repo.ListLatestCabinetScores(ctx, since)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询风险热力数据失败")
+	}
+	return buildFleetHeatmap(latest, time.Now()), nil
+}
+
+// buildFleetHeatmap 有坐标的储能柜作为热力点位，全部储能柜按位置汇总为区域
+func buildFleetHeatmap(latest []*models.FleetHeatmapPoint, now time.Time) *models.FleetHeatmap {
+	heatmap := &models.FleetHeatmap{
+		Points:      []*models.FleetHeatmapPoint{},
+		Regions:     []*models.FleetHeatmapRegion{},
+		RiskCounts:  map[string]int{},
+		GeneratedAt: now,
+	}
+
+	regions := map[string]*models.FleetHeatmapRegion{}
+	for _, point := range latest {
+		if point.Latitude != nil && point.Longitude != nil {
+			heatmap.Points = append(heatmap.Points, point)
+		} else {
+			heatmap.Unlocated++
+		}
+		heatmap.RiskCounts[point.RiskLevel]++
+
+		name := point.Location
+		if name == "" {
+			name = unlocatedRegionName
+		}
+		region, ok := regions[name]
+		if !ok {
+			region = &models.FleetHeatmapRegion{Region: name, MinScore: point.Score, RiskCounts: map[string]int{}}
+			regions[name] = region
+			heatmap.Regions = append(heatmap.Regions, region)
+		}
+		region.Cabinets++
+		region.AvgScore += point.Score
+		if point.Score < region.MinScore {
+			region.MinScore = point.Score
+		}
+		region.RiskCounts[point.RiskLevel]++
+	}
+
+	for _, region := range heatmap.Regions {
+		region.AvgScore /= float64(region.Cabinets)
+	}
+	sort.SliceStable(heatmap.Regions, func(i, j int) bool {
+		return heatmap.Regions[i].AvgScore < heatmap.Regions[j].AvgScore
+	})
+	return heatmap
+}
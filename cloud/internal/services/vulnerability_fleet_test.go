@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFleetRepo 记录看板查询参数
+type fakeFleetRepo struct {
+	repository.VulnerabilityRepository
+	since     time.Time
+	sort      string
+	ascending bool
+	limit     int
+	latest    []*models.FleetHeatmapPoint
+}
+
+func (f *fakeFleetRepo) RankCabinets(ctx context.Context, since time.Time, sort string, ascending bool, limit int) ([]*models.FleetCabinetRanking, error) {
+	f.since, f.sort, f.ascending, f.limit = since, sort, ascending, limit
+	return []*models.FleetCabinetRanking{}, nil
+}
+
+func (f *fakeFleetRepo) TopVulnerabilityTypes(ctx context.Context, since time.Time, limit int) ([]*models.FleetVulnerabilityType, error) {
+	f.since, f.limit = since, limit
+	return []*models.FleetVulnerabilityType{}, nil
+}
+
+func (f *fakeFleetRepo) ListLatestCabinetScores(ctx context.Context, since time.Time) ([]*models.FleetHeatmapPoint, error) {
+	f.since = since
+	return f.latest, nil
+}
+
+func TestFleetQueryWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     models.FleetQuery
+		wantDays  int
+		wantSort  string
+		wantAsc   bool
+		wantLimit int
+	}{
+		{name: "defaults", wantDays: defaultFleetDays, wantSort: models.FleetSortScore, wantAsc: true, wantLimit: defaultFleetLimit},
+		{name: "explicit", query: models.FleetQuery{Days: 7, Limit: 5, Sort: models.FleetSortTrend, Order: "desc"}, wantDays: 7, wantSort: models.FleetSortTrend, wantLimit: 5},
+		{name: "ascending order", query: models.FleetQuery{Sort: models.FleetSortDelta, Order: "asc"}, wantDays: defaultFleetDays, wantSort: models.FleetSortDelta, wantAsc: true, wantLimit: defaultFleetLimit},
+		{name: "out of range falls back", query: models.FleetQuery{Days: maxFleetDays + 1, Limit: maxFleetLimit + 1}, wantDays: defaultFleetDays, wantSort: models.FleetSortScore, wantAsc: true, wantLimit: defaultFleetLimit},
+		{name: "negative falls back", query: models.FleetQuery{Days: -1, Limit: -1}, wantDays: defaultFleetDays, wantSort: models.FleetSortScore, wantAsc: true, wantLimit: defaultFleetLimit},
+		{name: "maximum", query: models.FleetQuery{Days: maxFleetDays, Limit: maxFleetLimit}, wantDays: maxFleetDays, wantSort: models.FleetSortScore, wantAsc: true, wantLimit: maxFleetLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeFleetRepo{}
+			s := newTestVulnerabilityService(repo)
+
+			_, err := s.RankCabinets(context.Background(), &tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSort, repo.sort)
+			assert.Equal(t, tt.wantAsc, repo.ascending)
+			assert.Equal(t, tt.wantLimit, repo.limit)
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, -tt.wantDays), repo.since, time.Minute)
+
+			_, err = s.TopVulnerabilityTypes(context.Background(), &tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLimit, repo.limit)
+		})
+	}
+}
+
+func TestBuildFleetHeatmap(t *testing.T) {
+	lat, lon := 22.54, 114.05
+	latest := []*models.FleetHeatmapPoint{
+		{CabinetID: "CAB-1", Location: "深圳", Latitude: &lat, Longitude: &lon, Score: 30, RiskLevel: "high"},
+		{CabinetID: "CAB-2", Location: "深圳", Score: 70, RiskLevel: "medium"},
+		{CabinetID: "CAB-3", Location: "广州", Latitude: &lat, Longitude: &lon, Score: 90, RiskLevel: "healthy"},
+		{CabinetID: "CAB-4", Score: 45, RiskLevel: "high"},
+		{CabinetID: "CAB-5", Latitude: &lat, Score: 55, RiskLevel: "medium"}, // 坐标不完整
+	}
+	now := time.Now()
+
+	heatmap := buildFleetHeatmap(latest, now)
+	assert.Equal(t, now, heatmap.GeneratedAt)
+
+	// 只有完整坐标的储能柜作为点位，其余计入未定位
+	require.Len(t, heatmap.Points, 2)
+	assert.Equal(t, "CAB-1", heatmap.Points[0].CabinetID)
+	assert.Equal(t, "CAB-3", heatmap.Points[1].CabinetID)
+	assert.Equal(t, 3, heatmap.Unlocated)
+	assert.Equal(t, map[string]int{"high": 2, "medium": 2, "healthy": 1}, heatmap.RiskCounts)
+
+	// 区域按平均分升序，未设置位置的储能柜汇总到同一区域
+	require.Len(t, heatmap.Regions, 3)
+	shenzhen, unlocated, guangzhou := heatmap.Regions[0], heatmap.Regions[1], heatmap.Regions[2]
+	assert.Equal(t, "深圳", shenzhen.Region)
+	assert.Equal(t, 2, shenzhen.Cabinets)
+	assert.Equal(t, 50.0, shenzhen.AvgScore)
+	assert.Equal(t, 30.0, shenzhen.MinScore)
+	assert.Equal(t, map[string]int{"high": 1, "medium": 1}, shenzhen.RiskCounts)
+
+	assert.Equal(t, unlocatedRegionName, unlocated.Region)
+	assert.Equal(t, 2, unlocated.Cabinets)
+	assert.Equal(t, 50.0, unlocated.AvgScore)
+	assert.Equal(t, 45.0, unlocated.MinScore)
+
+	assert.Equal(t, "广州", guangzhou.Region)
+	assert.Equal(t, 90.0, guangzhou.AvgScore)
+
+	empty := buildFleetHeatmap(nil, now)
+	assert.NotNil(t, empty.Points)
+	assert.NotNil(t, empty.Regions)
+	assert.Empty(t, empty.RiskCounts)
+}
+
+func TestGetHeatmapUsesWindow(t *testing.T) {
+	repo := &fakeFleetRepo{latest: []*models.FleetHeatmapPoint{{CabinetID: "CAB-1", Score: 40, RiskLevel: "high"}}}
+	s := newTestVulnerabilityService(repo)
+
+	heatmap, err := s.GetHeatmap(context.Background(), &models.FleetQuery{Days: 3})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, -3), repo.since, time.Minute)
+	assert.Equal(t, 1, heatmap.Unlocated)
+	require.Len(t, heatmap.Regions, 1)
+}
@@ -0,0 +1,605 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/pkg/errors"
+
+	"go.uber.org/zap"
+)
+
+// maxReportRange 单份报告的最大时间范围
+const maxReportRange = 366 * 24 * time.Hour
+
+// reportData 评估报告内容
+type reportData struct {
+	Title        string
+	Scope        string
+	StartTime    time.Time
+	EndTime      time.Time
+	GeneratedAt  time.Time
+	GeneratedBy  string
+	Fleet        bool
+	Cabinets     []*reportCabinet
+	Trend        []*reportTrendDay
+	Changes      []*models.RiskLevelPoint
+	Open         []*models.VulnerabilityEventSummary
+	Resolved     []*models.VulnerabilityEventSummary
+	Dismissals   []*models.CabinetDismissal
+	Remediations []*models.CabinetRemediation
+}
+
+// reportCabinet 单个储能柜在报告区间内的汇总
+type reportCabinet struct {
+	CabinetID    string
+	Name         string
+	Assessments  int
+	StartLevel   string
+	StartScore   float64
+	EndLevel     string
+	EndScore     float64
+	AvgScore     float64
+	MinScore     float64
+	RiskChanges  int
+	Open         int
+	Dismissals   int
+	Remediations int
+}
+
+// reportTrendDay 每日评分（全网报告按评估次数加权汇总所有储能柜）
+type reportTrendDay struct {
+	Day      time.Time
+	AvgScore float64
+	MinScore float64
+	MaxScore float64
+	Samples  int
+}
+
+// GenerateReport 生成脆弱性评估报告并归档
+func (s *vulnerabilityService) GenerateReport(ctx context.Context, req *models.GenerateReportRequest, generatedBy string) (*models.VulnerabilityReport, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, errors.New(errors.ErrBadRequest, "结束时间必须晚于开始时间")
+	}
+	if req.EndTime.Sub(req.StartTime) > maxReportRange {
+		return nil, errors.New(errors.ErrBadRequest, "报告时间范围不能超过366天")
+	}
+
+	names := map[string]string{}
+	if req.CabinetID != "" {
+		cabinet, err := s.cabinetRepo.GetByID(ctx, req.CabinetID)
+		if err != nil {
+			if appErr, ok := err.(*errors.AppError); ok {
+				return nil, appErr
+			}
+			return nil, errors.Wrap(err, errors.ErrInternalServer, "查询储能柜失败")
+		}
+		names[cabinet.CabinetID] = cabinet.Name
+	} else {
+		cabinets, _, err := s.cabinetRepo.List(ctx, &models.CabinetListFilter{Page: 1, PageSize: 10000})
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInternalServer, "查询储能柜列表失败")
+		}
+		for _, cabinet := range cabinets {
+			names[cabinet.CabinetID] = cabinet.Name
+		}
+	}
+
+	data, err := s.collectReportData(ctx, req, names)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询报告数据失败")
+	}
+	data.GeneratedBy = generatedBy
+
+	var content, ext string
+	switch req.Format {
+	case models.ReportFormatHTML:
+		content, err = renderReportHTML(data)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrInternalServer, "生成HTML报告失败")
+		}
+		ext = "html"
+	case models.ReportFormatMarkdown:
+		content, ext = renderReportMarkdown(data), "md"
+	default:
+		return nil, errors.New(errors.ErrBadRequest, "不支持的报告格式")
+	}
+
+	scope := "fleet"
+	if req.CabinetID != "" {
+		scope = req.CabinetID
+	}
+	sum := sha256.Sum256([]byte(content))
+	report := &models.VulnerabilityReport{
+		CabinetID: req.CabinetID,
+		Format:    req.Format,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Title:     data.Title,
+		FileName: fmt.Sprintf("vulnerability-report-%s-%s-%s.%s",
+			scope, req.StartTime.Format("20060102"), req.EndTime.Format("20060102"), ext),
+		Content:     content,
+		SizeBytes:   len(content),
+		SHA256:      hex.EncodeToString(sum[:]),
+		GeneratedBy: generatedBy,
+		CreatedAt:   data.GeneratedAt,
+	}
+
+	report.ID, err = s.repo.CreateReport(ctx, report)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "归档评估报告失败")
+	}
+
+	s.logger.Info("脆弱性评估报告已生成",
+		zap.Int64("report_id", report.ID),
+		zap.String("cabinet_id", req.CabinetID),
+		zap.String("format", req.Format),
+		zap.Int("size_bytes", report.SizeBytes),
+	)
+	return report, nil
+}
+
+// ListReports 查询归档报告，默认最近50份
+func (s *vulnerabilityService) ListReports(ctx context.Context, query *models.ReportListQuery) ([]*models.VulnerabilityReport, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	reports, err := s.repo.ListReports(ctx, query.CabinetID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询归档报告失败")
+	}
+	return reports, nil
+}
+
+// GetReport 获取归档报告
+func (s *vulnerabilityService) GetReport(ctx context.Context, reportID int64) (*models.VulnerabilityReport, error) {
+	report, err := s.repo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "获取归档报告失败")
+	}
+	if report == nil {
+		return nil, errors.New(errors.ErrNotFound, "报告不存在")
+	}
+	return report, nil
+}
+
+// DeleteReport 删除归档报告
+func (s *vulnerabilityService) DeleteReport(ctx context.Context, reportID int64) error {
+	if _, err := s.GetReport(ctx, reportID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteReport(ctx, reportID); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "删除归档报告失败")
+	}
+	return nil
+}
+
+// collectReportData 查询报告区间内的评分趋势、风险等级变化、漏洞、消除及处置记录
+func (s *vulnerabilityService) collectReportData(ctx context.Context, req *models.GenerateReportRequest, names map[string]string) (*reportData, error) {
+	cabinetID, start, end := req.CabinetID, req.StartTime, req.EndTime
+
+	trend, err := s.repo.GetScoreTrend(ctx, cabinetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	levels, err := s.repo.GetRiskLevelPoints(ctx, cabinetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.repo.SummarizeEvents(ctx, cabinetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	dismissals, err := s.repo.ListDismissalsBetween(ctx, cabinetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	remediations, err := s.repo.ListRemediationsBetween(ctx, cabinetID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	data := buildReportData(cabinetID, names, trend, levels, events, dismissals, remediations)
+	data.StartTime, data.EndTime, data.GeneratedAt = start, end, time.Now()
+	return data, nil
+}
+
+// buildReportData 汇总报告内容
+func buildReportData(
+	cabinetID string,
+	names map[string]string,
+	trend []*models.ScoreTrendPoint,
+	levels []*models.RiskLevelPoint,
+	events []*models.VulnerabilityEventSummary,
+	dismissals []*models.CabinetDismissal,
+	remediations []*models.CabinetRemediation,
+) *reportData {
+	data := &reportData{
+		Fleet:        cabinetID == "",
+		Dismissals:   dismissals,
+		Remediations: remediations,
+	}
+	if data.Fleet {
+		data.Title = "全网脆弱性评估报告"
+	} else {
+		data.Title = "储能柜脆弱性评估报告"
+		data.Scope = cabinetID
+		if name := names[cabinetID]; name != "" {
+			data.Scope = fmt.Sprintf("%s（%s）", cabinetID, name)
+		}
+	}
+
+	cabinets := map[string]*reportCabinet{}
+	cabinet := func(id string) *reportCabinet {
+		c, ok := cabinets[id]
+		if !ok {
+			c = &reportCabinet{CabinetID: id, Name: names[id], MinScore: 100}
+			cabinets[id] = c
+		}
+		return c
+	}
+
+	days := map[int64]*reportTrendDay{}
+	for _, point := range trend {
+		c := cabinet(point.CabinetID)
+		c.AvgScore = (c.AvgScore*float64(c.Assessments) + point.AvgScore*float64(point.Samples)) / float64(c.Assessments+point.Samples)
+		c.Assessments += point.Samples
+		if point.MinScore < c.MinScore {
+			c.MinScore = point.MinScore
+		}
+
+		day, ok := days[point.Day.Unix()]
+		if !ok {
+			day = &reportTrendDay{Day: point.Day, MinScore: point.MinScore, MaxScore: point.MaxScore}
+			days[point.Day.Unix()] = day
+			data.Trend = append(data.Trend, day)
+		}
+		day.AvgScore = (day.AvgScore*float64(day.Samples) + point.AvgScore*float64(point.Samples)) / float64(day.Samples+point.Samples)
+		day.Samples += point.Samples
+		if point.MinScore < day.MinScore {
+			day.MinScore = point.MinScore
+		}
+		if point.MaxScore > day.MaxScore {
+			day.MaxScore = point.MaxScore
+		}
+	}
+	sort.Slice(data.Trend, func(i, j int) bool { return data.Trend[i].Day.Before(data.Trend[j].Day) })
+
+	for _, point := range levels {
+		c := cabinet(point.CabinetID)
+		if point.FromLevel == "" {
+			c.StartLevel, c.StartScore = point.RiskLevel, point.OverallScore
+		} else if point.FromLevel != point.RiskLevel {
+			c.RiskChanges++
+			data.Changes = append(data.Changes, point)
+		}
+		if point.Last {
+			c.EndLevel, c.EndScore = point.RiskLevel, point.OverallScore
+		}
+	}
+	sort.SliceStable(data.Changes, func(i, j int) bool { return data.Changes[i].Timestamp.Before(data.Changes[j].Timestamp) })
+
+	for _, event := range events {
+		if event.Open {
+			cabinet(event.CabinetID).Open++
+			data.Open = append(data.Open, event)
+		} else {
+			data.Resolved = append(data.Resolved, event)
+		}
+	}
+	sort.SliceStable(data.Open, func(i, j int) bool {
+		return severityRank(data.Open[i].Severity) > severityRank(data.Open[j].Severity)
+	})
+
+	for _, d := range dismissals {
+		cabinet(d.CabinetID).Dismissals++
+	}
+	for _, r := range remediations {
+		cabinet(r.CabinetID).Remediations++
+	}
+
+	for _, c := range cabinets {
+		if c.Assessments == 0 {
+			c.MinScore = 0
+		}
+		data.Cabinets = append(data.Cabinets, c)
+	}
+	// 区间末评分低的储能柜排在前面
+	sort.Slice(data.Cabinets, func(i, j int) bool {
+		a, b := data.Cabinets[i], data.Cabinets[j]
+		if a.EndScore != b.EndScore {
+			return a.EndScore < b.EndScore
+		}
+		return a.CabinetID < b.CabinetID
+	})
+	if data.Fleet {
+		data.Scope = fmt.Sprintf("全网（%d个储能柜有评估数据）", len(data.Cabinets))
+	}
+	return data
+}
+
+// severityRank 严重程度排序值
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 4
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
+const reportTimeLayout = "2006-01-02 15:04"
+
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(reportTimeLayout)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return formatReportTime(*t)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// trendChartSVG 每日评分折线图（平均分实线，最低分虚线），纵轴0-100
+func trendChartSVG(days []*reportTrendDay) template.HTML {
+	const width, height, pad = 720.0, 220.0, 30.0
+	if len(days) == 0 {
+		return ""
+	}
+
+	x := func(i int) float64 {
+		if len(days) == 1 {
+			return width / 2
+		}
+		return pad + float64(i)*(width-2*pad)/float64(len(days)-1)
+	}
+	y := func(score float64) float64 {
+		return height - pad - score*(height-2*pad)/100
+	}
+
+	var avgLine, minLine strings.Builder
+	for i, day := range days {
+		fmt.Fprintf(&avgLine, "%.1f,%.1f ", x(i), y(day.AvgScore))
+		fmt.Fprintf(&minLine, "%.1f,%.1f ", x(i), y(day.MinScore))
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %.0f %.0f" class="chart">`, width, height)
+	for _, level := range []float64{0, 40, 60, 80, 100} {
+		fmt.Fprintf(&svg, `<line x1="%.0f" y1="%.1f" x2="%.0f" y2="%.1f" class="grid"/><text x="4" y="%.1f">%.0f</text>`,
+			pad, y(level), width-pad, y(level), y(level)+4, level)
+	}
+	fmt.Fprintf(&svg, `<polyline points="%s" class="min"/><polyline points="%s" class="avg"/>`, minLine.String(), avgLine.String())
+	fmt.Fprintf(&svg, `<text x="%.0f" y="%.0f">%s</text><text x="%.0f" y="%.0f" text-anchor="end">%s</text>`,
+		pad, height-8, days[0].Day.Format("01-02"), width-pad, height-8, days[len(days)-1].Day.Format("01-02"))
+	svg.WriteString(`</svg>`)
+	return template.HTML(svg.String())
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":     formatReportTime,
+	"timePtr":  formatOptionalTime,
+	"dash":     orDash,
+	"chart":    trendChartSVG,
+	"score":    func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"date":     func(t time.Time) string { return t.Format("2006-01-02") },
+	"severity": func(s string) string { return "sev-" + s },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,"Segoe UI","PingFang SC","Microsoft YaHei",sans-serif;margin:32px;color:#1f2937}
+h1{font-size:22px;margin-bottom:4px}h2{font-size:17px;margin-top:32px;border-bottom:1px solid #e5e7eb;padding-bottom:6px}
+.meta{color:#6b7280;font-size:13px}table{border-collapse:collapse;width:100%;font-size:13px;margin-top:8px}
+th,td{border:1px solid #e5e7eb;padding:6px 8px;text-align:left;vertical-align:top}th{background:#f9fafb}
+.empty{color:#9ca3af;font-size:13px}.chart{width:100%;max-width:720px;height:auto}
+.chart .grid{stroke:#e5e7eb}.chart text{font-size:11px;fill:#6b7280}
+.chart .avg{fill:none;stroke:#2563eb;stroke-width:2}.chart .min{fill:none;stroke:#f59e0b;stroke-width:1.5;stroke-dasharray:4 3}
+.sev-critical{color:#b91c1c;font-weight:600}.sev-high{color:#dc2626}.sev-medium{color:#d97706}.sev-low{color:#2563eb}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">范围：{{.Scope}}<br>区间：{{time .StartTime}} 至 {{time .EndTime}}<br>生成：{{time .GeneratedAt}}{{if .GeneratedBy}}，{{.GeneratedBy}}{{end}}</div>
+
+<h2>概览</h2>
+{{if .Cabinets}}<table>
+<tr><th>储能柜</th><th>评估次数</th><th>期初等级/评分</th><th>期末等级/评分</th><th>平均分</th><th>最低分</th><th>等级变化</th><th>未关闭漏洞</th><th>消除记录</th><th>处置记录</th></tr>
+{{range .Cabinets}}<tr><td>{{.CabinetID}}{{if .Name}}<br><span class="meta">{{.Name}}</span>{{end}}</td><td>{{.Assessments}}</td><td>{{dash .StartLevel}} / {{score .StartScore}}</td><td>{{dash .EndLevel}} / {{score .EndScore}}</td><td>{{score .AvgScore}}</td><td>{{score .MinScore}}</td><td>{{.RiskChanges}}</td><td>{{.Open}}</td><td>{{.Dismissals}}</td><td>{{.Remediations}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间内没有评估数据</p>{{end}}
+
+<h2>评分趋势{{if .Fleet}}（全网按评估次数加权）{{end}}</h2>
+{{if .Trend}}{{chart .Trend}}
+<p class="meta">实线：每日平均分；虚线：每日最低分</p>
+<table>
+<tr><th>日期</th><th>平均分</th><th>最低分</th><th>最高分</th><th>评估次数</th></tr>
+{{range .Trend}}<tr><td>{{date .Day}}</td><td>{{score .AvgScore}}</td><td>{{score .MinScore}}</td><td>{{score .MaxScore}}</td><td>{{.Samples}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间内没有评估数据</p>{{end}}
+
+<h2>风险等级变化</h2>
+{{if .Changes}}<table>
+<tr><th>时间</th><th>储能柜</th><th>变化</th><th>综合评分</th></tr>
+{{range .Changes}}<tr><td>{{time .Timestamp}}</td><td>{{.CabinetID}}</td><td>{{.FromLevel}} → {{.RiskLevel}}</td><td>{{score .OverallScore}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间内风险等级没有变化</p>{{end}}
+
+<h2>未关闭漏洞</h2>
+{{if .Open}}<table>
+<tr><th>严重程度</th><th>储能柜</th><th>漏洞</th><th>设备</th><th>首次检测</th><th>最近检测</th><th>检测次数</th><th>修复建议</th></tr>
+{{range .Open}}<tr><td class="{{severity .Severity}}">{{.Severity}}</td><td>{{.CabinetID}}</td><td>{{.Title}}<br><span class="meta">{{.EventType}}</span></td><td>{{dash .DeviceID}}</td><td>{{time .FirstSeen}}</td><td>{{time .LastSeen}}</td><td>{{.Occurrences}}</td><td>{{dash .Solution}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间末没有未关闭的漏洞</p>{{end}}
+
+<h2>区间内已关闭漏洞</h2>
+{{if .Resolved}}<table>
+<tr><th>严重程度</th><th>储能柜</th><th>漏洞</th><th>设备</th><th>首次检测</th><th>最后检测</th><th>检测次数</th></tr>
+{{range .Resolved}}<tr><td class="{{severity .Severity}}">{{.Severity}}</td><td>{{.CabinetID}}</td><td>{{.Title}}<br><span class="meta">{{.EventType}}</span></td><td>{{dash .DeviceID}}</td><td>{{time .FirstSeen}}</td><td>{{time .LastSeen}}</td><td>{{.Occurrences}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">无</p>{{end}}
+
+<h2>已消除漏洞</h2>
+{{if .Dismissals}}<table>
+<tr><th>消除时间</th><th>储能柜</th><th>漏洞类型</th><th>原因</th><th>操作人</th><th>有效期至</th></tr>
+{{range .Dismissals}}<tr><td>{{time .DismissedAt}}</td><td>{{.CabinetID}}</td><td>{{.VulnerabilityType}}</td><td>{{dash .Reason}}</td><td>{{dash .DismissedBy}}</td><td>{{timePtr .ExpiresAt}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间内没有消除记录</p>{{end}}
+
+<h2>处置时间线</h2>
+{{if .Remediations}}<table>
+<tr><th>触发时间</th><th>储能柜</th><th>触发事件</th><th>处置动作</th><th>模式</th><th>状态</th><th>确认/执行人</th><th>执行时间</th><th>结果</th></tr>
+{{range .Remediations}}<tr><td>{{time .CreatedAt}}</td><td>{{.CabinetID}}</td><td>{{.EventType}}{{if .DeviceID}}<br><span class="meta">{{.DeviceID}}</span>{{end}}</td><td>{{.Action}}</td><td>{{.Mode}}</td><td>{{.Status}}</td><td>{{dash .ConfirmedBy}}</td><td>{{timePtr .ExecutedAt}}</td><td>{{dash .Result}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">区间内没有处置记录</p>{{end}}
+</body>
+</html>
+`))
+
+// renderReportHTML 渲染自包含的HTML报告（内联样式及SVG图表，无外部资源）
+func renderReportHTML(data *reportData) (string, error) {
+	var buf bytes.Buffer
+	if err := reportHTMLTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// mdCell 转义Markdown表格单元格
+func mdCell(value string) string {
+	value = strings.ReplaceAll(value, "|", `\|`)
+	value = strings.ReplaceAll(value, "\r", "")
+	return strings.ReplaceAll(value, "\n", "<br>")
+}
+
+// mdTable 写入Markdown表格
+func mdTable(b *strings.Builder, header []string, rows [][]string) {
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = mdCell(orDash(cell))
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	b.WriteString("\n")
+}
+
+// renderReportMarkdown 渲染Markdown报告
+func renderReportMarkdown(data *reportData) string {
+	var b strings.Builder
+	score := func(v float64) string { return fmt.Sprintf("%.1f", v) }
+	section := func(title, empty string, rows int) bool {
+		b.WriteString("## " + title + "\n\n")
+		if rows == 0 {
+			b.WriteString("_" + empty + "_\n\n")
+			return false
+		}
+		return true
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n", data.Title)
+	fmt.Fprintf(&b, "- 范围：%s\n- 区间：%s 至 %s\n- 生成：%s", data.Scope,
+		formatReportTime(data.StartTime), formatReportTime(data.EndTime), formatReportTime(data.GeneratedAt))
+	if data.GeneratedBy != "" {
+		fmt.Fprintf(&b, "，%s", data.GeneratedBy)
+	}
+	b.WriteString("\n\n")
+
+	if section("概览", "区间内没有评估数据", len(data.Cabinets)) {
+		rows := [][]string{}
+		for _, c := range data.Cabinets {
+			name := c.CabinetID
+			if c.Name != "" {
+				name += "（" + c.Name + "）"
+			}
+			rows = append(rows, []string{name, fmt.Sprint(c.Assessments),
+				orDash(c.StartLevel) + " / " + score(c.StartScore), orDash(c.EndLevel) + " / " + score(c.EndScore),
+				score(c.AvgScore), score(c.MinScore), fmt.Sprint(c.RiskChanges), fmt.Sprint(c.Open),
+				fmt.Sprint(c.Dismissals), fmt.Sprint(c.Remediations)})
+		}
+		mdTable(&b, []string{"储能柜", "评估次数", "期初等级/评分", "期末等级/评分", "平均分", "最低分", "等级变化", "未关闭漏洞", "消除记录", "处置记录"}, rows)
+	}
+
+	trendTitle := "评分趋势"
+	if data.Fleet {
+		trendTitle += "（全网按评估次数加权）"
+	}
+	if section(trendTitle, "区间内没有评估数据", len(data.Trend)) {
+		rows := [][]string{}
+		for _, day := range data.Trend {
+			rows = append(rows, []string{day.Day.Format("2006-01-02"), score(day.AvgScore), score(day.MinScore), score(day.MaxScore), fmt.Sprint(day.Samples)})
+		}
+		mdTable(&b, []string{"日期", "平均分", "最低分", "最高分", "评估次数"}, rows)
+	}
+
+	if section("风险等级变化", "区间内风险等级没有变化", len(data.Changes)) {
+		rows := [][]string{}
+		for _, c := range data.Changes {
+			rows = append(rows, []string{formatReportTime(c.Timestamp), c.CabinetID, c.FromLevel + " → " + c.RiskLevel, score(c.OverallScore)})
+		}
+		mdTable(&b, []string{"时间", "储能柜", "变化", "综合评分"}, rows)
+	}
+
+	if section("未关闭漏洞", "区间末没有未关闭的漏洞", len(data.Open)) {
+		rows := [][]string{}
+		for _, e := range data.Open {
+			rows = append(rows, []string{e.Severity, e.CabinetID, e.Title + " (" + e.EventType + ")", e.DeviceID,
+				formatReportTime(e.FirstSeen), formatReportTime(e.LastSeen), fmt.Sprint(e.Occurrences), e.Solution})
+		}
+		mdTable(&b, []string{"严重程度", "储能柜", "漏洞", "设备", "首次检测", "最近检测", "检测次数", "修复建议"}, rows)
+	}
+
+	if section("区间内已关闭漏洞", "无", len(data.Resolved)) {
+		rows := [][]string{}
+		for _, e := range data.Resolved {
+			rows = append(rows, []string{e.Severity, e.CabinetID, e.Title + " (" + e.EventType + ")", e.DeviceID,
+				formatReportTime(e.FirstSeen), formatReportTime(e.LastSeen), fmt.Sprint(e.Occurrences)})
+		}
+		mdTable(&b, []string{"严重程度", "储能柜", "漏洞", "设备", "首次检测", "最后检测", "检测次数"}, rows)
+	}
+
+	if section("已消除漏洞", "区间内没有消除记录", len(data.Dismissals)) {
+		rows := [][]string{}
+		for _, d := range data.Dismissals {
+			rows = append(rows, []string{formatReportTime(d.DismissedAt), d.CabinetID, d.VulnerabilityType, d.Reason, d.DismissedBy, formatOptionalTime(d.ExpiresAt)})
+		}
+		mdTable(&b, []string{"消除时间", "储能柜", "漏洞类型", "原因", "操作人", "有效期至"}, rows)
+	}
+
+	if section("处置时间线", "区间内没有处置记录", len(data.Remediations)) {
+		rows := [][]string{}
+		for _, r := range data.Remediations {
+			event := r.EventType
+			if r.DeviceID != "" {
+				event += " (" + r.DeviceID + ")"
+			}
+			rows = append(rows, []string{formatReportTime(r.CreatedAt), r.CabinetID, event, r.Action, r.Mode, r.Status,
+				r.ConfirmedBy, formatOptionalTime(r.ExecutedAt), r.Result})
+		}
+		mdTable(&b, []string{"触发时间", "储能柜", "触发事件", "处置动作", "模式", "状态", "确认/执行人", "执行时间", "结果"}, rows)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/repository"
+	"cloud-system/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportRange 报告查询的储能柜及时间区间
+type reportRange struct {
+	CabinetID  string
+	Start, End time.Time
+}
+
+// fakeReportRepo 返回固定的报告数据并记录查询区间
+type fakeReportRepo struct {
+	repository.VulnerabilityRepository
+	trend        []*models.ScoreTrendPoint
+	levels       []*models.RiskLevelPoint
+	events       []*models.VulnerabilityEventSummary
+	dismissals   []*models.CabinetDismissal
+	remediations []*models.CabinetRemediation
+	queried      []reportRange
+	reports      []*models.VulnerabilityReport
+}
+
+func (f *fakeReportRepo) record(cabinetID string, start, end time.Time) {
+	f.queried = append(f.queried, reportRange{CabinetID: cabinetID, Start: start, End: end})
+}
+
+func (f *fakeReportRepo) GetScoreTrend(ctx context.Context, cabinetID string, start, end time.Time) ([]*models.ScoreTrendPoint, error) {
+	f.record(cabinetID, start, end)
+	return f.trend, nil
+}
+
+func (f *fakeReportRepo) GetRiskLevelPoints(ctx context.Context, cabinetID string, start, end time.Time) ([]*models.RiskLevelPoint, error) {
+	f.record(cabinetID, start, end)
+	return f.levels, nil
+}
+
+func (f *fakeReportRepo) SummarizeEvents(ctx context.Context, cabinetID string, start, end time.Time) ([]*models.VulnerabilityEventSummary, error) {
+	f.record(cabinetID, start, end)
+	return f.events, nil
+}
+
+func (f *fakeReportRepo) ListDismissalsBetween(ctx context.Context, cabinetID string, start, end time.Time) ([]*models.CabinetDismissal, error) {
+	f.record(cabinetID, start, end)
+	return f.dismissals, nil
+}
+
+func (f *fakeReportRepo) ListRemediationsBetween(ctx context.Context, cabinetID string, start, end time.Time) ([]*models.CabinetRemediation, error) {
+	f.record(cabinetID, start, end)
+	return f.remediations, nil
+}
+
+func (f *fakeReportRepo) CreateReport(ctx context.Context, report *models.VulnerabilityReport) (int64, error) {
+	f.reports = append(f.reports, report)
+	return int64(len(f.reports)), nil
+}
+
+var reportDay = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testReportInputs CAB-1、CAB-2有评估数据，CAB-3只有消除记录
+func testReportInputs() *fakeReportRepo {
+	day := func(n int) time.Time { return reportDay.AddDate(0, 0, n) }
+	return &fakeReportRepo{
+		trend: []*models.ScoreTrendPoint{
+			{CabinetID: "CAB-1", Day: day(1), AvgScore: 80, MinScore: 70, MaxScore: 90, Samples: 2},
+			{CabinetID: "CAB-1", Day: day(2), AvgScore: 60, MinScore: 50, MaxScore: 65, Samples: 1},
+			{CabinetID: "CAB-2", Day: day(0), AvgScore: 50, MinScore: 40, MaxScore: 55, Samples: 2},
+			{CabinetID: "CAB-2", Day: day(1), AvgScore: 40, MinScore: 30, MaxScore: 50, Samples: 2},
+		},
+		levels: []*models.RiskLevelPoint{
+			{CabinetID: "CAB-1", Timestamp: day(1), RiskLevel: "low", OverallScore: 85},
+			{CabinetID: "CAB-1", Timestamp: day(2), FromLevel: "low", RiskLevel: "medium", OverallScore: 60, Last: true},
+			{CabinetID: "CAB-2", Timestamp: day(0), RiskLevel: "high", OverallScore: 50},
+			{CabinetID: "CAB-2", Timestamp: day(1), FromLevel: "high", RiskLevel: "high", OverallScore: 40, Last: true},
+		},
+		events: []*models.VulnerabilityEventSummary{
+			{CabinetID: "CAB-1", EventType: "weak_tls", Severity: "low", Title: "弱TLS", Open: true},
+			{CabinetID: "CAB-2", EventType: "firmware:FW-1", Severity: "critical", Title: "固件漏洞", DeviceID: "sensor-7", Open: true},
+			{CabinetID: "CAB-1", EventType: "open_port", Severity: "medium", Title: "开放端口"},
+			{CabinetID: "CAB-1", EventType: "default_password", Severity: "high", Title: "默认口令", Open: true},
+		},
+		dismissals: []*models.CabinetDismissal{
+			{CabinetID: "CAB-3", DismissalID: 1, VulnerabilityType: "weak_tls", DismissedAt: day(0)},
+			{CabinetID: "CAB-1", DismissalID: 2, VulnerabilityType: "open_port", DismissedAt: day(1)},
+		},
+		remediations: []*models.CabinetRemediation{
+			{CabinetID: "CAB-2", RemediationID: 1, EventType: "brute_force", Action: "block_ip", CreatedAt: day(0)},
+			{CabinetID: "CAB-2", RemediationID: 2, EventType: "disk_full", Action: "cleanup_storage", CreatedAt: day(1)},
+		},
+	}
+}
+
+func TestBuildReportData(t *testing.T) {
+	in := testReportInputs()
+	names := map[string]string{"CAB-1": "一号柜", "CAB-2": "二号柜"}
+
+	data := buildReportData("", names, in.trend, in.levels, in.events, in.dismissals, in.remediations)
+	assert.True(t, data.Fleet)
+	assert.Equal(t, "全网脆弱性评估报告", data.Title)
+	assert.Equal(t, "全网（3个储能柜有评估数据）", data.Scope)
+
+	// 每日趋势按评估次数加权汇总所有储能柜，按日期排序
+	require.Len(t, data.Trend, 3)
+	assert.Equal(t, reportTrendDay{Day: reportDay, AvgScore: 50, MinScore: 40, MaxScore: 55, Samples: 2}, *data.Trend[0])
+	assert.Equal(t, reportTrendDay{Day: reportDay.AddDate(0, 0, 1), AvgScore: 60, MinScore: 30, MaxScore: 90, Samples: 4}, *data.Trend[1])
+	assert.Equal(t, reportTrendDay{Day: reportDay.AddDate(0, 0, 2), AvgScore: 60, MinScore: 50, MaxScore: 65, Samples: 1}, *data.Trend[2])
+
+	// 期末评分低的储能柜在前，无评估数据的储能柜评分为0
+	require.Len(t, data.Cabinets, 3)
+	cab3, cab2, cab1 := data.Cabinets[0], data.Cabinets[1], data.Cabinets[2]
+	assert.Equal(t, reportCabinet{CabinetID: "CAB-3", Dismissals: 1}, *cab3)
+
+	assert.Equal(t, "CAB-2", cab2.CabinetID)
+	assert.Equal(t, "二号柜", cab2.Name)
+	assert.Equal(t, 4, cab2.Assessments)
+	assert.InDelta(t, 45, cab2.AvgScore, 1e-9)
+	assert.Equal(t, 30.0, cab2.MinScore)
+	assert.Equal(t, "high", cab2.StartLevel)
+	assert.Equal(t, 50.0, cab2.StartScore)
+	assert.Equal(t, "high", cab2.EndLevel)
+	assert.Equal(t, 40.0, cab2.EndScore)
+	assert.Equal(t, 0, cab2.RiskChanges)
+	assert.Equal(t, 1, cab2.Open)
+	assert.Equal(t, 2, cab2.Remediations)
+
+	assert.Equal(t, "CAB-1", cab1.CabinetID)
+	assert.Equal(t, 3, cab1.Assessments)
+	assert.InDelta(t, 220.0/3, cab1.AvgScore, 1e-9)
+	assert.Equal(t, 50.0, cab1.MinScore)
+	assert.Equal(t, "low", cab1.StartLevel)
+	assert.Equal(t, "medium", cab1.EndLevel)
+	assert.Equal(t, 1, cab1.RiskChanges)
+	assert.Equal(t, 2, cab1.Open)
+	assert.Equal(t, 1, cab1.Dismissals)
+
+	// 只有等级实际变化的点计入变化列表
+	require.Len(t, data.Changes, 1)
+	assert.Equal(t, "medium", data.Changes[0].RiskLevel)
+
+	// 未关闭漏洞按严重程度排序，已关闭的单独列出
+	require.Len(t, data.Open, 3)
+	assert.Equal(t, []string{"critical", "high", "low"},
+		[]string{data.Open[0].Severity, data.Open[1].Severity, data.Open[2].Severity})
+	require.Len(t, data.Resolved, 1)
+	assert.Equal(t, "open_port", data.Resolved[0].EventType)
+
+	single := buildReportData("CAB-1", names, nil, nil, nil, nil, nil)
+	assert.False(t, single.Fleet)
+	assert.Equal(t, "储能柜脆弱性评估报告", single.Title)
+	assert.Equal(t, "CAB-1（一号柜）", single.Scope)
+	assert.Empty(t, single.Cabinets)
+}
+
+func TestRenderReportHTMLEscapesContent(t *testing.T) {
+	in := testReportInputs()
+	in.events[0].Title = `<script>alert("title")</script>`
+	in.events[0].Solution = `"><img src=x onerror=alert(1)>`
+	in.dismissals[0].Reason = `<b>误报</b>`
+	in.remediations[0].Result = `</td><iframe src="//evil">`
+	names := map[string]string{"CAB-1": `<svg onload=alert(1)>`}
+
+	data := buildReportData("CAB-1", names, in.trend, in.levels, in.events, in.dismissals, in.remediations)
+	data.GeneratedBy = "<admin>"
+	html, err := renderReportHTML(data)
+	require.NoError(t, err)
+
+	for _, raw := range []string{"<script>", "<img src=x", "<b>误报", "<iframe", "<svg onload", "<admin>"} {
+		assert.NotContains(t, html, raw)
+	}
+	assert.Contains(t, html, "&lt;script&gt;alert(&#34;title&#34;)&lt;/script&gt;")
+	assert.Contains(t, html, "&lt;b&gt;误报&lt;/b&gt;")
+	assert.Contains(t, html, "&lt;admin&gt;")
+
+	// 内联SVG趋势图不被转义
+	assert.Contains(t, html, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(t, html, `<polyline points="`)
+	assert.Equal(t, 1, strings.Count(html, "<svg"))
+}
+
+func TestRenderReportMarkdownEscapesCells(t *testing.T) {
+	in := testReportInputs()
+	in.events[0].Title = "a|b\r\nc"
+	data := buildReportData("", nil, in.trend, in.levels, in.events, in.dismissals, in.remediations)
+
+	md := renderReportMarkdown(data)
+	assert.Contains(t, md, `a\|b<br>c (weak_tls)`)
+	assert.Contains(t, md, "## 处置时间线")
+	// 空值显示为占位符
+	assert.Contains(t, md, "| - |")
+}
+
+func TestGenerateReport(t *testing.T) {
+	ctx := context.Background()
+	start := reportDay
+	end := reportDay.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name     string
+		req      models.GenerateReportRequest
+		wantCode errors.ErrorCode
+		wantFile string
+	}{
+		{name: "end before start", req: models.GenerateReportRequest{Format: models.ReportFormatHTML, StartTime: end, EndTime: start}, wantCode: errors.ErrBadRequest},
+		{name: "empty range", req: models.GenerateReportRequest{Format: models.ReportFormatHTML, StartTime: start, EndTime: start}, wantCode: errors.ErrBadRequest},
+		{name: "range too long", req: models.GenerateReportRequest{Format: models.ReportFormatHTML, StartTime: start, EndTime: start.AddDate(0, 0, 367)}, wantCode: errors.ErrBadRequest},
+		{name: "unknown cabinet", req: models.GenerateReportRequest{CabinetID: "CAB-404", Format: models.ReportFormatHTML, StartTime: start, EndTime: end}, wantCode: errors.ErrNotFound},
+		{name: "unsupported format", req: models.GenerateReportRequest{Format: "pdf", StartTime: start, EndTime: end}, wantCode: errors.ErrBadRequest},
+		{name: "fleet html", req: models.GenerateReportRequest{Format: models.ReportFormatHTML, StartTime: start, EndTime: end}, wantFile: "vulnerability-report-fleet-20260301-20260308.html"},
+		{name: "cabinet markdown", req: models.GenerateReportRequest{CabinetID: "CAB-1", Format: models.ReportFormatMarkdown, StartTime: start, EndTime: end}, wantFile: "vulnerability-report-CAB-1-20260301-20260308.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := testReportInputs()
+			s := newTestVulnerabilityService(repo, "CAB-1", "CAB-2")
+
+			report, err := s.GenerateReport(ctx, &tt.req, "admin")
+			if tt.wantCode != "" {
+				assertAppError(t, err, tt.wantCode)
+				assert.Empty(t, repo.reports)
+				return
+			}
+			require.NoError(t, err)
+
+			// 所有数据查询使用请求的储能柜及区间
+			require.Len(t, repo.queried, 5)
+			for _, q := range repo.queried {
+				assert.Equal(t, reportRange{CabinetID: tt.req.CabinetID, Start: start, End: end}, q)
+			}
+
+			require.Len(t, repo.reports, 1)
+			assert.Equal(t, int64(1), report.ID)
+			assert.Equal(t, tt.wantFile, report.FileName)
+			assert.Equal(t, tt.req.CabinetID, report.CabinetID)
+			assert.Equal(t, "admin", report.GeneratedBy)
+			assert.Equal(t, len(report.Content), report.SizeBytes)
+			sum := sha256.Sum256([]byte(report.Content))
+			assert.Equal(t, hex.EncodeToString(sum[:]), report.SHA256)
+			assert.Contains(t, report.Content, "储能柜CAB-1")
+		})
+	}
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"cloud-system/internal/models"
@@ -31,6 +33,56 @@ type VulnerabilityService interface {
 
 	// GetStats 获取评估统计
 	GetStats(ctx context.Context, cabinetID string, days int) (map[string]interface{}, error)
+
+	// ListFirmwareAdvisories 获取固件漏洞目录
+	ListFirmwareAdvisories(ctx context.Context) ([]*models.FirmwareAdvisory, error)
+
+	// CreateFirmwareAdvisory 新增固件漏洞公告并下发目录
+	CreateFirmwareAdvisory(ctx context.Context, req *models.FirmwareAdvisoryRequest) (*models.FirmwareAdvisory, error)
+
+	// UpdateFirmwareAdvisory 更新固件漏洞公告并下发目录
+	UpdateFirmwareAdvisory(ctx context.Context, advisoryID string, req *models.FirmwareAdvisoryRequest) (*models.FirmwareAdvisory, error)
+
+	// DeleteFirmwareAdvisory 删除固件漏洞公告并下发目录
+	DeleteFirmwareAdvisory(ctx context.Context, advisoryID string) error
+
+	// DistributeFirmwareCatalog 下发固件漏洞目录，cabinetID为空时下发到所有储能柜
+	DistributeFirmwareCatalog(ctx context.Context, cabinetID string) error
+
+	// ListExpiringCertificates 查询全网（或指定储能柜）即将到期及已过期的TLS证书
+	ListExpiringCertificates(ctx context.Context, query *models.ExpiringCertificateQuery) ([]*models.CabinetCertificate, error)
+
+	// ListRemediations 查询储能柜的漏洞处置记录
+	ListRemediations(ctx context.Context, cabinetID string, query *models.RemediationListQuery) ([]*models.CabinetRemediation, error)
+
+	// GenerateReport 生成储能柜或全网的脆弱性评估报告并归档
+	GenerateReport(ctx context.Context, req *models.GenerateReportRequest, generatedBy string) (*models.VulnerabilityReport, error)
+
+	// ListReports 查询归档报告
+	ListReports(ctx context.Context, query *models.ReportListQuery) ([]*models.VulnerabilityReport, error)
+
+	// GetReport 获取归档报告（含内容）
+	GetReport(ctx context.Context, reportID int64) (*models.VulnerabilityReport, error)
+
+	// DeleteReport 删除归档报告
+	DeleteReport(ctx context.Context, reportID int64) error
+
+	// RankCabinets 全网储能柜评分排名
+	RankCabinets(ctx context.Context, query *models.FleetQuery) ([]*models.FleetCabinetRanking, error)
+
+	// TopVulnerabilityTypes 全网最常见的漏洞类型
+	TopVulnerabilityTypes(ctx context.Context, query *models.FleetQuery) ([]*models.FleetVulnerabilityType, error)
+
+	// GetRemediationTimes 按漏洞类型统计平均修复时长
+	GetRemediationTimes(ctx context.Context, query *models.FleetQuery) ([]*models.FleetRemediationTime, error)
+
+	// GetHeatmap 全网区域及风险热力数据
+	GetHeatmap(ctx context.Context, query *models.FleetQuery) (*models.FleetHeatmap, error)
+}
+
+// FirmwareCatalogPublisher 固件漏洞目录下发接口（由mqtt.FirmwareCatalogPublisher实现）
+type FirmwareCatalogPublisher interface {
+	PublishCatalog(ctx context.Context, cabinetID string, advisories []*models.FirmwareAdvisory) error
 }
 
 // vulnerabilityService 脆弱性评估服务实现
@@ -38,6 +90,8 @@ type vulnerabilityService struct {
 	repo        repository.VulnerabilityRepository
 	cabinetRepo repository.CabinetRepository // 新增：用于更新储能柜缓存
 	logger      *zap.Logger
+
+	catalogPublisher FirmwareCatalogPublisher // 固件漏洞目录下发（可选）
 }
 
 // NewVulnerabilityService 创建服务实例
@@ -49,6 +103,11 @@ func NewVulnerabilityService(repo repository.VulnerabilityRepository, cabinetRep
 	}
 }
 
+// SetFirmwareCatalogPublisher 设置固件漏洞目录下发器
+func (s *vulnerabilityService) SetFirmwareCatalogPublisher(publisher FirmwareCatalogPublisher) {
+	s.catalogPublisher = publisher
+}
+
 // SyncAssessment 接收Edge端同步的评估数据
 func (s *vulnerabilityService) SyncAssessment(ctx context.Context, req *models.VulnerabilitySyncRequest) error {
 	s.logger.Info("接收脆弱性评估同步",
@@ -94,6 +153,7 @@ func (s *vulnerabilityService) SyncAssessment(ctx context.Context, req *models.V
 			event := &models.VulnerabilityEvent{
 				AssessmentID: assessmentID,
 				CabinetID:    req.CabinetID,
+				DeviceID:     optionalString(dto.DeviceID),
 				EventType:    dto.Type,
 				Category:     dto.Category,
 				Title:        dto.Title,
@@ -111,7 +171,72 @@ func (s *vulnerabilityService) SyncAssessment(ctx context.Context, req *models.V
 		}
 	}
 
-	// 4. 更新储能柜的脆弱性评分缓存
+	// 4. 更新储能柜的TLS证书状态
+	if len(req.Certificates) > 0 {
+		for i := range req.Certificates {
+			// 检查失败的证书没有有效期
+			if req.Certificates[i].NotAfter != nil && req.Certificates[i].NotAfter.IsZero() {
+				req.Certificates[i].NotAfter = nil
+			}
+		}
+		if err := s.repo.ReplaceCabinetCertificates(ctx, req.CabinetID, req.Certificates); err != nil {
+			s.logger.Warn("更新储能柜证书状态失败",
+				zap.String("cabinet_id", req.CabinetID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// 5. 更新Edge处置剧本的处置记录
+	if len(req.Remediations) > 0 {
+		remediations := make([]*models.CabinetRemediation, 0, len(req.Remediations))
+		for _, dto := range req.Remediations {
+			remediations = append(remediations, &models.CabinetRemediation{
+				CabinetID:     req.CabinetID,
+				RemediationID: dto.ID,
+				EventType:     dto.EventType,
+				Severity:      dto.Severity,
+				DeviceID:      dto.DeviceID,
+				Action:        dto.Action,
+				Mode:          dto.Mode,
+				Status:        dto.Status,
+				Result:        dto.Result,
+				ConfirmedBy:   dto.ConfirmedBy,
+				CreatedAt:     dto.CreatedAt,
+				ExecutedAt:    dto.ExecutedAt,
+			})
+		}
+		if err := s.repo.UpsertRemediations(ctx, remediations); err != nil {
+			s.logger.Warn("更新储能柜处置记录失败",
+				zap.String("cabinet_id", req.CabinetID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// 6. 更新漏洞消除记录（评估报告使用）
+	if len(req.DismissedVulnerabilities) > 0 {
+		dismissals := make([]*models.CabinetDismissal, 0, len(req.DismissedVulnerabilities))
+		for _, dto := range req.DismissedVulnerabilities {
+			dismissals = append(dismissals, &models.CabinetDismissal{
+				CabinetID:         req.CabinetID,
+				DismissalID:       dto.ID,
+				VulnerabilityType: dto.Type,
+				Reason:            dto.Reason,
+				DismissedBy:       dto.DismissedBy,
+				DismissedAt:       dto.DismissedAt,
+				ExpiresAt:         dto.ExpiresAt,
+			})
+		}
+		if err := s.repo.UpsertDismissals(ctx, dismissals); err != nil {
+			s.logger.Warn("更新储能柜漏洞消除记录失败",
+				zap.String("cabinet_id", req.CabinetID),
+				zap.Error(err),
+			)
+		}
+	}
+
+	// 7. 更新储能柜的脆弱性评分缓存
 	if err := s.cabinetRepo.UpdateVulnerabilityScore(ctx, req.CabinetID, req.OverallScore, req.RiskLevel); err != nil {
 		s.logger.Warn("更新储能柜脆弱性评分缓存失败",
 			zap.String("cabinet_id", req.CabinetID),
@@ -227,6 +352,176 @@ func (s *vulnerabilityService) GetStats(ctx context.Context, cabinetID string, d
 	return stats, nil
 }
 
+// ListFirmwareAdvisories 获取固件漏洞目录
+func (s *vulnerabilityService) ListFirmwareAdvisories(ctx context.Context) ([]*models.FirmwareAdvisory, error) {
+	advisories, err := s.repo.ListFirmwareAdvisories(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询固件漏洞目录失败")
+	}
+	return advisories, nil
+}
+
+// CreateFirmwareAdvisory 新增固件漏洞公告
+func (s *vulnerabilityService) CreateFirmwareAdvisory(ctx context.Context, req *models.FirmwareAdvisoryRequest) (*models.FirmwareAdvisory, error) {
+	if req.AdvisoryID == "" {
+		return nil, errors.New(errors.ErrBadRequest, "公告ID不能为空")
+	}
+	existing, err := s.repo.GetFirmwareAdvisory(ctx, req.AdvisoryID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询固件漏洞公告失败")
+	}
+	if existing != nil {
+		return nil, errors.New(errors.ErrConflict, "公告ID已存在")
+	}
+
+	now := time.Now()
+	advisory := firmwareAdvisoryFromRequest(req.AdvisoryID, req)
+	advisory.CreatedAt, advisory.UpdatedAt = now, now
+	if err := s.repo.SaveFirmwareAdvisory(ctx, advisory); err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "保存固件漏洞公告失败")
+	}
+
+	s.distributeAfterChange(ctx)
+	return advisory, nil
+}
+
+// UpdateFirmwareAdvisory 更新固件漏洞公告
+func (s *vulnerabilityService) UpdateFirmwareAdvisory(ctx context.Context, advisoryID string, req *models.FirmwareAdvisoryRequest) (*models.FirmwareAdvisory, error) {
+	existing, err := s.repo.GetFirmwareAdvisory(ctx, advisoryID)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询固件漏洞公告失败")
+	}
+	if existing == nil {
+		return nil, errors.New(errors.ErrNotFound, "固件漏洞公告不存在")
+	}
+
+	advisory := firmwareAdvisoryFromRequest(advisoryID, req)
+	advisory.CreatedAt, advisory.UpdatedAt = existing.CreatedAt, time.Now()
+	if err := s.repo.SaveFirmwareAdvisory(ctx, advisory); err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "保存固件漏洞公告失败")
+	}
+
+	s.distributeAfterChange(ctx)
+	return advisory, nil
+}
+
+// DeleteFirmwareAdvisory 删除固件漏洞公告
+func (s *vulnerabilityService) DeleteFirmwareAdvisory(ctx context.Context, advisoryID string) error {
+	existing, err := s.repo.GetFirmwareAdvisory(ctx, advisoryID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "查询固件漏洞公告失败")
+	}
+	if existing == nil {
+		return errors.New(errors.ErrNotFound, "固件漏洞公告不存在")
+	}
+	if err := s.repo.DeleteFirmwareAdvisory(ctx, advisoryID); err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "删除固件漏洞公告失败")
+	}
+
+	s.distributeAfterChange(ctx)
+	return nil
+}
+
+// DistributeFirmwareCatalog 下发固件漏洞目录
+func (s *vulnerabilityService) DistributeFirmwareCatalog(ctx context.Context, cabinetID string) error {
+	if s.catalogPublisher == nil {
+		return errors.New(errors.ErrExternalService, "MQTT客户端未连接，无法下发固件漏洞目录")
+	}
+
+	advisories, err := s.repo.ListFirmwareAdvisories(ctx)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrInternalServer, "查询固件漏洞目录失败")
+	}
+
+	cabinetIDs := []string{cabinetID}
+	if cabinetID == "" {
+		cabinets, _, err := s.cabinetRepo.List(ctx, &models.CabinetListFilter{Page: 1, PageSize: 10000})
+		if err != nil {
+			return errors.Wrap(err, errors.ErrInternalServer, "查询储能柜列表失败")
+		}
+		cabinetIDs = cabinetIDs[:0]
+		for _, cabinet := range cabinets {
+			cabinetIDs = append(cabinetIDs, cabinet.CabinetID)
+		}
+	}
+
+	failed := 0
+	for _, id := range cabinetIDs {
+		if err := s.catalogPublisher.PublishCatalog(ctx, id, advisories); err != nil {
+			s.logger.Warn("下发固件漏洞目录失败", zap.String("cabinet_id", id), zap.Error(err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.New(errors.ErrExternalService, fmt.Sprintf("%d个储能柜下发失败", failed))
+	}
+	return nil
+}
+
+// ListExpiringCertificates 查询即将到期及已过期的TLS证书，默认30天内
+func (s *vulnerabilityService) ListExpiringCertificates(ctx context.Context, query *models.ExpiringCertificateQuery) ([]*models.CabinetCertificate, error) {
+	days := query.Days
+	if days <= 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	certificates, err := s.repo.ListExpiringCertificates(ctx, query.CabinetID, now.AddDate(0, 0, days))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询即将到期证书失败")
+	}
+	for _, cert := range certificates {
+		cert.DaysToExpiry = int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+	}
+	return certificates, nil
+}
+
+// ListRemediations 查询储能柜的漏洞处置记录，默认最近100条
+func (s *vulnerabilityService) ListRemediations(ctx context.Context, cabinetID string, query *models.RemediationListQuery) ([]*models.CabinetRemediation, error) {
+	limit := query.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	remediations, err := s.repo.ListRemediations(ctx, cabinetID, query.Status, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrInternalServer, "查询处置记录失败")
+	}
+	return remediations, nil
+}
+
+// distributeAfterChange 目录变更后下发到所有储能柜，失败只记录日志
+func (s *vulnerabilityService) distributeAfterChange(ctx context.Context) {
+	if s.catalogPublisher == nil {
+		return
+	}
+	if err := s.DistributeFirmwareCatalog(ctx, ""); err != nil {
+		s.logger.Warn("固件漏洞目录变更后下发失败", zap.Error(err))
+	}
+}
+
+func firmwareAdvisoryFromRequest(advisoryID string, req *models.FirmwareAdvisoryRequest) *models.FirmwareAdvisory {
+	return &models.FirmwareAdvisory{
+		AdvisoryID:   advisoryID,
+		Manufacturer: req.Manufacturer,
+		Model:        req.Model,
+		MinVersion:   req.MinVersion,
+		MaxVersion:   req.MaxVersion,
+		FixedVersion: req.FixedVersion,
+		Severity:     req.Severity,
+		Title:        req.Title,
+		Description:  req.Description,
+		Remediation:  req.Remediation,
+	}
+}
+
+// optionalString 空字符串转为nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
 // toJSONString 将对象转换为JSON字符串
 func toJSONString(data interface{}) *string {
 	if data == nil {
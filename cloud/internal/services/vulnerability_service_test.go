@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"cloud-system/internal/models"
+	"cloud-system/internal/repository"
+	"cloud-system/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeVulnerabilityRepo 内存实现，只实现测试用到的方法，其余方法调用时panic
+type fakeVulnerabilityRepo struct {
+	repository.VulnerabilityRepository
+	advisories map[string]*models.FirmwareAdvisory
+}
+
+func newFakeVulnerabilityRepo() *fakeVulnerabilityRepo {
+	return &fakeVulnerabilityRepo{advisories: map[string]*models.FirmwareAdvisory{}}
+}
+
+func (f *fakeVulnerabilityRepo) SaveFirmwareAdvisory(ctx context.Context, advisory *models.FirmwareAdvisory) error {
+	saved := *advisory
+	f.advisories[advisory.AdvisoryID] = &saved
+	return nil
+}
+
+func (f *fakeVulnerabilityRepo) GetFirmwareAdvisory(ctx context.Context, advisoryID string) (*models.FirmwareAdvisory, error) {
+	advisory, ok := f.advisories[advisoryID]
+	if !ok {
+		return nil, nil
+	}
+	found := *advisory
+	return &found, nil
+}
+
+func (f *fakeVulnerabilityRepo) ListFirmwareAdvisories(ctx context.Context) ([]*models.FirmwareAdvisory, error) {
+	advisories := []*models.FirmwareAdvisory{}
+	for _, advisory := range f.advisories {
+		listed := *advisory
+		advisories = append(advisories, &listed)
+	}
+	sort.Slice(advisories, func(i, j int) bool { return advisories[i].AdvisoryID < advisories[j].AdvisoryID })
+	return advisories, nil
+}
+
+func (f *fakeVulnerabilityRepo) DeleteFirmwareAdvisory(ctx context.Context, advisoryID string) error {
+	delete(f.advisories, advisoryID)
+	return nil
+}
+
+// fakeCabinetRepo 只实现储能柜查询
+type fakeCabinetRepo struct {
+	repository.CabinetRepository
+	cabinets []*models.Cabinet
+}
+
+func (f *fakeCabinetRepo) List(ctx context.Context, filter *models.CabinetListFilter) ([]*models.Cabinet, int64, error) {
+	return f.cabinets, int64(len(f.cabinets)), nil
+}
+
+func (f *fakeCabinetRepo) GetByID(ctx context.Context, cabinetID string) (*models.Cabinet, error) {
+	for _, cabinet := range f.cabinets {
+		if cabinet.CabinetID == cabinetID {
+			return cabinet, nil
+		}
+	}
+	return nil, errors.New(errors.ErrNotFound, "储能柜不存在")
+}
+
+// fakeCatalogPublisher 记录每个储能柜收到的目录
+type fakeCatalogPublisher struct {
+	published map[string][]string // cabinetID -> 每次下发的公告ID列表
+	failFor   map[string]bool
+}
+
+func (f *fakeCatalogPublisher) PublishCatalog(ctx context.Context, cabinetID string, advisories []*models.FirmwareAdvisory) error {
+	if f.failFor[cabinetID] {
+		return fmt.Errorf("publish to %s failed", cabinetID)
+	}
+	if f.published == nil {
+		f.published = map[string][]string{}
+	}
+	ids := ""
+	for i, advisory := range advisories {
+		if i > 0 {
+			ids += ","
+		}
+		ids += advisory.AdvisoryID
+	}
+	f.published[cabinetID] = append(f.published[cabinetID], ids)
+	return nil
+}
+
+func newTestVulnerabilityService(repo repository.VulnerabilityRepository, cabinetIDs ...string) *vulnerabilityService {
+	cabinets := &fakeCabinetRepo{}
+	for _, id := range cabinetIDs {
+		cabinets.cabinets = append(cabinets.cabinets, &models.Cabinet{CabinetID: id, Name: "储能柜" + id})
+	}
+	return NewVulnerabilityService(repo, cabinets, zap.NewNop()).(*vulnerabilityService)
+}
+
+func advisoryRequest(id, title string) *models.FirmwareAdvisoryRequest {
+	return &models.FirmwareAdvisoryRequest{
+		AdvisoryID:   id,
+		Manufacturer: "acme",
+		Model:        "SMK-100",
+		MaxVersion:   "1.2.9",
+		FixedVersion: "1.3.0",
+		Severity:     "high",
+		Title:        title,
+	}
+}
+
+// assertAppError 断言错误为指定错误码的AppError
+func assertAppError(t *testing.T, err error, code errors.ErrorCode) {
+	t.Helper()
+	require.Error(t, err)
+	appErr, ok := err.(*errors.AppError)
+	require.True(t, ok, "expected *errors.AppError, got %T", err)
+	assert.Equal(t, code, appErr.Code)
+}
+
+func TestFirmwareAdvisoryCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeVulnerabilityRepo()
+	s := newTestVulnerabilityService(repo, "CAB-1", "CAB-2")
+	publisher := &fakeCatalogPublisher{}
+	s.SetFirmwareCatalogPublisher(publisher)
+
+	_, err := s.CreateFirmwareAdvisory(ctx, advisoryRequest("", "missing id"))
+	assertAppError(t, err, errors.ErrBadRequest)
+
+	created, err := s.CreateFirmwareAdvisory(ctx, advisoryRequest("FW-1", "弱口令固件"))
+	require.NoError(t, err)
+	assert.Equal(t, "FW-1", created.AdvisoryID)
+	assert.False(t, created.CreatedAt.IsZero())
+	assert.Equal(t, created.CreatedAt, created.UpdatedAt)
+
+	_, err = s.CreateFirmwareAdvisory(ctx, advisoryRequest("FW-1", "duplicate"))
+	assertAppError(t, err, errors.ErrConflict)
+
+	_, err = s.CreateFirmwareAdvisory(ctx, advisoryRequest("FW-2", "调试接口未关闭"))
+	require.NoError(t, err)
+
+	// 更新保留创建时间，请求中的公告ID被忽略
+	time.Sleep(time.Millisecond)
+	update := advisoryRequest("IGNORED", "弱口令固件（已更新）")
+	update.Severity = "critical"
+	updated, err := s.UpdateFirmwareAdvisory(ctx, "FW-1", update)
+	require.NoError(t, err)
+	assert.Equal(t, "FW-1", updated.AdvisoryID)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+	assert.True(t, updated.UpdatedAt.After(created.UpdatedAt))
+	assert.Equal(t, "critical", repo.advisories["FW-1"].Severity)
+	assert.NotContains(t, repo.advisories, "IGNORED")
+
+	_, err = s.UpdateFirmwareAdvisory(ctx, "FW-404", update)
+	assertAppError(t, err, errors.ErrNotFound)
+
+	require.NoError(t, s.DeleteFirmwareAdvisory(ctx, "FW-2"))
+	assertAppError(t, s.DeleteFirmwareAdvisory(ctx, "FW-2"), errors.ErrNotFound)
+
+	advisories, err := s.ListFirmwareAdvisories(ctx)
+	require.NoError(t, err)
+	require.Len(t, advisories, 1)
+	assert.Equal(t, "弱口令固件（已更新）", advisories[0].Title)
+
+	// 每次成功变更都将完整目录下发到所有储能柜，失败的操作不下发
+	want := []string{"FW-1", "FW-1,FW-2", "FW-1,FW-2", "FW-1"}
+	assert.Equal(t, want, publisher.published["CAB-1"])
+	assert.Equal(t, want, publisher.published["CAB-2"])
+}
+
+func TestDistributeFirmwareCatalog(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeVulnerabilityRepo()
+	require.NoError(t, repo.SaveFirmwareAdvisory(ctx, &models.FirmwareAdvisory{AdvisoryID: "FW-1", Severity: "high", Title: "t"}))
+
+	t.Run("publisher not configured", func(t *testing.T) {
+		s := newTestVulnerabilityService(repo, "CAB-1")
+		assertAppError(t, s.DistributeFirmwareCatalog(ctx, ""), errors.ErrExternalService)
+
+		// 未配置下发器时目录变更仍然成功
+		_, err := s.CreateFirmwareAdvisory(ctx, advisoryRequest("FW-LOCAL", "local only"))
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteFirmwareAdvisory(ctx, "FW-LOCAL"))
+	})
+
+	t.Run("single cabinet", func(t *testing.T) {
+		s := newTestVulnerabilityService(repo, "CAB-1", "CAB-2")
+		publisher := &fakeCatalogPublisher{}
+		s.SetFirmwareCatalogPublisher(publisher)
+
+		require.NoError(t, s.DistributeFirmwareCatalog(ctx, "CAB-2"))
+		assert.Equal(t, map[string][]string{"CAB-2": {"FW-1"}}, publisher.published)
+	})
+
+	t.Run("all cabinets", func(t *testing.T) {
+		s := newTestVulnerabilityService(repo, "CAB-1", "CAB-2", "CAB-3")
+		publisher := &fakeCatalogPublisher{}
+		s.SetFirmwareCatalogPublisher(publisher)
+
+		require.NoError(t, s.DistributeFirmwareCatalog(ctx, ""))
+		assert.Len(t, publisher.published, 3)
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		s := newTestVulnerabilityService(repo, "CAB-1", "CAB-2", "CAB-3")
+		publisher := &fakeCatalogPublisher{failFor: map[string]bool{"CAB-1": true, "CAB-3": true}}
+		s.SetFirmwareCatalogPublisher(publisher)
+
+		err := s.DistributeFirmwareCatalog(ctx, "")
+		assertAppError(t, err, errors.ErrExternalService)
+		assert.Contains(t, err.Error(), "2个储能柜下发失败")
+		// 失败的储能柜不影响其他储能柜
+		assert.Equal(t, map[string][]string{"CAB-2": {"FW-1"}}, publisher.published)
+
+		// 变更后下发失败只记录日志
+		_, err = s.UpdateFirmwareAdvisory(ctx, "FW-1", advisoryRequest("FW-1", "updated"))
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,6 @@
+-- 为access_policies表添加effect字段
+-- 支持拒绝策略，配合组合算法(deny-overrides/permit-overrides/first-applicable)使用
+
+ALTER TABLE access_policies ADD COLUMN IF NOT EXISTS effect TEXT NOT NULL DEFAULT 'allow';
+
+COMMENT ON COLUMN access_policies.effect IS '策略效果: allow/deny';
@@ -0,0 +1,21 @@
+-- 策略版本对账
+-- access_policies增加version字段（每次修改递增），Edge心跳上报策略摘要后由Cloud对账
+
+ALTER TABLE access_policies ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN access_policies.version IS '策略版本，每次修改递增';
+
+CREATE TABLE IF NOT EXISTS policy_sync_status (
+    cabinet_id TEXT PRIMARY KEY,
+    in_sync BOOLEAN NOT NULL DEFAULT false,
+    expected_digest TEXT NOT NULL,
+    reported_digest TEXT NOT NULL,
+    expected_count INTEGER DEFAULT 0,
+    reported_count INTEGER DEFAULT 0,
+    drift JSONB,
+    last_action TEXT NOT NULL DEFAULT 'none',
+    last_heartbeat_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_reconciled_at TIMESTAMPTZ
+);
+
+COMMENT ON TABLE policy_sync_status IS '储能柜策略同步状态表,记录Edge心跳上报的策略摘要与Cloud的对账结果';
@@ -0,0 +1,8 @@
+-- 策略试运行模式
+-- shadow策略与生效策略一起评估，假设结果记录在access_logs.shadow中，不影响实际授权
+
+ALTER TABLE access_policies ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'enforce';
+ALTER TABLE access_logs ADD COLUMN IF NOT EXISTS shadow JSONB;
+
+COMMENT ON COLUMN access_policies.mode IS '策略模式: enforce/shadow（试运行，只记录不生效）';
+COMMENT ON COLUMN access_logs.shadow IS '试运行策略的假设结果';
@@ -0,0 +1,33 @@
+-- 设备行为信任度
+-- Edge根据认证失败、ABAC拒绝、数据质量异常、心跳缺失及固件版本维护动态信任分数，定期上报Cloud
+
+CREATE TABLE IF NOT EXISTS device_trust_scores (
+    cabinet_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 100,
+    penalty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    firmware_penalty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    firmware_version TEXT,
+    signals JSONB,
+    last_signal TEXT,
+    last_signal_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, device_id)
+);
+
+COMMENT ON TABLE device_trust_scores IS '设备行为信任度表,记录Edge上报的各设备当前动态信任分数';
+
+CREATE TABLE IF NOT EXISTS device_trust_events (
+    id SERIAL PRIMARY KEY,
+    cabinet_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    signal TEXT NOT NULL,
+    delta DOUBLE PRECISION NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL
+);
+
+COMMENT ON TABLE device_trust_events IS '设备信任度变化记录表,记录认证失败、访问拒绝、数据质量异常等行为信号';
+
+CREATE INDEX IF NOT EXISTS idx_trust_scores_score ON device_trust_scores(score);
+CREATE INDEX IF NOT EXISTS idx_trust_events_device ON device_trust_events(cabinet_id, device_id, timestamp DESC);
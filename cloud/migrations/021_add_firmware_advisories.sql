@@ -0,0 +1,23 @@
+-- 固件漏洞目录
+-- Cloud维护已知存在漏洞的固件版本范围并下发到Edge，Edge按设备型号、制造商及固件版本匹配生成设备级漏洞事件
+
+CREATE TABLE IF NOT EXISTS firmware_advisories (
+    advisory_id TEXT PRIMARY KEY,
+    manufacturer TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    min_version TEXT NOT NULL DEFAULT '',
+    max_version TEXT NOT NULL DEFAULT '',
+    fixed_version TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(16) NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    remediation TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE firmware_advisories IS '固件漏洞目录表,记录受影响的设备型号及固件版本范围';
+
+ALTER TABLE vulnerability_events ADD COLUMN IF NOT EXISTS device_id VARCHAR(64);
+
+COMMENT ON COLUMN vulnerability_events.device_id IS '设备级漏洞（如固件漏洞）关联的设备ID';
@@ -0,0 +1,28 @@
+-- 储能柜TLS证书状态
+-- Edge检查HTTPS、MQTT及Cloud端点使用中的证书，随脆弱性评估上报，Cloud据此汇总全网即将到期的证书
+
+CREATE TABLE IF NOT EXISTS cabinet_certificates (
+    cabinet_id TEXT NOT NULL,
+    target TEXT NOT NULL,
+    endpoint TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    issuer TEXT NOT NULL DEFAULT '',
+    serial_number TEXT NOT NULL DEFAULT '',
+    not_after TIMESTAMPTZ,
+    key_algorithm TEXT NOT NULL DEFAULT '',
+    key_size INTEGER NOT NULL DEFAULT 0,
+    signature_algorithm TEXT NOT NULL DEFAULT '',
+    chain_valid BOOLEAN NOT NULL DEFAULT FALSE,
+    chain_error TEXT NOT NULL DEFAULT '',
+    tls_version TEXT NOT NULL DEFAULT '',
+    cipher_suite TEXT NOT NULL DEFAULT '',
+    legacy_protocols TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, target)
+);
+
+COMMENT ON TABLE cabinet_certificates IS '储能柜TLS证书状态表,记录Edge上报的证书有效期、密钥及协议参数';
+
+CREATE INDEX IF NOT EXISTS idx_cabinet_certificates_not_after ON cabinet_certificates(not_after);
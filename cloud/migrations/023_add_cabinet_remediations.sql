@@ -0,0 +1,23 @@
+-- 储能柜漏洞处置记录
+-- Edge按处置剧本自动执行或等待确认的处置动作，随脆弱性评估上报，Cloud按储能柜及Edge记录ID更新
+
+CREATE TABLE IF NOT EXISTS cabinet_remediations (
+    cabinet_id TEXT NOT NULL,
+    remediation_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL,
+    severity VARCHAR(16) NOT NULL DEFAULT '',
+    device_id TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    result TEXT NOT NULL DEFAULT '',
+    confirmed_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    executed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, remediation_id)
+);
+
+COMMENT ON TABLE cabinet_remediations IS '储能柜漏洞处置记录表,记录Edge处置剧本触发的动作、模式及执行结果';
+
+CREATE INDEX IF NOT EXISTS idx_cabinet_remediations_status ON cabinet_remediations(status, created_at DESC);
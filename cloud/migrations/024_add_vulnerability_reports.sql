@@ -0,0 +1,36 @@
+-- 漏洞消除记录及脆弱性评估报告归档
+-- Edge随评估结果上报漏洞消除记录（含原因），Cloud按储能柜及日期范围生成HTML/Markdown评估报告并归档供下载
+
+CREATE TABLE IF NOT EXISTS cabinet_dismissals (
+    cabinet_id TEXT NOT NULL,
+    dismissal_id BIGINT NOT NULL,
+    vulnerability_type TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    dismissed_by TEXT NOT NULL DEFAULT '',
+    dismissed_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, dismissal_id)
+);
+
+COMMENT ON TABLE cabinet_dismissals IS '储能柜漏洞消除记录表,记录Edge消除漏洞的类型、原因及有效期';
+
+CREATE TABLE IF NOT EXISTS vulnerability_reports (
+    id BIGSERIAL PRIMARY KEY,
+    cabinet_id TEXT NOT NULL DEFAULT '',
+    format VARCHAR(16) NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    title TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    generated_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE vulnerability_reports IS '脆弱性评估报告归档表,cabinet_id为空表示全网报告';
+
+CREATE INDEX IF NOT EXISTS idx_cabinet_dismissals_time ON cabinet_dismissals(dismissed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vulnerability_reports_cabinet ON vulnerability_reports(cabinet_id, created_at DESC);
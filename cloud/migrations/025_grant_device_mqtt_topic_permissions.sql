@@ -0,0 +1,18 @@
+-- 设备MQTT Topic权限
+-- Edge内嵌broker按Topic评估设备权限（sensors/alerts/devices/{id}/status|heartbeat -> write:sensors等），
+-- 此前的预置策略只授予HTTP接口的write:sensor_data，新增基础策略授予未禁用设备的上行Topic及下行命令订阅权限
+
+INSERT INTO access_policies (id, name, description, subject_type, conditions, permissions, priority, enabled)
+VALUES (
+    'policy_device_mqtt_topics',
+    '设备MQTT上行及命令订阅',
+    '未禁用的设备可以发布传感器数据、状态、心跳和告警，并订阅下行命令',
+    'device',
+    '[
+        {"attribute": "status", "operator": "ne", "value": "disabled"}
+    ]'::jsonb,
+    '["write:sensors", "write:status", "write:heartbeat", "write:alerts", "read:commands"]'::jsonb,
+    40,
+    true
+)
+ON CONFLICT (id) DO NOTHING;
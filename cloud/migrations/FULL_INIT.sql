@@ -276,6 +276,7 @@ CREATE TABLE IF NOT EXISTS vulnerability_events (
     id BIGSERIAL PRIMARY KEY,
     assessment_id BIGINT NOT NULL,
     cabinet_id VARCHAR(64) NOT NULL,
+    device_id VARCHAR(64),
 
     -- 漏洞信息
     event_type VARCHAR(64) NOT NULL,
@@ -301,16 +302,20 @@ CREATE TABLE IF NOT EXISTS access_policies (
     name TEXT NOT NULL,
     description TEXT,
     subject_type TEXT NOT NULL,
+    effect TEXT NOT NULL DEFAULT 'allow',
+    mode TEXT NOT NULL DEFAULT 'enforce',
     conditions JSONB NOT NULL,
     permissions JSONB NOT NULL,
     priority INTEGER DEFAULT 50,
     enabled BOOLEAN DEFAULT true,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
 COMMENT ON TABLE access_policies IS 'ABAC访问策略表';
 COMMENT ON COLUMN access_policies.subject_type IS '主体类型: user/cabinet/device';
+COMMENT ON COLUMN access_policies.mode IS '策略模式: enforce/shadow（试运行，只记录不生效）';
 
 -- ABAC访问日志表
 CREATE TABLE IF NOT EXISTS access_logs (
@@ -324,10 +329,12 @@ CREATE TABLE IF NOT EXISTS access_logs (
     trust_score FLOAT,
     ip_address TEXT,
     timestamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    attributes JSONB
+    attributes JSONB,
+    shadow JSONB
 );
 
 COMMENT ON TABLE access_logs IS 'ABAC访问日志表,用于审计';
+COMMENT ON COLUMN access_logs.shadow IS '试运行策略的假设结果';
 
 -- 策略分发日志表
 CREATE TABLE IF NOT EXISTS policy_distribution_logs (
@@ -345,6 +352,144 @@ CREATE TABLE IF NOT EXISTS policy_distribution_logs (
 
 COMMENT ON TABLE policy_distribution_logs IS '策略分发日志表,记录每次策略分发操作';
 
+-- 储能柜策略同步状态表
+CREATE TABLE IF NOT EXISTS policy_sync_status (
+    cabinet_id TEXT PRIMARY KEY,
+    in_sync BOOLEAN NOT NULL DEFAULT false,
+    expected_digest TEXT NOT NULL,
+    reported_digest TEXT NOT NULL,
+    expected_count INTEGER DEFAULT 0,
+    reported_count INTEGER DEFAULT 0,
+    drift JSONB,
+    last_action TEXT NOT NULL DEFAULT 'none',
+    last_heartbeat_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_reconciled_at TIMESTAMPTZ
+);
+
+COMMENT ON TABLE policy_sync_status IS '储能柜策略同步状态表,记录Edge心跳上报的策略摘要与Cloud的对账结果';
+
+-- 设备行为信任度表
+CREATE TABLE IF NOT EXISTS device_trust_scores (
+    cabinet_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 100,
+    penalty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    firmware_penalty DOUBLE PRECISION NOT NULL DEFAULT 0,
+    firmware_version TEXT,
+    signals JSONB,
+    last_signal TEXT,
+    last_signal_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, device_id)
+);
+
+COMMENT ON TABLE device_trust_scores IS '设备行为信任度表,记录Edge上报的各设备当前动态信任分数';
+
+-- 设备信任度变化记录表
+CREATE TABLE IF NOT EXISTS device_trust_events (
+    id SERIAL PRIMARY KEY,
+    cabinet_id TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    signal TEXT NOT NULL,
+    delta DOUBLE PRECISION NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL
+);
+
+COMMENT ON TABLE device_trust_events IS '设备信任度变化记录表,记录认证失败、访问拒绝、数据质量异常等行为信号';
+
+-- 固件漏洞目录表
+CREATE TABLE IF NOT EXISTS firmware_advisories (
+    advisory_id TEXT PRIMARY KEY,
+    manufacturer TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    min_version TEXT NOT NULL DEFAULT '',
+    max_version TEXT NOT NULL DEFAULT '',
+    fixed_version TEXT NOT NULL DEFAULT '',
+    severity VARCHAR(16) NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    remediation TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE firmware_advisories IS '固件漏洞目录表,记录受影响的设备型号及固件版本范围';
+
+CREATE TABLE IF NOT EXISTS cabinet_certificates (
+    cabinet_id TEXT NOT NULL,
+    target TEXT NOT NULL,
+    endpoint TEXT NOT NULL DEFAULT '',
+    subject TEXT NOT NULL DEFAULT '',
+    issuer TEXT NOT NULL DEFAULT '',
+    serial_number TEXT NOT NULL DEFAULT '',
+    not_after TIMESTAMPTZ,
+    key_algorithm TEXT NOT NULL DEFAULT '',
+    key_size INTEGER NOT NULL DEFAULT 0,
+    signature_algorithm TEXT NOT NULL DEFAULT '',
+    chain_valid BOOLEAN NOT NULL DEFAULT FALSE,
+    chain_error TEXT NOT NULL DEFAULT '',
+    tls_version TEXT NOT NULL DEFAULT '',
+    cipher_suite TEXT NOT NULL DEFAULT '',
+    legacy_protocols TEXT[] NOT NULL DEFAULT '{}',
+    error TEXT NOT NULL DEFAULT '',
+    checked_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, target)
+);
+
+COMMENT ON TABLE cabinet_certificates IS '储能柜TLS证书状态表,记录Edge上报的证书有效期、密钥及协议参数';
+
+CREATE TABLE IF NOT EXISTS cabinet_remediations (
+    cabinet_id TEXT NOT NULL,
+    remediation_id BIGINT NOT NULL,
+    event_type TEXT NOT NULL,
+    severity VARCHAR(16) NOT NULL DEFAULT '',
+    device_id TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    mode VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    result TEXT NOT NULL DEFAULT '',
+    confirmed_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    executed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, remediation_id)
+);
+
+COMMENT ON TABLE cabinet_remediations IS '储能柜漏洞处置记录表,记录Edge处置剧本触发的动作、模式及执行结果';
+
+CREATE TABLE IF NOT EXISTS cabinet_dismissals (
+    cabinet_id TEXT NOT NULL,
+    dismissal_id BIGINT NOT NULL,
+    vulnerability_type TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    dismissed_by TEXT NOT NULL DEFAULT '',
+    dismissed_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cabinet_id, dismissal_id)
+);
+
+COMMENT ON TABLE cabinet_dismissals IS '储能柜漏洞消除记录表,记录Edge消除漏洞的类型、原因及有效期';
+
+CREATE TABLE IF NOT EXISTS vulnerability_reports (
+    id BIGSERIAL PRIMARY KEY,
+    cabinet_id TEXT NOT NULL DEFAULT '',
+    format VARCHAR(16) NOT NULL,
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time TIMESTAMPTZ NOT NULL,
+    title TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    sha256 VARCHAR(64) NOT NULL DEFAULT '',
+    generated_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE vulnerability_reports IS '脆弱性评估报告归档表,cabinet_id为空表示全网报告';
+
 -- ===============================================
 -- 第三部分: TimescaleDB Hypertables
 -- ===============================================
@@ -467,6 +612,20 @@ CREATE INDEX IF NOT EXISTS idx_distribution_cabinet ON policy_distribution_logs(
 CREATE INDEX IF NOT EXISTS idx_distribution_status ON policy_distribution_logs(status);
 CREATE INDEX IF NOT EXISTS idx_distribution_time ON policy_distribution_logs(distributed_at DESC);
 
+-- 设备信任度表索引
+CREATE INDEX IF NOT EXISTS idx_trust_scores_score ON device_trust_scores(score);
+CREATE INDEX IF NOT EXISTS idx_trust_events_device ON device_trust_events(cabinet_id, device_id, timestamp DESC);
+
+-- 储能柜证书表索引
+CREATE INDEX IF NOT EXISTS idx_cabinet_certificates_not_after ON cabinet_certificates(not_after);
+
+-- 储能柜处置记录表索引
+CREATE INDEX IF NOT EXISTS idx_cabinet_remediations_status ON cabinet_remediations(status, created_at DESC);
+
+-- 漏洞消除记录及评估报告表索引
+CREATE INDEX IF NOT EXISTS idx_cabinet_dismissals_time ON cabinet_dismissals(dismissed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_vulnerability_reports_cabinet ON vulnerability_reports(cabinet_id, created_at DESC);
+
 -- ===============================================
 -- 第五部分: 触发器
 -- ===============================================
@@ -584,6 +743,19 @@ VALUES
         '["write:sensor_data", "trigger:alert"]'::jsonb,
         70,
         true
+    ),
+    -- 策略6: 设备MQTT上行及命令订阅
+    (
+        'policy_device_mqtt_topics',
+        '设备MQTT上行及命令订阅',
+        '未禁用的设备可以发布传感器数据、状态、心跳和告警，并订阅下行命令',
+        'device',
+        '[
+            {"attribute": "status", "operator": "ne", "value": "disabled"}
+        ]'::jsonb,
+        '["write:sensors", "write:status", "write:heartbeat", "write:alerts", "read:commands"]'::jsonb,
+        40,
+        true
     )
 ON CONFLICT (id) DO NOTHING;
 
@@ -6,7 +6,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
@@ -16,6 +18,7 @@ import (
 	"github.com/edge/storage-cabinet/internal/auth"
 	"github.com/edge/storage-cabinet/internal/collector"
 	"github.com/edge/storage-cabinet/internal/device"
+	"github.com/edge/storage-cabinet/internal/pki"
 	"github.com/edge/storage-cabinet/internal/storage"
 	"github.com/edge/storage-cabinet/internal/vulnerability"
 	"github.com/edge/storage-cabinet/pkg/models"
@@ -65,20 +68,9 @@ func GetChallenge(authService *auth.Service) gin.HandlerFunc {
 			return
 		}
 
-		challenge, err := authService.GenerateChallenge(req.DeviceID)
+		challenge, err := authService.GenerateChallenge(req.DeviceID, c.ClientIP())
 		if err != nil {
-			// 检查是否是许可证错误
-			if strings.Contains(err.Error(), "LICENSE_001") {
-				c.JSON(http.StatusForbidden, gin.H{
-					"error":   "LICENSE_001",
-					"message": "许可证校验失败: " + err.Error(),
-				})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "CHALLENGE_FAILED",
-				"message": "生成挑战失败: " + err.Error(),
-			})
+			respondChallengeError(c, err)
 			return
 		}
 
@@ -86,8 +78,36 @@ func GetChallenge(authService *auth.Service) gin.HandlerFunc {
 			ChallengeID: challenge.ChallengeID,
 			Nonce:       challenge.Nonce,
 			ExpiresAt:   challenge.ExpiresAt,
+			KeyID:       challenge.KeyID,
+			TargetKeyID: challenge.TargetKeyID,
+		})
+	}
+}
+
+// respondChallengeError 返回生成挑战失败的响应
+func respondChallengeError(c *gin.Context, err error) {
+	if respondAuthLocked(c, err) {
+		return
+	}
+	if strings.Contains(err.Error(), "CHALLENGE_LIMIT") {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":   "CHALLENGE_LIMIT",
+			"message": "未使用的挑战过多，请使用已有挑战或等待其过期",
 		})
+		return
 	}
+	// 检查是否是许可证错误
+	if strings.Contains(err.Error(), "LICENSE_001") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "LICENSE_001",
+			"message": "许可证校验失败: " + err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   "CHALLENGE_FAILED",
+		"message": "生成挑战失败: " + err.Error(),
+	})
 }
 
 // VerifyProof 验证零知识证明
@@ -102,8 +122,19 @@ func VerifyProof(authService *auth.Service) gin.HandlerFunc {
 			return
 		}
 
-		session, err := authService.VerifyProof(&req)
+		session, err := authService.VerifyProof(&req, c.ClientIP())
 		if err != nil {
+			if respondAuthLocked(c, err) || respondVerifyBusy(c, err) {
+				return
+			}
+			// 设备被要求轮换凭证
+			if strings.Contains(err.Error(), "CREDENTIAL_001") {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":   "ROTATION_REQUIRED",
+					"message": "设备凭证需要轮换，请通过 /api/v1/auth/rotate/challenge 获取轮换挑战后调用 /api/v1/auth/rotate",
+				})
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "AUTH_FAILED",
 				"message": "认证失败: " + err.Error(),
@@ -153,6 +184,386 @@ func RefreshSession(authService *auth.Service) gin.HandlerFunc {
 	}
 }
 
+// respondAuthLocked 认证被锁定时返回429并设置Retry-After
+func respondAuthLocked(c *gin.Context, err error) bool {
+	var lockErr *auth.LockoutError
+	if !errors.As(err, &lockErr) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(lockErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "AUTH_LOCKED",
+		"message":     "认证失败次数过多，已临时锁定",
+		"scope":       lockErr.Scope,
+		"retry_after": retryAfter,
+	})
+	return true
+}
+
+// respondVerifyBusy 验证繁忙时返回503：队列已满时设备稍后使用同一挑战重试，
+// 验证超时时挑战已消费，设备需重新获取挑战
+func respondVerifyBusy(c *gin.Context, err error) bool {
+	switch {
+	case strings.Contains(err.Error(), "VERIFY_BUSY"):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "VERIFY_BUSY",
+			"message": "认证请求繁忙，请稍后重试",
+		})
+	case strings.Contains(err.Error(), "VERIFY_TIMEOUT"):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "VERIFY_TIMEOUT",
+			"message": "认证验证超时，请重新获取挑战",
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// GetVerificationStats 查询证明验证工作池的延迟和队列统计（Web管理界面）
+func GetVerificationStats(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, authService.VerificationStats())
+	}
+}
+
+// ListVerifyingKeys 查询已加载的verifying key及迁移进度（Web管理界面）
+func ListVerifyingKeys(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := authService.ListVerifyingKeys()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": "查询verifying key失败: " + err.Error(),
+			})
+			return
+		}
+
+		// 多个key同时加载表示处于迁移窗口
+		c.JSON(http.StatusOK, gin.H{
+			"keys":      keys,
+			"total":     len(keys),
+			"migrating": len(keys) > 1,
+		})
+	}
+}
+
+// RetireVerifyingKey 卸载verifying key（需运维群组会话认证）
+// 仍有设备使用该key时需要 force=true
+func RetireVerifyingKey(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keyID := c.Param("key_id")
+		force := c.Query("force") == "true"
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		if err := authService.RetireVerifyingKey(keyID, operator, force); err != nil {
+			status := http.StatusBadRequest
+			if strings.Contains(err.Error(), "still used") {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"error":   "RETIRE_FAILED",
+				"message": "卸载verifying key失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "verifying key已卸载",
+			"key_id":  keyID,
+		})
+	}
+}
+
+// ListAuthLockouts 查询认证失败计数和锁定状态（Web管理界面）
+func ListAuthLockouts(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lockouts := authService.ListLockouts()
+		c.JSON(http.StatusOK, gin.H{
+			"lockouts": lockouts,
+			"total":    len(lockouts),
+		})
+	}
+}
+
+// UnlockAuth 解除设备或IP的认证锁定（需运维群组会话认证）
+func UnlockAuth(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := c.Param("scope")
+		key := c.Param("key")
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		if err := authService.Unlock(scope, key, operator); err != nil {
+			status := http.StatusNotFound
+			if strings.Contains(err.Error(), "invalid lockout scope") {
+				status = http.StatusBadRequest
+			}
+			c.JSON(status, gin.H{
+				"error":   "UNLOCK_FAILED",
+				"message": "解除锁定失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "已解除锁定",
+			"scope":   scope,
+			"key":     key,
+		})
+	}
+}
+
+// ListDeviceSessions 查询设备会话（Web管理界面）
+// 默认只返回活跃会话，active=false 时包含已撤销和已过期的会话
+func ListDeviceSessions(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		activeOnly := c.DefaultQuery("active", "true") != "false"
+
+		sessions, err := authService.ListSessions(deviceID, activeOnly)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": "查询会话失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"device_id": deviceID,
+			"sessions":  sessions,
+			"total":     len(sessions),
+		})
+	}
+}
+
+// RevokeDeviceSessions 撤销设备的所有会话（Web管理界面）
+func RevokeDeviceSessions(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+
+		revoked, err := authService.RevokeDeviceSessions(deviceID, auth.SessionRevokeReasonAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "REVOKE_FAILED",
+				"message": "撤销会话失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "设备会话已撤销",
+			"device_id": deviceID,
+			"revoked":   revoked,
+		})
+	}
+}
+
+// ListSessions 查询所有设备的会话（Web管理界面）
+func ListSessions(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		activeOnly := c.DefaultQuery("active", "true") != "false"
+
+		sessions, err := authService.ListSessions(c.Query("device_id"), activeOnly)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": "查询会话失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"sessions": sessions,
+			"total":    len(sessions),
+		})
+	}
+}
+
+// RevokeSession 撤销单个会话（Web管理界面）
+func RevokeSession(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID := c.Param("session_id")
+
+		if err := authService.RevokeSession(sessionID, auth.SessionRevokeReasonAdmin); err != nil {
+			status := http.StatusInternalServerError
+			if strings.Contains(err.Error(), "not found") {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, gin.H{
+				"error":   "REVOKE_FAILED",
+				"message": "撤销会话失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "会话已撤销",
+			"session_id": sessionID,
+		})
+	}
+}
+
+// RevokeAllSessions 撤销本储能柜所有设备的会话（Web管理界面）
+func RevokeAllSessions(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		revoked, err := authService.RevokeAllSessions(auth.SessionRevokeReasonAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "REVOKE_FAILED",
+				"message": "撤销会话失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "所有会话已撤销",
+			"revoked": revoked,
+		})
+	}
+}
+
+// GetRotationChallenge 获取凭证轮换挑战
+// 挑战在签发时绑定新承诺，只能用于 /auth/rotate 登记该承诺
+func GetRotationChallenge(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RotationChallengeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		challenge, err := authService.GenerateRotationChallenge(req.DeviceID, req.NewCommitment, c.ClientIP())
+		if err != nil {
+			if strings.Contains(err.Error(), "invalid new commitment") {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "INVALID_COMMITMENT",
+					"message": "新承诺值无效: " + err.Error(),
+				})
+				return
+			}
+			respondChallengeError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ChallengeResponse{
+			ChallengeID: challenge.ChallengeID,
+			Nonce:       challenge.Nonce,
+			ExpiresAt:   challenge.ExpiresAt,
+			KeyID:       challenge.KeyID,
+			TargetKeyID: challenge.TargetKeyID,
+			BindingSalt: challenge.BindingSalt,
+		})
+	}
+}
+
+// RotateCredential 轮换设备凭证
+// 设备使用当前secret对轮换挑战的证明提交新承诺，成功后所有旧会话失效
+func RotateCredential(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CredentialRotationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		resp, err := authService.RotateCredential(&req, c.ClientIP())
+		if err != nil {
+			if respondAuthLocked(c, err) || respondVerifyBusy(c, err) {
+				return
+			}
+			if strings.Contains(err.Error(), "invalid new commitment") ||
+				strings.Contains(err.Error(), "must differ") {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "INVALID_COMMITMENT",
+					"message": "新承诺值无效: " + err.Error(),
+				})
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "ROTATION_FAILED",
+				"message": "凭证轮换失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ForceCredentialRotation 强制设备轮换凭证（Web管理界面）
+func ForceCredentialRotation(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "设备ID不能为空",
+			})
+			return
+		}
+
+		var req models.ForceRotationRequest
+		// 请求体可选
+		_ = c.ShouldBindJSON(&req)
+
+		count, err := authService.ForceRotation(deviceID, req.Reason, auth.RotationInitiatorAdmin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "FORCE_ROTATION_FAILED",
+				"message": "强制轮换失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "已要求设备轮换凭证",
+			"devices": count,
+		})
+	}
+}
+
+// ListCredentialRotations 查询设备凭证轮换记录
+func ListCredentialRotations(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+		rotations, err := authService.ListCredentialRotations(deviceID, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": "查询轮换记录失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"device_id": deviceID,
+			"rotations": rotations,
+			"total":     len(rotations),
+		})
+	}
+}
+
 // GetDeviceStatistics 获取设备统计信息
 func GetDeviceStatistics(deviceManager *device.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -486,6 +897,15 @@ func CollectData(dataCollector *collector.Service) gin.HandlerFunc {
 			return
 		}
 
+		// 数据所属设备必须与认证身份（会话令牌或设备证书）一致
+		if authDeviceID := c.GetString("device_id"); authDeviceID != "" && req.DeviceID != authDeviceID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "DEVICE_MISMATCH",
+				"message": "数据设备ID与认证设备不一致",
+			})
+			return
+		}
+
 		// 验证数据范围
 		if err := validateSensorData(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -2141,8 +2561,8 @@ func TriggerAssessment(vulnService interface{}) gin.HandlerFunc {
 	}
 }
 
-// DismissVulnerability 消除指定漏洞
-func DismissVulnerability(vulnService interface{}) gin.HandlerFunc {
+// GetVulnerabilityScorers 获取已注册评分器的权重及启用状态
+func GetVulnerabilityScorers(vulnService interface{}) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if vulnService == nil {
 			c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -2161,11 +2581,396 @@ func DismissVulnerability(vulnService interface{}) gin.HandlerFunc {
 			return
 		}
 
-		// 解析请求
-		var req struct {
-			VulnerabilityType string `json:"vulnerability_type" binding:"required"`
-			Reason            string `json:"reason"`
-		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    service.Scorers(),
+		})
+	}
+}
+
+// GetFirmwareVulnerabilities 获取固件漏洞目录及当前受影响的设备
+func GetFirmwareVulnerabilities(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if vulnService == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "脆弱性评估服务未启用",
+			})
+			return
+		}
+
+		service, ok := vulnService.(*vulnerability.Service)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "INTERNAL_ERROR",
+				"message": "服务类型错误",
+			})
+			return
+		}
+
+		catalog := service.GetFirmwareCatalog()
+		if catalog == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "固件漏洞目录未启用",
+			})
+			return
+		}
+
+		// 最近一次评估中的固件漏洞
+		affected := []models.VulnerabilityEvent{}
+		if report := service.GetCurrentAssessment(); report != nil {
+			for _, vuln := range report.DetectedVulnerabilities {
+				if vuln.Category == "firmware" {
+					affected = append(affected, vuln)
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"catalog":  catalog.Info(),
+				"affected": affected,
+			},
+		})
+	}
+}
+
+// GetTLSCertificates 获取TLS证书及连接状态，refresh=true时立即重新检查
+func GetTLSCertificates(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, ok := vulnService.(*vulnerability.Service)
+		if !ok || service == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "脆弱性评估服务未启用",
+			})
+			return
+		}
+
+		scorer := service.GetTLSScorer()
+		if scorer == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "TLS安全态势检查未启用",
+			})
+			return
+		}
+
+		if c.Query("refresh") == "true" {
+			scorer.Refresh()
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    scorer.Certificates(),
+		})
+	}
+}
+
+// GetListeningPorts 获取本机实际监听的端口、所属进程及与白名单比对的结论
+func GetListeningPorts(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		service, ok := vulnService.(*vulnerability.Service)
+		if !ok || service == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "脆弱性评估服务未启用",
+			})
+			return
+		}
+
+		auditor := service.GetPortAuditor()
+		if auditor == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "监听端口自查未启用",
+			})
+			return
+		}
+
+		listeners, err := auditor.Audit()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    listeners,
+		})
+	}
+}
+
+// GetConfigBaseline 获取当前配置基线及漂移检测结果
+func GetConfigBaseline(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		monitor, ok := driftMonitorFrom(c, vulnService)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"baseline": monitor.Baseline(),
+				"drift":    monitor.Check(),
+			},
+		})
+	}
+}
+
+// ApproveConfigBaseline 以当前配置建立新基线（本地授权变更后由运维群组批准）
+// 批准后漂移事件在下次评估时消除，操作人记录为会话群组
+func ApproveConfigBaseline(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		monitor, ok := driftMonitorFrom(c, vulnService)
+		if !ok {
+			return
+		}
+
+		var req struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "INVALID_REQUEST",
+				"message": "需要说明变更原因",
+			})
+			return
+		}
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		baseline, err := monitor.Approve(operator, req.Reason)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "APPROVE_FAILED",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    baseline,
+		})
+	}
+}
+
+// driftMonitorFrom 获取配置漂移监测器，未启用时写入错误响应
+func driftMonitorFrom(c *gin.Context, vulnService interface{}) (*vulnerability.DriftMonitor, bool) {
+	service, ok := vulnService.(*vulnerability.Service)
+	if !ok || service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "SERVICE_UNAVAILABLE",
+			"message": "脆弱性评估服务未启用",
+		})
+		return nil, false
+	}
+
+	monitor := service.GetDriftMonitor()
+	if monitor == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "SERVICE_UNAVAILABLE",
+			"message": "配置漂移检测未启用",
+		})
+		return nil, false
+	}
+	return monitor, true
+}
+
+// ListRemediations 查询漏洞处置记录，可按status过滤
+func ListRemediations(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		engine, ok := remediationEngineFrom(c, vulnService)
+		if !ok {
+			return
+		}
+
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		records, err := engine.List(c.Query("status"), time.Time{}, limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "QUERY_FAILED",
+				"message": "查询处置记录失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    records,
+		})
+	}
+}
+
+// ConfirmRemediation 确认并执行待处置记录
+func ConfirmRemediation(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		engine, ok := remediationEngineFrom(c, vulnService)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "INVALID_REQUEST",
+				"message": "处置记录ID无效",
+			})
+			return
+		}
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		record, err := engine.Confirm(id, operator)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "CONFIRM_FAILED",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    record,
+		})
+	}
+}
+
+// RejectRemediation 拒绝待处置记录
+func RejectRemediation(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		engine, ok := remediationEngineFrom(c, vulnService)
+		if !ok {
+			return
+		}
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "INVALID_REQUEST",
+				"message": "处置记录ID无效",
+			})
+			return
+		}
+
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error":   "INVALID_REQUEST",
+					"message": "请求参数错误: " + err.Error(),
+				})
+				return
+			}
+		}
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		record, err := engine.Reject(id, operator, req.Reason)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "REJECT_FAILED",
+				"message": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    record,
+		})
+	}
+}
+
+// groupOperator 从群组会话认证信息获取运维操作人，未认证时写入错误响应
+func groupOperator(c *gin.Context) (string, bool) {
+	groupID := c.GetString("group_id")
+	if groupID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "UNAUTHORIZED",
+			"message": "未找到群组认证信息",
+		})
+		return "", false
+	}
+	operator := "group:" + groupID
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		operator += "/" + sessionID
+	}
+	return operator, true
+}
+
+// remediationEngineFrom 获取漏洞处置引擎，未启用时写入错误响应
+func remediationEngineFrom(c *gin.Context, vulnService interface{}) (*vulnerability.RemediationEngine, bool) {
+	service, ok := vulnService.(*vulnerability.Service)
+	if !ok || service == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "SERVICE_UNAVAILABLE",
+			"message": "脆弱性评估服务未启用",
+		})
+		return nil, false
+	}
+
+	engine := service.GetRemediationEngine()
+	if engine == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "SERVICE_UNAVAILABLE",
+			"message": "漏洞处置剧本未启用",
+		})
+		return nil, false
+	}
+	return engine, true
+}
+
+// DismissVulnerability 消除指定漏洞
+func DismissVulnerability(vulnService interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if vulnService == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "SERVICE_UNAVAILABLE",
+				"message": "脆弱性评估服务未启用",
+			})
+			return
+		}
+
+		service, ok := vulnService.(*vulnerability.Service)
+		if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "INTERNAL_ERROR",
+				"message": "服务类型错误",
+			})
+			return
+		}
+
+		// 解析请求
+		var req struct {
+			VulnerabilityType string `json:"vulnerability_type" binding:"required"`
+			Reason            string `json:"reason"`
+		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -2315,3 +3120,419 @@ func SaveCabinetInfo(cfg interface {
 		c.JSON(http.StatusOK, response)
 	}
 }
+
+// certErrorResponse 将Edge CA错误码映射为HTTP状态码
+func certErrorResponse(c *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "CERT_FAILED"
+	for prefix, s := range map[string]int{
+		"CSR_INVALID":       http.StatusBadRequest,
+		"DEVICE_NOT_FOUND":  http.StatusNotFound,
+		"DEVICE_DISABLED":   http.StatusForbidden,
+		"CERT_NOT_FOUND":    http.StatusNotFound,
+		"CERT_INVALID":      http.StatusForbidden,
+		"CERT_REVOKED":      http.StatusForbidden,
+		"CERT_EXPIRED":      http.StatusForbidden,
+		"REQUEST_NOT_FOUND": http.StatusNotFound,
+		"REQUEST_CLOSED":    http.StatusConflict,
+		"REQUEST_LIMIT":     http.StatusTooManyRequests,
+	} {
+		if strings.HasPrefix(err.Error(), prefix) {
+			status, code = s, prefix
+			break
+		}
+	}
+	c.JSON(status, gin.H{
+		"error":   code,
+		"message": err.Error(),
+	})
+}
+
+// GetCACertificate 下载Edge CA证书（设备用于校验Edge服务端证书）
+func GetCACertificate(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/x-pem-file", ca.CertificatePEM())
+	}
+}
+
+// EnrollDeviceCertificate 设备完成ZKP认证后自助申请证书
+func EnrollDeviceCertificate(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if c.GetString("auth_method") != "zkp" || c.GetString("device_id") != deviceID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "AUTH_005",
+				"message": "证书签发需使用该设备的ZKP会话令牌",
+			})
+			return
+		}
+
+		var req models.CertificateSigningRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		cert, err := ca.Issue(deviceID, req.CSR, models.CertIssuedViaZKP)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, cert)
+	}
+}
+
+// RenewDeviceCertificate 设备使用当前证书（mTLS）续期
+func RenewDeviceCertificate(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		serial := c.GetString("cert_serial")
+		if serial == "" || c.GetString("device_id") != deviceID {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "AUTH_005",
+				"message": "证书续期需使用该设备当前有效的证书连接",
+			})
+			return
+		}
+
+		var req models.CertificateSigningRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		cert, err := ca.Renew(deviceID, serial, req.CSR)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, cert)
+	}
+}
+
+// SubmitCertificateRequest 提交证书申请，等待运维审批（无需认证，按设备和来源IP限制申请次数）
+func SubmitCertificateRequest(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CertificateSigningRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		certReq, err := ca.SubmitRequest(c.Param("id"), req.CSR, c.ClientIP())
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, certReq)
+	}
+}
+
+// GetCertificateRequest 查询证书申请状态，审批通过后返回证书
+func GetCertificateRequest(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		certReq, err := ca.GetRequest(c.Param("id"), c.Param("request_id"))
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, certReq)
+	}
+}
+
+// ListCertificateRequests 查询证书申请（Web管理界面）
+func ListCertificateRequests(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requests, err := ca.ListRequests(c.Query("status"))
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"requests": requests,
+			"total":    len(requests),
+		})
+	}
+}
+
+// ApproveCertificateRequest 审批通过证书申请（需运维群组会话认证）
+func ApproveCertificateRequest(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		certReq, err := ca.ApproveRequest(c.Param("request_id"), operator)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, certReq)
+	}
+}
+
+// RejectCertificateRequest 拒绝证书申请（需运维群组会话认证）
+func RejectCertificateRequest(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CertificateReviewRequest
+		// 请求体可选
+		_ = c.ShouldBindJSON(&req)
+
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+
+		certReq, err := ca.RejectRequest(c.Param("request_id"), operator, req.Reason)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, certReq)
+	}
+}
+
+// ListDeviceCertificates 查询设备证书（Web管理界面）
+func ListDeviceCertificates(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		activeOnly := c.DefaultQuery("active", "false") == "true"
+
+		certs, err := ca.List(deviceID, activeOnly)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"device_id":    deviceID,
+			"certificates": certs,
+			"total":        len(certs),
+		})
+	}
+}
+
+// RevokeDeviceCertificate 撤销设备的单个证书（Web管理界面）
+func RevokeDeviceCertificate(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.CertificateRevokeRequest
+		_ = c.ShouldBindJSON(&req)
+		if req.Reason == "" {
+			req.Reason = pki.RevokeReasonAdmin
+		}
+
+		deviceID := c.Param("id")
+		serial := c.Param("serial")
+		if err := ca.Revoke(deviceID, serial, req.Reason); err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":       "设备证书已撤销",
+			"device_id":     deviceID,
+			"serial_number": serial,
+		})
+	}
+}
+
+// RevokeAllDeviceCertificates 撤销设备的全部证书（Web管理界面）
+func RevokeAllDeviceCertificates(ca *pki.CA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		revoked, err := ca.RevokeDevice(deviceID, pki.RevokeReasonAdmin)
+		if err != nil {
+			certErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "设备证书已撤销",
+			"device_id": deviceID,
+			"revoked":   revoked,
+		})
+	}
+}
+
+// groupErrorResponse 将群组相关错误映射为HTTP响应
+func groupErrorResponse(c *gin.Context, err error, fallback string) {
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "GROUP_NOT_FOUND"), strings.HasPrefix(msg, "MEMBER_NOT_FOUND"):
+		c.JSON(http.StatusNotFound, gin.H{"error": strings.SplitN(msg, ":", 2)[0], "message": msg})
+	case strings.HasPrefix(msg, "GROUP_EXISTS"), strings.HasPrefix(msg, "MEMBER_EXISTS"), strings.HasPrefix(msg, "GROUP_FULL"):
+		c.JSON(http.StatusConflict, gin.H{"error": strings.SplitN(msg, ":", 2)[0], "message": msg})
+	case strings.HasPrefix(msg, "GROUP_INVALID"), strings.HasPrefix(msg, "MEMBER_INVALID"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": strings.SplitN(msg, ":", 2)[0], "message": msg})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback, "message": msg})
+	}
+}
+
+// ListAuthGroups 查询授权群组列表（Web管理界面）
+func ListAuthGroups(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groups, err := authService.ListGroups()
+		if err != nil {
+			groupErrorResponse(c, err, "QUERY_FAILED")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"groups": groups,
+			"total":  len(groups),
+		})
+	}
+}
+
+// GetAuthGroup 查询授权群组（含当前树根）
+func GetAuthGroup(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, err := authService.GetGroup(c.Param("group_id"))
+		if err != nil {
+			groupErrorResponse(c, err, "QUERY_FAILED")
+			return
+		}
+		c.JSON(http.StatusOK, group)
+	}
+}
+
+// ListAuthGroupMembers 查询群组成员承诺（按叶子顺序）
+// 成员据此在本地计算Merkle路径，不需要向Edge透露自己的位置
+func ListAuthGroupMembers(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupID := c.Param("group_id")
+		group, err := authService.GetGroup(groupID)
+		if err != nil {
+			groupErrorResponse(c, err, "QUERY_FAILED")
+			return
+		}
+		members, err := authService.ListGroupMembers(groupID)
+		if err != nil {
+			groupErrorResponse(c, err, "QUERY_FAILED")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"group_id": groupID,
+			"root":     group.Root,
+			"members":  members,
+			"total":    len(members),
+		})
+	}
+}
+
+// RevokeAuthGroupSessions 撤销群组的所有会话（需运维群组认证）
+func RevokeAuthGroupSessions(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operator, ok := groupOperator(c)
+		if !ok {
+			return
+		}
+		groupID := c.Param("group_id")
+		revoked, err := authService.RevokeGroupSessions(groupID, operator)
+		if err != nil {
+			groupErrorResponse(c, err, "REVOKE_FAILED")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "群组会话已撤销",
+			"group_id": groupID,
+			"revoked":  revoked,
+		})
+	}
+}
+
+// GetGroupChallenge 获取群组认证挑战
+func GetGroupChallenge(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		challenge, err := authService.GenerateGroupChallenge(c.Param("group_id"), c.ClientIP())
+		if err != nil {
+			if respondAuthLocked(c, err) {
+				return
+			}
+			msg := err.Error()
+			switch {
+			case strings.Contains(msg, "MEMBERSHIP_DISABLED"):
+				c.JSON(http.StatusNotImplemented, gin.H{
+					"error":   "MEMBERSHIP_DISABLED",
+					"message": "未加载群组成员电路verifying key",
+				})
+			case strings.Contains(msg, "CHALLENGE_LIMIT"):
+				c.JSON(http.StatusTooManyRequests, gin.H{
+					"error":   "CHALLENGE_LIMIT",
+					"message": "未使用的挑战过多，请使用已有挑战或等待其过期",
+				})
+			case strings.Contains(msg, "LICENSE_001"):
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":   "LICENSE_001",
+					"message": "许可证校验失败: " + msg,
+				})
+			case strings.HasPrefix(msg, "GROUP_EMPTY"):
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "GROUP_EMPTY",
+					"message": "群组没有成员",
+				})
+			default:
+				groupErrorResponse(c, err, "CHALLENGE_FAILED")
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, challenge)
+	}
+}
+
+// VerifyGroupProof 验证群组成员证明，成功后签发群组会话
+func VerifyGroupProof(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.GroupAuthRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "INVALID_REQUEST",
+				"message": "请求参数错误: " + err.Error(),
+			})
+			return
+		}
+
+		session, err := authService.VerifyGroupProof(c.Param("group_id"), &req, c.ClientIP())
+		if err != nil {
+			if respondAuthLocked(c, err) {
+				return
+			}
+			if strings.Contains(err.Error(), "MEMBERSHIP_DISABLED") {
+				c.JSON(http.StatusNotImplemented, gin.H{
+					"error":   "MEMBERSHIP_DISABLED",
+					"message": "未加载群组成员电路verifying key",
+				})
+				return
+			}
+			// 成员变更导致树根变化，重新获取成员列表后再生成证明
+			if strings.Contains(err.Error(), "ROOT_MISMATCH") {
+				c.JSON(http.StatusConflict, gin.H{
+					"error":   "ROOT_MISMATCH",
+					"message": "群组成员已变更，请重新获取成员列表",
+				})
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "AUTH_FAILED",
+				"message": "认证失败: " + err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":    true,
+			"group_id":   session.GroupID,
+			"session_id": session.SessionID,
+			"token":      session.Token,
+			"expires_at": session.ExpiresAt,
+			"message":    "认证成功",
+		})
+	}
+}
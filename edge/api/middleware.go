@@ -6,6 +6,7 @@ package api
 
 import (
 	"crypto/rand"
+	"crypto/x509"
 	"net/http"
 	"strings"
 	"sync"
@@ -55,9 +56,39 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// CertificateVerifier 设备证书校验接口（pki.CA实现）
+type CertificateVerifier interface {
+	VerifyDevice(cert *x509.Certificate) (deviceID, serial string, err error)
+}
+
 // AuthMiddleware 认证中间件
 func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
+	return AuthMiddlewareWithCerts(authService, nil)
+}
+
+// AuthMiddlewareWithCerts 认证中间件，同时接受Edge CA签发的设备证书（mTLS）
+// 未携带令牌且TLS握手中提供了已验证的客户端证书时，按证书主体映射到设备
+func AuthMiddlewareWithCerts(authService *auth.Service, certs CertificateVerifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if certs != nil && c.GetHeader("Authorization") == "" &&
+			c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+			deviceID, serial, err := certs.VerifyDevice(c.Request.TLS.VerifiedChains[0][0])
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":   "AUTH_004",
+					"message": "设备证书无效或已撤销: " + err.Error(),
+				})
+				c.Abort()
+				return
+			}
+
+			c.Set("device_id", deviceID)
+			c.Set("cert_serial", serial)
+			c.Set("auth_method", "mtls")
+			c.Next()
+			return
+		}
+
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -85,6 +116,15 @@ func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 		// 验证令牌
 		session, err := authService.ValidateToken(token)
 		if err != nil {
+			// 会话已被撤销（撤销后立即生效，不等待JWT过期）
+			if strings.Contains(err.Error(), "SESSION_REVOKED") {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":   "AUTH_003",
+					"message": "会话已被撤销，请重新认证",
+				})
+				c.Abort()
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error":   "AUTH_002",
 				"message": "认证令牌无效或已过期",
@@ -96,7 +136,47 @@ func AuthMiddleware(authService *auth.Service) gin.HandlerFunc {
 		// 将会话信息存储到上下文
 		c.Set("session", session)
 		c.Set("device_id", session.DeviceID)
+		c.Set("auth_method", "zkp")
+
+		c.Next()
+	}
+}
+
+// GroupAuthMiddleware 群组会话认证中间件
+// 群组令牌只标识群组，不含任何成员信息，权限由后续的群组ABAC中间件决定
+func GroupAuthMiddleware(authService *auth.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := extractTokenFromHeader(c.GetHeader("Authorization"))
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "AUTH_001",
+				"message": "缺少认证令牌",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := authService.ValidateGroupToken(token)
+		if err != nil {
+			if strings.Contains(err.Error(), "SESSION_REVOKED") {
+				c.JSON(http.StatusUnauthorized, gin.H{
+					"error":   "AUTH_003",
+					"message": "群组会话已被撤销，请重新认证",
+				})
+				c.Abort()
+				return
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":   "AUTH_002",
+				"message": "群组令牌无效或已过期",
+			})
+			c.Abort()
+			return
+		}
 
+		c.Set("group_id", claims.GroupID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("auth_method", "zkp_membership")
 		c.Next()
 	}
 }
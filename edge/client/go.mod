@@ -1,8 +0,0 @@
-module edge-client
-
-go 1.24
-
-require (
-	github.com/consensys/gnark v0.14.0
-	github.com/consensys/gnark-crypto v0.19.0
-)
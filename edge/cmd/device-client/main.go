@@ -0,0 +1,93 @@
+/*
+ * 设备客户端示例
+ * 基于 pkg/sdk 完成ZKP认证、数据上传和心跳，供网关集成时参考
+ */
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"github.com/edge/storage-cabinet/pkg/sdk"
+	"go.uber.org/zap"
+)
+
+func main() {
+	credsPath := flag.String("credentials", "device_credentials.json", "设备凭据文件")
+	pkPath := flag.String("proving-key", "auth_proving.key", "proving key文件")
+	keyID := flag.String("key-id", zkp.LegacyKeyID, "proving key对应的verifying key ID")
+	serverURL := flag.String("server", "http://localhost:8080", "Edge地址")
+	bufferPath := flag.String("buffer", "", "离线缓存文件（为空时使用内存缓存）")
+	interval := flag.Duration("interval", 10*time.Second, "数据上传间隔")
+	flag.Parse()
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	creds, err := sdk.LoadCredentials(*credsPath)
+	if err != nil {
+		logger.Fatal("Failed to load credentials", zap.Error(err))
+	}
+
+	prover := sdk.NewProver()
+	if err := prover.LoadProvingKey(*keyID, *pkPath); err != nil {
+		logger.Fatal("Failed to load proving key", zap.Error(err))
+	}
+
+	var buffer sdk.Buffer = sdk.NewMemoryBuffer(10000)
+	if *bufferPath != "" {
+		if buffer, err = sdk.NewFileBuffer(*bufferPath, 10000); err != nil {
+			logger.Fatal("Failed to open buffer file", zap.Error(err))
+		}
+	}
+
+	client, err := sdk.NewClient(sdk.Config{
+		ServerURL:   *serverURL,
+		Credentials: creds,
+		Prover:      prover,
+		Buffer:      buffer,
+		Logger:      logger,
+	})
+	if err != nil {
+		logger.Fatal("Failed to create client", zap.Error(err))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	session, err := client.Authenticate(ctx)
+	if err != nil {
+		logger.Fatal("Authentication failed", zap.Error(err))
+	}
+	logger.Info("Authenticated",
+		zap.String("session_id", session.SessionID),
+		zap.Time("expires_at", session.ExpiresAt))
+
+	go client.Run(ctx)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 示例数据，实际接入时替换为传感器读数
+			err := client.Upload(ctx, &models.DataCollectRequest{
+				SensorType: models.SensorCO2,
+				Value:      420.5,
+				Unit:       "ppm",
+				Quality:    95,
+			})
+			if err != nil {
+				logger.Warn("Upload failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -8,9 +8,11 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/edge/storage-cabinet/internal/device"
 	"github.com/edge/storage-cabinet/internal/license"
 	"github.com/edge/storage-cabinet/internal/mqtt"
+	"github.com/edge/storage-cabinet/internal/pki"
 	"github.com/edge/storage-cabinet/internal/storage"
 	"github.com/edge/storage-cabinet/internal/sync"
 	"github.com/edge/storage-cabinet/internal/vulnerability"
@@ -98,6 +101,23 @@ func main() {
 	if err := zkpVerifier.InitializeWithKeyPath(vkPath); err != nil {
 		logger.Fatal("初始化ZKP验证器失败", zap.Error(err))
 	}
+	// 加载额外的verifying key（迁移窗口内新旧key同时生效）
+	for _, key := range cfg.Auth.ZKP.VerifyingKeys {
+		if err := zkpVerifier.LoadKey(key.ID, key.Path); err != nil {
+			logger.Fatal("加载verifying key失败", zap.String("key_id", key.ID), zap.Error(err))
+		}
+	}
+	if cfg.Auth.ZKP.DefaultKeyID != "" {
+		if err := zkpVerifier.SetDefaultKey(cfg.Auth.ZKP.DefaultKeyID); err != nil {
+			logger.Fatal("设置默认verifying key失败", zap.Error(err))
+		}
+	}
+	// 群组成员证明（共享诊断工具等匿名证明属于授权群组）
+	if cfg.Auth.ZKP.MembershipKeyPath != "" {
+		if err := zkpVerifier.LoadMembershipKey(cfg.Auth.ZKP.MembershipKeyPath); err != nil {
+			logger.Fatal("加载群组成员verifying key失败", zap.Error(err))
+		}
+	}
 
 	// 【SPA单包授权】初始化许可证服务
 	var licenseService *license.Service
@@ -138,7 +158,14 @@ func main() {
 
 	// 初始化服务（传入许可证服务）
 	authService := auth.NewService(cfg.Auth, db, zkpVerifier, licenseService, logger)
+	defer authService.Close()
 	deviceManager := device.NewManager(cfg.Device, db, licenseService, logger, cfg.Cloud.CabinetID)
+	authService.SetCredentialSync(deviceManager)
+	deviceManager.SetDefaultCircuitVersion(zkpVerifier.DefaultKeyID())
+	// 卸载已无设备使用的旧key
+	if retired := authService.RetireUnusedKeys(); len(retired) > 0 {
+		logger.Info("已卸载无设备使用的verifying key", zap.Strings("key_ids", retired))
+	}
 	dataCollector := collector.NewService(cfg.Data, cfg.Alert, db, deviceManager, logger)
 	// 使用配置文件中的sync_interval，避免过于频繁的同步
 	// 传递db以便从数据库读取API凭证
@@ -154,18 +181,117 @@ func main() {
 	// 注入CloudSync到脆弱性评估服务(用于同步评估结果到Cloud端)
 	vulnService.SetCloudSync(cloudSync)
 
+	// 固件漏洞目录：Cloud下发已知漏洞固件版本范围，评估时与设备清单匹配
+	firmwareCatalog, err := vulnerability.NewFirmwareCatalog(db.GetDB(), cfg.Cloud.CabinetID, logger)
+	if err != nil {
+		logger.Warn("加载固件漏洞目录失败", zap.Error(err))
+	} else {
+		vulnService.SetFirmwareCatalog(firmwareCatalog)
+	}
+
+	// 配置漂移检测：对关键配置建立签名基线，评估时比对摘要
+	var driftMonitor *vulnerability.DriftMonitor
+	if cfg.Vulnerability.Drift.Enabled {
+		driftMonitor, err = vulnerability.NewDriftMonitor(db.GetDB(), buildDriftConfig(cfg, *configFile), logger)
+		if err != nil {
+			logger.Warn("初始化配置漂移检测失败", zap.Error(err))
+		} else {
+			vulnService.SetDriftMonitor(driftMonitor)
+		}
+	}
+
+	// TLS安全态势检查：握手检查证书有效期、密钥、证书链及协议版本
+	if cfg.Vulnerability.TLS.Enabled {
+		vulnService.SetTLSScorer(vulnerability.NewTLSScorer(buildTLSPostureConfig(cfg), logger))
+	}
+
+	// 监听端口自查：枚举/proc/net中实际监听的端口，与配置推导的白名单比对
+	if cfg.Vulnerability.PortAudit.Enabled {
+		vulnService.SetPortAuditor(vulnerability.NewPortAuditor(buildPortAuditConfig(cfg), logger))
+	}
+
+	// 漏洞处置剧本：按事件类型自动执行或等待确认的处置动作
+	var remediationEngine *vulnerability.RemediationEngine
+	if cfg.Vulnerability.Remediation.Enabled {
+		remediationEngine = vulnerability.NewRemediationEngine(db.GetDB(), buildPlaybooks(cfg), logger)
+		remediationEngine.RegisterAction(vulnerability.NewBlockIPAction(authService))
+		remediationEngine.RegisterAction(vulnerability.NewDisableDeviceAction(authService, deviceManager))
+		remediationEngine.RegisterAction(vulnerability.NewCleanupStorageAction(db))
+		remediationEngine.RegisterAction(vulnerability.NewRotateLogsAction(cfg.Vulnerability.Remediation.LogFiles))
+		remediationEngine.RegisterAction(vulnerability.NewRefreshLicenseAction(cloudSync))
+		vulnService.SetRemediationEngine(remediationEngine)
+	}
+
+	// 传感器数据在线异常检测：学习各传感器读数及上报间隔的EWMA基线，超出控制限时告警并计入评估
+	if cfg.Data.Anomaly.Enabled {
+		anomalyDetector, err := collector.NewAnomalyDetector(db, cfg.Data.Anomaly, logger)
+		if err != nil {
+			logger.Warn("初始化传感器异常检测失败", zap.Error(err))
+		} else {
+			dataCollector.SetAnomalyDetector(anomalyDetector)
+			vulnService.SetSensorAnomalySource(anomalyDetector)
+		}
+	}
+
 	// 【ABAC设备权限管理】初始化
 	abacRepo, err := abac.NewSQLiteRepository(db.GetDB())
 	if err != nil {
 		logger.Warn("初始化ABAC存储失败", zap.Error(err))
 	} else {
 		logger.Info("ABAC设备权限管理已初始化")
+		// 认证锁定事件写入设备访问日志
+		authService.SetAccessLogger(abacRepo)
 	}
+	// ABAC上下文属性：最新风险等级、未解决告警数及许可证状态（缓存10秒）
+	abacContext := abac.NewCachedContextProvider(abac.ContextProviderFunc(func(ctx context.Context) (*abac.CabinetState, error) {
+		state := &abac.CabinetState{}
+		if licenseService != nil {
+			state.LicenseStatus = licenseService.Status()
+		}
+		if report := vulnService.GetCurrentAssessment(); report != nil {
+			state.RiskLevel = report.RiskLevel
+			state.VulnerabilityScore = report.OverallScore
+		}
+		active, critical, err := db.CountActiveAlerts()
+		if err != nil {
+			return nil, err
+		}
+		state.ActiveAlerts, state.CriticalAlerts = active, critical
+		return state, nil
+	}), 10*time.Second)
+
+	// 设备动态信任度：已认证连接上的冒用与访问拒绝、数据质量异常、心跳缺失及固件版本驱动衰减与恢复
+	var trustTracker *abac.TrustTracker
+	if abacRepo != nil {
+		trustTracker, err = abac.NewTrustTracker(abacRepo, cfg.Cloud.CabinetID, abac.TrustConfig{
+			HalfLife:           cfg.ABAC.TrustHalfLife,
+			MinFirmwareVersion: cfg.ABAC.MinFirmwareVersion,
+		})
+		if err != nil {
+			logger.Warn("初始化设备信任度失败", zap.Error(err))
+			trustTracker = nil
+		} else {
+			trustTracker.SetFirmwareSource(deviceManager)
+			deviceManager.SetTrustObserver(trustTracker)
+			dataCollector.SetTrustObserver(trustTracker)
+		}
+	}
+
 	var abacMQTTHandler *abac.MQTTHandler
 	if abacRepo != nil && cfg.Cloud.CabinetID != "" {
 		abacMQTTHandler = abac.NewMQTTHandler(abacRepo, cfg.Cloud.CabinetID)
 	}
 
+	// 【Edge CA】为无法运行ZKP证明的设备签发客户端证书
+	var edgeCA *pki.CA
+	if cfg.PKI.Enabled {
+		edgeCA, err = pki.NewCA(cfg.PKI, db, deviceManager, logger)
+		if err != nil {
+			logger.Fatal("初始化Edge CA失败", zap.Error(err))
+		}
+		logger.Info("Edge CA已初始化", zap.Duration("cert_ttl", cfg.PKI.CertTTL))
+	}
+
 	// 启动后台服务
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -185,6 +311,18 @@ func main() {
 		logger.Fatal("启动脆弱性评估服务失败", zap.Error(err))
 	}
 
+	// MQTT Topic授权：内嵌broker按连接身份评估（enforce模式拒绝），外部broker的消息只评估记录
+	var topicAuthorizer *abac.TopicAuthorizer
+	if abacRepo != nil && cfg.ABAC.Enabled {
+		topicAuthorizer = abac.NewTopicAuthorizer(abacRepo)
+		defer topicAuthorizer.Close()
+		topicAuthorizer.SetMode(cfg.ABAC.MQTTMode)
+		topicAuthorizer.SetContextProvider(abacContext)
+		if trustTracker != nil {
+			topicAuthorizer.SetTrustTracker(trustTracker)
+		}
+	}
+
 	// 启动 MQTT 订阅器（新增）
 	var mqttSubscriber *mqtt.Subscriber
 	var trafficPublisher *mqtt.TrafficPublisher
@@ -209,6 +347,37 @@ func main() {
 			mqttSubscriber.SetABACHandler(abacMQTTHandler)
 		}
 
+		if topicAuthorizer != nil {
+			mqttSubscriber.SetAuthorizer(topicAuthorizer, deviceManager)
+		}
+
+		// 注入固件漏洞目录处理器
+		if firmwareCatalog != nil {
+			mqttSubscriber.SetFirmwareCatalogHandler(firmwareCatalog)
+		}
+
+		// 注入配置基线批准服务（Cloud远程批准新基线）
+		if remediationEngine != nil {
+			mqttSubscriber.SetRemediationConfirmer(remediationEngine)
+		}
+		if driftMonitor != nil {
+			mqttSubscriber.SetBaselineApprover(driftMonitor)
+		}
+
+		// 注入凭证轮换和会话撤销服务（处理Cloud下发的命令）
+		mqttSubscriber.SetCredentialRotator(authService)
+		mqttSubscriber.SetSessionRevoker(authService)
+
+		// 注入授权群组同步服务（群组和成员由Cloud下发）
+		if authService.MembershipEnabled() {
+			mqttSubscriber.SetGroupSyncer(authService)
+		}
+
+		// 注入证书申请审批服务（Cloud远程审批设备证书申请）
+		if edgeCA != nil {
+			mqttSubscriber.SetCertificateReviewer(edgeCA)
+		}
+
 		if err := mqttSubscriber.Start(ctx); err != nil {
 			logger.Fatal("启动 MQTT 订阅器失败", zap.Error(err))
 		}
@@ -248,15 +417,60 @@ func main() {
 				return token.Error()
 			}
 
-			// 设置ABAC MQTT Handler的发布函数(用于发送ACK)
+			// 设置ABAC MQTT Handler的发布函数(用于发送ACK和策略摘要心跳)
 			if abacMQTTHandler != nil {
 				abacMQTTHandler.SetPublishFunc(publishFunc)
+				go abacMQTTHandler.StartHeartbeat(ctx, time.Minute)
+				logger.Info("ABAC策略摘要心跳已启动", zap.Duration("interval", time.Minute))
 			}
 
 			// 启动ABAC日志同步服务
 			logSyncService := abac.NewLogSyncService(abacRepo, cfg.Cloud.CabinetID, publishFunc)
 			go logSyncService.StartPeriodicSync(ctx, 5*time.Minute)
 			logger.Info("ABAC日志同步服务已启动", zap.Duration("interval", 5*time.Minute))
+
+			// 定期上报设备信任度及其变化事件
+			if trustTracker != nil {
+				trustTracker.SetPublishFunc(publishFunc)
+				go trustTracker.StartPeriodicSync(ctx, 5*time.Minute)
+			}
+		}
+	}
+
+	// 启动内嵌MQTT broker：设备使用ZKP会话令牌直连，消息直接进入本地处理器
+	var mqttBroker *mqtt.Broker
+	var wsHub *mqtt.WebSocketHub
+	if mqttSubscriber != nil {
+		wsHub = mqttSubscriber.GetWebSocketHub()
+	}
+	if cfg.MQTTBroker.Enabled {
+		var handler *mqtt.Handler
+		if mqttSubscriber != nil {
+			handler = mqttSubscriber.GetHandler()
+		} else {
+			// 未连接外部broker时单独创建处理器
+			stats := mqtt.NewMQTTStats()
+			handler = mqtt.NewHandler(logger, dataCollector, deviceManager, stats, licenseService, nil)
+			if topicAuthorizer != nil {
+				handler.SetAuthorizer(topicAuthorizer, deviceManager)
+			}
+			vulnService.SetMQTTStats(stats)
+			wsHub = handler.GetWebSocketHub()
+			go wsHub.Run()
+		}
+
+		mqttBroker = mqtt.NewBroker(cfg.MQTTBroker, handler, authService, deviceManager, logger)
+		if edgeCA != nil {
+			mqttBroker.SetCertificateAuthority(edgeCA, cfg.PKI.ServerHosts)
+		}
+		if topicAuthorizer != nil {
+			mqttBroker.SetAuthorizer(topicAuthorizer)
+		}
+		if trustTracker != nil {
+			mqttBroker.SetTrustObserver(trustTracker)
+		}
+		if err := mqttBroker.Start(); err != nil {
+			logger.Fatal("启动内嵌MQTT broker失败", zap.Error(err))
 		}
 	}
 
@@ -277,7 +491,7 @@ func main() {
 	}
 
 	// 初始化HTTP服务器
-	router := setupRouter(cfg, authService, deviceManager, dataCollector, db, mqttSubscriber, licenseService, vulnService, abacRepo, cloudSync, logger)
+	router := setupRouter(cfg, authService, deviceManager, dataCollector, db, wsHub, licenseService, vulnService, abacRepo, abacContext, trustTracker, topicAuthorizer, cloudSync, edgeCA, logger)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
@@ -298,6 +512,29 @@ func main() {
 		}
 	}()
 
+	// 启动mTLS服务器：与HTTP共用路由，设备可使用Edge CA签发的证书代替会话令牌
+	var mtlsSrv *http.Server
+	if edgeCA != nil && cfg.PKI.MTLSAddress != "" {
+		tlsConfig, err := edgeCA.ServerTLSConfig(cfg.PKI.ServerHosts)
+		if err != nil {
+			logger.Fatal("生成mTLS服务端证书失败", zap.Error(err))
+		}
+		mtlsSrv = &http.Server{
+			Addr:         cfg.PKI.MTLSAddress,
+			Handler:      router,
+			TLSConfig:    tlsConfig,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+			IdleTimeout:  60 * time.Second,
+		}
+		go func() {
+			logger.Info("mTLS服务器启动", zap.String("address", mtlsSrv.Addr))
+			if err := mtlsSrv.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				logger.Fatal("mTLS服务器启动失败", zap.Error(err))
+			}
+		}()
+	}
+
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -313,6 +550,17 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("HTTP服务器关闭失败", zap.Error(err))
 	}
+	if mtlsSrv != nil {
+		if err := mtlsSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("mTLS服务器关闭失败", zap.Error(err))
+		}
+	}
+
+	// 停止内嵌MQTT broker
+	if mqttBroker != nil {
+		mqttBroker.Stop()
+		logger.Info("内嵌MQTT broker已停止")
+	}
 
 	// 停止 MQTT 订阅器
 	if mqttSubscriber != nil {
@@ -367,11 +615,15 @@ func setupRouter(
 	deviceManager *device.Manager,
 	dataCollector *collector.Service,
 	db *storage.SQLiteDB,
-	mqttSubscriber *mqtt.Subscriber,
+	wsHub *mqtt.WebSocketHub,
 	licenseService *license.Service,
 	vulnService *vulnerability.Service,
 	abacRepo abac.Repository,
+	abacContext abac.ContextProvider,
+	trustTracker *abac.TrustTracker,
+	topicAuthorizer *abac.TopicAuthorizer,
 	cloudSync api.CloudSyncInterface,
+	edgeCA *pki.CA,
 	logger *zap.Logger,
 ) *gin.Engine {
 	// 设置Gin模式
@@ -392,24 +644,33 @@ func setupRouter(
 	router.GET("/ready", api.ReadyCheck)
 
 	// WebSocket端点（用于实时数据推送）
-	if mqttSubscriber != nil {
-		wsHub := mqttSubscriber.GetWebSocketHub()
-		if wsHub != nil {
-			router.GET("/ws", func(c *gin.Context) {
-				wsHub.HandleWebSocket(c.Writer, c.Request)
-			})
-			logger.Info("WebSocket端点已注册", zap.String("path", "/ws"))
-		} else {
-			logger.Warn("WebSocket Hub未初始化")
-		}
+	if wsHub != nil {
+		router.GET("/ws", func(c *gin.Context) {
+			wsHub.HandleWebSocket(c.Writer, c.Request)
+		})
+		logger.Info("WebSocket端点已注册", zap.String("path", "/ws"))
 	} else {
-		logger.Warn("MQTT订阅器未初始化，WebSocket端点不可用")
+		logger.Warn("MQTT未启用，WebSocket端点不可用")
 	}
 
 	// 静态文件服务
 	router.Static("/static", "./web")
 	router.StaticFile("/", "./web/index.html")
 
+	// 设备认证：ZKP会话令牌，启用Edge CA时也接受设备证书（mTLS）
+	deviceAuth := api.AuthMiddleware(authService)
+	if edgeCA != nil {
+		deviceAuth = api.AuthMiddlewareWithCerts(authService, edgeCA)
+	}
+
+	// 运维操作认证：群组会话 + subject_type=group 的ABAC策略，未启用群组认证时不注册运维操作接口
+	var operatorAuth []gin.HandlerFunc
+	if abacRepo != nil && authService.MembershipEnabled() {
+		groupABAC := abac.NewGroupABACMiddleware(abacRepo, cfg.Cloud.CabinetID)
+		groupABAC.SetContextProvider(abacContext)
+		operatorAuth = []gin.HandlerFunc{api.GroupAuthMiddleware(authService), groupABAC.Handle()}
+	}
+
 	// API路由
 	v1 := router.Group("/api/v1")
 	{
@@ -419,6 +680,26 @@ func setupRouter(
 			authGroup.POST("/challenge", api.GetChallenge(authService))
 			authGroup.POST("/verify", api.VerifyProof(authService))
 			authGroup.POST("/refresh", api.RefreshSession(authService))
+			authGroup.POST("/rotate/challenge", api.GetRotationChallenge(authService))
+			authGroup.POST("/rotate", api.RotateCredential(authService))
+
+			// 认证锁定查询（无需认证，用于Web管理界面）
+			authGroup.GET("/lockouts", api.ListAuthLockouts(authService))
+
+			// verifying key查询（无需认证，用于Web管理界面）
+			authGroup.GET("/keys", api.ListVerifyingKeys(authService))
+
+			// 证明验证统计（无需认证，用于Web管理界面）
+			authGroup.GET("/metrics", api.GetVerificationStats(authService))
+
+			// 群组成员认证：成员列表公开，成员在本地计算Merkle路径后提交证明
+			authGroup.GET("/groups/:group_id", api.GetAuthGroup(authService))
+			authGroup.GET("/groups/:group_id/members", api.ListAuthGroupMembers(authService))
+			authGroup.POST("/groups/:group_id/challenge", api.GetGroupChallenge(authService))
+			authGroup.POST("/groups/:group_id/verify", api.VerifyGroupProof(authService))
+
+			// 授权群组查询（无需认证，用于Web管理界面），群组和成员由Cloud命令同步
+			authGroup.GET("/groups", api.ListAuthGroups(authService))
 		}
 
 		// 设备管理（无需认证，用于Web管理界面）
@@ -432,6 +713,38 @@ func setupRouter(
 			deviceGroup.PUT("/:id", api.UpdateDevice(deviceManager))
 			deviceGroup.DELETE("/:id", api.UnregisterDevice(deviceManager))
 			deviceGroup.POST("/:id/heartbeat", api.DeviceHeartbeat(deviceManager))
+			deviceGroup.POST("/:id/credentials/force-rotate", api.ForceCredentialRotation(authService))
+			deviceGroup.GET("/:id/credentials/rotations", api.ListCredentialRotations(authService))
+			deviceGroup.GET("/:id/sessions", api.ListDeviceSessions(authService))
+			deviceGroup.DELETE("/:id/sessions", api.RevokeDeviceSessions(authService))
+
+			// 设备证书：ZKP认证后自助签发、提交申请待运维审批、使用当前证书续期
+			if edgeCA != nil {
+				deviceGroup.POST("/:id/certificates", deviceAuth, api.EnrollDeviceCertificate(edgeCA))
+				deviceGroup.POST("/:id/certificates/renew", deviceAuth, api.RenewDeviceCertificate(edgeCA))
+				deviceGroup.POST("/:id/certificate-requests", api.SubmitCertificateRequest(edgeCA))
+				deviceGroup.GET("/:id/certificate-requests/:request_id", api.GetCertificateRequest(edgeCA))
+				deviceGroup.GET("/:id/certificates", api.ListDeviceCertificates(edgeCA))
+				deviceGroup.DELETE("/:id/certificates", api.RevokeAllDeviceCertificates(edgeCA))
+				deviceGroup.DELETE("/:id/certificates/:serial", api.RevokeDeviceCertificate(edgeCA))
+			}
+		}
+
+		// Edge CA查询（无需认证，用于Web管理界面）
+		if edgeCA != nil {
+			pkiGroup := v1.Group("/pki")
+			{
+				pkiGroup.GET("/ca", api.GetCACertificate(edgeCA))
+				pkiGroup.GET("/requests", api.ListCertificateRequests(edgeCA))
+			}
+		}
+
+		// 会话管理（无需认证，用于Web管理界面）
+		sessionGroup := v1.Group("/sessions")
+		{
+			sessionGroup.GET("", api.ListSessions(authService))
+			sessionGroup.DELETE("", api.RevokeAllSessions(authService))
+			sessionGroup.DELETE("/:session_id", api.RevokeSession(authService))
 		}
 
 		// 储能柜管理（无需认证，用于云端同步）
@@ -451,15 +764,19 @@ func setupRouter(
 				// 启用ABAC: 设备认证中间件 + ABAC权限评估中间件 + ZKP认证
 				deviceAuthMiddleware := abac.NewDeviceAuthMiddleware()
 				deviceABACMiddleware := abac.NewDeviceABACMiddleware(abacRepo)
+				deviceABACMiddleware.SetContextProvider(abacContext)
+				if trustTracker != nil {
+					deviceABACMiddleware.SetTrustTracker(trustTracker)
+				}
 				dataGroup.POST("/collect",
 					deviceAuthMiddleware.Handle(),
 					deviceABACMiddleware.Handle(),
-					api.AuthMiddleware(authService),
+					deviceAuth,
 					api.CollectData(dataCollector))
 				logger.Info("数据采集端点已启用ABAC设备权限控制")
 			} else {
 				// 仅ZKP认证
-				dataGroup.POST("/collect", api.AuthMiddleware(authService), api.CollectData(dataCollector))
+				dataGroup.POST("/collect", deviceAuth, api.CollectData(dataCollector))
 			}
 
 			// 查询和统计无需认证（Web管理界面使用）
@@ -512,6 +829,12 @@ func setupRouter(
 			vulnerabilityGroup.GET("/history", api.GetVulnerabilityHistory(vulnService))
 			vulnerabilityGroup.GET("/metrics", api.GetTransmissionMetrics(vulnService))
 			vulnerabilityGroup.POST("/trigger", api.TriggerAssessment(vulnService))
+			vulnerabilityGroup.GET("/scorers", api.GetVulnerabilityScorers(vulnService))
+			vulnerabilityGroup.GET("/firmware", api.GetFirmwareVulnerabilities(vulnService))
+			vulnerabilityGroup.GET("/certificates", api.GetTLSCertificates(vulnService))
+			vulnerabilityGroup.GET("/listeners", api.GetListeningPorts(vulnService))
+			vulnerabilityGroup.GET("/config-baseline", api.GetConfigBaseline(vulnService))
+			vulnerabilityGroup.GET("/remediations", api.ListRemediations(vulnService))
 			vulnerabilityGroup.POST("/dismiss", api.DismissVulnerability(vulnService))
 		}
 
@@ -521,14 +844,66 @@ func setupRouter(
 			mapGroup.POST("/search", handlers.SearchPlace(cfg, logger))
 		}
 
+		// 群组会话诊断接口：权限由subject_type=group的ABAC策略授予（如 read:devices、read:alerts）
+		if operatorAuth != nil {
+			diagGroup := v1.Group("/diagnostics", operatorAuth...)
+			{
+				diagGroup.GET("/devices", api.ListDevices(deviceManager))
+				diagGroup.GET("/devices/:id", api.GetDevice(deviceManager))
+				diagGroup.GET("/devices/:id/latest-data", api.GetDeviceLatestData(dataCollector))
+				diagGroup.GET("/alerts", api.ListAlerts(dataCollector))
+				diagGroup.GET("/vulnerability/current", api.GetCurrentVulnerability(vulnService))
+			}
+
+			// 处置确认/拒绝需群组会话认证，权限由group策略授予（write:confirm、write:reject），操作人记录为会话群组
+			remediationGroup := v1.Group("/vulnerability/remediations", operatorAuth...)
+			{
+				remediationGroup.POST("/:id/confirm", api.ConfirmRemediation(vulnService))
+				remediationGroup.POST("/:id/reject", api.RejectRemediation(vulnService))
+			}
+
+			// 本地配置变更后批准新基线（write:approve），也可由Cloud命令批准
+			baselineGroup := v1.Group("/vulnerability/config-baseline", operatorAuth...)
+			{
+				baselineGroup.POST("/approve", api.ApproveConfigBaseline(vulnService))
+			}
+
+			// 认证锁定解除（write:unlock）、verifying key卸载（write:retire）、
+			// 群组会话撤销（delete:sessions），操作人记录为会话群组
+			operatorAuthGroup := v1.Group("/auth", operatorAuth...)
+			{
+				operatorAuthGroup.POST("/lockouts/:scope/:key/unlock", api.UnlockAuth(authService))
+				operatorAuthGroup.POST("/keys/:key_id/retire", api.RetireVerifyingKey(authService))
+				operatorAuthGroup.DELETE("/groups/:group_id/sessions", api.RevokeAuthGroupSessions(authService))
+			}
+
+			// 证书申请审批（write:approve）、拒绝（write:reject），也可由Cloud命令审批
+			if edgeCA != nil {
+				operatorPKIGroup := v1.Group("/pki", operatorAuth...)
+				{
+					operatorPKIGroup.POST("/requests/:request_id/approve", api.ApproveCertificateRequest(edgeCA))
+					operatorPKIGroup.POST("/requests/:request_id/reject", api.RejectCertificateRequest(edgeCA))
+				}
+			}
+		}
+
 		// ABAC策略查询（只读API，无需认证，用于Web管理界面）
 		if abacRepo != nil {
 			abacHandler := handlers.NewABACHandler(abacRepo, logger)
+			if trustTracker != nil {
+				abacHandler.SetTrustTracker(trustTracker)
+			}
+			if topicAuthorizer != nil {
+				abacHandler.SetTopicAuthorizer(topicAuthorizer)
+			}
 			abacGroup := v1.Group("/abac")
 			{
-				abacGroup.GET("/policies", abacHandler.ListPolicies)         // 查询策略列表
-				abacGroup.GET("/policies/:id", abacHandler.GetPolicy)        // 查询策略详情
-				abacGroup.GET("/policies/stats", abacHandler.GetPolicyStats) // 策略统计
+				abacGroup.GET("/policies", abacHandler.ListPolicies)           // 查询策略列表
+				abacGroup.GET("/policies/:id", abacHandler.GetPolicy)          // 查询策略详情
+				abacGroup.GET("/policies/stats", abacHandler.GetPolicyStats)   // 策略统计
+				abacGroup.GET("/trust", abacHandler.ListDeviceTrust)           // 设备信任度列表
+				abacGroup.GET("/trust/:device_id", abacHandler.GetDeviceTrust) // 设备信任度及历史
+				abacGroup.GET("/mqtt/denials", abacHandler.GetMQTTDenialStats) // 设备MQTT消息拒绝统计
 			}
 		}
 	}
@@ -536,6 +911,150 @@ func setupRouter(
 	return router
 }
 
+// buildDriftConfig 构建配置漂移检测的基线范围：配置文件、verifying key、许可证文件
+func buildDriftConfig(cfg *config.Config, configFile string) vulnerability.DriftConfig {
+	keyFile := cfg.Vulnerability.Drift.KeyFile
+	if keyFile == "" {
+		keyFile = "./data/baseline_signing.key"
+	}
+
+	files := []vulnerability.DriftTarget{
+		{Name: "config_file", Path: configFile, Content: true},
+		{Name: "license_file", Path: cfg.License.Path},
+	}
+	hasV1 := false
+	for _, key := range cfg.Auth.ZKP.VerifyingKeys {
+		hasV1 = hasV1 || key.ID == "v1"
+		files = append(files, vulnerability.DriftTarget{Name: "verifying_key:" + key.ID, Path: key.Path})
+	}
+	if !hasV1 {
+		files = append(files, vulnerability.DriftTarget{Name: "verifying_key:v1", Path: cfg.Auth.ZKP.VerifyingKeyPath})
+	}
+	if cfg.Auth.ZKP.MembershipKeyPath != "" {
+		files = append(files, vulnerability.DriftTarget{Name: "membership_key", Path: cfg.Auth.ZKP.MembershipKeyPath})
+	}
+
+	return vulnerability.DriftConfig{KeyFile: keyFile, Files: files}
+}
+
+// buildTLSPostureConfig 构建TLS检查目标：Edge HTTPS及MQTT TLS监听、MQTT broker连接、Cloud端点及证书文件
+func buildTLSPostureConfig(cfg *config.Config) vulnerability.TLSPostureConfig {
+	serverName := "localhost"
+	if len(cfg.PKI.ServerHosts) > 0 {
+		serverName = cfg.PKI.ServerHosts[0]
+	}
+	edgeCAFile := ""
+	if cfg.PKI.Enabled {
+		edgeCAFile = cfg.PKI.CACertFile
+	}
+
+	var targets []vulnerability.TLSTarget
+	if cfg.PKI.Enabled && cfg.PKI.MTLSAddress != "" {
+		targets = append(targets, vulnerability.TLSTarget{
+			Name: "edge_https", Address: localAddress(cfg.PKI.MTLSAddress), ServerName: serverName, CAFile: edgeCAFile,
+		})
+	}
+	if cfg.MQTTBroker.Enabled && cfg.MQTTBroker.TLSAddress != "" {
+		caFile := edgeCAFile
+		if cfg.MQTTBroker.CertFile != "" {
+			caFile = ""
+		}
+		targets = append(targets, vulnerability.TLSTarget{
+			Name: "edge_mqtt_tls", Address: localAddress(cfg.MQTTBroker.TLSAddress), ServerName: serverName, CAFile: caFile,
+		})
+	}
+	if address := vulnerability.TLSAddress(cfg.MQTT.BrokerAddress); cfg.MQTT.Enabled && address != "" {
+		targets = append(targets, vulnerability.TLSTarget{Name: "mqtt_broker", Address: address, CAFile: cfg.MQTT.TLS.CAFile})
+	}
+	if address := vulnerability.TLSAddress(cfg.Cloud.Endpoint); cfg.Cloud.Enabled && address != "" {
+		targets = append(targets, vulnerability.TLSTarget{Name: "cloud_endpoint", Address: address})
+	}
+	if cfg.MQTT.TLS.CertFile != "" {
+		targets = append(targets, vulnerability.TLSTarget{Name: "mqtt_client_cert", CertFile: cfg.MQTT.TLS.CertFile, CAFile: cfg.MQTT.TLS.CAFile})
+	}
+	if edgeCAFile != "" {
+		targets = append(targets, vulnerability.TLSTarget{Name: "edge_ca", CertFile: edgeCAFile, CAFile: edgeCAFile})
+	}
+
+	return vulnerability.TLSPostureConfig{
+		Targets:       targets,
+		CheckInterval: cfg.Vulnerability.TLS.CheckInterval,
+		Timeout:       cfg.Vulnerability.TLS.Timeout,
+	}
+}
+
+// buildPortAuditConfig 由各监听配置推导端口白名单，配置为回环地址的服务只允许本机监听
+func buildPortAuditConfig(cfg *config.Config) vulnerability.PortAuditConfig {
+	rules := []vulnerability.ListenerRule{
+		{Name: "edge_api", Port: cfg.Server.Port, LocalOnly: isLoopbackHost(cfg.Server.Host)},
+	}
+	addListen := func(name, listen string) {
+		host, port, err := net.SplitHostPort(listen)
+		if err != nil {
+			return
+		}
+		if number, err := strconv.Atoi(port); err == nil {
+			rules = append(rules, vulnerability.ListenerRule{Name: name, Port: number, LocalOnly: isLoopbackHost(host)})
+		}
+	}
+	if cfg.MQTTBroker.Enabled {
+		addListen("mqtt_broker", cfg.MQTTBroker.Address)
+		addListen("mqtt_broker_tls", cfg.MQTTBroker.TLSAddress)
+	}
+	if cfg.PKI.Enabled {
+		addListen("edge_https", cfg.PKI.MTLSAddress)
+	}
+	if cfg.Monitoring.MetricsEnabled && cfg.Monitoring.MetricsPort > 0 {
+		// 指标只供本机采集代理抓取
+		rules = append(rules, vulnerability.ListenerRule{Name: "metrics", Port: cfg.Monitoring.MetricsPort, LocalOnly: true})
+	}
+
+	audit := cfg.Vulnerability.PortAudit
+	for _, port := range audit.AllowedPorts {
+		rules = append(rules, vulnerability.ListenerRule{Name: "allowed", Port: port})
+	}
+	for _, port := range audit.LocalOnlyPorts {
+		rules = append(rules, vulnerability.ListenerRule{Name: "local_only", Port: port, LocalOnly: true})
+	}
+
+	return vulnerability.PortAuditConfig{Allowed: rules, DebugPorts: audit.DebugPorts, UDPAllowed: audit.UDPAllowed}
+}
+
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// buildPlaybooks 转换处置剧本配置
+func buildPlaybooks(cfg *config.Config) []vulnerability.Playbook {
+	var playbooks []vulnerability.Playbook
+	for _, p := range cfg.Vulnerability.Remediation.Playbooks {
+		playbooks = append(playbooks, vulnerability.Playbook{
+			EventType: p.EventType,
+			Action:    p.Action,
+			Mode:      p.Mode,
+			Cooldown:  p.Cooldown,
+			Params:    p.Params,
+		})
+	}
+	return playbooks
+}
+
+// localAddress 将监听地址（如:8443）转换为本机握手地址
+func localAddress(listen string) string {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return listen
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
 // convertVulnerabilityConfig 转换配置格式
 func convertVulnerabilityConfig(allCfg *config.Config) vulnerability.VulnerabilityConfig {
 	cfg := allCfg.Vulnerability
@@ -562,6 +1081,12 @@ func convertVulnerabilityConfig(allCfg *config.Config) vulnerability.Vulnerabili
 	vulnCfg.DataAnomaly.AbnormalValueThreshold = cfg.DataAnomaly.AbnormalValueThreshold
 	vulnCfg.DataAnomaly.AlertFrequencyThreshold = cfg.DataAnomaly.AlertFrequencyThreshold
 
+	// 评分器权重及启用状态
+	vulnCfg.Scorers = make(map[string]vulnerability.ScorerSetting, len(cfg.Scorers))
+	for name, scorer := range cfg.Scorers {
+		vulnCfg.Scorers[name] = vulnerability.ScorerSetting{Enabled: scorer.Enabled, Weight: scorer.Weight}
+	}
+
 	// 配置检查参数
 	vulnCfg.MQTTBroker = allCfg.MQTT.BrokerAddress
 	vulnCfg.ServerHost = allCfg.Server.Host
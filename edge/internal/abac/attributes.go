@@ -20,6 +20,7 @@ type SubjectType string
 
 const (
 	SubjectTypeDevice SubjectType = "device"
+	SubjectTypeGroup  SubjectType = "group" // 群组成员认证主体，不对应具体设备
 )
 
 // Attributes 通用属性接口
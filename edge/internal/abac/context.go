@@ -0,0 +1,149 @@
+package abac
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContextAttributePrefix 条件中引用上下文属性的前缀，如 context.critical_alerts
+const ContextAttributePrefix = "context."
+
+// ContextAttributes 环境/上下文属性
+// 与主体无关，描述请求发生时的时间、来源及储能柜当前状态
+type ContextAttributes struct {
+	TimeOfDay          string  `json:"time_of_day"`         // HH:MM（本地时间）
+	Hour               int     `json:"hour"`                // 0-23
+	DayOfWeek          string  `json:"day_of_week"`         // monday ... sunday
+	SourceIP           string  `json:"source_ip"`           // 请求来源IP
+	RiskLevel          string  `json:"risk_level"`          // 最新脆弱性评估风险等级
+	VulnerabilityScore float64 `json:"vulnerability_score"` // 最新脆弱性评估综合评分
+	ActiveAlerts       int     `json:"active_alerts"`       // 未解决告警数
+	CriticalAlerts     int     `json:"critical_alerts"`     // 未解决的critical告警数
+	LicenseStatus      string  `json:"license_status"`      // valid, grace_period, expired, invalid, unlicensed, disabled
+	StateUnavailable   bool    `json:"state_unavailable"`   // 储能柜状态获取失败，以上状态属性未知
+}
+
+// cabinetStateAttributes 由储能柜状态填充的上下文属性
+var cabinetStateAttributes = map[string]bool{
+	"risk_level":          true,
+	"vulnerability_score": true,
+	"active_alerts":       true,
+	"critical_alerts":     true,
+	"license_status":      true,
+}
+
+// CabinetState 储能柜当前状态，由ContextProvider提供
+type CabinetState struct {
+	RiskLevel          string
+	VulnerabilityScore float64
+	ActiveAlerts       int
+	CriticalAlerts     int
+	LicenseStatus      string
+}
+
+// ContextProvider 储能柜状态提供者
+type ContextProvider interface {
+	CabinetState(ctx context.Context) (*CabinetState, error)
+}
+
+// ContextProviderFunc 函数形式的ContextProvider
+type ContextProviderFunc func(ctx context.Context) (*CabinetState, error)
+
+// CabinetState 实现ContextProvider接口
+func (f ContextProviderFunc) CabinetState(ctx context.Context) (*CabinetState, error) {
+	return f(ctx)
+}
+
+// cachedContextProvider 缓存储能柜状态，避免每次评估都查询数据库
+type cachedContextProvider struct {
+	provider ContextProvider
+	ttl      time.Duration
+
+	mu       sync.Mutex
+	state    *CabinetState
+	loadedAt time.Time
+}
+
+// NewCachedContextProvider 创建带缓存的ContextProvider
+func NewCachedContextProvider(provider ContextProvider, ttl time.Duration) ContextProvider {
+	return &cachedContextProvider{provider: provider, ttl: ttl}
+}
+
+// CabinetState 实现ContextProvider接口
+func (p *cachedContextProvider) CabinetState(ctx context.Context) (*CabinetState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state != nil && time.Since(p.loadedAt) < p.ttl {
+		return p.state, nil
+	}
+	state, err := p.provider.CabinetState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.state = state
+	p.loadedAt = time.Now()
+	return state, nil
+}
+
+// NewContextAttributes 根据当前时间和来源IP构建上下文属性
+func NewContextAttributes(now time.Time, sourceIP string) *ContextAttributes {
+	return &ContextAttributes{
+		TimeOfDay: now.Format("15:04"),
+		Hour:      now.Hour(),
+		DayOfWeek: strings.ToLower(now.Weekday().String()),
+		SourceIP:  sourceIP,
+	}
+}
+
+// ApplyCabinetState 填充储能柜状态
+func (c *ContextAttributes) ApplyCabinetState(state *CabinetState) {
+	if state == nil {
+		return
+	}
+	c.RiskLevel = state.RiskLevel
+	c.VulnerabilityScore = state.VulnerabilityScore
+	c.ActiveAlerts = state.ActiveAlerts
+	c.CriticalAlerts = state.CriticalAlerts
+	c.LicenseStatus = state.LicenseStatus
+}
+
+// buildContext 构建一次评估的上下文属性
+// 状态获取失败时标记为不可用，依赖状态属性的策略按失败关闭处理，不能把零值当作真实状态
+func buildContext(ctx context.Context, provider ContextProvider, sourceIP string) *ContextAttributes {
+	env := NewContextAttributes(time.Now(), sourceIP)
+	if provider != nil {
+		state, err := provider.CabinetState(ctx)
+		if err != nil || state == nil {
+			env.StateUnavailable = true
+		} else {
+			env.ApplyCabinetState(state)
+		}
+	}
+	return env
+}
+
+// referencesCabinetState 判断条件（含嵌套组合）是否引用储能柜状态属性
+func referencesCabinetState(conditions []PolicyCondition) bool {
+	for i := range conditions {
+		cond := &conditions[i]
+		if name, found := strings.CutPrefix(cond.Attribute, ContextAttributePrefix); found && cabinetStateAttributes[name] {
+			return true
+		}
+		if referencesCabinetState(cond.All) || referencesCabinetState(cond.Any) {
+			return true
+		}
+		if cond.Not != nil && referencesCabinetState([]PolicyCondition{*cond.Not}) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextualAttributes 将上下文属性附加到主体属性上，供条件匹配使用
+type contextualAttributes struct {
+	Attributes
+	env *ContextAttributes
+}
@@ -0,0 +1,303 @@
+package abac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEvaluateCombiningAlgorithms(t *testing.T) {
+	policies := []*AccessPolicy{
+		{
+			ID: "allow-sensors", Name: "allow-sensors", SubjectType: "device", Enabled: true, Priority: 100,
+			Permissions: []string{"write:sensors"},
+		},
+		{
+			ID: "deny-co", Name: "deny-co", SubjectType: "device", Effect: EffectDeny, Enabled: true, Priority: 50,
+			Conditions:  []PolicyCondition{{Attribute: "sensor_type", Operator: "eq", Value: "co"}},
+			Permissions: []string{"write:*"},
+		},
+		{
+			ID: "group-only", Name: "group-only", SubjectType: "group", Enabled: true, Priority: 200,
+			Permissions: []string{"*"},
+		},
+	}
+
+	tests := []struct {
+		algorithm  string
+		sensorType string
+		allowed    bool
+		decidedBy  string
+	}{
+		{AlgorithmDenyOverrides, "co", false, "deny-co"},
+		{AlgorithmDenyOverrides, "smoke", true, "allow-sensors"},
+		{AlgorithmPermitOverrides, "co", true, "allow-sensors"},
+		{AlgorithmFirstApplicable, "co", true, "allow-sensors"},
+		{"", "co", false, "deny-co"},
+	}
+
+	e := NewEvaluator()
+	for _, tt := range tests {
+		resp := e.Evaluate(&EvaluateRequest{
+			SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1", SensorType: tt.sensorType, Status: "active", Quality: 100},
+			Resource:     "/api/v1/data/sensors",
+			Action:       "POST",
+			Policies:     policies,
+			Algorithm:    tt.algorithm,
+		})
+		if resp.Allowed != tt.allowed {
+			t.Errorf("%s/%s: allowed=%v, want %v (%s)", tt.algorithm, tt.sensorType, resp.Allowed, tt.allowed, resp.Reason)
+		}
+		if resp.MatchedPolicy == nil || resp.MatchedPolicy.ID != tt.decidedBy {
+			t.Errorf("%s/%s: decided by %v, want %s", tt.algorithm, tt.sensorType, resp.MatchedPolicy, tt.decidedBy)
+		}
+		// group策略不参与设备评估
+		if len(resp.Decisions) != 2 {
+			t.Errorf("%s/%s: %d decisions, want 2", tt.algorithm, tt.sensorType, len(resp.Decisions))
+		}
+	}
+
+	// 条件匹配但未覆盖所需权限
+	resp := e.Evaluate(&EvaluateRequest{
+		SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1", SensorType: "smoke"},
+		Resource:     "/api/v1/devices/dev-1",
+		Action:       "DELETE",
+		Policies:     policies,
+	})
+	if resp.Allowed || resp.MatchedPolicy != nil || !resp.Decisions[0].Matched || resp.Decisions[0].Applicable {
+		t.Errorf("unexpected result for uncovered permission: %+v", resp)
+	}
+}
+
+func TestMatchConditionGroups(t *testing.T) {
+	var conds []PolicyCondition
+	// (sensor_type in [co, smoke] OR trust_score > 90) AND NOT device_id prefix "test-"
+	raw := `[
+		{"any": [
+			{"attribute": "sensor_type", "operator": "in", "value": ["co", "smoke"]},
+			{"attribute": "trust_score", "operator": "gt", "value": 90}
+		]},
+		{"not": {"attribute": "device_id", "operator": "prefix", "value": "test-"}},
+		{"attribute": "quality", "operator": "between", "value": [50, 100]},
+		{"attribute": "cabinet_id", "operator": "regex", "value": "^CAB-[0-9]+$"},
+		{"attribute": "status", "operator": "exists"}
+	]`
+	if err := json.Unmarshal([]byte(raw), &conds); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConditions(conds); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	base := DeviceAttributes{DeviceID: "dev-1", CabinetID: "CAB-001", SensorType: "co", Status: "active", Quality: 80}
+	tests := []struct {
+		name   string
+		modify func(a *DeviceAttributes)
+		want   bool
+	}{
+		{"matches any first branch", func(a *DeviceAttributes) {}, true},
+		{"matches any second branch", func(a *DeviceAttributes) { a.SensorType = "co2"; a.TrustScore = 95 }, true},
+		{"no any branch", func(a *DeviceAttributes) { a.SensorType = "co2"; a.TrustScore = 50 }, false},
+		{"negated prefix", func(a *DeviceAttributes) { a.DeviceID = "test-1" }, false},
+		{"out of range", func(a *DeviceAttributes) { a.Quality = 20 }, false},
+		{"regex mismatch", func(a *DeviceAttributes) { a.CabinetID = "cab-1" }, false},
+		{"missing attribute", func(a *DeviceAttributes) { a.Status = "" }, false},
+	}
+
+	e := NewEvaluator()
+	for _, tt := range tests {
+		attrs := base
+		tt.modify(&attrs)
+		if got := e.matchConditions(&attrs, conds); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !e.cidr("10.1.2.3", []interface{}{"192.168.0.0/16", "10.0.0.0/8"}) || e.cidr("172.16.0.1", "10.0.0.0/8") {
+		t.Error("unexpected cidr result")
+	}
+
+	invalid := [][]PolicyCondition{
+		{{Attribute: "device_id", Operator: "regex", Value: "("}},
+		{{Attribute: "device_id", Operator: "cidr", Value: "10.0.0.0"}},
+		{{Attribute: "quality", Operator: "between", Value: []interface{}{100.0, 1.0}}},
+		{{Attribute: "quality", Operator: "like", Value: "x"}},
+	}
+	for _, c := range invalid {
+		if ValidateConditions(c) == nil {
+			t.Errorf("expected validation error for %+v", c)
+		}
+	}
+}
+
+func TestEvaluateContextAttributes(t *testing.T) {
+	policies := []*AccessPolicy{
+		{
+			ID: "allow-config", SubjectType: "device", Enabled: true, Priority: 10,
+			Permissions: []string{"write:config"},
+		},
+		{
+			// 存在未解决的critical告警时禁止修改配置
+			ID: "deny-config-critical", SubjectType: "device", Effect: EffectDeny, Enabled: true, Priority: 90,
+			Conditions:  []PolicyCondition{{Attribute: "context.critical_alerts", Operator: "gt", Value: 0}},
+			Permissions: []string{"write:config"},
+		},
+		{
+			// 维护窗口外禁止来自外部网段的写操作
+			ID: "deny-remote-outside-window", SubjectType: "device", Effect: EffectDeny, Enabled: true, Priority: 80,
+			Conditions: []PolicyCondition{
+				{Not: &PolicyCondition{Attribute: "context.source_ip", Operator: "cidr", Value: "192.168.0.0/16"}},
+				{Not: &PolicyCondition{Attribute: "context.hour", Operator: "between", Value: []interface{}{2, 4}}},
+			},
+			Permissions: []string{"write:*"},
+		},
+	}
+
+	at := func(hour int) time.Time { return time.Date(2026, 3, 2, hour, 30, 0, 0, time.Local) }
+	tests := []struct {
+		name     string
+		env      *ContextAttributes
+		critical int
+		want     bool
+	}{
+		{"local, no alerts", NewContextAttributes(at(14), "192.168.1.10"), 0, true},
+		{"local, critical alert", NewContextAttributes(at(14), "192.168.1.10"), 1, false},
+		{"remote, outside window", NewContextAttributes(at(14), "10.0.0.5"), 0, false},
+		{"remote, inside window", NewContextAttributes(at(3), "10.0.0.5"), 0, true},
+	}
+
+	e := NewEvaluator()
+	for _, tt := range tests {
+		tt.env.ApplyCabinetState(&CabinetState{CriticalAlerts: tt.critical, ActiveAlerts: tt.critical})
+		resp := e.Evaluate(&EvaluateRequest{
+			SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1"},
+			Resource:     "/api/v1/config",
+			Action:       "PUT",
+			Policies:     policies,
+			Context:      tt.env,
+		})
+		if resp.Allowed != tt.want {
+			t.Errorf("%s: allowed=%v, want %v (%s)", tt.name, resp.Allowed, tt.want, resp.Reason)
+		}
+	}
+
+	// 储能柜状态获取失败时不能当作无告警：拒绝策略生效，依赖状态的允许策略不匹配
+	failing := ContextProviderFunc(func(ctx context.Context) (*CabinetState, error) {
+		return nil, errors.New("database locked")
+	})
+	env := buildContext(context.Background(), failing, "192.168.1.10")
+	if !env.StateUnavailable || e.getAttributeValue(&contextualAttributes{Attributes: &DeviceAttributes{}, env: env}, "context.critical_alerts") != nil {
+		t.Fatalf("unavailable state must not expose zero values: %+v", env)
+	}
+	resp := e.Evaluate(&EvaluateRequest{
+		SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1"},
+		Resource:     "/api/v1/config",
+		Action:       "PUT",
+		Policies:     policies,
+		Context:      env,
+	})
+	if resp.Allowed || resp.MatchedPolicy == nil || resp.MatchedPolicy.ID != "deny-config-critical" {
+		t.Errorf("deny policy on cabinet state must apply when state is unavailable: %s", resp.Reason)
+	}
+	resp = e.Evaluate(&EvaluateRequest{
+		SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1"},
+		Resource:     "/api/v1/config",
+		Action:       "PUT",
+		Policies: []*AccessPolicy{{
+			ID: "allow-config-no-alerts", SubjectType: "device", Enabled: true,
+			Conditions:  []PolicyCondition{{Not: &PolicyCondition{Attribute: "context.critical_alerts", Operator: "gt", Value: 0}}},
+			Permissions: []string{"write:config"},
+		}},
+		Context: env,
+	})
+	if resp.Allowed {
+		t.Error("allow policy on cabinet state must not match when state is unavailable")
+	}
+
+	if env := NewContextAttributes(at(3), ""); env.DayOfWeek != "monday" || env.TimeOfDay != "03:30" {
+		t.Errorf("unexpected time attributes: %+v", env)
+	}
+	// 未提供上下文时context.*属性不存在
+	if e.getAttributeValue(&DeviceAttributes{}, "context.hour") != nil {
+		t.Error("context attribute must be nil without context")
+	}
+}
+
+func TestEvaluateShadowPolicies(t *testing.T) {
+	policies := []*AccessPolicy{
+		{
+			ID: "allow-sensors", SubjectType: "device", Enabled: true, Priority: 10,
+			Permissions: []string{"write:sensors"},
+		},
+		{
+			// 试运行：拒绝低质量设备上传
+			ID: "shadow-deny-low-quality", SubjectType: "device", Effect: EffectDeny, Mode: ModeShadow, Enabled: true, Priority: 90,
+			Conditions:  []PolicyCondition{{Attribute: "quality", Operator: "lt", Value: 60}},
+			Permissions: []string{"write:*"},
+		},
+		{
+			ID: "shadow-disabled", SubjectType: "device", Effect: EffectDeny, Mode: ModeShadow, Enabled: false,
+			Permissions: []string{"*"},
+		},
+	}
+
+	e := NewEvaluator()
+	for _, tt := range []struct {
+		quality int
+		changed bool
+	}{{40, true}, {90, false}} {
+		resp := e.Evaluate(&EvaluateRequest{
+			SubjectAttrs: &DeviceAttributes{DeviceID: "dev-1", Quality: tt.quality},
+			Resource:     "/api/v1/data/sensors",
+			Action:       "POST",
+			Policies:     policies,
+		})
+		if !resp.Allowed || resp.MatchedPolicy.ID != "allow-sensors" {
+			t.Errorf("quality=%d: shadow policy must not affect enforcement: %+v", tt.quality, resp)
+		}
+		if len(resp.Decisions) != 1 {
+			t.Errorf("quality=%d: %d decisions, want only enforced policies", tt.quality, len(resp.Decisions))
+		}
+		if len(resp.Shadow) != 1 || resp.Shadow[0].PolicyID != "shadow-deny-low-quality" {
+			t.Fatalf("quality=%d: unexpected shadow decisions %+v", tt.quality, resp.Shadow)
+		}
+		if resp.Shadow[0].Changed != tt.changed || resp.Shadow[0].Allowed == tt.changed {
+			t.Errorf("quality=%d: shadow=%+v, want changed=%v", tt.quality, resp.Shadow[0], tt.changed)
+		}
+	}
+}
+
+// TestConditionParityVectors 与Cloud端评估器共用同一组向量，保证两端条件匹配结果一致
+func TestConditionParityVectors(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "abac", "condition_vectors.json"))
+	if err != nil {
+		t.Skipf("shared ABAC vectors not available: %v", err)
+	}
+	var vectors struct {
+		Device DeviceAttributes `json:"device"`
+		Cases  []struct {
+			Name       string            `json:"name"`
+			Conditions []PolicyCondition `json:"conditions"`
+			Want       bool              `json:"want"`
+		} `json:"cases"`
+	}
+	if err := json.Unmarshal(raw, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewEvaluator()
+	for _, tc := range vectors.Cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if err := ValidateConditions(tc.Conditions); err != nil {
+				t.Fatalf("invalid conditions: %v", err)
+			}
+			if got := e.matchConditions(&vectors.Device, tc.Conditions); got != tc.Want {
+				t.Errorf("matched=%v, want %v", got, tc.Want)
+			}
+		})
+	}
+}
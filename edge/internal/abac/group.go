@@ -0,0 +1,143 @@
+package abac
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupAttributes 群组成员认证主体属性
+// 成员证明不暴露具体成员，因此只有群组级属性可用于策略匹配
+type GroupAttributes struct {
+	GroupID    string  `json:"group_id"`
+	CabinetID  string  `json:"cabinet_id"`
+	AuthMethod string  `json:"auth_method"` // zkp_membership
+	TrustScore float64 `json:"trust_score"`
+}
+
+// GetType 实现Attributes接口
+func (g *GroupAttributes) GetType() SubjectType {
+	return SubjectTypeGroup
+}
+
+// GetID 实现Attributes接口
+func (g *GroupAttributes) GetID() string {
+	return g.GroupID
+}
+
+// GetTrustScore 实现Attributes接口
+func (g *GroupAttributes) GetTrustScore() float64 {
+	return g.TrustScore
+}
+
+// GroupABACMiddleware 群组ABAC中间件
+// 按 subject_type=group 的策略为群组会话授予权限
+type GroupABACMiddleware struct {
+	repo        Repository
+	evaluator   *Evaluator
+	cabinetID   string
+	contextProv ContextProvider // 储能柜状态（可选）
+}
+
+// NewGroupABACMiddleware 创建群组ABAC中间件
+func NewGroupABACMiddleware(repo Repository, cabinetID string) *GroupABACMiddleware {
+	return &GroupABACMiddleware{
+		repo:      repo,
+		evaluator: NewEvaluator(),
+		cabinetID: cabinetID,
+	}
+}
+
+// SetContextProvider 设置上下文属性中储能柜状态的提供者
+func (m *GroupABACMiddleware) SetContextProvider(provider ContextProvider) {
+	m.contextProv = provider
+}
+
+// Handle 中间件处理函数（需在群组令牌认证之后使用，从上下文读取group_id）
+func (m *GroupABACMiddleware) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupID := c.GetString("group_id")
+		if groupID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "未找到群组认证信息",
+			})
+			c.Abort()
+			return
+		}
+
+		attrs := &GroupAttributes{
+			GroupID:    groupID,
+			CabinetID:  m.cabinetID,
+			AuthMethod: "zkp_membership",
+		}
+
+		policies, err := m.repo.GetEnabledPolicies(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "加载策略失败",
+			})
+			c.Abort()
+			return
+		}
+		algorithm, err := m.repo.GetCombiningAlgorithm(c.Request.Context())
+		if err != nil {
+			algorithm = DefaultCombiningAlgorithm
+		}
+
+		evalResp := m.evaluator.Evaluate(&EvaluateRequest{
+			SubjectAttrs: attrs,
+			Resource:     c.Request.URL.Path,
+			Action:       c.Request.Method,
+			Policies:     policies,
+			Algorithm:    algorithm,
+			Context:      buildContext(c.Request.Context(), m.contextProv, c.ClientIP()),
+		})
+		go m.logAccess(attrs, c.Request.URL.Path, c.Request.Method, evalResp)
+
+		if !evalResp.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "访问被拒绝",
+				"reason":  evalResp.Reason,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set("group_attrs", attrs)
+		c.Set("abac_result", evalResp)
+		c.Set("permissions", evalResp.Permissions)
+
+		c.Next()
+	}
+}
+
+func (m *GroupABACMiddleware) logAccess(attrs *GroupAttributes, resource, action string, resp *EvaluateResponse) {
+	attrsJSON, _ := json.Marshal(attrs)
+
+	log := &AccessLog{
+		SubjectType: string(SubjectTypeGroup),
+		SubjectID:   attrs.GroupID,
+		Resource:    resource,
+		Action:      action,
+		Allowed:     resp.Allowed,
+		TrustScore:  &resp.TrustScore,
+		Reason:      resp.Reason,
+		Timestamp:   time.Now(),
+		Attributes:  attrsJSON,
+		Shadow:      resp.Shadow,
+		Synced:      false,
+	}
+	if resp.MatchedPolicy != nil {
+		log.PolicyID = &resp.MatchedPolicy.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.repo.LogAccess(ctx, log)
+}
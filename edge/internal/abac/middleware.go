@@ -22,6 +22,8 @@ type DeviceABACMiddleware struct {
 	evaluator     *Evaluator
 	stats         AccessStats
 	alertCallback SyncAlertCallback // 复用告警回调类型
+	contextProv   ContextProvider   // 储能柜状态（可选）
+	trust         *TrustTracker     // 行为信任度（可选）
 }
 
 // NewDeviceABACMiddleware 创建设备ABAC中间件
@@ -38,6 +40,16 @@ func (m *DeviceABACMiddleware) SetAlertCallback(callback SyncAlertCallback) {
 	m.alertCallback = callback
 }
 
+// SetContextProvider 设置上下文属性中储能柜状态的提供者
+func (m *DeviceABACMiddleware) SetContextProvider(provider ContextProvider) {
+	m.contextProv = provider
+}
+
+// SetTrustTracker 设置行为信任度跟踪器：评估前合入trust_score，拒绝时记录信号
+func (m *DeviceABACMiddleware) SetTrustTracker(tracker *TrustTracker) {
+	m.trust = tracker
+}
+
 // GetStats 获取访问统计
 func (m *DeviceABACMiddleware) GetStats() AccessStats {
 	return m.stats
@@ -85,13 +97,22 @@ func (m *DeviceABACMiddleware) Handle() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		algorithm, err := m.repo.GetCombiningAlgorithm(c.Request.Context())
+		if err != nil {
+			algorithm = DefaultCombiningAlgorithm
+		}
 
 		// 3. 执行策略评估
+		if m.trust != nil {
+			m.trust.Apply(attrs)
+		}
 		evalReq := &EvaluateRequest{
 			SubjectAttrs: attrs,
 			Resource:     c.Request.URL.Path,
 			Action:       c.Request.Method,
 			Policies:     policies,
+			Algorithm:    algorithm,
+			Context:      buildContext(c.Request.Context(), m.contextProv, c.ClientIP()),
 		}
 
 		evalResp := m.evaluator.Evaluate(evalReq)
@@ -100,6 +121,9 @@ func (m *DeviceABACMiddleware) Handle() gin.HandlerFunc {
 		m.stats.TotalRequests++
 		if !evalResp.Allowed {
 			m.stats.DeniedRequests++
+			if m.trust != nil {
+				m.trust.Observe(attrs.DeviceID, SignalAccessDenied)
+			}
 		}
 		go m.logAccess(attrs, c.Request.URL.Path, c.Request.Method, evalResp)
 
@@ -149,6 +173,7 @@ func (m *DeviceABACMiddleware) logAccess(attrs *DeviceAttributes, resource, acti
 		Reason:      resp.Reason,
 		Timestamp:   time.Now(),
 		Attributes:  attrsJSON,
+		Shadow:      resp.Shadow,
 		Synced:      false,
 	}
 
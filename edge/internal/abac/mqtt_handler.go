@@ -63,17 +63,32 @@ func (h *MQTTHandler) HandlePolicySync(payload []byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	if msg.CombiningAlgorithm != "" {
+		if err := h.repo.SetCombiningAlgorithm(ctx, msg.CombiningAlgorithm); err != nil {
+			log.Printf("[ABAC] 更新组合算法失败: %v", err)
+		} else {
+			log.Printf("[ABAC] 组合算法: %s", msg.CombiningAlgorithm)
+		}
+	}
+
+	var err error
 	switch msg.Action {
 	case "sync":
-		return h.handleSync(ctx, msg.Policies)
+		err = h.handleSync(ctx, msg.Policies)
 	case "delete":
-		return h.handleDelete(ctx, msg.PolicyIDs)
+		err = h.handleDelete(ctx, msg.PolicyIDs)
 	case "full_sync":
-		return h.handleFullSync(ctx, msg.Policies)
+		err = h.handleFullSync(ctx, msg.Policies)
 	default:
 		log.Printf("[ABAC] 未知的策略同步动作: %s", msg.Action)
 		return nil
 	}
+
+	// 应用后立即上报策略摘要，便于Cloud尽快确认是否一致
+	if hbErr := h.SendHeartbeat(ctx); hbErr != nil {
+		log.Printf("[ABAC] 上报策略摘要失败: %v", hbErr)
+	}
+	return err
 }
 
 // handleSync 增量同步策略
@@ -82,10 +97,18 @@ func (h *MQTTHandler) handleSync(ctx context.Context, policies []*AccessPolicy)
 	successPolicies := []string{}
 
 	for _, policy := range policies {
-		// 只处理device类型的策略
-		if policy.SubjectType != "device" {
+		// 只处理本地评估的策略（device、group）
+		if !isLocalPolicy(policy) {
 			continue
 		}
+		if err := ValidateConditions(policy.Conditions); err != nil {
+			log.Printf("[ABAC] 策略条件无效 %s: %v", policy.ID, err)
+			failedPolicies = append(failedPolicies, policy.ID)
+			continue
+		}
+		if policy.Hash != "" && policy.Hash != policy.ContentHash() {
+			log.Printf("[ABAC] 策略内容哈希不一致 %s (v%d)，以本地计算结果上报", policy.ID, policy.Version)
+		}
 
 		if err := h.repo.SavePolicy(ctx, policy); err != nil {
 			log.Printf("[ABAC] 保存策略失败 %s: %v", policy.ID, err)
@@ -137,7 +160,11 @@ func (h *MQTTHandler) handleFullSync(ctx context.Context, policies []*AccessPoli
 
 	// 导入新策略
 	for _, policy := range policies {
-		if policy.SubjectType != "device" {
+		if !isLocalPolicy(policy) {
+			continue
+		}
+		if err := ValidateConditions(policy.Conditions); err != nil {
+			log.Printf("[ABAC] 策略条件无效，跳过 %s: %v", policy.ID, err)
 			continue
 		}
 
@@ -145,12 +172,18 @@ func (h *MQTTHandler) handleFullSync(ctx context.Context, policies []*AccessPoli
 			log.Printf("[ABAC] 保存策略失败 %s: %v", policy.ID, err)
 			return err
 		}
+		h.sendAck(policy.ID, "success")
 	}
 
 	log.Printf("[ABAC] 全量同步完成，共导入 %d 条策略", len(policies))
 	return nil
 }
 
+// isLocalPolicy 判断策略是否由Edge本地评估
+func isLocalPolicy(policy *AccessPolicy) bool {
+	return policy.SubjectType == string(SubjectTypeDevice) || policy.SubjectType == string(SubjectTypeGroup)
+}
+
 // LogSyncService 日志同步服务 - 定期将访问日志同步到Cloud
 type LogSyncService struct {
 	repo        Repository
@@ -277,3 +310,58 @@ func (h *MQTTHandler) sendAck(policyID, status string) {
 		log.Printf("[ABAC] 已发送ACK: policy=%s status=%s", policyID, status)
 	}
 }
+
+// SendHeartbeat 上报本地策略集摘要（版本、内容哈希）到Cloud
+func (h *MQTTHandler) SendHeartbeat(ctx context.Context) error {
+	if h.publishFunc == nil {
+		return nil
+	}
+
+	policies, err := h.repo.GetAllPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	algorithm, err := h.repo.GetCombiningAlgorithm(ctx)
+	if err != nil {
+		return err
+	}
+
+	versions := PolicyVersions(policies)
+	heartbeat := PolicyHeartbeat{
+		CabinetID:          h.cabinetID,
+		Digest:             PolicySetDigest(versions),
+		PolicyCount:        len(versions),
+		Policies:           versions,
+		CombiningAlgorithm: algorithm,
+		Timestamp:          time.Now(),
+	}
+
+	payload, err := json.Marshal(heartbeat)
+	if err != nil {
+		return err
+	}
+
+	topic := "edge/cabinet/" + h.cabinetID + "/policy/heartbeat"
+	return h.publishFunc(topic, payload)
+}
+
+// StartHeartbeat 定期上报策略集摘要
+func (h *MQTTHandler) StartHeartbeat(ctx context.Context, interval time.Duration) {
+	if err := h.SendHeartbeat(ctx); err != nil {
+		log.Printf("[ABAC] 上报策略摘要失败: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.SendHeartbeat(ctx); err != nil {
+				log.Printf("[ABAC] 上报策略摘要失败: %v", err)
+			}
+		}
+	}
+}
@@ -10,42 +10,78 @@ type AccessPolicy struct {
 	ID          string            `json:"id"`
 	Name        string            `json:"name"`
 	Description string            `json:"description"`
-	SubjectType string            `json:"subject_type"` // device
+	SubjectType string            `json:"subject_type"` // device, group
+	Effect      string            `json:"effect"`       // allow, deny（为空视为allow）
+	Mode        string            `json:"mode"`         // enforce, shadow（为空视为enforce）
 	Conditions  []PolicyCondition `json:"conditions"`
 	Permissions []string          `json:"permissions"`
 	Priority    int               `json:"priority"`
 	Enabled     bool              `json:"enabled"`
+	Version     int               `json:"version"`        // Cloud端策略版本，每次修改递增
+	Hash        string            `json:"hash,omitempty"` // Cloud端计算的内容哈希
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
+// 策略效果
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// GetEffect 返回策略效果，兼容未设置effect的旧策略
+func (p *AccessPolicy) GetEffect() string {
+	if p.Effect == EffectDeny {
+		return EffectDeny
+	}
+	return EffectAllow
+}
+
+// 策略模式
+const (
+	ModeEnforce = "enforce" // 参与实际授权
+	ModeShadow  = "shadow"  // 仅试运行：评估并记录假设结果，不影响授权
+)
+
+// IsShadow 是否为试运行策略
+func (p *AccessPolicy) IsShadow() bool {
+	return p.Mode == ModeShadow
+}
+
 // PolicyCondition 策略条件
+// 叶子条件使用 Attribute/Operator/Value；条件组使用 All/Any/Not，可任意嵌套
 type PolicyCondition struct {
-	Attribute string      `json:"attribute"`
-	Operator  string      `json:"operator"` // eq, ne, gt, lt, gte, lte, in, contains
+	Attribute string      `json:"attribute,omitempty"`
+	Operator  string      `json:"operator,omitempty"` // eq, ne, gt, lt, gte, lte, in, contains, regex, cidr, prefix, between, exists
 	Value     interface{} `json:"value"`
+
+	All []PolicyCondition `json:"all,omitempty"`
+	Any []PolicyCondition `json:"any,omitempty"`
+	Not *PolicyCondition  `json:"not,omitempty"`
 }
 
 // AccessLog 访问日志
 type AccessLog struct {
-	ID          int64           `json:"id"`
-	SubjectType string          `json:"subject_type"`
-	SubjectID   string          `json:"subject_id"`
-	Resource    string          `json:"resource"`
-	Action      string          `json:"action"`
-	Allowed     bool            `json:"allowed"`
-	PolicyID    *string         `json:"policy_id,omitempty"`
-	TrustScore  *float64        `json:"trust_score,omitempty"`
-	Reason      string          `json:"reason,omitempty"`
-	Timestamp   time.Time       `json:"timestamp"`
-	Attributes  json.RawMessage `json:"attributes,omitempty"`
-	Synced      bool            `json:"synced"` // 是否已同步到Cloud
+	ID          int64            `json:"id"`
+	SubjectType string           `json:"subject_type"`
+	SubjectID   string           `json:"subject_id"`
+	Resource    string           `json:"resource"`
+	Action      string           `json:"action"`
+	Allowed     bool             `json:"allowed"`
+	PolicyID    *string          `json:"policy_id,omitempty"`
+	TrustScore  *float64         `json:"trust_score,omitempty"`
+	Reason      string           `json:"reason,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Attributes  json.RawMessage  `json:"attributes,omitempty"`
+	Shadow      []ShadowDecision `json:"shadow,omitempty"` // 试运行策略的假设结果
+	Synced      bool             `json:"synced"`           // 是否已同步到Cloud
 }
 
 // PolicySyncMessage Cloud下发的策略同步消息
 type PolicySyncMessage struct {
-	Action    string          `json:"action"` // sync, delete
-	Policies  []*AccessPolicy `json:"policies,omitempty"`
-	PolicyIDs []string        `json:"policy_ids,omitempty"` // 用于删除
-	Timestamp time.Time       `json:"timestamp"`
+	Action             string          `json:"action"` // sync, delete, full_sync
+	Policies           []*AccessPolicy `json:"policies,omitempty"`
+	PolicyIDs          []string        `json:"policy_ids,omitempty"`          // 用于删除
+	CombiningAlgorithm string          `json:"combining_algorithm,omitempty"` // 组合算法，为空时保持本地设置
+	Timestamp          time.Time       `json:"timestamp"`
 }
@@ -18,6 +18,10 @@ type Repository interface {
 	DeletePolicy(ctx context.Context, id string) error
 	ClearPolicies(ctx context.Context) error
 
+	// 组合算法
+	GetCombiningAlgorithm(ctx context.Context) (string, error)
+	SetCombiningAlgorithm(ctx context.Context, algorithm string) error
+
 	// 访问日志
 	LogAccess(ctx context.Context, log *AccessLog) error
 	GetUnsyncedLogs(ctx context.Context, limit int) ([]*AccessLog, error)
@@ -46,10 +50,13 @@ func (r *SQLiteRepository) initTables() error {
 			name TEXT NOT NULL,
 			description TEXT,
 			subject_type TEXT NOT NULL,
+			effect TEXT DEFAULT 'allow',
+			mode TEXT DEFAULT 'enforce',
 			conditions TEXT NOT NULL,
 			permissions TEXT NOT NULL,
 			priority INTEGER DEFAULT 0,
 			enabled INTEGER DEFAULT 1,
+			version INTEGER DEFAULT 0,
 			created_at DATETIME,
 			updated_at DATETIME
 		)
@@ -57,6 +64,27 @@ func (r *SQLiteRepository) initTables() error {
 	if err != nil {
 		return fmt.Errorf("create device_policies table: %w", err)
 	}
+	if err := r.addColumnIfMissing("device_policies", "effect", "TEXT DEFAULT 'allow'"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfMissing("device_policies", "version", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfMissing("device_policies", "mode", "TEXT DEFAULT 'enforce'"); err != nil {
+		return err
+	}
+
+	// 创建ABAC设置表（组合算法等）
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS abac_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create abac_settings table: %w", err)
+	}
 
 	// 创建访问日志表
 	_, err = r.db.Exec(`
@@ -72,17 +100,82 @@ func (r *SQLiteRepository) initTables() error {
 			reason TEXT,
 			timestamp DATETIME NOT NULL,
 			attributes TEXT,
+			shadow TEXT,
 			synced INTEGER DEFAULT 0
 		)
 	`)
 	if err != nil {
 		return fmt.Errorf("create device_access_logs table: %w", err)
 	}
+	if err := r.addColumnIfMissing("device_access_logs", "shadow", "TEXT"); err != nil {
+		return err
+	}
 
 	// 创建索引
 	r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_logs_synced ON device_access_logs(synced)`)
 	r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_logs_timestamp ON device_access_logs(timestamp)`)
 
+	// 创建设备行为信任度表
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS device_trust_scores (
+			device_id TEXT PRIMARY KEY,
+			score REAL NOT NULL,
+			penalty REAL NOT NULL DEFAULT 0,
+			firmware_penalty REAL NOT NULL DEFAULT 0,
+			firmware_version TEXT,
+			signals TEXT,
+			last_signal TEXT,
+			last_signal_at DATETIME,
+			updated_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create device_trust_scores table: %w", err)
+	}
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS device_trust_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			device_id TEXT NOT NULL,
+			signal TEXT NOT NULL,
+			delta REAL NOT NULL,
+			score REAL NOT NULL,
+			timestamp DATETIME NOT NULL,
+			synced INTEGER DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create device_trust_events table: %w", err)
+	}
+	r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_trust_events_device ON device_trust_events(device_id, timestamp)`)
+	r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_trust_events_synced ON device_trust_events(synced)`)
+
+	return nil
+}
+
+// addColumnIfMissing 为旧版本数据库补充字段
+func (r *SQLiteRepository) addColumnIfMissing(table, column, ddl string) error {
+	rows, err := r.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notnull, pk int
+		var name, typ string
+		var dfltValue interface{}
+		if err := rows.Scan(&cid, &name, &typ, &notnull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	rows.Close()
+
+	if _, err := r.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, ddl)); err != nil {
+		return fmt.Errorf("add %s.%s column: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -99,11 +192,11 @@ func (r *SQLiteRepository) SavePolicy(ctx context.Context, policy *AccessPolicy)
 
 	_, err = r.db.ExecContext(ctx, `
 		INSERT OR REPLACE INTO device_policies
-		(id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, policy.ID, policy.Name, policy.Description, policy.SubjectType,
+		(id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, policy.ID, policy.Name, policy.Description, policy.SubjectType, policy.GetEffect(), policyMode(policy),
 		string(conditionsJSON), string(permissionsJSON),
-		policy.Priority, policy.Enabled, policy.CreatedAt, policy.UpdatedAt)
+		policy.Priority, policy.Enabled, policy.Version, policy.CreatedAt, policy.UpdatedAt)
 
 	return err
 }
@@ -111,7 +204,7 @@ func (r *SQLiteRepository) SavePolicy(ctx context.Context, policy *AccessPolicy)
 // GetPolicy 获取单个策略
 func (r *SQLiteRepository) GetPolicy(ctx context.Context, id string) (*AccessPolicy, error) {
 	row := r.db.QueryRowContext(ctx, `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM device_policies WHERE id = ?
 	`, id)
 
@@ -121,7 +214,7 @@ func (r *SQLiteRepository) GetPolicy(ctx context.Context, id string) (*AccessPol
 // GetAllPolicies 获取所有策略
 func (r *SQLiteRepository) GetAllPolicies(ctx context.Context) ([]*AccessPolicy, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM device_policies ORDER BY priority DESC
 	`)
 	if err != nil {
@@ -135,7 +228,7 @@ func (r *SQLiteRepository) GetAllPolicies(ctx context.Context) ([]*AccessPolicy,
 // GetEnabledPolicies 获取所有启用的策略
 func (r *SQLiteRepository) GetEnabledPolicies(ctx context.Context) ([]*AccessPolicy, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, name, description, subject_type, conditions, permissions, priority, enabled, created_at, updated_at
+		SELECT id, name, description, subject_type, effect, mode, conditions, permissions, priority, enabled, version, created_at, updated_at
 		FROM device_policies WHERE enabled = 1 ORDER BY priority DESC
 	`)
 	if err != nil {
@@ -146,6 +239,14 @@ func (r *SQLiteRepository) GetEnabledPolicies(ctx context.Context) ([]*AccessPol
 	return r.scanPolicies(rows)
 }
 
+// policyMode 返回存储的策略模式，为空视为enforce
+func policyMode(policy *AccessPolicy) string {
+	if policy.IsShadow() {
+		return ModeShadow
+	}
+	return ModeEnforce
+}
+
 // DeletePolicy 删除策略
 func (r *SQLiteRepository) DeletePolicy(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM device_policies WHERE id = ?`, id)
@@ -158,19 +259,51 @@ func (r *SQLiteRepository) ClearPolicies(ctx context.Context) error {
 	return err
 }
 
+// GetCombiningAlgorithm 获取Cloud下发的组合算法，未设置时返回默认算法
+func (r *SQLiteRepository) GetCombiningAlgorithm(ctx context.Context) (string, error) {
+	var algorithm string
+	err := r.db.QueryRowContext(ctx, `SELECT value FROM abac_settings WHERE key = 'combining_algorithm'`).Scan(&algorithm)
+	if err == sql.ErrNoRows || (err == nil && !ValidCombiningAlgorithm(algorithm)) {
+		return DefaultCombiningAlgorithm, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return algorithm, nil
+}
+
+// SetCombiningAlgorithm 保存组合算法
+func (r *SQLiteRepository) SetCombiningAlgorithm(ctx context.Context, algorithm string) error {
+	if !ValidCombiningAlgorithm(algorithm) {
+		return fmt.Errorf("unsupported combining algorithm: %s", algorithm)
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO abac_settings (key, value, updated_at)
+		VALUES ('combining_algorithm', ?, ?)
+	`, algorithm, time.Now())
+	return err
+}
+
 // LogAccess 记录访问日志
 func (r *SQLiteRepository) LogAccess(ctx context.Context, log *AccessLog) error {
-	var attrsJSON string
+	var attrsJSON, shadowJSON string
 	if log.Attributes != nil {
 		attrsJSON = string(log.Attributes)
 	}
+	if len(log.Shadow) > 0 {
+		data, err := json.Marshal(log.Shadow)
+		if err != nil {
+			return err
+		}
+		shadowJSON = string(data)
+	}
 
 	_, err := r.db.ExecContext(ctx, `
 		INSERT INTO device_access_logs
-		(subject_type, subject_id, resource, action, allowed, policy_id, trust_score, reason, timestamp, attributes, synced)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		(subject_type, subject_id, resource, action, allowed, policy_id, trust_score, reason, timestamp, attributes, shadow, synced)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, log.SubjectType, log.SubjectID, log.Resource, log.Action, log.Allowed,
-		log.PolicyID, log.TrustScore, log.Reason, log.Timestamp, attrsJSON, 0)
+		log.PolicyID, log.TrustScore, log.Reason, log.Timestamp, attrsJSON, shadowJSON, 0)
 
 	return err
 }
@@ -178,7 +311,7 @@ func (r *SQLiteRepository) LogAccess(ctx context.Context, log *AccessLog) error
 // GetUnsyncedLogs 获取未同步的日志
 func (r *SQLiteRepository) GetUnsyncedLogs(ctx context.Context, limit int) ([]*AccessLog, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT id, subject_type, subject_id, resource, action, allowed, policy_id, trust_score, reason, timestamp, attributes, synced
+		SELECT id, subject_type, subject_id, resource, action, allowed, policy_id, trust_score, reason, timestamp, attributes, shadow, synced
 		FROM device_access_logs WHERE synced = 0 ORDER BY timestamp ASC LIMIT ?
 	`, limit)
 	if err != nil {
@@ -189,11 +322,11 @@ func (r *SQLiteRepository) GetUnsyncedLogs(ctx context.Context, limit int) ([]*A
 	var logs []*AccessLog
 	for rows.Next() {
 		var log AccessLog
-		var policyID, attrsJSON sql.NullString
+		var policyID, attrsJSON, shadowJSON sql.NullString
 		var trustScore sql.NullFloat64
 
 		err := rows.Scan(&log.ID, &log.SubjectType, &log.SubjectID, &log.Resource, &log.Action,
-			&log.Allowed, &policyID, &trustScore, &log.Reason, &log.Timestamp, &attrsJSON, &log.Synced)
+			&log.Allowed, &policyID, &trustScore, &log.Reason, &log.Timestamp, &attrsJSON, &shadowJSON, &log.Synced)
 		if err != nil {
 			return nil, err
 		}
@@ -207,6 +340,11 @@ func (r *SQLiteRepository) GetUnsyncedLogs(ctx context.Context, limit int) ([]*A
 		if attrsJSON.Valid && attrsJSON.String != "" {
 			log.Attributes = json.RawMessage(attrsJSON.String)
 		}
+		if shadowJSON.Valid && shadowJSON.String != "" {
+			if err := json.Unmarshal([]byte(shadowJSON.String), &log.Shadow); err != nil {
+				return nil, err
+			}
+		}
 
 		logs = append(logs, &log)
 	}
@@ -238,13 +376,16 @@ func (r *SQLiteRepository) MarkLogsSynced(ctx context.Context, ids []int64) erro
 func (r *SQLiteRepository) scanPolicy(row *sql.Row) (*AccessPolicy, error) {
 	var policy AccessPolicy
 	var conditionsJSON, permissionsJSON string
+	var effect, mode sql.NullString
 
 	err := row.Scan(&policy.ID, &policy.Name, &policy.Description, &policy.SubjectType,
-		&conditionsJSON, &permissionsJSON, &policy.Priority, &policy.Enabled,
-		&policy.CreatedAt, &policy.UpdatedAt)
+		&effect, &mode, &conditionsJSON, &permissionsJSON, &policy.Priority, &policy.Enabled,
+		&policy.Version, &policy.CreatedAt, &policy.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+	policy.Effect = effect.String
+	policy.Mode = mode.String
 
 	if err := json.Unmarshal([]byte(conditionsJSON), &policy.Conditions); err != nil {
 		return nil, err
@@ -262,13 +403,16 @@ func (r *SQLiteRepository) scanPolicies(rows *sql.Rows) ([]*AccessPolicy, error)
 	for rows.Next() {
 		var policy AccessPolicy
 		var conditionsJSON, permissionsJSON string
+		var effect, mode sql.NullString
 
 		err := rows.Scan(&policy.ID, &policy.Name, &policy.Description, &policy.SubjectType,
-			&conditionsJSON, &permissionsJSON, &policy.Priority, &policy.Enabled,
-			&policy.CreatedAt, &policy.UpdatedAt)
+			&effect, &mode, &conditionsJSON, &permissionsJSON, &policy.Priority, &policy.Enabled,
+			&policy.Version, &policy.CreatedAt, &policy.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
+		policy.Effect = effect.String
+		policy.Mode = mode.String
 
 		if err := json.Unmarshal([]byte(conditionsJSON), &policy.Conditions); err != nil {
 			return nil, err
@@ -294,3 +438,129 @@ func (r *SQLiteRepository) CleanOldLogs(ctx context.Context) (int64, error) {
 	}
 	return result.RowsAffected()
 }
+
+// SaveDeviceTrust 保存设备行为信任度
+func (r *SQLiteRepository) SaveDeviceTrust(ctx context.Context, trust *DeviceTrust) error {
+	signalsJSON, err := json.Marshal(trust.Signals)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO device_trust_scores
+		(device_id, score, penalty, firmware_penalty, firmware_version, signals, last_signal, last_signal_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, trust.DeviceID, trust.Score, trust.Penalty, trust.FirmwarePenalty, trust.FirmwareVersion,
+		string(signalsJSON), string(trust.LastSignal), trust.LastSignalAt, trust.UpdatedAt)
+	return err
+}
+
+// LoadDeviceTrust 加载所有设备行为信任度
+func (r *SQLiteRepository) LoadDeviceTrust(ctx context.Context) ([]*DeviceTrust, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT device_id, score, penalty, firmware_penalty, firmware_version, signals, last_signal, last_signal_at, updated_at
+		FROM device_trust_scores
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trusts []*DeviceTrust
+	for rows.Next() {
+		var trust DeviceTrust
+		var firmware, signalsJSON, lastSignal sql.NullString
+		var lastSignalAt sql.NullTime
+
+		err := rows.Scan(&trust.DeviceID, &trust.Score, &trust.Penalty, &trust.FirmwarePenalty,
+			&firmware, &signalsJSON, &lastSignal, &lastSignalAt, &trust.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		trust.FirmwareVersion = firmware.String
+		trust.LastSignal = TrustSignal(lastSignal.String)
+		if lastSignalAt.Valid {
+			trust.LastSignalAt = &lastSignalAt.Time
+		}
+		trust.Signals = map[TrustSignal]int64{}
+		if signalsJSON.Valid && signalsJSON.String != "" {
+			if err := json.Unmarshal([]byte(signalsJSON.String), &trust.Signals); err != nil {
+				return nil, err
+			}
+		}
+
+		trusts = append(trusts, &trust)
+	}
+
+	return trusts, rows.Err()
+}
+
+// AppendTrustEvent 记录信任度变化
+func (r *SQLiteRepository) AppendTrustEvent(ctx context.Context, event *TrustEvent) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO device_trust_events (device_id, signal, delta, score, timestamp, synced)
+		VALUES (?, ?, ?, ?, ?, 0)
+	`, event.DeviceID, string(event.Signal), event.Delta, event.Score, event.Timestamp)
+	if err != nil {
+		return err
+	}
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetTrustHistory 获取设备信任度变化记录（最新在前）
+func (r *SQLiteRepository) GetTrustHistory(ctx context.Context, deviceID string, limit int) ([]*TrustEvent, error) {
+	return r.queryTrustEvents(ctx, `
+		SELECT id, device_id, signal, delta, score, timestamp
+		FROM device_trust_events WHERE device_id = ? ORDER BY timestamp DESC, id DESC LIMIT ?
+	`, deviceID, limit)
+}
+
+// GetUnsyncedTrustEvents 获取未同步到Cloud的信任度变化记录
+func (r *SQLiteRepository) GetUnsyncedTrustEvents(ctx context.Context, limit int) ([]*TrustEvent, error) {
+	return r.queryTrustEvents(ctx, `
+		SELECT id, device_id, signal, delta, score, timestamp
+		FROM device_trust_events WHERE synced = 0 ORDER BY id ASC LIMIT ?
+	`, limit)
+}
+
+// MarkTrustEventsSynced 标记信任度变化记录已同步
+func (r *SQLiteRepository) MarkTrustEventsSynced(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := "UPDATE device_trust_events SET synced = 1 WHERE id IN ("
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		if i > 0 {
+			query += ","
+		}
+		query += "?"
+		args[i] = id
+	}
+	query += ")"
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *SQLiteRepository) queryTrustEvents(ctx context.Context, query string, args ...interface{}) ([]*TrustEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*TrustEvent{}
+	for rows.Next() {
+		var event TrustEvent
+		var signal string
+		if err := rows.Scan(&event.ID, &event.DeviceID, &signal, &event.Delta, &event.Score, &event.Timestamp); err != nil {
+			return nil, err
+		}
+		event.Signal = TrustSignal(signal)
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,295 @@
+package abac
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MQTT动作，评估时分别映射为 write / read 权限
+const (
+	ActionPublish   = "PUBLISH"
+	ActionSubscribe = "SUBSCRIBE"
+)
+
+// 策略缓存有效期，MQTT消息频率远高于HTTP请求，避免每条消息查询数据库
+const topicPolicyCacheTTL = 30 * time.Second
+
+// 访问日志队列长度，写入跟不上消息频率时丢弃新日志，不为每条消息创建协程
+const topicAccessLogQueueSize = 1024
+
+// Topic授权模式
+const (
+	TopicModeShadow  = "shadow"  // 仅评估并记录，不拒绝消息（默认）
+	TopicModeEnforce = "enforce" // 拒绝未授权的消息
+)
+
+// TopicResource 将MQTT Topic映射为ABAC资源
+//
+//	sensors/{device_id}/{sensor_type} -> mqtt/sensors
+//	alerts/{device_id}/...            -> mqtt/alerts
+//	devices/{device_id}/{kind}        -> mqtt/{kind}（status、heartbeat、commands等）
+//
+// 对应权限如 write:sensors、read:commands
+func TopicResource(topic string) string {
+	parts := strings.Split(strings.Trim(topic, "/"), "/")
+	category := parts[0]
+	if category == "devices" && len(parts) >= 3 && !isWildcard(parts[2]) {
+		category = parts[2]
+	}
+	if category == "" || isWildcard(category) {
+		category = "unknown"
+	}
+	return "mqtt/" + category
+}
+
+func isWildcard(s string) bool {
+	return s == "+" || s == "#"
+}
+
+// TopicDenialStats 设备MQTT访问评估及拒绝统计
+type TopicDenialStats struct {
+	DeviceID     string     `json:"device_id"`
+	Evaluated    int64      `json:"evaluated"`
+	Denied       int64      `json:"denied"`
+	LastResource string     `json:"last_resource,omitempty"` // 最近一次被拒绝的资源
+	LastReason   string     `json:"last_reason,omitempty"`
+	LastDeniedAt *time.Time `json:"last_denied_at,omitempty"`
+}
+
+// TopicAuthorizer 基于ABAC策略的MQTT Topic授权
+type TopicAuthorizer struct {
+	repo        Repository
+	evaluator   *Evaluator
+	contextProv ContextProvider // 储能柜状态（可选）
+	trust       *TrustTracker   // 行为信任度（可选）
+
+	enforce     bool            // 为false时只记录评估结果（shadow模式）
+
+	mu              sync.Mutex
+	policies        []*AccessPolicy
+	algorithm       string
+	hasDevicePolicy bool // 是否存在启用的设备策略
+	loadedAt        time.Time
+
+	statsMu sync.Mutex
+	stats   map[string]*TopicDenialStats // device_id -> 统计
+
+	logQueue    chan *AccessLog
+	droppedLogs atomic.Int64
+	stopCh      chan struct{}
+	done        chan struct{}
+}
+
+// NewTopicAuthorizer 创建Topic授权器（默认shadow模式），并启动访问日志写入协程
+func NewTopicAuthorizer(repo Repository) *TopicAuthorizer {
+	a := &TopicAuthorizer{
+		repo:      repo,
+		evaluator: NewEvaluator(),
+		stats:     make(map[string]*TopicDenialStats),
+		logQueue:  make(chan *AccessLog, topicAccessLogQueueSize),
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go a.logWorker()
+	return a
+}
+
+// SetMode 设置授权模式，只有enforce拒绝未授权的消息，其余按shadow处理
+func (a *TopicAuthorizer) SetMode(mode string) {
+	a.enforce = mode == TopicModeEnforce
+}
+
+// Enforcing 是否拒绝未授权的消息
+func (a *TopicAuthorizer) Enforcing() bool {
+	return a.enforce
+}
+
+// Close 停止访问日志写入协程，队列中剩余的日志写入后返回
+func (a *TopicAuthorizer) Close() {
+	close(a.stopCh)
+	<-a.done
+}
+
+// DroppedLogs 因队列已满被丢弃的访问日志数
+func (a *TopicAuthorizer) DroppedLogs() int64 {
+	return a.droppedLogs.Load()
+}
+
+// SetContextProvider 设置上下文属性中储能柜状态的提供者
+func (a *TopicAuthorizer) SetContextProvider(provider ContextProvider) {
+	a.contextProv = provider
+}
+
+// SetTrustTracker 设置行为信任度跟踪器：评估前合入trust_score，拒绝时记录信号
+func (a *TopicAuthorizer) SetTrustTracker(tracker *TrustTracker) {
+	a.trust = tracker
+}
+
+// Authorize 评估已认证设备对Topic的发布/订阅权限，并异步记录访问日志，enforce模式下拒绝时计入设备信任度
+// 没有任何启用的设备策略时不评估（策略尚未从Cloud同步），直接允许
+// sourceIP 为客户端地址，未知时传空字符串
+func (a *TopicAuthorizer) Authorize(ctx context.Context, attrs *DeviceAttributes, topic, action, sourceIP string) *EvaluateResponse {
+	return a.authorize(ctx, attrs, topic, action, sourceIP, true)
+}
+
+// AuthorizeUnverified 评估身份未经认证（取自消息体）的设备访问
+// 任何人都可以冒用设备ID，拒绝结果不计入该设备的信任度
+func (a *TopicAuthorizer) AuthorizeUnverified(ctx context.Context, attrs *DeviceAttributes, topic, action string) *EvaluateResponse {
+	return a.authorize(ctx, attrs, topic, action, "", false)
+}
+
+func (a *TopicAuthorizer) authorize(ctx context.Context, attrs *DeviceAttributes, topic, action, sourceIP string, verified bool) *EvaluateResponse {
+	resource := TopicResource(topic)
+
+	policies, algorithm, hasDevicePolicy, err := a.loadPolicies(ctx)
+	if err != nil {
+		return &EvaluateResponse{Reason: "加载策略失败: " + err.Error()}
+	}
+	if !hasDevicePolicy {
+		return &EvaluateResponse{Allowed: true, Reason: "无设备访问策略，未启用Topic授权"}
+	}
+
+	if a.trust != nil {
+		a.trust.Apply(attrs)
+	}
+	resp := a.evaluator.Evaluate(&EvaluateRequest{
+		SubjectAttrs: attrs,
+		Resource:     resource,
+		Action:       action,
+		Policies:     policies,
+		Algorithm:    algorithm,
+		Context:      buildContext(ctx, a.contextProv, sourceIP),
+	})
+	if !resp.Allowed && verified && a.enforce && a.trust != nil {
+		a.trust.Observe(attrs.DeviceID, SignalAccessDenied)
+	}
+	a.recordStats(attrs.DeviceID, resource, resp)
+	a.logAccess(attrs, resource, action, resp)
+	return resp
+}
+
+// DenialStats 返回各设备的MQTT访问统计（按拒绝次数降序）
+func (a *TopicAuthorizer) DenialStats() []*TopicDenialStats {
+	a.statsMu.Lock()
+	list := make([]*TopicDenialStats, 0, len(a.stats))
+	for _, stat := range a.stats {
+		c := *stat
+		list = append(list, &c)
+	}
+	a.statsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Denied != list[j].Denied {
+			return list[i].Denied > list[j].Denied
+		}
+		return list[i].DeviceID < list[j].DeviceID
+	})
+	return list
+}
+
+func (a *TopicAuthorizer) recordStats(deviceID, resource string, resp *EvaluateResponse) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+
+	stat, ok := a.stats[deviceID]
+	if !ok {
+		stat = &TopicDenialStats{DeviceID: deviceID}
+		a.stats[deviceID] = stat
+	}
+	stat.Evaluated++
+	if !resp.Allowed {
+		now := time.Now()
+		stat.Denied++
+		stat.LastResource = resource
+		stat.LastReason = resp.Reason
+		stat.LastDeniedAt = &now
+	}
+}
+
+func (a *TopicAuthorizer) loadPolicies(ctx context.Context) ([]*AccessPolicy, string, bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.policies != nil && time.Since(a.loadedAt) < topicPolicyCacheTTL {
+		return a.policies, a.algorithm, a.hasDevicePolicy, nil
+	}
+
+	policies, err := a.repo.GetEnabledPolicies(ctx)
+	if err != nil {
+		return nil, "", false, err
+	}
+	algorithm, err := a.repo.GetCombiningAlgorithm(ctx)
+	if err != nil {
+		algorithm = DefaultCombiningAlgorithm
+	}
+	a.hasDevicePolicy = false
+	for _, policy := range policies {
+		if policy.Enabled && policy.SubjectType == string(SubjectTypeDevice) {
+			a.hasDevicePolicy = true
+			break
+		}
+	}
+	a.policies = policies
+	a.algorithm = algorithm
+	a.loadedAt = time.Now()
+	return policies, algorithm, a.hasDevicePolicy, nil
+}
+
+// logAccess 将访问日志放入写入队列，队列已满时丢弃
+func (a *TopicAuthorizer) logAccess(attrs *DeviceAttributes, resource, action string, resp *EvaluateResponse) {
+	attrsJSON, _ := json.Marshal(attrs)
+
+	log := &AccessLog{
+		SubjectType: string(SubjectTypeDevice),
+		SubjectID:   attrs.DeviceID,
+		Resource:    resource,
+		Action:      action,
+		Allowed:     resp.Allowed,
+		TrustScore:  &resp.TrustScore,
+		Reason:      resp.Reason,
+		Timestamp:   time.Now(),
+		Attributes:  attrsJSON,
+		Shadow:      resp.Shadow,
+		Synced:      false,
+	}
+	if resp.MatchedPolicy != nil {
+		log.PolicyID = &resp.MatchedPolicy.ID
+	}
+
+	select {
+	case a.logQueue <- log:
+	default:
+		a.droppedLogs.Add(1)
+	}
+}
+
+// logWorker 逐条写入访问日志
+func (a *TopicAuthorizer) logWorker() {
+	defer close(a.done)
+
+	write := func(log *AccessLog) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		a.repo.LogAccess(ctx, log)
+	}
+	for {
+		select {
+		case log := <-a.logQueue:
+			write(log)
+		case <-a.stopCh:
+			for {
+				select {
+				case log := <-a.logQueue:
+					write(log)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package abac
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TrustSignal 影响设备信任度的行为信号
+type TrustSignal string
+
+const (
+	SignalAuthFailure     TrustSignal = "auth_failure"     // 已认证连接上冒用其他设备身份
+	SignalAccessDenied    TrustSignal = "access_denied"    // ABAC拒绝访问
+	SignalQualityAnomaly  TrustSignal = "quality_anomaly"  // 数据质量异常
+	SignalHeartbeatMissed TrustSignal = "heartbeat_missed" // 心跳延迟或离线
+	SignalFirmware        TrustSignal = "firmware"         // 固件版本变化（低于最低版本时扣分）
+)
+
+// 各行为信号的扣分，扣分随时间按半衰期衰减
+var trustSignalPenalties = map[TrustSignal]float64{
+	SignalAuthFailure:     15,
+	SignalAccessDenied:    5,
+	SignalQualityAnomaly:  8,
+	SignalHeartbeatMissed: 10,
+}
+
+// QualityAnomalyThreshold 数据质量低于该值视为异常（与静态信任度评估一致）
+const QualityAnomalyThreshold = 60
+
+// TrustConfig 行为信任度配置
+type TrustConfig struct {
+	HalfLife           time.Duration // 行为扣分衰减半衰期
+	SignalCooldown     time.Duration // 同一设备同一信号的最小计分间隔，避免高频消息刷分
+	MinFirmwareVersion string        // 最低固件版本，为空不检查
+	FirmwarePenalty    float64       // 固件低于最低版本时的固定扣分
+}
+
+// DefaultTrustConfig 默认配置
+func DefaultTrustConfig() TrustConfig {
+	return TrustConfig{
+		HalfLife:        6 * time.Hour,
+		SignalCooldown:  time.Minute,
+		FirmwarePenalty: 20,
+	}
+}
+
+// DeviceTrust 设备行为信任度
+type DeviceTrust struct {
+	DeviceID        string                `json:"device_id"`
+	Score           float64               `json:"score"`            // 行为信任度 (0-100)
+	Penalty         float64               `json:"penalty"`          // 行为扣分（随时间衰减）
+	FirmwarePenalty float64               `json:"firmware_penalty"` // 固件扣分
+	FirmwareVersion string                `json:"firmware_version,omitempty"`
+	Signals         map[TrustSignal]int64 `json:"signals"` // 各信号累计次数
+	LastSignal      TrustSignal           `json:"last_signal,omitempty"`
+	LastSignalAt    *time.Time            `json:"last_signal_at,omitempty"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+// TrustEvent 信任度变化记录
+type TrustEvent struct {
+	ID        int64       `json:"id"`
+	DeviceID  string      `json:"device_id"`
+	Signal    TrustSignal `json:"signal"`
+	Delta     float64     `json:"delta"` // 本次分数变化（负数为扣分）
+	Score     float64     `json:"score"` // 变化后的行为信任度
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// TrustReport 上报Cloud的设备信任度
+type TrustReport struct {
+	CabinetID string         `json:"cabinet_id"`
+	Devices   []*DeviceTrust `json:"devices"`
+	Events    []*TrustEvent  `json:"events"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// TrustStore 行为信任度存储接口
+type TrustStore interface {
+	SaveDeviceTrust(ctx context.Context, trust *DeviceTrust) error
+	LoadDeviceTrust(ctx context.Context) ([]*DeviceTrust, error)
+	AppendTrustEvent(ctx context.Context, event *TrustEvent) error
+	GetTrustHistory(ctx context.Context, deviceID string, limit int) ([]*TrustEvent, error)
+	GetUnsyncedTrustEvents(ctx context.Context, limit int) ([]*TrustEvent, error)
+	MarkTrustEventsSynced(ctx context.Context, ids []int64) error
+}
+
+// FirmwareSource 设备固件版本来源
+type FirmwareSource interface {
+	FirmwareVersion(deviceID string) string
+}
+
+// TrustTracker 基于设备行为的动态信任度
+// 认证失败、ABAC拒绝、数据质量异常、心跳异常会扣分，扣分按半衰期衰减（即随时间恢复）；
+// 固件低于最低版本时固定扣分，升级后恢复
+type TrustTracker struct {
+	store       TrustStore
+	cabinetID   string
+	cfg         TrustConfig
+	scorer      *TrustScorer
+	firmware    FirmwareSource
+	publishFunc func(topic string, payload []byte) error
+
+	mu         sync.Mutex
+	devices    map[string]*DeviceTrust
+	lastSignal map[string]time.Time // device_id/signal -> 上次计分时间
+	now        func() time.Time
+}
+
+// NewTrustTracker 创建行为信任度跟踪器，并从存储加载已有分数
+func NewTrustTracker(store TrustStore, cabinetID string, cfg TrustConfig) (*TrustTracker, error) {
+	defaults := DefaultTrustConfig()
+	if cfg.HalfLife <= 0 {
+		cfg.HalfLife = defaults.HalfLife
+	}
+	if cfg.SignalCooldown <= 0 {
+		cfg.SignalCooldown = defaults.SignalCooldown
+	}
+	if cfg.FirmwarePenalty <= 0 {
+		cfg.FirmwarePenalty = defaults.FirmwarePenalty
+	}
+
+	t := &TrustTracker{
+		store:      store,
+		cabinetID:  cabinetID,
+		cfg:        cfg,
+		scorer:     NewTrustScorer(),
+		devices:    make(map[string]*DeviceTrust),
+		lastSignal: make(map[string]time.Time),
+		now:        time.Now,
+	}
+	if store != nil {
+		trusts, err := store.LoadDeviceTrust(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, trust := range trusts {
+			t.devices[trust.DeviceID] = trust
+		}
+	}
+	return t, nil
+}
+
+// SetFirmwareSource 设置设备固件版本来源
+func (t *TrustTracker) SetFirmwareSource(source FirmwareSource) {
+	t.firmware = source
+}
+
+// SetPublishFunc 设置MQTT发布函数（用于上报Cloud）
+func (t *TrustTracker) SetPublishFunc(publishFunc func(string, []byte) error) {
+	t.publishFunc = publishFunc
+}
+
+// Observe 记录一次行为信号并更新信任度
+func (t *TrustTracker) Observe(deviceID string, signal TrustSignal) {
+	penalty, ok := trustSignalPenalties[signal]
+	if deviceID == "" || !ok {
+		return
+	}
+	firmware := t.firmwareVersion(deviceID)
+
+	t.mu.Lock()
+	now := t.now()
+	key := deviceID + "/" + string(signal)
+	if last, ok := t.lastSignal[key]; ok && now.Sub(last) < t.cfg.SignalCooldown {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSignal[key] = now
+
+	trust, firmwareEvent := t.refreshLocked(deviceID, firmware, now)
+	before := trust.Score
+	trust.Penalty = math.Min(100, trust.Penalty+penalty)
+	trust.Signals[signal]++
+	trust.LastSignal = signal
+	trust.LastSignalAt = &now
+	trust.Score = trustScore(trust)
+	event := &TrustEvent{DeviceID: deviceID, Signal: signal, Delta: trust.Score - before, Score: trust.Score, Timestamp: now}
+	snapshot := copyTrust(trust)
+	t.mu.Unlock()
+
+	if firmwareEvent != nil {
+		t.persist(snapshot, firmwareEvent)
+	}
+	t.persist(snapshot, event)
+}
+
+// Score 返回设备当前行为信任度（未知设备为100，扣除固件扣分）
+func (t *TrustTracker) Score(deviceID string) float64 {
+	return t.Get(deviceID).Score
+}
+
+// Get 返回设备当前行为信任度详情
+func (t *TrustTracker) Get(deviceID string) *DeviceTrust {
+	firmware := t.firmwareVersion(deviceID)
+
+	t.mu.Lock()
+	trust, firmwareEvent := t.refreshLocked(deviceID, firmware, t.now())
+	snapshot := copyTrust(trust)
+	t.mu.Unlock()
+
+	if firmwareEvent != nil {
+		t.persist(snapshot, firmwareEvent)
+	}
+	return snapshot
+}
+
+// List 返回所有已跟踪设备的行为信任度（按分数升序）
+func (t *TrustTracker) List() []*DeviceTrust {
+	t.mu.Lock()
+	ids := make([]string, 0, len(t.devices))
+	for id := range t.devices {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+
+	trusts := make([]*DeviceTrust, 0, len(ids))
+	for _, id := range ids {
+		trusts = append(trusts, t.Get(id))
+	}
+	sort.Slice(trusts, func(i, j int) bool {
+		if trusts[i].Score != trusts[j].Score {
+			return trusts[i].Score < trusts[j].Score
+		}
+		return trusts[i].DeviceID < trusts[j].DeviceID
+	})
+	return trusts
+}
+
+// History 返回设备信任度变化记录
+func (t *TrustTracker) History(ctx context.Context, deviceID string, limit int) ([]*TrustEvent, error) {
+	if t.store == nil {
+		return []*TrustEvent{}, nil
+	}
+	return t.store.GetTrustHistory(ctx, deviceID, limit)
+}
+
+// Apply 将行为信任度合入设备属性的trust_score
+// 静态评估（状态、数据质量、读取时间）减去行为扣分，供ABAC条件使用
+func (t *TrustTracker) Apply(attrs *DeviceAttributes) {
+	static := t.scorer.CalculateDeviceTrustScore(attrs)
+	behavior := t.Score(attrs.DeviceID)
+	attrs.TrustScore = math.Max(0, math.Min(100, static-(100-behavior)))
+}
+
+// refreshLocked 按半衰期衰减行为扣分并更新固件扣分（调用方持有锁）
+// 固件扣分发生变化时返回对应的变化记录
+func (t *TrustTracker) refreshLocked(deviceID, firmware string, now time.Time) (*DeviceTrust, *TrustEvent) {
+	trust, ok := t.devices[deviceID]
+	if !ok {
+		trust = &DeviceTrust{DeviceID: deviceID, Score: 100, Signals: map[TrustSignal]int64{}, UpdatedAt: now}
+		t.devices[deviceID] = trust
+	}
+	if trust.Signals == nil {
+		trust.Signals = map[TrustSignal]int64{}
+	}
+
+	if elapsed := now.Sub(trust.UpdatedAt); elapsed > 0 && trust.Penalty > 0 {
+		trust.Penalty *= math.Pow(0.5, float64(elapsed)/float64(t.cfg.HalfLife))
+		if trust.Penalty < 0.01 {
+			trust.Penalty = 0
+		}
+	}
+	trust.UpdatedAt = now
+
+	if firmware != "" {
+		trust.FirmwareVersion = firmware
+	}
+	previous := trust.FirmwarePenalty
+	trust.FirmwarePenalty = 0
+	if t.cfg.MinFirmwareVersion != "" && trust.FirmwareVersion != "" &&
+		CompareVersions(trust.FirmwareVersion, t.cfg.MinFirmwareVersion) < 0 {
+		trust.FirmwarePenalty = t.cfg.FirmwarePenalty
+	}
+
+	trust.Score = trustScore(trust)
+	if trust.FirmwarePenalty == previous {
+		return trust, nil
+	}
+	return trust, &TrustEvent{
+		DeviceID:  deviceID,
+		Signal:    SignalFirmware,
+		Delta:     previous - trust.FirmwarePenalty,
+		Score:     trust.Score,
+		Timestamp: now,
+	}
+}
+
+func (t *TrustTracker) firmwareVersion(deviceID string) string {
+	if t.firmware == nil {
+		return ""
+	}
+	return t.firmware.FirmwareVersion(deviceID)
+}
+
+func (t *TrustTracker) persist(trust *DeviceTrust, event *TrustEvent) {
+	if t.store == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.store.SaveDeviceTrust(ctx, trust); err != nil {
+		log.Printf("[ABAC] 保存设备信任度失败: %v", err)
+	}
+	if event != nil {
+		if err := t.store.AppendTrustEvent(ctx, event); err != nil {
+			log.Printf("[ABAC] 记录信任度变化失败: %v", err)
+		}
+	}
+}
+
+// SyncToCloud 保存衰减后的分数，并将分数及未同步的变化记录上报Cloud
+func (t *TrustTracker) SyncToCloud(ctx context.Context) error {
+	devices := t.List()
+	for _, trust := range devices {
+		t.persist(trust, nil)
+	}
+	if t.publishFunc == nil || t.store == nil || len(devices) == 0 {
+		return nil
+	}
+
+	events, err := t.store.GetUnsyncedTrustEvents(ctx, 500)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(TrustReport{
+		CabinetID: t.cabinetID,
+		Devices:   devices,
+		Events:    events,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	topic := "edge/cabinet/" + t.cabinetID + "/abac/trust"
+	if err := t.publishFunc(topic, data); err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		ids := make([]int64, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return t.store.MarkTrustEventsSynced(ctx, ids)
+	}
+	return nil
+}
+
+// StartPeriodicSync 定期上报设备信任度
+func (t *TrustTracker) StartPeriodicSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.SyncToCloud(ctx); err != nil {
+				log.Printf("[ABAC] 设备信任度上报失败: %v", err)
+			}
+		}
+	}
+}
+
+func trustScore(trust *DeviceTrust) float64 {
+	score := 100 - trust.Penalty - trust.FirmwarePenalty
+	return math.Round(math.Max(0, math.Min(100, score))*100) / 100
+}
+
+func copyTrust(trust *DeviceTrust) *DeviceTrust {
+	c := *trust
+	c.Signals = make(map[TrustSignal]int64, len(trust.Signals))
+	for k, v := range trust.Signals {
+		c.Signals[k] = v
+	}
+	return &c
+}
+
+// CompareVersions 比较点分版本号（忽略前缀v），返回-1、0、1
+func CompareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(strings.ToLower(a), "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(strings.ToLower(b), "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(leadingDigits(pa[i]))
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(leadingDigits(pb[i]))
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func leadingDigits(s string) string {
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return s[:i]
+		}
+	}
+	return s
+}
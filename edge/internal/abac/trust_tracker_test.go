@@ -0,0 +1,70 @@
+package abac
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type staticFirmware map[string]string
+
+func (f staticFirmware) FirmwareVersion(deviceID string) string {
+	return f[deviceID]
+}
+
+func TestTrustTrackerDecayAndCooldown(t *testing.T) {
+	tracker, err := NewTrustTracker(nil, "cabinet-1", TrustConfig{HalfLife: time.Hour, SignalCooldown: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Observe("dev-1", SignalAuthFailure)
+	tracker.Observe("dev-1", SignalAuthFailure) // 冷却期内不重复扣分
+	if score := tracker.Score("dev-1"); score != 85 {
+		t.Fatalf("score after auth failure = %v, want 85", score)
+	}
+
+	now = now.Add(time.Hour)
+	if score := tracker.Score("dev-1"); math.Abs(score-92.5) > 0.01 {
+		t.Fatalf("score after one half-life = %v, want 92.5", score)
+	}
+
+	tracker.Observe("dev-1", SignalAccessDenied)
+	if score := tracker.Score("dev-1"); math.Abs(score-87.5) > 0.01 {
+		t.Fatalf("score after access denied = %v, want 87.5", score)
+	}
+	if got := tracker.Get("dev-1").Signals[SignalAuthFailure]; got != 1 {
+		t.Fatalf("auth_failure count = %d, want 1", got)
+	}
+}
+
+func TestTrustTrackerFirmwareAndApply(t *testing.T) {
+	tracker, err := NewTrustTracker(nil, "cabinet-1", TrustConfig{MinFirmwareVersion: "v2.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	firmware := staticFirmware{"old": "2.0.9", "new": "v2.10"}
+	tracker.SetFirmwareSource(firmware)
+
+	if score := tracker.Score("old"); score != 80 {
+		t.Fatalf("outdated firmware score = %v, want 80", score)
+	}
+	if score := tracker.Score("new"); score != 100 {
+		t.Fatalf("current firmware score = %v, want 100", score)
+	}
+
+	firmware["old"] = "2.1.0"
+	if score := tracker.Score("old"); score != 100 {
+		t.Fatalf("upgraded firmware score = %v, want 100", score)
+	}
+
+	tracker.Observe("new", SignalHeartbeatMissed)
+	attrs := &DeviceAttributes{DeviceID: "new", Status: "active", Quality: 100, LastReadingAt: time.Now()}
+	static := tracker.scorer.CalculateDeviceTrustScore(attrs)
+	tracker.Apply(attrs)
+	if want := math.Max(0, static-10); attrs.TrustScore != want {
+		t.Fatalf("applied trust_score = %v, want %v", attrs.TrustScore, want)
+	}
+}
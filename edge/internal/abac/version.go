@@ -0,0 +1,84 @@
+package abac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// PolicyVersion 单条策略的版本和内容哈希
+type PolicyVersion struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+// PolicyHeartbeat Edge定期上报的策略集摘要，Cloud据此检测策略漂移
+type PolicyHeartbeat struct {
+	CabinetID          string          `json:"cabinet_id"`
+	Digest             string          `json:"digest"`
+	PolicyCount        int             `json:"policy_count"`
+	Policies           []PolicyVersion `json:"policies"`
+	CombiningAlgorithm string          `json:"combining_algorithm"`
+	Timestamp          time.Time       `json:"timestamp"`
+}
+
+// ContentHash 计算策略内容哈希（不含版本和时间戳），Cloud与Edge使用相同算法
+func (p *AccessPolicy) ContentHash() string {
+	conditions := p.Conditions
+	if conditions == nil {
+		conditions = []PolicyCondition{}
+	}
+	permissions := p.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	data, _ := json.Marshal(struct {
+		ID          string            `json:"id"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		SubjectType string            `json:"subject_type"`
+		Effect      string            `json:"effect"`
+		Mode        string            `json:"mode,omitempty"` // enforce不计入，保持旧策略哈希不变
+		Conditions  []PolicyCondition `json:"conditions"`
+		Permissions []string          `json:"permissions"`
+		Priority    int               `json:"priority"`
+		Enabled     bool              `json:"enabled"`
+	}{p.ID, p.Name, p.Description, p.SubjectType, p.GetEffect(), shadowMode(p), conditions, permissions, p.Priority, p.Enabled})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func shadowMode(p *AccessPolicy) string {
+	if p.IsShadow() {
+		return ModeShadow
+	}
+	return ""
+}
+
+// PolicyVersions 返回策略集的版本列表（按ID排序）
+func PolicyVersions(policies []*AccessPolicy) []PolicyVersion {
+	versions := make([]PolicyVersion, 0, len(policies))
+	for _, p := range policies {
+		versions = append(versions, PolicyVersion{ID: p.ID, Version: p.Version, Hash: p.ContentHash()})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID < versions[j].ID })
+	return versions
+}
+
+// PolicySetDigest 计算策略集摘要，只取决于各策略ID和内容哈希
+func PolicySetDigest(versions []PolicyVersion) string {
+	sorted := make([]PolicyVersion, len(versions))
+	copy(sorted, versions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	h := sha256.New()
+	for _, v := range sorted {
+		h.Write([]byte(v.ID + ":" + v.Hash + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package abac
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPolicyDigestVectors 与Cloud端共用同一组向量，保证两端内容哈希和策略集摘要一致
+func TestPolicyDigestVectors(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "abac", "policy_digest_vectors.json"))
+	if err != nil {
+		t.Skipf("shared ABAC vectors not available: %v", err)
+	}
+	var vectors struct {
+		Policies    []*AccessPolicy   `json:"policies"`
+		Hashes      map[string]string `json:"hashes"`
+		Digest      string            `json:"digest"`
+		EmptyDigest string            `json:"empty_digest"`
+	}
+	if err := json.Unmarshal(raw, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range vectors.Policies {
+		if got := p.ContentHash(); got != vectors.Hashes[p.ID] {
+			t.Errorf("%s: hash %s, want %s", p.ID, got, vectors.Hashes[p.ID])
+		}
+		// 版本号不计入内容哈希
+		bumped := *p
+		bumped.Version++
+		if bumped.ContentHash() != vectors.Hashes[p.ID] {
+			t.Errorf("%s: version must not affect hash", p.ID)
+		}
+	}
+
+	if got := PolicySetDigest(PolicyVersions(vectors.Policies)); got != vectors.Digest {
+		t.Errorf("digest %s, want %s", got, vectors.Digest)
+	}
+	// 摘要与策略顺序无关
+	reversed := make([]PolicyVersion, 0, len(vectors.Policies))
+	for i := len(vectors.Policies) - 1; i >= 0; i-- {
+		p := vectors.Policies[i]
+		reversed = append(reversed, PolicyVersion{ID: p.ID, Version: p.Version, Hash: p.ContentHash()})
+	}
+	if got := PolicySetDigest(reversed); got != vectors.Digest {
+		t.Errorf("digest depends on order: %s", got)
+	}
+	if got := PolicySetDigest(nil); got != vectors.EmptyDigest {
+		t.Errorf("empty digest %s, want %s", got, vectors.EmptyDigest)
+	}
+}
@@ -11,6 +11,8 @@ import (
 // ABACHandler ABAC策略处理器(只读API)
 type ABACHandler struct {
 	repo   abac.Repository
+	trust  *abac.TrustTracker
+	topics *abac.TopicAuthorizer
 	logger *zap.Logger
 }
 
@@ -93,6 +95,8 @@ func (h *ABACHandler) GetPolicyStats(c *gin.Context) {
 		"total_policies":   len(policies),
 		"enabled_policies": 0,
 		"device_policies":  0,
+		"deny_policies":    0,
+		"shadow_policies":  0,
 	}
 
 	for _, p := range policies {
@@ -102,6 +106,16 @@ func (h *ABACHandler) GetPolicyStats(c *gin.Context) {
 		if p.SubjectType == "device" {
 			stats["device_policies"] = stats["device_policies"].(int) + 1
 		}
+		if p.GetEffect() == abac.EffectDeny {
+			stats["deny_policies"] = stats["deny_policies"].(int) + 1
+		}
+		if p.IsShadow() {
+			stats["shadow_policies"] = stats["shadow_policies"].(int) + 1
+		}
+	}
+
+	if algorithm, err := h.repo.GetCombiningAlgorithm(c.Request.Context()); err == nil {
+		stats["combining_algorithm"] = algorithm
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -110,3 +124,92 @@ func (h *ABACHandler) GetPolicyStats(c *gin.Context) {
 		"data":    stats,
 	})
 }
+
+// SetTrustTracker 设置设备信任度跟踪器(可选)
+func (h *ABACHandler) SetTrustTracker(tracker *abac.TrustTracker) {
+	h.trust = tracker
+}
+
+// ListDeviceTrust 查询设备动态信任度
+func (h *ABACHandler) ListDeviceTrust(c *gin.Context) {
+	if h.trust == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "TRUST_DISABLED",
+			"message": "设备信任度未启用",
+		})
+		return
+	}
+
+	devices := h.trust.List()
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data":    devices,
+		"count":   len(devices),
+	})
+}
+
+// GetDeviceTrust 查询单个设备的信任度及变化历史
+func (h *ABACHandler) GetDeviceTrust(c *gin.Context) {
+	if h.trust == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "TRUST_DISABLED",
+			"message": "设备信任度未启用",
+		})
+		return
+	}
+
+	deviceID := c.Param("device_id")
+	history, err := h.trust.History(c.Request.Context(), deviceID, 50)
+	if err != nil {
+		h.logger.Error("查询信任度历史失败",
+			zap.String("device_id", deviceID),
+			zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "QUERY_FAILED",
+			"message": "查询失败",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    0,
+		"message": "success",
+		"data": gin.H{
+			"trust":   h.trust.Get(deviceID),
+			"history": history,
+		},
+	})
+}
+
+// SetTopicAuthorizer 设置MQTT Topic授权器(可选)
+func (h *ABACHandler) SetTopicAuthorizer(authorizer *abac.TopicAuthorizer) {
+	h.topics = authorizer
+}
+
+// GetMQTTDenialStats 查询各设备MQTT消息的ABAC评估及拒绝统计
+func (h *ABACHandler) GetMQTTDenialStats(c *gin.Context) {
+	if h.topics == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "MQTT_ABAC_DISABLED",
+			"message": "MQTT消息ABAC评估未启用",
+		})
+		return
+	}
+
+	stats := h.topics.DenialStats()
+	var denied int64
+	for _, stat := range stats {
+		denied += stat.Denied
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":         0,
+		"message":      "success",
+		"data":         stats,
+		"count":        len(stats),
+		"denied":       denied,
+		"enforced":     h.topics.Enforcing(),
+		"dropped_logs": h.topics.DroppedLogs(),
+	})
+}
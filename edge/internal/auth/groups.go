@@ -0,0 +1,509 @@
+/*
+ * 群组成员认证
+ * 共享诊断工具等登记为授权群组的成员，认证时只证明属于该群组，
+ * Edge无法得知具体是哪个成员；群组会话的权限由ABAC按群组授予
+ */
+package auth
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SyncGroup 按Cloud下发的完整成员列表同步授权群组
+// 群组和成员只由Cloud管理，Edge本地不开放写入；已有成员保持叶子位置，新成员追加在后，
+// 有成员被移除时撤销该群组的全部会话（群组会话无法对应到具体成员），其余成员需重新证明
+func (s *Service) SyncGroup(req *models.SyncGroupRequest) (*models.AuthGroup, error) {
+	if len(req.GroupID) == 0 || len(req.GroupID) > 64 {
+		return nil, fmt.Errorf("GROUP_INVALID: group id must be 1-64 characters")
+	}
+	if len(req.Members) > zkp.MaxGroupMembers {
+		return nil, fmt.Errorf("GROUP_FULL: group %s cannot have more than %d members", req.GroupID, zkp.MaxGroupMembers)
+	}
+
+	labels := make(map[string]string, len(req.Members))
+	order := make([]string, 0, len(req.Members))
+	for _, m := range req.Members {
+		leaf, err := zkp.NormalizeMemberLeaf(m.Commitment)
+		if err != nil {
+			return nil, fmt.Errorf("MEMBER_INVALID: %w", err)
+		}
+		if _, ok := labels[leaf]; ok {
+			return nil, fmt.Errorf("MEMBER_EXISTS: commitment listed more than once")
+		}
+		labels[leaf] = m.Label
+		order = append(order, leaf)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = req.GroupID
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		INSERT INTO auth_groups (group_id, name, description, root, member_count, created_at, updated_at)
+		VALUES (?, ?, ?, '', 0, ?, ?)
+		ON CONFLICT(group_id) DO UPDATE SET name = excluded.name, description = excluded.description
+	`, req.GroupID, name, req.Description, now, now); err != nil {
+		return nil, fmt.Errorf("failed to sync group: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT commitment FROM auth_group_members WHERE group_id = ?`, req.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var leaf string
+		if err := rows.Scan(&leaf); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		existing[leaf] = true
+	}
+	rows.Close()
+
+	removed := 0
+	for leaf := range existing {
+		if _, ok := labels[leaf]; ok {
+			continue
+		}
+		if _, err := tx.Exec(`
+			DELETE FROM auth_group_members WHERE group_id = ? AND commitment = ?
+		`, req.GroupID, leaf); err != nil {
+			return nil, fmt.Errorf("failed to remove group member: %w", err)
+		}
+		removed++
+	}
+	added := 0
+	for _, leaf := range order {
+		if existing[leaf] {
+			if _, err := tx.Exec(`
+				UPDATE auth_group_members SET label = ? WHERE group_id = ? AND commitment = ?
+			`, labels[leaf], req.GroupID, leaf); err != nil {
+				return nil, fmt.Errorf("failed to update group member: %w", err)
+			}
+			continue
+		}
+		// 按列表顺序递增登记时间，保证新成员的叶子顺序与Cloud一致
+		if _, err := tx.Exec(`
+			INSERT INTO auth_group_members (group_id, commitment, label, added_at) VALUES (?, ?, ?, ?)
+		`, req.GroupID, leaf, labels[leaf], now.Add(time.Duration(added)*time.Microsecond)); err != nil {
+			return nil, fmt.Errorf("failed to add group member: %w", err)
+		}
+		added++
+	}
+	if err := s.updateGroupRootTx(tx, req.GroupID); err != nil {
+		return nil, err
+	}
+
+	var revoked int64
+	if removed > 0 {
+		result, err := tx.Exec(`
+			UPDATE group_sessions SET revoked_at = ? WHERE group_id = ? AND revoked_at IS NULL
+		`, now, req.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to revoke group sessions: %w", err)
+		}
+		revoked, _ = result.RowsAffected()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Auth group synced",
+		zap.String("group_id", req.GroupID),
+		zap.Int("added", added),
+		zap.Int("removed", removed),
+		zap.Int64("sessions_revoked", revoked))
+	return s.GetGroup(req.GroupID)
+}
+
+// ListGroups 列出授权群组
+func (s *Service) ListGroups() ([]models.AuthGroup, error) {
+	rows, err := s.db.Query(`
+		SELECT group_id, name, description, root, member_count, created_at, updated_at
+		FROM auth_groups ORDER BY group_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := make([]models.AuthGroup, 0)
+	for rows.Next() {
+		var g models.AuthGroup
+		if err := rows.Scan(&g.GroupID, &g.Name, &g.Description, &g.Root, &g.MemberCount, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// GetGroup 查询授权群组
+func (s *Service) GetGroup(groupID string) (*models.AuthGroup, error) {
+	var g models.AuthGroup
+	err := s.db.QueryRow(`
+		SELECT group_id, name, description, root, member_count, created_at, updated_at
+		FROM auth_groups WHERE group_id = ?
+	`, groupID).Scan(&g.GroupID, &g.Name, &g.Description, &g.Root, &g.MemberCount, &g.CreatedAt, &g.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("GROUP_NOT_FOUND: group %s not found", groupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group: %w", err)
+	}
+	return &g, nil
+}
+
+// DeleteGroup 删除授权群组（Cloud命令），同时撤销该群组的所有会话
+func (s *Service) DeleteGroup(groupID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM auth_groups WHERE group_id = ?`, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("GROUP_NOT_FOUND: group %s not found", groupID)
+	}
+	if _, err := tx.Exec(`DELETE FROM auth_group_members WHERE group_id = ?`, groupID); err != nil {
+		return fmt.Errorf("failed to delete group members: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE group_sessions SET revoked_at = ? WHERE group_id = ? AND revoked_at IS NULL
+	`, time.Now(), groupID); err != nil {
+		return fmt.Errorf("failed to revoke group sessions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	s.logger.Info("Auth group deleted", zap.String("group_id", groupID))
+	return nil
+}
+
+// ListGroupMembers 按叶子顺序列出群组成员承诺
+// 成员承诺是公开信息，成员据此在本地计算自己的Merkle路径，Edge不需要知道是谁在查询
+func (s *Service) ListGroupMembers(groupID string) ([]models.GroupMember, error) {
+	if _, err := s.GetGroup(groupID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT commitment, label, added_at FROM auth_group_members
+		WHERE group_id = ? ORDER BY added_at, commitment
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query group members: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]models.GroupMember, 0)
+	for rows.Next() {
+		m := models.GroupMember{Index: len(members)}
+		if err := rows.Scan(&m.Commitment, &m.Label, &m.AddedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// updateGroupRootTx 按当前成员重新计算Merkle树根
+func (s *Service) updateGroupRootTx(tx *sql.Tx, groupID string) error {
+	rows, err := tx.Query(`
+		SELECT commitment FROM auth_group_members
+		WHERE group_id = ? ORDER BY added_at, commitment
+	`, groupID)
+	if err != nil {
+		return fmt.Errorf("failed to query group members: %w", err)
+	}
+	leaves := make([]string, 0)
+	for rows.Next() {
+		var leaf string
+		if err := rows.Scan(&leaf); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan group member: %w", err)
+		}
+		leaves = append(leaves, leaf)
+	}
+	rows.Close()
+
+	tree, err := zkp.NewMerkleTree(leaves)
+	if err != nil {
+		return fmt.Errorf("GROUP_FULL: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE auth_groups SET root = ?, member_count = ?, updated_at = ? WHERE group_id = ?
+	`, tree.Root(), len(leaves), time.Now(), groupID); err != nil {
+		return fmt.Errorf("failed to update group root: %w", err)
+	}
+	return nil
+}
+
+// GenerateGroupChallenge 生成群组认证挑战
+func (s *Service) GenerateGroupChallenge(groupID, clientIP string) (*models.GroupChallenge, error) {
+	if s.license != nil && s.license.IsEnabled() {
+		if err := s.license.Check(); err != nil {
+			return nil, fmt.Errorf("LICENSE_001: 许可证校验失败 - %w", err)
+		}
+	}
+	if err := s.checkMembershipEnabled(); err != nil {
+		return nil, err
+	}
+	if err := s.checkLockout("", clientIP); err != nil {
+		return nil, err
+	}
+
+	group, err := s.GetGroup(groupID)
+	if err != nil {
+		s.recordAuthFailure("", clientIP, err)
+		return nil, err
+	}
+	if group.MemberCount == 0 {
+		return nil, fmt.Errorf("GROUP_EMPTY: group %s has no members", groupID)
+	}
+
+	// 群组没有设备维度，按群组+来源IP限制未使用的挑战数量
+	var pending int
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM group_challenges
+		WHERE group_id = ? AND client_ip = ? AND used = FALSE AND expires_at > ?
+	`, groupID, clientIP, time.Now()).Scan(&pending); err != nil {
+		return nil, fmt.Errorf("failed to count pending challenges: %w", err)
+	}
+	if pending >= s.maxPending {
+		return nil, fmt.Errorf("CHALLENGE_LIMIT: too many pending challenges")
+	}
+
+	nonce, err := s.verifier.GenerateChallenge()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	now := time.Now()
+	challenge := &models.GroupChallenge{
+		ChallengeID: uuid.New().String(),
+		GroupID:     groupID,
+		Nonce:       nonce,
+		Root:        group.Root,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.challengeTTL),
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO group_challenges (challenge_id, group_id, nonce, client_ip, created_at, expires_at, used)
+		VALUES (?, ?, ?, ?, ?, ?, FALSE)
+	`, challenge.ChallengeID, groupID, nonce, clientIP, challenge.CreatedAt, challenge.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to save challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// VerifyGroupProof 验证群组成员证明并签发群组会话
+// 失败只计入来源IP的失败次数（没有可归属的设备）
+func (s *Service) VerifyGroupProof(groupID string, req *models.GroupAuthRequest, clientIP string) (*models.GroupSession, error) {
+	if err := s.checkMembershipEnabled(); err != nil {
+		return nil, err
+	}
+	if err := s.checkLockout("", clientIP); err != nil {
+		return nil, err
+	}
+
+	group, err := s.checkGroupProof(groupID, req)
+	if err != nil {
+		s.recordAuthFailure("", clientIP, err)
+		return nil, err
+	}
+
+	if _, err := s.db.Exec(`UPDATE group_challenges SET used = TRUE WHERE challenge_id = ?`, req.ChallengeID); err != nil {
+		s.logger.Error("Failed to mark group challenge as used", zap.Error(err))
+	}
+
+	now := time.Now()
+	session := &models.GroupSession{
+		SessionID: uuid.New().String(),
+		GroupID:   group.GroupID,
+		Root:      group.Root,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.sessionTTL),
+		IPAddress: clientIP,
+	}
+	if _, err := s.db.Exec(`
+		INSERT INTO group_sessions (session_id, group_id, root, created_at, expires_at, last_used_at, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, session.SessionID, session.GroupID, session.Root, now, session.ExpiresAt, now, clientIP); err != nil {
+		return nil, fmt.Errorf("failed to create group session: %w", err)
+	}
+
+	token, err := s.generateGroupToken(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	session.Token = token
+
+	s.logger.Info("Group membership authentication successful",
+		zap.String("group_id", group.GroupID),
+		zap.String("session_id", session.SessionID))
+	return session, nil
+}
+
+// checkGroupProof 校验挑战、树根和成员证明
+func (s *Service) checkGroupProof(groupID string, req *models.GroupAuthRequest) (*models.AuthGroup, error) {
+	var challenge models.GroupChallenge
+	err := s.db.QueryRow(`
+		SELECT challenge_id, group_id, nonce, expires_at, used
+		FROM group_challenges WHERE challenge_id = ?
+	`, req.ChallengeID).Scan(&challenge.ChallengeID, &challenge.GroupID, &challenge.Nonce, &challenge.ExpiresAt, &challenge.Used)
+	if err != nil {
+		return nil, fmt.Errorf("invalid challenge: %w", err)
+	}
+	if challenge.GroupID != groupID {
+		return nil, fmt.Errorf("challenge does not belong to group")
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		return nil, fmt.Errorf("challenge expired")
+	}
+	if challenge.Used {
+		return nil, fmt.Errorf("challenge already used")
+	}
+
+	group, err := s.GetGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	pw := req.Proof.PublicWitness
+	if pw.Root == "" || pw.Challenge == "" || pw.Response == "" {
+		return nil, fmt.Errorf("invalid public witness: missing required fields")
+	}
+	// 证明必须基于当前树根，成员变更后需重新获取成员列表生成证明
+	if !strings.EqualFold(pw.Root, group.Root) {
+		return nil, fmt.Errorf("ROOT_MISMATCH: group membership changed, refresh member list")
+	}
+	if pw.Challenge != challenge.Nonce {
+		return nil, fmt.Errorf("challenge mismatch in witness")
+	}
+
+	proofBytes, err := base64.StdEncoding.DecodeString(req.Proof.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode proof: %w", err)
+	}
+
+	mv := s.verifier.(zkp.MembershipVerifier)
+	valid, err := mv.VerifyMembershipProof(group.Root, challenge.Nonce, pw.Response, proofBytes)
+	if err != nil {
+		return nil, fmt.Errorf("verification failed: %w", err)
+	}
+	if !valid {
+		s.logger.Warn("Invalid membership proof", zap.String("group_id", groupID))
+		return nil, fmt.Errorf("invalid proof")
+	}
+	return group, nil
+}
+
+// ValidateGroupToken 验证群组会话令牌
+func (s *Service) ValidateGroupToken(tokenString string) (*models.GroupTokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &models.GroupTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*models.GroupTokenClaims)
+	if !ok || !token.Valid || claims.GroupID == "" {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	var groupID string
+	var expiresAt time.Time
+	var revokedAt sql.NullTime
+	err = s.db.QueryRow(`
+		SELECT group_id, expires_at, revoked_at FROM group_sessions WHERE session_id = ?
+	`, claims.SessionID).Scan(&groupID, &expiresAt, &revokedAt)
+	if err != nil {
+		return nil, fmt.Errorf("session not found")
+	}
+	if revokedAt.Valid {
+		return nil, fmt.Errorf("SESSION_REVOKED: group session revoked")
+	}
+	if groupID != claims.GroupID {
+		return nil, fmt.Errorf("session does not belong to group")
+	}
+	if time.Now().After(expiresAt) {
+		return nil, fmt.Errorf("session expired")
+	}
+
+	s.db.Exec(`UPDATE group_sessions SET last_used_at = ? WHERE session_id = ?`, time.Now(), claims.SessionID)
+	return claims, nil
+}
+
+// RevokeGroupSessions 撤销群组的所有会话
+func (s *Service) RevokeGroupSessions(groupID, revokedBy string) (int64, error) {
+	result, err := s.db.Exec(`
+		UPDATE group_sessions SET revoked_at = ? WHERE group_id = ? AND revoked_at IS NULL
+	`, time.Now(), groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke group sessions: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	s.logger.Info("Group sessions revoked",
+		zap.String("group_id", groupID),
+		zap.String("revoked_by", revokedBy),
+		zap.Int64("revoked", n))
+	return n, nil
+}
+
+// MembershipEnabled 是否已加载群组成员电路的verifying key
+func (s *Service) MembershipEnabled() bool {
+	return s.checkMembershipEnabled() == nil
+}
+
+func (s *Service) checkMembershipEnabled() error {
+	mv, ok := s.verifier.(zkp.MembershipVerifier)
+	if !ok || !mv.HasMembershipKey() {
+		return fmt.Errorf("MEMBERSHIP_DISABLED: membership verifying key not loaded")
+	}
+	return nil
+}
+
+// generateGroupToken 生成群组会话JWT
+func (s *Service) generateGroupToken(session *models.GroupSession) (string, error) {
+	claims := &models.GroupTokenClaims{
+		GroupID:   session.GroupID,
+		SessionID: session.SessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(session.CreatedAt),
+			NotBefore: jwt.NewNumericDate(session.CreatedAt),
+			Subject:   "group:" + session.GroupID,
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.jwtSecret)
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+func TestSyncGroup(t *testing.T) {
+	s := newTestService(t, config.AuthConfig{}, newFakeVerifier(zkp.LegacyKeyID))
+
+	group, err := s.SyncGroup(&models.SyncGroupRequest{
+		GroupID: "ops",
+		Members: []models.SyncGroupMember{
+			{Commitment: testCommitment(1), Label: "tool-a"},
+			{Commitment: testCommitment(2), Label: "tool-b"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Name != "ops" || group.MemberCount != 2 {
+		t.Fatalf("unexpected group after first sync: %+v", group)
+	}
+	firstRoot := group.Root
+
+	if _, err := s.db.Exec(`
+		INSERT INTO group_sessions (session_id, group_id, root) VALUES ('sess-1', 'ops', ?)
+	`, firstRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	// 仅新增成员不影响现有会话，已有成员保持叶子位置
+	group, err = s.SyncGroup(&models.SyncGroupRequest{
+		GroupID: "ops",
+		Members: []models.SyncGroupMember{
+			{Commitment: testCommitment(3), Label: "tool-c"},
+			{Commitment: testCommitment(1), Label: "tool-a"},
+			{Commitment: testCommitment(2), Label: "tool-b"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.MemberCount != 3 || group.Root == firstRoot {
+		t.Fatalf("expected new member to change root: %+v", group)
+	}
+	members, err := s.ListGroupMembers("ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members[0].Commitment != testCommitment(1) || members[2].Commitment != testCommitment(3) {
+		t.Fatalf("existing members must keep their leaf positions: %+v", members)
+	}
+	if revokedGroupSession(t, s, "sess-1") {
+		t.Fatal("adding members must not revoke sessions")
+	}
+
+	// 移除成员撤销群组的全部会话
+	group, err = s.SyncGroup(&models.SyncGroupRequest{
+		GroupID: "ops",
+		Members: []models.SyncGroupMember{{Commitment: testCommitment(3)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.MemberCount != 1 {
+		t.Fatalf("expected one member after removal: %+v", group)
+	}
+	if !revokedGroupSession(t, s, "sess-1") {
+		t.Fatal("removing a member must revoke group sessions")
+	}
+
+	if _, err := s.SyncGroup(&models.SyncGroupRequest{
+		GroupID: "ops",
+		Members: []models.SyncGroupMember{{Commitment: testCommitment(4)}, {Commitment: testCommitment(4)}},
+	}); err == nil {
+		t.Fatal("duplicate commitments should be rejected")
+	}
+}
+
+func revokedGroupSession(t *testing.T, s *Service, sessionID string) bool {
+	t.Helper()
+	var revoked int
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM group_sessions WHERE session_id = ? AND revoked_at IS NOT NULL
+	`, sessionID).Scan(&revoked); err != nil {
+		t.Fatal(err)
+	}
+	return revoked > 0
+}
@@ -0,0 +1,205 @@
+/*
+ * Verifying key迁移
+ * 设备按记录的电路版本选择verifying key；迁移窗口内同时接受设备当前key和默认key，
+ * 设备使用默认key认证成功即视为完成迁移，旧key在无设备使用后自动卸载
+ */
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// KeyStatus verifying key状态
+type KeyStatus struct {
+	zkp.KeyInfo
+	Devices int `json:"devices"` // 仍使用该key的设备数量
+}
+
+// CircuitVersionSync 设备电路版本变更回调接口
+// 由设备管理器实现，用于同步内存中的设备缓存
+type CircuitVersionSync interface {
+	SyncCircuitVersion(deviceID, version string)
+}
+
+// deviceKeyID 返回设备当前使用的key ID，未记录时视为旧版单key
+func deviceKeyID(device *models.Device) string {
+	if device.CircuitVersion == "" {
+		return zkp.LegacyKeyID
+	}
+	return device.CircuitVersion
+}
+
+// selectProofKey 确定验证设备证明使用的key
+// 只接受设备当前key或默认key（迁移目标），且key必须已加载
+func (s *Service) selectProofKey(device *models.Device, proofKeyID string) (string, error) {
+	current := deviceKeyID(device)
+	keyID := proofKeyID
+	if keyID == "" {
+		keyID = current
+	}
+
+	if keyID != current && keyID != s.verifier.DefaultKeyID() {
+		return "", fmt.Errorf("verifying key %s not accepted for device (current %s)", keyID, current)
+	}
+	if !s.verifier.HasKey(keyID) {
+		return "", fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+	return keyID, nil
+}
+
+// fillChallengeKeys 填充挑战中的key信息，提示设备迁移到默认key
+func (s *Service) fillChallengeKeys(challenge *models.Challenge, device *models.Device) {
+	challenge.KeyID = deviceKeyID(device)
+	if target := s.verifier.DefaultKeyID(); target != challenge.KeyID {
+		challenge.TargetKeyID = target
+	}
+}
+
+// migrateDeviceCircuit 记录设备已切换到新的电路版本
+func (s *Service) migrateDeviceCircuit(deviceID, from, to string) {
+	now := time.Now()
+	if _, err := s.db.Exec(`UPDATE devices SET circuit_version = ?, updated_at = ? WHERE device_id = ?`,
+		to, now, deviceID); err != nil {
+		s.logger.Error("Failed to record circuit migration",
+			zap.String("device_id", deviceID),
+			zap.Error(err))
+		return
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"device_id": deviceID,
+		"from":      from,
+		"to":        to,
+	})
+	if _, err := s.db.Exec(`
+		INSERT INTO system_logs (level, module, message, details, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`, "info", "auth", "circuit_migrated", string(details), now); err != nil {
+		s.logger.Error("Failed to write migration system log", zap.Error(err))
+	}
+
+	if sync, ok := s.credentialSync.(CircuitVersionSync); ok {
+		sync.SyncCircuitVersion(deviceID, to)
+	}
+
+	s.logger.Info("Device migrated to new verifying key",
+		zap.String("device_id", deviceID),
+		zap.String("from", from),
+		zap.String("to", to))
+
+	s.RetireUnusedKeys()
+}
+
+// ListVerifyingKeys 列出已加载的verifying key及使用设备数量
+func (s *Service) ListVerifyingKeys() ([]KeyStatus, error) {
+	km, ok := s.verifier.(zkp.KeyManager)
+	if !ok {
+		return nil, fmt.Errorf("verifier does not support key management")
+	}
+
+	keys := km.Keys()
+	statuses := make([]KeyStatus, 0, len(keys))
+	for _, key := range keys {
+		count, err := s.countDevicesOnKey(key.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, KeyStatus{KeyInfo: key, Devices: count})
+	}
+	return statuses, nil
+}
+
+// RetireVerifyingKey 手动卸载verifying key（运维操作）
+// 仍有设备使用时需要force，这些设备将无法认证直到重新注册；最后一个已加载的key不能卸载
+func (s *Service) RetireVerifyingKey(keyID, operator string, force bool) error {
+	km, ok := s.verifier.(zkp.KeyManager)
+	if !ok {
+		return fmt.Errorf("verifier does not support key management")
+	}
+	if keys := km.Keys(); len(keys) == 1 && keys[0].KeyID == keyID {
+		return fmt.Errorf("cannot retire the last active verifying key: %s", keyID)
+	}
+
+	count, err := s.countDevicesOnKey(keyID)
+	if err != nil {
+		return err
+	}
+	if count > 0 && !force {
+		return fmt.Errorf("verifying key %s still used by %d devices", keyID, count)
+	}
+
+	if err := km.RetireKey(keyID); err != nil {
+		return err
+	}
+	s.logKeyRetired(keyID, count, operator)
+	return nil
+}
+
+// RetireUnusedKeys 卸载不再有设备使用的非默认key，返回卸载的key ID
+func (s *Service) RetireUnusedKeys() []string {
+	km, ok := s.verifier.(zkp.KeyManager)
+	if !ok {
+		return nil
+	}
+
+	var retired []string
+	for _, key := range km.Keys() {
+		if key.Default {
+			continue
+		}
+		count, err := s.countDevicesOnKey(key.KeyID)
+		if err != nil {
+			s.logger.Error("Failed to count devices on key",
+				zap.String("key_id", key.KeyID),
+				zap.Error(err))
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		if err := km.RetireKey(key.KeyID); err != nil {
+			s.logger.Error("Failed to retire verifying key",
+				zap.String("key_id", key.KeyID),
+				zap.Error(err))
+			continue
+		}
+		s.logKeyRetired(key.KeyID, 0, "auto")
+		retired = append(retired, key.KeyID)
+	}
+	return retired
+}
+
+// countDevicesOnKey 统计使用指定key的设备数量
+func (s *Service) countDevicesOnKey(keyID string) (int, error) {
+	query := `SELECT COUNT(*) FROM devices WHERE circuit_version = ?`
+	if keyID == zkp.LegacyKeyID {
+		query = `SELECT COUNT(*) FROM devices WHERE circuit_version = ? OR circuit_version = '' OR circuit_version IS NULL`
+	}
+
+	var count int
+	if err := s.db.QueryRow(query, keyID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count devices on key %s: %w", keyID, err)
+	}
+	return count, nil
+}
+
+// logKeyRetired 记录key卸载事件
+func (s *Service) logKeyRetired(keyID string, devices int, retiredBy string) {
+	details, _ := json.Marshal(map[string]interface{}{
+		"key_id":     keyID,
+		"devices":    devices,
+		"retired_by": retiredBy,
+	})
+	if _, err := s.db.Exec(`
+		INSERT INTO system_logs (level, module, message, details, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`, "warn", "auth", "verifying_key_retired", string(details), time.Now()); err != nil {
+		s.logger.Error("Failed to write key retirement system log", zap.Error(err))
+	}
+}
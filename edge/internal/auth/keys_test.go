@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+func TestSelectProofKey(t *testing.T) {
+	s := newTestService(t, config.AuthConfig{}, newFakeVerifier("v2", zkp.LegacyKeyID, "v0"))
+
+	tests := []struct {
+		name       string
+		device     *models.Device
+		proofKeyID string
+		want       string
+		wantErr    string
+	}{
+		{name: "legacy device defaults to v1", device: &models.Device{}, want: zkp.LegacyKeyID},
+		{name: "device current key", device: &models.Device{CircuitVersion: "v0"}, proofKeyID: "v0", want: "v0"},
+		{name: "migration to default key", device: &models.Device{}, proofKeyID: "v2", want: "v2"},
+		{name: "migrated device", device: &models.Device{CircuitVersion: "v2"}, want: "v2"},
+		{name: "other loaded key rejected", device: &models.Device{}, proofKeyID: "v0", wantErr: "not accepted"},
+		{name: "downgrade rejected", device: &models.Device{CircuitVersion: "v2"}, proofKeyID: zkp.LegacyKeyID, wantErr: "not accepted"},
+		{name: "current key not loaded", device: &models.Device{CircuitVersion: "v9"}, wantErr: "not loaded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.selectProofKey(tt.device, tt.proofKeyID)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("expected %s, got %s (%v)", tt.want, got, err)
+			}
+		})
+	}
+}
+
+func TestMigrateDeviceCircuit(t *testing.T) {
+	tests := []struct {
+		name        string
+		others      []string // 其他设备的电路版本
+		proofKeyID  string
+		wantVersion string
+		wantKeys    []string
+	}{
+		{name: "last device migrates and old key retired", proofKeyID: "v2", wantVersion: "v2", wantKeys: []string{"v2"}},
+		{name: "old key kept while in use", others: []string{""}, proofKeyID: "v2", wantVersion: "v2", wantKeys: []string{zkp.LegacyKeyID, "v2"}},
+		{name: "authenticating with current key does not migrate", proofKeyID: zkp.LegacyKeyID, wantVersion: "", wantKeys: []string{zkp.LegacyKeyID, "v2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newFakeVerifier("v2", zkp.LegacyKeyID)
+			s := newTestService(t, config.AuthConfig{}, verifier)
+			addTestDevice(t, s, "sensor-1", testCommitment(1), "")
+			for i, version := range tt.others {
+				addTestDevice(t, s, "sensor-other-"+string(rune('a'+i)), testCommitment(10+i), version)
+			}
+
+			challenge, err := s.GenerateChallenge("sensor-1", "10.0.0.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if challenge.KeyID != zkp.LegacyKeyID || challenge.TargetKeyID != "v2" {
+				t.Fatalf("expected migration hint v1 -> v2, got %s -> %s", challenge.KeyID, challenge.TargetKeyID)
+			}
+			if _, err := s.VerifyProof(&models.AuthRequest{
+				DeviceID:    "sensor-1",
+				ChallengeID: challenge.ChallengeID,
+				Proof:       proveChallenge(challenge, testCommitment(1), tt.proofKeyID),
+			}, "10.0.0.1"); err != nil {
+				t.Fatalf("authentication failed: %v", err)
+			}
+
+			device, err := s.getDevice("sensor-1")
+			if err != nil || device.CircuitVersion != tt.wantVersion {
+				t.Fatalf("expected circuit version %q, got %+v (%v)", tt.wantVersion, device, err)
+			}
+			keys := verifier.Keys()
+			if len(keys) != len(tt.wantKeys) {
+				t.Fatalf("expected keys %v, got %+v", tt.wantKeys, keys)
+			}
+			for i, key := range keys {
+				if key.KeyID != tt.wantKeys[i] {
+					t.Fatalf("expected keys %v, got %+v", tt.wantKeys, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestRetireVerifyingKey(t *testing.T) {
+	verifier := newFakeVerifier("v2", zkp.LegacyKeyID, "v0")
+	s := newTestService(t, config.AuthConfig{}, verifier)
+	addTestDevice(t, s, "sensor-1", testCommitment(1), "")
+	addTestDevice(t, s, "sensor-2", testCommitment(2), zkp.LegacyKeyID)
+	addTestDevice(t, s, "sensor-3", testCommitment(3), "v2")
+
+	statuses, err := s.ListVerifyingKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	devices := map[string]int{}
+	for _, st := range statuses {
+		devices[st.KeyID] = st.Devices
+	}
+	if devices[zkp.LegacyKeyID] != 2 || devices["v2"] != 1 || devices["v0"] != 0 {
+		t.Fatalf("unexpected key usage: %+v", statuses)
+	}
+
+	tests := []struct {
+		name    string
+		keyID   string
+		force   bool
+		wantErr string
+	}{
+		{name: "key in use requires force", keyID: zkp.LegacyKeyID, wantErr: "still used by 2 devices"},
+		{name: "unused key", keyID: "v0"},
+		{name: "already retired", keyID: "v0", wantErr: "not loaded"},
+		{name: "default key", keyID: "v2", force: true, wantErr: "default"},
+		{name: "forced retirement", keyID: zkp.LegacyKeyID, force: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.RetireVerifyingKey(tt.keyID, "group:ops", tt.force)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if verifier.HasKey(tt.keyID) {
+				t.Fatalf("key %s still loaded", tt.keyID)
+			}
+		})
+	}
+
+	// 强制卸载后仍在旧key上的设备无法认证
+	challenge, err := s.GenerateChallenge("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyProof(&models.AuthRequest{
+		DeviceID:    "sensor-1",
+		ChallengeID: challenge.ChallengeID,
+		Proof:       proveChallenge(challenge, testCommitment(1), zkp.LegacyKeyID),
+	}, "10.0.0.1"); err == nil || !strings.Contains(err.Error(), "not loaded") {
+		t.Fatalf("expected retired key to be rejected, got %v", err)
+	}
+	if retired := s.RetireUnusedKeys(); len(retired) != 0 {
+		t.Fatalf("default key must never be retired automatically: %v", retired)
+	}
+
+	// 只剩一个key时拒绝卸载，避免所有设备无法认证
+	if err := s.RetireVerifyingKey("v2", "group:ops", true); err == nil || !strings.Contains(err.Error(), "last active") {
+		t.Fatalf("expected last key retirement to be refused, got %v", err)
+	}
+	if !verifier.HasKey("v2") {
+		t.Fatal("last key must stay loaded")
+	}
+}
@@ -0,0 +1,396 @@
+/*
+ * 认证失败锁定
+ * 按设备ID和来源IP统计失败次数，超过阈值后指数退避锁定。
+ * 设备计数不区分来源，轮换来源IP无法绕过单设备阈值
+ */
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/abac"
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 锁定范围
+const (
+	LockoutScopeDevice = "device"
+	LockoutScopeIP     = "ip"
+)
+
+// 锁定默认参数
+const (
+	defaultIPMaxFailures        = 20
+	defaultLockoutBase          = time.Minute
+	defaultLockoutMax           = time.Hour
+	defaultFailureWindow        = 15 * time.Minute
+	defaultMaxPendingChallenges = 3
+
+	// 锁定表条目超过该数量时清理过期条目
+	lockoutPruneThreshold = 4096
+)
+
+// AccessLogger ABAC访问日志记录接口
+type AccessLogger interface {
+	LogAccess(ctx context.Context, log *abac.AccessLog) error
+}
+
+// LockoutError 认证被锁定错误
+type LockoutError struct {
+	Scope      string
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("AUTH_LOCKED: %s %s locked, retry after %s",
+		e.Scope, e.Key, e.RetryAfter.Round(time.Second))
+}
+
+// lockoutEntry 单个设备或IP的失败记录
+type lockoutEntry struct {
+	failures      int
+	lockCount     int
+	lockedUntil   time.Time
+	lastFailureAt time.Time
+}
+
+// lockoutTracker 失败计数与锁定状态（内存）
+type lockoutTracker struct {
+	mu      sync.Mutex
+	entries map[string]*lockoutEntry
+
+	deviceMaxFailures int
+	ipMaxFailures     int
+	base              time.Duration
+	max               time.Duration
+	window            time.Duration
+}
+
+// newLockoutTracker 创建锁定跟踪器，未配置项使用默认值
+func newLockoutTracker(cfg config.LockoutConfig, maxRetry int) *lockoutTracker {
+	t := &lockoutTracker{
+		entries:           make(map[string]*lockoutEntry),
+		deviceMaxFailures: cfg.DeviceMaxFailures,
+		ipMaxFailures:     cfg.IPMaxFailures,
+		base:              cfg.BaseDuration,
+		max:               cfg.MaxDuration,
+		window:            cfg.FailureWindow,
+	}
+	if t.deviceMaxFailures <= 0 {
+		t.deviceMaxFailures = maxRetry
+	}
+	if t.deviceMaxFailures <= 0 {
+		t.deviceMaxFailures = 3
+	}
+	if t.ipMaxFailures <= 0 {
+		t.ipMaxFailures = defaultIPMaxFailures
+	}
+	if t.base <= 0 {
+		t.base = defaultLockoutBase
+	}
+	if t.max < t.base {
+		t.max = defaultLockoutMax
+		if t.max < t.base {
+			t.max = t.base
+		}
+	}
+	if t.window <= 0 {
+		t.window = defaultFailureWindow
+	}
+	return t
+}
+
+func lockoutKey(scope, key string) string {
+	return scope + ":" + key
+}
+
+// threshold 返回范围对应的失败阈值
+func (t *lockoutTracker) threshold(scope string) int {
+	if scope == LockoutScopeIP {
+		return t.ipMaxFailures
+	}
+	return t.deviceMaxFailures
+}
+
+// remaining 返回剩余锁定时长，未锁定返回0
+func (t *lockoutTracker) remaining(scope, key string, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[lockoutKey(scope, key)]
+	if !ok || !now.Before(entry.lockedUntil) {
+		return 0
+	}
+	return entry.lockedUntil.Sub(now)
+}
+
+// recordFailure 记录一次失败，达到阈值时锁定并返回锁定截止时间
+func (t *lockoutTracker) recordFailure(scope, key string, now time.Time) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.entries) > lockoutPruneThreshold {
+		t.pruneLocked(now)
+	}
+
+	k := lockoutKey(scope, key)
+	entry, ok := t.entries[k]
+	if !ok {
+		entry = &lockoutEntry{}
+		t.entries[k] = entry
+	}
+
+	// 超出窗口后失败计数清零；锁定解除后静默一个窗口则锁定级别也清零
+	if now.Sub(entry.lastFailureAt) > t.window {
+		entry.failures = 0
+		if now.Sub(entry.lockedUntil) > t.window {
+			entry.lockCount = 0
+		}
+	}
+	entry.lastFailureAt = now
+
+	// 锁定期间的失败不再累计
+	if now.Before(entry.lockedUntil) {
+		return entry.lockedUntil, false
+	}
+
+	entry.failures++
+	if entry.failures < t.threshold(scope) {
+		return time.Time{}, false
+	}
+
+	// 指数退避：base * 2^(lockCount)，不超过上限
+	duration := t.base
+	for i := 0; i < entry.lockCount && duration < t.max; i++ {
+		duration *= 2
+	}
+	if duration > t.max {
+		duration = t.max
+	}
+
+	entry.lockCount++
+	entry.failures = 0
+	entry.lockedUntil = now.Add(duration)
+	return entry.lockedUntil, true
+}
+
+// reset 清除失败记录，返回是否存在记录
+func (t *lockoutTracker) reset(scope, key string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := lockoutKey(scope, key)
+	_, ok := t.entries[k]
+	delete(t.entries, k)
+	return ok
+}
+
+// block 直接锁定到指定时间（已有更晚的锁定时保持不变）
+func (t *lockoutTracker) block(scope, key string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := lockoutKey(scope, key)
+	entry, ok := t.entries[k]
+	if !ok {
+		entry = &lockoutEntry{lastFailureAt: time.Now()}
+		t.entries[k] = entry
+	}
+	if until.After(entry.lockedUntil) {
+		entry.lockedUntil = until
+	}
+	entry.lockCount++
+}
+
+// list 返回所有仍有意义的失败记录（锁定中或窗口内有失败）
+func (t *lockoutTracker) list(now time.Time) []models.AuthLockout {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pruneLocked(now)
+
+	result := make([]models.AuthLockout, 0, len(t.entries))
+	for k, entry := range t.entries {
+		scope, key := splitLockoutKey(k)
+		item := models.AuthLockout{
+			Scope:         scope,
+			Key:           key,
+			Failures:      entry.failures,
+			LockCount:     entry.lockCount,
+			LastFailureAt: entry.lastFailureAt,
+		}
+		if now.Before(entry.lockedUntil) {
+			until := entry.lockedUntil
+			item.LockedUntil = &until
+		}
+		result = append(result, item)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastFailureAt.After(result[j].LastFailureAt)
+	})
+	return result
+}
+
+// pruneLocked 删除已过期的条目（调用方持有锁）
+func (t *lockoutTracker) pruneLocked(now time.Time) {
+	for k, entry := range t.entries {
+		if now.Sub(entry.lastFailureAt) > t.window && now.Sub(entry.lockedUntil) > t.window {
+			delete(t.entries, k)
+		}
+	}
+}
+
+func splitLockoutKey(k string) (string, string) {
+	for i := 0; i < len(k); i++ {
+		if k[i] == ':' {
+			return k[:i], k[i+1:]
+		}
+	}
+	return "", k
+}
+
+// SetAccessLogger 设置ABAC访问日志记录器（锁定事件写入设备访问日志）
+func (s *Service) SetAccessLogger(logger AccessLogger) {
+	s.accessLogger = logger
+}
+
+// checkLockout 检查设备和来源IP是否处于锁定状态
+func (s *Service) checkLockout(deviceID, clientIP string) error {
+	now := time.Now()
+	if deviceID != "" {
+		if d := s.lockout.remaining(LockoutScopeDevice, deviceID, now); d > 0 {
+			return &LockoutError{Scope: LockoutScopeDevice, Key: deviceID, RetryAfter: d}
+		}
+	}
+	if clientIP != "" {
+		if d := s.lockout.remaining(LockoutScopeIP, clientIP, now); d > 0 {
+			return &LockoutError{Scope: LockoutScopeIP, Key: clientIP, RetryAfter: d}
+		}
+	}
+	return nil
+}
+
+// recordAuthFailure 记录认证失败，触发锁定时写入日志
+// 认证前的失败无法确认来自设备本身（任何人都可以用设备ID提交证明），不计入设备信任度
+func (s *Service) recordAuthFailure(deviceID, clientIP string, cause error) {
+	now := time.Now()
+	if deviceID != "" {
+		if until, locked := s.lockout.recordFailure(LockoutScopeDevice, deviceID, now); locked {
+			s.logLockoutEvent("auth_lockout", LockoutScopeDevice, deviceID, deviceID, clientIP, until, cause.Error())
+		}
+	}
+	if clientIP != "" {
+		if until, locked := s.lockout.recordFailure(LockoutScopeIP, clientIP, now); locked {
+			s.logLockoutEvent("auth_lockout", LockoutScopeIP, clientIP, deviceID, clientIP, until, cause.Error())
+		}
+	}
+}
+
+// recordAuthSuccess 认证成功后清除设备的失败记录
+// 来源IP的计数不清除，避免攻击者借助一个合法设备重置IP计数
+func (s *Service) recordAuthSuccess(deviceID string) {
+	s.lockout.reset(LockoutScopeDevice, deviceID)
+}
+
+// ListLockouts 列出当前的失败计数和锁定状态
+func (s *Service) ListLockouts() []models.AuthLockout {
+	return s.lockout.list(time.Now())
+}
+
+// Unlock 解除设备或IP的锁定（运维操作），operator记录在锁定日志中
+func (s *Service) Unlock(scope, key, operator string) error {
+	if scope != LockoutScopeDevice && scope != LockoutScopeIP {
+		return fmt.Errorf("invalid lockout scope: %s", scope)
+	}
+	if !s.lockout.reset(scope, key) {
+		return fmt.Errorf("no lockout record for %s %s", scope, key)
+	}
+
+	deviceID, clientIP := "", key
+	if scope == LockoutScopeDevice {
+		deviceID, clientIP = key, ""
+	}
+	s.logLockoutEvent("auth_unlock", scope, key, deviceID, clientIP, time.Time{}, "unlocked by "+operator)
+	return nil
+}
+
+// Block 锁定设备或IP指定时长（管理员操作或漏洞处置剧本）
+func (s *Service) Block(scope, key string, duration time.Duration, reason string) error {
+	if scope != LockoutScopeDevice && scope != LockoutScopeIP {
+		return fmt.Errorf("invalid lockout scope: %s", scope)
+	}
+	if key == "" || duration <= 0 {
+		return fmt.Errorf("invalid lockout request")
+	}
+
+	until := time.Now().Add(duration)
+	s.lockout.block(scope, key, until)
+
+	deviceID, clientIP := "", key
+	if scope == LockoutScopeDevice {
+		deviceID, clientIP = key, ""
+	}
+	s.logLockoutEvent("auth_block", scope, key, deviceID, clientIP, until, reason)
+	return nil
+}
+
+// logLockoutEvent 将锁定/解锁事件写入系统日志和ABAC访问日志
+func (s *Service) logLockoutEvent(event, scope, key, deviceID, clientIP string, until time.Time, reason string) {
+	now := time.Now()
+	details := map[string]interface{}{
+		"scope":     scope,
+		"key":       key,
+		"device_id": deviceID,
+		"client_ip": clientIP,
+		"reason":    reason,
+	}
+	if !until.IsZero() {
+		details["locked_until"] = until
+		s.logger.Warn("Authentication locked out",
+			zap.String("scope", scope),
+			zap.String("key", key),
+			zap.Time("locked_until", until),
+			zap.String("reason", reason))
+	} else {
+		s.logger.Info("Authentication lockout cleared",
+			zap.String("scope", scope),
+			zap.String("key", key))
+	}
+	detailsJSON, _ := json.Marshal(details)
+
+	if _, err := s.db.Exec(`
+		INSERT INTO system_logs (level, module, message, details, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`, "warn", "auth", event, string(detailsJSON), now); err != nil {
+		s.logger.Error("Failed to write lockout system log", zap.Error(err))
+	}
+
+	if s.accessLogger == nil {
+		return
+	}
+	subjectID := deviceID
+	if scope == LockoutScopeIP {
+		subjectID = clientIP
+	}
+	if err := s.accessLogger.LogAccess(context.Background(), &abac.AccessLog{
+		SubjectType: scope,
+		SubjectID:   subjectID,
+		Resource:    "auth",
+		Action:      event,
+		Allowed:     until.IsZero(),
+		Reason:      reason,
+		Timestamp:   now,
+		Attributes:  detailsJSON,
+	}); err != nil {
+		s.logger.Error("Failed to write lockout access log", zap.Error(err))
+	}
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+func TestLockoutTrackerBackoff(t *testing.T) {
+	cfg := config.LockoutConfig{
+		DeviceMaxFailures: 3,
+		IPMaxFailures:     5,
+		BaseDuration:      time.Minute,
+		MaxDuration:       4 * time.Minute,
+		FailureWindow:     15 * time.Minute,
+	}
+
+	type step struct {
+		at       time.Duration // 相对起始时间
+		locked   bool
+		duration time.Duration // 触发锁定时的锁定时长
+	}
+	tests := []struct {
+		name  string
+		scope string
+		steps []step
+	}{
+		{
+			name:  "locks at threshold",
+			scope: LockoutScopeDevice,
+			steps: []step{{at: 0}, {at: time.Second}, {at: 2 * time.Second, locked: true, duration: time.Minute}},
+		},
+		{
+			name:  "ip threshold",
+			scope: LockoutScopeIP,
+			steps: []step{{at: 0}, {at: 1}, {at: 2}, {at: 3}, {at: 4, locked: true, duration: time.Minute}},
+		},
+		{
+			name:  "failures while locked are not counted",
+			scope: LockoutScopeDevice,
+			steps: []step{
+				{at: 0}, {at: 1}, {at: 2, locked: true, duration: time.Minute},
+				{at: 10 * time.Second}, {at: 20 * time.Second}, {at: 30 * time.Second},
+				{at: 2 * time.Minute}, {at: 2*time.Minute + 1}, {at: 2*time.Minute + 2, locked: true, duration: 2 * time.Minute},
+			},
+		},
+		{
+			name:  "exponential backoff capped at max",
+			scope: LockoutScopeDevice,
+			steps: []step{
+				{at: 0}, {at: 1}, {at: 2, locked: true, duration: time.Minute},
+				{at: 2 * time.Minute}, {at: 2*time.Minute + 1}, {at: 2*time.Minute + 2, locked: true, duration: 2 * time.Minute},
+				{at: 5 * time.Minute}, {at: 5*time.Minute + 1}, {at: 5*time.Minute + 2, locked: true, duration: 4 * time.Minute},
+				{at: 10 * time.Minute}, {at: 10*time.Minute + 1}, {at: 10*time.Minute + 2, locked: true, duration: 4 * time.Minute},
+			},
+		},
+		{
+			name:  "failure count resets outside window",
+			scope: LockoutScopeDevice,
+			steps: []step{{at: 0}, {at: 1}, {at: 16 * time.Minute}, {at: 16*time.Minute + 1}, {at: 16*time.Minute + 2, locked: true, duration: time.Minute}},
+		},
+		{
+			name:  "lock level resets after a quiet window",
+			scope: LockoutScopeDevice,
+			steps: []step{
+				{at: 0}, {at: 1}, {at: 2, locked: true, duration: time.Minute},
+				{at: 2 * time.Minute}, {at: 2*time.Minute + 1}, {at: 2*time.Minute + 2, locked: true, duration: 2 * time.Minute},
+				{at: 30 * time.Minute}, {at: 30*time.Minute + 1}, {at: 30*time.Minute + 2, locked: true, duration: time.Minute},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newLockoutTracker(cfg, 0)
+			start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			for i, st := range tt.steps {
+				now := start.Add(st.at)
+				until, locked := tracker.recordFailure(tt.scope, "k", now)
+				if locked != st.locked {
+					t.Fatalf("step %d: expected locked=%v, got %v", i, st.locked, locked)
+				}
+				if locked && until.Sub(now) != st.duration {
+					t.Fatalf("step %d: expected lock for %s, got %s", i, st.duration, until.Sub(now))
+				}
+				if locked && tracker.remaining(tt.scope, "k", now) != st.duration {
+					t.Fatalf("step %d: remaining lock mismatch", i)
+				}
+			}
+		})
+	}
+}
+
+func TestLockoutByDeviceAndIP(t *testing.T) {
+	commitment := testCommitment(1)
+	s := newTestService(t, config.AuthConfig{Lockout: config.LockoutConfig{
+		DeviceMaxFailures:    2,
+		IPMaxFailures:        3,
+		MaxPendingChallenges: 2,
+	}}, newFakeVerifier(zkp.LegacyKeyID))
+	addTestDevice(t, s, "sensor-1", commitment, "")
+
+	fail := func(clientIP string) error {
+		challenge, err := s.GenerateChallenge("sensor-1", clientIP)
+		if err != nil {
+			return err
+		}
+		_, err = s.VerifyProof(&models.AuthRequest{
+			DeviceID:    "sensor-1",
+			ChallengeID: challenge.ChallengeID,
+			Proof:       proveChallenge(challenge, testCommitment(9), zkp.LegacyKeyID),
+		}, clientIP)
+		return err
+	}
+	login := func(clientIP string) error {
+		challenge, err := s.GenerateChallenge("sensor-1", clientIP)
+		if err != nil {
+			return err
+		}
+		_, err = s.VerifyProof(&models.AuthRequest{
+			DeviceID:    "sensor-1",
+			ChallengeID: challenge.ChallengeID,
+			Proof:       proveChallenge(challenge, commitment, zkp.LegacyKeyID),
+		}, clientIP)
+		return err
+	}
+
+	// 设备失败计数不区分来源IP，轮换来源无法绕过单设备阈值
+	for _, clientIP := range []string{"10.0.0.66", "10.0.0.67"} {
+		if err := fail(clientIP); err == nil || !strings.Contains(err.Error(), "commitment mismatch") {
+			t.Fatalf("expected failed proof, got %v", err)
+		}
+	}
+	var lockErr *LockoutError
+	for _, clientIP := range []string{"10.0.0.68", "10.0.0.1"} {
+		if _, err := s.GenerateChallenge("sensor-1", clientIP); !errors.As(err, &lockErr) || lockErr.Scope != LockoutScopeDevice {
+			t.Fatalf("expected device lockout from %s, got %v", clientIP, err)
+		}
+	}
+
+	lockouts := s.ListLockouts()
+	found := false
+	for _, l := range lockouts {
+		if l.Scope == LockoutScopeDevice && l.Key == "sensor-1" && l.LockedUntil != nil {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected device lockout recorded: %+v", lockouts)
+	}
+	if err := s.Unlock(LockoutScopeDevice, "sensor-1", "group:ops"); err != nil {
+		t.Fatal(err)
+	}
+	if err := login("10.0.0.1"); err != nil {
+		t.Fatalf("device should authenticate after unlock: %v", err)
+	}
+
+	// 来源IP的失败计数跨设备累计
+	if err := fail("10.0.0.77"); err == nil {
+		t.Fatal("expected failed proof")
+	}
+	if _, err := s.GenerateChallenge("unknown-device", "10.0.0.77"); err == nil {
+		t.Fatal("unknown device should fail")
+	}
+	if _, err := s.GenerateChallenge("unknown-device", "10.0.0.77"); err == nil {
+		t.Fatal("unknown device should fail")
+	}
+	if _, err := s.GenerateChallenge("sensor-1", "10.0.0.77"); !errors.As(err, &lockErr) || lockErr.Scope != LockoutScopeIP {
+		t.Fatalf("expected IP lockout, got %v", err)
+	}
+
+	// 管理员锁定设备对所有来源生效
+	if err := s.Block(LockoutScopeDevice, "sensor-1", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GenerateChallenge("sensor-1", "10.0.0.1"); !errors.As(err, &lockErr) {
+		t.Fatalf("expected admin lockout, got %v", err)
+	}
+	if err := s.Unlock(LockoutScopeDevice, "sensor-1", "group:ops"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unlock(LockoutScopeDevice, "sensor-1", "group:ops"); err == nil {
+		t.Fatal("unlocking twice should report no record")
+	}
+	if err := login("10.0.0.66"); err != nil {
+		t.Fatalf("unlock should clear the device lockout: %v", err)
+	}
+	if err := s.Unlock("user", "x", "group:ops"); err == nil {
+		t.Fatal("invalid scope should fail")
+	}
+}
+
+func TestPendingChallengeLimitPerSource(t *testing.T) {
+	s := newTestService(t, config.AuthConfig{Lockout: config.LockoutConfig{MaxPendingChallenges: 2}}, newFakeVerifier(zkp.LegacyKeyID))
+	addTestDevice(t, s, "sensor-1", testCommitment(1), "")
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GenerateChallenge("sensor-1", "10.0.0.66"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.GenerateChallenge("sensor-1", "10.0.0.66"); err == nil || !strings.Contains(err.Error(), "CHALLENGE_LIMIT") {
+		t.Fatalf("expected CHALLENGE_LIMIT, got %v", err)
+	}
+	// 其他来源占满名额不影响设备自身申请挑战
+	if _, err := s.GenerateChallenge("sensor-1", "10.0.0.1"); err != nil {
+		t.Fatalf("pending limit must not block other sources: %v", err)
+	}
+}
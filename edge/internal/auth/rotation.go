@@ -0,0 +1,299 @@
+/*
+ * 设备凭证轮换
+ * 设备先申请绑定新承诺的轮换挑战，证明持有当前secret后提交新承诺，Edge原子替换承诺并撤销已有会话
+ */
+package auth
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 凭证轮换发起方
+const (
+	RotationInitiatorDevice = "device"
+	RotationInitiatorCloud  = "cloud"
+	RotationInitiatorAdmin  = "admin"
+)
+
+// CredentialSync 凭证状态变更回调接口
+// 由设备管理器实现，用于同步内存中的设备缓存
+type CredentialSync interface {
+	SyncCredential(deviceID, commitment string, rotationRequired bool, reason string)
+}
+
+// SetCredentialSync 设置凭证变更回调
+func (s *Service) SetCredentialSync(sync CredentialSync) {
+	s.credentialSync = sync
+}
+
+// GenerateRotationChallenge 生成凭证轮换挑战
+// 挑战值为 MiMC(盐值 || 新承诺)，基于该挑战的证明只能用于登记这一新承诺，
+// 截获的认证证明或轮换证明都无法用来替换为其他承诺
+func (s *Service) GenerateRotationChallenge(deviceID, newCommitment, clientIP string) (*models.Challenge, error) {
+	if err := validateCommitment(newCommitment); err != nil {
+		return nil, fmt.Errorf("invalid new commitment: %w", err)
+	}
+	return s.issueChallenge(deviceID, clientIP, newCommitment)
+}
+
+// RotateCredential 轮换设备凭证
+// 使用当前secret对轮换挑战的证明换取新承诺的登记，成功后该设备所有会话立即失效
+func (s *Service) RotateCredential(req *models.CredentialRotationRequest, clientIP string) (*models.CredentialRotationResponse, error) {
+	if err := validateCommitment(req.NewCommitment); err != nil {
+		return nil, fmt.Errorf("invalid new commitment: %w", err)
+	}
+
+	device, keyID, err := s.verifyDeviceProof(req.DeviceID, req.ChallengeID, req.Proof, req.NewCommitment, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新承诺对应的电路版本：未指定时沿用本次证明所用的key
+	circuitVersion := req.NewKeyID
+	if circuitVersion == "" {
+		circuitVersion = keyID
+	}
+	if !s.verifier.HasKey(circuitVersion) {
+		return nil, fmt.Errorf("invalid new commitment: verifying key not loaded: %s", circuitVersion)
+	}
+
+	if req.NewCommitment == device.Commitment {
+		return nil, fmt.Errorf("new commitment must differ from current commitment")
+	}
+
+	now := time.Now()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 比较并替换承诺，防止并发轮换覆盖（挑战已在验证时消费）
+	result, err := tx.Exec(`
+		UPDATE devices SET commitment = ?, circuit_version = ?, rotation_required = FALSE, rotation_reason = '', updated_at = ?
+		WHERE device_id = ? AND commitment = ?
+	`, req.NewCommitment, circuitVersion, now, device.DeviceID, device.Commitment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update commitment: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("commitment changed concurrently")
+	}
+
+	revoked, err := revokeDeviceSessionsTx(tx, device.DeviceID, SessionRevokeReasonRotation, now)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation := &models.CredentialRotation{
+		DeviceID:        device.DeviceID,
+		Event:           "rotated",
+		OldCommitment:   device.Commitment,
+		NewCommitment:   req.NewCommitment,
+		InitiatedBy:     RotationInitiatorDevice,
+		Reason:          device.RotationReason,
+		SessionsRevoked: revoked,
+		CreatedAt:       now,
+	}
+	if err := insertRotationTx(tx, rotation); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rotation: %w", err)
+	}
+
+	if s.credentialSync != nil {
+		s.credentialSync.SyncCredential(device.DeviceID, req.NewCommitment, false, "")
+	}
+	s.recordAuthSuccess(device.DeviceID)
+
+	if current := deviceKeyID(device); circuitVersion != current {
+		if sync, ok := s.credentialSync.(CircuitVersionSync); ok {
+			sync.SyncCircuitVersion(device.DeviceID, circuitVersion)
+		}
+		s.RetireUnusedKeys()
+	}
+
+	s.logger.Info("Device credential rotated",
+		zap.String("device_id", device.DeviceID),
+		zap.Int64("sessions_revoked", revoked))
+
+	return &models.CredentialRotationResponse{
+		Success:         true,
+		DeviceID:        device.DeviceID,
+		SessionsRevoked: revoked,
+		RotatedAt:       now,
+		Message:         "凭证轮换成功",
+	}, nil
+}
+
+// ForceRotation 强制设备轮换凭证
+// deviceID为空时作用于本储能柜的所有设备；被标记的设备会话立即撤销，
+// 在完成轮换前无法再获得新会话。返回受影响的设备数量
+func (s *Service) ForceRotation(deviceID, reason, initiatedBy string) (int, error) {
+	var deviceIDs []string
+	if deviceID != "" {
+		device, err := s.getDevice(deviceID)
+		if err != nil {
+			return 0, fmt.Errorf("device not found: %w", err)
+		}
+		deviceIDs = append(deviceIDs, device.DeviceID)
+	} else {
+		rows, err := s.db.Query(`SELECT device_id FROM devices`)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list devices: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return 0, err
+			}
+			deviceIDs = append(deviceIDs, id)
+		}
+		rows.Close()
+	}
+
+	now := time.Now()
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	commitments := make(map[string]string, len(deviceIDs))
+	for _, id := range deviceIDs {
+		var commitment string
+		if err := tx.QueryRow(`SELECT commitment FROM devices WHERE device_id = ?`, id).Scan(&commitment); err != nil {
+			return 0, fmt.Errorf("failed to load device %s: %w", id, err)
+		}
+		commitments[id] = commitment
+
+		if _, err := tx.Exec(`
+			UPDATE devices SET rotation_required = TRUE, rotation_reason = ?, updated_at = ?
+			WHERE device_id = ?
+		`, reason, now, id); err != nil {
+			return 0, fmt.Errorf("failed to flag device %s: %w", id, err)
+		}
+
+		revoked, err := revokeDeviceSessionsTx(tx, id, SessionRevokeReasonRotation, now)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := insertRotationTx(tx, &models.CredentialRotation{
+			DeviceID:        id,
+			Event:           "forced",
+			OldCommitment:   commitment,
+			InitiatedBy:     initiatedBy,
+			Reason:          reason,
+			SessionsRevoked: revoked,
+			CreatedAt:       now,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit forced rotation: %w", err)
+	}
+
+	if s.credentialSync != nil {
+		for _, id := range deviceIDs {
+			s.credentialSync.SyncCredential(id, commitments[id], true, reason)
+		}
+	}
+
+	s.logger.Warn("Credential rotation forced",
+		zap.String("device_id", deviceID),
+		zap.Int("devices", len(deviceIDs)),
+		zap.String("initiated_by", initiatedBy),
+		zap.String("reason", reason))
+
+	return len(deviceIDs), nil
+}
+
+// ListCredentialRotations 查询设备凭证轮换记录
+func (s *Service) ListCredentialRotations(deviceID string, limit int) ([]*models.CredentialRotation, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, device_id, event, old_commitment, COALESCE(new_commitment, ''),
+		       initiated_by, COALESCE(reason, ''), sessions_revoked, created_at
+		FROM credential_rotations
+		WHERE device_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rotations := make([]*models.CredentialRotation, 0)
+	for rows.Next() {
+		var r models.CredentialRotation
+		if err := rows.Scan(&r.ID, &r.DeviceID, &r.Event, &r.OldCommitment, &r.NewCommitment,
+			&r.InitiatedBy, &r.Reason, &r.SessionsRevoked, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rotations = append(rotations, &r)
+	}
+
+	return rotations, nil
+}
+
+// validateCommitment 校验承诺值为合法的BN254标量域元素hex
+func validateCommitment(commitment string) error {
+	commitmentBytes, err := hex.DecodeString(commitment)
+	if err != nil {
+		return fmt.Errorf("commitment must be hex encoded: %w", err)
+	}
+	if len(commitmentBytes) != 32 {
+		return fmt.Errorf("commitment must be 32 bytes, got %d", len(commitmentBytes))
+	}
+	if new(big.Int).SetBytes(commitmentBytes).Cmp(ecc.BN254.ScalarField()) >= 0 {
+		return fmt.Errorf("commitment exceeds scalar field")
+	}
+	return nil
+}
+
+// insertRotationTx 在事务中写入轮换记录和系统日志
+func insertRotationTx(tx *sql.Tx, r *models.CredentialRotation) error {
+	if _, err := tx.Exec(`
+		INSERT INTO credential_rotations
+		(device_id, event, old_commitment, new_commitment, initiated_by, reason, sessions_revoked, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.DeviceID, r.Event, r.OldCommitment, r.NewCommitment, r.InitiatedBy, r.Reason,
+		r.SessionsRevoked, r.CreatedAt); err != nil {
+		return fmt.Errorf("failed to record rotation: %w", err)
+	}
+
+	details, _ := json.Marshal(map[string]interface{}{
+		"device_id":        r.DeviceID,
+		"event":            r.Event,
+		"initiated_by":     r.InitiatedBy,
+		"reason":           r.Reason,
+		"sessions_revoked": r.SessionsRevoked,
+	})
+	if _, err := tx.Exec(`
+		INSERT INTO system_logs (level, module, message, details, timestamp)
+		VALUES (?, ?, ?, ?, ?)
+	`, "warn", "auth", "credential_"+r.Event, string(details), r.CreatedAt); err != nil {
+		return fmt.Errorf("failed to write system log: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,381 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// fakeVerifier 证明为公开输入的哈希，公开输入（含挑战值）被篡改后证明即失效
+type fakeVerifier struct {
+	*zkp.SimpleVerifier
+
+	mu         sync.Mutex
+	keys       map[string]bool
+	defaultKey string
+}
+
+func newFakeVerifier(defaultKey string, keys ...string) *fakeVerifier {
+	v := &fakeVerifier{
+		SimpleVerifier: zkp.NewSimpleVerifier(zap.NewNop()),
+		keys:           map[string]bool{defaultKey: true},
+		defaultKey:     defaultKey,
+	}
+	for _, k := range keys {
+		v.keys[k] = true
+	}
+	return v
+}
+
+func fakeProof(keyID, deviceID, challenge, commitment, response string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join([]string{keyID, deviceID, challenge, commitment, response}, "|")))
+	return sum[:]
+}
+
+func (v *fakeVerifier) VerifyProofWithKey(keyID, deviceID, challenge, commitment, response string, proofData []byte) (bool, error) {
+	if !v.HasKey(keyID) {
+		return false, fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+	return string(proofData) == string(fakeProof(keyID, deviceID, challenge, commitment, response)), nil
+}
+
+func (v *fakeVerifier) DefaultKeyID() string { return v.defaultKey }
+
+func (v *fakeVerifier) HasKey(keyID string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.keys[keyID]
+}
+
+func (v *fakeVerifier) Keys() []zkp.KeyInfo {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]zkp.KeyInfo, 0, len(v.keys))
+	for k := range v.keys {
+		keys = append(keys, zkp.KeyInfo{KeyID: k, Default: k == v.defaultKey})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
+
+func (v *fakeVerifier) RetireKey(keyID string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.keys[keyID] {
+		return fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+	if keyID == v.defaultKey {
+		return fmt.Errorf("cannot retire default verifying key: %s", keyID)
+	}
+	delete(v.keys, keyID)
+	return nil
+}
+
+func newTestService(t *testing.T, cfg config.AuthConfig, verifier zkp.ZKPVerifier) *Service {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:             "sqlite3",
+		Path:               filepath.Join(t.TempDir(), "edge.db"),
+		MaxConnections:     1,
+		MaxIdleConnections: 1,
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if cfg.ChallengeTTL == 0 {
+		cfg.ChallengeTTL = time.Minute
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = time.Hour
+	}
+	cfg.Verification.Workers = 1
+
+	s := NewService(cfg, db, verifier, nil, zap.NewNop())
+	t.Cleanup(s.verifyPool.stop)
+	return s
+}
+
+func testCommitment(n int) string {
+	return fmt.Sprintf("%064x", n)
+}
+
+func addTestDevice(t *testing.T, s *Service, deviceID, commitment, circuitVersion string) {
+	t.Helper()
+	if _, err := s.db.Exec(`
+		INSERT INTO devices (device_id, device_type, sensor_type, public_key, commitment, status, circuit_version)
+		VALUES (?, 'sensor', 'co2', '', ?, 'online', ?)
+	`, deviceID, commitment, circuitVersion); err != nil {
+		t.Fatalf("failed to insert device: %v", err)
+	}
+}
+
+// proveChallenge 生成设备对挑战的证明
+func proveChallenge(challenge *models.Challenge, commitment, keyID string) *models.ZKProof {
+	const response = "01"
+	proof := fakeProof(keyID, challenge.DeviceID, challenge.Nonce, commitment, response)
+	return &models.ZKProof{
+		Proof: base64.StdEncoding.EncodeToString(proof),
+		PublicWitness: models.PublicWitness{
+			DeviceID:   deviceIDFieldHex(challenge.DeviceID),
+			Challenge:  challenge.Nonce,
+			Commitment: commitment,
+			Response:   response,
+		},
+		KeyID: keyID,
+	}
+}
+
+// rotationRequest 申请绑定newCommitment的轮换挑战并用当前承诺生成证明
+func rotationRequest(t *testing.T, s *Service, deviceID, commitment, newCommitment string) *models.CredentialRotationRequest {
+	t.Helper()
+	challenge, err := s.GenerateRotationChallenge(deviceID, newCommitment, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to generate rotation challenge: %v", err)
+	}
+	return &models.CredentialRotationRequest{
+		DeviceID:      deviceID,
+		ChallengeID:   challenge.ChallengeID,
+		Proof:         proveChallenge(challenge, commitment, zkp.LegacyKeyID),
+		NewCommitment: newCommitment,
+	}
+}
+
+func TestRotateCredential(t *testing.T) {
+	oldCommitment, newCommitment := testCommitment(1), testCommitment(2)
+
+	tests := []struct {
+		name    string
+		rotate  func(t *testing.T, s *Service) error
+		wantErr string
+	}{
+		{
+			name: "valid rotation",
+			rotate: func(t *testing.T, s *Service) error {
+				_, err := s.RotateCredential(rotationRequest(t, s, "sensor-1", oldCommitment, newCommitment), "10.0.0.1")
+				return err
+			},
+		},
+		{
+			name: "replayed rotation request",
+			rotate: func(t *testing.T, s *Service) error {
+				req := rotationRequest(t, s, "sensor-1", oldCommitment, newCommitment)
+				if _, err := s.RotateCredential(req, "10.0.0.1"); err != nil {
+					t.Fatalf("first rotation failed: %v", err)
+				}
+				_, err := s.RotateCredential(req, "10.0.0.2")
+				return err
+			},
+			wantErr: "challenge already used",
+		},
+		{
+			name: "proof rebound to another commitment",
+			rotate: func(t *testing.T, s *Service) error {
+				req := rotationRequest(t, s, "sensor-1", oldCommitment, newCommitment)
+				req.NewCommitment = testCommitment(3)
+				_, err := s.RotateCredential(req, "10.0.0.1")
+				return err
+			},
+			wantErr: "challenge not bound to new commitment",
+		},
+		{
+			name: "rejected attempt consumes the challenge",
+			rotate: func(t *testing.T, s *Service) error {
+				req := rotationRequest(t, s, "sensor-1", oldCommitment, newCommitment)
+				req.NewCommitment = testCommitment(3)
+				if _, err := s.RotateCredential(req, "10.0.0.1"); err == nil {
+					t.Fatal("rotation to an unbound commitment should fail")
+				}
+				req.NewCommitment = newCommitment
+				_, err := s.RotateCredential(req, "10.0.0.1")
+				return err
+			},
+			wantErr: "challenge already used",
+		},
+		{
+			name: "authentication challenge cannot rotate",
+			rotate: func(t *testing.T, s *Service) error {
+				challenge, err := s.GenerateChallenge("sensor-1", "10.0.0.1")
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = s.RotateCredential(&models.CredentialRotationRequest{
+					DeviceID:      "sensor-1",
+					ChallengeID:   challenge.ChallengeID,
+					Proof:         proveChallenge(challenge, oldCommitment, zkp.LegacyKeyID),
+					NewCommitment: newCommitment,
+				}, "10.0.0.1")
+				return err
+			},
+			wantErr: "challenge not bound to new commitment",
+		},
+		{
+			name: "rotation challenge cannot authenticate",
+			rotate: func(t *testing.T, s *Service) error {
+				req := rotationRequest(t, s, "sensor-1", oldCommitment, newCommitment)
+				_, err := s.VerifyProof(&models.AuthRequest{
+					DeviceID:    req.DeviceID,
+					ChallengeID: req.ChallengeID,
+					Proof:       req.Proof,
+				}, "10.0.0.1")
+				return err
+			},
+			wantErr: "challenge purpose mismatch",
+		},
+		{
+			name: "proof from another secret",
+			rotate: func(t *testing.T, s *Service) error {
+				req := rotationRequest(t, s, "sensor-1", testCommitment(9), newCommitment)
+				_, err := s.RotateCredential(req, "10.0.0.1")
+				return err
+			},
+			wantErr: "commitment mismatch in witness",
+		},
+		{
+			name: "unchanged commitment",
+			rotate: func(t *testing.T, s *Service) error {
+				_, err := s.RotateCredential(rotationRequest(t, s, "sensor-1", oldCommitment, oldCommitment), "10.0.0.1")
+				return err
+			},
+			wantErr: "must differ",
+		},
+		{
+			name: "malformed commitment",
+			rotate: func(t *testing.T, s *Service) error {
+				_, err := s.GenerateRotationChallenge("sensor-1", "not-hex", "10.0.0.1")
+				return err
+			},
+			wantErr: "invalid new commitment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t, config.AuthConfig{MaxRetry: 10}, newFakeVerifier(zkp.LegacyKeyID))
+			addTestDevice(t, s, "sensor-1", oldCommitment, "")
+			if _, err := s.createSession("sensor-1", "10.0.0.1"); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.rotate(t, s)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			device, err := s.getDevice("sensor-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if device.Commitment != newCommitment {
+				t.Fatalf("expected commitment %s, got %s", newCommitment, device.Commitment)
+			}
+			if active, _ := s.ListSessions("sensor-1", true); len(active) != 0 {
+				t.Fatalf("expected sessions revoked after rotation, got %d", len(active))
+			}
+			rotations, err := s.ListCredentialRotations("sensor-1", 10)
+			if err != nil || len(rotations) != 1 || rotations[0].Event != "rotated" || rotations[0].SessionsRevoked != 1 {
+				t.Fatalf("unexpected rotation records: %+v (%v)", rotations, err)
+			}
+		})
+	}
+}
+
+func TestForceRotation(t *testing.T) {
+	oldCommitment := testCommitment(1)
+	s := newTestService(t, config.AuthConfig{}, newFakeVerifier(zkp.LegacyKeyID))
+	addTestDevice(t, s, "sensor-1", oldCommitment, "")
+	addTestDevice(t, s, "sensor-2", testCommitment(5), "")
+	for _, id := range []string{"sensor-1", "sensor-2"} {
+		if _, err := s.createSession(id, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.ForceRotation("missing", "leak", RotationInitiatorAdmin); err == nil {
+		t.Fatal("forcing rotation of an unknown device should fail")
+	}
+
+	n, err := s.ForceRotation("", "key leak", RotationInitiatorCloud)
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 devices flagged, got %d (%v)", n, err)
+	}
+	if active, _ := s.ListSessions("", true); len(active) != 0 {
+		t.Fatalf("expected all sessions revoked, got %d", len(active))
+	}
+
+	// 被标记的设备认证被拒绝，且该挑战已被消费，不能再用于轮换
+	challenge, err := s.GenerateChallenge("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := proveChallenge(challenge, oldCommitment, zkp.LegacyKeyID)
+	if _, err := s.VerifyProof(&models.AuthRequest{DeviceID: "sensor-1", ChallengeID: challenge.ChallengeID, Proof: proof}, "10.0.0.1"); err == nil ||
+		!strings.Contains(err.Error(), "CREDENTIAL_001") {
+		t.Fatalf("expected CREDENTIAL_001, got %v", err)
+	}
+	if _, err := s.RotateCredential(&models.CredentialRotationRequest{
+		DeviceID:      "sensor-1",
+		ChallengeID:   challenge.ChallengeID,
+		Proof:         proof,
+		NewCommitment: testCommitment(2),
+	}, "10.0.0.1"); err == nil || !strings.Contains(err.Error(), "challenge already used") {
+		t.Fatalf("refused authentication challenge must not be reusable, got %v", err)
+	}
+
+	// 使用轮换挑战完成轮换后清除标记并可正常认证
+	if _, err := s.RotateCredential(rotationRequest(t, s, "sensor-1", oldCommitment, testCommitment(2)), "10.0.0.1"); err != nil {
+		t.Fatalf("rotation failed: %v", err)
+	}
+	device, err := s.getDevice("sensor-1")
+	if err != nil || device.RotationRequired || device.Commitment != testCommitment(2) {
+		t.Fatalf("unexpected device after rotation: %+v (%v)", device, err)
+	}
+	challenge, err = s.GenerateChallenge("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyProof(&models.AuthRequest{
+		DeviceID:    "sensor-1",
+		ChallengeID: challenge.ChallengeID,
+		Proof:       proveChallenge(challenge, testCommitment(2), zkp.LegacyKeyID),
+	}, "10.0.0.1"); err != nil {
+		t.Fatalf("authentication after rotation failed: %v", err)
+	}
+
+	rotations, err := s.ListCredentialRotations("sensor-1", 10)
+	if err != nil || len(rotations) != 2 {
+		t.Fatalf("expected forced and rotated records, got %+v (%v)", rotations, err)
+	}
+	events := map[string]bool{}
+	for _, r := range rotations {
+		events[r.Event] = true
+	}
+	if !events["forced"] || !events["rotated"] {
+		t.Fatalf("unexpected rotation events: %+v", rotations)
+	}
+
+	if other, _ := s.getDevice("sensor-2"); !other.RotationRequired || other.RotationReason != "key leak" {
+		t.Fatalf("sensor-2 should still require rotation: %+v", other)
+	}
+}
@@ -6,11 +6,13 @@ package auth
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/edge/storage-cabinet/internal/config"
@@ -38,8 +40,13 @@ type Service struct {
 	jwtSecret    []byte
 	challengeTTL time.Duration
 	sessionTTL   time.Duration
-	maxRetry     int
-	retryCount   map[string]int // 设备重试次数记录
+	lockout      *lockoutTracker // 设备/IP失败计数与锁定
+	maxPending   int             // 单设备单来源IP未使用挑战上限
+	maxSessions  int             // 单设备活跃会话上限
+	verifyPool   *verifyPool     // 证明验证工作池
+
+	credentialSync CredentialSync // 凭证变更回调（可选，用于刷新设备缓存）
+	accessLogger   AccessLogger   // ABAC访问日志（可选，记录锁定事件）
 }
 
 // NewService 创建认证服务
@@ -54,6 +61,16 @@ func NewService(cfg config.AuthConfig, db *storage.SQLiteDB, verifier zkp.ZKPVer
 		logger.Warn("JWT_SECRET not set, using random secret")
 	}
 
+	maxSessions := cfg.MaxSessionsPerDevice
+	if maxSessions <= 0 {
+		maxSessions = defaultMaxSessionsPerDevice
+	}
+
+	maxPending := cfg.Lockout.MaxPendingChallenges
+	if maxPending <= 0 {
+		maxPending = defaultMaxPendingChallenges
+	}
+
 	return &Service{
 		logger:       logger,
 		db:           db,
@@ -62,13 +79,27 @@ func NewService(cfg config.AuthConfig, db *storage.SQLiteDB, verifier zkp.ZKPVer
 		jwtSecret:    []byte(jwtSecret),
 		challengeTTL: cfg.ChallengeTTL,
 		sessionTTL:   cfg.SessionTTL,
-		maxRetry:     cfg.MaxRetry,
-		retryCount:   make(map[string]int),
+		lockout:      newLockoutTracker(cfg.Lockout, cfg.MaxRetry),
+		maxPending:   maxPending,
+		maxSessions:  maxSessions,
+		verifyPool:   newVerifyPool(cfg.Verification, verifier, logger),
 	}
 }
 
+// 挑战用途
+const (
+	ChallengePurposeAuth     = "auth"
+	ChallengePurposeRotation = "rotation"
+)
+
 // GenerateChallenge 生成认证挑战
-func (s *Service) GenerateChallenge(deviceID string) (*models.Challenge, error) {
+// clientIP 为请求来源IP，用于失败计数和锁定
+func (s *Service) GenerateChallenge(deviceID, clientIP string) (*models.Challenge, error) {
+	return s.issueChallenge(deviceID, clientIP, "")
+}
+
+// issueChallenge 签发挑战，newCommitment非空时签发与其绑定的凭证轮换挑战
+func (s *Service) issueChallenge(deviceID, clientIP, newCommitment string) (*models.Challenge, error) {
 	// 【SPA单包授权】许可证校验（单点门控）
 	if s.license != nil && s.license.IsEnabled() {
 		if err := s.license.Check(); err != nil {
@@ -79,18 +110,34 @@ func (s *Service) GenerateChallenge(deviceID string) (*models.Challenge, error)
 		}
 	}
 
-	// 检查设备是否存在
+	// 检查设备和来源IP是否被锁定
+	if err := s.checkLockout(deviceID, clientIP); err != nil {
+		return nil, err
+	}
+
+	// 检查设备是否存在（未知设备计入来源IP失败次数，防止枚举）
 	device, err := s.getDevice(deviceID)
 	if err != nil {
-		return nil, fmt.Errorf("device not found: %w", err)
+		err = fmt.Errorf("device not found: %w", err)
+		s.recordAuthFailure("", clientIP, err)
+		return nil, err
 	}
 
-	// 检查重试次数
-	if count := s.retryCount[deviceID]; count >= s.maxRetry {
-		s.logger.Warn("Too many auth attempts",
+	// 限制单设备在同一来源IP上未使用的挑战数量
+	// （按设备和IP共同计数，其他来源无法占满设备的挑战名额）
+	var pending int
+	if err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM challenges
+		WHERE device_id = ? AND client_ip = ? AND used = FALSE AND expires_at > ?
+	`, deviceID, clientIP, time.Now()).Scan(&pending); err != nil {
+		return nil, fmt.Errorf("failed to count pending challenges: %w", err)
+	}
+	if pending >= s.maxPending {
+		s.logger.Warn("Too many pending challenges",
 			zap.String("device_id", deviceID),
-			zap.Int("attempts", count))
-		return nil, fmt.Errorf("too many authentication attempts")
+			zap.String("client_ip", clientIP),
+			zap.Int("pending", pending))
+		return nil, fmt.Errorf("CHALLENGE_LIMIT: too many pending challenges")
 	}
 
 	// 生成挑战
@@ -107,126 +154,213 @@ func (s *Service) GenerateChallenge(deviceID string) (*models.Challenge, error)
 		CreatedAt:   time.Now(),
 		ExpiresAt:   time.Now().Add(s.challengeTTL),
 		Used:        false,
+		ClientIP:    clientIP,
+		Purpose:     ChallengePurposeAuth,
+	}
+
+	// 轮换挑战：随机数作为盐值，挑战值绑定新承诺
+	if newCommitment != "" {
+		challenge.Purpose = ChallengePurposeRotation
+		challenge.BindingSalt = nonce
+		challenge.NewCommitment = newCommitment
+		challenge.Nonce, err = zkp.ComputeRotationChallenge(nonce, newCommitment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bind rotation challenge: %w", err)
+		}
 	}
 
 	// 保存到数据库
 	if err := s.saveChallenge(challenge); err != nil {
 		return nil, fmt.Errorf("failed to save challenge: %w", err)
 	}
+	s.fillChallengeKeys(challenge, device)
 
 	s.logger.Info("Challenge created",
 		zap.String("device_id", deviceID),
-		zap.String("challenge_id", challenge.ChallengeID))
+		zap.String("challenge_id", challenge.ChallengeID),
+		zap.String("purpose", challenge.Purpose))
 
 	return challenge, nil
 }
 
 // VerifyProof 验证零知识证明
-func (s *Service) VerifyProof(req *models.AuthRequest) (*models.Session, error) {
+func (s *Service) VerifyProof(req *models.AuthRequest, clientIP string) (*models.Session, error) {
+	device, keyID, err := s.verifyDeviceProof(req.DeviceID, req.ChallengeID, req.Proof, "", clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	// 被要求轮换凭证的设备不再签发会话，必须先使用轮换挑战完成轮换
+	// （挑战已在验证时消费，被拒绝的证明无法再用于轮换）
+	if device.RotationRequired {
+		s.logger.Warn("Credential rotation required, session refused",
+			zap.String("device_id", device.DeviceID),
+			zap.String("reason", device.RotationReason))
+		return nil, fmt.Errorf("CREDENTIAL_001: credential rotation required")
+	}
+
+	// 创建会话
+	session, err := s.createSession(device.DeviceID, clientIP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	// 生成JWT令牌
+	token, err := s.GenerateToken(device.DeviceID, session.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	// 清除失败计数
+	s.recordAuthSuccess(req.DeviceID)
+
+	// 使用默认key认证成功，记录设备已迁移到新电路版本
+	if current := deviceKeyID(device); keyID != current {
+		s.migrateDeviceCircuit(device.DeviceID, current, keyID)
+	}
+
+	s.logger.Info("Authentication successful",
+		zap.String("device_id", req.DeviceID),
+		zap.String("session_id", session.SessionID))
+
+	session.Token = token
+	return session, nil
+}
+
+// verifyDeviceProof 校验挑战并验证设备基于当前secret生成的证明
+// 认证和凭证轮换共用该流程：newCommitment为空时只接受认证挑战，否则只接受绑定该承诺的轮换挑战。
+// 挑战在验证前被消费，此后任何结果（包括后续被拒绝）都不能再次使用；失败计入设备和来源IP的失败次数
+// 返回设备信息和验证所用的verifying key ID
+func (s *Service) verifyDeviceProof(deviceID, challengeID string, proof *models.ZKProof, newCommitment, clientIP string) (*models.Device, string, error) {
+	if err := s.checkLockout(deviceID, clientIP); err != nil {
+		return nil, "", err
+	}
+
+	device, keyID, err := s.checkDeviceProof(deviceID, challengeID, proof, newCommitment)
+	if err != nil {
+		// 验证繁忙或超时不属于认证失败
+		if !strings.Contains(err.Error(), "VERIFY_BUSY") && !strings.Contains(err.Error(), "VERIFY_TIMEOUT") {
+			s.recordAuthFailure(deviceID, clientIP, err)
+		}
+		return nil, "", err
+	}
+	return device, keyID, nil
+}
+
+// checkDeviceProof 校验挑战、公开见证和零知识证明
+func (s *Service) checkDeviceProof(deviceID, challengeID string, proof *models.ZKProof, newCommitment string) (*models.Device, string, error) {
 	// 获取挑战
-	challenge, err := s.getChallenge(req.ChallengeID)
+	challenge, err := s.getChallenge(challengeID)
 	if err != nil {
-		s.retryCount[req.DeviceID]++
-		return nil, fmt.Errorf("invalid challenge: %w", err)
+		return nil, "", fmt.Errorf("invalid challenge: %w", err)
+	}
+
+	// 检查挑战是否属于该设备
+	if challenge.DeviceID != deviceID {
+		return nil, "", fmt.Errorf("challenge does not belong to device")
 	}
 
 	// 检查挑战是否过期
 	if time.Now().After(challenge.ExpiresAt) {
-		s.retryCount[req.DeviceID]++
-		return nil, fmt.Errorf("challenge expired")
+		return nil, "", fmt.Errorf("challenge expired")
+	}
+
+	// 消费挑战（原子操作，防止并发重放）
+	if err := s.claimChallenge(challengeID); err != nil {
+		return nil, "", err
 	}
 
-	// 检查挑战是否已使用
-	if challenge.Used {
-		s.retryCount[req.DeviceID]++
-		return nil, fmt.Errorf("challenge already used")
+	// 检查挑战用途，轮换挑战的公开输入由盐值和提交的新承诺重新计算
+	nonce := challenge.Nonce
+	if newCommitment == "" {
+		if challenge.Purpose != ChallengePurposeAuth {
+			return nil, "", fmt.Errorf("challenge purpose mismatch: %s", challenge.Purpose)
+		}
+	} else {
+		if challenge.Purpose != ChallengePurposeRotation || challenge.NewCommitment != newCommitment {
+			return nil, "", fmt.Errorf("challenge not bound to new commitment")
+		}
+		nonce, err = zkp.ComputeRotationChallenge(challenge.BindingSalt, newCommitment)
+		if err != nil || nonce != challenge.Nonce {
+			return nil, "", fmt.Errorf("challenge not bound to new commitment")
+		}
 	}
 
 	// 获取设备信息
-	device, err := s.getDevice(req.DeviceID)
+	device, err := s.getDevice(deviceID)
 	if err != nil {
-		s.retryCount[req.DeviceID]++
-		return nil, fmt.Errorf("device not found: %w", err)
+		return nil, "", fmt.Errorf("device not found: %w", err)
 	}
 
 	// 从PublicWitness对象中提取参数（新格式：对象而非数组）
-	pw := req.Proof.PublicWitness
+	pw := proof.PublicWitness
 	if pw.DeviceID == "" || pw.Challenge == "" || pw.Commitment == "" || pw.Response == "" {
-		return nil, fmt.Errorf("invalid public witness: missing required fields")
+		return nil, "", fmt.Errorf("invalid public witness: missing required fields")
 	}
 
 	// 验证公开见证的一致性
 	// ✅ 修复：将DeviceID转换为域元素hex后再比较
-	deviceIDBytes := []byte(device.DeviceID)
-	deviceIDBig := new(big.Int).SetBytes(deviceIDBytes)
-	deviceIDFieldBytes := make([]byte, 32)
-	deviceIDBig.FillBytes(deviceIDFieldBytes)
-	expectedDeviceIDHex := hex.EncodeToString(deviceIDFieldBytes)
-
-	if pw.DeviceID != expectedDeviceIDHex {
-		return nil, fmt.Errorf("device ID mismatch in witness")
+	if pw.DeviceID != deviceIDFieldHex(device.DeviceID) {
+		return nil, "", fmt.Errorf("device ID mismatch in witness")
 	}
-	if pw.Challenge != challenge.Nonce {
-		return nil, fmt.Errorf("challenge mismatch in witness")
+	if pw.Challenge != nonce {
+		return nil, "", fmt.Errorf("challenge mismatch in witness")
 	}
 	if pw.Commitment != device.Commitment {
-		return nil, fmt.Errorf("commitment mismatch in witness")
+		return nil, "", fmt.Errorf("commitment mismatch in witness")
 	}
 
 	// 解码Base64 proof数据（新格式：Base64字符串而非字节数组）
-	proofBytes, err := base64.StdEncoding.DecodeString(req.Proof.Proof)
+	proofBytes, err := base64.StdEncoding.DecodeString(proof.Proof)
 	if err != nil {
 		s.logger.Error("Failed to decode proof", zap.Error(err))
-		return nil, fmt.Errorf("failed to decode proof: %w", err)
+		return nil, "", fmt.Errorf("failed to decode proof: %w", err)
+	}
+
+	// 选择verifying key（设备当前电路版本或迁移目标）
+	keyID, err := s.selectProofKey(device, proof.KeyID)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// 验证零知识证明
-	valid, err := s.verifier.VerifyProof(
-		device.DeviceID,
-		challenge.Nonce,
-		device.Commitment,
-		pw.Response,
-		proofBytes,
-	)
+	valid, err := s.verifyPool.verify(keyID, zkp.BatchItem{
+		DeviceID:   device.DeviceID,
+		Challenge:  nonce,
+		Commitment: device.Commitment,
+		Response:   pw.Response,
+		Proof:      proofBytes,
+	})
 	if err != nil {
+		// 队列已满时验证从未开始，归还挑战让设备重试；
+		// 等待超时时验证可能仍在进行，挑战保持已消费，设备需重新获取挑战，避免同一挑战被接受两次
+		if strings.Contains(err.Error(), "VERIFY_BUSY") {
+			s.releaseChallenge(challengeID)
+			return nil, "", err
+		}
+		if strings.Contains(err.Error(), "VERIFY_TIMEOUT") {
+			return nil, "", err
+		}
 		s.logger.Error("Failed to verify proof", zap.Error(err))
-		return nil, fmt.Errorf("verification failed: %w", err)
+		return nil, "", fmt.Errorf("verification failed: %w", err)
 	}
 
 	if !valid {
-		s.retryCount[req.DeviceID]++
 		s.logger.Warn("Invalid proof",
-			zap.String("device_id", req.DeviceID),
-			zap.String("challenge_id", req.ChallengeID))
-		return nil, fmt.Errorf("invalid proof")
-	}
-
-	// 标记挑战已使用
-	if err := s.markChallengeUsed(req.ChallengeID); err != nil {
-		s.logger.Error("Failed to mark challenge as used", zap.Error(err))
-	}
-
-	// 创建会话
-	session, err := s.createSession(device.DeviceID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
-	}
-
-	// 生成JWT令牌
-	token, err := s.GenerateToken(device.DeviceID, session.SessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+			zap.String("device_id", deviceID),
+			zap.String("challenge_id", challengeID))
+		return nil, "", fmt.Errorf("invalid proof")
 	}
 
-	// 重置重试计数
-	delete(s.retryCount, req.DeviceID)
-
-	s.logger.Info("Authentication successful",
-		zap.String("device_id", req.DeviceID),
-		zap.String("session_id", session.SessionID))
+	return device, keyID, nil
+}
 
-	session.Token = token
-	return session, nil
+// deviceIDFieldHex 将设备ID转换为32字节域元素的hex表示（与电路公开输入一致）
+func deviceIDFieldHex(deviceID string) string {
+	deviceIDBig := new(big.Int).SetBytes([]byte(deviceID))
+	deviceIDFieldBytes := make([]byte, 32)
+	deviceIDBig.FillBytes(deviceIDFieldBytes)
+	return hex.EncodeToString(deviceIDFieldBytes)
 }
 
 // ValidateToken 验证令牌
@@ -244,12 +378,20 @@ func (s *Service) ValidateToken(tokenString string) (*models.TokenClaims, error)
 	}
 
 	if claims, ok := token.Claims.(*models.TokenClaims); ok && token.Valid {
-		// 检查会话是否有效
+		// 检查会话是否有效（每次请求都查询会话表，撤销立即生效）
 		session, err := s.getSession(claims.SessionID)
 		if err != nil {
 			return nil, fmt.Errorf("session not found")
 		}
 
+		if session.RevokedAt != nil {
+			return nil, fmt.Errorf("SESSION_REVOKED: session revoked (%s)", session.RevokedReason)
+		}
+
+		if session.DeviceID != claims.DeviceID {
+			return nil, fmt.Errorf("session does not belong to device")
+		}
+
 		if time.Now().After(session.ExpiresAt) {
 			return nil, fmt.Errorf("session expired")
 		}
@@ -298,40 +440,52 @@ func (s *Service) RefreshSession(tokenString string) (*models.Session, error) {
 	return session, nil
 }
 
-// RevokeSession 撤销会话
-func (s *Service) RevokeSession(sessionID string) error {
-	query := `DELETE FROM sessions WHERE session_id = ?`
-	_, err := s.db.Exec(query, sessionID)
-	if err != nil {
-		return fmt.Errorf("failed to revoke session: %w", err)
-	}
-
-	s.logger.Info("Session revoked", zap.String("session_id", sessionID))
-	return nil
-}
-
 // createSession 创建会话
-func (s *Service) createSession(deviceID string) (*models.Session, error) {
+// 设备活跃会话达到上限时，撤销最久未使用的会话
+func (s *Service) createSession(deviceID, clientIP string) (*models.Session, error) {
+	now := time.Now()
 	session := &models.Session{
 		SessionID:  uuid.New().String(),
 		DeviceID:   deviceID,
 		Token:      "", // 将在生成JWT后更新
-		CreatedAt:  time.Now(),
-		ExpiresAt:  time.Now().Add(s.sessionTTL),
-		LastUsedAt: time.Now(),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.sessionTTL),
+		LastUsedAt: now,
+		IPAddress:  clientIP,
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	evicted, err := s.enforceSessionLimitTx(tx, deviceID, now)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
-		INSERT INTO sessions (session_id, device_id, token, created_at, expires_at, last_used_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO sessions (session_id, device_id, token, created_at, expires_at, last_used_at, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := s.db.Exec(query,
+	_, err = tx.Exec(query,
 		session.SessionID, session.DeviceID, session.Token,
-		session.CreatedAt, session.ExpiresAt, session.LastUsedAt)
+		session.CreatedAt, session.ExpiresAt, session.LastUsedAt, session.IPAddress)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if evicted > 0 {
+		s.logger.Info("Session limit reached, oldest sessions revoked",
+			zap.String("device_id", deviceID),
+			zap.Int64("revoked", evicted))
+	}
+
 	return session, nil
 }
 
@@ -359,13 +513,17 @@ func (s *Service) getDevice(deviceID string) (*models.Device, error) {
 	var device models.Device
 	query := `
 		SELECT device_id, device_type, sensor_type,
-		       public_key, commitment, status
+		       public_key, commitment, status,
+		       COALESCE(rotation_required, FALSE), COALESCE(rotation_reason, ''),
+		       COALESCE(circuit_version, '')
 		FROM devices WHERE device_id = ?
 	`
 	err := s.db.QueryRow(query, deviceID).Scan(
 		&device.DeviceID, &device.DeviceType, &device.SensorType,
 		&device.PublicKey, &device.Commitment,
 		&device.Status,
+		&device.RotationRequired, &device.RotationReason,
+		&device.CircuitVersion,
 	)
 	if err != nil {
 		return nil, err
@@ -377,12 +535,14 @@ func (s *Service) getDevice(deviceID string) (*models.Device, error) {
 
 func (s *Service) saveChallenge(challenge *models.Challenge) error {
 	query := `
-		INSERT INTO challenges (challenge_id, device_id, nonce, created_at, expires_at, used)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO challenges (challenge_id, device_id, nonce, created_at, expires_at, used, client_ip,
+		                        purpose, binding_salt, new_commitment)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err := s.db.Exec(query,
 		challenge.ChallengeID, challenge.DeviceID, challenge.Nonce,
-		challenge.CreatedAt, challenge.ExpiresAt, challenge.Used,
+		challenge.CreatedAt, challenge.ExpiresAt, challenge.Used, challenge.ClientIP,
+		challenge.Purpose, challenge.BindingSalt, challenge.NewCommitment,
 	)
 	return err
 }
@@ -390,12 +550,14 @@ func (s *Service) saveChallenge(challenge *models.Challenge) error {
 func (s *Service) getChallenge(challengeID string) (*models.Challenge, error) {
 	var challenge models.Challenge
 	query := `
-		SELECT challenge_id, device_id, nonce, created_at, expires_at, used
+		SELECT challenge_id, device_id, nonce, created_at, expires_at, used, COALESCE(client_ip, ''),
+		       COALESCE(purpose, 'auth'), COALESCE(binding_salt, ''), COALESCE(new_commitment, '')
 		FROM challenges WHERE challenge_id = ?
 	`
 	err := s.db.QueryRow(query, challengeID).Scan(
 		&challenge.ChallengeID, &challenge.DeviceID, &challenge.Nonce,
-		&challenge.CreatedAt, &challenge.ExpiresAt, &challenge.Used,
+		&challenge.CreatedAt, &challenge.ExpiresAt, &challenge.Used, &challenge.ClientIP,
+		&challenge.Purpose, &challenge.BindingSalt, &challenge.NewCommitment,
 	)
 	if err != nil {
 		return nil, err
@@ -403,25 +565,44 @@ func (s *Service) getChallenge(challengeID string) (*models.Challenge, error) {
 	return &challenge, nil
 }
 
-func (s *Service) markChallengeUsed(challengeID string) error {
-	query := `UPDATE challenges SET used = TRUE WHERE challenge_id = ?`
-	_, err := s.db.Exec(query, challengeID)
-	return err
+// claimChallenge 将未使用的挑战标记为已使用，已被使用时返回错误
+func (s *Service) claimChallenge(challengeID string) error {
+	result, err := s.db.Exec(`UPDATE challenges SET used = TRUE WHERE challenge_id = ? AND used = FALSE`, challengeID)
+	if err != nil {
+		return fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("challenge already used")
+	}
+	return nil
+}
+
+// releaseChallenge 归还已消费但未开始验证的挑战（仅用于验证队列已满）
+func (s *Service) releaseChallenge(challengeID string) {
+	if _, err := s.db.Exec(`UPDATE challenges SET used = FALSE WHERE challenge_id = ?`, challengeID); err != nil {
+		s.logger.Error("Failed to release challenge", zap.Error(err))
+	}
 }
 
 func (s *Service) getSession(sessionID string) (*models.Session, error) {
 	var session models.Session
 	query := `
-		SELECT session_id, device_id, token, created_at, expires_at, last_used_at
+		SELECT session_id, device_id, token, created_at, expires_at, last_used_at,
+		       revoked_at, COALESCE(revoked_reason, '')
 		FROM sessions WHERE session_id = ?
 	`
+	var revokedAt sql.NullTime
 	err := s.db.QueryRow(query, sessionID).Scan(
 		&session.SessionID, &session.DeviceID, &session.Token,
 		&session.CreatedAt, &session.ExpiresAt, &session.LastUsedAt,
+		&revokedAt, &session.RevokedReason,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
 	return &session, nil
 }
 
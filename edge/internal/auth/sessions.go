@@ -0,0 +1,186 @@
+/*
+ * 会话管理
+ * 会话查询、撤销以及单设备并发会话上限
+ */
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 会话撤销原因
+const (
+	SessionRevokeReasonAdmin    = "admin"
+	SessionRevokeReasonCloud    = "cloud"
+	SessionRevokeReasonLimit    = "session_limit"
+	SessionRevokeReasonRotation = "credential_rotation"
+)
+
+// defaultMaxSessionsPerDevice 单设备活跃会话默认上限
+const defaultMaxSessionsPerDevice = 5
+
+// ListSessions 查询会话列表，deviceID为空表示全部设备
+// activeOnly为true时只返回未撤销且未过期的会话
+func (s *Service) ListSessions(deviceID string, activeOnly bool) ([]models.SessionInfo, error) {
+	query := `
+		SELECT session_id, device_id, created_at, expires_at, last_used_at,
+		       COALESCE(ip_address, ''), COALESCE(user_agent, ''),
+		       revoked_at, COALESCE(revoked_reason, '')
+		FROM sessions WHERE 1=1
+	`
+	args := []interface{}{}
+	if deviceID != "" {
+		query += ` AND device_id = ?`
+		args = append(args, deviceID)
+	}
+	if activeOnly {
+		query += ` AND revoked_at IS NULL AND expires_at > ?`
+		args = append(args, time.Now())
+	}
+	query += ` ORDER BY last_used_at DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	sessions := make([]models.SessionInfo, 0)
+	for rows.Next() {
+		var info models.SessionInfo
+		var revokedAt sql.NullTime
+		if err := rows.Scan(
+			&info.SessionID, &info.DeviceID, &info.CreatedAt, &info.ExpiresAt, &info.LastUsedAt,
+			&info.IPAddress, &info.UserAgent, &revokedAt, &info.RevokedReason,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		if revokedAt.Valid {
+			info.RevokedAt = &revokedAt.Time
+		}
+		info.Active = !revokedAt.Valid && now.Before(info.ExpiresAt)
+		sessions = append(sessions, info)
+	}
+
+	return sessions, rows.Err()
+}
+
+// CheckSession 检查会话是否仍有效（未撤销且未过期）
+// 用于长连接（如MQTT）定期复查：刷新令牌只延长会话，不影响已建立的连接
+func (s *Service) CheckSession(sessionID string) error {
+	session, err := s.getSession(sessionID)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+	if session.RevokedAt != nil {
+		return fmt.Errorf("SESSION_REVOKED: session revoked (%s)", session.RevokedReason)
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return fmt.Errorf("session expired")
+	}
+	return nil
+}
+
+// RevokeSession 撤销单个会话
+func (s *Service) RevokeSession(sessionID, reason string) error {
+	result, err := s.db.Exec(`
+		UPDATE sessions SET revoked_at = ?, revoked_reason = ?
+		WHERE session_id = ? AND revoked_at IS NULL
+	`, time.Now(), reason, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("session not found or already revoked")
+	}
+
+	s.logger.Info("Session revoked",
+		zap.String("session_id", sessionID),
+		zap.String("reason", reason))
+	return nil
+}
+
+// RevokeDeviceSessions 撤销设备的所有会话
+func (s *Service) RevokeDeviceSessions(deviceID, reason string) (int64, error) {
+	return s.revokeSessions(deviceID, reason)
+}
+
+// RevokeAllSessions 撤销本储能柜所有设备的会话
+func (s *Service) RevokeAllSessions(reason string) (int64, error) {
+	return s.revokeSessions("", reason)
+}
+
+// revokeSessions 撤销会话，deviceID为空表示全部设备
+func (s *Service) revokeSessions(deviceID, reason string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	revoked, err := revokeDeviceSessionsTx(tx, deviceID, reason, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit session revocation: %w", err)
+	}
+
+	s.logger.Info("Sessions revoked",
+		zap.String("device_id", deviceID),
+		zap.String("reason", reason),
+		zap.Int64("revoked", revoked))
+	return revoked, nil
+}
+
+// revokeDeviceSessionsTx 在事务中撤销设备的所有未撤销会话，deviceID为空表示全部设备
+func revokeDeviceSessionsTx(tx *sql.Tx, deviceID, reason string, now time.Time) (int64, error) {
+	query := `UPDATE sessions SET revoked_at = ?, revoked_reason = ? WHERE revoked_at IS NULL`
+	args := []interface{}{now, reason}
+	if deviceID != "" {
+		query += ` AND device_id = ?`
+		args = append(args, deviceID)
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// enforceSessionLimitTx 为新会话腾出名额：活跃会话达到上限时撤销最久未使用的会话
+func (s *Service) enforceSessionLimitTx(tx *sql.Tx, deviceID string, now time.Time) (int64, error) {
+	var active int
+	if err := tx.QueryRow(`
+		SELECT COUNT(*) FROM sessions
+		WHERE device_id = ? AND revoked_at IS NULL AND expires_at > ?
+	`, deviceID, now).Scan(&active); err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	excess := active - s.maxSessions + 1
+	if excess <= 0 {
+		return 0, nil
+	}
+
+	result, err := tx.Exec(`
+		UPDATE sessions SET revoked_at = ?, revoked_reason = ?
+		WHERE session_id IN (
+			SELECT session_id FROM sessions
+			WHERE device_id = ? AND revoked_at IS NULL AND expires_at > ?
+			ORDER BY last_used_at ASC
+			LIMIT ?
+		)
+	`, now, SessionRevokeReasonLimit, deviceID, now, excess)
+	if err != nil {
+		return 0, fmt.Errorf("failed to evict sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
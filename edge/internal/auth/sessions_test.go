@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+)
+
+func TestSessionLimitEvictsLeastRecentlyUsed(t *testing.T) {
+	s := newTestService(t, config.AuthConfig{MaxSessionsPerDevice: 2}, newFakeVerifier(zkp.LegacyKeyID))
+	addTestDevice(t, s, "sensor-1", testCommitment(1), "")
+	addTestDevice(t, s, "sensor-2", testCommitment(2), "")
+
+	first, err := s.createSession("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.createSession("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.createSession("sensor-2", "10.0.0.2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// first最近被使用，second成为最久未使用的会话
+	if _, err := s.db.Exec(`UPDATE sessions SET last_used_at = ? WHERE session_id = ?`,
+		time.Now().Add(-time.Minute), second.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	third, err := s.createSession("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		wantErr   string
+	}{
+		{name: "recently used session kept", sessionID: first.SessionID},
+		{name: "new session active", sessionID: third.SessionID},
+		{name: "least recently used evicted", sessionID: second.SessionID, wantErr: "SESSION_REVOKED: session revoked (" + SessionRevokeReasonLimit + ")"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.CheckSession(tt.sessionID)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if active, _ := s.ListSessions("sensor-2", true); len(active) != 1 {
+		t.Fatalf("other devices must not be affected by the limit, got %d", len(active))
+	}
+}
+
+func TestListAndRevokeSessions(t *testing.T) {
+	s := newTestService(t, config.AuthConfig{}, newFakeVerifier(zkp.LegacyKeyID))
+	addTestDevice(t, s, "sensor-1", testCommitment(1), "")
+	addTestDevice(t, s, "sensor-2", testCommitment(2), "")
+
+	ids := map[string][]string{}
+	for _, deviceID := range []string{"sensor-1", "sensor-1", "sensor-1", "sensor-2", "sensor-2"} {
+		session, err := s.createSession(deviceID, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[deviceID] = append(ids[deviceID], session.SessionID)
+	}
+
+	// 过期一个会话，撤销一个会话
+	if _, err := s.db.Exec(`UPDATE sessions SET expires_at = ? WHERE session_id = ?`,
+		time.Now().Add(-time.Second), ids["sensor-1"][0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(ids["sensor-1"][1], SessionRevokeReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeSession(ids["sensor-1"][1], SessionRevokeReasonAdmin); err == nil {
+		t.Fatal("revoking a session twice should fail")
+	}
+
+	listTests := []struct {
+		name       string
+		deviceID   string
+		activeOnly bool
+		want       int
+	}{
+		{name: "all sessions", want: 5},
+		{name: "all active sessions", activeOnly: true, want: 3},
+		{name: "device sessions", deviceID: "sensor-1", want: 3},
+		{name: "device active sessions", deviceID: "sensor-1", activeOnly: true, want: 1},
+		{name: "unknown device", deviceID: "missing", want: 0},
+	}
+	for _, tt := range listTests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := s.ListSessions(tt.deviceID, tt.activeOnly)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sessions) != tt.want {
+				t.Fatalf("expected %d sessions, got %d", tt.want, len(sessions))
+			}
+			for _, info := range sessions {
+				if tt.activeOnly && !info.Active {
+					t.Fatalf("inactive session listed: %+v", info)
+				}
+				if info.SessionID == ids["sensor-1"][1] && (info.RevokedAt == nil || info.RevokedReason != SessionRevokeReasonAdmin || info.Active) {
+					t.Fatalf("revoked session reported incorrectly: %+v", info)
+				}
+			}
+		})
+	}
+
+	checkTests := []struct {
+		name      string
+		sessionID string
+		wantErr   string
+	}{
+		{name: "active", sessionID: ids["sensor-1"][2]},
+		{name: "expired", sessionID: ids["sensor-1"][0], wantErr: "session expired"},
+		{name: "revoked", sessionID: ids["sensor-1"][1], wantErr: "SESSION_REVOKED"},
+		{name: "missing", sessionID: "missing", wantErr: "session not found"},
+	}
+	for _, tt := range checkTests {
+		t.Run("check "+tt.name, func(t *testing.T) {
+			err := s.CheckSession(tt.sessionID)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// 已撤销的会话不重复计数（过期未撤销的会话仍会被标记）
+	revoked, err := s.RevokeDeviceSessions("sensor-1", SessionRevokeReasonCloud)
+	if err != nil || revoked != 2 {
+		t.Fatalf("expected 2 sensor-1 sessions revoked, got %d (%v)", revoked, err)
+	}
+	revoked, err = s.RevokeAllSessions(SessionRevokeReasonCloud)
+	if err != nil || revoked != 2 {
+		t.Fatalf("expected the remaining 2 sessions revoked, got %d (%v)", revoked, err)
+	}
+	if active, _ := s.ListSessions("", true); len(active) != 0 {
+		t.Fatalf("expected no active sessions, got %d", len(active))
+	}
+}
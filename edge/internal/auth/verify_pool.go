@@ -0,0 +1,311 @@
+/*
+ * 证明验证工作池
+ * 限制并发的Groth16验证，排队等待的同key证明合并批量验证，
+ * 避免断电恢复后大量设备同时认证时逐个串行验证导致挑战超时
+ */
+package auth
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 验证工作池默认参数
+const (
+	defaultVerifyQueueSize = 256
+	defaultVerifyMaxBatch  = 16
+	defaultVerifyTimeout   = 10 * time.Second
+
+	// 延迟样本数量（用于计算P95）
+	verifyLatencySamples = 256
+)
+
+// verifyRequest 排队中的验证请求
+type verifyRequest struct {
+	keyID      string
+	item       zkp.BatchItem
+	enqueuedAt time.Time
+	result     chan verifyResult
+}
+
+// verifyResult 验证结果
+type verifyResult struct {
+	valid bool
+	err   error
+}
+
+// verifyPool 验证工作池
+type verifyPool struct {
+	logger   *zap.Logger
+	verifier zkp.ZKPVerifier
+	batcher  zkp.BatchVerifier // 验证器不支持批量时为nil
+
+	queue    chan *verifyRequest
+	workers  int
+	maxBatch int
+	timeout  time.Duration
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+
+	mu             sync.Mutex
+	inFlight       int
+	verified       int64
+	invalid        int64
+	rejected       int64
+	timedOut       int64
+	batches        int64
+	batchedProofs  int64
+	maxBatchSize   int
+	totalWait      time.Duration
+	totalVerify    time.Duration
+	latencySamples []time.Duration
+	maxLatency     time.Duration
+}
+
+// newVerifyPool 创建并启动验证工作池
+func newVerifyPool(cfg config.VerificationConfig, verifier zkp.ZKPVerifier, logger *zap.Logger) *verifyPool {
+	p := &verifyPool{
+		logger:   logger,
+		verifier: verifier,
+		workers:  cfg.Workers,
+		maxBatch: cfg.MaxBatch,
+		timeout:  cfg.Timeout,
+		stopCh:   make(chan struct{}),
+	}
+	if p.workers <= 0 {
+		p.workers = runtime.NumCPU()
+	}
+	if p.maxBatch <= 0 {
+		p.maxBatch = defaultVerifyMaxBatch
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultVerifyTimeout
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultVerifyQueueSize
+	}
+	p.queue = make(chan *verifyRequest, queueSize)
+
+	if batcher, ok := verifier.(zkp.BatchVerifier); ok {
+		p.batcher = batcher
+	}
+
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+// stop 停止工作池
+func (p *verifyPool) stop() {
+	close(p.stopCh)
+	p.wg.Wait()
+}
+
+// verify 提交验证请求并等待结果
+// 队列已满时请求未入队，返回 VERIFY_BUSY 错误；等待超时时请求可能正在验证，返回 VERIFY_TIMEOUT 错误
+func (p *verifyPool) verify(keyID string, item zkp.BatchItem) (bool, error) {
+	req := &verifyRequest{
+		keyID:      keyID,
+		item:       item,
+		enqueuedAt: time.Now(),
+		result:     make(chan verifyResult, 1),
+	}
+
+	select {
+	case p.queue <- req:
+	default:
+		p.mu.Lock()
+		p.rejected++
+		p.mu.Unlock()
+		return false, fmt.Errorf("VERIFY_BUSY: verification queue full")
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-req.result:
+		return res.valid, res.err
+	case <-timer.C:
+		return false, fmt.Errorf("VERIFY_TIMEOUT: verification timed out")
+	}
+}
+
+// worker 取出请求，并顺带取出队列中已排队的请求组成批次
+func (p *verifyPool) worker() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case req := <-p.queue:
+			batch := []*verifyRequest{req}
+		drain:
+			for len(batch) < p.maxBatch {
+				select {
+				case next := <-p.queue:
+					batch = append(batch, next)
+				default:
+					break drain
+				}
+			}
+			p.process(batch)
+		}
+	}
+}
+
+// process 按key分组验证，同组多个证明时批量验证
+func (p *verifyPool) process(batch []*verifyRequest) {
+	start := time.Now()
+
+	groups := make(map[string][]*verifyRequest)
+	for _, req := range batch {
+		// 等待已超时的请求调用方已放弃，不再验证
+		if start.Sub(req.enqueuedAt) > p.timeout {
+			p.mu.Lock()
+			p.timedOut++
+			p.mu.Unlock()
+			req.result <- verifyResult{err: fmt.Errorf("VERIFY_TIMEOUT: verification timed out")}
+			continue
+		}
+		groups[req.keyID] = append(groups[req.keyID], req)
+	}
+
+	for keyID, reqs := range groups {
+		p.mu.Lock()
+		p.inFlight += len(reqs)
+		p.mu.Unlock()
+
+		verifyStart := time.Now()
+		results := p.verifyGroup(keyID, reqs)
+		verifyDuration := time.Since(verifyStart)
+
+		p.mu.Lock()
+		p.inFlight -= len(reqs)
+		if len(reqs) > 1 && p.batcher != nil {
+			p.batches++
+			p.batchedProofs += int64(len(reqs))
+			if len(reqs) > p.maxBatchSize {
+				p.maxBatchSize = len(reqs)
+			}
+		}
+		p.totalVerify += verifyDuration
+		for i, req := range reqs {
+			p.verified++
+			if !results[i].valid {
+				p.invalid++
+			}
+			p.totalWait += verifyStart.Sub(req.enqueuedAt)
+			p.recordLatencyLocked(time.Since(req.enqueuedAt))
+		}
+		p.mu.Unlock()
+
+		for i, req := range reqs {
+			req.result <- results[i]
+		}
+	}
+}
+
+// verifyGroup 验证使用同一key的一组请求
+func (p *verifyPool) verifyGroup(keyID string, reqs []*verifyRequest) []verifyResult {
+	results := make([]verifyResult, len(reqs))
+
+	if len(reqs) > 1 && p.batcher != nil {
+		items := make([]zkp.BatchItem, len(reqs))
+		for i, req := range reqs {
+			items[i] = req.item
+		}
+		valid, err := p.batcher.VerifyBatch(keyID, items)
+		if err == nil {
+			for i := range reqs {
+				results[i] = verifyResult{valid: valid[i]}
+			}
+			return results
+		}
+		p.logger.Warn("Batch verification failed, verifying individually",
+			zap.String("key_id", keyID),
+			zap.Int("size", len(reqs)),
+			zap.Error(err))
+	}
+
+	for i, req := range reqs {
+		valid, err := p.verifier.VerifyProofWithKey(keyID,
+			req.item.DeviceID, req.item.Challenge, req.item.Commitment, req.item.Response, req.item.Proof)
+		results[i] = verifyResult{valid: valid, err: err}
+	}
+	return results
+}
+
+// recordLatencyLocked 记录端到端延迟样本（调用方持有锁）
+func (p *verifyPool) recordLatencyLocked(latency time.Duration) {
+	if len(p.latencySamples) >= verifyLatencySamples {
+		p.latencySamples = p.latencySamples[1:]
+	}
+	p.latencySamples = append(p.latencySamples, latency)
+	if latency > p.maxLatency {
+		p.maxLatency = latency
+	}
+}
+
+// snapshot 获取统计数据快照
+func (p *verifyPool) snapshot() models.VerificationStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := models.VerificationStats{
+		Workers:       p.workers,
+		QueueDepth:    len(p.queue),
+		QueueCapacity: cap(p.queue),
+		InFlight:      p.inFlight,
+		Verified:      p.verified,
+		Invalid:       p.invalid,
+		Rejected:      p.rejected,
+		TimedOut:      p.timedOut,
+		Batches:       p.batches,
+		BatchedProofs: p.batchedProofs,
+		MaxBatchSize:  p.maxBatchSize,
+		MaxLatencyMs:  float64(p.maxLatency) / float64(time.Millisecond),
+		BatchEnabled:  p.batcher != nil,
+	}
+	if p.verified > 0 {
+		stats.AvgWaitMs = float64(p.totalWait) / float64(p.verified) / float64(time.Millisecond)
+		// 批量验证时单个证明的摊薄耗时
+		stats.AvgVerifyMs = float64(p.totalVerify) / float64(p.verified) / float64(time.Millisecond)
+	}
+	if n := len(p.latencySamples); n > 0 {
+		sorted := make([]time.Duration, n)
+		copy(sorted, p.latencySamples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		var sum time.Duration
+		for _, d := range sorted {
+			sum += d
+		}
+		stats.AvgLatencyMs = float64(sum) / float64(n) / float64(time.Millisecond)
+		stats.P95LatencyMs = float64(sorted[(n*95-1)/100]) / float64(time.Millisecond)
+	}
+	return stats
+}
+
+// VerificationStats 获取证明验证工作池的统计数据
+func (s *Service) VerificationStats() models.VerificationStats {
+	return s.verifyPool.snapshot()
+}
+
+// Close 停止认证服务的后台工作池
+func (s *Service) Close() {
+	s.verifyPool.stop()
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+// blockingVerifier 验证开始时通知started，直到release关闭才返回结果
+type blockingVerifier struct {
+	*fakeVerifier
+	started chan struct{}
+	release chan struct{}
+}
+
+func (v *blockingVerifier) VerifyProofWithKey(keyID, deviceID, challenge, commitment, response string, proofData []byte) (bool, error) {
+	v.started <- struct{}{}
+	<-v.release
+	return v.fakeVerifier.VerifyProofWithKey(keyID, deviceID, challenge, commitment, response, proofData)
+}
+
+func newBlockingTestService(t *testing.T, verification config.VerificationConfig) (*Service, *blockingVerifier) {
+	t.Helper()
+	verifier := &blockingVerifier{
+		fakeVerifier: newFakeVerifier(zkp.LegacyKeyID),
+		started:      make(chan struct{}, 8),
+		release:      make(chan struct{}),
+	}
+	s := newTestService(t, config.AuthConfig{Verification: verification}, verifier)
+	// 先于工作池停止执行，释放阻塞中的验证
+	t.Cleanup(func() {
+		select {
+		case <-verifier.release:
+		default:
+			close(verifier.release)
+		}
+	})
+	return s, verifier
+}
+
+func TestVerifyTimeoutConsumesChallenge(t *testing.T) {
+	s, verifier := newBlockingTestService(t, config.VerificationConfig{QueueSize: 4, Timeout: 50 * time.Millisecond})
+	commitment := testCommitment(1)
+	addTestDevice(t, s, "sensor-1", commitment, "")
+
+	challenge, err := s.GenerateChallenge("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &models.AuthRequest{
+		DeviceID:    "sensor-1",
+		ChallengeID: challenge.ChallengeID,
+		Proof:       proveChallenge(challenge, commitment, zkp.LegacyKeyID),
+	}
+
+	// 验证已开始但等待超时，验证仍可能完成，挑战不能再被接受
+	if _, err := s.VerifyProof(req, "10.0.0.1"); err == nil || !strings.Contains(err.Error(), "VERIFY_TIMEOUT") {
+		t.Fatalf("expected verification timeout, got %v", err)
+	}
+	close(verifier.release)
+	if _, err := s.VerifyProof(req, "10.0.0.1"); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Fatalf("timed out challenge must stay consumed, got %v", err)
+	}
+
+	// 超时不计入认证失败，设备用新挑战可以认证
+	for _, l := range s.ListLockouts() {
+		if l.Failures != 1 {
+			t.Fatalf("expected only the replay to be counted, got %+v", l)
+		}
+	}
+	challenge, err = s.GenerateChallenge("sensor-1", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyProof(&models.AuthRequest{
+		DeviceID:    "sensor-1",
+		ChallengeID: challenge.ChallengeID,
+		Proof:       proveChallenge(challenge, commitment, zkp.LegacyKeyID),
+	}, "10.0.0.1"); err != nil {
+		t.Fatalf("new challenge should authenticate: %v", err)
+	}
+}
+
+func TestVerifyQueueFullReleasesChallenge(t *testing.T) {
+	s, verifier := newBlockingTestService(t, config.VerificationConfig{QueueSize: 1, MaxBatch: 1, Timeout: 5 * time.Second})
+	commitment := testCommitment(1)
+	for _, id := range []string{"sensor-1", "sensor-2", "sensor-3"} {
+		addTestDevice(t, s, id, commitment, "")
+	}
+
+	request := func(deviceID string) *models.AuthRequest {
+		t.Helper()
+		challenge, err := s.GenerateChallenge(deviceID, "10.0.0.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &models.AuthRequest{
+			DeviceID:    deviceID,
+			ChallengeID: challenge.ChallengeID,
+			Proof:       proveChallenge(challenge, commitment, zkp.LegacyKeyID),
+		}
+	}
+
+	// 唯一的工作协程阻塞在第一个请求上，第二个请求占满队列
+	first, second, req := request("sensor-1"), request("sensor-2"), request("sensor-3")
+	done := make(chan error, 2)
+	go func() { _, err := s.VerifyProof(first, "10.0.0.1"); done <- err }()
+	<-verifier.started
+	go func() { _, err := s.VerifyProof(second, "10.0.0.1"); done <- err }()
+	deadline := time.Now().Add(time.Second)
+	for len(s.verifyPool.queue) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("second request was not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 队列已满时验证从未开始，挑战归还后可用同一证明重试
+	if _, err := s.VerifyProof(req, "10.0.0.1"); err == nil || !strings.Contains(err.Error(), "VERIFY_BUSY") {
+		t.Fatalf("expected full queue, got %v", err)
+	}
+	close(verifier.release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("queued verification failed: %v", err)
+		}
+	}
+	if _, err := s.VerifyProof(req, "10.0.0.1"); err != nil {
+		t.Fatalf("released challenge should be accepted on retry: %v", err)
+	}
+}
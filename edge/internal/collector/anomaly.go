@@ -0,0 +1,270 @@
+/*
+ * 传感器数据在线异常检测
+ * 按设备、传感器类型分别学习读数及上报间隔的EWMA均值和方差，
+ * 观测值偏离均值超过控制限（标准差倍数）时判定为异常，模型状态持久化到SQLite
+ */
+package collector
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 异常检测指标
+const (
+	MetricValue    = "value"    // 传感器读数
+	MetricInterval = "interval" // 相邻两次上报的间隔（秒）
+)
+
+// maxRecentAnomalies 窗口期内保留的异常记录上限
+const maxRecentAnomalies = 1000
+
+type anomalyKey struct {
+	deviceID   string
+	sensorType models.SensorType
+	metric     string
+}
+
+// ewmaModel 指数加权均值及方差
+type ewmaModel struct {
+	samples  int
+	mean     float64
+	variance float64
+	lastSeen time.Time
+}
+
+// AnomalyDetector 传感器数据在线异常检测器
+type AnomalyDetector struct {
+	db            *storage.SQLiteDB
+	logger        *zap.Logger
+	alpha         float64
+	threshold     float64
+	warmupSamples int
+	window        time.Duration
+
+	mu     sync.Mutex
+	models map[anomalyKey]*ewmaModel
+	dirty  map[anomalyKey]bool
+	recent []models.SensorAnomaly
+}
+
+// NewAnomalyDetector 创建异常检测器并加载已学习的模型
+func NewAnomalyDetector(db *storage.SQLiteDB, cfg config.SensorAnomalyConfig, logger *zap.Logger) (*AnomalyDetector, error) {
+	d := &AnomalyDetector{
+		db:            db,
+		logger:        logger,
+		alpha:         cfg.Alpha,
+		threshold:     cfg.Threshold,
+		warmupSamples: cfg.WarmupSamples,
+		window:        cfg.Window,
+		models:        make(map[anomalyKey]*ewmaModel),
+		dirty:         make(map[anomalyKey]bool),
+	}
+	if d.alpha <= 0 || d.alpha >= 1 {
+		d.alpha = 0.05
+	}
+	if d.threshold <= 0 {
+		d.threshold = 4
+	}
+	if d.warmupSamples <= 0 {
+		d.warmupSamples = 30
+	}
+	if d.window <= 0 {
+		d.window = time.Hour
+	}
+
+	if err := d.load(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// load 从数据库加载模型状态
+func (d *AnomalyDetector) load() error {
+	rows, err := d.db.Query(`SELECT device_id, sensor_type, metric, samples, mean, variance, last_seen FROM sensor_anomaly_models`)
+	if err != nil {
+		return fmt.Errorf("加载异常检测模型失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key anomalyKey
+		var model ewmaModel
+		var lastSeen *time.Time
+		if err := rows.Scan(&key.deviceID, &key.sensorType, &key.metric, &model.samples, &model.mean, &model.variance, &lastSeen); err != nil {
+			return fmt.Errorf("读取异常检测模型失败: %w", err)
+		}
+		if lastSeen != nil {
+			model.lastSeen = *lastSeen
+		}
+		d.models[key] = &model
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	d.logger.Info("传感器异常检测模型已加载", zap.Int("models", len(d.models)))
+	return nil
+}
+
+// Flush 将有变化的模型写入数据库
+func (d *AnomalyDetector) Flush() error {
+	d.mu.Lock()
+	type row struct {
+		key   anomalyKey
+		model ewmaModel
+	}
+	rows := make([]row, 0, len(d.dirty))
+	for key := range d.dirty {
+		rows = append(rows, row{key: key, model: *d.models[key]})
+	}
+	d.dirty = make(map[anomalyKey]bool)
+	d.mu.Unlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, r := range rows {
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO sensor_anomaly_models
+				(device_id, sensor_type, metric, samples, mean, variance, last_seen, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.key.deviceID, r.key.sensorType, r.key.metric, r.model.samples, r.model.mean, r.model.variance, r.model.lastSeen, now,
+		); err != nil {
+			return fmt.Errorf("保存异常检测模型失败: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Observe 用一条传感器数据更新模型，返回判定出的异常（学习期内不判定）
+func (d *AnomalyDetector) Observe(data *models.SensorData) []models.SensorAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	var anomalies []models.SensorAnomaly
+	valueKey := anomalyKey{deviceID: data.DeviceID, sensorType: data.SensorType, metric: MetricValue}
+	valueModel := d.model(valueKey)
+
+	// 上报间隔：乱序或重复时间戳的数据不参与
+	if !valueModel.lastSeen.IsZero() && data.Timestamp.After(valueModel.lastSeen) {
+		intervalKey := anomalyKey{deviceID: data.DeviceID, sensorType: data.SensorType, metric: MetricInterval}
+		interval := data.Timestamp.Sub(valueModel.lastSeen).Seconds()
+		if anomaly := d.update(intervalKey, d.model(intervalKey), interval, now); anomaly != nil {
+			anomalies = append(anomalies, *anomaly)
+		}
+	}
+	if anomaly := d.update(valueKey, valueModel, data.Value, now); anomaly != nil {
+		anomalies = append(anomalies, *anomaly)
+	}
+	if data.Timestamp.After(valueModel.lastSeen) {
+		valueModel.lastSeen = data.Timestamp
+	}
+
+	if len(anomalies) > 0 {
+		d.recent = append(d.recent, anomalies...)
+		d.prune(now)
+	}
+	return anomalies
+}
+
+// Recent 获取窗口期内的异常记录
+func (d *AnomalyDetector) Recent() []models.SensorAnomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prune(time.Now())
+	result := make([]models.SensorAnomaly, len(d.recent))
+	copy(result, d.recent)
+	return result
+}
+
+func (d *AnomalyDetector) prune(now time.Time) {
+	cutoff := now.Add(-d.window)
+	i := 0
+	for i < len(d.recent) && d.recent[i].DetectedAt.Before(cutoff) {
+		i++
+	}
+	if overflow := len(d.recent) - i - maxRecentAnomalies; overflow > 0 {
+		i += overflow
+	}
+	d.recent = d.recent[i:]
+}
+
+func (d *AnomalyDetector) model(key anomalyKey) *ewmaModel {
+	model, ok := d.models[key]
+	if !ok {
+		model = &ewmaModel{}
+		d.models[key] = model
+	}
+	return model
+}
+
+// update 计算异常分数后更新EWMA均值和方差
+// 异常样本按控制限截断后再参与更新，避免离群值污染模型，持续的水平变化仍会被逐步学习
+func (d *AnomalyDetector) update(key anomalyKey, model *ewmaModel, x float64, at time.Time) *models.SensorAnomaly {
+	d.dirty[key] = true
+	if model.samples == 0 {
+		model.mean = x
+		model.samples = 1
+		return nil
+	}
+
+	// 恒定信号方差接近0，以均值的一定比例作为标准差下限（读数1%，上报间隔存在调度抖动取10%），避免微小抖动被判为异常
+	floor := 0.01
+	if key.metric == MetricInterval {
+		floor = 0.1
+	}
+	stddev := math.Max(math.Sqrt(model.variance), math.Max(math.Abs(model.mean)*floor, 1e-6))
+	score := math.Abs(x-model.mean) / stddev
+
+	var anomaly *models.SensorAnomaly
+	sample := x
+	if model.samples >= d.warmupSamples && score >= d.threshold {
+		limit := model.mean + d.threshold*stddev
+		if x < model.mean {
+			limit = model.mean - d.threshold*stddev
+		}
+		severity := string(models.SeverityMedium)
+		if score >= 2*d.threshold {
+			severity = string(models.SeverityHigh)
+		}
+		anomaly = &models.SensorAnomaly{
+			DeviceID:   key.deviceID,
+			SensorType: key.sensorType,
+			Metric:     key.metric,
+			Value:      x,
+			Expected:   model.mean,
+			StdDev:     stddev,
+			Limit:      limit,
+			Score:      math.Round(score*100) / 100,
+			Severity:   severity,
+			DetectedAt: at,
+		}
+		sample = limit
+	}
+
+	// 学习期内按累计均值收敛，之后按固定平滑系数
+	alpha := math.Max(d.alpha, 1/float64(model.samples+1))
+	diff := sample - model.mean
+	model.mean += alpha * diff
+	model.variance = (1 - alpha) * (model.variance + alpha*diff*diff)
+	model.samples++
+	return anomaly
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+func TestAnomalyDetectorLearnsAndPersists(t *testing.T) {
+	logger := zap.NewNop()
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:             "sqlite3",
+		Path:               filepath.Join(t.TempDir(), "edge.db"),
+		MaxConnections:     1,
+		MaxIdleConnections: 1,
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	cfg := config.SensorAnomalyConfig{Alpha: 0.1, Threshold: 4, WarmupSamples: 20}
+	detector, err := NewAnomalyDetector(db, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	sample := func(d *AnomalyDetector, i int, value float64) []models.SensorAnomaly {
+		return d.Observe(&models.SensorData{
+			DeviceID:   "sensor-1",
+			SensorType: models.SensorTemperature,
+			Value:      value,
+			Timestamp:  start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	// 学习期：25±0.5度，每分钟上报
+	for i := 0; i < 40; i++ {
+		if anomalies := sample(detector, i, 25+float64(i%3-1)*0.5); len(anomalies) != 0 {
+			t.Fatalf("unexpected anomaly during normal operation at %d: %+v", i, anomalies)
+		}
+	}
+	if err := detector.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后模型继续生效，无需重新学习
+	restored, err := NewAnomalyDetector(db, cfg, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anomalies := sample(restored, 40, 40)
+	if len(anomalies) != 1 || anomalies[0].Metric != MetricValue || anomalies[0].Severity != "high" {
+		t.Fatalf("expected high value anomaly after restart, got %+v", anomalies)
+	}
+	if anomalies[0].Expected < 24 || anomalies[0].Expected > 26 || anomalies[0].Score < 8 {
+		t.Fatalf("unexpected anomaly statistics: %+v", anomalies[0])
+	}
+
+	// 离群值按控制限截断后更新，回到正常值不再告警
+	if anomalies := sample(restored, 41, 25); len(anomalies) != 0 {
+		t.Fatalf("outlier should not poison the model: %+v", anomalies)
+	}
+
+	// 上报间隔突变（1分钟间隔后隔2秒上报）
+	anomalies = restored.Observe(&models.SensorData{
+		DeviceID:   "sensor-1",
+		SensorType: models.SensorTemperature,
+		Value:      25,
+		Timestamp:  start.Add(41*time.Minute + 2*time.Second),
+	})
+	if len(anomalies) != 1 || anomalies[0].Metric != MetricInterval {
+		t.Fatalf("expected interval anomaly, got %+v", anomalies)
+	}
+
+	if recent := restored.Recent(); len(recent) != 2 {
+		t.Fatalf("expected 2 recent anomalies, got %+v", recent)
+	}
+}
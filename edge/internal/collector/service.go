@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/edge/storage-cabinet/internal/abac"
 	"github.com/edge/storage-cabinet/internal/config"
 	"github.com/edge/storage-cabinet/internal/device"
 	"github.com/edge/storage-cabinet/internal/storage"
@@ -40,6 +41,8 @@ type Service struct {
 	wg              sync.WaitGroup
 	cloudSync       CloudSyncInterface      // 云端同步接口（用于即时告警上报）
 	alertPublisher  AlertPublisherInterface // MQTT告警发布器（用于实时推送）
+	trustObserver   TrustObserver           // 设备行为信任度（可选，记录数据质量异常）
+	anomalyDetector *AnomalyDetector        // 传感器数据在线异常检测（可选）
 }
 
 // CloudSyncInterface 定义云端同步接口（避免循环依赖）
@@ -53,6 +56,11 @@ type AlertPublisherInterface interface {
 	IsEnabled() bool
 }
 
+// TrustObserver 设备行为信任度观察者
+type TrustObserver interface {
+	Observe(deviceID string, signal abac.TrustSignal)
+}
+
 // NewService 创建数据采集服务
 func NewService(cfg config.DataConfig, alertCfg config.AlertConfig, db *storage.SQLiteDB, deviceManager *device.Manager, logger *zap.Logger) *Service {
 	return &Service{
@@ -87,6 +95,93 @@ func (s *Service) SetAlertPublisher(publisher AlertPublisherInterface) {
 	s.logger.Info("Alert MQTT publisher set for real-time alert notification")
 }
 
+// SetTrustObserver 设置设备行为信任度观察者（数据质量异常降低设备信任度）
+func (s *Service) SetTrustObserver(observer TrustObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trustObserver = observer
+}
+
+// SetAnomalyDetector 设置传感器数据在线异常检测器（需在Start之前设置）
+func (s *Service) SetAnomalyDetector(detector *AnomalyDetector) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.anomalyDetector = detector
+}
+
+// checkAnomaly 用数据更新异常检测模型，超出控制限时产生data_abnormal告警
+func (s *Service) checkAnomaly(data *models.SensorData) {
+	s.mu.RLock()
+	detector := s.anomalyDetector
+	s.mu.RUnlock()
+	if detector == nil {
+		return
+	}
+
+	for _, anomaly := range detector.Observe(data) {
+		value, limit := anomaly.Value, anomaly.Limit
+		metric := "读数"
+		if anomaly.Metric == MetricInterval {
+			metric = "上报间隔(秒)"
+		}
+		alert := &models.Alert{
+			DeviceID:  anomaly.DeviceID,
+			AlertType: string(models.AlertDataAbnormal),
+			Severity:  anomaly.Severity,
+			Message: fmt.Sprintf("%s%s统计异常: %.2f (学习均值: %.2f, 控制限: %.2f, 异常分数: %.2f)",
+				anomaly.SensorType, metric, anomaly.Value, anomaly.Expected, anomaly.Limit, anomaly.Score),
+			Value:     &value,
+			Threshold: &limit,
+			Timestamp: time.Now(),
+			Resolved:  false,
+		}
+
+		select {
+		case s.alertChan <- alert:
+			s.logger.Warn("Sensor anomaly detected",
+				zap.String("device_id", anomaly.DeviceID),
+				zap.String("sensor_type", string(anomaly.SensorType)),
+				zap.String("metric", anomaly.Metric),
+				zap.Float64("score", anomaly.Score))
+		default:
+			s.logger.Error("Alert channel full")
+		}
+	}
+}
+
+// flushAnomalyModels 定时保存异常检测模型，停止时保存最后状态
+func (s *Service) flushAnomalyModels(detector *AnomalyDetector) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			if err := detector.Flush(); err != nil {
+				s.logger.Error("Failed to save anomaly models", zap.Error(err))
+			}
+			return
+		case <-ticker.C:
+			if err := detector.Flush(); err != nil {
+				s.logger.Error("Failed to save anomaly models", zap.Error(err))
+			}
+		}
+	}
+}
+
+// observeQuality 数据质量低于阈值时记录信任度信号（未上报质量的数据不计）
+func (s *Service) observeQuality(data *models.SensorData) {
+	s.mu.RLock()
+	observer := s.trustObserver
+	s.mu.RUnlock()
+
+	if observer != nil && data.Quality > 0 && data.Quality < abac.QualityAnomalyThreshold {
+		observer.Observe(data.DeviceID, abac.SignalQualityAnomaly)
+	}
+}
+
 // initThresholdsFromConfig 从配置文件初始化传感器阈值
 func initThresholdsFromConfig(alertCfg config.AlertConfig) map[models.SensorType]*models.SensorThreshold {
 	if !alertCfg.Enabled {
@@ -182,6 +277,15 @@ func (s *Service) Start(ctx context.Context) error {
 	s.wg.Add(1)
 	go s.cleanupOldData()
 
+	// 启动异常检测模型保存协程
+	s.mu.RLock()
+	detector := s.anomalyDetector
+	s.mu.RUnlock()
+	if detector != nil {
+		s.wg.Add(1)
+		go s.flushAnomalyModels(detector)
+	}
+
 	s.logger.Info("Data collector started")
 	return nil
 }
@@ -229,6 +333,7 @@ func (s *Service) CollectData(req *models.DataCollectRequest) error {
 	if data.Timestamp.IsZero() {
 		data.Timestamp = time.Now()
 	}
+	s.observeQuality(data)
 
 	// 检查数据是否超出阈值
 	if err := s.checkThreshold(data); err != nil {
@@ -238,6 +343,7 @@ func (s *Service) CollectData(req *models.DataCollectRequest) error {
 			zap.Float64("value", data.Value),
 			zap.Error(err))
 	}
+	s.checkAnomaly(data)
 
 	// 发送到数据通道
 	select {
@@ -286,11 +392,13 @@ func (s *Service) receiveRS485Data() {
 			}
 
 			// 检查阈值并发送
+			s.observeQuality(data)
 			if err := s.checkThreshold(data); err != nil {
 				s.logger.Warn("RS485 data threshold exceeded",
 					zap.String("device_id", data.DeviceID),
 					zap.Error(err))
 			}
+			s.checkAnomaly(data)
 
 			select {
 			case s.dataChan <- data:
@@ -945,6 +1053,7 @@ func (s *Service) SaveSensorData(mqttData interface{}) error {
 	}
 
 	// 检查阈值
+	s.observeQuality(data)
 	if err := s.checkThreshold(data); err != nil {
 		s.logger.Warn("MQTT data threshold exceeded",
 			zap.String("device_id", data.DeviceID),
@@ -27,6 +27,8 @@ type Config struct {
 	Log           LogConfig           `yaml:"log"`
 	Monitoring    MonitoringConfig    `yaml:"monitoring"`
 	MQTT          MQTTConfig          `yaml:"mqtt"`
+	MQTTBroker    MQTTBrokerConfig    `yaml:"mqtt_broker"`
+	PKI           PKIConfig           `yaml:"pki"`
 	Vulnerability VulnerabilityConfig `yaml:"vulnerability"`
 	ABAC          ABACConfig          `yaml:"abac"`
 	Map           MapConfig           `yaml:"map"`
@@ -34,7 +36,10 @@ type Config struct {
 
 // ABACConfig ABAC设备权限管理配置
 type ABACConfig struct {
-	Enabled bool `yaml:"enabled"` // 是否启用设备ABAC权限控制
+	Enabled            bool          `yaml:"enabled"`              // 是否启用设备ABAC权限控制
+	MinFirmwareVersion string        `yaml:"min_firmware_version"` // 低于该固件版本的设备信任度扣分，为空不检查
+	TrustHalfLife      time.Duration `yaml:"trust_half_life"`      // 设备行为扣分衰减半衰期，默认6h
+	MQTTMode           string        `yaml:"mqtt_mode"`            // 内嵌broker Topic授权模式：shadow（默认，仅记录）或enforce
 }
 
 // ServerConfig 服务器配置
@@ -50,13 +55,49 @@ type AuthConfig struct {
 	SessionTTL   time.Duration `yaml:"session_ttl"`
 	MaxRetry     int           `yaml:"max_retry"`
 	ZKP          ZKPConfig     `yaml:"zkp"`
+	Lockout      LockoutConfig `yaml:"lockout"`
+
+	MaxSessionsPerDevice int                `yaml:"max_sessions_per_device"` // 单设备活跃会话上限，超出时撤销最久未使用的会话
+	Verification         VerificationConfig `yaml:"verification"`
+}
+
+// VerificationConfig 证明验证工作池配置
+type VerificationConfig struct {
+	Workers   int           `yaml:"workers"`    // 并发验证协程数（默认CPU核数）
+	QueueSize int           `yaml:"queue_size"` // 排队上限，超出时拒绝请求
+	MaxBatch  int           `yaml:"max_batch"`  // 单批最多合并的证明数
+	Timeout   time.Duration `yaml:"timeout"`    // 排队加验证的最长等待时间
+}
+
+// LockoutConfig 认证失败锁定配置
+// 未配置的字段使用认证服务内置的默认值
+type LockoutConfig struct {
+	DeviceMaxFailures    int           `yaml:"device_max_failures"`    // 单设备连续失败阈值，不区分来源IP（默认取max_retry）
+	IPMaxFailures        int           `yaml:"ip_max_failures"`        // 单来源IP连续失败阈值
+	BaseDuration         time.Duration `yaml:"base_duration"`          // 首次锁定时长，之后逐次翻倍
+	MaxDuration          time.Duration `yaml:"max_duration"`           // 锁定时长上限
+	FailureWindow        time.Duration `yaml:"failure_window"`         // 失败计数窗口，超出后计数清零
+	MaxPendingChallenges int           `yaml:"max_pending_challenges"` // 单设备在单个来源IP上的未使用挑战上限
 }
 
 // ZKPConfig 零知识证明配置
 type ZKPConfig struct {
 	CircuitPath      string `yaml:"circuit_path"`
 	ProvingScheme    string `yaml:"proving_scheme"`
-	VerifyingKeyPath string `yaml:"verifying_key_path"` // verifying key文件路径
+	VerifyingKeyPath string `yaml:"verifying_key_path"` // verifying key文件路径（以key ID v1加载）
+
+	// 多verifying key配置（可信设置更新或电路升级时同时加载新旧key）
+	VerifyingKeys []VerifyingKeyConfig `yaml:"verifying_keys,omitempty"`
+	DefaultKeyID  string               `yaml:"default_key_id,omitempty"` // 新注册设备和迁移目标使用的key ID
+
+	// 群组成员电路verifying key路径（可选，配置后启用群组成员认证）
+	MembershipKeyPath string `yaml:"membership_key_path,omitempty"`
+}
+
+// VerifyingKeyConfig verifying key配置
+type VerifyingKeyConfig struct {
+	ID   string `yaml:"id"`
+	Path string `yaml:"path"`
 }
 
 // DeviceConfig 设备配置
@@ -74,6 +115,18 @@ type DataConfig struct {
 	RetentionDays   int           `yaml:"retention_days"`
 	BatchSize       int           `yaml:"batch_size"`
 	BufferSize      int           `yaml:"buffer_size"`
+	// 传感器数据在线异常检测
+	Anomaly SensorAnomalyConfig `yaml:"anomaly"`
+}
+
+// SensorAnomalyConfig 传感器数据在线异常检测配置
+// 按设备、传感器类型分别学习读数及上报间隔的EWMA均值和方差，超出控制限时告警
+type SensorAnomalyConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Alpha         float64       `yaml:"alpha"`          // EWMA平滑系数，默认0.05
+	Threshold     float64       `yaml:"threshold"`      // 控制限（标准差倍数），默认4
+	WarmupSamples int           `yaml:"warmup_samples"` // 学习样本数，达到后才判定异常，默认30
+	Window        time.Duration `yaml:"window"`         // 近期异常计入脆弱性评估的时长，默认1小时
 }
 
 // DatabaseConfig 数据库配置
@@ -173,6 +226,29 @@ type MQTTConfig struct {
 	TLS TLSConfig `yaml:"tls"`
 }
 
+// MQTTBrokerConfig 内嵌MQTT broker配置
+// 设备以设备ID为用户名、ZKP认证获得的会话令牌为密码连接，消息直接交给本地处理器
+type MQTTBrokerConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Address        string        `yaml:"address"`          // TCP监听地址，如 :1883
+	TLSAddress     string        `yaml:"tls_address"`      // TLS监听地址，如 :8883（为空时不启用）
+	CertFile       string        `yaml:"cert_file"`        // TLS证书
+	KeyFile        string        `yaml:"key_file"`         // TLS私钥
+	SessionCheck   time.Duration `yaml:"session_check"`    // 检查已连接客户端会话是否被撤销/过期的间隔
+	MaxPayloadSize int           `yaml:"max_payload_size"` // 单条消息最大字节数
+}
+
+// PKIConfig Edge CA配置
+// 为无法运行ZKP证明的第三方传感器签发短期客户端证书，数据接口和MQTT监听接受mTLS认证
+type PKIConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	CACertFile  string        `yaml:"ca_cert_file"` // CA证书，不存在时自动生成
+	CAKeyFile   string        `yaml:"ca_key_file"`  // CA私钥，不存在时自动生成
+	CertTTL     time.Duration `yaml:"cert_ttl"`     // 设备证书有效期（默认24小时）
+	MTLSAddress string        `yaml:"mtls_address"` // HTTPS监听地址，如 :8443（为空时不启用）
+	ServerHosts []string      `yaml:"server_hosts"` // HTTPS服务端证书的主机名/IP
+}
+
 // TLSConfig TLS配置
 type TLSConfig struct {
 	Enabled            bool   `yaml:"enabled"`              // 是否启用TLS
@@ -297,6 +373,22 @@ func (c *Config) validate() error {
 		}
 	}
 
+	// 验证内嵌MQTT broker配置
+	if c.MQTTBroker.Enabled {
+		if c.MQTTBroker.Address == "" && c.MQTTBroker.TLSAddress == "" {
+			return fmt.Errorf("内嵌MQTT broker至少需要配置一个监听地址")
+		}
+		// 未配置证书文件时由Edge CA签发服务端证书
+		if c.MQTTBroker.TLSAddress != "" && !c.PKI.Enabled && (c.MQTTBroker.CertFile == "" || c.MQTTBroker.KeyFile == "") {
+			return fmt.Errorf("内嵌MQTT broker启用TLS时必须配置cert_file和key_file（或启用pki）")
+		}
+	}
+
+	// 验证Edge CA配置
+	if c.PKI.Enabled && (c.PKI.CACertFile == "" || c.PKI.CAKeyFile == "") {
+		return fmt.Errorf("启用Edge CA时必须配置ca_cert_file和ca_key_file")
+	}
+
 	// 验证告警阈值
 	if c.Alert.Enabled {
 		if err := c.validateAlertThresholds(); err != nil {
@@ -490,6 +582,57 @@ type VulnerabilityConfig struct {
 	Weights              VulnerabilityWeights             `yaml:"weights"`
 	Communication        VulnerabilityCommunicationConfig `yaml:"communication"`
 	DataAnomaly          VulnerabilityDataAnomalyConfig   `yaml:"data_anomaly"`
+	// 按评分器名称覆盖权重和启用状态（license、communication、config_security、data_anomaly、detector及扩展评分器）
+	Scorers     map[string]VulnerabilityScorerConfig `yaml:"scorers"`
+	Drift       VulnerabilityDriftConfig             `yaml:"drift"`
+	TLS         VulnerabilityTLSConfig               `yaml:"tls"`
+	PortAudit   VulnerabilityPortAuditConfig         `yaml:"port_audit"`
+	Remediation VulnerabilityRemediationConfig       `yaml:"remediation"`
+}
+
+// VulnerabilityRemediationConfig 漏洞处置剧本配置
+type VulnerabilityRemediationConfig struct {
+	Enabled   bool                        `yaml:"enabled"`
+	LogFiles  []string                    `yaml:"log_files"` // rotate_logs轮转的日志文件
+	Playbooks []RemediationPlaybookConfig `yaml:"playbooks"`
+}
+
+// RemediationPlaybookConfig 处置剧本条目
+type RemediationPlaybookConfig struct {
+	EventType string            `yaml:"event_type"` // 漏洞事件类型或评分扣分项，如port_scan、disk_full、license_expiry；firmware等带":"后缀的类型按前缀匹配
+	Action    string            `yaml:"action"`     // block_ip/disable_device/cleanup_storage/rotate_logs/refresh_license
+	Mode      string            `yaml:"mode"`       // auto: 自动执行, confirm: 运维确认后执行（默认）
+	Cooldown  time.Duration     `yaml:"cooldown"`   // 同一事件同一动作的最小间隔，默认1小时
+	Params    map[string]string `yaml:"params"`     // 动作参数
+}
+
+// VulnerabilityPortAuditConfig 本机监听端口自查配置（仅Linux）
+// Edge API、MQTT broker、mTLS及指标端口由对应配置自动加入白名单
+type VulnerabilityPortAuditConfig struct {
+	Enabled        bool  `yaml:"enabled"`
+	AllowedPorts   []int `yaml:"allowed_ports"`     // 额外允许对外监听的端口，如SSH 22
+	LocalOnlyPorts []int `yaml:"local_only_ports"`  // 额外允许但只能绑定本机的端口
+	DebugPorts     []int `yaml:"debug_ports"`       // 调试/性能分析端口，未配置时使用6060、2345、9229、5005
+	UDPAllowed     []int `yaml:"udp_allowed_ports"` // 允许的未连接UDP端口，未配置时使用68、546、5353
+}
+
+// VulnerabilityTLSConfig TLS安全态势检查配置
+type VulnerabilityTLSConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	CheckInterval time.Duration `yaml:"check_interval"` // 重新握手检查间隔，默认1小时
+	Timeout       time.Duration `yaml:"timeout"`        // 单次握手超时，默认5秒
+}
+
+// VulnerabilityDriftConfig 配置漂移检测配置
+type VulnerabilityDriftConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	KeyFile string `yaml:"key_file"` // 基线签名私钥文件，默认./data/baseline_signing.key
+}
+
+// VulnerabilityScorerConfig 单个评分器配置
+type VulnerabilityScorerConfig struct {
+	Enabled *bool   `yaml:"enabled"` // 未配置时启用
+	Weight  float64 `yaml:"weight"`  // 未配置时使用默认权重
 }
 
 // VulnerabilityWeights 评分权重配置
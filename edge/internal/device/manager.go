@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/edge/storage-cabinet/internal/abac"
 	"github.com/edge/storage-cabinet/internal/config"
 	"github.com/edge/storage-cabinet/internal/storage"
 	"github.com/edge/storage-cabinet/pkg/models"
@@ -36,8 +37,15 @@ type Manager struct {
 	maxDevices       int
 	supportedSensors []string
 	cabinetID        string // 储能柜ID（从配置获取）
+	circuitVersion   string // 新注册设备的电路版本（默认verifying key ID）
 	stopChan         chan struct{}
 	running          bool
+	trustObserver    TrustObserver // 设备行为信任度（可选，记录心跳异常）
+}
+
+// TrustObserver 设备行为信任度观察者
+type TrustObserver interface {
+	Observe(deviceID string, signal abac.TrustSignal)
 }
 
 // DeviceSession 设备会话信息
@@ -151,6 +159,8 @@ func (m *Manager) RegisterDevice(req *models.DeviceRegistration) (*models.Device
 		FirmwareVer:  req.FirmwareVer,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
+
+		CircuitVersion: m.circuitVersion,
 	}
 
 	// 保存到数据库
@@ -213,6 +223,24 @@ func (m *Manager) GetDevice(deviceID string) (*models.Device, error) {
 	return device, nil
 }
 
+// FirmwareVersion 获取设备固件版本，设备不存在时返回空字符串
+func (m *Manager) FirmwareVersion(deviceID string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if device, exists := m.devices[deviceID]; exists {
+		return device.FirmwareVer
+	}
+	return ""
+}
+
+// SetTrustObserver 设置设备行为信任度观察者（心跳延迟和离线降低设备信任度）
+func (m *Manager) SetTrustObserver(observer TrustObserver) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trustObserver = observer
+}
+
 // GetAllDevices 获取所有设备
 func (m *Manager) GetAllDevices() ([]*models.Device, error) {
 	m.mu.RLock()
@@ -323,6 +351,17 @@ func (m *Manager) heartbeatChecker(ctx context.Context) {
 
 // checkHeartbeats 检查所有设备心跳
 func (m *Manager) checkHeartbeats() {
+	// 信任度观察者可能回查固件版本，在释放锁后通知
+	var irregular []string
+	defer func() {
+		if m.trustObserver == nil {
+			return
+		}
+		for _, deviceID := range irregular {
+			m.trustObserver.Observe(deviceID, abac.SignalHeartbeatMissed)
+		}
+	}()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -348,10 +387,14 @@ func (m *Manager) checkHeartbeats() {
 
 				// 触发离线告警
 				m.triggerOfflineAlert(deviceID)
+				irregular = append(irregular, deviceID)
 			}
 		} else if timeSinceLastHeartbeat > m.heartbeatTTL*2 {
 			// 心跳延迟警告
 			session.FailCount++
+			if session.FailCount == 3 {
+				irregular = append(irregular, deviceID)
+			}
 			if session.FailCount >= 3 {
 				m.logger.Warn("Device heartbeat delayed",
 					zap.String("device_id", deviceID),
@@ -373,7 +416,9 @@ func (m *Manager) loadDevices() error {
 	query := `
 		SELECT device_id, device_type, sensor_type, 
 			   public_key, commitment, status, model, manufacturer, 
-			   firmware_ver, created_at, updated_at, last_seen_at
+			   firmware_ver, created_at, updated_at, last_seen_at,
+			   COALESCE(rotation_required, FALSE), COALESCE(rotation_reason, ''),
+			   COALESCE(circuit_version, '')
 		FROM devices
 	`
 
@@ -391,6 +436,8 @@ func (m *Manager) loadDevices() error {
 			&device.Status, &device.Model, &device.Manufacturer,
 			&device.FirmwareVer, &device.CreatedAt, &device.UpdatedAt,
 			&device.LastSeenAt,
+			&device.RotationRequired, &device.RotationReason,
+			&device.CircuitVersion,
 		)
 		if err != nil {
 			m.logger.Error("Failed to scan device", zap.Error(err))
@@ -425,8 +472,8 @@ func (m *Manager) saveDevice(device *models.Device) error {
 		INSERT INTO devices (
 			device_id, device_type, sensor_type,
 			public_key, commitment, status, model, manufacturer,
-			firmware_ver, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			firmware_ver, created_at, updated_at, circuit_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := m.db.Exec(query,
@@ -434,6 +481,7 @@ func (m *Manager) saveDevice(device *models.Device) error {
 		device.PublicKey, device.Commitment,
 		device.Status, device.Model, device.Manufacturer,
 		device.FirmwareVer, device.CreatedAt, device.UpdatedAt,
+		device.CircuitVersion,
 	)
 
 	return err
@@ -755,6 +803,54 @@ func (m *Manager) UpdateLastSeen(deviceID string) error {
 	return nil
 }
 
+// SyncCredential 同步设备凭证状态到内存缓存（由认证服务在轮换后回调）
+func (m *Manager) SyncCredential(deviceID, commitment string, rotationRequired bool, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	device, exists := m.devices[deviceID]
+	if !exists {
+		return
+	}
+
+	// 复制后替换，避免与已返回给调用方的指针产生数据竞争
+	updated := *device
+	updated.Commitment = commitment
+	updated.RotationRequired = rotationRequired
+	updated.RotationReason = reason
+	updated.UpdatedAt = time.Now()
+	m.devices[deviceID] = &updated
+	if session, ok := m.sessions[deviceID]; ok {
+		session.Device = &updated
+	}
+}
+
+// SetDefaultCircuitVersion 设置新注册设备使用的电路版本
+func (m *Manager) SetDefaultCircuitVersion(version string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.circuitVersion = version
+}
+
+// SyncCircuitVersion 同步设备电路版本到内存缓存（由认证服务在迁移后回调）
+func (m *Manager) SyncCircuitVersion(deviceID, version string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	device, exists := m.devices[deviceID]
+	if !exists {
+		return
+	}
+
+	updated := *device
+	updated.CircuitVersion = version
+	updated.UpdatedAt = time.Now()
+	m.devices[deviceID] = &updated
+	if session, ok := m.sessions[deviceID]; ok {
+		session.Device = &updated
+	}
+}
+
 // getCabinetID 获取储能柜ID，如果提供的为空则使用默认值
 func (m *Manager) getCabinetID(providedID string) string {
 	if providedID != "" {
@@ -44,7 +44,7 @@ func createTestManager(t *testing.T) (*Manager, func()) {
 		SupportedSensors:  []string{"co2", "co", "smoke", "liquid_level", "conductivity", "temperature", "flow"},
 	}
 	
-	manager := NewManager(deviceCfg, db, nil, logger) // nil license for test
+	manager := NewManager(deviceCfg, db, nil, logger, "") // nil license for test
 	
 	// 清理函数
 	cleanup := func() {
@@ -257,6 +257,32 @@ func (s *Service) Check() error {
 	return nil
 }
 
+// Status 许可证状态摘要，供ABAC上下文属性使用
+// disabled: 未启用校验；unlicensed: 未加载许可证；invalid: MAC不匹配；
+// valid / grace_period / expired: 按过期时间及宽限期判断
+func (s *Service) Status() string {
+	if !s.enabled {
+		return "disabled"
+	}
+	if s.claims == nil {
+		return "unlicensed"
+	}
+	if !strings.EqualFold(s.claims.MACAddress, s.macAddress) {
+		return "invalid"
+	}
+
+	now := time.Now()
+	expiresAt := s.claims.ExpiresAt.Time
+	switch {
+	case !now.After(expiresAt):
+		return "valid"
+	case now.Before(expiresAt.Add(s.gracePeriod)):
+		return "grace_period"
+	default:
+		return "expired"
+	}
+}
+
 // GetMaxDevices 获取许可证允许的最大设备数
 func (s *Service) GetMaxDevices() int {
 	if !s.enabled || s.claims == nil {
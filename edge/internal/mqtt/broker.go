@@ -0,0 +1,580 @@
+/*
+ * 内嵌 MQTT Broker
+ * 设备使用ZKP认证获得的会话令牌连接，按ABAC策略控制Topic发布/订阅，
+ * 收到的消息直接交给本地Handler处理，小型站点无需单独部署broker
+ */
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/abac"
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/pkg/models"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 内嵌broker默认参数
+const (
+	defaultBrokerSessionCheck   = 30 * time.Second
+	defaultBrokerMaxPayloadSize = 64 * 1024
+)
+
+// TokenValidator 会话令牌校验接口（auth.Service实现）
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*models.TokenClaims, error)
+	CheckSession(sessionID string) error
+}
+
+// CertificateAuthority 设备证书校验接口（pki.CA实现）
+type CertificateAuthority interface {
+	CertPool() *x509.CertPool
+	ServerTLSConfig(hosts []string) (*tls.Config, error)
+	VerifyDevice(cert *x509.Certificate) (deviceID, serial string, err error)
+	CheckCertificate(deviceID, serial string) error
+}
+
+// DeviceLookup 设备查询接口（device.Manager实现）
+type DeviceLookup interface {
+	GetDevice(deviceID string) (*models.Device, error)
+}
+
+// TrustObserver 设备行为信任度观察者
+type TrustObserver interface {
+	Observe(deviceID string, signal abac.TrustSignal)
+}
+
+// Broker 内嵌MQTT broker
+type Broker struct {
+	logger     *zap.Logger
+	config     config.MQTTBrokerConfig
+	handler    *Handler
+	validator  TokenValidator
+	devices    DeviceLookup
+	authorizer *abac.TopicAuthorizer // ABAC Topic授权（可选）
+	trust      TrustObserver         // 设备行为信任度（可选）
+
+	certs       CertificateAuthority // 设备证书认证（可选）
+	serverHosts []string             // 未配置证书文件时由Edge CA签发服务端证书使用的主机名
+
+	server *mqttserver.Server
+	tcp    *listeners.TCP
+
+	mu      sync.Mutex
+	clients map[*mqttserver.Client]clientAuth // 已连接客户端的认证信息，用于定期复查
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewBroker 创建内嵌broker
+func NewBroker(cfg config.MQTTBrokerConfig, handler *Handler, validator TokenValidator, devices DeviceLookup, logger *zap.Logger) *Broker {
+	if cfg.SessionCheck <= 0 {
+		cfg.SessionCheck = defaultBrokerSessionCheck
+	}
+	if cfg.MaxPayloadSize <= 0 {
+		cfg.MaxPayloadSize = defaultBrokerMaxPayloadSize
+	}
+	return &Broker{
+		logger:    logger,
+		config:    cfg,
+		handler:   handler,
+		validator: validator,
+		devices:   devices,
+		clients:   make(map[*mqttserver.Client]clientAuth),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// clientAuth 客户端认证方式：ZKP会话或设备证书
+type clientAuth struct {
+	deviceID   string
+	sessionID  string
+	certSerial string
+}
+
+// SetCertificateAuthority 启用设备证书认证
+// TLS监听要求客户端证书可选：提供Edge CA签发的证书即可免令牌连接
+func (b *Broker) SetCertificateAuthority(ca CertificateAuthority, serverHosts []string) {
+	b.certs = ca
+	b.serverHosts = serverHosts
+}
+
+// SetAuthorizer 设置ABAC Topic授权器，未设置时只做设备Topic归属检查
+func (b *Broker) SetAuthorizer(authorizer *abac.TopicAuthorizer) {
+	b.authorizer = authorizer
+}
+
+// SetTrustObserver 设置设备行为信任度观察者
+// 连接已认证，违规行为可以归属到设备本身：冒用其他设备身份计为认证失败，越权访问Topic计为访问拒绝
+func (b *Broker) SetTrustObserver(observer TrustObserver) {
+	b.trust = observer
+}
+
+// Start 启动监听
+func (b *Broker) Start() error {
+	server := mqttserver.New(&mqttserver.Options{
+		InlineClient: true, // 用于Publish向设备下发消息
+		Logger:       slog.New(&zapSlogHandler{logger: b.logger.Named("mqtt-broker")}),
+	})
+	if err := server.AddHook(&brokerHook{broker: b}, nil); err != nil {
+		return fmt.Errorf("failed to add broker hook: %w", err)
+	}
+
+	if b.config.Address != "" {
+		b.tcp = listeners.NewTCP(listeners.Config{Type: "tcp", ID: "tcp", Address: b.config.Address})
+		if err := server.AddListener(b.tcp); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", b.config.Address, err)
+		}
+	}
+	if b.config.TLSAddress != "" {
+		tlsConfig, err := b.serverTLSConfig()
+		if err != nil {
+			return err
+		}
+		ssl := listeners.NewTCP(listeners.Config{
+			Type:      "tcp",
+			ID:        "tls",
+			Address:   b.config.TLSAddress,
+			TLSConfig: tlsConfig,
+		})
+		if err := server.AddListener(ssl); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", b.config.TLSAddress, err)
+		}
+	}
+
+	if err := server.Serve(); err != nil {
+		return fmt.Errorf("failed to start broker: %w", err)
+	}
+	b.server = server
+
+	b.wg.Add(1)
+	go b.sessionChecker()
+
+	b.logger.Info("内嵌MQTT broker已启动",
+		zap.String("address", b.Addr()),
+		zap.String("tls_address", b.config.TLSAddress))
+	return nil
+}
+
+// Stop 停止broker并断开所有客户端
+func (b *Broker) Stop() {
+	if b.server == nil {
+		return
+	}
+	close(b.stopCh)
+	b.wg.Wait()
+	if err := b.server.Close(); err != nil {
+		b.logger.Warn("关闭内嵌MQTT broker失败", zap.Error(err))
+	}
+}
+
+// serverTLSConfig TLS监听配置：优先使用配置的证书文件，否则由Edge CA签发
+func (b *Broker) serverTLSConfig() (*tls.Config, error) {
+	if b.config.CertFile == "" {
+		if b.certs == nil {
+			return nil, fmt.Errorf("broker TLS requires cert_file/key_file or edge CA")
+		}
+		return b.certs.ServerTLSConfig(b.serverHosts)
+	}
+
+	cert, err := tls.LoadX509KeyPair(b.config.CertFile, b.config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load broker certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if b.certs != nil {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsConfig.ClientCAs = b.certs.CertPool()
+	}
+	return tlsConfig, nil
+}
+
+// Publish 向已订阅的设备发布消息
+func (b *Broker) Publish(topic string, payload []byte, retain bool, qos byte) error {
+	if b.server == nil {
+		return fmt.Errorf("broker not started")
+	}
+	return b.server.Publish(topic, payload, retain, qos)
+}
+
+// Addr TCP监听的实际地址（配置端口为0时用于获取分配的端口）
+func (b *Broker) Addr() string {
+	if b.tcp == nil {
+		return ""
+	}
+	return b.tcp.Address()
+}
+
+// ConnectedClients 当前已认证的设备连接数
+func (b *Broker) ConnectedClients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients)
+}
+
+// authenticate 校验连接凭据
+// 提供了Edge CA签发的客户端证书时按证书主体认证，否则用户名为设备ID、密码为会话令牌
+func (b *Broker) authenticate(cl *mqttserver.Client, pk packets.Packet) error {
+	auth, err := b.authenticateCertificate(cl, pk)
+	if err != nil {
+		return err
+	}
+	if auth == nil {
+		if auth, err = b.authenticateToken(pk); err != nil {
+			return err
+		}
+	}
+
+	// 客户端ID必须属于该设备，避免抢占其他设备的MQTT会话
+	if cl.ID != auth.deviceID && !strings.HasPrefix(cl.ID, auth.deviceID+"-") {
+		return fmt.Errorf("client id %q does not belong to device", cl.ID)
+	}
+
+	device, err := b.devices.GetDevice(auth.deviceID)
+	if err != nil {
+		return err
+	}
+	if device.Status == models.DeviceStatusDisabled {
+		return fmt.Errorf("device disabled")
+	}
+
+	// ACL按用户名识别设备，证书认证时用户名可省略
+	cl.Properties.Username = []byte(auth.deviceID)
+
+	b.mu.Lock()
+	b.clients[cl] = *auth
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *Broker) authenticateToken(pk packets.Packet) (*clientAuth, error) {
+	deviceID := string(pk.Connect.Username)
+	token := string(pk.Connect.Password)
+	if deviceID == "" || token == "" {
+		return nil, fmt.Errorf("missing device id or token")
+	}
+
+	claims, err := b.validator.ValidateToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.DeviceID != deviceID {
+		return nil, fmt.Errorf("token does not belong to device")
+	}
+	return &clientAuth{deviceID: deviceID, sessionID: claims.SessionID}, nil
+}
+
+// authenticateCertificate 校验TLS客户端证书，未提供证书时返回nil
+func (b *Broker) authenticateCertificate(cl *mqttserver.Client, pk packets.Packet) (*clientAuth, error) {
+	if b.certs == nil {
+		return nil, nil
+	}
+	conn, ok := cl.Net.Conn.(*tls.Conn)
+	if !ok {
+		return nil, nil
+	}
+	state := conn.ConnectionState()
+	if len(state.VerifiedChains) == 0 {
+		return nil, nil
+	}
+
+	deviceID, serial, err := b.certs.VerifyDevice(state.VerifiedChains[0][0])
+	if err != nil {
+		return nil, err
+	}
+	if username := string(pk.Connect.Username); username != "" && username != deviceID {
+		return nil, fmt.Errorf("username %q does not match certificate subject", username)
+	}
+	return &clientAuth{deviceID: deviceID, certSerial: serial}, nil
+}
+
+// authorize 检查设备对Topic的发布/订阅权限
+// 先检查Topic归属（设备只能发布自己的数据、订阅自己的下行Topic），再按ABAC策略评估（shadow模式只记录）
+func (b *Broker) authorize(cl *mqttserver.Client, topic string, write bool) bool {
+	deviceID := string(cl.Properties.Username)
+	if !deviceOwnsTopic(deviceID, topic, write) {
+		b.logger.Warn("设备访问非本设备Topic",
+			zap.String("device_id", deviceID),
+			zap.String("topic", topic),
+			zap.Bool("publish", write))
+		if b.trust != nil {
+			b.trust.Observe(deviceID, abac.SignalAccessDenied)
+		}
+		return false
+	}
+	if b.authorizer == nil {
+		return true
+	}
+
+	attrs := deviceAttributes(b.devices, deviceID)
+	action := abac.ActionSubscribe
+	if write {
+		action = abac.ActionPublish
+	}
+	sourceIP, _, err := net.SplitHostPort(cl.Net.Remote)
+	if err != nil {
+		sourceIP = cl.Net.Remote
+	}
+	resp := b.authorizer.Authorize(context.Background(), attrs, topic, action, sourceIP)
+	if !resp.Allowed {
+		b.logger.Warn("ABAC拒绝MQTT访问",
+			zap.String("device_id", deviceID),
+			zap.String("topic", topic),
+			zap.String("action", action),
+			zap.Bool("enforced", b.authorizer.Enforcing()),
+			zap.String("reason", resp.Reason))
+	}
+	// shadow模式只记录评估结果
+	return resp.Allowed || !b.authorizer.Enforcing()
+}
+
+// deviceAttributes 构建设备的ABAC属性，设备未注册时使用默认属性
+func deviceAttributes(devices DeviceLookup, deviceID string) *abac.DeviceAttributes {
+	attrs := &abac.DeviceAttributes{
+		DeviceID: deviceID,
+		Status:   "active",
+		Quality:  80,
+	}
+	if devices == nil {
+		return attrs
+	}
+	if device, err := devices.GetDevice(deviceID); err == nil {
+		attrs.CabinetID = device.CabinetID
+		attrs.SensorType = string(device.SensorType)
+		if device.Status != models.DeviceStatusOnline {
+			attrs.Status = string(device.Status)
+		}
+		if device.LastSeenAt != nil {
+			attrs.LastReadingAt = *device.LastSeenAt
+		}
+	}
+	return attrs
+}
+
+// checkPayload 检查消息大小及消息体中的设备ID与连接身份一致
+func (b *Broker) checkPayload(cl *mqttserver.Client, pk packets.Packet) error {
+	deviceID := string(cl.Properties.Username)
+	if len(pk.Payload) > b.config.MaxPayloadSize {
+		return fmt.Errorf("payload too large: %d bytes", len(pk.Payload))
+	}
+
+	var body struct {
+		DeviceID string `json:"device_id"`
+	}
+	if err := json.Unmarshal(pk.Payload, &body); err != nil {
+		return fmt.Errorf("invalid payload: %w", err)
+	}
+	if body.DeviceID != deviceID {
+		if b.trust != nil {
+			b.trust.Observe(deviceID, abac.SignalAuthFailure)
+		}
+		return fmt.Errorf("payload device_id %q does not match connection", body.DeviceID)
+	}
+	return nil
+}
+
+// sessionChecker 定期复查已连接客户端的会话，撤销或过期后断开连接
+func (b *Broker) sessionChecker() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.config.SessionCheck)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			b.checkSessions()
+		}
+	}
+}
+
+func (b *Broker) checkSessions() {
+	b.mu.Lock()
+	clients := make(map[*mqttserver.Client]clientAuth, len(b.clients))
+	for cl, auth := range b.clients {
+		clients[cl] = auth
+	}
+	b.mu.Unlock()
+
+	for cl, auth := range clients {
+		var err error
+		if auth.certSerial != "" {
+			err = b.certs.CheckCertificate(auth.deviceID, auth.certSerial)
+		} else {
+			err = b.validator.CheckSession(auth.sessionID)
+		}
+		if err != nil {
+			b.logger.Info("设备会话或证书已失效，断开MQTT连接",
+				zap.String("device_id", auth.deviceID),
+				zap.String("client_id", cl.ID),
+				zap.Error(err))
+			b.server.DisconnectClient(cl, packets.ErrNotAuthorized)
+		}
+	}
+}
+
+func (b *Broker) forget(cl *mqttserver.Client) {
+	b.mu.Lock()
+	delete(b.clients, cl)
+	b.mu.Unlock()
+}
+
+// deviceOwnsTopic Topic是否属于该设备
+//
+//	发布: sensors/{id}/{sensor_type}、alerts/{id}/...、devices/{id}/status、devices/{id}/heartbeat
+//	订阅: devices/{id}/...
+func deviceOwnsTopic(deviceID, topic string, publish bool) bool {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 2 || parts[1] != deviceID {
+		return false
+	}
+
+	if !publish {
+		return parts[0] == "devices" && len(parts) >= 3
+	}
+
+	switch parts[0] {
+	case "sensors":
+		return len(parts) == 3
+	case "alerts":
+		return true
+	case "devices":
+		return len(parts) == 3 && (parts[2] == "status" || parts[2] == "heartbeat")
+	}
+	return false
+}
+
+// brokerHook 接入mochi broker的认证、ACL和消息回调
+type brokerHook struct {
+	mqttserver.HookBase
+	broker *Broker
+}
+
+func (h *brokerHook) ID() string {
+	return "edge-zkp-auth"
+}
+
+func (h *brokerHook) Provides(b byte) bool {
+	switch b {
+	case mqttserver.OnConnectAuthenticate,
+		mqttserver.OnACLCheck,
+		mqttserver.OnPublish,
+		mqttserver.OnPublished,
+		mqttserver.OnDisconnect:
+		return true
+	}
+	return false
+}
+
+func (h *brokerHook) OnConnectAuthenticate(cl *mqttserver.Client, pk packets.Packet) bool {
+	if err := h.broker.authenticate(cl, pk); err != nil {
+		h.broker.logger.Warn("MQTT连接认证失败",
+			zap.String("client_id", cl.ID),
+			zap.String("device_id", string(pk.Connect.Username)),
+			zap.String("remote", cl.Net.Remote),
+			zap.Error(err))
+		return false
+	}
+	h.broker.logger.Info("设备已连接内嵌MQTT broker",
+		zap.String("device_id", string(cl.Properties.Username)),
+		zap.String("client_id", cl.ID),
+		zap.String("remote", cl.Net.Remote))
+	return true
+}
+
+func (h *brokerHook) OnACLCheck(cl *mqttserver.Client, topic string, write bool) bool {
+	return h.broker.authorize(cl, topic, write)
+}
+
+func (h *brokerHook) OnPublish(cl *mqttserver.Client, pk packets.Packet) (packets.Packet, error) {
+	if cl.Net.Inline {
+		return pk, nil
+	}
+	if err := h.broker.checkPayload(cl, pk); err != nil {
+		h.broker.logger.Warn("拒绝MQTT消息",
+			zap.String("device_id", string(cl.Properties.Username)),
+			zap.String("topic", pk.TopicName),
+			zap.Error(err))
+		return pk, packets.ErrRejectPacket
+	}
+	return pk, nil
+}
+
+func (h *brokerHook) OnPublished(cl *mqttserver.Client, pk packets.Packet) {
+	if cl.Net.Inline {
+		return
+	}
+	// 发布权限已在ACL检查中评估，不再重复评估
+	h.broker.handler.dispatch(pk.TopicName, pk.Payload, true)
+}
+
+func (h *brokerHook) OnDisconnect(cl *mqttserver.Client, err error, expire bool) {
+	h.broker.forget(cl)
+}
+
+// zapSlogHandler 将mochi的slog日志转发到zap
+type zapSlogHandler struct {
+	logger *zap.Logger
+	fields []zap.Field
+}
+
+func (h *zapSlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(zapLevel(level))
+}
+
+func (h *zapSlogHandler) Handle(_ context.Context, r slog.Record) error {
+	fields := make([]zap.Field, 0, len(h.fields)+r.NumAttrs())
+	fields = append(fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		fields = append(fields, zap.Any(a.Key, a.Value.Any()))
+		return true
+	})
+	if ce := h.logger.Check(zapLevel(r.Level), r.Message); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+func (h *zapSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, a := range attrs {
+		fields = append(fields, zap.Any(a.Key, a.Value.Any()))
+	}
+	return &zapSlogHandler{logger: h.logger, fields: fields}
+}
+
+func (h *zapSlogHandler) WithGroup(name string) slog.Handler {
+	return &zapSlogHandler{logger: h.logger.Named(name), fields: h.fields}
+}
+
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
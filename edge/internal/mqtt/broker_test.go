@@ -0,0 +1,249 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edge/storage-cabinet/internal/abac"
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+type fakeValidator struct {
+	mu      sync.Mutex
+	tokens  map[string]*models.TokenClaims // token -> claims
+	revoked map[string]bool                // session_id -> revoked
+}
+
+func (v *fakeValidator) ValidateToken(token string) (*models.TokenClaims, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	claims, ok := v.tokens[token]
+	if !ok || v.revoked[claims.SessionID] {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+func (v *fakeValidator) CheckSession(sessionID string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.revoked[sessionID] {
+		return fmt.Errorf("SESSION_REVOKED: session revoked")
+	}
+	return nil
+}
+
+func (v *fakeValidator) revoke(sessionID string) {
+	v.mu.Lock()
+	v.revoked[sessionID] = true
+	v.mu.Unlock()
+}
+
+type fakeDevices struct{}
+
+func (fakeDevices) GetDevice(deviceID string) (*models.Device, error) {
+	return &models.Device{DeviceID: deviceID, SensorType: models.SensorCO2, Status: models.DeviceStatusOnline}, nil
+}
+
+func (fakeDevices) UpdateDeviceStatusString(deviceID, status string) error { return nil }
+func (fakeDevices) UpdateLastSeen(deviceID string) error                   { return nil }
+
+type fakeCollector struct {
+	mu   sync.Mutex
+	data []*SensorData
+}
+
+func (c *fakeCollector) SaveSensorData(data interface{}) error {
+	c.mu.Lock()
+	c.data = append(c.data, data.(*SensorData))
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *fakeCollector) SaveAlert(alert interface{}) error { return nil }
+func (c *fakeCollector) ResolveAlert(alertID int64) error  { return nil }
+
+func (c *fakeCollector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+func startTestBroker(t *testing.T) (*Broker, *fakeValidator, *fakeCollector) {
+	t.Helper()
+	return startTestBrokerWithAuthorizer(t, nil)
+}
+
+func startTestBrokerWithAuthorizer(t *testing.T, authorizer *abac.TopicAuthorizer) (*Broker, *fakeValidator, *fakeCollector) {
+	t.Helper()
+	logger := zap.NewNop()
+	collector := &fakeCollector{}
+	handler := NewHandler(logger, collector, fakeDevices{}, NewMQTTStats(), nil, nil)
+	validator := &fakeValidator{
+		tokens: map[string]*models.TokenClaims{
+			"token-a": {DeviceID: "DEVA", SessionID: "session-a"},
+			"token-b": {DeviceID: "DEVB", SessionID: "session-b"},
+		},
+		revoked: map[string]bool{},
+	}
+
+	broker := NewBroker(config.MQTTBrokerConfig{
+		Address:      "127.0.0.1:0",
+		SessionCheck: 100 * time.Millisecond,
+	}, handler, validator, fakeDevices{}, logger)
+	if authorizer != nil {
+		broker.SetAuthorizer(authorizer)
+	}
+	if err := broker.Start(); err != nil {
+		t.Fatalf("start broker: %v", err)
+	}
+	t.Cleanup(broker.Stop)
+	return broker, validator, collector
+}
+
+func connect(t *testing.T, broker *Broker, deviceID, token string) (paho.Client, error) {
+	t.Helper()
+	opts := paho.NewClientOptions().
+		AddBroker("tcp://" + broker.Addr()).
+		SetClientID(deviceID).
+		SetUsername(deviceID).
+		SetPassword(token).
+		SetAutoReconnect(false).
+		SetConnectTimeout(2 * time.Second)
+	client := paho.NewClient(opts)
+	tok := client.Connect()
+	if !tok.WaitTimeout(3 * time.Second) {
+		return nil, fmt.Errorf("connect timeout")
+	}
+	if err := tok.Error(); err != nil {
+		return nil, err
+	}
+	t.Cleanup(func() { client.Disconnect(100) })
+	return client, nil
+}
+
+func publishReading(t *testing.T, client paho.Client, topic, deviceID string) {
+	t.Helper()
+	payload, _ := json.Marshal(SensorData{
+		DeviceID:   deviceID,
+		SensorType: "co2",
+		Value:      420,
+		Timestamp:  time.Now(),
+	})
+	tok := client.Publish(topic, 0, false, payload)
+	tok.WaitTimeout(2 * time.Second)
+}
+
+func waitFor(t *testing.T, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestBrokerRejectsInvalidCredentials(t *testing.T) {
+	broker, _, _ := startTestBroker(t)
+
+	if _, err := connect(t, broker, "DEVA", "bogus"); err == nil {
+		t.Fatal("expected invalid token to be rejected")
+	}
+	// 令牌属于其他设备
+	if _, err := connect(t, broker, "DEVA", "token-b"); err == nil {
+		t.Fatal("expected token of another device to be rejected")
+	}
+}
+
+func TestBrokerDispatchesOwnTopicsOnly(t *testing.T) {
+	broker, _, collector := startTestBroker(t)
+
+	client, err := connect(t, broker, "DEVA", "token-a")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	publishReading(t, client, "sensors/DEVA/co2", "DEVA")
+	if !waitFor(t, func() bool { return collector.count() == 1 }) {
+		t.Fatalf("expected reading to be dispatched, got %d", collector.count())
+	}
+
+	// 其他设备的Topic、消息体设备ID不一致的消息均被丢弃
+	publishReading(t, client, "sensors/DEVB/co2", "DEVB")
+	publishReading(t, client, "sensors/DEVA/co2", "DEVB")
+	publishReading(t, client, "sensors/DEVA/co2", "DEVA")
+	if !waitFor(t, func() bool { return collector.count() == 2 }) {
+		t.Fatalf("expected 2 readings, got %d", collector.count())
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := collector.count(); n != 2 {
+		t.Fatalf("foreign messages were dispatched: %d readings", n)
+	}
+
+	// 只能订阅本设备的下行Topic
+	tok := client.Subscribe("devices/DEVB/commands", 0, nil)
+	tok.WaitTimeout(2 * time.Second)
+	if sub, ok := tok.(*paho.SubscribeToken); ok {
+		if code := sub.Result()["devices/DEVB/commands"]; code < 0x80 {
+			t.Fatalf("expected subscribe to be refused, got code %#x", code)
+		}
+	}
+}
+
+func TestBrokerTopicAuthorizationModes(t *testing.T) {
+	repo := &fakePolicyRepo{policies: []*abac.AccessPolicy{{
+		ID: "allow-status", Name: "allow-status", SubjectType: "device", Enabled: true,
+		Permissions: []string{"write:status"},
+	}}}
+
+	for _, tt := range []struct {
+		mode string
+		want int
+	}{
+		{abac.TopicModeShadow, 1},
+		{abac.TopicModeEnforce, 0},
+	} {
+		authorizer := abac.NewTopicAuthorizer(repo)
+		authorizer.SetMode(tt.mode)
+		broker, _, collector := startTestBrokerWithAuthorizer(t, authorizer)
+		t.Cleanup(authorizer.Close)
+
+		client, err := connect(t, broker, "DEVA", "token-a")
+		if err != nil {
+			t.Fatalf("%s: connect: %v", tt.mode, err)
+		}
+		// 策略未授予write:sensors，shadow模式只记录，enforce模式丢弃
+		publishReading(t, client, "sensors/DEVA/co2", "DEVA")
+		waitFor(t, func() bool { return collector.count() == tt.want })
+		time.Sleep(100 * time.Millisecond)
+		if n := collector.count(); n != tt.want {
+			t.Fatalf("%s: expected %d readings, got %d", tt.mode, tt.want, n)
+		}
+	}
+}
+
+func TestBrokerDisconnectsRevokedSession(t *testing.T) {
+	broker, validator, _ := startTestBroker(t)
+
+	client, err := connect(t, broker, "DEVA", "token-a")
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if !waitFor(t, func() bool { return broker.ConnectedClients() == 1 }) {
+		t.Fatal("client not tracked")
+	}
+
+	validator.revoke("session-a")
+	if !waitFor(t, func() bool { return !client.IsConnectionOpen() }) {
+		t.Fatal("expected revoked session to be disconnected")
+	}
+}
@@ -5,13 +5,17 @@
 package mqtt
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edge/storage-cabinet/internal/abac"
 	"github.com/edge/storage-cabinet/internal/cloud"
 	"github.com/edge/storage-cabinet/internal/license"
+	"github.com/edge/storage-cabinet/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -24,6 +28,15 @@ type Handler struct {
 	stats            *MQTTStats    // MQTT统计数据
 	licenseService   *license.Service
 	ackClient        *cloud.CommandClient
+	rotator          CredentialRotator    // 凭证轮换服务（可选）
+	sessionRevoker   SessionRevoker       // 会话撤销服务（可选）
+	baselineApprover BaselineApprover     // 配置基线批准服务（可选）
+	remediation      RemediationConfirmer // 漏洞处置确认服务（可选）
+	certReviewer     CertificateReviewer  // 证书申请审批服务（可选）
+	groupSyncer      GroupSyncer          // 授权群组同步服务（可选）
+
+	authorizer *abac.TopicAuthorizer // ABAC Topic授权（可选）
+	devices    DeviceLookup          // 构建设备ABAC属性
 }
 
 // CollectorService 数据采集服务接口
@@ -39,6 +52,40 @@ type DeviceManager interface {
 	UpdateLastSeen(deviceID string) error
 }
 
+// CredentialRotator 凭证轮换接口
+type CredentialRotator interface {
+	ForceRotation(deviceID, reason, initiatedBy string) (int, error)
+}
+
+// SessionRevoker 会话撤销接口
+type SessionRevoker interface {
+	RevokeDeviceSessions(deviceID, reason string) (int64, error)
+	RevokeAllSessions(reason string) (int64, error)
+}
+
+// BaselineApprover 配置基线批准接口
+type BaselineApprover interface {
+	ApproveBaseline(approvedBy, reason string) (int64, error)
+}
+
+// RemediationConfirmer 漏洞处置确认接口
+type RemediationConfirmer interface {
+	Confirm(id int64, operator string) (*models.RemediationRecord, error)
+	Reject(id int64, operator, reason string) (*models.RemediationRecord, error)
+}
+
+// CertificateReviewer 证书申请审批接口
+type CertificateReviewer interface {
+	ApproveRequest(requestID, operator string) (*models.CertificateRequest, error)
+	RejectRequest(requestID, operator, reason string) (*models.CertificateRequest, error)
+}
+
+// GroupSyncer 授权群组同步接口（群组和成员只由Cloud管理）
+type GroupSyncer interface {
+	SyncGroup(req *models.SyncGroupRequest) (*models.AuthGroup, error)
+	DeleteGroup(groupID string) error
+}
+
 // NewHandler 创建消息处理器
 func NewHandler(logger *zap.Logger, collector CollectorService, deviceMgr DeviceManager, stats *MQTTStats, licenseSvc *license.Service, ackClient *cloud.CommandClient) *Handler {
 	return &Handler{
@@ -57,6 +104,42 @@ func (h *Handler) SetWebSocketHub(wsHub *WebSocketHub) {
 	h.wsHub = wsHub
 }
 
+// SetCredentialRotator 设置凭证轮换服务
+func (h *Handler) SetCredentialRotator(rotator CredentialRotator) {
+	h.rotator = rotator
+}
+
+// SetSessionRevoker 设置会话撤销服务
+func (h *Handler) SetSessionRevoker(revoker SessionRevoker) {
+	h.sessionRevoker = revoker
+}
+
+// SetBaselineApprover 设置配置基线批准服务
+func (h *Handler) SetBaselineApprover(approver BaselineApprover) {
+	h.baselineApprover = approver
+}
+
+// SetRemediationConfirmer 设置漏洞处置确认服务
+func (h *Handler) SetRemediationConfirmer(confirmer RemediationConfirmer) {
+	h.remediation = confirmer
+}
+
+// SetCertificateReviewer 设置证书申请审批服务
+func (h *Handler) SetCertificateReviewer(reviewer CertificateReviewer) {
+	h.certReviewer = reviewer
+}
+
+// SetGroupSyncer 设置授权群组同步服务
+func (h *Handler) SetGroupSyncer(syncer GroupSyncer) {
+	h.groupSyncer = syncer
+}
+
+// SetAuthorizer 设置ABAC Topic授权器，外部broker转发的设备上行消息按策略评估并记录
+func (h *Handler) SetAuthorizer(authorizer *abac.TopicAuthorizer, devices DeviceLookup) {
+	h.authorizer = authorizer
+	h.devices = devices
+}
+
 // GetWebSocketHub 获取WebSocket管理器
 func (h *Handler) GetWebSocketHub() *WebSocketHub {
 	return h.wsHub
@@ -64,16 +147,28 @@ func (h *Handler) GetWebSocketHub() *WebSocketHub {
 
 // HandleMessage 处理 MQTT 消息（路由器）
 func (h *Handler) HandleMessage(client mqtt.Client, msg mqtt.Message) {
+	h.Dispatch(msg.Topic(), msg.Payload())
+}
+
+// Dispatch 按Topic分发消息，供外部broker订阅和内嵌broker共用
+func (h *Handler) Dispatch(topic string, payload []byte) {
+	h.dispatch(topic, payload, false)
+}
+
+// dispatch 分发消息，authorized为true表示发布权限已由内嵌broker评估
+// 外部broker的消息无法确认发送方身份，只按消息体中的设备ID评估并记录，不拒绝
+func (h *Handler) dispatch(topic string, payload []byte, authorized bool) {
 	// 记录消息接收时间（用于计算延迟）
 	receiveTime := time.Now()
 
-	topic := msg.Topic()
-	payload := msg.Payload()
-
 	h.logger.Debug("📥 收到 MQTT 消息",
 		zap.String("topic", topic),
 		zap.Int("payload_size", len(payload)))
 
+	if !authorized {
+		h.audit(topic, payload)
+	}
+
 	// 根据 Topic 前缀路由到不同处理器
 	switch {
 	case strings.HasPrefix(topic, "cloud/cabinets/"):
@@ -94,6 +189,38 @@ func (h *Handler) HandleMessage(client mqtt.Client, msg mqtt.Message) {
 	}
 }
 
+// audit 按ABAC策略评估外部broker转发的设备上行消息（动作publish）并记录结果，Cloud下行命令不评估
+// 设备ID取自消息体，缺失时取Topic第二段；该身份可以伪造，评估结果只用于审计，不拒绝消息
+func (h *Handler) audit(topic string, payload []byte) {
+	if h.authorizer == nil || strings.HasPrefix(topic, "cloud/") {
+		return
+	}
+
+	var body struct {
+		DeviceID string `json:"device_id"`
+	}
+	_ = json.Unmarshal(payload, &body)
+	deviceID := body.DeviceID
+	if deviceID == "" {
+		if parts := strings.Split(topic, "/"); len(parts) >= 2 {
+			deviceID = parts[1]
+		}
+	}
+	if deviceID == "" {
+		h.logger.Warn("无法识别MQTT消息的设备", zap.String("topic", topic))
+		return
+	}
+
+	attrs := deviceAttributes(h.devices, deviceID)
+	resp := h.authorizer.AuthorizeUnverified(context.Background(), attrs, topic, abac.ActionPublish)
+	if !resp.Allowed {
+		h.logger.Warn("ABAC评估未授权的MQTT消息（外部broker，仅记录）",
+			zap.String("device_id", deviceID),
+			zap.String("topic", topic),
+			zap.String("reason", resp.Reason))
+	}
+}
+
 type commandMessage struct {
 	CommandID   string                 `json:"command_id"`
 	CommandType string                 `json:"command_type"`
@@ -191,6 +318,203 @@ func (h *Handler) handleCommand(topic string, payload []byte) {
 			zap.String("command_id", cmd.CommandID),
 			zap.Int64("alert_id", int64(alertID)))
 		h.ackCommand(cmd.CommandID, "success", "alert resolved")
+	case "credential_rotate":
+		if h.rotator == nil {
+			h.ackCommand(cmd.CommandID, "failed", "credential rotator not initialized")
+			return
+		}
+		// device_id为空表示整个储能柜的设备
+		deviceID, _ := cmd.Payload["device_id"].(string)
+		reason, _ := cmd.Payload["reason"].(string)
+		count, err := h.rotator.ForceRotation(deviceID, reason, "cloud")
+		if err != nil {
+			h.logger.Error("强制凭证轮换失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.String("device_id", deviceID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Warn("已强制设备轮换凭证（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.String("device_id", deviceID),
+			zap.Int("devices", count))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("rotation required for %d devices", count))
+	case "session_revoke":
+		if h.sessionRevoker == nil {
+			h.ackCommand(cmd.CommandID, "failed", "session revoker not initialized")
+			return
+		}
+		// device_id为空表示撤销整个储能柜的会话
+		deviceID, _ := cmd.Payload["device_id"].(string)
+		var revoked int64
+		var err error
+		if deviceID != "" {
+			revoked, err = h.sessionRevoker.RevokeDeviceSessions(deviceID, "cloud")
+		} else {
+			revoked, err = h.sessionRevoker.RevokeAllSessions("cloud")
+		}
+		if err != nil {
+			h.logger.Error("撤销会话失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.String("device_id", deviceID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Info("已撤销设备会话（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.String("device_id", deviceID),
+			zap.Int64("revoked", revoked))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("%d sessions revoked", revoked))
+	case "config_baseline_approve":
+		if h.baselineApprover == nil {
+			h.ackCommand(cmd.CommandID, "failed", "config drift monitor not initialized")
+			return
+		}
+		reason, _ := cmd.Payload["reason"].(string)
+		approvedBy, _ := cmd.Payload["approved_by"].(string)
+		if approvedBy == "" {
+			approvedBy = "cloud"
+		}
+		baselineID, err := h.baselineApprover.ApproveBaseline(approvedBy, reason)
+		if err != nil {
+			h.logger.Error("批准配置基线失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Info("配置基线已批准（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.String("approved_by", approvedBy),
+			zap.Int64("baseline_id", baselineID))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("baseline %d approved", baselineID))
+	case "remediation_confirm":
+		if h.remediation == nil {
+			h.ackCommand(cmd.CommandID, "failed", "remediation playbooks not enabled")
+			return
+		}
+		id, _ := cmd.Payload["remediation_id"].(float64)
+		if id <= 0 {
+			h.ackCommand(cmd.CommandID, "failed", "missing remediation_id")
+			return
+		}
+		operator, _ := cmd.Payload["operator"].(string)
+		if operator == "" {
+			operator = "cloud"
+		}
+		approve, ok := cmd.Payload["approve"].(bool)
+		if !ok {
+			approve = true
+		}
+
+		var record *models.RemediationRecord
+		var err error
+		if approve {
+			record, err = h.remediation.Confirm(int64(id), operator)
+		} else {
+			reason, _ := cmd.Payload["reason"].(string)
+			record, err = h.remediation.Reject(int64(id), operator, reason)
+		}
+		if err != nil {
+			h.logger.Error("处理漏洞处置确认失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Info("漏洞处置已确认（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.Int64("remediation_id", record.ID),
+			zap.String("status", record.Status))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("remediation %d %s: %s", record.ID, record.Status, record.Result))
+	case "certificate_request_review":
+		if h.certReviewer == nil {
+			h.ackCommand(cmd.CommandID, "failed", "edge CA not enabled")
+			return
+		}
+		requestID, _ := cmd.Payload["request_id"].(string)
+		if requestID == "" {
+			h.ackCommand(cmd.CommandID, "failed", "missing request_id")
+			return
+		}
+		operator, _ := cmd.Payload["operator"].(string)
+		if operator == "" {
+			operator = "cloud"
+		}
+		approve, _ := cmd.Payload["approve"].(bool)
+
+		var req *models.CertificateRequest
+		var err error
+		if approve {
+			req, err = h.certReviewer.ApproveRequest(requestID, operator)
+		} else {
+			reason, _ := cmd.Payload["reason"].(string)
+			req, err = h.certReviewer.RejectRequest(requestID, operator, reason)
+		}
+		if err != nil {
+			h.logger.Error("审批证书申请失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.String("request_id", requestID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Info("证书申请已审批（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.String("request_id", requestID),
+			zap.String("device_id", req.DeviceID),
+			zap.String("status", req.Status))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("certificate request %s %s", requestID, req.Status))
+	case "auth_group_sync":
+		if h.groupSyncer == nil {
+			h.ackCommand(cmd.CommandID, "failed", "group authentication not enabled")
+			return
+		}
+		// payload为完整的群组定义，delete为true时删除群组
+		var req models.SyncGroupRequest
+		raw, _ := json.Marshal(cmd.Payload)
+		if err := json.Unmarshal(raw, &req); err != nil || req.GroupID == "" {
+			h.ackCommand(cmd.CommandID, "failed", "missing or invalid group definition")
+			return
+		}
+		if remove, _ := cmd.Payload["delete"].(bool); remove {
+			if err := h.groupSyncer.DeleteGroup(req.GroupID); err != nil {
+				h.logger.Error("删除授权群组失败",
+					zap.String("command_id", cmd.CommandID),
+					zap.String("group_id", req.GroupID),
+					zap.Error(err))
+				h.ackCommand(cmd.CommandID, "failed", err.Error())
+				return
+			}
+			h.logger.Info("授权群组已删除（通过Cloud命令）",
+				zap.String("command_id", cmd.CommandID),
+				zap.String("group_id", req.GroupID))
+			h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("group %s deleted", req.GroupID))
+			return
+		}
+
+		group, err := h.groupSyncer.SyncGroup(&req)
+		if err != nil {
+			h.logger.Error("同步授权群组失败",
+				zap.String("command_id", cmd.CommandID),
+				zap.String("group_id", req.GroupID),
+				zap.Error(err))
+			h.ackCommand(cmd.CommandID, "failed", err.Error())
+			return
+		}
+
+		h.logger.Info("授权群组已同步（通过Cloud命令）",
+			zap.String("command_id", cmd.CommandID),
+			zap.String("group_id", group.GroupID),
+			zap.Int("members", group.MemberCount))
+		h.ackCommand(cmd.CommandID, "success", fmt.Sprintf("group %s synced: %d members, root %s", group.GroupID, group.MemberCount, group.Root))
 	default:
 		h.logger.Warn("收到未知命令",
 			zap.String("command_type", cmd.CommandType))
@@ -0,0 +1,113 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/abac"
+	"go.uber.org/zap"
+)
+
+type fakePolicyRepo struct {
+	mu       sync.Mutex
+	policies []*abac.AccessPolicy
+	logs     []*abac.AccessLog
+}
+
+func (r *fakePolicyRepo) SavePolicy(ctx context.Context, policy *abac.AccessPolicy) error { return nil }
+func (r *fakePolicyRepo) GetPolicy(ctx context.Context, id string) (*abac.AccessPolicy, error) {
+	return nil, nil
+}
+func (r *fakePolicyRepo) GetAllPolicies(ctx context.Context) ([]*abac.AccessPolicy, error) {
+	return r.policies, nil
+}
+func (r *fakePolicyRepo) GetEnabledPolicies(ctx context.Context) ([]*abac.AccessPolicy, error) {
+	return r.policies, nil
+}
+func (r *fakePolicyRepo) DeletePolicy(ctx context.Context, id string) error { return nil }
+func (r *fakePolicyRepo) ClearPolicies(ctx context.Context) error           { return nil }
+func (r *fakePolicyRepo) GetCombiningAlgorithm(ctx context.Context) (string, error) {
+	return abac.AlgorithmDenyOverrides, nil
+}
+func (r *fakePolicyRepo) SetCombiningAlgorithm(ctx context.Context, algorithm string) error {
+	return nil
+}
+func (r *fakePolicyRepo) GetUnsyncedLogs(ctx context.Context, limit int) ([]*abac.AccessLog, error) {
+	return nil, nil
+}
+func (r *fakePolicyRepo) MarkLogsSynced(ctx context.Context, ids []int64) error { return nil }
+
+func (r *fakePolicyRepo) LogAccess(ctx context.Context, log *abac.AccessLog) error {
+	r.mu.Lock()
+	r.logs = append(r.logs, log)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fakePolicyRepo) deniedLogs() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, log := range r.logs {
+		if !log.Allowed {
+			n++
+		}
+	}
+	return n
+}
+
+func TestHandlerAuditsExternalMessages(t *testing.T) {
+	repo := &fakePolicyRepo{policies: []*abac.AccessPolicy{
+		{
+			ID: "allow-sensors", Name: "allow-sensors", SubjectType: "device", Enabled: true, Priority: 100,
+			Permissions: []string{"write:sensors", "write:status"},
+		},
+		{
+			ID: "deny-devb", Name: "deny-devb", SubjectType: "device", Effect: abac.EffectDeny, Enabled: true, Priority: 50,
+			Conditions:  []abac.PolicyCondition{{Attribute: "device_id", Operator: "eq", Value: "DEVB"}},
+			Permissions: []string{"write:*"},
+		},
+	}}
+	authorizer := abac.NewTopicAuthorizer(repo)
+	authorizer.SetMode(abac.TopicModeEnforce)
+	defer authorizer.Close()
+
+	collector := &fakeCollector{}
+	handler := NewHandler(zap.NewNop(), collector, fakeDevices{}, NewMQTTStats(), nil, nil)
+	handler.SetAuthorizer(authorizer, fakeDevices{})
+
+	reading := func(deviceID string) []byte {
+		payload, _ := json.Marshal(SensorData{DeviceID: deviceID, SensorType: "co2", Value: 420, Timestamp: time.Now()})
+		return payload
+	}
+
+	// 外部broker消息的设备ID取自消息体，可以伪造：即使enforce模式也只评估记录，不丢弃
+	handler.Dispatch("sensors/DEVA/co2", reading("DEVA"))
+	handler.Dispatch("sensors/DEVB/co2", reading("DEVB"))
+	if n := collector.count(); n != 2 {
+		t.Fatalf("expected external readings to be saved, got %d", n)
+	}
+
+	// 内嵌broker已在ACL中评估的消息不重复评估
+	handler.dispatch("sensors/DEVB/co2", reading("DEVB"), true)
+	if n := collector.count(); n != 3 {
+		t.Fatalf("expected pre-authorized reading to be saved, got %d", n)
+	}
+
+	stats := map[string]*abac.TopicDenialStats{}
+	for _, stat := range authorizer.DenialStats() {
+		stats[stat.DeviceID] = stat
+	}
+	if s := stats["DEVA"]; s == nil || s.Evaluated != 1 || s.Denied != 0 {
+		t.Fatalf("unexpected DEVA stats: %+v", s)
+	}
+	if s := stats["DEVB"]; s == nil || s.Evaluated != 1 || s.Denied != 1 || s.LastResource != "mqtt/sensors" {
+		t.Fatalf("unexpected DEVB stats: %+v", s)
+	}
+	if !waitFor(t, func() bool { return repo.deniedLogs() == 1 }) {
+		t.Fatalf("expected denied message to be logged, got %d", repo.deniedLogs())
+	}
+}
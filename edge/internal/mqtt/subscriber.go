@@ -13,6 +13,7 @@ import (
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edge/storage-cabinet/internal/abac"
 	"github.com/edge/storage-cabinet/internal/cloud"
 	"github.com/edge/storage-cabinet/internal/config"
 	"github.com/edge/storage-cabinet/internal/license"
@@ -25,6 +26,12 @@ type ABACPolicyHandler interface {
 	HandlePolicySync(payload []byte) error
 }
 
+// FirmwareCatalogHandler 固件漏洞目录处理接口
+type FirmwareCatalogHandler interface {
+	GetCatalogTopic() string
+	HandleCatalogSync(payload []byte) error
+}
+
 // Subscriber MQTT 订阅器
 type Subscriber struct {
 	logger    *zap.Logger
@@ -43,6 +50,10 @@ type Subscriber struct {
 	// ABAC策略处理器
 	abacHandler ABACPolicyHandler
 	abacTopic   string
+
+	// 固件漏洞目录处理器
+	catalogHandler FirmwareCatalogHandler
+	catalogTopic   string
 }
 
 // NewSubscriber 创建 MQTT 订阅器
@@ -79,6 +90,50 @@ func (s *Subscriber) SetABACHandler(handler ABACPolicyHandler) {
 	}
 }
 
+// SetFirmwareCatalogHandler 设置固件漏洞目录处理器
+func (s *Subscriber) SetFirmwareCatalogHandler(handler FirmwareCatalogHandler) {
+	s.catalogHandler = handler
+	if handler != nil {
+		s.catalogTopic = handler.GetCatalogTopic()
+		s.logger.Info("固件漏洞目录处理器已注册", zap.String("topic", s.catalogTopic))
+	}
+}
+
+// SetCredentialRotator 设置凭证轮换服务
+func (s *Subscriber) SetCredentialRotator(rotator CredentialRotator) {
+	s.handler.SetCredentialRotator(rotator)
+}
+
+// SetSessionRevoker 设置会话撤销服务
+func (s *Subscriber) SetSessionRevoker(revoker SessionRevoker) {
+	s.handler.SetSessionRevoker(revoker)
+}
+
+// SetBaselineApprover 设置配置基线批准服务
+func (s *Subscriber) SetBaselineApprover(approver BaselineApprover) {
+	s.handler.SetBaselineApprover(approver)
+}
+
+// SetRemediationConfirmer 设置漏洞处置确认服务
+func (s *Subscriber) SetRemediationConfirmer(confirmer RemediationConfirmer) {
+	s.handler.SetRemediationConfirmer(confirmer)
+}
+
+// SetCertificateReviewer 设置证书申请审批服务
+func (s *Subscriber) SetCertificateReviewer(reviewer CertificateReviewer) {
+	s.handler.SetCertificateReviewer(reviewer)
+}
+
+// SetGroupSyncer 设置授权群组同步服务
+func (s *Subscriber) SetGroupSyncer(syncer GroupSyncer) {
+	s.handler.SetGroupSyncer(syncer)
+}
+
+// SetAuthorizer 设置ABAC Topic授权器（设备上行消息按策略评估并记录）
+func (s *Subscriber) SetAuthorizer(authorizer *abac.TopicAuthorizer, devices DeviceLookup) {
+	s.handler.SetAuthorizer(authorizer, devices)
+}
+
 // Start 启动 MQTT 订阅器
 func (s *Subscriber) Start(ctx context.Context) error {
 	if !s.config.Enabled {
@@ -236,6 +291,9 @@ func (s *Subscriber) onConnect(client mqtt.Client) {
 	if s.abacTopic != "" {
 		topics[s.abacTopic] = s.config.QoS
 	}
+	if s.catalogTopic != "" {
+		topics[s.catalogTopic] = s.config.QoS
+	}
 
 	for topic, qos := range topics {
 		token := client.Subscribe(topic, qos, nil)
@@ -280,6 +338,9 @@ func (s *Subscriber) unsubscribeAll() {
 	if s.abacTopic != "" {
 		topics = append(topics, s.abacTopic)
 	}
+	if s.catalogTopic != "" {
+		topics = append(topics, s.catalogTopic)
+	}
 
 	for _, topic := range topics {
 		token := s.client.Unsubscribe(topic)
@@ -304,6 +365,11 @@ func (s *Subscriber) GetWebSocketHub() *WebSocketHub {
 	return nil
 }
 
+// GetHandler 获取消息处理器（内嵌broker与外部订阅共用）
+func (s *Subscriber) GetHandler() *Handler {
+	return s.handler
+}
+
 // GetStats 获取MQTT统计数据
 func (s *Subscriber) GetStats() *MQTTStats {
 	return s.stats
@@ -328,6 +394,14 @@ func (s *Subscriber) createMessageHandler() mqtt.MessageHandler {
 			return
 		}
 
+		// 固件漏洞目录
+		if s.catalogHandler != nil && s.catalogTopic != "" && topic == s.catalogTopic {
+			if err := s.catalogHandler.HandleCatalogSync(msg.Payload()); err != nil {
+				s.logger.Error("处理固件漏洞目录失败", zap.Error(err))
+			}
+			return
+		}
+
 		// 其他消息交给原有handler处理
 		s.handler.HandleMessage(client, msg)
 	}
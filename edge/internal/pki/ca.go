@@ -0,0 +1,400 @@
+/*
+ * Edge CA
+ * 为无法运行Groth16证明的第三方传感器签发短期TLS客户端证书，
+ * 证书主体CN即设备ID，数据接口和MQTT监听据此将mTLS连接映射到设备记录
+ */
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCertTTL     = 24 * time.Hour
+	caValidity         = 10 * 365 * 24 * time.Hour
+	serverCertValidity = 365 * 24 * time.Hour
+
+	// 证书生效时间提前量，容忍设备时钟偏差
+	clockSkew = 5 * time.Minute
+
+	// 设备证书主体OU，用于区分设备证书和服务端证书
+	deviceOU = "device"
+)
+
+// 证书撤销原因
+const (
+	RevokeReasonAdmin      = "admin"
+	RevokeReasonSuperseded = "superseded" // 续期后旧证书作废
+)
+
+// DeviceLookup 设备查询接口（device.Manager实现）
+type DeviceLookup interface {
+	GetDevice(deviceID string) (*models.Device, error)
+}
+
+// CA Edge证书颁发机构
+type CA struct {
+	logger  *zap.Logger
+	db      *storage.SQLiteDB
+	devices DeviceLookup
+	certTTL time.Duration
+
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+	pool    *x509.CertPool
+}
+
+// NewCA 加载CA证书和私钥，文件不存在时生成新的自签名CA
+func NewCA(cfg config.PKIConfig, db *storage.SQLiteDB, devices DeviceLookup, logger *zap.Logger) (*CA, error) {
+	ca := &CA{
+		logger:  logger,
+		db:      db,
+		devices: devices,
+		certTTL: cfg.CertTTL,
+	}
+	if ca.certTTL <= 0 {
+		ca.certTTL = defaultCertTTL
+	}
+
+	if err := ca.loadOrCreate(cfg.CACertFile, cfg.CAKeyFile); err != nil {
+		return nil, err
+	}
+	ca.pool = x509.NewCertPool()
+	ca.pool.AddCert(ca.cert)
+	return ca, nil
+}
+
+// CertificatePEM CA证书（PEM），设备用于校验Edge服务端证书
+func (ca *CA) CertificatePEM() []byte {
+	return ca.certPEM
+}
+
+// CertPool 仅包含本CA的证书池，用作TLS ClientCAs
+func (ca *CA) CertPool() *x509.CertPool {
+	return ca.pool
+}
+
+// ServerTLSConfig 生成接受设备证书的服务端TLS配置
+// 服务端证书由本CA签发（每次启动重新生成），客户端证书可选：未提供证书的连接仍可使用令牌认证
+func (ca *CA) ServerTLSConfig(hosts []string) (*tls.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate server key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "edge-server"},
+		NotBefore:    now.Add(-clockSkew),
+		NotAfter:     now.Add(serverCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else if h != "" {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign server certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{der, ca.cert.Raw},
+			PrivateKey:  key,
+		}},
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  ca.pool,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+// Issue 根据设备CSR签发客户端证书
+func (ca *CA) Issue(deviceID, csrPEM, issuedVia string) (*models.DeviceCertificate, error) {
+	csr, err := ca.parseCSR(deviceID, csrPEM)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.checkDevice(deviceID); err != nil {
+		return nil, err
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:         deviceID,
+			OrganizationalUnit: []string{deviceOU},
+		},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(ca.certTTL),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, csr.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	fingerprint := sha256.Sum256(der)
+
+	cert := &models.DeviceCertificate{
+		SerialNumber:   serial.Text(16),
+		DeviceID:       deviceID,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		IssuedVia:      issuedVia,
+		NotBefore:      tmpl.NotBefore,
+		NotAfter:       tmpl.NotAfter,
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		Active:         true,
+		CreatedAt:      now,
+	}
+	if err := ca.saveCertificate(cert); err != nil {
+		return nil, err
+	}
+
+	ca.logger.Info("Device certificate issued",
+		zap.String("device_id", deviceID),
+		zap.String("serial", cert.SerialNumber),
+		zap.String("issued_via", issuedVia),
+		zap.Time("not_after", cert.NotAfter))
+	return cert, nil
+}
+
+// Renew 使用新CSR续期，签发成功后作废旧证书
+func (ca *CA) Renew(deviceID, currentSerial, csrPEM string) (*models.DeviceCertificate, error) {
+	if err := ca.CheckCertificate(deviceID, currentSerial); err != nil {
+		return nil, err
+	}
+
+	cert, err := ca.Issue(deviceID, csrPEM, models.CertIssuedViaRenewal)
+	if err != nil {
+		return nil, err
+	}
+	if err := ca.Revoke(deviceID, currentSerial, RevokeReasonSuperseded); err != nil {
+		ca.logger.Warn("Failed to revoke superseded certificate",
+			zap.String("device_id", deviceID),
+			zap.String("serial", currentSerial),
+			zap.Error(err))
+	}
+	return cert, nil
+}
+
+// VerifyDevice 校验TLS握手中的客户端证书，返回对应的设备ID和证书序列号
+// 除证书链外还检查证书是否仍在签发记录中有效（未撤销、未过期）及设备状态
+func (ca *CA) VerifyDevice(cert *x509.Certificate) (string, string, error) {
+	if err := cert.CheckSignatureFrom(ca.cert); err != nil {
+		return "", "", fmt.Errorf("CERT_INVALID: certificate not issued by edge CA")
+	}
+	if !hasClientAuth(cert) || !hasDeviceOU(cert) {
+		return "", "", fmt.Errorf("CERT_INVALID: not a device certificate")
+	}
+
+	deviceID := cert.Subject.CommonName
+	serial := cert.SerialNumber.Text(16)
+	if err := ca.CheckCertificate(deviceID, serial); err != nil {
+		return "", "", err
+	}
+	if err := ca.checkDevice(deviceID); err != nil {
+		return "", "", err
+	}
+	return deviceID, serial, nil
+}
+
+// CheckCertificate 检查证书记录是否属于设备且仍有效
+func (ca *CA) CheckCertificate(deviceID, serial string) error {
+	cert, err := ca.getCertificate(serial)
+	if err != nil {
+		return err
+	}
+	if cert.DeviceID != deviceID {
+		return fmt.Errorf("CERT_INVALID: certificate does not belong to device")
+	}
+	if cert.RevokedAt != nil {
+		return fmt.Errorf("CERT_REVOKED: certificate revoked (%s)", cert.RevokedReason)
+	}
+	if time.Now().After(cert.NotAfter) {
+		return fmt.Errorf("CERT_EXPIRED: certificate expired")
+	}
+	return nil
+}
+
+func (ca *CA) checkDevice(deviceID string) error {
+	device, err := ca.devices.GetDevice(deviceID)
+	if err != nil {
+		return fmt.Errorf("DEVICE_NOT_FOUND: %s", deviceID)
+	}
+	if device.Status == models.DeviceStatusDisabled {
+		return fmt.Errorf("DEVICE_DISABLED: %s", deviceID)
+	}
+	return nil
+}
+
+// parseCSR 解析并校验CSR：签名有效且主体CN为设备ID
+func (ca *CA) parseCSR(deviceID, csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("CSR_INVALID: expected PEM encoded CERTIFICATE REQUEST")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("CSR_INVALID: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("CSR_INVALID: bad signature: %v", err)
+	}
+	if csr.Subject.CommonName != deviceID {
+		return nil, fmt.Errorf("CSR_INVALID: subject CN %q does not match device %s", csr.Subject.CommonName, deviceID)
+	}
+	return csr, nil
+}
+
+// loadOrCreate 加载CA，文件不存在时生成P-256自签名CA并写入文件
+func (ca *CA) loadOrCreate(certFile, keyFile string) error {
+	certPEM, certErr := os.ReadFile(certFile)
+	keyPEM, keyErr := os.ReadFile(keyFile)
+	if certErr == nil && keyErr == nil {
+		return ca.load(certPEM, keyPEM)
+	}
+	if !os.IsNotExist(certErr) && certErr != nil {
+		return fmt.Errorf("failed to read CA certificate: %w", certErr)
+	}
+	if !os.IsNotExist(keyErr) && keyErr != nil {
+		return fmt.Errorf("failed to read CA key: %w", keyErr)
+	}
+	if certErr == nil || keyErr == nil {
+		return fmt.Errorf("CA certificate and key must both exist (%s, %s)", certFile, keyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "Storage Cabinet Edge CA"},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	for _, dir := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create CA directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write CA key: %w", err)
+	}
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write CA certificate: %w", err)
+	}
+
+	ca.logger.Info("Edge CA created",
+		zap.String("cert_file", certFile),
+		zap.Time("not_after", tmpl.NotAfter))
+	return ca.load(certPEM, keyPEM)
+}
+
+func (ca *CA) load(certPEM, keyPEM []byte) error {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid CA key pair: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	if !cert.IsCA {
+		return fmt.Errorf("CA certificate is not a CA")
+	}
+	key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("CA key must be ECDSA")
+	}
+
+	ca.cert = cert
+	ca.certPEM = certPEM
+	ca.key = key
+	return nil
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serial, nil
+}
+
+func hasClientAuth(cert *x509.Certificate) bool {
+	for _, usage := range cert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageClientAuth {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDeviceOU(cert *x509.Certificate) bool {
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == deviceOU {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+type fakeDevices map[string]models.DeviceStatus // device_id -> status
+
+func (d fakeDevices) GetDevice(deviceID string) (*models.Device, error) {
+	status, ok := d[deviceID]
+	if !ok {
+		return nil, fmt.Errorf("device not found")
+	}
+	return &models.Device{DeviceID: deviceID, Status: status}, nil
+}
+
+func newTestCA(t *testing.T) *CA {
+	t.Helper()
+	dir := t.TempDir()
+	logger := zap.NewNop()
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:             "sqlite3",
+		Path:               filepath.Join(dir, "edge.db"),
+		MaxConnections:     1,
+		MaxIdleConnections: 1,
+	}, logger)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ca, err := NewCA(config.PKIConfig{
+		CACertFile: filepath.Join(dir, "ca.crt"),
+		CAKeyFile:  filepath.Join(dir, "ca.key"),
+		CertTTL:    time.Hour,
+	}, db, fakeDevices{"DEVA": models.DeviceStatusOnline, "DEVB": models.DeviceStatusOnline}, logger)
+	if err != nil {
+		t.Fatalf("new ca: %v", err)
+	}
+	return ca
+}
+
+func newCSR(t *testing.T, cn string) string {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: cn},
+	}, key)
+	if err != nil {
+		t.Fatalf("create csr: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+}
+
+func parseCert(t *testing.T, certPEM string) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(certPEM))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse cert: %v", err)
+	}
+	return cert
+}
+
+func TestIssueVerifyRevoke(t *testing.T) {
+	ca := newTestCA(t)
+
+	// CSR主体必须是设备本身
+	if _, err := ca.Issue("DEVA", newCSR(t, "DEVB"), models.CertIssuedViaZKP); err == nil || !strings.HasPrefix(err.Error(), "CSR_INVALID") {
+		t.Fatalf("expected CSR_INVALID, got %v", err)
+	}
+
+	issued, err := ca.Issue("DEVA", newCSR(t, "DEVA"), models.CertIssuedViaZKP)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	cert := parseCert(t, issued.CertificatePEM)
+	deviceID, serial, err := ca.VerifyDevice(cert)
+	if err != nil || deviceID != "DEVA" || serial != issued.SerialNumber {
+		t.Fatalf("verify: device=%s serial=%s err=%v", deviceID, serial, err)
+	}
+
+	// 续期后旧证书作废
+	renewed, err := ca.Renew("DEVA", serial, newCSR(t, "DEVA"))
+	if err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+	if err := ca.CheckCertificate("DEVA", serial); err == nil || !strings.HasPrefix(err.Error(), "CERT_REVOKED") {
+		t.Fatalf("expected superseded cert to be revoked, got %v", err)
+	}
+	if err := ca.CheckCertificate("DEVA", renewed.SerialNumber); err != nil {
+		t.Fatalf("renewed cert rejected: %v", err)
+	}
+
+	if err := ca.Revoke("DEVA", renewed.SerialNumber, RevokeReasonAdmin); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if _, _, err := ca.VerifyDevice(parseCert(t, renewed.CertificatePEM)); err == nil {
+		t.Fatal("expected revoked cert to fail verification")
+	}
+}
+
+func TestCertificateRequestApproval(t *testing.T) {
+	ca := newTestCA(t)
+
+	req, err := ca.SubmitRequest("DEVB", newCSR(t, "DEVB"), "127.0.0.1")
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if req.Status != models.CertRequestPending {
+		t.Fatalf("expected pending, got %s", req.Status)
+	}
+
+	approved, err := ca.ApproveRequest(req.RequestID, "ops")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.Status != models.CertRequestApproved || approved.Certificate == nil {
+		t.Fatalf("unexpected approval result: %+v", approved)
+	}
+	if approved.Certificate.IssuedVia != models.CertIssuedViaOperator {
+		t.Fatalf("expected operator issuance, got %s", approved.Certificate.IssuedVia)
+	}
+
+	// 已处理的申请不能再次审批
+	if _, err := ca.RejectRequest(req.RequestID, "ops", "dup"); err == nil || !strings.HasPrefix(err.Error(), "REQUEST_CLOSED") {
+		t.Fatalf("expected REQUEST_CLOSED, got %v", err)
+	}
+}
+
+func TestCertificateRequestLimitPerIP(t *testing.T) {
+	ca := newTestCA(t)
+
+	// 申请被处理后不再占用设备的待审批名额，但仍计入来源IP的次数
+	for i := 0; i < maxRequestsPerIP; i++ {
+		deviceID := []string{"DEVA", "DEVB"}[i%2]
+		req, err := ca.SubmitRequest(deviceID, newCSR(t, deviceID), "10.0.0.66")
+		if err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+		if _, err := ca.RejectRequest(req.RequestID, "ops", "test"); err != nil {
+			t.Fatalf("reject %d: %v", i, err)
+		}
+	}
+
+	if _, err := ca.SubmitRequest("DEVB", newCSR(t, "DEVB"), "10.0.0.66"); err == nil || !strings.HasPrefix(err.Error(), "REQUEST_LIMIT") {
+		t.Fatalf("expected per-IP REQUEST_LIMIT, got %v", err)
+	}
+	if _, err := ca.SubmitRequest("DEVB", newCSR(t, "DEVB"), "10.0.0.1"); err != nil {
+		t.Fatalf("other sources should not be limited: %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+/*
+ * 设备证书记录与签发申请
+ * 无法完成ZKP认证的设备提交CSR，运维审批后签发；撤销立即对新连接和已建立的MQTT连接生效
+ */
+package pki
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 证书申请限制，防止未认证的申请接口被滥用
+const (
+	maxPendingRequests = 3         // 单设备待审批申请上限
+	maxRequestsPerIP   = 10        // 单来源IP在统计窗口内的申请上限（不区分设备）
+	requestRateWindow  = time.Hour // 来源IP申请次数统计窗口
+)
+
+// List 查询设备证书，deviceID为空表示全部设备
+func (ca *CA) List(deviceID string, activeOnly bool) ([]models.DeviceCertificate, error) {
+	query := `
+		SELECT serial_number, device_id, fingerprint, issued_via, not_before, not_after,
+		       revoked_at, COALESCE(revoked_reason, ''), created_at
+		FROM device_certificates WHERE 1=1
+	`
+	args := []interface{}{}
+	if deviceID != "" {
+		query += ` AND device_id = ?`
+		args = append(args, deviceID)
+	}
+	if activeOnly {
+		query += ` AND revoked_at IS NULL AND not_after > ?`
+		args = append(args, time.Now())
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := ca.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificates: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	certs := make([]models.DeviceCertificate, 0)
+	for rows.Next() {
+		var cert models.DeviceCertificate
+		var revokedAt sql.NullTime
+		if err := rows.Scan(
+			&cert.SerialNumber, &cert.DeviceID, &cert.Fingerprint, &cert.IssuedVia,
+			&cert.NotBefore, &cert.NotAfter, &revokedAt, &cert.RevokedReason, &cert.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate: %w", err)
+		}
+		if revokedAt.Valid {
+			cert.RevokedAt = &revokedAt.Time
+		}
+		cert.Active = !revokedAt.Valid && now.Before(cert.NotAfter)
+		certs = append(certs, cert)
+	}
+	return certs, rows.Err()
+}
+
+// Revoke 撤销设备的单个证书
+func (ca *CA) Revoke(deviceID, serial, reason string) error {
+	result, err := ca.db.Exec(`
+		UPDATE device_certificates SET revoked_at = ?, revoked_reason = ?
+		WHERE serial_number = ? AND device_id = ? AND revoked_at IS NULL
+	`, time.Now(), reason, serial, deviceID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke certificate: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("CERT_NOT_FOUND: certificate not found or already revoked")
+	}
+
+	ca.logger.Info("Device certificate revoked",
+		zap.String("device_id", deviceID),
+		zap.String("serial", serial),
+		zap.String("reason", reason))
+	return nil
+}
+
+// RevokeDevice 撤销设备的全部证书
+func (ca *CA) RevokeDevice(deviceID, reason string) (int64, error) {
+	result, err := ca.db.Exec(`
+		UPDATE device_certificates SET revoked_at = ?, revoked_reason = ?
+		WHERE device_id = ? AND revoked_at IS NULL
+	`, time.Now(), reason, deviceID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke certificates: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		ca.logger.Info("Device certificates revoked",
+			zap.String("device_id", deviceID),
+			zap.Int64("count", n),
+			zap.String("reason", reason))
+	}
+	return n, nil
+}
+
+// SubmitRequest 提交待运维审批的证书申请
+func (ca *CA) SubmitRequest(deviceID, csrPEM, clientIP string) (*models.CertificateRequest, error) {
+	if _, err := ca.parseCSR(deviceID, csrPEM); err != nil {
+		return nil, err
+	}
+	if err := ca.checkDevice(deviceID); err != nil {
+		return nil, err
+	}
+
+	// 同一来源为不同设备提交申请也计入来源IP的次数
+	var recent int
+	if err := ca.db.QueryRow(`
+		SELECT COUNT(*) FROM certificate_requests WHERE client_ip = ? AND created_at > ?
+	`, clientIP, time.Now().Add(-requestRateWindow)).Scan(&recent); err != nil {
+		return nil, fmt.Errorf("failed to count recent requests: %w", err)
+	}
+	if recent >= maxRequestsPerIP {
+		return nil, fmt.Errorf("REQUEST_LIMIT: too many certificate requests from %s", clientIP)
+	}
+
+	var pending int
+	if err := ca.db.QueryRow(`
+		SELECT COUNT(*) FROM certificate_requests WHERE device_id = ? AND status = ?
+	`, deviceID, models.CertRequestPending).Scan(&pending); err != nil {
+		return nil, fmt.Errorf("failed to count pending requests: %w", err)
+	}
+	if pending >= maxPendingRequests {
+		return nil, fmt.Errorf("REQUEST_LIMIT: too many pending certificate requests for device %s", deviceID)
+	}
+
+	req := &models.CertificateRequest{
+		RequestID: uuid.New().String(),
+		DeviceID:  deviceID,
+		Status:    models.CertRequestPending,
+		ClientIP:  clientIP,
+		CreatedAt: time.Now(),
+	}
+	if _, err := ca.db.Exec(`
+		INSERT INTO certificate_requests (request_id, device_id, csr, status, client_ip, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, req.RequestID, req.DeviceID, csrPEM, req.Status, req.ClientIP, req.CreatedAt); err != nil {
+		return nil, fmt.Errorf("failed to save certificate request: %w", err)
+	}
+
+	ca.logger.Info("Certificate request submitted",
+		zap.String("device_id", deviceID),
+		zap.String("request_id", req.RequestID),
+		zap.String("client_ip", clientIP))
+	return req, nil
+}
+
+// GetRequest 查询证书申请，已审批的申请附带签发的证书
+func (ca *CA) GetRequest(deviceID, requestID string) (*models.CertificateRequest, error) {
+	req, _, err := ca.getRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.DeviceID != deviceID {
+		return nil, fmt.Errorf("REQUEST_NOT_FOUND: %s", requestID)
+	}
+	if req.SerialNumber != "" {
+		if cert, err := ca.getCertificate(req.SerialNumber); err == nil {
+			req.Certificate = cert
+		}
+	}
+	return req, nil
+}
+
+// ListRequests 查询证书申请，status为空表示全部
+func (ca *CA) ListRequests(status string) ([]models.CertificateRequest, error) {
+	query := `
+		SELECT request_id, device_id, status, COALESCE(serial_number, ''), COALESCE(reviewed_by, ''),
+		       COALESCE(reason, ''), COALESCE(client_ip, ''), created_at, reviewed_at
+		FROM certificate_requests
+	`
+	args := []interface{}{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := ca.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificate requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := make([]models.CertificateRequest, 0)
+	for rows.Next() {
+		var req models.CertificateRequest
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(
+			&req.RequestID, &req.DeviceID, &req.Status, &req.SerialNumber, &req.ReviewedBy,
+			&req.Reason, &req.ClientIP, &req.CreatedAt, &reviewedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan certificate request: %w", err)
+		}
+		if reviewedAt.Valid {
+			req.ReviewedAt = &reviewedAt.Time
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+// ApproveRequest 运维审批通过并签发证书
+func (ca *CA) ApproveRequest(requestID, operator string) (*models.CertificateRequest, error) {
+	req, csrPEM, err := ca.getRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != models.CertRequestPending {
+		return nil, fmt.Errorf("REQUEST_CLOSED: request already %s", req.Status)
+	}
+
+	cert, err := ca.Issue(req.DeviceID, csrPEM, models.CertIssuedViaOperator)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := ca.db.Exec(`
+		UPDATE certificate_requests SET status = ?, serial_number = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE request_id = ?
+	`, models.CertRequestApproved, cert.SerialNumber, operator, now, requestID); err != nil {
+		return nil, fmt.Errorf("failed to update certificate request: %w", err)
+	}
+
+	req.Status = models.CertRequestApproved
+	req.SerialNumber = cert.SerialNumber
+	req.ReviewedBy = operator
+	req.ReviewedAt = &now
+	req.Certificate = cert
+	return req, nil
+}
+
+// RejectRequest 运维拒绝证书申请
+func (ca *CA) RejectRequest(requestID, operator, reason string) (*models.CertificateRequest, error) {
+	now := time.Now()
+	result, err := ca.db.Exec(`
+		UPDATE certificate_requests SET status = ?, reviewed_by = ?, reason = ?, reviewed_at = ?
+		WHERE request_id = ? AND status = ?
+	`, models.CertRequestRejected, operator, reason, now, requestID, models.CertRequestPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject certificate request: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, _, err := ca.getRequest(requestID); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("REQUEST_CLOSED: request already reviewed")
+	}
+
+	req, _, err := ca.getRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	ca.logger.Info("Certificate request rejected",
+		zap.String("device_id", req.DeviceID),
+		zap.String("request_id", requestID),
+		zap.String("operator", operator))
+	return req, nil
+}
+
+func (ca *CA) saveCertificate(cert *models.DeviceCertificate) error {
+	_, err := ca.db.Exec(`
+		INSERT INTO device_certificates
+		(serial_number, device_id, fingerprint, issued_via, not_before, not_after, certificate, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, cert.SerialNumber, cert.DeviceID, cert.Fingerprint, cert.IssuedVia,
+		cert.NotBefore, cert.NotAfter, cert.CertificatePEM, cert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save certificate: %w", err)
+	}
+	return nil
+}
+
+func (ca *CA) getCertificate(serial string) (*models.DeviceCertificate, error) {
+	var cert models.DeviceCertificate
+	var revokedAt sql.NullTime
+	err := ca.db.QueryRow(`
+		SELECT serial_number, device_id, fingerprint, issued_via, not_before, not_after,
+		       certificate, revoked_at, COALESCE(revoked_reason, ''), created_at
+		FROM device_certificates WHERE serial_number = ?
+	`, serial).Scan(
+		&cert.SerialNumber, &cert.DeviceID, &cert.Fingerprint, &cert.IssuedVia,
+		&cert.NotBefore, &cert.NotAfter, &cert.CertificatePEM, &revokedAt, &cert.RevokedReason, &cert.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("CERT_NOT_FOUND: %s", serial)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query certificate: %w", err)
+	}
+	if revokedAt.Valid {
+		cert.RevokedAt = &revokedAt.Time
+	}
+	cert.Active = !revokedAt.Valid && time.Now().Before(cert.NotAfter)
+	return &cert, nil
+}
+
+func (ca *CA) getRequest(requestID string) (*models.CertificateRequest, string, error) {
+	var req models.CertificateRequest
+	var csrPEM string
+	var reviewedAt sql.NullTime
+	err := ca.db.QueryRow(`
+		SELECT request_id, device_id, csr, status, COALESCE(serial_number, ''), COALESCE(reviewed_by, ''),
+		       COALESCE(reason, ''), COALESCE(client_ip, ''), created_at, reviewed_at
+		FROM certificate_requests WHERE request_id = ?
+	`, requestID).Scan(
+		&req.RequestID, &req.DeviceID, &csrPEM, &req.Status, &req.SerialNumber, &req.ReviewedBy,
+		&req.Reason, &req.ClientIP, &req.CreatedAt, &reviewedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, "", fmt.Errorf("REQUEST_NOT_FOUND: %s", requestID)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query certificate request: %w", err)
+	}
+	if reviewedAt.Valid {
+		req.ReviewedAt = &reviewedAt.Time
+	}
+	return &req, csrPEM, nil
+}
@@ -209,6 +209,7 @@ func (s *SQLiteDB) initTables() error {
 			traffic_features TEXT,
 			config_checks TEXT,
 			detected_vulnerabilities TEXT,
+			scorer_results TEXT,
 			synced BOOLEAN DEFAULT FALSE,
 			synced_at TIMESTAMP
 		)`,
@@ -248,6 +249,68 @@ func (s *SQLiteDB) initTables() error {
 		`CREATE INDEX IF NOT EXISTS idx_dv_type ON dismissed_vulnerabilities(vulnerability_type)`,
 		`CREATE INDEX IF NOT EXISTS idx_dv_expires ON dismissed_vulnerabilities(expires_at)`,
 
+		// 固件漏洞目录（Cloud下发）
+		`CREATE TABLE IF NOT EXISTS firmware_advisories (
+			advisory_id VARCHAR(64) PRIMARY KEY,
+			manufacturer TEXT DEFAULT '',
+			model TEXT DEFAULT '',
+			min_version TEXT DEFAULT '',
+			max_version TEXT DEFAULT '',
+			fixed_version TEXT DEFAULT '',
+			severity VARCHAR(16),
+			title TEXT,
+			description TEXT DEFAULT '',
+			remediation TEXT DEFAULT '',
+			updated_at TIMESTAMP
+		)`,
+
+		// 固件漏洞目录版本
+		`CREATE TABLE IF NOT EXISTS firmware_catalog (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			version TEXT,
+			received_at TIMESTAMP
+		)`,
+
+		// 配置基线（签名的关键文件及数据库设置摘要，最新一条为当前基线）
+		`CREATE TABLE IF NOT EXISTS config_baselines (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			items TEXT NOT NULL,
+			signature TEXT NOT NULL,
+			approved_by VARCHAR(64),
+			reason TEXT DEFAULT '',
+			approved_at TIMESTAMP
+		)`,
+
+		// 传感器异常检测模型（按设备、传感器类型、指标保存EWMA状态，重启后继续使用）
+		`CREATE TABLE IF NOT EXISTS sensor_anomaly_models (
+			device_id VARCHAR(64) NOT NULL,
+			sensor_type VARCHAR(32) NOT NULL,
+			metric VARCHAR(16) NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			mean REAL NOT NULL DEFAULT 0,
+			variance REAL NOT NULL DEFAULT 0,
+			last_seen TIMESTAMP,
+			updated_at TIMESTAMP,
+			PRIMARY KEY (device_id, sensor_type, metric)
+		)`,
+
+		// 漏洞处置记录（处置剧本触发的自动或待确认动作）
+		`CREATE TABLE IF NOT EXISTS remediation_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type VARCHAR(128) NOT NULL,
+			severity VARCHAR(16) DEFAULT '',
+			device_id VARCHAR(64) DEFAULT '',
+			action VARCHAR(32) NOT NULL,
+			mode VARCHAR(16) NOT NULL,
+			status VARCHAR(16) NOT NULL,
+			result TEXT DEFAULT '',
+			confirmed_by VARCHAR(64) DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			executed_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_remediation_actions_lookup ON remediation_actions(event_type, action, created_at)`,
+
 		// Cloud凭证表（存储API Key等敏感信息）
 		`CREATE TABLE IF NOT EXISTS cloud_credentials (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -263,6 +326,106 @@ func (s *SQLiteDB) initTables() error {
 		// Cloud凭证索引
 		`CREATE INDEX IF NOT EXISTS idx_cc_cabinet_id ON cloud_credentials(cabinet_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_cc_enabled ON cloud_credentials(enabled)`,
+
+		// 设备凭证轮换记录表
+		`CREATE TABLE IF NOT EXISTS credential_rotations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			device_id VARCHAR(64),
+			event VARCHAR(16),
+			old_commitment TEXT,
+			new_commitment TEXT,
+			initiated_by VARCHAR(32),
+			reason TEXT,
+			sessions_revoked INTEGER DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 凭证轮换索引
+		`CREATE INDEX IF NOT EXISTS idx_cr_device ON credential_rotations(device_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_cr_created ON credential_rotations(created_at)`,
+
+		// Edge CA签发的设备证书表
+		`CREATE TABLE IF NOT EXISTS device_certificates (
+			serial_number VARCHAR(40) PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL,
+			fingerprint VARCHAR(64),
+			issued_via VARCHAR(16),
+			not_before TIMESTAMP,
+			not_after TIMESTAMP,
+			certificate TEXT,
+			revoked_at TIMESTAMP,
+			revoked_reason TEXT DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 设备证书索引
+		`CREATE INDEX IF NOT EXISTS idx_dc_device ON device_certificates(device_id)`,
+
+		// 设备证书申请表（运维审批）
+		`CREATE TABLE IF NOT EXISTS certificate_requests (
+			request_id VARCHAR(64) PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL,
+			csr TEXT NOT NULL,
+			status VARCHAR(16) DEFAULT 'pending',
+			serial_number VARCHAR(40) DEFAULT '',
+			reviewed_by VARCHAR(64) DEFAULT '',
+			reason TEXT DEFAULT '',
+			client_ip VARCHAR(45),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reviewed_at TIMESTAMP
+		)`,
+
+		// 证书申请索引
+		`CREATE INDEX IF NOT EXISTS idx_creq_device ON certificate_requests(device_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_creq_status ON certificate_requests(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_creq_client_ip ON certificate_requests(client_ip, created_at)`,
+
+		// 授权群组表（成员Merkle树根由Edge维护）
+		`CREATE TABLE IF NOT EXISTS auth_groups (
+			group_id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(128) DEFAULT '',
+			description TEXT DEFAULT '',
+			root VARCHAR(64) NOT NULL,
+			member_count INTEGER DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// 群组成员承诺表
+		`CREATE TABLE IF NOT EXISTS auth_group_members (
+			group_id VARCHAR(64) NOT NULL,
+			commitment VARCHAR(64) NOT NULL,
+			label VARCHAR(128) DEFAULT '',
+			added_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, commitment)
+		)`,
+
+		// 群组认证挑战表
+		`CREATE TABLE IF NOT EXISTS group_challenges (
+			challenge_id VARCHAR(64) PRIMARY KEY,
+			group_id VARCHAR(64) NOT NULL,
+			nonce VARCHAR(128),
+			client_ip VARCHAR(45),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			used BOOLEAN DEFAULT FALSE
+		)`,
+
+		// 群组会话表
+		`CREATE TABLE IF NOT EXISTS group_sessions (
+			session_id VARCHAR(64) PRIMARY KEY,
+			group_id VARCHAR(64) NOT NULL,
+			root VARCHAR(64),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			ip_address VARCHAR(45),
+			revoked_at TIMESTAMP
+		)`,
+
+		// 群组认证索引
+		`CREATE INDEX IF NOT EXISTS idx_gc_group ON group_challenges(group_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_gs_group ON group_sessions(group_id)`,
 	}
 
 	// 开始事务
@@ -296,15 +459,41 @@ func (s *SQLiteDB) initTables() error {
 
 // runMigrations 执行数据库迁移
 func (s *SQLiteDB) runMigrations() error {
-	// 检查 vulnerability_assessments 表是否有 license_compliance_score 字段
-	query := `PRAGMA table_info(vulnerability_assessments)`
-	rows, err := s.db.Query(query)
+	migrations := []struct {
+		table  string
+		column string
+		ddl    string
+	}{
+		{"vulnerability_assessments", "license_compliance_score", "REAL DEFAULT 100"},
+		{"devices", "rotation_required", "BOOLEAN DEFAULT FALSE"},
+		{"devices", "rotation_reason", "TEXT DEFAULT ''"},
+		{"sessions", "revoked_at", "TIMESTAMP"},
+		{"sessions", "revoked_reason", "TEXT DEFAULT ''"},
+		{"devices", "circuit_version", "TEXT DEFAULT ''"},
+		{"vulnerability_assessments", "scorer_results", "TEXT"},
+		{"challenges", "purpose", "TEXT DEFAULT 'auth'"},
+		{"challenges", "binding_salt", "TEXT DEFAULT ''"},
+		{"challenges", "new_commitment", "TEXT DEFAULT ''"},
+		{"challenges", "client_ip", "TEXT DEFAULT ''"},
+	}
+
+	for _, m := range migrations {
+		if err := s.addColumnIfMissing(m.table, m.column, m.ddl); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumnIfMissing 检查表是否包含指定字段，不存在则添加
+func (s *SQLiteDB) addColumnIfMissing(table, column, ddl string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	hasLicenseColumn := false
 	for rows.Next() {
 		var cid int
 		var name string
@@ -317,21 +506,21 @@ func (s *SQLiteDB) runMigrations() error {
 			return err
 		}
 
-		if name == "license_compliance_score" {
-			hasLicenseColumn = true
-			break
+		if name == column {
+			return nil
 		}
 	}
+	rows.Close()
 
-	// 如果没有该字段,则添加
-	if !hasLicenseColumn {
-		s.logger.Info("Adding license_compliance_score column to vulnerability_assessments")
-		_, err = s.db.Exec(`ALTER TABLE vulnerability_assessments ADD COLUMN license_compliance_score REAL DEFAULT 100`)
-		if err != nil {
-			return fmt.Errorf("failed to add license_compliance_score column: %w", err)
-		}
-		s.logger.Info("Migration completed: license_compliance_score column added")
+	s.logger.Info("Adding column",
+		zap.String("table", table),
+		zap.String("column", column))
+	if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, ddl)); err != nil {
+		return fmt.Errorf("failed to add %s.%s column: %w", table, column, err)
 	}
+	s.logger.Info("Migration completed",
+		zap.String("table", table),
+		zap.String("column", column))
 
 	return nil
 }
@@ -393,6 +582,21 @@ func (s *SQLiteDB) CleanOldData(retentionDays int) error {
 	return nil
 }
 
+// CleanSystemLogs 清理过期的系统日志，返回删除条数
+func (s *SQLiteDB) CleanSystemLogs(retentionDays int) (int64, error) {
+	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
+	result, err := s.db.Exec(`DELETE FROM system_logs WHERE timestamp < ?`, cutoffTime)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clean system logs: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	s.logger.Info("Cleaned old system logs",
+		zap.Int64("rows_deleted", rowsAffected),
+		zap.Time("cutoff_time", cutoffTime))
+	return rowsAffected, nil
+}
+
 // GetDatabaseStats 获取数据库统计信息
 func (s *SQLiteDB) GetDatabaseStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
@@ -430,6 +634,15 @@ func (s *SQLiteDB) GetDatabaseStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// CountActiveAlerts 统计未解决告警数量及其中的critical告警数量
+func (s *SQLiteDB) CountActiveAlerts() (active, critical int, err error) {
+	err = s.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN severity = 'critical' THEN 1 ELSE 0 END), 0)
+		FROM alerts WHERE resolved = FALSE
+	`).Scan(&active, &critical)
+	return active, critical, err
+}
+
 // BeginTransaction 开始事务
 func (s *SQLiteDB) BeginTransaction() (*sql.Tx, error) {
 	return s.db.Begin()
@@ -467,10 +467,22 @@ func (cs *CloudSync) SyncVulnerabilityAssessment(report *models.EdgeVulnerabilit
 				"solution":    vuln.Solution,
 				"detected_at": vuln.DetectedAt,
 			}
+			if vuln.DeviceID != "" {
+				event["device_id"] = vuln.DeviceID
+			}
 			events = append(events, event)
 		}
 		syncRequest["detected_vulnerabilities"] = events
 	}
+	if len(report.Certificates) > 0 {
+		syncRequest["certificates"] = report.Certificates
+	}
+	if len(report.Remediations) > 0 {
+		syncRequest["remediations"] = report.Remediations
+	}
+	if len(report.DismissedVulnerabilities) > 0 {
+		syncRequest["dismissed_vulnerabilities"] = report.DismissedVulnerabilities
+	}
 
 	// 序列化数据
 	jsonData, err := json.Marshal(syncRequest)
@@ -977,3 +989,37 @@ func (cs *CloudSync) SyncCabinetInfo(cabinetID, name, location string, latitude,
 
 	return nil
 }
+
+// RequestLicenseRefresh 请求Cloud重新下发本储能柜的许可证（许可证即将过期或失效时由处置剧本调用）
+func (cs *CloudSync) RequestLicenseRefresh() error {
+	if !cs.config.Enabled {
+		return fmt.Errorf("Cloud端未启用")
+	}
+
+	apiKey := cs.getAPIKey()
+	if apiKey == "" {
+		return fmt.Errorf("API Key未配置，请先注册到Cloud端获取API Key")
+	}
+
+	url := fmt.Sprintf("%s/cabinets/%s/license/refresh", cs.getEndpoint(), cs.getCabinetID())
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("User-Agent", "Edge-System/1.0")
+
+	resp, err := cs.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求许可证重新下发失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("请求许可证重新下发失败 (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	cs.logger.Info("已请求Cloud重新下发许可证", zap.String("cabinet_id", cs.getCabinetID()))
+	return nil
+}
@@ -25,42 +25,30 @@ func NewAggregator(cfg VulnerabilityConfig, logger *zap.Logger) *Aggregator {
 	}
 }
 
-// AggregateScores 聚合评分 (四维度)
+// AggregateScores 聚合评分
+// 按各评分器权重归一化加权，再根据漏洞严重程度扣分；权重为0的评分器只贡献扣分依据
 func (a *Aggregator) AggregateScores(
 	cabinetID string,
-	licenseScore float64, // 新增: 许可证合规性评分
-	commScore float64,
-	configScore float64,
-	dataScore float64,
+	results []models.ScorerResult,
 	metrics *models.TransmissionMetrics,
 	vulnerabilities []models.VulnerabilityEvent,
 	configChecks map[string]bool,
 ) *models.EdgeVulnerabilityReport {
-	// 1. 计算加权综合评分 (四维度权重: 30%, 25%, 20%, 25%)
-	weightLicense := 0.30 // 许可证合规性
-	weightComm := 0.25    // 通信异常
-	weightConfig := 0.20  // 配置安全
-	weightData := 0.25    // 数据异常
-
-	// 从配置读取权重(如果存在)
-	if a.config.Weights.Communication > 0 {
-		weightComm = a.config.Weights.Communication
-	}
-	if a.config.Weights.ConfigSecurity > 0 {
-		weightConfig = a.config.Weights.ConfigSecurity
-	}
-	if a.config.Weights.DataAnomaly > 0 {
-		weightData = a.config.Weights.DataAnomaly
+	// 1. 计算加权综合评分
+	totalWeight := 0.0
+	weightedScore := 0.0
+	for _, result := range results {
+		if result.Weight <= 0 {
+			continue
+		}
+		totalWeight += result.Weight
+		weightedScore += result.Weight * result.Score
 	}
 
-	// 归一化权重
-	totalWeight := weightLicense + weightComm + weightConfig + weightData
-	weightLicense /= totalWeight
-	weightComm /= totalWeight
-	weightConfig /= totalWeight
-	weightData /= totalWeight
-
-	overallScore := weightLicense*licenseScore + weightComm*commScore + weightConfig*configScore + weightData*dataScore
+	overallScore := 100.0
+	if totalWeight > 0 {
+		overallScore = weightedScore / totalWeight
+	}
 
 	// 2. 根据漏洞严重程度调整评分
 	for _, vuln := range vulnerabilities {
@@ -69,37 +57,43 @@ func (a *Aggregator) AggregateScores(
 	}
 
 	// 确保评分在0-100范围内
-	if overallScore < 0 {
-		overallScore = 0
-	}
-	if overallScore > 100 {
-		overallScore = 100
-	}
+	overallScore = clampScore(overallScore)
 
 	// 3. 确定风险等级
 	riskLevel := a.determineRiskLevel(overallScore)
 
-	// 4. 构建评估报告（configChecks已从参数传入）
+	// 4. 构建评估报告，未启用的内置维度按满分填充
+	scoreOf := func(name string) float64 {
+		for _, result := range results {
+			if result.Name == name {
+				return result.Score
+			}
+		}
+		return 100.0
+	}
+
 	report := &models.EdgeVulnerabilityReport{
 		CabinetID:               cabinetID,
 		Timestamp:               time.Now(),
-		LicenseComplianceScore:  licenseScore, // 新增
-		CommunicationScore:      commScore,
-		ConfigSecurityScore:     configScore,
-		DataAnomalyScore:        dataScore,
+		LicenseComplianceScore:  scoreOf(ScorerLicense),
+		CommunicationScore:      scoreOf(ScorerCommunication),
+		ConfigSecurityScore:     scoreOf(ScorerConfig),
+		DataAnomalyScore:        scoreOf(ScorerData),
 		OverallScore:            overallScore,
 		RiskLevel:               riskLevel,
 		TransmissionMetrics:     metrics,
 		TrafficFeatures:         []float64{}, // 暂时为空
 		ConfigChecks:            configChecks,
 		DetectedVulnerabilities: vulnerabilities,
+		ScorerResults:           results,
 	}
 
-	a.logger.Debug("评分聚合完成(四维度)",
-		zap.Float64("license_score", licenseScore),
-		zap.Float64("comm_score", commScore),
-		zap.Float64("config_score", configScore),
-		zap.Float64("data_score", dataScore),
+	a.logger.Debug("评分聚合完成",
+		zap.Int("scorers", len(results)),
+		zap.Float64("license_score", report.LicenseComplianceScore),
+		zap.Float64("comm_score", report.CommunicationScore),
+		zap.Float64("config_score", report.ConfigSecurityScore),
+		zap.Float64("data_score", report.DataAnomalyScore),
 		zap.Float64("overall_score", overallScore),
 		zap.String("risk_level", riskLevel),
 	)
@@ -128,6 +122,11 @@ func (a *Aggregator) determineRiskLevel(score float64) string {
 
 // getVulnerabilityPenalty 根据漏洞严重程度获取扣分
 func (a *Aggregator) getVulnerabilityPenalty(severity string) float64 {
+	return vulnerabilityPenalty(severity)
+}
+
+// vulnerabilityPenalty 漏洞严重程度对应的扣分
+func vulnerabilityPenalty(severity string) float64 {
 	switch severity {
 	case "critical":
 		return 15.0  // 降低：20 → 15
@@ -0,0 +1,101 @@
+/*
+ * 传感器数据统计异常评分器
+ * 汇总采集服务在线异常检测器近期判定的异常，按设备、传感器类型及指标生成漏洞事件
+ */
+package vulnerability
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+// ScorerSensorAnomaly 传感器数据统计异常评分器名称
+const ScorerSensorAnomaly = "sensor_anomaly"
+
+// SensorAnomalySource 传感器数据异常来源（采集服务的异常检测器）
+type SensorAnomalySource interface {
+	Recent() []models.SensorAnomaly
+}
+
+// SensorAnomalyScorer 传感器数据统计异常评分器
+type SensorAnomalyScorer struct {
+	source SensorAnomalySource
+}
+
+// NewSensorAnomalyScorer 创建传感器数据统计异常评分器
+func NewSensorAnomalyScorer(source SensorAnomalySource) *SensorAnomalyScorer {
+	return &SensorAnomalyScorer{source: source}
+}
+
+// Name 评分器名称
+func (s *SensorAnomalyScorer) Name() string {
+	return ScorerSensorAnomaly
+}
+
+// Score 同一设备、传感器类型及指标的异常合并为一个漏洞，取最高异常分数
+func (s *SensorAnomalyScorer) Score(input *AssessmentInput) *ScoreResult {
+	type group struct {
+		worst models.SensorAnomaly
+		count int
+	}
+	groups := map[string]*group{}
+	var keys []string
+	for _, anomaly := range s.source.Recent() {
+		key := fmt.Sprintf("%s:%s:%s", anomaly.DeviceID, anomaly.SensorType, anomaly.Metric)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{worst: anomaly}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.count++
+		if anomaly.Score > g.worst.Score {
+			g.worst = anomaly
+		}
+	}
+	sort.Strings(keys)
+
+	score := 100.0
+	findings := []models.ScoreFinding{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+	for _, key := range keys {
+		g := groups[key]
+		worst := g.worst
+		metric := "读数"
+		if worst.Metric == "interval" {
+			metric = "上报间隔"
+		}
+
+		event := models.VulnerabilityEvent{
+			Type:     "sensor_anomaly:" + key,
+			Category: "data",
+			Title:    fmt.Sprintf("设备%s的%s%s统计异常", worst.DeviceID, worst.SensorType, metric),
+			Severity: worst.Severity,
+			Description: fmt.Sprintf("近期%d次超出控制限，最高异常分数%.2f（观测值%.2f，学习均值%.2f，标准差%.2f）",
+				g.count, worst.Score, worst.Value, worst.Expected, worst.StdDev),
+			Solution:   "核查传感器是否故障、被替换或数据被篡改，确认为正常工况变化后模型会自动适应",
+			DeviceID:   worst.DeviceID,
+			DetectedAt: worst.DetectedAt,
+		}
+		penalty := vulnerabilityPenalty(event.Severity)
+		score -= penalty
+		findings = append(findings, models.ScoreFinding{
+			Item:    event.Type,
+			Penalty: penalty,
+			Message: event.Title,
+			Evidence: map[string]interface{}{
+				"anomaly_score": worst.Score,
+				"value":         worst.Value,
+				"expected":      worst.Expected,
+				"std_dev":       worst.StdDev,
+				"limit":         worst.Limit,
+				"count":         g.count,
+			},
+		})
+		vulnerabilities = append(vulnerabilities, event)
+	}
+
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
@@ -103,8 +103,32 @@ func (s *CommunicationScorer) CollectMetrics() *models.TransmissionMetrics {
 	}
 }
 
+// Name 评分器名称
+func (s *CommunicationScorer) Name() string {
+	return ScorerCommunication
+}
+
+// Score 计算通信评分，input中未提供传输指标时使用自带统计
+func (s *CommunicationScorer) Score(input *AssessmentInput) *ScoreResult {
+	var metrics *models.TransmissionMetrics
+	if input != nil {
+		metrics = input.Metrics
+	}
+	if metrics == nil {
+		metrics = s.CollectMetrics()
+	}
+	score, findings := s.scoreMetrics(metrics)
+	return &ScoreResult{Score: score, Findings: findings}
+}
+
 // CalculateScore 计算通信评分
 func (s *CommunicationScorer) CalculateScore(metrics *models.TransmissionMetrics) float64 {
+	score, _ := s.scoreMetrics(metrics)
+	return score
+}
+
+// scoreMetrics 计算通信评分及各分项扣分依据
+func (s *CommunicationScorer) scoreMetrics(metrics *models.TransmissionMetrics) (float64, []models.ScoreFinding) {
 	// 权重配置
 	w1 := 0.3 // 丢包率权重
 	w2 := 0.3 // 延迟权重
@@ -255,7 +279,30 @@ func (s *CommunicationScorer) CalculateScore(metrics *models.TransmissionMetrics
 		}()),
 	)
 
-	return finalScore
+	findings := []models.ScoreFinding{}
+	if hasData {
+		addFinding := func(item string, weight, subScore float64, message string, evidence map[string]interface{}) {
+			if subScore >= 100 {
+				return
+			}
+			findings = append(findings, models.ScoreFinding{
+				Item:     item,
+				Penalty:  weight * (100 - subScore),
+				Message:  message,
+				Evidence: evidence,
+			})
+		}
+		addFinding("packet_loss", w1, lossScore, "存在消息丢失",
+			map[string]interface{}{"packet_loss_rate": metrics.PacketLossRate})
+		addFinding("latency", w2, latencyScore, "通信延迟偏高",
+			map[string]interface{}{"latency_avg_ms": metrics.LatencyAvg, "threshold_ms": latencyThreshold})
+		addFinding("mqtt_success", w3, mqttScore, "MQTT消息发送成功率不足",
+			map[string]interface{}{"mqtt_success_rate": metrics.MQTTSuccessRate})
+		addFinding("stability", w4, stabilityScore, "连接不稳定，存在重连",
+			map[string]interface{}{"reconnection_count": metrics.ReconnectionCount, "threshold_per_hour": reconnectThreshold})
+	}
+
+	return finalScore, findings
 }
 
 // RecordConnection MQTT连接事件
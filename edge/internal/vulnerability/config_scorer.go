@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/edge/storage-cabinet/internal/license"
+	"github.com/edge/storage-cabinet/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -24,10 +25,11 @@ type ConfigScorer struct {
 
 // ConfigCheckResult 配置检查结果
 type ConfigCheckResult struct {
-	Item    string
-	Passed  bool
-	Penalty float64
-	Message string
+	Item     string
+	Passed   bool
+	Penalty  float64
+	Message  string
+	Evidence map[string]interface{} // 检查依据（配置值、文件权限等）
 }
 
 // NewConfigScorer 创建配置安全评分器
@@ -42,11 +44,17 @@ func NewConfigScorer(cfg VulnerabilityConfig, licenseService *license.Service, m
 	}
 }
 
-// CalculateScore 计算配置安全评分
-func (s *ConfigScorer) CalculateScore() float64 {
+// Name 评分器名称
+func (s *ConfigScorer) Name() string {
+	return ScorerConfig
+}
+
+// Score 计算配置安全评分，未通过的检查和加分项作为扣分依据
+func (s *ConfigScorer) Score(input *AssessmentInput) *ScoreResult {
 	baseScore := 100.0
 
 	checks := s.performChecks()
+	findings := []models.ScoreFinding{}
 
 	for _, check := range checks {
 		if !check.Passed {
@@ -64,7 +72,15 @@ func (s *ConfigScorer) CalculateScore() float64 {
 				zap.Float64("bonus", -check.Penalty),
 				zap.String("message", check.Message),
 			)
+		} else {
+			continue
 		}
+		findings = append(findings, models.ScoreFinding{
+			Item:     check.Item,
+			Penalty:  check.Penalty,
+			Message:  check.Message,
+			Evidence: check.Evidence,
+		})
 	}
 
 	// 确保评分在合理范围内
@@ -94,7 +110,12 @@ func (s *ConfigScorer) CalculateScore() float64 {
 		}()),
 	)
 
-	return finalScore
+	return &ScoreResult{Score: finalScore, Findings: findings}
+}
+
+// CalculateScore 计算配置安全评分
+func (s *ConfigScorer) CalculateScore() float64 {
+	return s.Score(nil).Score
 }
 
 // performChecks 执行所有配置检查
@@ -131,7 +152,8 @@ func (s *ConfigScorer) checkMQTTPort() ConfigCheckResult {
 			Item:    "mqtt_port",
 			Passed:  false,
 			Penalty: 0.5,  // 降低：10 → 5
-			Message: "MQTT使用默认端口1883，建议更改为非标准端口",
+			Message:  "MQTT使用默认端口1883，建议更改为非标准端口",
+			Evidence: map[string]interface{}{"broker": s.mqttBroker},
 		}
 	}
 
@@ -153,7 +175,8 @@ func (s *ConfigScorer) checkTLSEnabled() ConfigCheckResult {
 			Item:    "tls_enabled",
 			Passed:  false,
 			Penalty: 1.0, // 提高：未加密是严重安全问题
-			Message: "未启用TLS加密传输，通信数据存在泄露风险",
+			Message:  "未启用TLS加密传输，通信数据存在泄露风险",
+			Evidence: map[string]interface{}{"broker": s.mqttBroker},
 		}
 	}
 
@@ -182,7 +205,8 @@ func (s *ConfigScorer) checkLicenseValidity() ConfigCheckResult {
 			Item:    "license_validity",
 			Passed:  false,
 			Penalty: 1.0,
-			Message: "许可证无效或已过期: " + err.Error(),
+			Message:  "许可证无效或已过期: " + err.Error(),
+			Evidence: map[string]interface{}{"error": err.Error()},
 		}
 	}
 
@@ -215,7 +239,8 @@ func (s *ConfigScorer) checkDatabasePermissions() ConfigCheckResult {
 			Item:    "database_permissions",
 			Passed:  false,
 			Penalty: 0.5,
-			Message: "数据库文件权限过于宽松，其他用户可访问",
+			Message:  "数据库文件权限过于宽松，其他用户可访问",
+			Evidence: map[string]interface{}{"path": dbPath, "mode": perm.String()},
 		}
 	}
 
@@ -234,7 +259,8 @@ func (s *ConfigScorer) checkLogLevel() ConfigCheckResult {
 			Item:    "log_level",
 			Passed:  false,
 			Penalty: 0.2,  // 降低：5 → 2
-			Message: "生产环境使用debug日志级别，可能泄露敏感信息",
+			Message:  "生产环境使用debug日志级别，可能泄露敏感信息",
+			Evidence: map[string]interface{}{"log_level": s.logLevel},
 		}
 	}
 
@@ -251,7 +277,8 @@ func (s *ConfigScorer) checkAPIExposure() ConfigCheckResult {
 			Item:    "api_exposure",
 			Passed:  false,
 			Penalty: 0.5,  // 降低：10 → 5（测试环境常见）
-			Message: "API服务绑定到所有网络接口，建议限制为127.0.0.1或特定IP",
+			Message:  "API服务绑定到所有网络接口，建议限制为127.0.0.1或特定IP",
+			Evidence: map[string]interface{}{"server_host": s.serverHost},
 		}
 	}
 
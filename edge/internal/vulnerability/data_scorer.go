@@ -9,6 +9,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/edge/storage-cabinet/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -39,8 +40,23 @@ func NewDataScorer(cfg VulnerabilityConfig, db *sql.DB, logger *zap.Logger) *Dat
 	}
 }
 
+// Name 评分器名称
+func (s *DataScorer) Name() string {
+	return ScorerData
+}
+
 // CalculateScore 计算数据异常评分
 func (s *DataScorer) CalculateScore(cabinetID string) float64 {
+	return s.Score(&AssessmentInput{CabinetID: cabinetID}).Score
+}
+
+// Score 计算数据异常评分，缺失、异常值、告警频率和连续性扣分作为扣分依据
+func (s *DataScorer) Score(input *AssessmentInput) *ScoreResult {
+	cabinetID := ""
+	if input != nil {
+		cabinetID = input.CabinetID
+	}
+
 	// 获取最近1小时的数据质量指标
 	metrics := s.collectDataMetrics(cabinetID, 1*time.Hour)
 
@@ -53,10 +69,23 @@ func (s *DataScorer) CalculateScore(cabinetID string) float64 {
 			zap.Int("total_expected", metrics.TotalExpected),
 			zap.Int("total_received", metrics.TotalReceived))
 		// 系统刚启动或测试阶段，给予较高默认分
-		return 95.0
+		return &ScoreResult{
+			Score: 95.0,
+			Findings: []models.ScoreFinding{{
+				Item:     "no_data",
+				Penalty:  5,
+				Message:  "最近1小时无传感器数据",
+				Evidence: map[string]interface{}{"total_expected": metrics.TotalExpected},
+			}},
+		}
 	}
 
 	baseScore := 100.0
+	findings := []models.ScoreFinding{}
+	addFinding := func(item string, penalty float64, message string, evidence map[string]interface{}) {
+		baseScore -= penalty
+		findings = append(findings, models.ScoreFinding{Item: item, Penalty: penalty, Message: message, Evidence: evidence})
+	}
 
 	// 1. 缺失率扣分 (采用更宽松的评分策略，适应实际运行环境)
 	missingThreshold := s.config.DataAnomaly.MissingRateThreshold
@@ -65,13 +94,17 @@ func (s *DataScorer) CalculateScore(cabinetID string) float64 {
 	}
 
 	if metrics.MissingRate > missingThreshold {
-		if metrics.MissingRate > 0.40 {
-			baseScore -= 10 // 降低扣分：30→20，40%以上才重扣
-		} else {
+		penalty := 10.0 // 降低扣分：30→20，40%以上才重扣
+		if metrics.MissingRate <= 0.40 {
 			// 线性插值：从阈值到40%，扣分从3到20
-			penalty := 1 + (metrics.MissingRate-missingThreshold)/(0.40-missingThreshold)*17
-			baseScore -= penalty
+			penalty = 1 + (metrics.MissingRate-missingThreshold)/(0.40-missingThreshold)*17
 		}
+		addFinding("missing_rate", penalty, "数据缺失率超过阈值", map[string]interface{}{
+			"missing_rate":   metrics.MissingRate,
+			"threshold":      missingThreshold,
+			"total_expected": metrics.TotalExpected,
+			"total_received": metrics.TotalReceived,
+		})
 	}
 
 	// 2. 异常值比例扣分 (异常值 < 阈值: 不扣分, 阈值-30%: 线性扣分5-25分, >30%: 扣35分)
@@ -81,13 +114,16 @@ func (s *DataScorer) CalculateScore(cabinetID string) float64 {
 	}
 
 	if metrics.AbnormalRate > abnormalThreshold {
-		if metrics.AbnormalRate > 0.30 {
-			baseScore -= 35 // 降低：40 → 35
-		} else {
+		penalty := 35.0 // 降低：40 → 35
+		if metrics.AbnormalRate <= 0.30 {
 			// 线性插值：从阈值到30%，扣分从5到35
-			penalty := 5 + (metrics.AbnormalRate-abnormalThreshold)/(0.30-abnormalThreshold)*30
-			baseScore -= penalty
+			penalty = 5 + (metrics.AbnormalRate-abnormalThreshold)/(0.30-abnormalThreshold)*30
 		}
+		addFinding("abnormal_rate", penalty, "异常值比例超过阈值", map[string]interface{}{
+			"abnormal_rate":  metrics.AbnormalRate,
+			"abnormal_count": metrics.AbnormalCount,
+			"threshold":      abnormalThreshold,
+		})
 	}
 
 	// 3. 告警频率扣分 (告警数 < 阈值: 不扣分, 阈值-30: 线性扣分3-12分, >30: 扣15分)
@@ -97,17 +133,23 @@ func (s *DataScorer) CalculateScore(cabinetID string) float64 {
 	}
 
 	if metrics.AlertCount > alertThreshold {
-		if metrics.AlertCount > 30 {
-			baseScore -= 15 // 降低：20 → 15
-		} else {
+		penalty := 15.0 // 降低：20 → 15
+		if metrics.AlertCount <= 30 {
 			// 线性插值：从阈值到30，扣分从3到15
-			penalty := 3 + float64(metrics.AlertCount-alertThreshold)/float64(30-alertThreshold)*12
-			baseScore -= penalty
+			penalty = 3 + float64(metrics.AlertCount-alertThreshold)/float64(30-alertThreshold)*12
 		}
+		addFinding("alert_frequency", penalty, "告警频率超过阈值", map[string]interface{}{
+			"alert_count": metrics.AlertCount,
+			"threshold":   alertThreshold,
+		})
 	}
 
 	// 4. 数据连续性扣分 (进一步降低影响，连续性不是核心指标)
-	baseScore -= (1.0 - metrics.ContinuityScore) * 2 // 降低：8 → 5
+	if continuityPenalty := (1.0 - metrics.ContinuityScore) * 2; continuityPenalty > 0 { // 降低：8 → 5
+		addFinding("continuity", continuityPenalty, "数据存在时间间断", map[string]interface{}{
+			"continuity": metrics.ContinuityScore,
+		})
+	}
 
 	// 确保评分在合理范围内
 	if baseScore < 0 {
@@ -133,7 +175,7 @@ func (s *DataScorer) CalculateScore(cabinetID string) float64 {
 		zap.Float64("continuity", metrics.ContinuityScore),
 	)
 
-	return finalScore
+	return &ScoreResult{Score: finalScore, Findings: findings}
 }
 
 // collectDataMetrics 收集数据质量指标
@@ -18,6 +18,9 @@ type Detector struct {
 	logger *zap.Logger
 	config VulnerabilityConfig
 	db     *sql.DB
+
+	// 已启用配置漂移检测时不再按修改时间判断配置篡改
+	driftMonitored bool
 }
 
 // NewDetector 创建漏洞检测器
@@ -60,6 +63,38 @@ func (d *Detector) DetectVulnerabilities(cabinetID string) []models.Vulnerabilit
 	return vulnerabilities
 }
 
+// Name 评分器名称
+func (d *Detector) Name() string {
+	return ScorerDetector
+}
+
+// Score 执行漏洞检测，每个漏洞按严重程度扣分并作为扣分依据
+func (d *Detector) Score(input *AssessmentInput) *ScoreResult {
+	cabinetID := ""
+	if input != nil {
+		cabinetID = input.CabinetID
+	}
+	vulnerabilities := d.DetectVulnerabilities(cabinetID)
+
+	score := 100.0
+	findings := make([]models.ScoreFinding, 0, len(vulnerabilities))
+	for _, vuln := range vulnerabilities {
+		penalty := vulnerabilityPenalty(vuln.Severity)
+		score -= penalty
+		findings = append(findings, models.ScoreFinding{
+			Item:    vuln.Type,
+			Penalty: penalty,
+			Message: vuln.Title,
+			Evidence: map[string]interface{}{
+				"category":    vuln.Category,
+				"severity":    vuln.Severity,
+				"description": vuln.Description,
+			},
+		})
+	}
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
+
 // detectPortScan 检测端口扫描攻击
 func (d *Detector) detectPortScan() *models.VulnerabilityEvent {
 	// 查询最近10分钟内的认证失败次数
@@ -177,8 +212,11 @@ func (d *Detector) detectFrequencyAnomaly(cabinetID string) *models.Vulnerabilit
 	return nil
 }
 
-// detectConfigTampering 检测配置篡改
+// detectConfigTampering 检测配置篡改（未启用配置漂移检测时的简易检查）
 func (d *Detector) detectConfigTampering() *models.VulnerabilityEvent {
+	if d.driftMonitored {
+		return nil
+	}
 	configPath := "./configs/config.yaml"
 	
 	fileInfo, err := os.Stat(configPath)
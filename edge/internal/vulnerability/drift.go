@@ -0,0 +1,558 @@
+/*
+ * 配置漂移检测
+ * 对配置文件、验证密钥、许可证文件及关键SQLite设置建立签名基线，
+ * 周期性重新计算摘要，与基线不一致时生成带脱敏差异的漏洞事件
+ *
+ * 合法的配置变更同样会产生漂移事件，直到以变更后的状态批准新基线：
+ * 本地运维通过群组会话调用 POST /api/v1/vulnerability/config-baseline/approve，
+ * 或由Cloud下发 config_baseline_approve 命令；未批准前事件持续存在，提醒确认变更是否授权
+ */
+package vulnerability
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ScorerConfigDrift 配置漂移评分器名称
+const ScorerConfigDrift = "config_drift"
+
+// sqliteSettingsItem 关键SQLite设置基线项名称
+const sqliteSettingsItem = "sqlite_settings"
+
+// maxDiffLines 漏洞描述中保留的差异行数
+const maxDiffLines = 20
+
+// yamlValuePattern YAML键值行
+var yamlValuePattern = regexp.MustCompile(`^(\s*-?\s*)([\w.-]+)(\s*:\s*)(\S.*)$`)
+
+// DriftTarget 纳入基线的文件
+type DriftTarget struct {
+	Name    string // 基线项名称，如config_file、license_file、verifying_key:v1
+	Path    string
+	Content bool // 是否保存脱敏后的文本用于差异展示（仅适用于文本配置文件）
+}
+
+// DriftConfig 配置漂移检测配置
+type DriftConfig struct {
+	KeyFile string        // 基线签名私钥文件，不存在时自动生成
+	Files   []DriftTarget // 纳入基线的文件
+}
+
+// BaselineItem 基线项
+type BaselineItem struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`
+	Hash    string `json:"hash"`              // 原始内容的SHA-256，文件不存在时为空
+	Missing bool   `json:"missing,omitempty"` // 文件不存在
+	Content string `json:"content,omitempty"` // 脱敏后的文本内容
+}
+
+// ConfigBaseline 已批准的配置基线
+type ConfigBaseline struct {
+	ID         int64          `json:"id"`
+	Items      []BaselineItem `json:"items"`
+	Signature  string         `json:"signature"`
+	ApprovedBy string         `json:"approved_by"`
+	Reason     string         `json:"reason,omitempty"`
+	ApprovedAt time.Time      `json:"approved_at"`
+}
+
+// DriftItem 单个基线项的比对结果
+type DriftItem struct {
+	Name         string   `json:"name"`
+	Path         string   `json:"path,omitempty"`
+	Status       string   `json:"status"` // unchanged/modified/missing/added/removed
+	BaselineHash string   `json:"baseline_hash"`
+	CurrentHash  string   `json:"current_hash"`
+	Diff         []string `json:"diff,omitempty"` // 脱敏后的行差异（"- "为基线，"+ "为当前）
+}
+
+// DriftReport 配置漂移检测结果
+type DriftReport struct {
+	CheckedAt      time.Time   `json:"checked_at"`
+	BaselineID     int64       `json:"baseline_id"`
+	SignatureValid bool        `json:"signature_valid"`
+	Drifted        bool        `json:"drifted"`
+	Items          []DriftItem `json:"items"`
+}
+
+// DriftMonitor 配置漂移监测器
+type DriftMonitor struct {
+	db     *sql.DB
+	files  []DriftTarget
+	key    ed25519.PrivateKey
+	logger *zap.Logger
+
+	mu         sync.RWMutex
+	baseline   *ConfigBaseline
+	lastReport *DriftReport
+}
+
+// NewDriftMonitor 创建配置漂移监测器，尚无基线时以当前状态建立初始基线
+func NewDriftMonitor(db *sql.DB, cfg DriftConfig, logger *zap.Logger) (*DriftMonitor, error) {
+	key, err := loadOrCreateSigningKey(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &DriftMonitor{db: db, files: cfg.Files, key: key, logger: logger}
+	baseline, err := m.loadBaseline()
+	if err != nil {
+		return nil, err
+	}
+	if baseline == nil {
+		if baseline, err = m.Approve("system", "初始基线"); err != nil {
+			return nil, err
+		}
+		logger.Info("已建立初始配置基线", zap.Int64("baseline_id", baseline.ID))
+	}
+	m.baseline = baseline
+	return m, nil
+}
+
+// Approve 以当前状态建立新基线（授权变更后调用）
+func (m *DriftMonitor) Approve(approvedBy, reason string) (*ConfigBaseline, error) {
+	baseline := &ConfigBaseline{
+		Items:      m.snapshot(),
+		ApprovedBy: approvedBy,
+		Reason:     reason,
+		ApprovedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	payload, err := baselinePayload(baseline)
+	if err != nil {
+		return nil, err
+	}
+	baseline.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(m.key, payload))
+
+	items, err := json.Marshal(baseline.Items)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置基线失败: %w", err)
+	}
+	result, err := m.db.Exec(`
+		INSERT INTO config_baselines (items, signature, approved_by, reason, approved_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		string(items), baseline.Signature, baseline.ApprovedBy, baseline.Reason, baseline.ApprovedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("保存配置基线失败: %w", err)
+	}
+	baseline.ID, _ = result.LastInsertId()
+
+	m.mu.Lock()
+	m.baseline = baseline
+	m.lastReport = nil
+	m.mu.Unlock()
+
+	m.logger.Info("配置基线已批准",
+		zap.Int64("baseline_id", baseline.ID),
+		zap.String("approved_by", approvedBy),
+		zap.String("reason", reason),
+	)
+	return baseline, nil
+}
+
+// ApproveBaseline 批准新基线（供Cloud命令调用）
+func (m *DriftMonitor) ApproveBaseline(approvedBy, reason string) (int64, error) {
+	baseline, err := m.Approve(approvedBy, reason)
+	if err != nil {
+		return 0, err
+	}
+	return baseline.ID, nil
+}
+
+// Baseline 获取当前基线
+func (m *DriftMonitor) Baseline() *ConfigBaseline {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.baseline
+}
+
+// LastReport 获取最近一次检测结果
+func (m *DriftMonitor) LastReport() *DriftReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastReport
+}
+
+// Check 重新计算摘要并与基线比对
+func (m *DriftMonitor) Check() *DriftReport {
+	m.mu.RLock()
+	baseline := m.baseline
+	m.mu.RUnlock()
+
+	report := &DriftReport{
+		CheckedAt:      time.Now(),
+		BaselineID:     baseline.ID,
+		SignatureValid: m.verify(baseline),
+		Items:          compareBaseline(baseline.Items, m.snapshot()),
+	}
+	for _, item := range report.Items {
+		if item.Status != "unchanged" {
+			report.Drifted = true
+			break
+		}
+	}
+
+	m.mu.Lock()
+	m.lastReport = report
+	m.mu.Unlock()
+	return report
+}
+
+// Name 评分器名称
+func (m *DriftMonitor) Name() string {
+	return ScorerConfigDrift
+}
+
+// Score 检测配置漂移，基线签名无效或存在漂移时生成漏洞
+func (m *DriftMonitor) Score(input *AssessmentInput) *ScoreResult {
+	report := m.Check()
+	now := time.Now()
+	score := 100.0
+	findings := []models.ScoreFinding{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+
+	if !report.SignatureValid {
+		score -= vulnerabilityPenalty("critical")
+		findings = append(findings, models.ScoreFinding{
+			Item:     "baseline_signature",
+			Penalty:  vulnerabilityPenalty("critical"),
+			Message:  "配置基线签名校验失败",
+			Evidence: map[string]interface{}{"baseline_id": report.BaselineID},
+		})
+		vulnerabilities = append(vulnerabilities, models.VulnerabilityEvent{
+			Type:        "config_baseline_invalid",
+			Category:    "config",
+			Title:       "配置基线签名无效",
+			Severity:    "critical",
+			Description: fmt.Sprintf("配置基线#%d的签名校验失败，基线记录可能被篡改", report.BaselineID),
+			Solution:    "排查数据库及签名密钥的访问记录，确认配置无误后重新批准基线",
+			DetectedAt:  now,
+		})
+	}
+
+	var drifted []string
+	var diff []string
+	for _, item := range report.Items {
+		if item.Status == "unchanged" {
+			continue
+		}
+		drifted = append(drifted, fmt.Sprintf("%s(%s)", item.Name, item.Status))
+		for _, line := range item.Diff {
+			diff = append(diff, item.Name+": "+line)
+		}
+		findings = append(findings, models.ScoreFinding{
+			Item:    item.Name,
+			Message: "与已批准基线不一致",
+			Evidence: map[string]interface{}{
+				"path":          item.Path,
+				"status":        item.Status,
+				"baseline_hash": item.BaselineHash,
+				"current_hash":  item.CurrentHash,
+				"diff":          item.Diff,
+			},
+		})
+	}
+	if len(drifted) > 0 {
+		score -= vulnerabilityPenalty("high")
+		findings = append(findings, models.ScoreFinding{
+			Item:     "config_drift",
+			Penalty:  vulnerabilityPenalty("high"),
+			Message:  "存在未批准的配置变更",
+			Evidence: map[string]interface{}{"baseline_id": report.BaselineID, "drifted": drifted},
+		})
+
+		description := fmt.Sprintf("以下配置项与基线#%d不一致: %s", report.BaselineID, strings.Join(drifted, ", "))
+		if len(diff) > maxDiffLines {
+			diff = append(diff[:maxDiffLines], fmt.Sprintf("... 另有%d行差异", len(diff)-maxDiffLines))
+		}
+		if len(diff) > 0 {
+			description += "\n" + strings.Join(diff, "\n")
+		}
+		vulnerabilities = append(vulnerabilities, models.VulnerabilityEvent{
+			Type:        "config_drift",
+			Category:    "config",
+			Title:       "配置与已批准基线不一致",
+			Severity:    "high",
+			Description: description,
+			Solution:    "确认变更是否为授权操作：授权变更请在云端批准新基线，非授权变更请回滚并排查入侵痕迹",
+			DetectedAt:  now,
+		})
+	}
+
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
+
+// snapshot 采集当前状态
+func (m *DriftMonitor) snapshot() []BaselineItem {
+	items := make([]BaselineItem, 0, len(m.files)+1)
+	for _, target := range m.files {
+		if target.Path == "" {
+			continue
+		}
+		item := BaselineItem{Name: target.Name, Path: target.Path}
+		data, err := os.ReadFile(target.Path)
+		if err != nil {
+			item.Missing = true
+			items = append(items, item)
+			continue
+		}
+		item.Hash = hashBytes(data)
+		if target.Content {
+			item.Content = redactConfig(string(data))
+		}
+		items = append(items, item)
+	}
+
+	settings := m.sqliteSettings()
+	items = append(items, BaselineItem{
+		Name:    sqliteSettingsItem,
+		Hash:    hashBytes([]byte(settings)),
+		Content: settings,
+	})
+	return items
+}
+
+// sqliteSettings 读取关键SQLite设置（PRAGMA及ABAC设置），每行一项
+func (m *DriftMonitor) sqliteSettings() string {
+	var lines []string
+	for _, pragma := range []string{"journal_mode", "synchronous", "foreign_keys", "secure_delete"} {
+		var value string
+		if err := m.db.QueryRow("PRAGMA " + pragma).Scan(&value); err != nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("pragma.%s=%s", pragma, value))
+	}
+
+	// abac_settings表由ABAC模块创建，未启用时忽略
+	rows, err := m.db.Query(`SELECT key, value FROM abac_settings ORDER BY key`)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var key, value string
+			if rows.Scan(&key, &value) == nil {
+				lines = append(lines, fmt.Sprintf("abac.%s=%s", key, value))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *DriftMonitor) loadBaseline() (*ConfigBaseline, error) {
+	var baseline ConfigBaseline
+	var items string
+	err := m.db.QueryRow(`
+		SELECT id, items, signature, approved_by, reason, approved_at
+		FROM config_baselines
+		ORDER BY id DESC LIMIT 1
+	`).Scan(&baseline.ID, &items, &baseline.Signature, &baseline.ApprovedBy, &baseline.Reason, &baseline.ApprovedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("加载配置基线失败: %w", err)
+	}
+	if err := json.Unmarshal([]byte(items), &baseline.Items); err != nil {
+		return nil, fmt.Errorf("解析配置基线失败: %w", err)
+	}
+	baseline.ApprovedAt = baseline.ApprovedAt.UTC()
+	return &baseline, nil
+}
+
+// verify 校验基线签名
+func (m *DriftMonitor) verify(baseline *ConfigBaseline) bool {
+	signature, err := base64.StdEncoding.DecodeString(baseline.Signature)
+	if err != nil {
+		return false
+	}
+	payload, err := baselinePayload(baseline)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(m.key.Public().(ed25519.PublicKey), payload, signature)
+}
+
+// baselinePayload 基线签名内容
+func baselinePayload(baseline *ConfigBaseline) ([]byte, error) {
+	return json.Marshal(struct {
+		Items      []BaselineItem `json:"items"`
+		ApprovedBy string         `json:"approved_by"`
+		Reason     string         `json:"reason"`
+		ApprovedAt int64          `json:"approved_at"`
+	}{baseline.Items, baseline.ApprovedBy, baseline.Reason, baseline.ApprovedAt.Unix()})
+}
+
+// compareBaseline 逐项比对基线与当前状态
+func compareBaseline(baseline, current []BaselineItem) []DriftItem {
+	currentByName := make(map[string]BaselineItem, len(current))
+	for _, item := range current {
+		currentByName[item.Name] = item
+	}
+
+	result := make([]DriftItem, 0, len(current))
+	seen := make(map[string]bool, len(baseline))
+	for _, base := range baseline {
+		seen[base.Name] = true
+		cur, ok := currentByName[base.Name]
+		item := DriftItem{Name: base.Name, Path: base.Path, BaselineHash: base.Hash, CurrentHash: cur.Hash}
+		switch {
+		case !ok:
+			item.Status = "removed"
+		case cur.Missing && !base.Missing:
+			item.Status = "missing"
+		case cur.Hash == base.Hash && cur.Missing == base.Missing:
+			item.Status = "unchanged"
+		default:
+			item.Status = "modified"
+			item.Diff = diffLines(base.Content, cur.Content)
+		}
+		result = append(result, item)
+	}
+	for _, cur := range current {
+		if !seen[cur.Name] {
+			result = append(result, DriftItem{Name: cur.Name, Path: cur.Path, Status: "added", CurrentHash: cur.Hash})
+		}
+	}
+	return result
+}
+
+// diffLines 基于最长公共子序列的行差异
+func diffLines(before, after string) []string {
+	if before == "" && after == "" {
+		return nil
+	}
+	a, b := strings.Split(before, "\n"), strings.Split(after, "\n")
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "- "+a[i])
+			i++
+		default:
+			diff = append(diff, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+ "+b[j])
+	}
+	return diff
+}
+
+// redactConfig 脱敏敏感键的值，保留摘要前缀以便判断是否变化
+func redactConfig(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		match := yamlValuePattern.FindStringSubmatch(line)
+		if match == nil || !isSensitiveKey(match[2]) {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(match[4]), `"'`)
+		if value == "" {
+			continue
+		}
+		lines[i] = match[1] + match[2] + match[3] + "<redacted:" + hashBytes([]byte(value))[:8] + ">"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isSensitiveKey 判断键名是否为密码、密钥、令牌等敏感项
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "passwd", "secret", "token", "credential"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return key == "key" || strings.HasSuffix(key, "_key")
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadOrCreateSigningKey 加载基线签名私钥（PKCS#8 PEM），不存在时生成
+func loadOrCreateSigningKey(path string) (ed25519.PrivateKey, error) {
+	if path == "" {
+		return nil, errors.New("未配置基线签名密钥文件")
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("基线签名密钥格式错误: %s", path)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析基线签名密钥失败: %w", err)
+		}
+		key, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("基线签名密钥不是Ed25519密钥: %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取基线签名密钥失败: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成基线签名密钥失败: %w", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("编码基线签名密钥失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, fmt.Errorf("保存基线签名密钥失败: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,86 @@
+package vulnerability
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestDriftMonitorDetectsAndApprovesChanges(t *testing.T) {
+	dir := t.TempDir()
+	logger := zap.NewNop()
+
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:             "sqlite3",
+		Path:               filepath.Join(dir, "edge.db"),
+		MaxConnections:     1,
+		MaxIdleConnections: 1,
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	configPath := filepath.Join(dir, "config.yaml")
+	writeFile := func(content string) {
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("server:\n    port: 8001\ncloud:\n    api_key: old-secret\n")
+
+	monitor, err := NewDriftMonitor(db.GetDB(), DriftConfig{
+		KeyFile: filepath.Join(dir, "baseline.key"),
+		Files: []DriftTarget{
+			{Name: "config_file", Path: configPath, Content: true},
+			{Name: "license_file", Path: filepath.Join(dir, "license.lic")},
+		},
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to create drift monitor: %v", err)
+	}
+
+	if report := monitor.Check(); report.Drifted || !report.SignatureValid {
+		t.Fatalf("expected clean initial baseline, got %+v", report)
+	}
+
+	writeFile("server:\n    port: 9001\ncloud:\n    api_key: new-secret\n")
+	result := monitor.Score(&AssessmentInput{})
+	if len(result.Vulnerabilities) != 1 || result.Vulnerabilities[0].Type != "config_drift" {
+		t.Fatalf("expected one config_drift vulnerability, got %+v", result.Vulnerabilities)
+	}
+	description := result.Vulnerabilities[0].Description
+	if !strings.Contains(description, "+     port: 9001") || !strings.Contains(description, "api_key: <redacted:") {
+		t.Fatalf("expected redacted diff in description, got %q", description)
+	}
+	if strings.Contains(description, "secret") {
+		t.Fatalf("secret leaked into description: %q", description)
+	}
+
+	if _, err := monitor.Approve("tester", "port change"); err != nil {
+		t.Fatalf("approve failed: %v", err)
+	}
+	if result := monitor.Score(&AssessmentInput{}); len(result.Vulnerabilities) != 0 || result.Score != 100 {
+		t.Fatalf("expected no drift after approval, got %+v", result)
+	}
+
+	// 篡改数据库中的基线记录后重新加载，签名校验应失败
+	if _, err := db.GetDB().Exec(`UPDATE config_baselines SET approved_by = 'attacker'`); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewDriftMonitor(db.GetDB(), DriftConfig{
+		KeyFile: filepath.Join(dir, "baseline.key"),
+		Files:   []DriftTarget{{Name: "config_file", Path: configPath, Content: true}},
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to reload drift monitor: %v", err)
+	}
+	if report := reloaded.Check(); report.SignatureValid {
+		t.Fatal("expected tampered baseline signature to be invalid")
+	}
+}
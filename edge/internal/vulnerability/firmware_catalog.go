@@ -0,0 +1,294 @@
+/*
+ * 固件漏洞目录
+ * 保存Cloud下发的已知漏洞固件版本范围，并与设备清单匹配生成设备级漏洞
+ */
+package vulnerability
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/abac"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ScorerFirmware 固件漏洞评分器名称
+const ScorerFirmware = "firmware"
+
+// FirmwareCatalogMessage Cloud下发的固件漏洞目录（全量）
+type FirmwareCatalogMessage struct {
+	Version    string                    `json:"version"`
+	Advisories []models.FirmwareAdvisory `json:"advisories"`
+	Timestamp  time.Time                 `json:"timestamp"`
+}
+
+// FirmwareCatalogInfo 固件漏洞目录状态
+type FirmwareCatalogInfo struct {
+	Version    string                    `json:"version"`
+	ReceivedAt *time.Time                `json:"received_at,omitempty"`
+	Advisories []models.FirmwareAdvisory `json:"advisories"`
+}
+
+// FirmwareCatalog 固件漏洞目录
+type FirmwareCatalog struct {
+	db        *sql.DB
+	cabinetID string
+	logger    *zap.Logger
+
+	mu         sync.RWMutex
+	version    string
+	receivedAt *time.Time
+	advisories []models.FirmwareAdvisory
+}
+
+// NewFirmwareCatalog 创建固件漏洞目录并加载本地已保存的目录
+func NewFirmwareCatalog(db *sql.DB, cabinetID string, logger *zap.Logger) (*FirmwareCatalog, error) {
+	c := &FirmwareCatalog{
+		db:         db,
+		cabinetID:  cabinetID,
+		logger:     logger,
+		advisories: []models.FirmwareAdvisory{},
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCatalogTopic 获取目录订阅主题
+func (c *FirmwareCatalog) GetCatalogTopic() string {
+	return "cloud/cabinet/" + c.cabinetID + "/firmware/catalog"
+}
+
+// HandleCatalogSync 处理Cloud下发的目录，整体替换本地目录
+func (c *FirmwareCatalog) HandleCatalogSync(payload []byte) error {
+	var msg FirmwareCatalogMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("解析固件漏洞目录失败: %w", err)
+	}
+
+	c.mu.RLock()
+	unchanged := msg.Version != "" && msg.Version == c.version
+	c.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := c.replace(msg.Version, msg.Advisories); err != nil {
+		return err
+	}
+
+	c.logger.Info("固件漏洞目录已更新",
+		zap.String("version", msg.Version),
+		zap.Int("advisories", len(msg.Advisories)),
+	)
+	return nil
+}
+
+// Info 获取当前目录
+func (c *FirmwareCatalog) Info() FirmwareCatalogInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	advisories := make([]models.FirmwareAdvisory, len(c.advisories))
+	copy(advisories, c.advisories)
+	return FirmwareCatalogInfo{Version: c.version, ReceivedAt: c.receivedAt, Advisories: advisories}
+}
+
+// Match 返回影响该设备的公告；固件版本未知的设备不匹配
+func (c *FirmwareCatalog) Match(device *models.Device) []models.FirmwareAdvisory {
+	if device == nil || device.FirmwareVer == "" {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var matched []models.FirmwareAdvisory
+	for _, advisory := range c.advisories {
+		if advisoryAffects(advisory, device) {
+			matched = append(matched, advisory)
+		}
+	}
+	return matched
+}
+
+// advisoryAffects 判断公告是否影响设备（制造商、型号不区分大小写，版本范围含边界）
+func advisoryAffects(advisory models.FirmwareAdvisory, device *models.Device) bool {
+	if advisory.Manufacturer != "" && !strings.EqualFold(advisory.Manufacturer, device.Manufacturer) {
+		return false
+	}
+	if advisory.Model != "" && !strings.EqualFold(advisory.Model, device.Model) {
+		return false
+	}
+
+	version := device.FirmwareVer
+	if advisory.MinVersion != "" && abac.CompareVersions(version, advisory.MinVersion) < 0 {
+		return false
+	}
+	if advisory.MaxVersion != "" && abac.CompareVersions(version, advisory.MaxVersion) > 0 {
+		return false
+	}
+	if advisory.FixedVersion != "" && abac.CompareVersions(version, advisory.FixedVersion) >= 0 {
+		return false
+	}
+	return true
+}
+
+func (c *FirmwareCatalog) load() error {
+	var version string
+	var receivedAt time.Time
+	err := c.db.QueryRow(`SELECT version, received_at FROM firmware_catalog WHERE id = 1`).Scan(&version, &receivedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("加载固件漏洞目录失败: %w", err)
+	}
+
+	rows, err := c.db.Query(`
+		SELECT advisory_id, manufacturer, model, min_version, max_version, fixed_version,
+			severity, title, description, remediation, updated_at
+		FROM firmware_advisories
+		ORDER BY advisory_id
+	`)
+	if err != nil {
+		return fmt.Errorf("加载固件漏洞公告失败: %w", err)
+	}
+	defer rows.Close()
+
+	advisories := []models.FirmwareAdvisory{}
+	for rows.Next() {
+		var a models.FirmwareAdvisory
+		if err := rows.Scan(&a.AdvisoryID, &a.Manufacturer, &a.Model, &a.MinVersion, &a.MaxVersion,
+			&a.FixedVersion, &a.Severity, &a.Title, &a.Description, &a.Remediation, &a.UpdatedAt); err != nil {
+			return fmt.Errorf("加载固件漏洞公告失败: %w", err)
+		}
+		advisories = append(advisories, a)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	c.version, c.receivedAt, c.advisories = version, &receivedAt, advisories
+	return nil
+}
+
+func (c *FirmwareCatalog) replace(version string, advisories []models.FirmwareAdvisory) error {
+	now := time.Now()
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM firmware_advisories`); err != nil {
+		return fmt.Errorf("清空固件漏洞公告失败: %w", err)
+	}
+	for _, a := range advisories {
+		if a.AdvisoryID == "" {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO firmware_advisories (
+				advisory_id, manufacturer, model, min_version, max_version, fixed_version,
+				severity, title, description, remediation, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.AdvisoryID, a.Manufacturer, a.Model, a.MinVersion, a.MaxVersion, a.FixedVersion,
+			a.Severity, a.Title, a.Description, a.Remediation, a.UpdatedAt,
+		); err != nil {
+			return fmt.Errorf("保存固件漏洞公告失败: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO firmware_catalog (id, version, received_at) VALUES (1, ?, ?)`, version, now); err != nil {
+		return fmt.Errorf("保存固件漏洞目录版本失败: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if advisories == nil {
+		advisories = []models.FirmwareAdvisory{}
+	}
+	c.mu.Lock()
+	c.version, c.receivedAt, c.advisories = version, &now, advisories
+	c.mu.Unlock()
+	return nil
+}
+
+// DeviceInventory 设备清单（由device.Manager实现）
+type DeviceInventory interface {
+	GetAllDevices() ([]*models.Device, error)
+}
+
+// FirmwareScorer 固件漏洞评分器，按设备清单匹配目录生成设备级漏洞
+type FirmwareScorer struct {
+	catalog *FirmwareCatalog
+	devices DeviceInventory
+	logger  *zap.Logger
+}
+
+// NewFirmwareScorer 创建固件漏洞评分器
+func NewFirmwareScorer(catalog *FirmwareCatalog, devices DeviceInventory, logger *zap.Logger) *FirmwareScorer {
+	return &FirmwareScorer{catalog: catalog, devices: devices, logger: logger}
+}
+
+// Name 评分器名称
+func (s *FirmwareScorer) Name() string {
+	return ScorerFirmware
+}
+
+// Score 匹配设备固件，每个受影响的设备和公告生成一条漏洞
+func (s *FirmwareScorer) Score(input *AssessmentInput) *ScoreResult {
+	devices, err := s.devices.GetAllDevices()
+	if err != nil {
+		s.logger.Warn("获取设备清单失败，跳过固件漏洞匹配", zap.Error(err))
+		return &ScoreResult{Score: 100}
+	}
+
+	now := time.Now()
+	score := 100.0
+	findings := []models.ScoreFinding{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+	for _, device := range devices {
+		for _, advisory := range s.catalog.Match(device) {
+			penalty := vulnerabilityPenalty(advisory.Severity)
+			score -= penalty
+
+			solution := advisory.Remediation
+			if advisory.FixedVersion != "" {
+				solution = strings.TrimSpace(fmt.Sprintf("升级固件至%s或更高版本。%s", advisory.FixedVersion, advisory.Remediation))
+			}
+			vulnerabilities = append(vulnerabilities, models.VulnerabilityEvent{
+				Type:        "firmware:" + advisory.AdvisoryID,
+				Category:    "firmware",
+				Title:       fmt.Sprintf("设备%s固件存在已知漏洞 %s", device.DeviceID, advisory.AdvisoryID),
+				Severity:    advisory.Severity,
+				Description: fmt.Sprintf("%s（%s %s 固件%s）", advisory.Title, device.Manufacturer, device.Model, device.FirmwareVer),
+				Solution:    solution,
+				DeviceID:    device.DeviceID,
+				DetectedAt:  now,
+			})
+			findings = append(findings, models.ScoreFinding{
+				Item:    advisory.AdvisoryID,
+				Penalty: penalty,
+				Message: advisory.Title,
+				Evidence: map[string]interface{}{
+					"device_id":        device.DeviceID,
+					"manufacturer":     device.Manufacturer,
+					"model":            device.Model,
+					"firmware_version": device.FirmwareVer,
+					"min_version":      advisory.MinVersion,
+					"max_version":      advisory.MaxVersion,
+					"fixed_version":    advisory.FixedVersion,
+				},
+			})
+		}
+	}
+
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
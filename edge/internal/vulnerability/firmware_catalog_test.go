@@ -0,0 +1,43 @@
+package vulnerability
+
+import (
+	"testing"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+type staticInventory []*models.Device
+
+func (d staticInventory) GetAllDevices() ([]*models.Device, error) { return d, nil }
+
+func TestFirmwareScorerMatchesCatalog(t *testing.T) {
+	catalog := &FirmwareCatalog{advisories: []models.FirmwareAdvisory{
+		{AdvisoryID: "ADV-1", Manufacturer: "Acme", Model: "TH-100", MinVersion: "1.0", MaxVersion: "1.4.9", FixedVersion: "1.5.0", Severity: "high"},
+		{AdvisoryID: "ADV-2", Manufacturer: "Acme", FixedVersion: "2.0", Severity: "low"},
+	}}
+	devices := staticInventory{
+		{DeviceID: "dev-old", Manufacturer: "acme", Model: "th-100", FirmwareVer: "v1.2.3"},
+		{DeviceID: "dev-fixed", Manufacturer: "Acme", Model: "TH-100", FirmwareVer: "2.1"},
+		{DeviceID: "dev-other", Manufacturer: "Other", Model: "TH-100", FirmwareVer: "1.2"},
+		{DeviceID: "dev-unknown", Manufacturer: "Acme", Model: "TH-100"},
+	}
+
+	result := NewFirmwareScorer(catalog, devices, zap.NewNop()).Score(&AssessmentInput{})
+
+	// dev-old命中ADV-1(high)和ADV-2(low)，其余设备不受影响
+	if len(result.Vulnerabilities) != 2 {
+		t.Fatalf("expected 2 vulnerabilities, got %+v", result.Vulnerabilities)
+	}
+	for _, vuln := range result.Vulnerabilities {
+		if vuln.DeviceID != "dev-old" || vuln.Category != "firmware" {
+			t.Fatalf("unexpected vulnerability: %+v", vuln)
+		}
+	}
+	if result.Vulnerabilities[0].Type != "firmware:ADV-1" || result.Vulnerabilities[1].Type != "firmware:ADV-2" {
+		t.Fatalf("unexpected vulnerability types: %+v", result.Vulnerabilities)
+	}
+	if result.Score != 88 {
+		t.Fatalf("expected score 88, got %v", result.Score)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/edge/storage-cabinet/internal/license"
+	"github.com/edge/storage-cabinet/pkg/models"
 	"go.uber.org/zap"
 )
 
@@ -25,34 +26,78 @@ func NewLicenseScorer(licenseService *license.Service, logger *zap.Logger) *Lice
 	}
 }
 
-// CalculateScore 计算许可证合规性评分 (0-100)
-func (s *LicenseScorer) CalculateScore() float64 {
+// Name 评分器名称
+func (s *LicenseScorer) Name() string {
+	return ScorerLicense
+}
+
+// Score 计算许可证合规性评分及扣分依据
+func (s *LicenseScorer) Score(input *AssessmentInput) *ScoreResult {
 	// 如果许可证系统未启用，返回满分
 	if !s.licenseService.IsEnabled() {
 		s.logger.Debug("许可证系统未启用，返回满分")
-		return 100.0
+		return &ScoreResult{Score: 100.0}
 	}
 
 	// 获取许可证信息
 	licenseInfoMap := s.licenseService.GetLicenseInfo()
 	if licenseInfoMap == nil || !licenseInfoMap["enabled"].(bool) {
 		s.logger.Warn("无法获取许可证信息")
-		return 0.0
+		return &ScoreResult{
+			Score: 0.0,
+			Findings: []models.ScoreFinding{{
+				Item:    "license_info",
+				Penalty: 100,
+				Message: "无法获取许可证信息",
+			}},
+		}
 	}
 
 	var score float64 = 100.0
+	findings := []models.ScoreFinding{}
 
 	// 1. 检查许可证有效性 (40%)
 	validityScore := s.checkValidity(licenseInfoMap)
 	score = score * 0.4 * validityScore / 100
+	if validityScore < 100 {
+		findings = append(findings, models.ScoreFinding{
+			Item:    "license_validity",
+			Penalty: 0.4 * (100 - validityScore),
+			Message: "许可证已过期且超过宽限期",
+			Evidence: map[string]interface{}{
+				"is_expired":      licenseInfoMap["is_expired"],
+				"in_grace_period": licenseInfoMap["in_grace_period"],
+			},
+		})
+	}
 
 	// 2. 检查有效期 (35%)
 	expiryScore := s.checkExpiry(licenseInfoMap)
 	score += 100 * 0.35 * expiryScore / 100
+	if expiryScore < 100 {
+		findings = append(findings, models.ScoreFinding{
+			Item:    "license_expiry",
+			Penalty: 0.35 * (100 - expiryScore),
+			Message: "许可证已过期或即将过期",
+			Evidence: map[string]interface{}{
+				"expires_at":   licenseInfoMap["expires_at"],
+				"expiry_score": expiryScore,
+			},
+		})
+	}
 
 	// 3. 检查设备数量限制 (25%)
-	deviceScore := s.checkDeviceLimit(s.licenseService.GetMaxDevices())
+	maxDevices := s.licenseService.GetMaxDevices()
+	deviceScore := s.checkDeviceLimit(maxDevices)
 	score += 100 * 0.25 * deviceScore / 100
+	if deviceScore < 100 {
+		findings = append(findings, models.ScoreFinding{
+			Item:     "device_limit",
+			Penalty:  0.25 * (100 - deviceScore),
+			Message:  "设备数量接近或超过许可证限制",
+			Evidence: map[string]interface{}{"max_devices": maxDevices},
+		})
+	}
 
 	s.logger.Debug("许可证合规性评分",
 		zap.Float64("validity_score", validityScore),
@@ -61,7 +106,12 @@ func (s *LicenseScorer) CalculateScore() float64 {
 		zap.Float64("total_score", score),
 	)
 
-	return score
+	return &ScoreResult{Score: score, Findings: findings}
+}
+
+// CalculateScore 计算许可证合规性评分 (0-100)
+func (s *LicenseScorer) CalculateScore() float64 {
+	return s.Score(nil).Score
 }
 
 // checkValidity 检查许可证有效性
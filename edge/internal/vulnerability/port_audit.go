@@ -0,0 +1,386 @@
+/*
+ * 本机监听端口自查
+ * 读取/proc/net中实际处于监听状态的套接字并关联进程，与配置推导的白名单比对，
+ * 标记非预期监听、应仅本机访问却绑定到所有接口的服务以及调试/性能分析端口
+ */
+package vulnerability
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ScorerPortAudit 监听端口自查评分器名称
+const ScorerPortAudit = "port_audit"
+
+// DefaultDebugPorts 常见调试/性能分析端口：pprof、delve、Node inspector、JDWP
+var DefaultDebugPorts = []int{6060, 2345, 9229, 5005}
+
+// DefaultUDPAllowedPorts 系统客户端/发现协议绑定的UDP端口：DHCP客户端、DHCPv6客户端、mDNS
+var DefaultUDPAllowedPorts = []int{68, 546, 5353}
+
+// 监听端口审计结论
+const (
+	ListenerAllowed    = "allowed"    // 白名单内
+	ListenerLocal      = "local"      // 仅本机可访问，未在白名单中
+	ListenerUnexpected = "unexpected" // 非预期的对外监听
+	ListenerExposed    = "exposed"    // 应仅本机访问却对外监听
+	ListenerDebug      = "debug"      // 调试/性能分析端口
+)
+
+// ListenerRule 允许的监听端口
+type ListenerRule struct {
+	Name      string // 用途，如edge_api、mqtt_broker
+	Port      int
+	LocalOnly bool // 只允许绑定本机回环地址
+}
+
+// PortAuditConfig 监听端口自查配置
+type PortAuditConfig struct {
+	Allowed    []ListenerRule
+	DebugPorts []int
+	UDPAllowed []int  // 允许的未连接UDP端口，默认DefaultUDPAllowedPorts
+	ProcRoot   string // 默认/proc
+}
+
+// Listener 监听中的套接字
+type Listener struct {
+	Protocol string `json:"protocol"` // tcp/tcp6/udp/udp6
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	Inode    string `json:"inode"`
+	PID      int    `json:"pid,omitempty"`
+	Process  string `json:"process,omitempty"` // 无权限读取其他进程时为空
+}
+
+// AuditedListener 带审计结论的监听套接字
+type AuditedListener struct {
+	Listener
+	Verdict string `json:"verdict"`
+	Rule    string `json:"rule,omitempty"` // 匹配的白名单规则
+}
+
+// PortAuditor 监听端口自查
+type PortAuditor struct {
+	config PortAuditConfig
+	logger *zap.Logger
+}
+
+// NewPortAuditor 创建监听端口自查
+func NewPortAuditor(cfg PortAuditConfig, logger *zap.Logger) *PortAuditor {
+	if cfg.ProcRoot == "" {
+		cfg.ProcRoot = "/proc"
+	}
+	if cfg.DebugPorts == nil {
+		cfg.DebugPorts = DefaultDebugPorts
+	}
+	if cfg.UDPAllowed == nil {
+		cfg.UDPAllowed = DefaultUDPAllowedPorts
+	}
+	return &PortAuditor{config: cfg, logger: logger}
+}
+
+// Name 评分器名称
+func (a *PortAuditor) Name() string {
+	return ScorerPortAudit
+}
+
+// Audit 枚举监听套接字并给出审计结论（仅支持Linux）
+func (a *PortAuditor) Audit() ([]AuditedListener, error) {
+	if runtime.GOOS != "linux" && a.config.ProcRoot == "/proc" {
+		return nil, fmt.Errorf("监听端口自查仅支持Linux")
+	}
+
+	listeners, err := a.Listeners()
+	if err != nil {
+		return nil, err
+	}
+
+	audited := make([]AuditedListener, 0, len(listeners))
+	for _, listener := range listeners {
+		audited = append(audited, a.classify(listener))
+	}
+	return audited, nil
+}
+
+// Listeners 从/proc/net读取监听中的TCP套接字及未连接的UDP套接字，并关联进程
+func (a *PortAuditor) Listeners() ([]Listener, error) {
+	var listeners []Listener
+	read := 0
+	for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		entries, err := parseProcNet(filepath.Join(a.config.ProcRoot, "net", protocol), protocol)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // 未启用IPv6等
+			}
+			return nil, err
+		}
+		read++
+		listeners = append(listeners, entries...)
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("无法读取%s/net", a.config.ProcRoot)
+	}
+
+	processes := a.socketOwners()
+	for i := range listeners {
+		if owner, ok := processes[listeners[i].Inode]; ok {
+			listeners[i].PID, listeners[i].Process = owner.pid, owner.name
+		}
+	}
+
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].Protocol < listeners[j].Protocol
+	})
+	return listeners, nil
+}
+
+// classify 按调试端口、白名单、绑定地址给出结论
+// UDP套接字只在绑定非回环地址且不在UDP白名单时才可能被标记
+func (a *PortAuditor) classify(listener Listener) AuditedListener {
+	result := AuditedListener{Listener: listener}
+	local := isLoopbackAddress(listener.Address)
+
+	if strings.HasPrefix(listener.Protocol, "udp") {
+		if local {
+			result.Verdict = ListenerLocal
+			return result
+		}
+		for _, port := range a.config.UDPAllowed {
+			if listener.Port == port {
+				result.Verdict = ListenerAllowed
+				result.Rule = "udp_system"
+				return result
+			}
+		}
+	}
+
+	for _, port := range a.config.DebugPorts {
+		if listener.Port == port {
+			result.Verdict = ListenerDebug
+			return result
+		}
+	}
+
+	for _, rule := range a.config.Allowed {
+		if rule.Port != listener.Port {
+			continue
+		}
+		result.Rule = rule.Name
+		if rule.LocalOnly && !local {
+			result.Verdict = ListenerExposed
+		} else {
+			result.Verdict = ListenerAllowed
+		}
+		return result
+	}
+
+	if local {
+		result.Verdict = ListenerLocal
+	} else {
+		result.Verdict = ListenerUnexpected
+	}
+	return result
+}
+
+// Score 非预期监听、应本机却对外监听及调试端口按严重程度扣分，同一端口只生成一个漏洞
+func (a *PortAuditor) Score(input *AssessmentInput) *ScoreResult {
+	audited, err := a.Audit()
+	if err != nil {
+		a.logger.Debug("跳过监听端口自查", zap.Error(err))
+		return &ScoreResult{Score: 100}
+	}
+
+	score := 100.0
+	findings := []models.ScoreFinding{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+	reported := map[string]bool{}
+	now := time.Now()
+	for _, listener := range audited {
+		event := listenerEvent(listener, now)
+		if event == nil {
+			continue
+		}
+
+		penalty := vulnerabilityPenalty(event.Severity)
+		findings = append(findings, models.ScoreFinding{
+			Item:    event.Type,
+			Penalty: penalty,
+			Message: event.Title,
+			Evidence: map[string]interface{}{
+				"protocol": listener.Protocol,
+				"address":  listener.Address,
+				"port":     listener.Port,
+				"pid":      listener.PID,
+				"process":  listener.Process,
+				"rule":     listener.Rule,
+			},
+		})
+		if reported[event.Type] {
+			findings[len(findings)-1].Penalty = 0
+			continue
+		}
+		reported[event.Type] = true
+		score -= penalty
+		vulnerabilities = append(vulnerabilities, *event)
+	}
+
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
+
+// listenerEvent 根据审计结论生成漏洞事件，允许的监听返回nil
+func listenerEvent(listener AuditedListener, now time.Time) *models.VulnerabilityEvent {
+	process := listener.Process
+	if process == "" {
+		process = "未知进程"
+	}
+	endpoint := fmt.Sprintf("%s %s:%d（%s）", listener.Protocol, listener.Address, listener.Port, process)
+	base := strings.TrimSuffix(listener.Protocol, "6")
+
+	event := &models.VulnerabilityEvent{Category: "network", DetectedAt: now}
+	switch listener.Verdict {
+	case ListenerDebug:
+		event.Type = fmt.Sprintf("port_debug:%d", listener.Port)
+		event.Title = fmt.Sprintf("调试/性能分析端口%d正在监听", listener.Port)
+		event.Severity = "medium"
+		if !isLoopbackAddress(listener.Address) {
+			event.Severity = "high"
+		}
+		event.Description = "检测到调试或性能分析服务: " + endpoint
+		event.Solution = "生产环境关闭调试及性能分析服务，确需保留时仅绑定127.0.0.1"
+	case ListenerExposed:
+		event.Type = fmt.Sprintf("port_exposed:%d", listener.Port)
+		event.Title = fmt.Sprintf("%s端口%d绑定到外部接口", listener.Rule, listener.Port)
+		event.Severity = "medium"
+		event.Description = "该服务应仅允许本机访问，实际监听: " + endpoint
+		event.Solution = "将监听地址修改为127.0.0.1，或通过防火墙限制访问来源"
+	case ListenerUnexpected:
+		event.Type = fmt.Sprintf("port_unexpected:%s/%d", base, listener.Port)
+		event.Title = fmt.Sprintf("非预期的对外监听端口%s/%d", base, listener.Port)
+		event.Severity = "medium"
+		event.Description = "端口不在配置推导的白名单中: " + endpoint
+		event.Solution = "确认该服务是否必要：不需要时停止服务，需要时加入vulnerability.port_audit.allowed_ports"
+	default:
+		return nil
+	}
+	return event
+}
+
+type socketOwner struct {
+	pid  int
+	name string
+}
+
+// socketOwners 遍历/proc/<pid>/fd建立套接字inode到进程的映射，无权限的进程跳过
+func (a *PortAuditor) socketOwners() map[string]socketOwner {
+	owners := map[string]socketOwner{}
+	entries, err := os.ReadDir(a.config.ProcRoot)
+	if err != nil {
+		return owners
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		fdDir := filepath.Join(a.config.ProcRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		var name string
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			if name == "" {
+				comm, _ := os.ReadFile(filepath.Join(a.config.ProcRoot, entry.Name(), "comm"))
+				name = strings.TrimSpace(string(comm))
+			}
+			owners[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] = socketOwner{pid: pid, name: name}
+		}
+	}
+	return owners
+}
+
+// parseProcNet 解析/proc/net/{tcp,tcp6,udp,udp6}，TCP取LISTEN状态，UDP取未连接的套接字
+func parseProcNet(path, protocol string) ([]Listener, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var listeners []Listener
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		state := fields[3]
+		if strings.HasPrefix(protocol, "tcp") && state != "0A" {
+			continue
+		}
+		if strings.HasPrefix(protocol, "udp") && state != "07" {
+			continue
+		}
+
+		address, port, err := parseHexAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(protocol, "udp") {
+			// 已连接的UDP套接字（远端端口非0）不是监听
+			if _, remotePort, err := parseHexAddress(fields[2]); err != nil || remotePort != 0 {
+				continue
+			}
+		}
+		listeners = append(listeners, Listener{Protocol: protocol, Address: address, Port: port, Inode: fields[9]})
+	}
+	return listeners, scanner.Err()
+}
+
+// parseHexAddress 解析/proc/net中的地址，IP按32位字小端存储
+func parseHexAddress(value string) (string, int, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("地址格式错误: %s", value)
+	}
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return "", 0, fmt.Errorf("地址格式错误: %s", value)
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("端口格式错误: %s", value)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+	return ip.String(), int(port), nil
+}
+
+func isLoopbackAddress(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
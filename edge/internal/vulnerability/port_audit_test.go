@@ -0,0 +1,148 @@
+package vulnerability
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+func writeFakeProc(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"net/tcp": procNetHeader +
+			"   0: 00000000:1F41 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0 100 0 0 10 0\n" +
+			"   1: 00000000:2382 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1002 1 0 100 0 0 10 0\n" +
+			"   2: 0100007F:17AC 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1003 1 0 100 0 0 10 0\n" +
+			"   3: 00000000:0CEA 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 3001 1 0 100 0 0 10 0\n" +
+			"   4: 0100007F:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1005 1 0 100 0 0 10 0\n" +
+			"   5: 0A00000A:115C 0B00000A:C350 01 00000000:00000000 00:00000000 00000000     0        0 1006 1 0 100 0 0 10 0\n",
+		"net/tcp6": procNetHeader +
+			"   0: 00000000000000000000000000000000:0CEA 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 3002 1 0 100 0 0 10 0\n",
+		"net/udp": procNetHeader +
+			"   0: 00000000:00A1 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1008 2 0\n",
+		"42/comm": "mysqld\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "42", "fd"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("socket:[3001]", filepath.Join(root, "42", "fd", "7")); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestPortAuditorFlagsListeners(t *testing.T) {
+	auditor := NewPortAuditor(PortAuditConfig{
+		ProcRoot: writeFakeProc(t),
+		Allowed: []ListenerRule{
+			{Name: "edge_api", Port: 8001},
+			{Name: "metrics", Port: 9090, LocalOnly: true},
+		},
+	}, zap.NewNop())
+
+	listeners, err := auditor.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verdicts := map[string]string{}
+	for _, listener := range listeners {
+		verdicts[listener.Protocol+"/"+listener.Address] += listener.Verdict + ","
+		if listener.Port == 3306 && listener.Protocol == "tcp" && (listener.PID != 42 || listener.Process != "mysqld") {
+			t.Fatalf("expected process mapping for 3306, got %+v", listener)
+		}
+	}
+	if len(listeners) != 7 {
+		t.Fatalf("expected 7 listeners (established socket skipped), got %+v", listeners)
+	}
+	if verdicts["tcp/127.0.0.1"] != "local,debug," || verdicts["tcp6/::"] != "unexpected," {
+		t.Fatalf("unexpected verdicts: %+v", verdicts)
+	}
+
+	result := auditor.Score(&AssessmentInput{})
+	types := map[string]string{}
+	for _, vuln := range result.Vulnerabilities {
+		types[vuln.Type] = vuln.Severity
+	}
+	want := map[string]string{
+		"port_exposed:9090":        "medium",
+		"port_debug:6060":          "medium",
+		"port_unexpected:tcp/3306": "medium",
+		"port_unexpected:udp/161":  "medium",
+	}
+	if len(types) != len(want) {
+		t.Fatalf("unexpected vulnerabilities: %+v", result.Vulnerabilities)
+	}
+	for typ, severity := range want {
+		if types[typ] != severity {
+			t.Fatalf("expected %s=%s, got %+v", typ, severity, types)
+		}
+	}
+}
+
+func TestPortAuditorUDPSockets(t *testing.T) {
+	root := t.TempDir()
+	udp := procNetHeader +
+		// DHCP客户端 0.0.0.0:68
+		"   0: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2001 2 0\n" +
+		// mDNS 0.0.0.0:5353
+		"   1: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000   101        0 2002 2 0\n" +
+		// 本地DNS 127.0.0.53:53
+		"   2: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   102        0 2003 2 0\n" +
+		// 已连接的客户端套接字
+		"   3: 0A00000A:9C40 0B00000A:0035 01 00000000:00000000 00:00000000 00000000     0        0 2004 2 0\n" +
+		"   4: 00000000:00A1 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 2005 2 0\n"
+	if err := os.MkdirAll(filepath.Join(root, "net"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "net", "udp"), []byte(udp), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	auditor := NewPortAuditor(PortAuditConfig{ProcRoot: root}, zap.NewNop())
+	listeners, err := auditor.Audit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 4 {
+		t.Fatalf("expected 4 unconnected udp sockets, got %+v", listeners)
+	}
+	verdicts := map[int]string{}
+	for _, listener := range listeners {
+		verdicts[listener.Port] = listener.Verdict
+	}
+	if verdicts[68] != ListenerAllowed || verdicts[5353] != ListenerAllowed || verdicts[53] != ListenerLocal {
+		t.Fatalf("unexpected verdicts: %+v", verdicts)
+	}
+
+	result := auditor.Score(&AssessmentInput{})
+	if len(result.Vulnerabilities) != 1 || result.Vulnerabilities[0].Type != "port_unexpected:udp/161" {
+		t.Fatalf("expected only udp/161 to be reported, got %+v", result.Vulnerabilities)
+	}
+}
+
+func TestParseHexAddress(t *testing.T) {
+	cases := map[string]string{
+		"0100007F:1F41":                         "127.0.0.1",
+		"00000000000000000000000001000000:1F41": "::1",
+		"0000000000000000FFFF00000100007F:1F41": "127.0.0.1",
+	}
+	for raw, want := range cases {
+		address, port, err := parseHexAddress(raw)
+		if err != nil || address != want || port != 8001 {
+			t.Errorf("parseHexAddress(%s) = %s, %d, %v", raw, address, port, err)
+		}
+	}
+}
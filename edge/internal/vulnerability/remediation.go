@@ -0,0 +1,371 @@
+/*
+ * 漏洞处置剧本
+ * 按配置将漏洞事件类型（或评分扣分项）映射为处置动作，自动执行或等待运维确认，
+ * 处置记录保存在本地并随评估结果同步到Cloud
+ */
+package vulnerability
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 处置模式
+const (
+	RemediationModeAuto    = "auto"    // 自动执行
+	RemediationModeConfirm = "confirm" // 运维确认后执行
+)
+
+// 处置状态
+const (
+	RemediationPending   = "pending"
+	RemediationSucceeded = "succeeded"
+	RemediationFailed    = "failed"
+	RemediationRejected  = "rejected"
+)
+
+// 默认参数
+const (
+	defaultRemediationCooldown = time.Hour
+	// remediationReportWindow 随评估结果上报的处置记录时间范围
+	remediationReportWindow = 24 * time.Hour
+	remediationReportLimit  = 50
+)
+
+// Playbook 处置剧本条目
+type Playbook struct {
+	EventType string            // 漏洞事件类型或扣分项；带":"后缀的事件类型（如firmware:xxx）按前缀匹配
+	Action    string            // 处置动作名称
+	Mode      string            // auto/confirm，默认confirm
+	Cooldown  time.Duration     // 同一事件同一动作的最小间隔，默认1小时
+	Params    map[string]string // 动作参数
+}
+
+// matches 判断事件类型是否匹配剧本
+func (p Playbook) matches(eventType string) bool {
+	return eventType == p.EventType || strings.HasPrefix(eventType, p.EventType+":")
+}
+
+// RemediationTrigger 触发处置的漏洞事件或扣分项
+type RemediationTrigger struct {
+	Type     string
+	Severity string
+	DeviceID string
+	Title    string
+}
+
+// RemediationAction 处置动作
+type RemediationAction interface {
+	// Name 动作名称，与剧本中的action对应
+	Name() string
+	// Execute 执行处置，返回结果说明
+	Execute(trigger RemediationTrigger, params map[string]string) (string, error)
+}
+
+// RemediationEngine 漏洞处置引擎
+type RemediationEngine struct {
+	db        *sql.DB
+	logger    *zap.Logger
+	playbooks []Playbook
+
+	mu      sync.RWMutex
+	actions map[string]RemediationAction
+
+	// 同一时间只执行一个处置，避免评估与人工确认并发处理同一记录
+	execMu sync.Mutex
+}
+
+// NewRemediationEngine 创建漏洞处置引擎
+func NewRemediationEngine(db *sql.DB, playbooks []Playbook, logger *zap.Logger) *RemediationEngine {
+	normalized := make([]Playbook, 0, len(playbooks))
+	for _, p := range playbooks {
+		if p.EventType == "" || p.Action == "" {
+			continue
+		}
+		if p.Mode != RemediationModeAuto {
+			p.Mode = RemediationModeConfirm
+		}
+		if p.Cooldown <= 0 {
+			p.Cooldown = defaultRemediationCooldown
+		}
+		normalized = append(normalized, p)
+	}
+	return &RemediationEngine{
+		db:        db,
+		logger:    logger,
+		playbooks: normalized,
+		actions:   make(map[string]RemediationAction),
+	}
+}
+
+// RegisterAction 注册处置动作，同名动作会被替换
+func (e *RemediationEngine) RegisterAction(action RemediationAction) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.actions[action.Name()] = action
+}
+
+func (e *RemediationEngine) action(name string) RemediationAction {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.actions[name]
+}
+
+// Handle 按剧本处理本次评估发现的事件：自动模式立即执行，确认模式记录为待确认
+func (e *RemediationEngine) Handle(triggers []RemediationTrigger) {
+	for _, trigger := range triggers {
+		for _, playbook := range e.playbooks {
+			if !playbook.matches(trigger.Type) {
+				continue
+			}
+			if e.action(playbook.Action) == nil {
+				e.logger.Warn("处置剧本引用了未注册的动作",
+					zap.String("event_type", playbook.EventType),
+					zap.String("action", playbook.Action))
+				continue
+			}
+
+			cooling, err := e.coolingDown(trigger, playbook)
+			if err != nil {
+				e.logger.Warn("查询处置记录失败", zap.Error(err))
+				continue
+			}
+			if cooling {
+				continue
+			}
+
+			record := &models.RemediationRecord{
+				EventType: trigger.Type,
+				Severity:  trigger.Severity,
+				DeviceID:  trigger.DeviceID,
+				Action:    playbook.Action,
+				Mode:      playbook.Mode,
+				Status:    RemediationPending,
+				CreatedAt: time.Now(),
+			}
+			if err := e.insert(record); err != nil {
+				e.logger.Error("保存处置记录失败", zap.Error(err))
+				continue
+			}
+
+			if playbook.Mode == RemediationModeAuto {
+				e.execMu.Lock()
+				e.execute(record, playbook.Params, "system")
+				e.execMu.Unlock()
+			} else {
+				e.logger.Info("处置等待确认",
+					zap.Int64("id", record.ID),
+					zap.String("event_type", record.EventType),
+					zap.String("action", record.Action))
+			}
+		}
+	}
+}
+
+// Confirm 确认并执行待处置记录
+func (e *RemediationEngine) Confirm(id int64, operator string) (*models.RemediationRecord, error) {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+
+	record, err := e.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != RemediationPending {
+		return nil, fmt.Errorf("处置记录%d状态为%s，无法确认", id, record.Status)
+	}
+
+	params := map[string]string{}
+	for _, playbook := range e.playbooks {
+		if playbook.Action == record.Action && playbook.matches(record.EventType) {
+			params = playbook.Params
+			break
+		}
+	}
+	if e.action(record.Action) == nil {
+		return nil, fmt.Errorf("处置动作未注册: %s", record.Action)
+	}
+
+	e.execute(record, params, operator)
+	return record, nil
+}
+
+// Reject 拒绝待处置记录
+func (e *RemediationEngine) Reject(id int64, operator, reason string) (*models.RemediationRecord, error) {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+
+	record, err := e.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != RemediationPending {
+		return nil, fmt.Errorf("处置记录%d状态为%s，无法拒绝", id, record.Status)
+	}
+
+	record.Status = RemediationRejected
+	record.Result = reason
+	record.ConfirmedBy = operator
+	if err := e.update(record); err != nil {
+		return nil, err
+	}
+	e.logger.Info("处置已拒绝", zap.Int64("id", id), zap.String("operator", operator))
+	return record, nil
+}
+
+// execute 执行处置并保存结果（调用方持有execMu）
+func (e *RemediationEngine) execute(record *models.RemediationRecord, params map[string]string, operator string) {
+	trigger := RemediationTrigger{Type: record.EventType, Severity: record.Severity, DeviceID: record.DeviceID}
+	result, err := e.action(record.Action).Execute(trigger, params)
+
+	now := time.Now()
+	record.ExecutedAt = &now
+	record.ConfirmedBy = operator
+	record.Result = result
+	record.Status = RemediationSucceeded
+	if err != nil {
+		record.Status = RemediationFailed
+		record.Result = err.Error()
+	}
+	if err := e.update(record); err != nil {
+		e.logger.Error("保存处置结果失败", zap.Int64("id", record.ID), zap.Error(err))
+	}
+
+	e.logger.Info("处置已执行",
+		zap.Int64("id", record.ID),
+		zap.String("event_type", record.EventType),
+		zap.String("action", record.Action),
+		zap.String("status", record.Status),
+		zap.String("result", record.Result))
+}
+
+// coolingDown 同一事件（同一设备）同一动作存在待确认记录或冷却期内已处置时跳过
+func (e *RemediationEngine) coolingDown(trigger RemediationTrigger, playbook Playbook) (bool, error) {
+	var count int
+	err := e.db.QueryRow(`
+		SELECT COUNT(*) FROM remediation_actions
+		WHERE event_type = ? AND device_id = ? AND action = ? AND (status = ? OR created_at >= ?)`,
+		trigger.Type, trigger.DeviceID, playbook.Action, RemediationPending, time.Now().Add(-playbook.Cooldown),
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (e *RemediationEngine) insert(record *models.RemediationRecord) error {
+	result, err := e.db.Exec(`
+		INSERT INTO remediation_actions (event_type, severity, device_id, action, mode, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.EventType, record.Severity, record.DeviceID, record.Action, record.Mode, record.Status,
+		record.CreatedAt, record.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	record.ID, err = result.LastInsertId()
+	return err
+}
+
+func (e *RemediationEngine) update(record *models.RemediationRecord) error {
+	_, err := e.db.Exec(`
+		UPDATE remediation_actions
+		SET status = ?, result = ?, confirmed_by = ?, executed_at = ?, updated_at = ?
+		WHERE id = ?`,
+		record.Status, record.Result, record.ConfirmedBy, record.ExecutedAt, time.Now(), record.ID,
+	)
+	return err
+}
+
+const remediationColumns = `id, event_type, severity, device_id, action, mode, status, result, confirmed_by, created_at, executed_at`
+
+func scanRemediation(scanner interface{ Scan(...interface{}) error }) (*models.RemediationRecord, error) {
+	var record models.RemediationRecord
+	var executedAt sql.NullTime
+	if err := scanner.Scan(&record.ID, &record.EventType, &record.Severity, &record.DeviceID, &record.Action,
+		&record.Mode, &record.Status, &record.Result, &record.ConfirmedBy, &record.CreatedAt, &executedAt); err != nil {
+		return nil, err
+	}
+	if executedAt.Valid {
+		record.ExecutedAt = &executedAt.Time
+	}
+	return &record, nil
+}
+
+// Get 获取处置记录
+func (e *RemediationEngine) Get(id int64) (*models.RemediationRecord, error) {
+	record, err := scanRemediation(e.db.QueryRow(`SELECT `+remediationColumns+` FROM remediation_actions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("处置记录不存在: %d", id)
+	}
+	return record, err
+}
+
+// List 查询处置记录，status为空时返回全部状态，since为零值时不限时间
+func (e *RemediationEngine) List(status string, since time.Time, limit int) ([]models.RemediationRecord, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `SELECT ` + remediationColumns + ` FROM remediation_actions WHERE updated_at >= ?`
+	args := []interface{}{since}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.RemediationRecord{}
+	for rows.Next() {
+		record, err := scanRemediation(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	return records, rows.Err()
+}
+
+// Recent 近期有变化的处置记录（随评估结果上报，Cloud按记录ID更新）
+func (e *RemediationEngine) Recent() []models.RemediationRecord {
+	records, err := e.List("", time.Now().Add(-remediationReportWindow), remediationReportLimit)
+	if err != nil {
+		e.logger.Warn("查询近期处置记录失败", zap.Error(err))
+		return nil
+	}
+	return records
+}
+
+// remediationTriggers 汇总漏洞事件及有扣分的评分项作为处置触发条件（同一类型只取一次）
+func remediationTriggers(vulnerabilities []models.VulnerabilityEvent, results []models.ScorerResult) []RemediationTrigger {
+	seen := map[string]bool{}
+	triggers := []RemediationTrigger{}
+	for _, vuln := range vulnerabilities {
+		key := vuln.Type + "|" + vuln.DeviceID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		triggers = append(triggers, RemediationTrigger{Type: vuln.Type, Severity: vuln.Severity, DeviceID: vuln.DeviceID, Title: vuln.Title})
+	}
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			key := finding.Item + "|"
+			if finding.Penalty <= 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			triggers = append(triggers, RemediationTrigger{Type: finding.Item, Title: finding.Message})
+		}
+	}
+	return triggers
+}
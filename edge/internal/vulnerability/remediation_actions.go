@@ -0,0 +1,206 @@
+/*
+ * 内置处置动作
+ * 封禁来源IP、禁用设备、清理存储、轮转日志文件、请求Cloud重新下发许可证
+ */
+package vulnerability
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+// 内置处置动作名称
+const (
+	ActionBlockIP        = "block_ip"
+	ActionDisableDevice  = "disable_device"
+	ActionCleanupStorage = "cleanup_storage"
+	ActionRotateLogs     = "rotate_logs"
+	ActionRefreshLicense = "refresh_license"
+)
+
+// AuthController 认证锁定及会话控制（auth.Service）
+type AuthController interface {
+	ListLockouts() []models.AuthLockout
+	Block(scope, key string, duration time.Duration, reason string) error
+	RevokeDeviceSessions(deviceID, reason string) (int64, error)
+}
+
+// DeviceStatusUpdater 设备状态更新（device.Manager）
+type DeviceStatusUpdater interface {
+	UpdateDeviceStatus(deviceID string, status models.DeviceStatus) error
+}
+
+// StorageCleaner 存储清理（storage.SQLiteDB）
+type StorageCleaner interface {
+	CleanOldData(retentionDays int) error
+	CleanSystemLogs(retentionDays int) (int64, error)
+}
+
+// LicenseRefresher 请求Cloud重新下发许可证（sync.CloudSync）
+type LicenseRefresher interface {
+	RequestLicenseRefresh() error
+}
+
+// remediationActionFunc 函数形式的处置动作
+type remediationActionFunc struct {
+	name string
+	fn   func(trigger RemediationTrigger, params map[string]string) (string, error)
+}
+
+func (a *remediationActionFunc) Name() string { return a.name }
+
+func (a *remediationActionFunc) Execute(trigger RemediationTrigger, params map[string]string) (string, error) {
+	return a.fn(trigger, params)
+}
+
+// NewBlockIPAction 封禁认证失败的来源IP
+// 参数: duration 封禁时长（默认1h）, min_failures 未被锁定过的IP达到该失败次数也封禁（默认5）
+func NewBlockIPAction(auth AuthController) RemediationAction {
+	return &remediationActionFunc{name: ActionBlockIP, fn: func(trigger RemediationTrigger, params map[string]string) (string, error) {
+		duration := paramDuration(params, "duration", time.Hour)
+		minFailures := paramInt(params, "min_failures", 5)
+
+		var blocked []string
+		for _, lockout := range auth.ListLockouts() {
+			if lockout.Scope != "ip" || (lockout.LockCount == 0 && lockout.Failures < minFailures) {
+				continue
+			}
+			if err := auth.Block("ip", lockout.Key, duration, "漏洞处置: "+trigger.Type); err != nil {
+				return "", err
+			}
+			blocked = append(blocked, lockout.Key)
+		}
+		if len(blocked) == 0 {
+			return "未发现需要封禁的来源IP", nil
+		}
+		return fmt.Sprintf("已封禁来源IP %s: %s", duration, strings.Join(blocked, ", ")), nil
+	}}
+}
+
+// NewDisableDeviceAction 禁用事件关联的设备并撤销其会话
+// 只处置关联了设备的事件；认证失败锁定按设备及来源IP计数，不能据此判断设备本身失陷，因此不据锁定记录禁用设备
+func NewDisableDeviceAction(auth AuthController, devices DeviceStatusUpdater) RemediationAction {
+	return &remediationActionFunc{name: ActionDisableDevice, fn: func(trigger RemediationTrigger, params map[string]string) (string, error) {
+		if trigger.DeviceID == "" {
+			return "事件未关联设备，不禁用设备", nil
+		}
+		if err := devices.UpdateDeviceStatus(trigger.DeviceID, models.DeviceStatusDisabled); err != nil {
+			return "", fmt.Errorf("禁用设备失败: %s(%v)", trigger.DeviceID, err)
+		}
+		if _, err := auth.RevokeDeviceSessions(trigger.DeviceID, "漏洞处置: "+trigger.Type); err != nil {
+			return "", fmt.Errorf("设备已禁用，撤销会话失败: %s(%v)", trigger.DeviceID, err)
+		}
+		return "已禁用设备并撤销会话: " + trigger.DeviceID, nil
+	}}
+}
+
+// NewCleanupStorageAction 清理过期数据及系统日志。参数: retention_days 保留天数（默认30）
+func NewCleanupStorageAction(storage StorageCleaner) RemediationAction {
+	return &remediationActionFunc{name: ActionCleanupStorage, fn: func(trigger RemediationTrigger, params map[string]string) (string, error) {
+		days := paramInt(params, "retention_days", 30)
+		if err := storage.CleanOldData(days); err != nil {
+			return "", err
+		}
+		logs, err := storage.CleanSystemLogs(days)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("已清理%d天前的已同步数据，删除系统日志%d条", days, logs), nil
+	}}
+}
+
+// NewRotateLogsAction 轮转日志文件：复制为.1并截断原文件（日志器以追加方式写入，无需重新打开）
+// 参数: keep 保留的历史文件数（默认3）
+func NewRotateLogsAction(files []string) RemediationAction {
+	return &remediationActionFunc{name: ActionRotateLogs, fn: func(trigger RemediationTrigger, params map[string]string) (string, error) {
+		keep := paramInt(params, "keep", 3)
+		var rotated []string
+		var freed int64
+		for _, file := range files {
+			size, err := rotateLogFile(file, keep)
+			if err != nil {
+				return "", fmt.Errorf("轮转%s失败: %w", file, err)
+			}
+			if size > 0 {
+				rotated = append(rotated, file)
+				freed += size
+			}
+		}
+		if len(rotated) == 0 {
+			return "没有需要轮转的日志文件", nil
+		}
+		return fmt.Sprintf("已轮转%s，当前日志释放%.1fMB", strings.Join(rotated, ", "), float64(freed)/1024/1024), nil
+	}}
+}
+
+// NewRefreshLicenseAction 请求Cloud重新下发许可证
+func NewRefreshLicenseAction(refresher LicenseRefresher) RemediationAction {
+	return &remediationActionFunc{name: ActionRefreshLicense, fn: func(trigger RemediationTrigger, params map[string]string) (string, error) {
+		if err := refresher.RequestLicenseRefresh(); err != nil {
+			return "", err
+		}
+		return "已请求Cloud重新下发许可证", nil
+	}}
+}
+
+// rotateLogFile 依次后移历史文件（超出keep的删除），将当前文件复制为.1后截断，返回原文件大小
+func rotateLogFile(path string, keep int) (int64, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil || info.Size() == 0 {
+		return 0, err
+	}
+	if keep < 1 {
+		keep = 1
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", path, keep))
+	for i := keep - 1; i >= 1; i-- {
+		older := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(older); err == nil {
+			if err := os.Rename(older, fmt.Sprintf("%s.%d", path, i+1)); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".1", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return 0, err
+	}
+	if err := dst.Close(); err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Truncate(path, 0)
+}
+
+func paramInt(params map[string]string, key string, def int) int {
+	if value, err := strconv.Atoi(params[key]); err == nil && value > 0 {
+		return value
+	}
+	return def
+}
+
+func paramDuration(params map[string]string, key string, def time.Duration) time.Duration {
+	if value, err := time.ParseDuration(params[key]); err == nil && value > 0 {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,155 @@
+package vulnerability
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+type fakeAuth struct {
+	lockouts  []models.AuthLockout
+	blocked   []string
+	revoked   []string
+	revokeErr error
+}
+
+func (f *fakeAuth) ListLockouts() []models.AuthLockout { return f.lockouts }
+
+func (f *fakeAuth) Block(scope, key string, duration time.Duration, reason string) error {
+	f.blocked = append(f.blocked, scope+":"+key)
+	return nil
+}
+
+func (f *fakeAuth) RevokeDeviceSessions(deviceID, reason string) (int64, error) {
+	if f.revokeErr != nil {
+		return 0, f.revokeErr
+	}
+	f.revoked = append(f.revoked, deviceID)
+	return 1, nil
+}
+
+type fakeDevices struct{ disabled []string }
+
+func (f *fakeDevices) UpdateDeviceStatus(deviceID string, status models.DeviceStatus) error {
+	f.disabled = append(f.disabled, deviceID+"="+string(status))
+	return nil
+}
+
+func TestRemediationEnginePlaybooks(t *testing.T) {
+	logger := zap.NewNop()
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:             "sqlite3",
+		Path:               filepath.Join(t.TempDir(), "edge.db"),
+		MaxConnections:     1,
+		MaxIdleConnections: 1,
+	}, logger)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	auth := &fakeAuth{lockouts: []models.AuthLockout{
+		{Scope: "ip", Key: "10.0.0.9", LockCount: 1},
+		{Scope: "ip", Key: "10.0.0.10", Failures: 2},
+		{Scope: "device", Key: "sensor-8", LockCount: 3},
+	}}
+	devices := &fakeDevices{}
+
+	engine := NewRemediationEngine(db.GetDB(), []Playbook{
+		{EventType: "port_scan", Action: ActionBlockIP, Mode: RemediationModeAuto, Params: map[string]string{"duration": "30m"}},
+		{EventType: "port_scan", Action: ActionDisableDevice},
+		{EventType: "license_expiry", Action: "unknown_action", Mode: RemediationModeAuto},
+	}, logger)
+	engine.RegisterAction(NewBlockIPAction(auth))
+	engine.RegisterAction(NewDisableDeviceAction(auth, devices))
+
+	triggers := remediationTriggers(
+		[]models.VulnerabilityEvent{{Type: "port_scan", Severity: "medium", DeviceID: "sensor-7"}, {Type: "port_scan", Severity: "high", DeviceID: "sensor-7"}},
+		[]models.ScorerResult{{Findings: []models.ScoreFinding{{Item: "license_expiry", Penalty: 10}}}},
+	)
+	if len(triggers) != 2 {
+		t.Fatalf("expected port_scan and license_expiry triggers, got %+v", triggers)
+	}
+
+	engine.Handle(triggers)
+	// 冷却期内重复评估不再处置
+	engine.Handle(triggers)
+
+	if len(auth.blocked) != 1 || auth.blocked[0] != "ip:10.0.0.9" {
+		t.Fatalf("expected only the locked IP to be blocked once, got %v", auth.blocked)
+	}
+	if len(devices.disabled) != 0 {
+		t.Fatalf("confirm-mode action must not run before confirmation: %v", devices.disabled)
+	}
+
+	records, err := engine.List("", time.Time{}, 10)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("expected 2 records, got %+v (%v)", records, err)
+	}
+	pending, err := engine.List(RemediationPending, time.Time{}, 10)
+	if err != nil || len(pending) != 1 || pending[0].Action != ActionDisableDevice {
+		t.Fatalf("expected pending disable_device, got %+v (%v)", pending, err)
+	}
+
+	record, err := engine.Confirm(pending[0].ID, "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != RemediationSucceeded || record.ConfirmedBy != "operator" || record.ExecutedAt == nil {
+		t.Fatalf("unexpected confirmed record: %+v", record)
+	}
+	if len(devices.disabled) != 1 || devices.disabled[0] != "sensor-7=disabled" || len(auth.revoked) != 1 {
+		t.Fatalf("expected sensor-7 disabled with sessions revoked, got %v %v", devices.disabled, auth.revoked)
+	}
+	if _, err := engine.Confirm(pending[0].ID, "operator"); err == nil {
+		t.Fatal("confirming an executed record should fail")
+	}
+
+	if recent := engine.Recent(); len(recent) != 2 || recent[0].Status != RemediationSucceeded {
+		t.Fatalf("unexpected recent records: %+v", recent)
+	}
+
+	// 未关联设备的事件不按锁定记录禁用设备
+	if _, err := NewDisableDeviceAction(auth, devices).Execute(RemediationTrigger{Type: "port_scan"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(devices.disabled) != 1 || len(auth.revoked) != 1 {
+		t.Fatalf("locked-out device must not be disabled without a device event: %v %v", devices.disabled, auth.revoked)
+	}
+
+	// 撤销会话失败时动作失败，不能报告处置成功
+	auth.revokeErr = errors.New("database is locked")
+	if _, err := NewDisableDeviceAction(auth, devices).Execute(RemediationTrigger{Type: "port_scan", DeviceID: "sensor-9"}, nil); err == nil {
+		t.Fatal("expected revoke failure to be returned")
+	}
+}
+
+func TestRotateLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "edge.log")
+	for i, content := range []string{"first\n", "second\n", "third\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		size, err := rotateLogFile(path, 2)
+		if err != nil || size != int64(len(content)) {
+			t.Fatalf("rotation %d: size=%d err=%v", i, size, err)
+		}
+	}
+
+	info, _ := os.Stat(path)
+	newest, _ := os.ReadFile(path + ".1")
+	older, _ := os.ReadFile(path + ".2")
+	if info.Size() != 0 || string(newest) != "third\n" || string(older) != "second\n" {
+		t.Fatalf("unexpected rotation result: size=%d .1=%q .2=%q", info.Size(), newest, older)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("backups beyond keep should be removed")
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * 评分器框架
+ * 评分器通过注册表接入评估流程，权重和启用状态可由配置覆盖
+ */
+package vulnerability
+
+import (
+	"sync"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+// 内置评分器名称（同时作为配置中的键）
+const (
+	ScorerLicense       = "license"
+	ScorerCommunication = "communication"
+	ScorerConfig        = "config_security"
+	ScorerData          = "data_anomaly"
+	ScorerDetector      = "detector"
+)
+
+// AssessmentInput 单次评估中各评分器共享的输入
+type AssessmentInput struct {
+	CabinetID string
+	Metrics   *models.TransmissionMetrics // 传输指标（MQTT统计或通信评分器自带统计）
+}
+
+// ScoreResult 评分器的评估结果
+type ScoreResult struct {
+	Score           float64
+	Findings        []models.ScoreFinding
+	Vulnerabilities []models.VulnerabilityEvent // 检测到的漏洞，按严重程度从综合评分中扣分
+}
+
+// Scorer 脆弱性评分器
+type Scorer interface {
+	Name() string
+	Score(input *AssessmentInput) *ScoreResult
+}
+
+// ScorerSetting 评分器配置
+type ScorerSetting struct {
+	Enabled *bool   // 为空时启用
+	Weight  float64 // 加权权重，<=0时使用注册时的默认权重
+}
+
+// ScorerInfo 已注册评分器的状态
+type ScorerInfo struct {
+	Name    string  `json:"name"`
+	Weight  float64 `json:"weight"`
+	Enabled bool    `json:"enabled"`
+}
+
+type scorerEntry struct {
+	scorer  Scorer
+	weight  float64
+	enabled bool
+}
+
+// ScorerRegistry 评分器注册表，按注册顺序执行
+type ScorerRegistry struct {
+	mu       sync.RWMutex
+	entries  []*scorerEntry
+	settings map[string]ScorerSetting
+}
+
+// NewScorerRegistry 创建评分器注册表，settings按评分器名称覆盖权重和启用状态
+func NewScorerRegistry(settings map[string]ScorerSetting) *ScorerRegistry {
+	if settings == nil {
+		settings = map[string]ScorerSetting{}
+	}
+	return &ScorerRegistry{settings: settings}
+}
+
+// Register 注册评分器，weight为默认权重（0表示只贡献发现和漏洞，不参与加权）
+// 同名评分器会被替换
+func (r *ScorerRegistry) Register(scorer Scorer, weight float64) {
+	entry := &scorerEntry{scorer: scorer, weight: weight, enabled: true}
+	if setting, ok := r.settings[scorer.Name()]; ok {
+		if setting.Enabled != nil {
+			entry.enabled = *setting.Enabled
+		}
+		if setting.Weight > 0 {
+			entry.weight = setting.Weight
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.entries {
+		if e.scorer.Name() == scorer.Name() {
+			r.entries[i] = entry
+			return
+		}
+	}
+	r.entries = append(r.entries, entry)
+}
+
+// Enabled 判断评分器是否已注册并启用
+func (r *ScorerRegistry) Enabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, e := range r.entries {
+		if e.scorer.Name() == name {
+			return e.enabled
+		}
+	}
+	return false
+}
+
+// List 返回已注册评分器的状态
+func (r *ScorerRegistry) List() []ScorerInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	infos := make([]ScorerInfo, 0, len(r.entries))
+	for _, e := range r.entries {
+		infos = append(infos, ScorerInfo{Name: e.scorer.Name(), Weight: e.weight, Enabled: e.enabled})
+	}
+	return infos
+}
+
+// Run 依次执行已启用的评分器，返回各评分器结果（权重未归一化）及检测到的漏洞
+func (r *ScorerRegistry) Run(input *AssessmentInput) ([]models.ScorerResult, []models.VulnerabilityEvent) {
+	r.mu.RLock()
+	entries := make([]*scorerEntry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.RUnlock()
+
+	results := []models.ScorerResult{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+	for _, e := range entries {
+		if !e.enabled {
+			continue
+		}
+		res := e.scorer.Score(input)
+		if res == nil {
+			continue
+		}
+		findings := res.Findings
+		if findings == nil {
+			findings = []models.ScoreFinding{}
+		}
+		results = append(results, models.ScorerResult{
+			Name:     e.scorer.Name(),
+			Score:    clampScore(res.Score),
+			Weight:   e.weight,
+			Findings: findings,
+		})
+		vulnerabilities = append(vulnerabilities, res.Vulnerabilities...)
+	}
+	return results, vulnerabilities
+}
+
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
@@ -0,0 +1,62 @@
+package vulnerability
+
+import (
+	"math"
+	"testing"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+type fixedScorer struct {
+	name  string
+	score float64
+	vulns []models.VulnerabilityEvent
+}
+
+func (s *fixedScorer) Name() string { return s.name }
+
+func (s *fixedScorer) Score(input *AssessmentInput) *ScoreResult {
+	return &ScoreResult{
+		Score:           s.score,
+		Findings:        []models.ScoreFinding{{Item: "fixed", Penalty: 100 - s.score}},
+		Vulnerabilities: s.vulns,
+	}
+}
+
+func TestScorerRegistryAggregation(t *testing.T) {
+	disabled := false
+	registry := NewScorerRegistry(map[string]ScorerSetting{
+		ScorerConfig: {Enabled: &disabled},
+		"custom":     {Weight: 3},
+	})
+	registry.Register(&fixedScorer{name: ScorerLicense, score: 80}, 1)
+	registry.Register(&fixedScorer{name: ScorerConfig, score: 0}, 1)
+	registry.Register(&fixedScorer{name: "custom", score: 40}, 1)
+	registry.Register(&fixedScorer{name: ScorerDetector, score: 90, vulns: []models.VulnerabilityEvent{
+		{Type: "storage_warning", Severity: "medium"},
+	}}, 0)
+
+	if registry.Enabled(ScorerConfig) {
+		t.Fatal("config scorer should be disabled by settings")
+	}
+
+	results, vulns := registry.Run(&AssessmentInput{CabinetID: "CABINET-001"})
+	if len(results) != 3 || len(vulns) != 1 {
+		t.Fatalf("unexpected run output: %d results, %d vulnerabilities", len(results), len(vulns))
+	}
+
+	report := NewAggregator(VulnerabilityConfig{}, zap.NewNop()).AggregateScores("CABINET-001", results, nil, vulns, nil)
+
+	// (80*1 + 40*3) / 4 = 50，检测器权重为0不参与加权，medium漏洞扣5分
+	if math.Abs(report.OverallScore-45) > 1e-9 {
+		t.Fatalf("expected overall score 45, got %v", report.OverallScore)
+	}
+	if report.LicenseComplianceScore != 80 || report.ConfigSecurityScore != 100 {
+		t.Fatalf("unexpected legacy dimension scores: license=%v config=%v",
+			report.LicenseComplianceScore, report.ConfigSecurityScore)
+	}
+	if len(report.ScorerResults) != 3 || report.ScorerResults[1].Name != "custom" || report.ScorerResults[1].Weight != 3 {
+		t.Fatalf("unexpected scorer results: %+v", report.ScorerResults)
+	}
+}
@@ -40,6 +40,24 @@ type Service struct {
 	detector      *Detector
 	aggregator    *Aggregator
 
+	// 评分器注册表（内置评分器及扩展评分器）
+	scorers *ScorerRegistry
+
+	// 固件漏洞目录（可选）
+	firmwareCatalog *FirmwareCatalog
+
+	// 配置漂移监测（可选）
+	driftMonitor *DriftMonitor
+
+	// TLS安全态势检查（可选）
+	tlsScorer *TLSScorer
+
+	// 监听端口自查（可选）
+	portAuditor *PortAuditor
+
+	// 漏洞处置剧本（可选）
+	remediation *RemediationEngine
+
 	// MQTT统计(可选)
 	mqttStats MQTTStatsProvider
 
@@ -98,6 +116,9 @@ type VulnerabilityConfig struct {
 		AlertFrequencyThreshold int
 	}
 
+	// 按评分器名称覆盖权重和启用状态
+	Scorers map[string]ScorerSetting
+
 	// 用于配置检查的参数
 	MQTTBroker string
 	ServerHost string
@@ -140,9 +161,110 @@ func NewService(
 	s.detector = NewDetector(cfg, db, logger)
 	s.aggregator = NewAggregator(cfg, logger)
 
+	// 注册内置评分器 (四维度默认权重: 30%, 25%, 20%, 25%；漏洞检测只按严重程度扣分)
+	s.scorers = NewScorerRegistry(cfg.Scorers)
+	s.scorers.Register(s.licenseScorer, 0.30)
+	s.scorers.Register(s.commScorer, defaultWeight(cfg.Weights.Communication, 0.25))
+	s.scorers.Register(s.configScorer, defaultWeight(cfg.Weights.ConfigSecurity, 0.20))
+	s.scorers.Register(s.dataScorer, defaultWeight(cfg.Weights.DataAnomaly, 0.25))
+	s.scorers.Register(s.detector, 0)
+
 	return s
 }
 
+// defaultWeight 旧版Weights配置优先，未配置时使用默认权重
+func defaultWeight(configured, fallback float64) float64 {
+	if configured > 0 {
+		return configured
+	}
+	return fallback
+}
+
+// RegisterScorer 注册扩展评分器，同名评分器会被替换，配置中的Scorers设置同样生效
+func (s *Service) RegisterScorer(scorer Scorer, weight float64) {
+	s.scorers.Register(scorer, weight)
+}
+
+// SetFirmwareCatalog 设置固件漏洞目录，并注册按设备清单匹配的固件漏洞评分器
+func (s *Service) SetFirmwareCatalog(catalog *FirmwareCatalog) {
+	s.firmwareCatalog = catalog
+	if catalog != nil && s.deviceManager != nil {
+		// 与漏洞检测相同，只按漏洞严重程度扣分
+		s.scorers.Register(NewFirmwareScorer(catalog, s.deviceManager, s.logger), 0)
+	}
+}
+
+// GetFirmwareCatalog 获取固件漏洞目录
+func (s *Service) GetFirmwareCatalog() *FirmwareCatalog {
+	return s.firmwareCatalog
+}
+
+// SetDriftMonitor 设置配置漂移监测器，注册漂移评分器并替代检测器中按修改时间的篡改检查
+func (s *Service) SetDriftMonitor(monitor *DriftMonitor) {
+	s.driftMonitor = monitor
+	if monitor != nil {
+		s.detector.driftMonitored = true
+		// 只按漂移及签名异常扣分
+		s.scorers.Register(monitor, 0)
+	}
+}
+
+// GetDriftMonitor 获取配置漂移监测器
+func (s *Service) GetDriftMonitor() *DriftMonitor {
+	return s.driftMonitor
+}
+
+// SetTLSScorer 设置TLS安全态势评分器，证书状态随评估结果上报
+func (s *Service) SetTLSScorer(scorer *TLSScorer) {
+	s.tlsScorer = scorer
+	if scorer != nil {
+		// 只按证书及协议问题的严重程度扣分
+		s.scorers.Register(scorer, 0)
+	}
+}
+
+// GetTLSScorer 获取TLS安全态势评分器
+func (s *Service) GetTLSScorer() *TLSScorer {
+	return s.tlsScorer
+}
+
+// SetPortAuditor 设置监听端口自查，按实际监听的套接字核对配置推导的白名单
+func (s *Service) SetPortAuditor(auditor *PortAuditor) {
+	s.portAuditor = auditor
+	if auditor != nil {
+		// 只按非预期监听及调试端口的严重程度扣分
+		s.scorers.Register(auditor, 0)
+	}
+}
+
+// GetPortAuditor 获取监听端口自查
+func (s *Service) GetPortAuditor() *PortAuditor {
+	return s.portAuditor
+}
+
+// SetSensorAnomalySource 设置传感器数据异常来源，近期统计异常作为漏洞事件计入评估
+func (s *Service) SetSensorAnomalySource(source SensorAnomalySource) {
+	if source != nil {
+		// 只按异常严重程度扣分
+		s.scorers.Register(NewSensorAnomalyScorer(source), 0)
+	}
+}
+
+// SetRemediationEngine 设置漏洞处置引擎，每次评估后按剧本处置发现的事件
+func (s *Service) SetRemediationEngine(engine *RemediationEngine) {
+	s.remediation = engine
+}
+
+// GetRemediationEngine 获取漏洞处置引擎
+func (s *Service) GetRemediationEngine() *RemediationEngine {
+	return s.remediation
+}
+
+// Scorers 获取已注册评分器的状态
+func (s *Service) Scorers() []ScorerInfo {
+	return s.scorers.List()
+}
+
 // SetMQTTStats 设置MQTT统计数据提供者
 func (s *Service) SetMQTTStats(mqttStats MQTTStatsProvider) {
 	s.mqttStats = mqttStats
@@ -232,32 +354,41 @@ func (s *Service) performAssessment() {
 		metrics = s.commScorer.CollectMetrics()
 	}
 
-	// 2. 计算各维度评分 (四维度)
-	licenseScore := s.licenseScorer.CalculateScore() // 新增: 许可证评分
-	commScore := s.commScorer.CalculateScore(metrics)
-	configScore := s.configScorer.CalculateScore()
-	dataScore := s.dataScorer.CalculateScore(cabinetID)
-
-	// 3. 检测漏洞
-	vulnerabilities := s.detector.DetectVulnerabilities(cabinetID)
+	// 2. 执行已启用的评分器（含漏洞检测）
+	results, vulnerabilities := s.scorers.Run(&AssessmentInput{
+		CabinetID: cabinetID,
+		Metrics:   metrics,
+	})
 
-	// 4. 过滤已消除的漏洞
+	// 3. 过滤已消除的漏洞
 	vulnerabilities = s.filterDismissedVulnerabilities(vulnerabilities)
 
-	// 5. 获取配置检查结果
-	configChecks := s.configScorer.GetCheckResults()
+	// 按处置剧本处置（已消除的漏洞不处置）
+	if s.remediation != nil {
+		s.remediation.Handle(remediationTriggers(vulnerabilities, results))
+	}
+
+	// 4. 获取配置检查结果
+	configChecks := map[string]bool{}
+	if s.scorers.Enabled(ScorerConfig) {
+		configChecks = s.configScorer.GetCheckResults()
+	}
 
-	// 6. 聚合评分 (四维度)
+	// 5. 聚合评分
 	report := s.aggregator.AggregateScores(
 		cabinetID,
-		licenseScore, // 新增参数
-		commScore,
-		configScore,
-		dataScore,
+		results,
 		metrics,
 		vulnerabilities,
 		configChecks,
 	)
+	if s.tlsScorer != nil && s.scorers.Enabled(ScorerTLS) {
+		report.Certificates = s.tlsScorer.Certificates()
+	}
+	if s.remediation != nil {
+		report.Remediations = s.remediation.Recent()
+	}
+	report.DismissedVulnerabilities = s.recentDismissals()
 
 	if s.trafficPublisher != nil {
 		if err := s.trafficPublisher.PublishReport(report); err != nil {
@@ -315,14 +446,15 @@ func (s *Service) saveAssessment(report *models.EdgeVulnerabilityReport) error {
 	featuresJSON, _ := json.Marshal(report.TrafficFeatures)
 	checksJSON, _ := json.Marshal(report.ConfigChecks)
 	vulnJSON, _ := json.Marshal(report.DetectedVulnerabilities)
+	scorerJSON, _ := json.Marshal(report.ScorerResults)
 
 	query := `
 		INSERT INTO vulnerability_assessments (
 			cabinet_id, timestamp, license_compliance_score, communication_score,
 			config_security_score, data_anomaly_score, overall_score, risk_level,
 			transmission_metrics, traffic_features, config_checks,
-			detected_vulnerabilities, synced
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			detected_vulnerabilities, scorer_results, synced
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := s.db.Exec(query,
@@ -338,6 +470,7 @@ func (s *Service) saveAssessment(report *models.EdgeVulnerabilityReport) error {
 		string(featuresJSON),
 		string(checksJSON),
 		string(vulnJSON),
+		string(scorerJSON),
 		false, // synced
 	)
 
@@ -509,6 +642,39 @@ func (s *Service) GetDismissedVulnerabilities() ([]string, error) {
 	return dismissed, nil
 }
 
+// recentDismissals 有效及24小时内过期的消除记录（随评估结果上报，Cloud按记录ID更新）
+func (s *Service) recentDismissals() []models.DismissedVulnerability {
+	rows, err := s.db.Query(`
+		SELECT id, vulnerability_type, COALESCE(reason, ''), COALESCE(dismissed_by, 'system'), dismissed_at, expires_at
+		FROM dismissed_vulnerabilities
+		ORDER BY id DESC
+		LIMIT 200
+	`)
+	if err != nil {
+		s.logger.Warn("查询漏洞消除记录失败", zap.Error(err))
+		return nil
+	}
+	defer rows.Close()
+
+	cutoff := time.Now().Add(-24 * time.Hour)
+	dismissals := []models.DismissedVulnerability{}
+	for rows.Next() {
+		var d models.DismissedVulnerability
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.Type, &d.Reason, &d.DismissedBy, &d.DismissedAt, &expiresAt); err != nil {
+			continue
+		}
+		if expiresAt.Valid {
+			if expiresAt.Time.Before(cutoff) {
+				continue
+			}
+			d.ExpiresAt = &expiresAt.Time
+		}
+		dismissals = append(dismissals, d)
+	}
+	return dismissals
+}
+
 // filterDismissedVulnerabilities 过滤已消除的漏洞
 func (s *Service) filterDismissedVulnerabilities(vulnerabilities []models.VulnerabilityEvent) []models.VulnerabilityEvent {
 	dismissed, err := s.GetDismissedVulnerabilities()
@@ -554,7 +720,7 @@ func (s *Service) GetAssessmentHistory(cabinetID string, startTime, endTime time
 		SELECT id, cabinet_id, timestamp, license_compliance_score, communication_score,
 			config_security_score, data_anomaly_score, overall_score, risk_level,
 			transmission_metrics, traffic_features, config_checks,
-			detected_vulnerabilities, synced, synced_at
+			detected_vulnerabilities, COALESCE(scorer_results, ''), synced, synced_at
 		FROM vulnerability_assessments
 		WHERE cabinet_id = ? AND timestamp >= ? AND timestamp <= ?
 		ORDER BY timestamp DESC
@@ -577,6 +743,7 @@ func (s *Service) GetAssessmentHistory(cabinetID string, startTime, endTime time
 			&a.OverallScore, &a.RiskLevel,
 			&a.TransmissionMetricsJSON, &a.TrafficFeaturesJSON,
 			&a.ConfigChecksJSON, &a.DetectedVulnJSON,
+			&a.ScorerResultsJSON,
 			&a.Synced, &a.SyncedAt,
 		)
 		if err != nil {
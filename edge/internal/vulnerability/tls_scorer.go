@@ -0,0 +1,396 @@
+/*
+ * TLS安全态势评分器
+ * 对Edge HTTPS、MQTT连接及Cloud端点实际握手，检查证书有效期、密钥强度、
+ * 证书链、协议版本及密码套件，证书到期前30/7/1天生成漏洞事件
+ */
+package vulnerability
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ScorerTLS TLS安全态势评分器名称
+const ScorerTLS = "tls"
+
+// defaultTLSCheckInterval 默认检查间隔（证书状态变化缓慢，无需每次评估都握手）
+const defaultTLSCheckInterval = time.Hour
+
+// TLSTarget TLS检查目标
+type TLSTarget struct {
+	Name       string // 目标名称，如edge_https、mqtt_broker、cloud_endpoint
+	Address    string // 握手地址host:port，为空时只检查CertFile
+	ServerName string // SNI及证书主机名校验，为空时使用Address中的主机
+	CertFile   string // 证书文件（PEM，可含中间证书）
+	CAFile     string // 额外信任的CA证书，如Edge CA
+}
+
+// TLSPostureConfig TLS安全态势检查配置
+type TLSPostureConfig struct {
+	Targets       []TLSTarget
+	CheckInterval time.Duration
+	Timeout       time.Duration
+}
+
+// TLSScorer TLS安全态势评分器
+type TLSScorer struct {
+	config TLSPostureConfig
+	logger *zap.Logger
+
+	mu           sync.RWMutex
+	certificates []models.CertificateStatus
+	checkedAt    time.Time
+}
+
+// NewTLSScorer 创建TLS安全态势评分器
+func NewTLSScorer(cfg TLSPostureConfig, logger *zap.Logger) *TLSScorer {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultTLSCheckInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &TLSScorer{config: cfg, logger: logger}
+}
+
+// Name 评分器名称
+func (s *TLSScorer) Name() string {
+	return ScorerTLS
+}
+
+// Certificates 获取最近一次检查的证书状态，到期天数按当前时间重新计算
+func (s *TLSScorer) Certificates() []models.CertificateStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	certificates := make([]models.CertificateStatus, len(s.certificates))
+	copy(certificates, s.certificates)
+	for i := range certificates {
+		if certificates[i].Error == "" {
+			certificates[i].DaysToExpiry = daysUntil(certificates[i].NotAfter, now)
+		}
+	}
+	return certificates
+}
+
+// Refresh 立即重新检查所有目标
+func (s *TLSScorer) Refresh() {
+	certificates := make([]models.CertificateStatus, 0, len(s.config.Targets))
+	for _, target := range s.config.Targets {
+		certificates = append(certificates, s.inspect(target))
+	}
+
+	s.mu.Lock()
+	s.certificates = certificates
+	s.checkedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// Score 按检查结果扣分，超过检查间隔时重新握手
+func (s *TLSScorer) Score(input *AssessmentInput) *ScoreResult {
+	s.mu.RLock()
+	stale := time.Since(s.checkedAt) >= s.config.CheckInterval
+	s.mu.RUnlock()
+	if stale {
+		s.Refresh()
+	}
+	certificates := s.Certificates()
+
+	now := time.Now()
+	score := 100.0
+	findings := []models.ScoreFinding{}
+	vulnerabilities := []models.VulnerabilityEvent{}
+	add := func(cert models.CertificateStatus, kind, severity, title, description, solution string) {
+		penalty := vulnerabilityPenalty(severity)
+		score -= penalty
+		findings = append(findings, models.ScoreFinding{
+			Item:    kind + ":" + cert.Target,
+			Penalty: penalty,
+			Message: title,
+			Evidence: map[string]interface{}{
+				"endpoint":        cert.Endpoint,
+				"subject":         cert.Subject,
+				"not_after":       cert.NotAfter,
+				"days_to_expiry":  cert.DaysToExpiry,
+				"key_algorithm":   cert.KeyAlgorithm,
+				"key_size":        cert.KeySize,
+				"tls_version":     cert.TLSVersion,
+				"cipher_suite":    cert.CipherSuite,
+				"chain_error":     cert.ChainError,
+				"legacy_protocol": cert.LegacyProtocols,
+			},
+		})
+		vulnerabilities = append(vulnerabilities, models.VulnerabilityEvent{
+			Type:        kind + ":" + cert.Target,
+			Category:    "network",
+			Title:       title,
+			Severity:    severity,
+			Description: description,
+			Solution:    solution,
+			DetectedAt:  now,
+		})
+	}
+
+	for _, cert := range certificates {
+		if cert.Error != "" {
+			// 握手失败属于连通性问题，由通信评分反映，这里只记录
+			findings = append(findings, models.ScoreFinding{
+				Item:     "tls_unreachable:" + cert.Target,
+				Message:  "TLS检查失败",
+				Evidence: map[string]interface{}{"endpoint": cert.Endpoint, "error": cert.Error},
+			})
+			continue
+		}
+
+		if severity := expirySeverity(cert.DaysToExpiry); severity != "" {
+			title := fmt.Sprintf("%s证书将在%d天后到期", cert.Target, cert.DaysToExpiry)
+			if cert.DaysToExpiry <= 0 {
+				title = fmt.Sprintf("%s证书已过期", cert.Target)
+			}
+			add(cert, "tls_cert_expiry", severity, title,
+				fmt.Sprintf("证书%s（签发者%s）有效期至%s", cert.Subject, cert.Issuer, cert.NotAfter.Format(time.RFC3339)),
+				"在到期前更新证书并重启相关服务")
+		}
+		if !cert.ChainValid {
+			add(cert, "tls_chain_invalid", "high", fmt.Sprintf("%s证书链校验失败", cert.Target),
+				cert.ChainError, "使用受信任CA签发的证书，并确认中间证书完整、主机名匹配")
+		}
+		if weakKey(cert.KeyAlgorithm, cert.KeySize) {
+			add(cert, "tls_weak_key", "medium", fmt.Sprintf("%s证书密钥强度不足", cert.Target),
+				fmt.Sprintf("证书使用%s %d位密钥", cert.KeyAlgorithm, cert.KeySize),
+				"改用RSA 2048位以上或ECDSA P-256以上的密钥重新签发证书")
+		}
+		if len(cert.LegacyProtocols) > 0 {
+			add(cert, "tls_legacy_protocol", "medium", fmt.Sprintf("%s仍接受旧版TLS协议", cert.Target),
+				fmt.Sprintf("服务端接受%s", strings.Join(cert.LegacyProtocols, "、")),
+				"将服务端最低协议版本设置为TLS 1.2")
+		}
+		if insecureCipher(cert.CipherSuite) {
+			add(cert, "tls_weak_cipher", "high", fmt.Sprintf("%s协商了不安全的密码套件", cert.Target),
+				fmt.Sprintf("协商的密码套件为%s", cert.CipherSuite),
+				"禁用RC4、3DES及CBC-SHA1等不安全密码套件")
+		}
+	}
+
+	return &ScoreResult{Score: score, Findings: findings, Vulnerabilities: vulnerabilities}
+}
+
+// expirySeverity 到期提醒阈值：30天medium、7天high、1天及已过期critical
+func expirySeverity(days int) string {
+	switch {
+	case days <= 1:
+		return "critical"
+	case days <= 7:
+		return "high"
+	case days <= 30:
+		return "medium"
+	default:
+		return ""
+	}
+}
+
+func daysUntil(notAfter, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
+
+func weakKey(algorithm string, size int) bool {
+	switch algorithm {
+	case "RSA":
+		return size < 2048
+	case "ECDSA":
+		return size < 256
+	default:
+		return false
+	}
+}
+
+func insecureCipher(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// inspect 检查单个目标：有地址时握手获取证书，否则读取证书文件
+func (s *TLSScorer) inspect(target TLSTarget) models.CertificateStatus {
+	status := models.CertificateStatus{Target: target.Name, CheckedAt: time.Now()}
+
+	roots, err := trustRoots(target.CAFile)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	var chain []*x509.Certificate
+	serverName := target.ServerName
+	if target.Address != "" {
+		status.Endpoint = target.Address
+		if serverName == "" {
+			serverName, _, _ = net.SplitHostPort(target.Address)
+		}
+		state, err := s.handshake(target.Address, serverName, 0, 0)
+		if err != nil {
+			status.Error = err.Error()
+			return status
+		}
+		chain = state.PeerCertificates
+		status.TLSVersion = tls.VersionName(state.Version)
+		status.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+		for _, version := range []uint16{tls.VersionTLS10, tls.VersionTLS11} {
+			if _, err := s.handshake(target.Address, serverName, version, version); err == nil {
+				status.LegacyProtocols = append(status.LegacyProtocols, tls.VersionName(version))
+			}
+		}
+	} else {
+		status.Endpoint = target.CertFile
+		if chain, err = readCertificateChain(target.CertFile); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	if len(chain) == 0 {
+		status.Error = "未获取到证书"
+		return status
+	}
+
+	leaf := chain[0]
+	status.Subject = leaf.Subject.String()
+	status.Issuer = leaf.Issuer.String()
+	status.SerialNumber = leaf.SerialNumber.Text(16)
+	status.NotAfter = leaf.NotAfter
+	status.DaysToExpiry = daysUntil(leaf.NotAfter, time.Now())
+	status.SignatureAlgorithm = leaf.SignatureAlgorithm.String()
+	status.KeyAlgorithm, status.KeySize = publicKeyInfo(leaf.PublicKey)
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		status.ChainError = err.Error()
+	} else {
+		status.ChainValid = true
+	}
+	return status
+}
+
+// handshake 建立TLS连接并返回连接状态；证书由调用方自行校验
+func (s *TLSScorer) handshake(address, serverName string, minVersion, maxVersion uint16) (tls.ConnectionState, error) {
+	dialer := &net.Dialer{Timeout: s.config.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+		MinVersion:         minVersion,
+		MaxVersion:         maxVersion,
+	})
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState(), nil
+}
+
+// trustRoots 系统信任的根证书，附加指定的CA证书
+func trustRoots(caFile string) (*x509.CertPool, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil || roots == nil {
+		roots = x509.NewCertPool()
+	}
+	if caFile == "" {
+		return roots, nil
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取CA证书失败: %w", err)
+	}
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("解析CA证书失败: %s", caFile)
+	}
+	return roots, nil
+}
+
+// readCertificateChain 读取PEM证书文件，第一张为叶子证书
+func readCertificateChain(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取证书失败: %w", err)
+	}
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %w", err)
+		}
+		chain = append(chain, cert)
+	}
+	return chain, nil
+}
+
+func publicKeyInfo(key interface{}) (string, int) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return "unknown", 0
+	}
+}
+
+// TLSAddress 从URL中提取TLS握手地址，非TLS协议返回空
+// 支持https、ssl、tls、tcps、mqtts协议，未指定端口时使用协议默认端口
+func TLSAddress(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	defaultPort := ""
+	switch u.Scheme {
+	case "https":
+		defaultPort = "443"
+	case "ssl", "tls", "tcps", "mqtts":
+		defaultPort = "8883"
+	default:
+		return ""
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
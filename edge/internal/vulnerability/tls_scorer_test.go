@@ -0,0 +1,86 @@
+package vulnerability
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func writeSelfSignedCert(t *testing.T, path string, validFor time.Duration) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(validFor),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSScorerExpiryAndChain(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	certFile := filepath.Join(t.TempDir(), "ca.pem")
+	writeSelfSignedCert(t, certFile, 5*24*time.Hour+time.Hour)
+
+	scorer := NewTLSScorer(TLSPostureConfig{Targets: []TLSTarget{
+		// httptest证书不在信任根中，证书链校验应失败
+		{Name: "cloud_endpoint", Address: server.Listener.Addr().String(), ServerName: "example.com"},
+		{Name: "edge_ca", CertFile: certFile, CAFile: certFile},
+	}}, zap.NewNop())
+
+	result := scorer.Score(&AssessmentInput{})
+
+	certs := scorer.Certificates()
+	if len(certs) != 2 || certs[0].Error != "" || certs[0].TLSVersion == "" || certs[0].CipherSuite == "" {
+		t.Fatalf("unexpected handshake status: %+v", certs)
+	}
+	if certs[0].ChainValid || certs[0].KeyAlgorithm == "" {
+		t.Fatalf("expected untrusted chain with key info, got %+v", certs[0])
+	}
+	if !certs[1].ChainValid || certs[1].DaysToExpiry != 5 || certs[1].KeyAlgorithm != "ECDSA" || certs[1].KeySize != 256 {
+		t.Fatalf("unexpected certificate file status: %+v", certs[1])
+	}
+
+	types := map[string]string{}
+	for _, vuln := range result.Vulnerabilities {
+		types[vuln.Type] = vuln.Severity
+	}
+	if types["tls_chain_invalid:cloud_endpoint"] != "high" || types["tls_cert_expiry:edge_ca"] != "high" || len(types) != 2 {
+		t.Fatalf("unexpected vulnerabilities: %+v", result.Vulnerabilities)
+	}
+}
+
+func TestExpirySeverityThresholds(t *testing.T) {
+	cases := map[int]string{45: "", 30: "medium", 8: "medium", 7: "high", 2: "high", 1: "critical", -3: "critical"}
+	for days, want := range cases {
+		if got := expirySeverity(days); got != want {
+			t.Errorf("expirySeverity(%d) = %q, want %q", days, got, want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Groth16批量验证
+ * 对共享同一verifying key的多个证明做随机线性组合，合并为一次多配对检查
+ */
+package zkp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	groth16bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"go.uber.org/zap"
+)
+
+// BatchItem 批量验证中的单个证明
+type BatchItem struct {
+	DeviceID   string
+	Challenge  string
+	Commitment string
+	Response   string
+	Proof      []byte
+}
+
+// BatchVerifier 批量验证接口
+type BatchVerifier interface {
+	// VerifyBatch 批量验证使用同一key的证明，返回每个证明是否有效
+	VerifyBatch(keyID string, items []BatchItem) ([]bool, error)
+}
+
+// batchEntry 已解析的待验证证明
+type batchEntry struct {
+	index  int
+	proof  *groth16bn254.Proof
+	public fr.Vector
+}
+
+// VerifyBatch 批量验证证明
+// 组合检查通过则全部有效；否则逐个验证以定位无效证明
+func (v *Verifier) VerifyBatch(keyID string, items []BatchItem) ([]bool, error) {
+	v.mu.RLock()
+	if !v.initialized {
+		v.mu.RUnlock()
+		return nil, fmt.Errorf("verifier not initialized")
+	}
+	entry, ok := v.keys[keyID]
+	v.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+
+	results := make([]bool, len(items))
+
+	// 带Pedersen承诺的电路或单个证明不做批量，直接逐个验证
+	vk, ok := entry.vk.(*groth16bn254.VerifyingKey)
+	if !ok || len(vk.CommitmentKeys) > 0 || len(items) < 2 {
+		for i, item := range items {
+			results[i], _ = v.VerifyProofWithKey(keyID, item.DeviceID, item.Challenge, item.Commitment, item.Response, item.Proof)
+		}
+		return results, nil
+	}
+
+	entries := make([]batchEntry, 0, len(items))
+	for i, item := range items {
+		proof := new(groth16bn254.Proof)
+		if _, err := proof.ReadFrom(bytes.NewReader(item.Proof)); err != nil {
+			continue
+		}
+		if len(proof.Commitments) > 0 ||
+			!proof.Ar.IsInSubGroup() || !proof.Krs.IsInSubGroup() || !proof.Bs.IsInSubGroup() {
+			continue
+		}
+
+		w, err := v.preparePublicWitness(item.DeviceID, item.Challenge, item.Commitment, item.Response)
+		if err != nil {
+			continue
+		}
+		public, ok := w.Vector().(fr.Vector)
+		if !ok || len(public) != len(vk.G1.K)-1 {
+			continue
+		}
+
+		entries = append(entries, batchEntry{index: i, proof: proof, public: public})
+	}
+	if len(entries) == 0 {
+		return results, nil
+	}
+
+	valid, err := batchPairingCheck(vk, entries)
+	if err != nil {
+		v.logger.Warn("Batch pairing check error, falling back to single verification", zap.Error(err))
+	}
+	if valid {
+		for _, e := range entries {
+			results[e.index] = true
+		}
+		return results, nil
+	}
+
+	for _, e := range entries {
+		results[e.index] = groth16bn254.Verify(e.proof, vk, e.public) == nil
+	}
+	return results, nil
+}
+
+// batchPairingCheck 随机线性组合后的单次多配对检查
+//
+// 单个证明满足 e(A,B)·e(C,-δ)·e(L,-γ) = e(α,β)，其中 L = K0 + Σ w_j·K_j。
+// 取随机数 r_i，检查 Π e(r_i·A_i, B_i) · e(Σr_i·C_i, -δ) · e(Σr_i·L_i, -γ) · e(Σr_i·α, -β) = 1，
+// 任一证明无效时组合检查通过的概率可忽略。
+func batchPairingCheck(vk *groth16bn254.VerifyingKey, entries []batchEntry) (bool, error) {
+	n := len(entries)
+	P := make([]curve.G1Affine, 0, n+3)
+	Q := make([]curve.G2Affine, 0, n+3)
+
+	var rSum fr.Element
+	kScalars := make([]fr.Element, len(vk.G1.K))
+	var cSum curve.G1Jac
+
+	for _, e := range entries {
+		r, err := randomScalar()
+		if err != nil {
+			return false, err
+		}
+		rBig := r.BigInt(new(big.Int))
+
+		var rA curve.G1Affine
+		rA.ScalarMultiplication(&e.proof.Ar, rBig)
+		P = append(P, rA)
+		Q = append(Q, e.proof.Bs)
+
+		var c, rC curve.G1Jac
+		c.FromAffine(&e.proof.Krs)
+		rC.ScalarMultiplication(&c, rBig)
+		cSum.AddAssign(&rC)
+
+		rSum.Add(&rSum, &r)
+		kScalars[0].Add(&kScalars[0], &r)
+		for j := range e.public {
+			var t fr.Element
+			t.Mul(&e.public[j], &r)
+			kScalars[j+1].Add(&kScalars[j+1], &t)
+		}
+	}
+
+	var lSum curve.G1Affine
+	if _, err := lSum.MultiExp(vk.G1.K, kScalars, ecc.MultiExpConfig{}); err != nil {
+		return false, err
+	}
+
+	var cAff, alphaR curve.G1Affine
+	cAff.FromJacobian(&cSum)
+	alphaR.ScalarMultiplication(&vk.G1.Alpha, rSum.BigInt(new(big.Int)))
+
+	var negDelta, negGamma, negBeta curve.G2Affine
+	negDelta.Neg(&vk.G2.Delta)
+	negGamma.Neg(&vk.G2.Gamma)
+	negBeta.Neg(&vk.G2.Beta)
+
+	P = append(P, cAff, lSum, alphaR)
+	Q = append(Q, negDelta, negGamma, negBeta)
+
+	return curve.PairingCheck(P, Q)
+}
+
+// randomScalar 生成128位随机标量（批量验证的安全性只需统计意义上的随机性）
+func randomScalar() (fr.Element, error) {
+	var r fr.Element
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return r, fmt.Errorf("failed to generate random scalar: %w", err)
+	}
+	buf[0] |= 0x80 // 保证非零
+	r.SetBytes(buf)
+	return r, nil
+}
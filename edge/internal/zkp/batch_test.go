@@ -0,0 +1,140 @@
+package zkp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/edge/storage-cabinet/internal/zkp/circuits"
+	"go.uber.org/zap"
+)
+
+// setupBatchVerifier 编译认证电路并生成测试用密钥
+func setupBatchVerifier(t *testing.T) (*Verifier, groth16.ProvingKey, constraint.ConstraintSystem) {
+	t.Helper()
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuits.AuthCircuit{})
+	if err != nil {
+		t.Fatalf("compile circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatalf("write vk: %v", err)
+	}
+	vkPath := filepath.Join(t.TempDir(), "auth_verifying.key")
+	if err := os.WriteFile(vkPath, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("save vk: %v", err)
+	}
+
+	v := NewVerifier(zap.NewNop())
+	if err := v.InitializeWithKeyPath(vkPath); err != nil {
+		t.Fatalf("init verifier: %v", err)
+	}
+	return v, pk, ccs
+}
+
+// proveItem 为设备生成一个有效证明
+func proveItem(t *testing.T, v *Verifier, pk groth16.ProvingKey, ccs constraint.ConstraintSystem, deviceID string, seed byte) BatchItem {
+	t.Helper()
+
+	secretBytes := bytes.Repeat([]byte{seed}, 32)
+	secretBytes[0] = 0 // 保证小于标量域
+	secret := hex.EncodeToString(secretBytes)
+
+	challenge, err := v.GenerateChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment, err := v.ComputeCommitment(secret, deviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := v.ComputeResponse(secret, challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	toBig := func(h string) *big.Int {
+		b, _ := hex.DecodeString(h)
+		return new(big.Int).SetBytes(b)
+	}
+	assignment := &circuits.AuthCircuit{
+		Secret:     toBig(secret),
+		DeviceID:   new(big.Int).SetBytes([]byte(deviceID)),
+		Challenge:  toBig(challenge),
+		Commitment: toBig(commitment),
+		Response:   toBig(response),
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return BatchItem{
+		DeviceID:   deviceID,
+		Challenge:  challenge,
+		Commitment: commitment,
+		Response:   response,
+		Proof:      buf.Bytes(),
+	}
+}
+
+func TestVerifyBatch(t *testing.T) {
+	v, pk, ccs := setupBatchVerifier(t)
+
+	items := []BatchItem{
+		proveItem(t, v, pk, ccs, "DEV-001", 1),
+		proveItem(t, v, pk, ccs, "DEV-002", 2),
+		proveItem(t, v, pk, ccs, "DEV-003", 3),
+	}
+
+	results, err := v.VerifyBatch(LegacyKeyID, items)
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	for i, ok := range results {
+		if !ok {
+			t.Errorf("item %d: expected valid proof", i)
+		}
+	}
+
+	// 篡改其中一个证明的公开输入，批量检查失败后应只判定该证明无效
+	items[1].Response = items[0].Response
+	items = append(items, BatchItem{DeviceID: "DEV-004", Proof: []byte("garbage")})
+
+	results, err = v.VerifyBatch(LegacyKeyID, items)
+	if err != nil {
+		t.Fatalf("VerifyBatch: %v", err)
+	}
+	want := []bool{true, false, true, false}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("item %d: got %v, want %v", i, results[i], want[i])
+		}
+	}
+
+	if _, err := v.VerifyBatch("missing", items); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
@@ -0,0 +1,59 @@
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+)
+
+// MembershipTreeDepth 成员Merkle树深度（每个群组最多 2^10 个成员）
+const MembershipTreeDepth = 10
+
+// MembershipCircuit 定义群组成员证明电路
+// 证明客户端知道 secret，使得:
+// 1. leaf = MiMC(secret) 是根为 root 的Merkle树中的某个叶子
+// 2. response = MiMC(secret || challenge)
+// 叶子位置和路径均为私有输入，验证者只能确认证明者属于该群组，无法得知是哪个成员
+type MembershipCircuit struct {
+	// 私有输入 (仅证明者知道)
+	Secret       frontend.Variable                      `gnark:",secret"`
+	PathElements [MembershipTreeDepth]frontend.Variable `gnark:",secret"` // 各层兄弟节点
+	PathIndices  [MembershipTreeDepth]frontend.Variable `gnark:",secret"` // 各层位置，1表示当前节点为右子节点
+
+	// 公开输入 (证明者和验证者都知道)
+	Root      frontend.Variable `gnark:",public"`
+	Challenge frontend.Variable `gnark:",public"`
+	Response  frontend.Variable `gnark:",public"`
+}
+
+// Define 定义电路约束
+func (circuit *MembershipCircuit) Define(api frontend.API) error {
+	mimcHasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+
+	// 约束 1: 从叶子 MiMC(secret) 沿路径计算出的根等于 root
+	mimcHasher.Reset()
+	mimcHasher.Write(circuit.Secret)
+	node := mimcHasher.Sum()
+
+	for i := 0; i < MembershipTreeDepth; i++ {
+		api.AssertIsBoolean(circuit.PathIndices[i])
+		left := api.Select(circuit.PathIndices[i], circuit.PathElements[i], node)
+		right := api.Select(circuit.PathIndices[i], node, circuit.PathElements[i])
+
+		mimcHasher.Reset()
+		mimcHasher.Write(left)
+		mimcHasher.Write(right)
+		node = mimcHasher.Sum()
+	}
+	api.AssertIsEqual(circuit.Root, node)
+
+	// 约束 2: response = MiMC(secret, challenge)，将证明绑定到本次挑战
+	mimcHasher.Reset()
+	mimcHasher.Write(circuit.Secret)
+	mimcHasher.Write(circuit.Challenge)
+	api.AssertIsEqual(circuit.Response, mimcHasher.Sum())
+
+	return nil
+}
@@ -3,12 +3,42 @@
  */
 package zkp
 
+import "time"
+
+// LegacyKeyID 单key时代的verifying key ID
+// 未记录电路版本的设备视为使用该key
+const LegacyKeyID = "v1"
+
 // ZKPVerifier ZKP验证器接口
 type ZKPVerifier interface {
 	Initialize() error
 	GenerateChallenge() (string, error)
 	VerifyProof(deviceID, challenge, commitment, response string, proofData []byte) (bool, error)
+	VerifyProofWithKey(keyID, deviceID, challenge, commitment, response string, proofData []byte) (bool, error)
+	DefaultKeyID() string
+	HasKey(keyID string) bool
 	ComputeCommitment(secret, deviceID string) (string, error)
 	ComputeResponse(secret, challenge string) (string, error)
 	GenerateProof(secret, deviceID, challenge, commitment, response string) ([]byte, error)
 }
+
+// KeyManager 多verifying key管理接口
+type KeyManager interface {
+	Keys() []KeyInfo
+	RetireKey(keyID string) error
+}
+
+// MembershipVerifier 群组成员证明验证接口
+type MembershipVerifier interface {
+	HasMembershipKey() bool
+	VerifyMembershipProof(root, challenge, response string, proofData []byte) (bool, error)
+}
+
+// KeyInfo 已加载的verifying key信息
+type KeyInfo struct {
+	KeyID       string    `json:"key_id"`
+	Path        string    `json:"path"`
+	Fingerprint string    `json:"fingerprint"` // verifying key内容的SHA256前8字节
+	LoadedAt    time.Time `json:"loaded_at"`
+	Default     bool      `json:"default"`
+}
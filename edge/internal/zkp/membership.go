@@ -0,0 +1,221 @@
+/*
+ * 群组成员证明
+ * 成员承诺 MiMC(secret) 组成固定深度的Merkle树，Edge维护树根，
+ * 成员证明自己是树中某个叶子而不暴露具体是哪一个
+ */
+package zkp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/edge/storage-cabinet/internal/zkp/circuits"
+	"go.uber.org/zap"
+)
+
+// MaxGroupMembers 单个群组的成员上限（由电路树深度决定）
+const MaxGroupMembers = 1 << circuits.MembershipTreeDepth
+
+// MerkleTree 固定深度的MiMC Merkle树，空位叶子为0
+type MerkleTree struct {
+	levels [][]*big.Int // levels[0]为叶子层，最后一层为根
+}
+
+// NewMerkleTree 由成员承诺（hex）构建Merkle树，叶子顺序即成员顺序
+func NewMerkleTree(leaves []string) (*MerkleTree, error) {
+	if len(leaves) > MaxGroupMembers {
+		return nil, fmt.Errorf("too many members: %d (max %d)", len(leaves), MaxGroupMembers)
+	}
+
+	level := make([]*big.Int, len(leaves))
+	for i, leaf := range leaves {
+		v, err := parseFieldHex(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf %d: %w", i, err)
+		}
+		level[i] = v
+	}
+
+	// 每层的空位节点：第0层为0，之后为下一层空节点的哈希
+	zero := new(big.Int)
+	tree := &MerkleTree{levels: [][]*big.Int{level}}
+	for d := 0; d < circuits.MembershipTreeDepth; d++ {
+		next := make([]*big.Int, max((len(level)+1)/2, 1))
+		for i := range next {
+			left, right := zero, zero
+			if 2*i < len(level) {
+				left = level[2*i]
+			}
+			if 2*i+1 < len(level) {
+				right = level[2*i+1]
+			}
+			next[i] = hashPair(left, right)
+		}
+		zero = hashPair(zero, zero)
+		level = next
+		tree.levels = append(tree.levels, level)
+	}
+	return tree, nil
+}
+
+// Root 返回树根（32字节hex）
+func (t *MerkleTree) Root() string {
+	return fieldHex(t.levels[len(t.levels)-1][0])
+}
+
+// Proof 返回第index个叶子的Merkle路径：各层兄弟节点（hex）及位置（1表示当前节点为右子节点）
+func (t *MerkleTree) Proof(index int) ([]string, []int, error) {
+	if index < 0 || index >= len(t.levels[0]) {
+		return nil, nil, fmt.Errorf("leaf index out of range: %d", index)
+	}
+
+	zero := new(big.Int)
+	elements := make([]string, circuits.MembershipTreeDepth)
+	indices := make([]int, circuits.MembershipTreeDepth)
+	for d := 0; d < circuits.MembershipTreeDepth; d++ {
+		level := t.levels[d]
+		sibling := index ^ 1
+		if sibling < len(level) {
+			elements[d] = fieldHex(level[sibling])
+		} else {
+			elements[d] = fieldHex(zero)
+		}
+		indices[d] = index & 1
+		index >>= 1
+		zero = hashPair(zero, zero)
+	}
+	return elements, indices, nil
+}
+
+// ComputeMemberLeaf 计算成员承诺 MiMC(secret)（用于成员登记）
+func ComputeMemberLeaf(secret string) (string, error) {
+	secretBytes, err := hex.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret hex: %w", err)
+	}
+	secretFieldBytes := make([]byte, 32)
+	new(big.Int).SetBytes(secretBytes).FillBytes(secretFieldBytes)
+
+	mimcHash := hash.MIMC_BN254.New()
+	mimcHash.Write(secretFieldBytes)
+	return hex.EncodeToString(mimcHash.Sum(nil)), nil
+}
+
+// NormalizeMemberLeaf 校验成员承诺为合法域元素，并统一为32字节小写hex
+func NormalizeMemberLeaf(leaf string) (string, error) {
+	v, err := parseFieldHex(leaf)
+	if err != nil {
+		return "", err
+	}
+	return fieldHex(v), nil
+}
+
+// hashPair 计算 MiMC(left || right)，与电路中逐层哈希一致
+func hashPair(left, right *big.Int) *big.Int {
+	buf := make([]byte, 64)
+	left.FillBytes(buf[:32])
+	right.FillBytes(buf[32:])
+
+	mimcHash := hash.MIMC_BN254.New()
+	mimcHash.Write(buf)
+	return new(big.Int).SetBytes(mimcHash.Sum(nil))
+}
+
+func parseFieldHex(s string) (*big.Int, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	if len(b) == 0 || len(b) > 32 {
+		return nil, fmt.Errorf("invalid field element length: %d", len(b))
+	}
+	v := new(big.Int).SetBytes(b)
+	if v.Cmp(ecc.BN254.ScalarField()) >= 0 {
+		return nil, fmt.Errorf("value exceeds scalar field")
+	}
+	return v, nil
+}
+
+func fieldHex(v *big.Int) string {
+	buf := make([]byte, 32)
+	v.FillBytes(buf)
+	return hex.EncodeToString(buf)
+}
+
+// LoadMembershipKey 加载群组成员电路的verifying key
+func (v *Verifier) LoadMembershipKey(vkPath string) error {
+	vkBytes, err := os.ReadFile(vkPath)
+	if err != nil {
+		return fmt.Errorf("failed to open membership verifying key file: %w", err)
+	}
+
+	vk := groth16.NewVerifyingKey(v.curve)
+	if _, err := vk.ReadFrom(bytes.NewReader(vkBytes)); err != nil {
+		return fmt.Errorf("failed to read membership verifying key: %w", err)
+	}
+
+	v.mu.Lock()
+	v.membershipVK = vk
+	v.mu.Unlock()
+
+	v.logger.Info("Membership verifying key loaded", zap.String("key_path", vkPath))
+	return nil
+}
+
+// HasMembershipKey 判断是否已加载群组成员电路的verifying key
+func (v *Verifier) HasMembershipKey() bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.membershipVK != nil
+}
+
+// VerifyMembershipProof 验证群组成员证明
+func (v *Verifier) VerifyMembershipProof(root, challenge, response string, proofData []byte) (bool, error) {
+	v.mu.RLock()
+	vk := v.membershipVK
+	v.mu.RUnlock()
+	if vk == nil {
+		return false, fmt.Errorf("membership verifying key not loaded")
+	}
+
+	proof := groth16.NewProof(v.curve)
+	if _, err := proof.ReadFrom(bytes.NewReader(proofData)); err != nil {
+		return false, fmt.Errorf("invalid proof format: %w", err)
+	}
+
+	rootBig, err := parseFieldHex(root)
+	if err != nil {
+		return false, fmt.Errorf("invalid root: %w", err)
+	}
+	challengeBig, err := parseFieldHex(challenge)
+	if err != nil {
+		return false, fmt.Errorf("invalid challenge: %w", err)
+	}
+	responseBig, err := parseFieldHex(response)
+	if err != nil {
+		return false, fmt.Errorf("invalid response: %w", err)
+	}
+
+	publicWitness, err := frontend.NewWitness(&circuits.MembershipCircuit{
+		Root:      rootBig,
+		Challenge: challengeBig,
+		Response:  responseBig,
+	}, v.curve.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare public witness: %w", err)
+	}
+
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		v.logger.Debug("Membership proof verification failed", zap.Error(err))
+		return false, nil
+	}
+	return true, nil
+}
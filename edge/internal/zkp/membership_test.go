@@ -0,0 +1,105 @@
+package zkp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/edge/storage-cabinet/internal/zkp/circuits"
+	"go.uber.org/zap"
+)
+
+func TestMembershipProof(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuits.MembershipCircuit{})
+	if err != nil {
+		t.Fatalf("compile circuit: %v", err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatalf("write vk: %v", err)
+	}
+	vkPath := filepath.Join(t.TempDir(), "membership_verifying.key")
+	if err := os.WriteFile(vkPath, buf.Bytes(), 0600); err != nil {
+		t.Fatalf("save vk: %v", err)
+	}
+
+	v := NewVerifier(zap.NewNop())
+	if err := v.LoadMembershipKey(vkPath); err != nil {
+		t.Fatalf("load membership key: %v", err)
+	}
+
+	// 三个成员，证明者为第二个
+	secrets := make([]string, 3)
+	leaves := make([]string, 3)
+	for i := range secrets {
+		secretBytes := bytes.Repeat([]byte{byte(0x11 * (i + 1))}, 32)
+		secretBytes[0] = 0
+		secrets[i] = hex.EncodeToString(secretBytes)
+		if leaves[i], err = ComputeMemberLeaf(secrets[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree, err := NewMerkleTree(leaves)
+	if err != nil {
+		t.Fatalf("build tree: %v", err)
+	}
+	elements, indices, err := tree.Proof(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, _ := v.GenerateChallenge()
+	response, _ := v.ComputeResponse(secrets[1], challenge)
+
+	toBig := func(h string) *big.Int {
+		b, _ := hex.DecodeString(h)
+		return new(big.Int).SetBytes(b)
+	}
+	assignment := &circuits.MembershipCircuit{
+		Secret:    toBig(secrets[1]),
+		Root:      toBig(tree.Root()),
+		Challenge: toBig(challenge),
+		Response:  toBig(response),
+	}
+	for i := range elements {
+		assignment.PathElements[i] = toBig(elements[i])
+		assignment.PathIndices[i] = indices[i]
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatalf("witness: %v", err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatalf("prove: %v", err)
+	}
+	var proofBuf bytes.Buffer
+	proof.WriteTo(&proofBuf)
+	proofBytes := proofBuf.Bytes()
+
+	ok, err := v.VerifyMembershipProof(tree.Root(), challenge, response, proofBytes)
+	if err != nil || !ok {
+		t.Fatalf("expected valid membership proof, ok=%v err=%v", ok, err)
+	}
+
+	// 成员变更后树根改变，旧证明失效
+	other, _ := NewMerkleTree(leaves[:2])
+	if ok, _ := v.VerifyMembershipProof(other.Root(), challenge, response, proofBytes); ok {
+		t.Fatal("proof must not verify against a different root")
+	}
+	otherChallenge, _ := v.GenerateChallenge()
+	if ok, _ := v.VerifyMembershipProof(tree.Root(), otherChallenge, response, proofBytes); ok {
+		t.Fatal("proof must not verify against a different challenge")
+	}
+}
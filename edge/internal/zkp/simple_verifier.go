@@ -39,6 +39,7 @@ func (v *SimpleVerifier) GenerateChallenge() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to generate random nonce: %w", err)
 	}
+	nonce[0] &= 0x1f // 与Verifier一致，保证小于标量域
 
 	return hex.EncodeToString(nonce), nil
 }
@@ -70,6 +71,24 @@ func (v *SimpleVerifier) VerifyProof(deviceID, challenge, commitment, response s
 	return true, nil
 }
 
+// VerifyProofWithKey 验证证明（简化版本不区分key）
+// 实现 ZKPVerifier 接口
+func (v *SimpleVerifier) VerifyProofWithKey(keyID, deviceID, challenge, commitment, response string, proofData []byte) (bool, error) {
+	return v.VerifyProof(deviceID, challenge, commitment, response, proofData)
+}
+
+// DefaultKeyID 返回默认key ID
+// 实现 ZKPVerifier 接口
+func (v *SimpleVerifier) DefaultKeyID() string {
+	return LegacyKeyID
+}
+
+// HasKey 简化版本接受任意key
+// 实现 ZKPVerifier 接口
+func (v *SimpleVerifier) HasKey(keyID string) bool {
+	return true
+}
+
 // ComputeCommitment 计算承诺值
 // 实现 ZKPVerifier 接口
 func (v *SimpleVerifier) ComputeCommitment(secret, deviceID string) (string, error) {
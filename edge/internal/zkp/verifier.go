@@ -7,11 +7,14 @@ package zkp
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"math/big"
 	"os"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/hash"
@@ -23,18 +26,31 @@ import (
 )
 
 // Verifier ZKP验证器
+// 支持同时加载多个verifying key（按key ID区分），用于可信设置更新或电路升级时的平滑迁移
 type Verifier struct {
 	logger       *zap.Logger
-	verifyingKey groth16.VerifyingKey
+	keys         map[string]*verifyingKeyEntry
+	defaultKeyID string
 	curve        ecc.ID
 	mu           sync.RWMutex
 	initialized  bool
+
+	membershipVK groth16.VerifyingKey // 群组成员电路verifying key（可选）
+}
+
+// verifyingKeyEntry 已加载的verifying key
+type verifyingKeyEntry struct {
+	vk          groth16.VerifyingKey
+	path        string
+	fingerprint string
+	loadedAt    time.Time
 }
 
 // NewVerifier 创建新的验证器
 func NewVerifier(logger *zap.Logger) *Verifier {
 	return &Verifier{
 		logger: logger,
+		keys:   make(map[string]*verifyingKeyEntry),
 		curve:  ecc.BN254, // 使用BN254曲线
 	}
 }
@@ -45,15 +61,27 @@ func (v *Verifier) Initialize() error {
 }
 
 // InitializeWithKeyPath 使用指定路径初始化验证器
+// 该key以 LegacyKeyID 加载，未设置默认key时作为默认key
 func (v *Verifier) InitializeWithKeyPath(vkPath string) error {
-	v.mu.Lock()
-	defer v.mu.Unlock()
-
-	if v.initialized {
+	v.mu.RLock()
+	initialized := v.initialized
+	v.mu.RUnlock()
+	if initialized {
 		return nil
 	}
 
-	v.logger.Info("Initializing ZKP verifier from key file...", zap.String("key_path", vkPath))
+	return v.LoadKey(LegacyKeyID, vkPath)
+}
+
+// LoadKey 加载verifying key，已存在的key ID会被替换
+func (v *Verifier) LoadKey(keyID, vkPath string) error {
+	if keyID == "" {
+		return fmt.Errorf("verifying key id is required")
+	}
+
+	v.logger.Info("Loading ZKP verifying key...",
+		zap.String("key_id", keyID),
+		zap.String("key_path", vkPath))
 
 	// 检查文件是否存在
 	if _, err := os.Stat(vkPath); os.IsNotExist(err) {
@@ -62,22 +90,103 @@ func (v *Verifier) InitializeWithKeyPath(vkPath string) error {
 	}
 
 	// 加载验证密钥
-	vkFile, err := os.Open(vkPath)
+	vkBytes, err := os.ReadFile(vkPath)
 	if err != nil {
 		return fmt.Errorf("failed to open verifying key file: %w", err)
 	}
-	defer vkFile.Close()
 
-	v.verifyingKey = groth16.NewVerifyingKey(v.curve)
-	if _, err := v.verifyingKey.ReadFrom(vkFile); err != nil {
+	vk := groth16.NewVerifyingKey(v.curve)
+	if _, err := vk.ReadFrom(bytes.NewReader(vkBytes)); err != nil {
 		return fmt.Errorf("failed to read verifying key: %w", err)
 	}
 
+	sum := sha256.Sum256(vkBytes)
+	entry := &verifyingKeyEntry{
+		vk:          vk,
+		path:        vkPath,
+		fingerprint: hex.EncodeToString(sum[:8]),
+		loadedAt:    time.Now(),
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.keys[keyID] = entry
+	if v.defaultKeyID == "" {
+		v.defaultKeyID = keyID
+	}
 	v.initialized = true
-	v.logger.Info("ZKP verifier initialized successfully with pre-generated verifying key")
+
+	v.logger.Info("ZKP verifying key loaded",
+		zap.String("key_id", keyID),
+		zap.String("fingerprint", entry.fingerprint),
+		zap.Int("loaded_keys", len(v.keys)))
+	return nil
+}
+
+// SetDefaultKey 设置默认key（新注册设备和迁移目标使用的电路版本）
+func (v *Verifier) SetDefaultKey(keyID string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.keys[keyID]; !ok {
+		return fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+	v.defaultKeyID = keyID
 	return nil
 }
 
+// RetireKey 卸载verifying key，此后使用该key的证明将被拒绝
+func (v *Verifier) RetireKey(keyID string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.keys[keyID]; !ok {
+		return fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
+	if keyID == v.defaultKeyID {
+		return fmt.Errorf("cannot retire default verifying key: %s", keyID)
+	}
+	delete(v.keys, keyID)
+
+	v.logger.Info("ZKP verifying key retired", zap.String("key_id", keyID))
+	return nil
+}
+
+// DefaultKeyID 返回默认key ID
+func (v *Verifier) DefaultKeyID() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.defaultKeyID
+}
+
+// HasKey 判断key是否已加载
+func (v *Verifier) HasKey(keyID string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, ok := v.keys[keyID]
+	return ok
+}
+
+// Keys 返回已加载的key列表（按ID排序）
+func (v *Verifier) Keys() []KeyInfo {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	keys := make([]KeyInfo, 0, len(v.keys))
+	for id, entry := range v.keys {
+		keys = append(keys, KeyInfo{
+			KeyID:       id,
+			Path:        entry.path,
+			Fingerprint: entry.fingerprint,
+			LoadedAt:    entry.loadedAt,
+			Default:     id == v.defaultKeyID,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
+}
+
 // GenerateChallenge 生成认证挑战
 func (v *Verifier) GenerateChallenge() (string, error) {
 	// 生成32字节的随机数
@@ -85,10 +194,12 @@ func (v *Verifier) GenerateChallenge() (string, error) {
 	if _, err := rand.Read(challenge); err != nil {
 		return "", fmt.Errorf("failed to generate challenge: %w", err)
 	}
+	// 清除最高3位，保证挑战值小于BN254标量域，否则MiMC无法将其作为域元素哈希
+	challenge[0] &= 0x1f
 	return hex.EncodeToString(challenge), nil
 }
 
-// VerifyProof 验证零知识证明
+// VerifyProof 使用默认key验证零知识证明
 func (v *Verifier) VerifyProof(
 	deviceID string,
 	challenge string,
@@ -96,12 +207,28 @@ func (v *Verifier) VerifyProof(
 	response string,
 	proofData []byte,
 ) (bool, error) {
-	v.mu.RLock()
-	defer v.mu.RUnlock()
+	return v.VerifyProofWithKey(v.DefaultKeyID(), deviceID, challenge, commitment, response, proofData)
+}
 
+// VerifyProofWithKey 使用指定key验证零知识证明
+func (v *Verifier) VerifyProofWithKey(
+	keyID string,
+	deviceID string,
+	challenge string,
+	commitment string,
+	response string,
+	proofData []byte,
+) (bool, error) {
+	v.mu.RLock()
 	if !v.initialized {
+		v.mu.RUnlock()
 		return false, fmt.Errorf("verifier not initialized")
 	}
+	entry, ok := v.keys[keyID]
+	v.mu.RUnlock()
+	if !ok {
+		return false, fmt.Errorf("verifying key not loaded: %s", keyID)
+	}
 
 	// 解析证明
 	proof := groth16.NewProof(v.curve)
@@ -117,15 +244,18 @@ func (v *Verifier) VerifyProof(
 	}
 
 	// 验证证明
-	err = groth16.Verify(proof, v.verifyingKey, publicWitness)
+	err = groth16.Verify(proof, entry.vk, publicWitness)
 	if err != nil {
 		v.logger.Debug("Proof verification failed",
 			zap.String("device_id", deviceID),
+			zap.String("key_id", keyID),
 			zap.Error(err))
 		return false, nil
 	}
 
-	v.logger.Info("Proof verified successfully", zap.String("device_id", deviceID))
+	v.logger.Info("Proof verified successfully",
+		zap.String("device_id", deviceID),
+		zap.String("key_id", keyID))
 	return true, nil
 }
 
@@ -241,3 +371,17 @@ func (v *Verifier) ComputeResponse(secret string, challenge string) (string, err
 	return hex.EncodeToString(hashBytes), nil
 }
 
+
+// ComputeRotationChallenge 计算凭证轮换挑战 MiMC(salt || newCommitment)
+// 该值作为认证电路的公开输入Challenge，使轮换证明与提交的新承诺绑定，无法挪用于其他承诺
+func ComputeRotationChallenge(salt, newCommitment string) (string, error) {
+	saltValue, err := parseFieldHex(salt)
+	if err != nil {
+		return "", fmt.Errorf("invalid binding salt: %w", err)
+	}
+	commitmentValue, err := parseFieldHex(newCommitment)
+	if err != nil {
+		return "", fmt.Errorf("invalid new commitment: %w", err)
+	}
+	return fieldHex(hashPair(saltValue, commitmentValue)), nil
+}
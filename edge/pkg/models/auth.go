@@ -18,6 +18,16 @@ type Challenge struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
 	Used        bool      `json:"used" db:"used"`
+	ClientIP    string    `json:"client_ip" db:"client_ip"` // 申请挑战的来源IP
+
+	// 凭证轮换挑战：Nonce = MiMC(BindingSalt || NewCommitment)，只能用于轮换到该承诺
+	Purpose       string `json:"purpose" db:"purpose"` // auth / rotation
+	BindingSalt   string `json:"binding_salt,omitempty" db:"binding_salt"`
+	NewCommitment string `json:"new_commitment,omitempty" db:"new_commitment"`
+
+	// 以下字段不持久化，由认证服务根据设备电路版本填充
+	KeyID       string `json:"key_id,omitempty" db:"-"`        // 设备当前使用的verifying key ID
+	TargetKeyID string `json:"target_key_id,omitempty" db:"-"` // 迁移目标key ID（与KeyID相同时为空）
 }
 
 // PublicWitness 公开见证
@@ -32,8 +42,9 @@ type PublicWitness struct {
 // ZKProof 零知识证明
 // 符合Gnark Groth16格式
 type ZKProof struct {
-	Proof         string        `json:"proof"`          // Base64编码的Groth16 proof（~192字节）
-	PublicWitness PublicWitness `json:"public_witness"` // 公开见证（公开输入集合）
+	Proof         string        `json:"proof"`            // Base64编码的Groth16 proof（~192字节）
+	PublicWitness PublicWitness `json:"public_witness"`   // 公开见证（公开输入集合）
+	KeyID         string        `json:"key_id,omitempty"` // 生成证明所用的verifying key ID，为空表示设备当前电路版本
 }
 
 // AuthRequest 认证请求
@@ -53,6 +64,23 @@ type Session struct {
 	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
 	IPAddress  string    `json:"ip_address" db:"ip_address"`
 	UserAgent  string    `json:"user_agent" db:"user_agent"`
+
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+}
+
+// SessionInfo 会话信息（管理接口使用，不包含令牌）
+type SessionInfo struct {
+	SessionID     string     `json:"session_id"`
+	DeviceID      string     `json:"device_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	IPAddress     string     `json:"ip_address,omitempty"`
+	UserAgent     string     `json:"user_agent,omitempty"`
+	Active        bool       `json:"active"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
 }
 
 // ChallengeRequest 挑战请求
@@ -60,11 +88,58 @@ type ChallengeRequest struct {
 	DeviceID string `json:"device_id" binding:"required"`
 }
 
+// RotationChallengeRequest 凭证轮换挑战请求
+// 轮换挑战在签发时即绑定新承诺，设备可用 BindingSalt 自行校验 Nonce = MiMC(BindingSalt || NewCommitment)
+type RotationChallengeRequest struct {
+	DeviceID      string `json:"device_id" binding:"required"`
+	NewCommitment string `json:"new_commitment" binding:"required"` // 新承诺 MiMC(new_secret, deviceID)
+}
+
 // ChallengeResponse 挑战响应
 type ChallengeResponse struct {
 	ChallengeID string    `json:"challenge_id"`
 	Nonce       string    `json:"nonce"`
 	ExpiresAt   time.Time `json:"expires_at"`
+	KeyID       string    `json:"key_id,omitempty"`        // 设备当前使用的verifying key ID
+	TargetKeyID string    `json:"target_key_id,omitempty"` // 迁移窗口内设备应切换到的key ID
+	BindingSalt string    `json:"binding_salt,omitempty"`  // 轮换挑战的绑定盐值
+}
+
+// CredentialRotationRequest 凭证轮换请求
+// 设备使用当前secret对轮换挑战生成证明，同时提交挑战绑定的新承诺值
+type CredentialRotationRequest struct {
+	DeviceID      string   `json:"device_id" binding:"required"`
+	ChallengeID   string   `json:"challenge_id" binding:"required"`
+	Proof         *ZKProof `json:"proof" binding:"required"`          // 基于当前secret的证明
+	NewCommitment string   `json:"new_commitment" binding:"required"` // 新承诺 MiMC(new_secret, deviceID)
+	NewKeyID      string   `json:"new_key_id,omitempty"`              // 新承诺对应的电路版本（电路升级时使用）
+}
+
+// CredentialRotationResponse 凭证轮换响应
+type CredentialRotationResponse struct {
+	Success         bool      `json:"success"`
+	DeviceID        string    `json:"device_id"`
+	SessionsRevoked int64     `json:"sessions_revoked"`
+	RotatedAt       time.Time `json:"rotated_at"`
+	Message         string    `json:"message,omitempty"`
+}
+
+// ForceRotationRequest 强制轮换请求（Cloud或管理员发起）
+type ForceRotationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CredentialRotation 凭证轮换记录
+type CredentialRotation struct {
+	ID              int64     `json:"id" db:"id"`
+	DeviceID        string    `json:"device_id" db:"device_id"`
+	Event           string    `json:"event" db:"event"`                   // forced, rotated
+	OldCommitment   string    `json:"old_commitment" db:"old_commitment"` // 旧承诺值
+	NewCommitment   string    `json:"new_commitment" db:"new_commitment"` // 新承诺值（forced事件为空）
+	InitiatedBy     string    `json:"initiated_by" db:"initiated_by"`     // device, cloud, admin
+	Reason          string    `json:"reason" db:"reason"`
+	SessionsRevoked int64     `json:"sessions_revoked" db:"sessions_revoked"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 }
 
 // AuthResponse 认证响应
@@ -82,3 +157,34 @@ type TokenClaims struct {
 	SessionID string `json:"session_id"`
 	jwt.RegisteredClaims
 }
+
+// AuthLockout 认证失败锁定状态
+type AuthLockout struct {
+	Scope         string     `json:"scope"` // device / ip
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	LockCount     int        `json:"lock_count"` // 连续锁定次数，决定下次锁定时长
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+}
+
+// VerificationStats 证明验证工作池统计
+type VerificationStats struct {
+	Workers       int     `json:"workers"`
+	QueueDepth    int     `json:"queue_depth"`
+	QueueCapacity int     `json:"queue_capacity"`
+	InFlight      int     `json:"in_flight"`
+	Verified      int64   `json:"verified"`  // 已完成验证的证明数
+	Invalid       int64   `json:"invalid"`   // 验证未通过的证明数
+	Rejected      int64   `json:"rejected"`  // 队列已满被拒绝的请求数
+	TimedOut      int64   `json:"timed_out"` // 排队超时的请求数
+	BatchEnabled  bool    `json:"batch_enabled"`
+	Batches       int64   `json:"batches"`
+	BatchedProofs int64   `json:"batched_proofs"`
+	MaxBatchSize  int     `json:"max_batch_size"`
+	AvgWaitMs     float64 `json:"avg_wait_ms"`    // 平均排队时间
+	AvgVerifyMs   float64 `json:"avg_verify_ms"`  // 单个证明平均验证耗时（批量时为摊薄值）
+	AvgLatencyMs  float64 `json:"avg_latency_ms"` // 最近样本的平均端到端延迟
+	P95LatencyMs  float64 `json:"p95_latency_ms"`
+	MaxLatencyMs  float64 `json:"max_latency_ms"`
+}
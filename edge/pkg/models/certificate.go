@@ -0,0 +1,70 @@
+/*
+ * 设备证书数据模型
+ * 定义Edge CA签发的设备客户端证书及签发申请
+ */
+package models
+
+import (
+	"time"
+)
+
+// 证书签发途径
+const (
+	CertIssuedViaZKP      = "zkp"      // 设备ZKP认证后自助申请
+	CertIssuedViaOperator = "operator" // 运维人员审批
+	CertIssuedViaRenewal  = "renewal"  // 使用有效证书续期
+)
+
+// 证书申请状态
+const (
+	CertRequestPending  = "pending"
+	CertRequestApproved = "approved"
+	CertRequestRejected = "rejected"
+)
+
+// DeviceCertificate 设备客户端证书
+type DeviceCertificate struct {
+	SerialNumber   string     `json:"serial_number" db:"serial_number"` // 十六进制序列号
+	DeviceID       string     `json:"device_id" db:"device_id"`         // 证书主体CN
+	Fingerprint    string     `json:"fingerprint" db:"fingerprint"`     // SHA-256指纹
+	IssuedVia      string     `json:"issued_via" db:"issued_via"`       // zkp, operator, renewal
+	NotBefore      time.Time  `json:"not_before" db:"not_before"`
+	NotAfter       time.Time  `json:"not_after" db:"not_after"`
+	CertificatePEM string     `json:"certificate_pem,omitempty" db:"certificate"`
+	Active         bool       `json:"active"` // 未撤销且未过期
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason  string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CertificateSigningRequest 证书签发请求
+// CSR由设备生成，私钥不离开设备；CSR主体CN必须为设备ID
+type CertificateSigningRequest struct {
+	CSR string `json:"csr" binding:"required"` // PEM编码的PKCS#10 CSR
+}
+
+// CertificateRequest 待运维审批的证书申请
+type CertificateRequest struct {
+	RequestID    string     `json:"request_id" db:"request_id"`
+	DeviceID     string     `json:"device_id" db:"device_id"`
+	Status       string     `json:"status" db:"status"` // pending, approved, rejected
+	SerialNumber string     `json:"serial_number,omitempty" db:"serial_number"`
+	ReviewedBy   string     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	Reason       string     `json:"reason,omitempty" db:"reason"`
+	ClientIP     string     `json:"client_ip,omitempty" db:"client_ip"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+
+	// 审批通过后返回签发的证书
+	Certificate *DeviceCertificate `json:"certificate,omitempty"`
+}
+
+// CertificateReviewRequest 运维拒绝证书申请（操作人取自群组会话）
+type CertificateReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CertificateRevokeRequest 撤销证书请求
+type CertificateRevokeRequest struct {
+	Reason string `json:"reason"`
+}
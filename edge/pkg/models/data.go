@@ -52,6 +52,20 @@ type Alert struct {
 	SyncedAt   *time.Time `json:"synced_at" db:"synced_at"`     // 同步到云端时间
 }
 
+// SensorAnomaly 传感器数据流统计异常（EWMA控制限）
+type SensorAnomaly struct {
+	DeviceID   string     `json:"device_id"`
+	SensorType SensorType `json:"sensor_type"`
+	Metric     string     `json:"metric"`   // value: 读数, interval: 上报间隔（秒）
+	Value      float64    `json:"value"`    // 观测值
+	Expected   float64    `json:"expected"` // 学习到的均值
+	StdDev     float64    `json:"std_dev"`
+	Limit      float64    `json:"limit"` // 被突破的控制限
+	Score      float64    `json:"score"` // 异常分数：偏离均值的标准差倍数
+	Severity   string     `json:"severity"`
+	DetectedAt time.Time  `json:"detected_at"`
+}
+
 // AlertType 告警类型
 type AlertType string
 
@@ -46,6 +46,10 @@ type Device struct {
 	CreatedAt    time.Time    `json:"created_at" db:"created_at"`     // 创建时间
 	UpdatedAt    time.Time    `json:"updated_at" db:"updated_at"`     // 更新时间
 	LastSeenAt   *time.Time   `json:"last_seen_at" db:"last_seen_at"` // 最后在线时间
+
+	RotationRequired bool   `json:"rotation_required" db:"rotation_required"` // 是否需要轮换凭证
+	RotationReason   string `json:"rotation_reason,omitempty" db:"rotation_reason"`
+	CircuitVersion   string `json:"circuit_version" db:"circuit_version"` // 设备使用的电路版本（verifying key ID）
 }
 
 // DeviceRegistration 设备注册请求
@@ -0,0 +1,94 @@
+/*
+ * 群组成员认证数据模型
+ * 共享诊断工具等以群组身份认证，只证明属于某个授权群组而不暴露具体成员
+ */
+package models
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AuthGroup 授权群组
+type AuthGroup struct {
+	GroupID     string    `json:"group_id" db:"group_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description,omitempty" db:"description"`
+	Root        string    `json:"root" db:"root"` // 成员Merkle树根（hex）
+	MemberCount int       `json:"member_count" db:"member_count"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// GroupMember 群组成员
+// 叶子顺序按登记时间排列，成员据此在本地计算Merkle路径
+type GroupMember struct {
+	Index      int       `json:"index"`
+	Commitment string    `json:"commitment" db:"commitment"` // 成员承诺 MiMC(secret)
+	Label      string    `json:"label,omitempty" db:"label"`
+	AddedAt    time.Time `json:"added_at" db:"added_at"`
+}
+
+// SyncGroupRequest Cloud下发的群组定义（完整成员列表）
+type SyncGroupRequest struct {
+	GroupID     string            `json:"group_id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Members     []SyncGroupMember `json:"members"`
+}
+
+// SyncGroupMember 群组成员定义
+type SyncGroupMember struct {
+	Commitment string `json:"commitment"`
+	Label      string `json:"label"`
+}
+
+// GroupChallenge 群组认证挑战
+type GroupChallenge struct {
+	ChallengeID string    `json:"challenge_id" db:"challenge_id"`
+	GroupID     string    `json:"group_id" db:"group_id"`
+	Nonce       string    `json:"nonce" db:"nonce"`
+	Root        string    `json:"root"` // 当前树根，证明须基于该树根生成
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+	Used        bool      `json:"used" db:"used"`
+}
+
+// GroupPublicWitness 群组成员证明公开见证
+type GroupPublicWitness struct {
+	Root      string `json:"root"`      // 成员Merkle树根
+	Challenge string `json:"challenge"` // 挑战值
+	Response  string `json:"response"`  // 挑战响应 MiMC(secret, challenge)
+}
+
+// MembershipProof 群组成员零知识证明
+type MembershipProof struct {
+	Proof         string             `json:"proof"` // Base64编码的Groth16 proof
+	PublicWitness GroupPublicWitness `json:"public_witness"`
+}
+
+// GroupAuthRequest 群组认证请求
+type GroupAuthRequest struct {
+	ChallengeID string           `json:"challenge_id" binding:"required"`
+	Proof       *MembershipProof `json:"proof" binding:"required"`
+}
+
+// GroupSession 群组会话
+type GroupSession struct {
+	SessionID string     `json:"session_id" db:"session_id"`
+	GroupID   string     `json:"group_id" db:"group_id"`
+	Token     string     `json:"token,omitempty"`
+	Root      string     `json:"root" db:"root"` // 认证时的树根
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	IPAddress string     `json:"ip_address,omitempty" db:"ip_address"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// GroupTokenClaims 群组会话JWT声明（不含任何成员标识）
+type GroupTokenClaims struct {
+	GroupID   string `json:"group_id"`
+	SessionID string `json:"session_id"`
+	jwt.RegisteredClaims
+}
@@ -27,6 +27,80 @@ type EdgeVulnerabilityReport struct {
 	TrafficFeatures         []float64            `json:"traffic_features,omitempty"`
 	ConfigChecks            map[string]bool      `json:"config_checks"`
 	DetectedVulnerabilities []VulnerabilityEvent `json:"detected_vulnerabilities"`
+
+	// 各评分器的评分、权重及扣分依据
+	ScorerResults []ScorerResult `json:"scorer_results,omitempty"`
+
+	// 使用中的TLS证书及连接参数
+	Certificates []CertificateStatus `json:"certificates,omitempty"`
+
+	// 近期的漏洞处置记录
+	Remediations []RemediationRecord `json:"remediations,omitempty"`
+
+	// 有效及近期过期的漏洞消除记录
+	DismissedVulnerabilities []DismissedVulnerability `json:"dismissed_vulnerabilities,omitempty"`
+}
+
+// DismissedVulnerability 漏洞消除记录
+type DismissedVulnerability struct {
+	ID          int64      `json:"id"`
+	Type        string     `json:"type"`
+	Reason      string     `json:"reason"`
+	DismissedBy string     `json:"dismissed_by"`
+	DismissedAt time.Time  `json:"dismissed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// RemediationRecord 漏洞处置记录（由处置剧本触发）
+type RemediationRecord struct {
+	ID          int64      `json:"id"`
+	EventType   string     `json:"event_type"` // 触发处置的漏洞事件类型或扣分项
+	Severity    string     `json:"severity,omitempty"`
+	DeviceID    string     `json:"device_id,omitempty"`
+	Action      string     `json:"action"` // block_ip/disable_device/cleanup_storage/rotate_logs/refresh_license
+	Mode        string     `json:"mode"`   // auto: 自动执行, confirm: 运维确认后执行
+	Status      string     `json:"status"` // pending/succeeded/failed/rejected
+	Result      string     `json:"result,omitempty"`
+	ConfirmedBy string     `json:"confirmed_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExecutedAt  *time.Time `json:"executed_at,omitempty"`
+}
+
+// CertificateStatus TLS证书及连接状态
+type CertificateStatus struct {
+	Target             string    `json:"target"`             // edge_https/edge_mqtt_tls/mqtt_broker/cloud_endpoint等
+	Endpoint           string    `json:"endpoint,omitempty"` // 握手地址或证书文件路径
+	Subject            string    `json:"subject,omitempty"`
+	Issuer             string    `json:"issuer,omitempty"`
+	SerialNumber       string    `json:"serial_number,omitempty"`
+	NotAfter           time.Time `json:"not_after,omitempty"`
+	DaysToExpiry       int       `json:"days_to_expiry"`
+	KeyAlgorithm       string    `json:"key_algorithm,omitempty"` // RSA/ECDSA/Ed25519
+	KeySize            int       `json:"key_size,omitempty"`      // 密钥位数
+	SignatureAlgorithm string    `json:"signature_algorithm,omitempty"`
+	ChainValid         bool      `json:"chain_valid"`
+	ChainError         string    `json:"chain_error,omitempty"`
+	TLSVersion         string    `json:"tls_version,omitempty"`      // 协商的协议版本
+	CipherSuite        string    `json:"cipher_suite,omitempty"`     // 协商的密码套件
+	LegacyProtocols    []string  `json:"legacy_protocols,omitempty"` // 仍接受的旧版协议（TLS 1.0/1.1）
+	Error              string    `json:"error,omitempty"`            // 握手或读取失败原因
+	CheckedAt          time.Time `json:"checked_at"`
+}
+
+// ScorerResult 单个评分器的评估结果
+type ScorerResult struct {
+	Name     string         `json:"name"`
+	Score    float64        `json:"score"`  // 评分 (0-100)
+	Weight   float64        `json:"weight"` // 归一化后的权重，0表示不参与加权
+	Findings []ScoreFinding `json:"findings"`
+}
+
+// ScoreFinding 评分扣分项及证据
+type ScoreFinding struct {
+	Item     string                 `json:"item"`               // 检查项
+	Penalty  float64                `json:"penalty"`            // 扣分（负数为加分）
+	Message  string                 `json:"message"`            // 说明
+	Evidence map[string]interface{} `json:"evidence,omitempty"` // 观测值及阈值
 }
 
 // TransmissionMetrics 传输指标
@@ -40,13 +114,29 @@ type TransmissionMetrics struct {
 
 // VulnerabilityEvent 漏洞事件
 type VulnerabilityEvent struct {
-	Type        string    `json:"type"`        // 漏洞类型
-	Category    string    `json:"category"`    // 漏洞分类: network/config/data/license
-	Title       string    `json:"title"`       // 漏洞标题
-	Severity    string    `json:"severity"`    // 严重程度: low/medium/high/critical
-	Description string    `json:"description"` // 描述
-	Solution    string    `json:"solution"`    // 解决方案
-	DetectedAt  time.Time `json:"detected_at"` // 检测时间
+	Type        string    `json:"type"`                // 漏洞类型
+	Category    string    `json:"category"`            // 漏洞分类: network/config/data/license/firmware
+	Title       string    `json:"title"`               // 漏洞标题
+	Severity    string    `json:"severity"`            // 严重程度: low/medium/high/critical
+	Description string    `json:"description"`         // 描述
+	Solution    string    `json:"solution"`            // 解决方案
+	DeviceID    string    `json:"device_id,omitempty"` // 设备级漏洞（如固件漏洞）关联的设备
+	DetectedAt  time.Time `json:"detected_at"`         // 检测时间
+}
+
+// FirmwareAdvisory 固件漏洞公告（由Cloud维护并下发）
+type FirmwareAdvisory struct {
+	AdvisoryID   string    `json:"advisory_id"`
+	Manufacturer string    `json:"manufacturer"`  // 为空时匹配所有制造商
+	Model        string    `json:"model"`         // 为空时匹配所有型号
+	MinVersion   string    `json:"min_version"`   // 受影响的最低版本（含），为空表示不限
+	MaxVersion   string    `json:"max_version"`   // 受影响的最高版本（含），为空表示不限
+	FixedVersion string    `json:"fixed_version"` // 修复版本，不低于该版本视为已修复
+	Severity     string    `json:"severity"`      // low/medium/high/critical
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	Remediation  string    `json:"remediation"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // VulnerabilityAssessment 脆弱性评估结果（数据库模型）
@@ -64,6 +154,7 @@ type VulnerabilityAssessment struct {
 	TrafficFeaturesJSON     string     `json:"-" db:"traffic_features"`         // JSON字符串
 	ConfigChecksJSON        string     `json:"-" db:"config_checks"`            // JSON字符串
 	DetectedVulnJSON        string     `json:"-" db:"detected_vulnerabilities"` // JSON字符串
+	ScorerResultsJSON       string     `json:"-" db:"scorer_results"`           // JSON字符串
 	Synced                  bool       `json:"synced" db:"synced"`
 	SyncedAt                *time.Time `json:"synced_at,omitempty" db:"synced_at"`
 }
@@ -0,0 +1,193 @@
+/*
+ * 离线缓存
+ * 网络不可用时暂存待上传的数据，连接恢复后按原顺序补传
+ */
+package sdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+// Buffer 离线数据缓存接口
+// 实现需保证先进先出，达到容量上限时丢弃最旧的数据
+type Buffer interface {
+	Push(reading *models.DataCollectRequest) error
+	Peek(n int) ([]*models.DataCollectRequest, error) // 返回最旧的n条，不移除
+	Remove(n int) error                               // 移除最旧的n条
+	Len() int
+}
+
+// MemoryBuffer 内存缓存，进程重启后丢失
+type MemoryBuffer struct {
+	mu       sync.Mutex
+	items    []*models.DataCollectRequest
+	capacity int
+	dropped  int64
+}
+
+// NewMemoryBuffer 创建内存缓存，capacity<=0 表示不限制
+func NewMemoryBuffer(capacity int) *MemoryBuffer {
+	return &MemoryBuffer{capacity: capacity}
+}
+
+// Push 追加数据，超出容量时丢弃最旧的一条
+func (b *MemoryBuffer) Push(reading *models.DataCollectRequest) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.capacity > 0 && len(b.items) >= b.capacity {
+		b.items = b.items[1:]
+		b.dropped++
+	}
+	b.items = append(b.items, reading)
+	return nil
+}
+
+// Peek 返回最旧的n条数据
+func (b *MemoryBuffer) Peek(n int) ([]*models.DataCollectRequest, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > len(b.items) {
+		n = len(b.items)
+	}
+	out := make([]*models.DataCollectRequest, n)
+	copy(out, b.items[:n])
+	return out, nil
+}
+
+// Remove 移除最旧的n条数据
+func (b *MemoryBuffer) Remove(n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if n > len(b.items) {
+		n = len(b.items)
+	}
+	b.items = b.items[n:]
+	return nil
+}
+
+// Len 缓存中的数据条数
+func (b *MemoryBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.items)
+}
+
+// Dropped 因容量上限被丢弃的数据条数
+func (b *MemoryBuffer) Dropped() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// FileBuffer 文件持久化缓存（JSON Lines），设备断电重启后仍可补传
+type FileBuffer struct {
+	mem  *MemoryBuffer
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileBuffer 创建文件缓存并加载已有数据
+func NewFileBuffer(path string, capacity int) (*FileBuffer, error) {
+	b := &FileBuffer{
+		mem:  NewMemoryBuffer(capacity),
+		path: path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read buffer file: %w", err)
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var reading models.DataCollectRequest
+		// 断电可能留下不完整的最后一行，跳过无法解析的行
+		if err := json.Unmarshal(line, &reading); err != nil {
+			continue
+		}
+		b.mem.Push(&reading)
+	}
+	return b, nil
+}
+
+// Push 追加数据并写入文件
+func (b *FileBuffer) Push(reading *models.DataCollectRequest) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	dropping := b.mem.capacity > 0 && b.mem.Len() >= b.mem.capacity
+	b.mem.Push(reading)
+	if dropping {
+		return b.rewriteLocked()
+	}
+
+	line, err := json.Marshal(reading)
+	if err != nil {
+		return fmt.Errorf("failed to encode reading: %w", err)
+	}
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open buffer file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write buffer file: %w", err)
+	}
+	return nil
+}
+
+// Peek 返回最旧的n条数据
+func (b *FileBuffer) Peek(n int) ([]*models.DataCollectRequest, error) {
+	return b.mem.Peek(n)
+}
+
+// Remove 移除最旧的n条数据并重写文件
+func (b *FileBuffer) Remove(n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mem.Remove(n)
+	return b.rewriteLocked()
+}
+
+// Len 缓存中的数据条数
+func (b *FileBuffer) Len() int {
+	return b.mem.Len()
+}
+
+// rewriteLocked 用内存中的数据重写缓存文件（先写临时文件再替换）
+func (b *FileBuffer) rewriteLocked() error {
+	items, _ := b.mem.Peek(b.mem.Len())
+
+	var buf bytes.Buffer
+	for _, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode reading: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write buffer file: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("failed to replace buffer file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+/*
+ * Edge客户端
+ * ZKP认证与会话维护：挑战-证明-验证，令牌临近过期自动刷新，会话失效时重新认证
+ */
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 客户端默认参数
+const (
+	defaultHTTPTimeout       = 30 * time.Second
+	defaultRefreshBefore     = time.Minute
+	defaultHeartbeatInterval = 30 * time.Second
+
+	// 验证队列繁忙（VERIFY_BUSY）时使用同一挑战重试的次数，
+	// 验证超时（VERIFY_TIMEOUT）时挑战已被消费，重新获取挑战的次数相同
+	verifyBusyRetries = 3
+)
+
+// Config 客户端配置
+type Config struct {
+	ServerURL   string       // Edge地址，如 http://edge:8080
+	Credentials *Credentials // 设备凭据
+	Prover      *Prover      // 证明生成器，需已加载proving key
+
+	HTTPClient        *http.Client  // 为空时使用默认客户端
+	RefreshBefore     time.Duration // 令牌到期前多久刷新（默认1分钟）
+	HeartbeatInterval time.Duration // Run中的心跳间隔（默认30秒）
+	Buffer            Buffer        // 离线缓存，为空时上传失败直接返回错误
+	Logger            *zap.Logger
+}
+
+// Session 当前会话
+type Session struct {
+	SessionID string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// APIError Edge返回的错误响应
+type APIError struct {
+	StatusCode int
+	Code       string // 响应中的error字段，如 AUTH_LOCKED、ROTATION_REQUIRED
+	Message    string
+	RetryAfter time.Duration // Retry-After响应头，未设置时为0
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("edge error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsRetryable 错误是否为暂时性的（网络错误、服务端繁忙或5xx），可稍后重试或写入离线缓存
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	// 调用方主动取消不算暂时性错误
+	return !errors.Is(err, context.Canceled)
+}
+
+// Client Edge设备客户端，可并发使用
+type Client struct {
+	baseURL string
+	creds   *Credentials
+	prover  *Prover
+	http    *http.Client
+	buffer  Buffer
+	logger  *zap.Logger
+
+	refreshBefore     time.Duration
+	heartbeatInterval time.Duration
+
+	authMu  sync.Mutex // 串行化认证和刷新，避免并发请求同时认证
+	mu      sync.RWMutex
+	session *Session
+
+	flushMu sync.Mutex
+}
+
+// NewClient 创建客户端
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.ServerURL == "" {
+		return nil, fmt.Errorf("server URL is required")
+	}
+	if cfg.Credentials == nil {
+		return nil, fmt.Errorf("credentials are required")
+	}
+	if err := cfg.Credentials.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Prover == nil {
+		return nil, fmt.Errorf("prover is required")
+	}
+
+	c := &Client{
+		baseURL:           strings.TrimRight(cfg.ServerURL, "/"),
+		creds:             cfg.Credentials,
+		prover:            cfg.Prover,
+		http:              cfg.HTTPClient,
+		buffer:            cfg.Buffer,
+		logger:            cfg.Logger,
+		refreshBefore:     cfg.RefreshBefore,
+		heartbeatInterval: cfg.HeartbeatInterval,
+	}
+	if c.http == nil {
+		c.http = &http.Client{Timeout: defaultHTTPTimeout}
+	}
+	if c.logger == nil {
+		c.logger = zap.NewNop()
+	}
+	if c.refreshBefore <= 0 {
+		c.refreshBefore = defaultRefreshBefore
+	}
+	if c.heartbeatInterval <= 0 {
+		c.heartbeatInterval = defaultHeartbeatInterval
+	}
+	return c, nil
+}
+
+// DeviceID 设备ID
+func (c *Client) DeviceID() string {
+	return c.creds.DeviceID
+}
+
+// Session 返回当前会话，未认证时返回nil
+func (c *Client) Session() *Session {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.session == nil {
+		return nil
+	}
+	s := *c.session
+	return &s
+}
+
+// Token 返回有效的访问令牌
+// 未认证时执行认证，临近过期时刷新，刷新失败则重新认证
+func (c *Client) Token(ctx context.Context) (string, error) {
+	if s := c.Session(); s != nil && time.Until(s.ExpiresAt) > c.refreshBefore {
+		return s.Token, nil
+	}
+
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	// 等待锁期间其他请求可能已完成认证
+	s := c.Session()
+	if s != nil && time.Until(s.ExpiresAt) > c.refreshBefore {
+		return s.Token, nil
+	}
+
+	if s != nil && time.Now().Before(s.ExpiresAt) {
+		refreshed, err := c.refreshLocked(ctx, s.Token)
+		if err == nil {
+			return refreshed.Token, nil
+		}
+		c.logger.Warn("Token refresh failed, re-authenticating",
+			zap.String("device_id", c.creds.DeviceID),
+			zap.Error(err))
+	}
+
+	session, err := c.authenticateLocked(ctx)
+	if err != nil {
+		return "", err
+	}
+	return session.Token, nil
+}
+
+// Authenticate 执行完整的ZKP认证并建立新会话
+func (c *Client) Authenticate(ctx context.Context) (*Session, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.authenticateLocked(ctx)
+}
+
+// Refresh 立即刷新当前会话
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	s := c.Session()
+	if s == nil {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	return c.refreshLocked(ctx, s.Token)
+}
+
+// authenticateLocked 挑战-证明-验证（调用方持有authMu）
+func (c *Client) authenticateLocked(ctx context.Context) (*Session, error) {
+	for attempt := 0; ; attempt++ {
+		session, err := c.authenticateOnce(ctx)
+		var apiErr *APIError
+		if err == nil || attempt >= verifyBusyRetries ||
+			!errors.As(err, &apiErr) || apiErr.Code != "VERIFY_TIMEOUT" {
+			return session, err
+		}
+		// 超时的挑战不能再用，稍后重新获取挑战
+		if err := sleepContext(ctx, retryDelay(apiErr)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authenticateOnce 使用一个新挑战完成认证
+func (c *Client) authenticateOnce(ctx context.Context) (*Session, error) {
+	var challenge models.ChallengeResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/auth/challenge", "",
+		models.ChallengeRequest{DeviceID: c.creds.DeviceID}, &challenge); err != nil {
+		return nil, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	// 迁移窗口内优先使用目标key生成证明，服务端验证成功后即完成迁移
+	keyID := challenge.KeyID
+	if challenge.TargetKeyID != "" && c.prover.HasKey(challenge.TargetKeyID) {
+		keyID = challenge.TargetKeyID
+	}
+	if keyID != "" && !c.prover.HasKey(keyID) {
+		keyID = c.creds.KeyID
+	}
+
+	proof, err := c.prover.Prove(keyID, c.creds, challenge.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.AuthRequest{
+		DeviceID:    c.creds.DeviceID,
+		ChallengeID: challenge.ChallengeID,
+		Proof:       proof,
+	}
+
+	var resp models.AuthResponse
+	for attempt := 0; ; attempt++ {
+		err = c.doJSON(ctx, http.MethodPost, "/api/v1/auth/verify", "", req, &resp)
+		var apiErr *APIError
+		if err == nil || attempt >= verifyBusyRetries ||
+			!errors.As(err, &apiErr) || apiErr.Code != "VERIFY_BUSY" {
+			break
+		}
+		// 挑战未被消耗，稍后用同一证明重试
+		if err := sleepContext(ctx, retryDelay(apiErr)); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify proof: %w", err)
+	}
+
+	session := &Session{SessionID: resp.SessionID, Token: resp.Token, ExpiresAt: resp.ExpiresAt}
+	c.setSession(session)
+
+	c.logger.Info("Device authenticated",
+		zap.String("device_id", c.creds.DeviceID),
+		zap.String("session_id", session.SessionID),
+		zap.String("key_id", proof.KeyID),
+		zap.Time("expires_at", session.ExpiresAt))
+	return session, nil
+}
+
+// refreshLocked 刷新会话（调用方持有authMu）
+func (c *Client) refreshLocked(ctx context.Context, token string) (*Session, error) {
+	var resp models.AuthResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/auth/refresh", token, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	session := &Session{SessionID: resp.SessionID, Token: resp.Token, ExpiresAt: resp.ExpiresAt}
+	c.setSession(session)
+
+	c.logger.Debug("Session refreshed",
+		zap.String("device_id", c.creds.DeviceID),
+		zap.Time("expires_at", session.ExpiresAt))
+	return session, nil
+}
+
+func (c *Client) setSession(s *Session) {
+	c.mu.Lock()
+	c.session = s
+	c.mu.Unlock()
+}
+
+// invalidate 丢弃被服务端拒绝的令牌（仅当它仍是当前令牌时）
+func (c *Client) invalidate(token string) {
+	c.mu.Lock()
+	if c.session != nil && c.session.Token == token {
+		c.session = nil
+	}
+	c.mu.Unlock()
+}
+
+// doAuthJSON 携带令牌发送请求；令牌被拒绝（过期或会话被撤销）时重新认证并重试一次
+func (c *Client) doAuthJSON(ctx context.Context, method, path string, body, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.Token(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = c.doJSON(ctx, method, path, token, body, out)
+		var apiErr *APIError
+		if attempt == 0 && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.logger.Info("Token rejected, re-authenticating",
+				zap.String("device_id", c.creds.DeviceID),
+				zap.String("code", apiErr.Code))
+			c.invalidate(token)
+			continue
+		}
+		return err
+	}
+}
+
+// doJSON 发送JSON请求并解析响应，非2xx响应返回 *APIError
+func (c *Client) doJSON(ctx context.Context, method, path, token string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &errBody) == nil {
+			apiErr.Code = errBody.Error
+			apiErr.Message = errBody.Message
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return apiErr
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func retryDelay(apiErr *APIError) time.Duration {
+	if apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+	return time.Second
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,410 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/edge/storage-cabinet/api"
+	"github.com/edge/storage-cabinet/internal/auth"
+	"github.com/edge/storage-cabinet/internal/config"
+	"github.com/edge/storage-cabinet/internal/device"
+	"github.com/edge/storage-cabinet/internal/storage"
+	"github.com/edge/storage-cabinet/internal/zkp"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	keysOnce sync.Once
+	keysErr  error
+	testPK   groth16.ProvingKey
+	testVK   groth16.VerifyingKey
+)
+
+// testKeys 生成测试用的proving/verifying key（所有测试共享）
+func testKeys(t *testing.T) (groth16.ProvingKey, groth16.VerifyingKey) {
+	t.Helper()
+	keysOnce.Do(func() {
+		ccs, err := compiledCircuit()
+		if err != nil {
+			keysErr = err
+			return
+		}
+		testPK, testVK, keysErr = groth16.Setup(ccs)
+	})
+	if keysErr != nil {
+		t.Fatalf("setup keys: %v", keysErr)
+	}
+	return testPK, testVK
+}
+
+// testEdge 进程内的Edge服务端（真实认证服务和数据库）
+type testEdge struct {
+	server  *httptest.Server
+	auth    *auth.Service
+	devices *device.Manager
+	offline atomic.Bool
+
+	mu        sync.Mutex
+	collected []models.DataCollectRequest
+}
+
+func newTestEdge(t *testing.T, sessionTTL time.Duration) *testEdge {
+	t.Helper()
+	_, vk := testKeys(t)
+	logger := zap.NewNop()
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+	if _, err := vk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	vkPath := filepath.Join(dir, "auth_verifying.key")
+	if err := os.WriteFile(vkPath, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	verifier := zkp.NewVerifier(logger)
+	if err := verifier.InitializeWithKeyPath(vkPath); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := storage.NewSQLiteDB(config.DatabaseConfig{
+		Driver:         "sqlite3",
+		Path:           filepath.Join(dir, "edge.db"),
+		MaxConnections: 1,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	authService := auth.NewService(config.AuthConfig{
+		ChallengeTTL: 5 * time.Minute,
+		SessionTTL:   sessionTTL,
+		MaxRetry:     3,
+	}, db, verifier, nil, logger)
+	t.Cleanup(authService.Close)
+
+	deviceManager := device.NewManager(config.DeviceConfig{
+		HeartbeatInterval: 10 * time.Second,
+		OfflineTimeout:    30 * time.Second,
+		MaxDevices:        10,
+		SupportedSensors:  []string{"co2"},
+	}, db, nil, logger, "")
+	authService.SetCredentialSync(deviceManager)
+
+	e := &testEdge{auth: authService, devices: deviceManager}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/auth/challenge", api.GetChallenge(authService))
+	v1.POST("/auth/verify", api.VerifyProof(authService))
+	v1.POST("/auth/refresh", api.RefreshSession(authService))
+	v1.POST("/devices/:id/heartbeat", api.DeviceHeartbeat(deviceManager))
+	v1.POST("/data/collect", api.AuthMiddleware(authService), func(c *gin.Context) {
+		var req models.DataCollectRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "INVALID_REQUEST", "message": err.Error()})
+			return
+		}
+		e.mu.Lock()
+		e.collected = append(e.collected, req)
+		e.mu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+
+	// 离线时直接断开连接，模拟网络不可用
+	e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.offline.Load() {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(e.server.Close)
+	return e
+}
+
+// register 生成凭据并在服务端注册设备
+func (e *testEdge) register(t *testing.T, deviceID string) *Credentials {
+	t.Helper()
+	creds, err := GenerateCredentials(deviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.devices.RegisterDevice(&models.DeviceRegistration{
+		DeviceID:   deviceID,
+		DeviceType: "sensor",
+		SensorType: models.SensorCO2,
+		PublicKey:  creds.PublicKey,
+		Commitment: creds.Commitment,
+	}); err != nil {
+		t.Fatalf("register device: %v", err)
+	}
+	return creds
+}
+
+func (e *testEdge) collectedCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.collected)
+}
+
+func newTestClient(t *testing.T, e *testEdge, creds *Credentials, cfg Config) *Client {
+	t.Helper()
+	pk, _ := testKeys(t)
+	prover := NewProver()
+	prover.SetProvingKey(zkp.LegacyKeyID, pk)
+
+	cfg.ServerURL = e.server.URL
+	cfg.Credentials = creds
+	cfg.Prover = prover
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func reading(value float64) *models.DataCollectRequest {
+	return &models.DataCollectRequest{SensorType: models.SensorCO2, Value: value, Unit: "ppm"}
+}
+
+func TestCredentials(t *testing.T) {
+	creds, err := GenerateCredentials("CO2-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "creds.json")
+	if err := creds.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCredentials(path)
+	if err != nil {
+		t.Fatalf("LoadCredentials: %v", err)
+	}
+	if *loaded != *creds {
+		t.Errorf("loaded credentials differ: %+v", loaded)
+	}
+
+	// 与服务端注册时的承诺计算一致
+	v := zkp.NewVerifier(zap.NewNop())
+	serverCommitment, err := v.ComputeCommitment(creds.Secret, creds.DeviceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serverCommitment != creds.Commitment {
+		t.Errorf("commitment mismatch with server: %s vs %s", serverCommitment, creds.Commitment)
+	}
+
+	loaded.Commitment = serverCommitment[:len(serverCommitment)-2] + "00"
+	if err := loaded.Validate(); err == nil {
+		t.Error("expected mismatched commitment to fail validation")
+	}
+	if _, err := GenerateCredentials("DEVICE-ID-LONGER-THAN-THIRTY-ONE-BYTES"); err == nil {
+		t.Error("expected overlong device ID to be rejected")
+	}
+}
+
+func TestLoadProvingKeyCached(t *testing.T) {
+	pk, _ := testKeys(t)
+	var buf bytes.Buffer
+	if _, err := pk.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "auth_proving.key")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p1, p2 := NewProver(), NewProver()
+	if err := p1.LoadProvingKey("v1", path); err != nil {
+		t.Fatalf("LoadProvingKey: %v", err)
+	}
+	// 文件删除后仍可从缓存加载
+	os.Remove(path)
+	if err := p2.LoadProvingKey("v1", path); err != nil {
+		t.Fatalf("cached LoadProvingKey: %v", err)
+	}
+	if !p2.HasKey("v1") || p2.HasKey("v2") {
+		t.Error("unexpected key set")
+	}
+}
+
+func TestAuthenticateAndUpload(t *testing.T) {
+	e := newTestEdge(t, time.Hour)
+	c := newTestClient(t, e, e.register(t, "CO2-001"), Config{})
+	ctx := context.Background()
+
+	// 首次上传时自动认证
+	if err := c.Upload(ctx, reading(420)); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	s := c.Session()
+	if s == nil || s.Token == "" {
+		t.Fatal("expected session after upload")
+	}
+	if e.collectedCount() != 1 {
+		t.Fatalf("collected %d readings, want 1", e.collectedCount())
+	}
+
+	// 再次上传复用会话
+	if err := c.Upload(ctx, reading(421)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Session().SessionID != s.SessionID {
+		t.Error("expected session to be reused")
+	}
+
+	if err := c.Heartbeat(ctx, "", nil); err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+}
+
+func TestWrongSecretRejected(t *testing.T) {
+	e := newTestEdge(t, time.Hour)
+	e.register(t, "CO2-001")
+
+	other, err := GenerateCredentials("CO2-001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, e, other, Config{})
+	_, err = c.Authenticate(context.Background())
+	if err == nil {
+		t.Fatal("expected authentication with wrong secret to fail")
+	}
+	if IsRetryable(err) {
+		t.Errorf("auth failure should not be retryable: %v", err)
+	}
+}
+
+func TestTokenRefreshBeforeExpiry(t *testing.T) {
+	e := newTestEdge(t, 3*time.Second)
+	c := newTestClient(t, e, e.register(t, "CO2-001"), Config{RefreshBefore: 2 * time.Second})
+	ctx := context.Background()
+
+	first, err := c.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	if _, err := c.Token(ctx); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	refreshed := c.Session()
+	if refreshed.SessionID != first.SessionID {
+		t.Error("expected refresh to keep the session")
+	}
+	if !refreshed.ExpiresAt.After(first.ExpiresAt) {
+		t.Errorf("expected expiry to be extended: %v -> %v", first.ExpiresAt, refreshed.ExpiresAt)
+	}
+}
+
+func TestReauthenticateAfterRevocation(t *testing.T) {
+	e := newTestEdge(t, time.Hour)
+	c := newTestClient(t, e, e.register(t, "CO2-001"), Config{})
+	ctx := context.Background()
+
+	first, err := c.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.auth.RevokeDeviceSessions("CO2-001", auth.SessionRevokeReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Upload(ctx, reading(420)); err != nil {
+		t.Fatalf("Upload after revocation: %v", err)
+	}
+	if c.Session().SessionID == first.SessionID {
+		t.Error("expected a new session after revocation")
+	}
+	if e.collectedCount() != 1 {
+		t.Errorf("collected %d readings, want 1", e.collectedCount())
+	}
+}
+
+func TestOfflineBuffering(t *testing.T) {
+	e := newTestEdge(t, time.Hour)
+	bufPath := filepath.Join(t.TempDir(), "buffer.jsonl")
+	buffer, err := NewFileBuffer(bufPath, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newTestClient(t, e, e.register(t, "CO2-001"), Config{Buffer: buffer})
+	ctx := context.Background()
+
+	if _, err := c.Authenticate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	e.offline.Store(true)
+	for i := 0; i < 3; i++ {
+		if err := c.Upload(ctx, reading(float64(400+i))); err != nil {
+			t.Fatalf("Upload while offline: %v", err)
+		}
+	}
+	if c.Pending() != 3 {
+		t.Fatalf("pending %d, want 3", c.Pending())
+	}
+	if err := c.Flush(ctx); err == nil {
+		t.Error("expected flush to fail while offline")
+	}
+
+	// 缓存持久化到文件，重启后仍可恢复
+	reloaded, err := NewFileBuffer(bufPath, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len() != 3 {
+		t.Fatalf("reloaded buffer has %d readings, want 3", reloaded.Len())
+	}
+
+	e.offline.Store(false)
+	if err := c.Upload(ctx, reading(403)); err != nil {
+		t.Fatalf("Upload after reconnect: %v", err)
+	}
+	if c.Pending() != 0 {
+		t.Errorf("pending %d after reconnect, want 0", c.Pending())
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.collected) != 4 {
+		t.Fatalf("collected %d readings, want 4", len(e.collected))
+	}
+	for i, r := range e.collected {
+		if r.Value != float64(400+i) {
+			t.Errorf("reading %d out of order: %v", i, r.Value)
+		}
+	}
+}
+
+func TestMemoryBufferCapacity(t *testing.T) {
+	b := NewMemoryBuffer(2)
+	for i := 0; i < 3; i++ {
+		b.Push(reading(float64(i)))
+	}
+	items, _ := b.Peek(10)
+	if len(items) != 2 || items[0].Value != 1 || b.Dropped() != 1 {
+		t.Errorf("unexpected buffer state: len=%d dropped=%d", len(items), b.Dropped())
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * 设备凭据
+ * 凭据文件加载/保存，以及与电路一致的MiMC承诺和响应计算
+ */
+package sdk
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/hash"
+)
+
+// Credentials 设备凭据
+// Secret 为32字节域元素的hex表示，只保存在设备本地，不会发送到服务端
+type Credentials struct {
+	DeviceID   string `json:"device_id"`
+	Secret     string `json:"secret"`
+	PublicKey  string `json:"public_key,omitempty"`
+	Commitment string `json:"commitment"`
+	KeyID      string `json:"key_id,omitempty"` // 生成承诺时使用的电路版本，为空表示设备当前版本
+}
+
+// LoadCredentials 从JSON文件加载设备凭据
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials: %w", err)
+	}
+	if err := creds.Validate(); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// Save 保存凭据到文件（仅所有者可读写）
+func (c *Credentials) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write credentials: %w", err)
+	}
+	return nil
+}
+
+// Validate 检查凭据字段完整且secret与承诺匹配
+func (c *Credentials) Validate() error {
+	if c.DeviceID == "" || c.Secret == "" || c.Commitment == "" {
+		return fmt.Errorf("credentials missing required fields")
+	}
+	if err := checkDeviceID(c.DeviceID); err != nil {
+		return err
+	}
+	commitment, err := ComputeCommitment(c.Secret, c.DeviceID)
+	if err != nil {
+		return err
+	}
+	if commitment != c.Commitment {
+		return fmt.Errorf("credentials commitment does not match secret")
+	}
+	return nil
+}
+
+// GenerateCredentials 生成新的设备secret和承诺（用于设备注册和凭证轮换）
+func GenerateCredentials(deviceID string) (*Credentials, error) {
+	if err := checkDeviceID(deviceID); err != nil {
+		return nil, err
+	}
+	secret, err := randomFieldHex()
+	if err != nil {
+		return nil, err
+	}
+	commitment, err := ComputeCommitment(secret, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return &Credentials{
+		DeviceID:   deviceID,
+		Secret:     secret,
+		PublicKey:  commitment,
+		Commitment: commitment,
+	}, nil
+}
+
+// ComputeCommitment 计算身份承诺 MiMC(secret, deviceID)
+func ComputeCommitment(secret, deviceID string) (string, error) {
+	secretBytes, err := decodeFieldHex(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	return mimcHex(secretBytes, deviceIDField(deviceID))
+}
+
+// ComputeResponse 计算挑战响应 MiMC(secret, nonce)
+func ComputeResponse(secret, nonce string) (string, error) {
+	secretBytes, err := decodeFieldHex(secret)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	nonceBytes, err := decodeFieldHex(nonce)
+	if err != nil {
+		return "", fmt.Errorf("invalid nonce: %w", err)
+	}
+	return mimcHex(secretBytes, nonceBytes)
+}
+
+// DeviceIDFieldHex 设备ID对应的32字节域元素hex（公开见证中的device_id字段）
+func DeviceIDFieldHex(deviceID string) string {
+	return hex.EncodeToString(deviceIDField(deviceID))
+}
+
+// checkDeviceID 设备ID按字节直接作为域元素，长度不能超过31字节
+func checkDeviceID(deviceID string) error {
+	if deviceID == "" || len(deviceID) >= fr.Bytes {
+		return fmt.Errorf("device ID must be 1-%d bytes", fr.Bytes-1)
+	}
+	return nil
+}
+
+func deviceIDField(deviceID string) []byte {
+	b := make([]byte, fr.Bytes)
+	new(big.Int).SetBytes([]byte(deviceID)).FillBytes(b)
+	return b
+}
+
+// decodeFieldHex 解码hex并左补齐为32字节，要求数值小于标量域
+func decodeFieldHex(s string) ([]byte, error) {
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > fr.Bytes {
+		return nil, fmt.Errorf("value longer than %d bytes", fr.Bytes)
+	}
+	b := make([]byte, fr.Bytes)
+	copy(b[fr.Bytes-len(raw):], raw)
+	if new(big.Int).SetBytes(b).Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("value is not a valid field element")
+	}
+	return b, nil
+}
+
+func mimcHex(a, b []byte) (string, error) {
+	h := hash.MIMC_BN254.New()
+	if _, err := h.Write(a); err != nil {
+		return "", fmt.Errorf("mimc hash failed: %w", err)
+	}
+	if _, err := h.Write(b); err != nil {
+		return "", fmt.Errorf("mimc hash failed: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// randomFieldHex 生成随机域元素（最高3位清零，保证小于标量域）
+func randomFieldHex() (string, error) {
+	b := make([]byte, fr.Bytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	b[0] &= 0x1f
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,26 @@
+/*
+ * 设备端SDK
+ * 网关/设备接入Edge的Go客户端：凭据加载、证明生成、会话维护、数据上传、心跳与离线缓存
+ */
+
+// Package sdk 提供设备接入Edge系统的客户端实现。
+//
+// 基本用法：
+//
+//	creds, err := sdk.LoadCredentials("device_credentials.json")
+//	prover := sdk.NewProver()
+//	err = prover.LoadProvingKey(zkp.LegacyKeyID, "auth_proving.key")
+//
+//	client, err := sdk.NewClient(sdk.Config{
+//		ServerURL:   "http://edge:8080",
+//		Credentials: creds,
+//		Prover:      prover,
+//		Buffer:      sdk.NewMemoryBuffer(1000),
+//	})
+//	err = client.Upload(ctx, &models.DataCollectRequest{...})
+//	go client.Run(ctx) // 心跳、令牌续期、离线数据补传
+//
+// 客户端在首次调用需要认证的接口时自动完成ZKP认证，令牌临近过期时自动刷新，
+// 会话被撤销或刷新失败时重新认证。网络不可用时上传的数据写入Buffer，
+// 连接恢复后由Flush或Run按原顺序补传。
+package sdk
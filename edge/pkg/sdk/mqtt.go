@@ -0,0 +1,171 @@
+/*
+ * MQTT上传
+ * 以设备ID和会话令牌作为MQTT用户名/密码连接broker，每次（重）连接时取最新令牌
+ */
+package sdk
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// MQTT默认参数
+const (
+	defaultMQTTTimeout = 10 * time.Second
+	defaultMQTTQoS     = 1
+)
+
+// MQTTConfig MQTT连接配置
+type MQTTConfig struct {
+	Broker    string      // 如 tcp://edge:1883、ssl://edge:8883
+	ClientID  string      // 为空时使用设备ID；内嵌broker要求为设备ID或以"设备ID-"开头
+	TLSConfig *tls.Config // ssl:// 地址使用
+	QoS       byte        // 默认1
+	Timeout   time.Duration
+}
+
+// MQTTPublisher 通过MQTT上传数据和心跳
+type MQTTPublisher struct {
+	client  *Client
+	mqtt    mqtt.Client
+	qos     byte
+	timeout time.Duration
+}
+
+// ConnectMQTT 连接MQTT broker
+// 连接前自动完成ZKP认证；断线重连时使用刷新后的令牌
+func (c *Client) ConnectMQTT(ctx context.Context, cfg MQTTConfig) (*MQTTPublisher, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("MQTT broker is required")
+	}
+	if _, err := c.Token(ctx); err != nil {
+		return nil, err
+	}
+
+	p := &MQTTPublisher{
+		client:  c,
+		qos:     cfg.QoS,
+		timeout: cfg.Timeout,
+	}
+	if p.qos == 0 {
+		p.qos = defaultMQTTQoS
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultMQTTTimeout
+	}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = c.creds.DeviceID
+	}
+
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(cfg.Broker)
+	opts.SetClientID(clientID)
+	opts.SetConnectTimeout(p.timeout)
+	opts.SetAutoReconnect(true)
+	if cfg.TLSConfig != nil {
+		opts.SetTLSConfig(cfg.TLSConfig)
+	}
+	opts.SetCredentialsProvider(func() (string, string) {
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancel()
+		token, err := c.Token(ctx)
+		if err != nil {
+			c.logger.Warn("Failed to obtain token for MQTT connection",
+				zap.String("device_id", c.creds.DeviceID),
+				zap.Error(err))
+		}
+		return c.creds.DeviceID, token
+	})
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		c.logger.Warn("MQTT connection lost",
+			zap.String("device_id", c.creds.DeviceID),
+			zap.Error(err))
+	})
+
+	p.mqtt = mqtt.NewClient(opts)
+	token := p.mqtt.Connect()
+	if !token.WaitTimeout(p.timeout) {
+		return nil, fmt.Errorf("MQTT connect timeout")
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("MQTT connect failed: %w", err)
+	}
+	return p, nil
+}
+
+// SensorTopic 传感器数据Topic：sensors/{device_id}/{sensor_type}
+func SensorTopic(deviceID string, sensorType models.SensorType) string {
+	return fmt.Sprintf("sensors/%s/%s", deviceID, sensorType)
+}
+
+// HeartbeatTopic 心跳Topic：devices/{device_id}/heartbeat
+func HeartbeatTopic(deviceID string) string {
+	return fmt.Sprintf("devices/%s/heartbeat", deviceID)
+}
+
+// Publish 发布一条传感器数据，发布失败时写入客户端的离线缓存（之后经HTTP补传）
+func (p *MQTTPublisher) Publish(ctx context.Context, reading *models.DataCollectRequest) error {
+	if reading.DeviceID == "" {
+		reading.DeviceID = p.client.creds.DeviceID
+	}
+	if reading.Timestamp.IsZero() {
+		reading.Timestamp = time.Now()
+	}
+
+	payload, err := json.Marshal(reading)
+	if err != nil {
+		return fmt.Errorf("failed to encode reading: %w", err)
+	}
+	err = p.publish(ctx, SensorTopic(reading.DeviceID, reading.SensorType), payload)
+	if err != nil && p.client.buffer != nil {
+		return p.client.bufferReading(reading, err)
+	}
+	return err
+}
+
+// Heartbeat 通过MQTT发送心跳
+func (p *MQTTPublisher) Heartbeat(ctx context.Context) error {
+	payload, err := json.Marshal(models.Heartbeat{
+		DeviceID:  p.client.creds.DeviceID,
+		Timestamp: time.Now(),
+		Status:    "online",
+	})
+	if err != nil {
+		return err
+	}
+	return p.publish(ctx, HeartbeatTopic(p.client.creds.DeviceID), payload)
+}
+
+// IsConnected 是否已连接broker
+func (p *MQTTPublisher) IsConnected() bool {
+	return p.mqtt.IsConnectionOpen()
+}
+
+// Close 断开连接
+func (p *MQTTPublisher) Close() {
+	p.mqtt.Disconnect(250)
+}
+
+func (p *MQTTPublisher) publish(ctx context.Context, topic string, payload []byte) error {
+	if !p.mqtt.IsConnectionOpen() {
+		return fmt.Errorf("MQTT not connected")
+	}
+	token := p.mqtt.Publish(topic, p.qos, false, payload)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.timeout):
+		return fmt.Errorf("MQTT publish timeout")
+	}
+}
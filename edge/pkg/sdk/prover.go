@@ -0,0 +1,182 @@
+/*
+ * 证明生成器
+ * 编译后的认证电路和proving key按进程缓存，同一网关下的多个设备共享
+ */
+package sdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/edge/storage-cabinet/internal/zkp/circuits"
+	"github.com/edge/storage-cabinet/pkg/models"
+)
+
+var (
+	circuitOnce sync.Once
+	circuitCS   constraint.ConstraintSystem
+	circuitErr  error
+
+	// proving key缓存，按文件绝对路径索引（proving key有数MB，避免重复反序列化）
+	pkCacheMu sync.Mutex
+	pkCache   = make(map[string]groth16.ProvingKey)
+)
+
+// compiledCircuit 返回编译后的认证电路（进程内只编译一次）
+func compiledCircuit() (constraint.ConstraintSystem, error) {
+	circuitOnce.Do(func() {
+		circuitCS, circuitErr = frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuits.AuthCircuit{})
+		if circuitErr != nil {
+			circuitErr = fmt.Errorf("failed to compile auth circuit: %w", circuitErr)
+		}
+	})
+	return circuitCS, circuitErr
+}
+
+// Prover Groth16证明生成器
+// 每个verifying key ID对应一个proving key，电路升级的迁移窗口内可同时持有新旧两个
+type Prover struct {
+	mu   sync.RWMutex
+	keys map[string]groth16.ProvingKey
+}
+
+// NewProver 创建证明生成器
+func NewProver() *Prover {
+	return &Prover{
+		keys: make(map[string]groth16.ProvingKey),
+	}
+}
+
+// LoadProvingKey 加载proving key，同一文件在进程内只读取一次
+func (p *Prover) LoadProvingKey(keyID, path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid proving key path: %w", err)
+	}
+
+	pkCacheMu.Lock()
+	pk, ok := pkCache[absPath]
+	if !ok {
+		pk, err = readProvingKey(absPath)
+		if err != nil {
+			pkCacheMu.Unlock()
+			return err
+		}
+		pkCache[absPath] = pk
+	}
+	pkCacheMu.Unlock()
+
+	p.SetProvingKey(keyID, pk)
+	return nil
+}
+
+func readProvingKey(path string) (groth16.ProvingKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proving key: %w", err)
+	}
+	defer f.Close()
+
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if _, err := pk.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("failed to read proving key: %w", err)
+	}
+	return pk, nil
+}
+
+// SetProvingKey 直接设置proving key（密钥已在内存中时使用）
+func (p *Prover) SetProvingKey(keyID string, pk groth16.ProvingKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[keyID] = pk
+}
+
+// HasKey 是否持有指定key ID的proving key
+func (p *Prover) HasKey(keyID string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	_, ok := p.keys[keyID]
+	return ok
+}
+
+// provingKey 获取proving key；keyID为空且只持有一个key时使用该key
+func (p *Prover) provingKey(keyID string) (groth16.ProvingKey, string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if keyID == "" && len(p.keys) == 1 {
+		for id, pk := range p.keys {
+			return pk, id, nil
+		}
+	}
+	pk, ok := p.keys[keyID]
+	if !ok {
+		return nil, "", fmt.Errorf("proving key not loaded: %q", keyID)
+	}
+	return pk, keyID, nil
+}
+
+// Prove 针对挑战nonce生成认证证明
+func (p *Prover) Prove(keyID string, creds *Credentials, nonce string) (*models.ZKProof, error) {
+	pk, keyID, err := p.provingKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	ccs, err := compiledCircuit()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ComputeResponse(creds.Secret, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment := &circuits.AuthCircuit{
+		Secret:     hexToBig(creds.Secret),
+		DeviceID:   new(big.Int).SetBytes([]byte(creds.DeviceID)),
+		Challenge:  hexToBig(nonce),
+		Commitment: hexToBig(creds.Commitment),
+		Response:   hexToBig(response),
+	}
+	w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("failed to build witness: %w", err)
+	}
+
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate proof: %w", err)
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode proof: %w", err)
+	}
+
+	return &models.ZKProof{
+		Proof: base64.StdEncoding.EncodeToString(buf.Bytes()),
+		PublicWitness: models.PublicWitness{
+			DeviceID:   DeviceIDFieldHex(creds.DeviceID),
+			Challenge:  nonce,
+			Commitment: creds.Commitment,
+			Response:   response,
+		},
+		KeyID: keyID,
+	}, nil
+}
+
+func hexToBig(s string) *big.Int {
+	b, _ := hex.DecodeString(s)
+	return new(big.Int).SetBytes(b)
+}
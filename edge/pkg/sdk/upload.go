@@ -0,0 +1,185 @@
+/*
+ * 数据上传与心跳
+ * 上传失败且为暂时性错误时写入离线缓存，Run 定期心跳、续期令牌并补传缓存数据
+ */
+package sdk
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/edge/storage-cabinet/pkg/models"
+	"go.uber.org/zap"
+)
+
+// 单次补传读取的缓存条数
+const flushBatchSize = 32
+
+// Upload 上传一条传感器数据
+// 网络不可用或服务端繁忙时数据写入离线缓存并返回nil；未配置缓存时返回错误。
+// 缓存中仍有数据时先补传，保证服务端按采集顺序收到数据。
+func (c *Client) Upload(ctx context.Context, reading *models.DataCollectRequest) error {
+	if reading.DeviceID == "" {
+		reading.DeviceID = c.creds.DeviceID
+	}
+	// 缓存补传时保留原始采集时间
+	if reading.Timestamp.IsZero() {
+		reading.Timestamp = time.Now()
+	}
+
+	if c.buffer != nil && c.buffer.Len() > 0 {
+		if err := c.Flush(ctx); err != nil {
+			return c.bufferReading(reading, err)
+		}
+	}
+
+	err := c.send(ctx, reading)
+	if err != nil && c.buffer != nil && IsRetryable(err) {
+		return c.bufferReading(reading, err)
+	}
+	return err
+}
+
+// Flush 按顺序补传离线缓存中的数据
+// 遇到暂时性错误时停止并返回该错误；服务端明确拒绝的数据（如校验失败）直接丢弃
+func (c *Client) Flush(ctx context.Context) error {
+	if c.buffer == nil {
+		return nil
+	}
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	for {
+		items, err := c.buffer.Peek(flushBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		for i, item := range items {
+			err := c.send(ctx, item)
+			if err == nil {
+				continue
+			}
+			if IsRetryable(err) {
+				if rmErr := c.buffer.Remove(i); rmErr != nil {
+					return rmErr
+				}
+				return err
+			}
+			c.logger.Warn("Dropping buffered reading rejected by edge",
+				zap.String("device_id", item.DeviceID),
+				zap.String("sensor_type", string(item.SensorType)),
+				zap.Error(err))
+		}
+		if err := c.buffer.Remove(len(items)); err != nil {
+			return err
+		}
+	}
+}
+
+// Pending 离线缓存中待补传的数据条数
+func (c *Client) Pending() int {
+	if c.buffer == nil {
+		return 0
+	}
+	return c.buffer.Len()
+}
+
+func (c *Client) send(ctx context.Context, reading *models.DataCollectRequest) error {
+	return c.doAuthJSON(ctx, http.MethodPost, "/api/v1/data/collect", reading, nil)
+}
+
+func (c *Client) bufferReading(reading *models.DataCollectRequest, cause error) error {
+	if err := c.buffer.Push(reading); err != nil {
+		return err
+	}
+	c.logger.Debug("Reading buffered for later upload",
+		zap.String("device_id", reading.DeviceID),
+		zap.Int("pending", c.buffer.Len()),
+		zap.Error(cause))
+	return nil
+}
+
+// Heartbeat 发送一次心跳
+func (c *Client) Heartbeat(ctx context.Context, status string, metadata map[string]interface{}) error {
+	if status == "" {
+		status = "online"
+	}
+	if c.buffer != nil {
+		md := make(map[string]interface{}, len(metadata)+1)
+		for k, v := range metadata {
+			md[k] = v
+		}
+		md["buffered"] = c.buffer.Len()
+		metadata = md
+	}
+
+	hb := models.Heartbeat{
+		DeviceID:  c.creds.DeviceID,
+		Timestamp: time.Now(),
+		Status:    status,
+		Metadata:  metadata,
+	}
+	// 心跳接口不要求认证，已有会话时仍附带令牌
+	token := ""
+	if s := c.Session(); s != nil {
+		token = s.Token
+	}
+	return c.doJSON(ctx, http.MethodPost, "/api/v1/devices/"+c.creds.DeviceID+"/heartbeat", token, hb, nil)
+}
+
+// Run 后台维护连接直到ctx取消：定期心跳，令牌到期前续期，连接恢复后补传离线缓存
+func (c *Client) Run(ctx context.Context) error {
+	heartbeat := time.NewTicker(c.heartbeatInterval)
+	defer heartbeat.Stop()
+	refresh := time.NewTimer(c.untilRefresh())
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-heartbeat.C:
+			if err := c.Heartbeat(ctx, "", nil); err != nil {
+				c.logger.Warn("Heartbeat failed",
+					zap.String("device_id", c.creds.DeviceID),
+					zap.Error(err))
+				continue
+			}
+			if c.Pending() > 0 {
+				if err := c.Flush(ctx); err != nil {
+					c.logger.Warn("Flushing buffered readings failed",
+						zap.String("device_id", c.creds.DeviceID),
+						zap.Int("pending", c.Pending()),
+						zap.Error(err))
+				}
+			}
+
+		case <-refresh.C:
+			if _, err := c.Token(ctx); err != nil {
+				c.logger.Warn("Keeping session alive failed",
+					zap.String("device_id", c.creds.DeviceID),
+					zap.Error(err))
+			}
+			refresh.Reset(c.untilRefresh())
+		}
+	}
+}
+
+// untilRefresh 距离下次令牌续期的时间；未认证时按心跳间隔重试
+func (c *Client) untilRefresh() time.Duration {
+	s := c.Session()
+	if s == nil {
+		return c.heartbeatInterval
+	}
+	d := time.Until(s.ExpiresAt) - c.refreshBefore
+	if d < time.Second {
+		d = time.Second
+	}
+	return d
+}